@@ -0,0 +1,315 @@
+// Command swarmctl chats with a swarm defined in a declarative spec (see
+// swarm.LoadConfig), so prompts can be iterated on without writing a Go
+// harness.
+//
+// Each turn shows the agents that ran, their tool calls and handoffs, and
+// the answer of the active agent. The agents' models are OpenAI models,
+// configured with the OPENAI_API_KEY environment variable: agents without a
+// model use -model, and other model names in the spec are OpenAI model
+// names. swarmctl registers no tools besides the handoff tools, so specs
+// referring to other tools are rejected.
+//
+// Usage:
+//
+//	swarmctl -config swarm.yaml                     # chat in a new conversation
+//	swarmctl -config swarm.yaml -thread support-1   # save and resume a conversation
+//	swarmctl -config swarm.yaml -replay support-1   # re-send a saved conversation's user messages
+//	swarmctl -config swarm.yaml -dump-graph mermaid # print the graph ("mermaid" or "dot")
+//
+// In the chat, /exit quits and /state prints the active agent and the
+// handoffs so far. Tool calls that need approval (see interrupt_before) are
+// confirmed at the prompt.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+	"gopkg.in/yaml.v3"
+)
+
+// newModel creates the model registered under name. Tests replace it.
+var newModel = func(name string) (llms.Model, error) {
+	return openai.New(openai.WithModel(name))
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "swarmctl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes swarmctl with the command line arguments args.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("swarmctl", flag.ContinueOnError)
+	configPath := flags.String("config", "swarm.yaml", "swarm spec to load (YAML or JSON)")
+	modelName := flags.String("model", "gpt-4o", "OpenAI model of the agents without a model")
+	dumpGraph := flags.String("dump-graph", "", `print the swarm's graph as "mermaid" or "dot" and exit`)
+	thread := flags.String("thread", "", "save the conversation to this thread, resuming it if it exists")
+	replay := flags.String("replay", "", "re-send the user messages of this saved thread and exit")
+	dir := flags.String("dir", ".swarmctl", "directory of the saved threads")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *replay != "" && *replay == *thread {
+		return fmt.Errorf("cannot replay thread '%s' into itself", *replay)
+	}
+
+	config, err := loadConfig(*configPath, *modelName)
+	if err != nil {
+		return err
+	}
+
+	var store swarm.CheckpointStore = swarm.NewMemorySaver()
+	if *thread != "" || *replay != "" {
+		if store, err = swarm.NewFileSaver(*dir); err != nil {
+			return err
+		}
+	}
+	config.Checkpointer = store
+
+	s, err := swarm.CreateSwarm(config)
+	if err != nil {
+		return err
+	}
+	switch *dumpGraph {
+	case "":
+	case "mermaid":
+		_, err := fmt.Fprint(stdout, s.ExportMermaid())
+		return err
+	case "dot":
+		_, err := fmt.Fprint(stdout, s.ExportDOT())
+		return err
+	default:
+		return fmt.Errorf("unknown graph format '%s', want \"mermaid\" or \"dot\"", *dumpGraph)
+	}
+
+	app, err := s.Compile()
+	if err != nil {
+		return err
+	}
+	c := &chat{app: app, threadID: *thread, in: bufio.NewScanner(stdin), out: stdout}
+	if c.threadID == "" {
+		c.threadID = "swarmctl"
+	} else if err := c.load(ctx); err != nil {
+		return err
+	}
+
+	if *replay != "" {
+		return c.replay(ctx, store, *replay)
+	}
+	return c.repl(ctx)
+}
+
+// loadConfig reads the swarm spec at path and builds it with an OpenAI
+// model for each model name it refers to; "default" is defaultModel.
+func loadConfig(path, defaultModel string) (swarm.SwarmConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return swarm.SwarmConfig{}, err
+	}
+	defer file.Close()
+
+	var spec swarm.SwarmSpec
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return swarm.SwarmConfig{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	registry := swarm.Registry{Models: make(map[string]llms.Model)}
+	for _, agent := range spec.Agents {
+		name := agent.Model
+		if name == "" {
+			name = "default"
+		}
+		if _, ok := registry.Models[name]; ok {
+			continue
+		}
+		modelName := name
+		if name == "default" {
+			modelName = defaultModel
+		}
+		model, err := newModel(modelName)
+		if err != nil {
+			return swarm.SwarmConfig{}, fmt.Errorf("create model '%s': %w", modelName, err)
+		}
+		registry.Models[name] = model
+	}
+	return spec.Build(registry)
+}
+
+// chat is a conversation with a compiled swarm, saved to a thread.
+type chat struct {
+	app      *swarm.CompiledSwarm
+	threadID string
+	state    swarm.SwarmState
+	in       *bufio.Scanner
+	out      io.Writer
+	// agent is the last agent that started running
+	agent string
+}
+
+// load resumes the conversation saved to the chat's thread, if any.
+func (c *chat) load(ctx context.Context) error {
+	checkpoint, err := c.app.Checkpointer().Latest(ctx, c.threadID)
+	if errors.Is(err, swarm.ErrCheckpointNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.state = checkpoint.State
+	fmt.Fprintf(c.out, "Resuming thread %s: %d messages, active agent %s\n",
+		c.threadID, len(c.state.Messages), c.activeAgent())
+	return nil
+}
+
+// repl reads user messages until the input ends or the user types /exit.
+func (c *chat) repl(ctx context.Context) error {
+	for {
+		fmt.Fprintf(c.out, "%s> ", c.activeAgent())
+		if !c.in.Scan() {
+			fmt.Fprintln(c.out)
+			return c.in.Err()
+		}
+		line := strings.TrimSpace(c.in.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		case "/state":
+			c.printState()
+			continue
+		}
+		if err := c.send(ctx, line); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintln(c.out, "error:", err)
+		}
+	}
+}
+
+// replay sends the user messages of the saved thread threadID, in order.
+func (c *chat) replay(ctx context.Context, store swarm.CheckpointStore, threadID string) error {
+	checkpoint, err := store.Latest(ctx, threadID)
+	if err != nil {
+		return fmt.Errorf("replay thread '%s': %w", threadID, err)
+	}
+	for _, message := range checkpoint.State.Messages {
+		text := messageText(message)
+		if message.Role != llms.ChatMessageTypeHuman || text == "" {
+			continue
+		}
+		fmt.Fprintf(c.out, "%s> %s\n", c.activeAgent(), text)
+		if err := c.send(ctx, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send runs a turn of the conversation and prints what happened.
+func (c *chat) send(ctx context.Context, text string) error {
+	ctx = swarm.WithStreamHandler(ctx, swarm.StreamHandlerFuncs{
+		AgentStart: func(ctx context.Context, agent string) {
+			c.agent = agent
+		},
+		ToolCall: func(ctx context.Context, agent string, call llms.ToolCall) {
+			if call.FunctionCall != nil {
+				fmt.Fprintf(c.out, "  [%s] tool %s %s\n", agent, call.FunctionCall.Name, call.FunctionCall.Arguments)
+			}
+		},
+		Handoff: func(ctx context.Context, from, to string) {
+			fmt.Fprintf(c.out, "  [%s] handoff to %s\n", from, to)
+		},
+	})
+
+	state := c.state
+	state.Messages = append(slices.Clip(state.Messages), llms.TextParts(llms.ChatMessageTypeHuman, text))
+	result, err := c.app.Invoke(ctx, state, swarm.WithThreadID(c.threadID))
+	for {
+		var interrupt *swarm.InterruptError
+		if !errors.As(err, &interrupt) {
+			break
+		}
+		result, err = c.app.Resume(ctx, c.threadID, c.approve(interrupt))
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := len(result.Messages) - 1; i >= len(state.Messages); i-- {
+		if answer := messageText(result.Messages[i]); result.Messages[i].Role == llms.ChatMessageTypeAI && answer != "" {
+			fmt.Fprintf(c.out, "%s: %s\n", c.agent, answer)
+			break
+		}
+	}
+	c.state = result
+	return nil
+}
+
+// approve asks the user to approve the tool calls of an interrupted run.
+func (c *chat) approve(interrupt *swarm.InterruptError) swarm.Approval {
+	for _, call := range interrupt.ToolCalls {
+		if call.FunctionCall != nil {
+			fmt.Fprintf(c.out, "  [%s] wants to call %s %s\n", interrupt.Agent, call.FunctionCall.Name, call.FunctionCall.Arguments)
+		}
+	}
+	fmt.Fprint(c.out, "Approve? [y/N] ")
+	if !c.in.Scan() {
+		fmt.Fprintln(c.out)
+		return swarm.Approval{Feedback: "The user did not answer."}
+	}
+	answer := strings.ToLower(strings.TrimSpace(c.in.Text()))
+	return swarm.Approval{Approved: answer == "y" || answer == "yes"}
+}
+
+// printState prints the active agent and the handoffs of the conversation.
+func (c *chat) printState() {
+	fmt.Fprintf(c.out, "thread %s: %d messages, active agent %s\n", c.threadID, len(c.state.Messages), c.activeAgent())
+	for _, handoff := range c.state.Handoffs {
+		fmt.Fprintf(c.out, "  %s -> %s", handoff.From, handoff.To)
+		if handoff.Reason != "" {
+			fmt.Fprintf(c.out, " (%s)", handoff.Reason)
+		}
+		fmt.Fprintln(c.out)
+	}
+}
+
+// activeAgent returns the agent answering the next message.
+func (c *chat) activeAgent() string {
+	if c.state.ActiveAgent != "" {
+		return c.state.ActiveAgent
+	}
+	return c.app.Swarm().DefaultActiveAgent()
+}
+
+// messageText returns the text parts of message, joined.
+func messageText(message llms.MessageContent) string {
+	var texts []string
+	for _, part := range message.Parts {
+		if text, ok := part.(llms.TextContent); ok && text.Text != "" {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const testSpec = `
+default_agent: Alice
+agents:
+  - name: Alice
+    system_prompt: You are Alice.
+    destinations: [Bob]
+  - name: Bob
+    model: pirate
+    system_prompt: You are Bob, you speak like a pirate.
+    destinations: [Alice]
+`
+
+// setup writes testSpec to a temporary directory and makes every model
+// answer from responses. It returns the spec's path.
+func setup(t *testing.T, responses ...*llms.ContentChoice) string {
+	t.Helper()
+	model := swarmtest.NewMockModel(responses...)
+	previous := newModel
+	newModel = func(name string) (llms.Model, error) {
+		if name != "gpt-4o" && name != "pirate" {
+			t.Errorf("created model %q, want gpt-4o or pirate", name)
+		}
+		return model, nil
+	}
+	t.Cleanup(func() { newModel = previous })
+
+	path := filepath.Join(t.TempDir(), "swarm.yaml")
+	if err := os.WriteFile(path, []byte(testSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestChat(t *testing.T) {
+	path := setup(t, swarmtest.Handoff("Bob"), swarmtest.Text("Arr, hello!"), swarmtest.Text("Yo ho!"))
+
+	var out strings.Builder
+	input := strings.NewReader("Talk like a pirate\nSing\n/state\n/exit\n")
+	if err := run(context.Background(), []string{"-config", path}, input, &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"Alice> ",
+		"  [Alice] tool transfer_to_bob {}\n",
+		"  [Alice] handoff to Bob\n",
+		"Bob: Arr, hello!\n",
+		"Bob> Bob: Yo ho!\n",
+		"thread swarmctl: 6 messages, active agent Bob\n  Alice -> Bob\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestThreadReplay(t *testing.T) {
+	path := setup(t, swarmtest.Handoff("Bob"), swarmtest.Text("Arr!"),
+		swarmtest.Handoff("Bob"), swarmtest.Text("Ahoy!"))
+	dir := t.TempDir()
+
+	var out strings.Builder
+	args := []string{"-config", path, "-dir", dir, "-thread", "first"}
+	if err := run(context.Background(), args, strings.NewReader("Talk like a pirate\n"), &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out.Reset()
+	args = []string{"-config", path, "-dir", dir, "-thread", "second", "-replay", "first"}
+	if err := run(context.Background(), args, strings.NewReader(""), &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	want := "Alice> Talk like a pirate\n  [Alice] tool transfer_to_bob {}\n  [Alice] handoff to Bob\nBob: Ahoy!\n"
+	if out.String() != want {
+		t.Errorf("replay output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	args = []string{"-config", path, "-dir", dir, "-thread", "second"}
+	if err := run(context.Background(), args, strings.NewReader("/exit\n"), &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Resuming thread second: 4 messages, active agent Bob\n") {
+		t.Errorf("output = %q, want the saved thread to be resumed", out.String())
+	}
+}
+
+func TestDumpGraph(t *testing.T) {
+	path := setup(t)
+
+	var out strings.Builder
+	if err := run(context.Background(), []string{"-config", path, "-dump-graph", "mermaid"}, nil, &out); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Alice") || !strings.Contains(out.String(), "Bob") {
+		t.Errorf("graph = %q", out.String())
+	}
+
+	if err := run(context.Background(), []string{"-config", path, "-dump-graph", "png"}, nil, &out); err == nil {
+		t.Error("run() with an unknown graph format succeeded")
+	}
+}
@@ -101,4 +101,4 @@
 // - GitHub: https://github.com/yourusername/langgraphgo_swarm
 // - LangGraphGo: https://github.com/smallnest/langgraphgo
 // - Documentation: https://lango.rpcx.io
-package main
+package langgraphgo_swarm
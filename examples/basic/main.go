@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/go-hare/langchaingo_swarm/swarm"
 	"github.com/smallnest/langgraphgo/graph"
@@ -16,6 +17,12 @@ func addTool(a, b int) int {
 	return a + b
 }
 
+// addArgs are the arguments of the add tool
+type addArgs struct {
+	A int `json:"a" jsonschema:"description=First number"`
+	B int `json:"b" jsonschema:"description=Second number"`
+}
+
 func main() {
 	ctx := context.Background()
 
@@ -25,6 +32,11 @@ func main() {
 		log.Fatalf("Failed to create model: %v", err)
 	}
 
+	// Expose addTool to the model; the schema is derived from addArgs
+	add := swarm.NewStructTool("add", "Add two numbers", func(ctx context.Context, args addArgs) (string, error) {
+		return strconv.Itoa(addTool(args.A, args.B)), nil
+	})
+
 	// Create handoff tools
 	transferToBob := swarm.CreateHandoffTool(swarm.HandoffToolConfig{
 		AgentName:   "Bob",
@@ -39,37 +51,11 @@ func main() {
 	// Create Alice agent - addition expert
 	aliceGraph := graph.NewStateGraph[swarm.SwarmState]()
 	aliceGraph.AddNode("call_model", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
-		systemPrompt := llms.TextParts("system", "You are Alice, an addition expert.")
-		messages := append([]llms.MessageContent{systemPrompt}, state.Messages...)
-
-		response, err := model.GenerateContent(ctx, messages,
+		// The swarm prepends the agent's SystemPrompt to state.Messages
+		response, err := model.GenerateContent(ctx, state.Messages,
 			llms.WithTools([]llms.Tool{
-				{
-					Type: "function",
-					Function: &llms.FunctionDefinition{
-						Name:        "add",
-						Description: "Add two numbers",
-						Parameters: map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"a": map[string]interface{}{"type": "integer"},
-								"b": map[string]interface{}{"type": "integer"},
-							},
-							"required": []string{"a", "b"},
-						},
-					},
-				},
-				{
-					Type: "function",
-					Function: &llms.FunctionDefinition{
-						Name:        transferToBob.Name(),
-						Description: transferToBob.Description(),
-						Parameters: map[string]interface{}{
-							"type":       "object",
-							"properties": map[string]interface{}{},
-						},
-					},
-				},
+				swarm.ToolDefinition(add),
+				swarm.ToolDefinition(transferToBob),
 			}),
 		)
 		if err != nil {
@@ -89,22 +75,10 @@ func main() {
 	// Create Bob agent - pirate speaker
 	bobGraph := graph.NewStateGraph[swarm.SwarmState]()
 	bobGraph.AddNode("call_model", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
-		systemPrompt := llms.TextParts("system", "You are Bob, you speak like a pirate.")
-		messages := append([]llms.MessageContent{systemPrompt}, state.Messages...)
-
-		response, err := model.GenerateContent(ctx, messages,
+		// The swarm prepends the agent's SystemPrompt to state.Messages
+		response, err := model.GenerateContent(ctx, state.Messages,
 			llms.WithTools([]llms.Tool{
-				{
-					Type: "function",
-					Function: &llms.FunctionDefinition{
-						Name:        transferToAlice.Name(),
-						Description: transferToAlice.Description(),
-						Parameters: map[string]interface{}{
-							"type":       "object",
-							"properties": map[string]interface{}{},
-						},
-					},
-				},
+				swarm.ToolDefinition(transferToAlice),
 			}),
 		)
 		if err != nil {
@@ -124,8 +98,18 @@ func main() {
 	// Create swarm with both agents
 	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
 		Agents: []swarm.Agent{
-			{Name: "Alice", Runnable: alice, Destinations: []string{"Bob"}},
-			{Name: "Bob", Runnable: bob, Destinations: []string{"Alice"}},
+			{
+				Name:         "Alice",
+				Runnable:     alice,
+				Destinations: []string{"Bob"},
+				SystemPrompt: "You are Alice, an addition expert.",
+			},
+			{
+				Name:         "Bob",
+				Runnable:     bob,
+				Destinations: []string{"Alice"},
+				SystemPrompt: "You are Bob, you speak like a pirate.",
+			},
 		},
 		DefaultActiveAgent: "Alice",
 	})
@@ -134,53 +118,29 @@ func main() {
 	}
 
 	// Compile the swarm
-	var app any
-	if compiler, ok := workflow.(interface{ Compile() (any, error) }); ok {
-		var err error
-		app, err = compiler.Compile()
-		if err != nil {
-			log.Fatalf("Failed to compile swarm: %v", err)
-		}
-	} else {
-		log.Fatal("Workflow does not support Compile()")
+	app, err := workflow.Compile()
+	if err != nil {
+		log.Fatalf("Failed to compile swarm: %v", err)
 	}
 
+	// The chat session carries the state from one turn to the next
+	session := swarm.NewChatSession(app)
+
 	// Turn 1: Ask to speak to Bob
 	fmt.Println("=== Turn 1: Speaking to Bob ===")
-	state1 := swarm.SwarmState{
-		Messages: []llms.MessageContent{
-			llms.TextParts("user", "i'd like to speak to Bob"),
-		},
-	}
-	var result1 any
-	if invoker, ok := app.(interface {
-		Invoke(context.Context, swarm.SwarmState) (any, error)
-	}); ok {
-		result1, err = invoker.Invoke(ctx, state1)
-	}
+	reply, err := session.Send(ctx, "i'd like to speak to Bob")
 	if err != nil {
 		log.Fatalf("Turn 1 failed: %v", err)
 	}
-	if resultState, ok := result1.(swarm.SwarmState); ok {
-		fmt.Printf("Active Agent: %s\n", resultState.ActiveAgent)
-		fmt.Printf("Last Message: %s\n\n", resultState.Messages[len(resultState.Messages)-1])
-	}
+	fmt.Printf("Active Agent: %s\n", reply.ActiveAgent)
+	fmt.Printf("Reply: %s\n\n", reply.Text)
 
 	// Turn 2: Ask Bob to do math (should transfer to Alice)
 	fmt.Println("=== Turn 2: Asking for math ===")
-	state2 := result1.(swarm.SwarmState)
-	state2.Messages = append(state2.Messages, llms.TextParts("user", "what's 5 + 7?"))
-	var result2 any
-	if invoker, ok := app.(interface {
-		Invoke(context.Context, swarm.SwarmState) (any, error)
-	}); ok {
-		result2, err = invoker.Invoke(ctx, state2)
-	}
+	reply, err = session.Send(ctx, "what's 5 + 7?")
 	if err != nil {
 		log.Fatalf("Turn 2 failed: %v", err)
 	}
-	if resultState, ok := result2.(swarm.SwarmState); ok {
-		fmt.Printf("Active Agent: %s\n", resultState.ActiveAgent)
-		fmt.Printf("Last Message: %s\n", resultState.Messages[len(resultState.Messages)-1])
-	}
+	fmt.Printf("Active Agent: %s\n", reply.ActiveAgent)
+	fmt.Printf("Reply: %s\n", reply.Text)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/go-hare/langchaingo_swarm/swarm"
@@ -33,10 +34,12 @@ type Reservation struct {
 	HotelInfo  Hotel
 }
 
-// Global mock data
+// Global mock data. Conversations may run concurrently, so reservations are
+// guarded by reservationsMu.
 var (
-	reservations = make(map[string]*Reservation)
-	flights      = []Flight{
+	reservationsMu sync.Mutex
+	reservations   = make(map[string]*Reservation)
+	flights        = []Flight{
 		{
 			DepartureAirport: "BOS",
 			ArrivalAirport:   "JFK",
@@ -64,10 +67,7 @@ func searchFlights(departureAirport, arrivalAirport, date string) []Flight {
 func bookFlight(flightID, userID string) string {
 	for _, flight := range flights {
 		if flight.ID == flightID {
-			if reservations[userID] == nil {
-				reservations[userID] = &Reservation{}
-			}
-			reservations[userID].FlightInfo = flight
+			updateReservation(userID, func(r *Reservation) { r.FlightInfo = flight })
 			return "Successfully booked flight"
 		}
 	}
@@ -83,36 +83,63 @@ func searchHotels(location string) []Hotel {
 func bookHotel(hotelID, userID string) string {
 	for _, hotel := range hotels {
 		if hotel.ID == hotelID {
-			if reservations[userID] == nil {
-				reservations[userID] = &Reservation{}
-			}
-			reservations[userID].HotelInfo = hotel
+			updateReservation(userID, func(r *Reservation) { r.HotelInfo = hotel })
 			return "Successfully booked hotel"
 		}
 	}
 	return "Hotel not found"
 }
 
-// Create agent with tools and system prompt
-func createFlightAgent(ctx context.Context, model llms.Model, transferTool swarm.HandoffToolConfig) (any, error) {
-	g := graph.NewStateGraph[swarm.SwarmState]()
+// updateReservation applies update to the user's reservation, creating it
+// if needed.
+func updateReservation(userID string, update func(r *Reservation)) {
+	reservationsMu.Lock()
+	defer reservationsMu.Unlock()
+	if reservations[userID] == nil {
+		reservations[userID] = &Reservation{}
+	}
+	update(reservations[userID])
+}
 
-	g.AddNode("process", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
-		// Get user ID from context (in real app, would be from config)
-		userID := "user1"
+// reservation returns a copy of the user's reservation, or nil.
+func reservation(userID string) *Reservation {
+	reservationsMu.Lock()
+	defer reservationsMu.Unlock()
+	if reservations[userID] == nil {
+		return nil
+	}
+	r := *reservations[userID]
+	return &r
+}
 
-		// Build system prompt with reservation info
-		reservation := reservations[userID]
-		systemPrompt := fmt.Sprintf(
-			"You are a flight booking assistant.\n\nUser's active reservation: %+v\nToday is: %s",
-			reservation,
-			time.Now().Format("2006-01-02"),
-		)
+// supportContext is the run context of each conversation
+type supportContext struct {
+	UserID string
+}
 
-		messages := append([]llms.MessageContent{
-			llms.TextParts("system", systemPrompt),
-		}, state.Messages...)
+// reservationData adds the user's active reservation to the prompt templates
+func reservationData(ctx context.Context, state swarm.SwarmState) (map[string]any, error) {
+	sc, _ := swarm.ContextAs[supportContext](ctx)
+	return map[string]any{"Reservation": reservation(sc.UserID)}, nil
+}
+
+// System prompts, rendered with the current reservation on every run
+var (
+	flightPrompt = swarm.MustPromptTemplate(
+		"You are a flight booking assistant.\n\nUser's active reservation: {{.Reservation}}\nToday is: {{.Today}}",
+		swarm.WithPromptData(reservationData),
+	)
+	hotelPrompt = swarm.MustPromptTemplate(
+		"You are a hotel booking assistant.\n\nUser's active reservation: {{.Reservation}}\nToday is: {{.Today}}",
+		swarm.WithPromptData(reservationData),
+	)
+)
+
+// Create agent with tools
+func createFlightAgent(ctx context.Context, model llms.Model, transferTool swarm.HandoffToolConfig) (swarm.AgentRunnable, error) {
+	g := graph.NewStateGraph[swarm.SwarmState]()
 
+	g.AddNode("process", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
 		// Define available tools
 		tools := []llms.Tool{
 			{
@@ -149,19 +176,9 @@ func createFlightAgent(ctx context.Context, model llms.Model, transferTool swarm
 
 		// Add handoff tool
 		handoffTool := swarm.CreateHandoffTool(transferTool)
-		tools = append(tools, llms.Tool{
-			Type: "function",
-			Function: &llms.FunctionDefinition{
-				Name:        handoffTool.Name(),
-				Description: handoffTool.Description(),
-				Parameters: map[string]interface{}{
-					"type":       "object",
-					"properties": map[string]interface{}{},
-				},
-			},
-		})
+		tools = append(tools, swarm.ToolDefinition(handoffTool))
 
-		response, err := model.GenerateContent(ctx, messages, llms.WithTools(tools))
+		response, err := model.GenerateContent(ctx, state.Messages, llms.WithTools(tools))
 		if err != nil {
 			return state, err
 		}
@@ -179,23 +196,10 @@ func createFlightAgent(ctx context.Context, model llms.Model, transferTool swarm
 	return g.Compile()
 }
 
-func createHotelAgent(ctx context.Context, model llms.Model, transferTool swarm.HandoffToolConfig) (any, error) {
+func createHotelAgent(ctx context.Context, model llms.Model, transferTool swarm.HandoffToolConfig) (swarm.AgentRunnable, error) {
 	g := graph.NewStateGraph[swarm.SwarmState]()
 
 	g.AddNode("process", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
-		userID := "user1"
-
-		reservation := reservations[userID]
-		systemPrompt := fmt.Sprintf(
-			"You are a hotel booking assistant.\n\nUser's active reservation: %+v\nToday is: %s",
-			reservation,
-			time.Now().Format("2006-01-02"),
-		)
-
-		messages := append([]llms.MessageContent{
-			llms.TextParts("system", systemPrompt),
-		}, state.Messages...)
-
 		tools := []llms.Tool{
 			{
 				Type: "function",
@@ -228,19 +232,9 @@ func createHotelAgent(ctx context.Context, model llms.Model, transferTool swarm.
 		}
 
 		handoffTool := swarm.CreateHandoffTool(transferTool)
-		tools = append(tools, llms.Tool{
-			Type: "function",
-			Function: &llms.FunctionDefinition{
-				Name:        handoffTool.Name(),
-				Description: handoffTool.Description(),
-				Parameters: map[string]interface{}{
-					"type":       "object",
-					"properties": map[string]interface{}{},
-				},
-			},
-		})
+		tools = append(tools, swarm.ToolDefinition(handoffTool))
 
-		response, err := model.GenerateContent(ctx, messages, llms.WithTools(tools))
+		response, err := model.GenerateContent(ctx, state.Messages, llms.WithTools(tools))
 		if err != nil {
 			return state, err
 		}
@@ -291,28 +285,26 @@ func main() {
 	// Create swarm
 	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
 		Agents: []swarm.Agent{
-			{Name: "flight_assistant", Runnable: flightAgent, Destinations: []string{"hotel_assistant"}},
-			{Name: "hotel_assistant", Runnable: hotelAgent, Destinations: []string{"flight_assistant"}},
+			{Name: "flight_assistant", Runnable: flightAgent, Destinations: []string{"hotel_assistant"}, Prompt: flightPrompt},
+			{Name: "hotel_assistant", Runnable: hotelAgent, Destinations: []string{"flight_assistant"}, Prompt: hotelPrompt},
 		},
 		DefaultActiveAgent: "flight_assistant",
+		ContextSchema:      supportContext{},
+		// Keep card numbers, emails and phone numbers away from the model
+		Middlewares: []swarm.Middleware{&swarm.PIIRedactor{}},
 	})
 	if err != nil {
 		log.Fatalf("Failed to create swarm: %v", err)
 	}
 
-	var app any
-	if compiler, ok := workflow.(interface{ Compile() (any, error) }); ok {
-		var compileErr error
-		app, compileErr = compiler.Compile()
-		if compileErr != nil {
-			log.Fatalf("Failed to compile swarm: %v", compileErr)
-		}
-	} else {
-		log.Fatal("Workflow does not support Compile()")
+	app, err := workflow.Compile()
+	if err != nil {
+		log.Fatalf("Failed to compile swarm: %v", err)
 	}
 
 	// Example interaction
-	fmt.Println("=== Customer Support Agent Swarm ===\n")
+	fmt.Println("=== Customer Support Agent Swarm ===")
+	fmt.Println()
 
 	state := swarm.SwarmState{
 		Messages: []llms.MessageContent{
@@ -320,22 +312,14 @@ func main() {
 		},
 	}
 
-	var result any
-	if invoker, ok := app.(interface {
-		Invoke(context.Context, swarm.SwarmState) (any, error)
-	}); ok {
-		var invokeErr error
-		result, invokeErr = invoker.Invoke(ctx, state)
-		if invokeErr != nil {
-			log.Fatalf("Failed to invoke: %v", invokeErr)
-		}
+	result, err := app.Invoke(ctx, state, swarm.WithContext(supportContext{UserID: "user1"}))
+	if err != nil {
+		log.Fatalf("Failed to invoke: %v", err)
 	}
 
-	if resultState, ok := result.(swarm.SwarmState); ok {
-		fmt.Printf("Active Agent: %s\n", resultState.ActiveAgent)
-		for i, msg := range resultState.Messages {
-			fmt.Printf("Message %d: %v\n", i+1, msg)
-		}
+	fmt.Printf("Active Agent: %s\n", result.ActiveAgent)
+	for i, msg := range result.Messages {
+		fmt.Printf("Message %d: %v\n", i+1, msg)
 	}
 
 	fmt.Println("\n=== Customer Support Example Complete ===")
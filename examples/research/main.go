@@ -3,57 +3,27 @@ package main
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"time"
 
 	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/cache"
+	"github.com/go-hare/langchaingo_swarm/swarm/tools/webfetch"
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 )
 
-// fetchDocTool creates a tool for fetching documentation
-type fetchDocTool struct{}
-
-func (t *fetchDocTool) Name() string {
-	return "fetch_doc"
-}
-
-func (t *fetchDocTool) Description() string {
-	return "Fetch documentation from a URL. Returns the content of the page."
-}
-
-func (t *fetchDocTool) Call(ctx context.Context, url string) (string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Limit response size
-	maxLen := 5000
-	content := string(body)
-	if len(content) > maxLen {
-		content = content[:maxLen] + "...(truncated)"
-	}
-
-	return content, nil
-}
-
 func main() {
 	ctx := context.Background()
 
-	// Initialize the LLM
-	model, err := openai.New(openai.WithModel("gpt-4o"))
+	// Initialize the LLM, serving identical prompts from a cache
+	llm, err := openai.New(openai.WithModel("gpt-4o"))
 	if err != nil {
 		log.Fatalf("Failed to create model: %v", err)
 	}
+	responses := cache.NewMemory(1000)
+	model := cache.Model(llm, "gpt-4o", responses, time.Hour)
 
 	// LLMS.txt for LangGraph documentation
 	llmsTxt := "LangGraph:https://langchain-ai.github.io/langgraph/llms.txt"
@@ -70,8 +40,13 @@ func main() {
 		Description: "Transfer to the researcher_agent to perform research and implement the solution to the user's request.",
 	})
 
-	// Create fetch doc tool
-	fetchDoc := &fetchDocTool{}
+	// Create fetch doc tool, limited to the LangGraph documentation; both
+	// agents fetch the same pages, so cache them
+	fetchDoc := cache.Tool(webfetch.New(
+		webfetch.WithName("fetch_doc"),
+		webfetch.WithAllowedHosts("langchain-ai.github.io"),
+		webfetch.WithMaxLength(5000),
+	), responses, time.Hour)
 
 	// Planner agent system prompt
 	plannerPrompt := fmt.Sprintf(`You are a planner agent. Your job is to:
@@ -95,39 +70,12 @@ Be thorough and provide complete, working solutions.`
 	// Create planner agent
 	plannerGraph := graph.NewStateGraph[swarm.SwarmState]()
 	plannerGraph.AddNode("process", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
-		messages := append([]llms.MessageContent{
-			llms.TextParts("system", plannerPrompt),
-		}, state.Messages...)
-
 		toolsList := []llms.Tool{
-			{
-				Type: "function",
-				Function: &llms.FunctionDefinition{
-					Name:        fetchDoc.Name(),
-					Description: fetchDoc.Description(),
-					Parameters: map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"url": map[string]interface{}{"type": "string"},
-						},
-						"required": []string{"url"},
-					},
-				},
-			},
-			{
-				Type: "function",
-				Function: &llms.FunctionDefinition{
-					Name:        transferToResearcher.Name(),
-					Description: transferToResearcher.Description(),
-					Parameters: map[string]interface{}{
-						"type":       "object",
-						"properties": map[string]interface{}{},
-					},
-				},
-			},
+			swarm.ToolDefinition(fetchDoc),
+			swarm.ToolDefinition(transferToResearcher),
 		}
 
-		response, err := model.GenerateContent(ctx, messages, llms.WithTools(toolsList))
+		response, err := model.GenerateContent(ctx, state.Messages, llms.WithTools(toolsList))
 		if err != nil {
 			return state, err
 		}
@@ -143,39 +91,12 @@ Be thorough and provide complete, working solutions.`
 	// Create researcher agent
 	researcherGraph := graph.NewStateGraph[swarm.SwarmState]()
 	researcherGraph.AddNode("process", "", func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
-		messages := append([]llms.MessageContent{
-			llms.TextParts("system", researcherPrompt),
-		}, state.Messages...)
-
 		toolsList := []llms.Tool{
-			{
-				Type: "function",
-				Function: &llms.FunctionDefinition{
-					Name:        fetchDoc.Name(),
-					Description: fetchDoc.Description(),
-					Parameters: map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"url": map[string]interface{}{"type": "string"},
-						},
-						"required": []string{"url"},
-					},
-				},
-			},
-			{
-				Type: "function",
-				Function: &llms.FunctionDefinition{
-					Name:        transferToPlanner.Name(),
-					Description: transferToPlanner.Description(),
-					Parameters: map[string]interface{}{
-						"type":       "object",
-						"properties": map[string]interface{}{},
-					},
-				},
-			},
+			swarm.ToolDefinition(fetchDoc),
+			swarm.ToolDefinition(transferToPlanner),
 		}
 
-		response, err := model.GenerateContent(ctx, messages, llms.WithTools(toolsList))
+		response, err := model.GenerateContent(ctx, state.Messages, llms.WithTools(toolsList))
 		if err != nil {
 			return state, err
 		}
@@ -191,8 +112,18 @@ Be thorough and provide complete, working solutions.`
 	// Create the swarm
 	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
 		Agents: []swarm.Agent{
-			{Name: "planner_agent", Runnable: plannerAgent, Destinations: []string{"researcher_agent"}},
-			{Name: "researcher_agent", Runnable: researcherAgent, Destinations: []string{"planner_agent"}},
+			{
+				Name:         "planner_agent",
+				Runnable:     plannerAgent,
+				Destinations: []string{"researcher_agent"},
+				SystemPrompt: plannerPrompt,
+			},
+			{
+				Name:         "researcher_agent",
+				Runnable:     researcherAgent,
+				Destinations: []string{"planner_agent"},
+				SystemPrompt: researcherPrompt,
+			},
 		},
 		DefaultActiveAgent: "planner_agent",
 	})
@@ -201,19 +132,15 @@ Be thorough and provide complete, working solutions.`
 	}
 
 	// Compile the swarm
-	var app any
-	if compiler, ok := workflow.(interface{ Compile() (any, error) }); ok {
-		app, err = compiler.Compile()
-		if err != nil {
-			log.Fatalf("Failed to compile swarm: %v", err)
-		}
-	} else {
-		log.Fatal("Workflow does not support Compile()")
+	app, err := workflow.Compile()
+	if err != nil {
+		log.Fatalf("Failed to compile swarm: %v", err)
 	}
 
 	// Example interaction
 	fmt.Println("=== Research Assistant Swarm ===")
-	fmt.Println("Planner and Researcher agents working together\n")
+	fmt.Println("Planner and Researcher agents working together")
+	fmt.Println()
 
 	state := swarm.SwarmState{
 		Messages: []llms.MessageContent{
@@ -221,22 +148,15 @@ Be thorough and provide complete, working solutions.`
 		},
 	}
 
-	var result any
-	if invoker, ok := app.(interface {
-		Invoke(context.Context, swarm.SwarmState) (any, error)
-	}); ok {
-		result, err = invoker.Invoke(ctx, state)
-		if err != nil {
-			log.Fatalf("Failed to invoke: %v", err)
-		}
+	result, err := app.Invoke(ctx, state)
+	if err != nil {
+		log.Fatalf("Failed to invoke: %v", err)
 	}
 
 	// Print results
-	if resultState, ok := result.(swarm.SwarmState); ok {
-		fmt.Printf("\nActive Agent: %s\n", resultState.ActiveAgent)
-		fmt.Printf("\nConversation History (%d messages):\n", len(resultState.Messages))
-		for i, msg := range resultState.Messages {
-			fmt.Printf("%d. %v\n", i+1, msg)
-		}
+	fmt.Printf("\nActive Agent: %s\n", result.ActiveAgent)
+	fmt.Printf("\nConversation History (%d messages):\n", len(result.Messages))
+	for i, msg := range result.Messages {
+		fmt.Printf("%d. %v\n", i+1, msg)
 	}
 }
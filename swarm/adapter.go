@@ -0,0 +1,244 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// MessageAdapter rewrites the messages of a model call to meet the
+// constraints of a provider, such as where system messages may appear, how
+// tool results are sent or which roles may follow each other. The swarm
+// applies an agent's adapter (Agent.MessageAdapter) to every model call of
+// the prebuilt agents; the conversation itself is not changed.
+//
+// Example:
+//
+//	swarm.Agent{
+//	    Name:           "Writer",
+//	    Runnable:       writer, // created with an Anthropic model
+//	    MessageAdapter: swarm.AnthropicAdapter{},
+//	}
+type MessageAdapter interface {
+	Adapt(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error)
+}
+
+// MessageAdapterFunc adapts a function to the MessageAdapter interface.
+type MessageAdapterFunc func(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error)
+
+// Adapt calls f.
+func (f MessageAdapterFunc) Adapt(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	return f(ctx, messages)
+}
+
+// OpenAIAdapter is a MessageAdapter for the OpenAI Chat Completions API and
+// compatible servers. Each tool result is sent in a tool message of its
+// own, and tool results answering no earlier tool call, as well as tool
+// calls left without a result, are dropped.
+type OpenAIAdapter struct{}
+
+// Adapt implements MessageAdapter.
+func (OpenAIAdapter) Adapt(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	return pairToolCalls(messages), nil
+}
+
+// AnthropicAdapter is a MessageAdapter for the Anthropic Messages API. On
+// top of pairing tool calls and results as OpenAIAdapter does, it merges
+// the system messages into one at the start, drops empty text, and splits
+// AI messages so that each holds a single text or tool call, as langchaingo
+// sends only the first part of an assistant message.
+type AnthropicAdapter struct{}
+
+// Adapt implements MessageAdapter.
+func (AnthropicAdapter) Adapt(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	messages = withoutEmptyText(pairToolCalls(mergeSystemMessages(messages)))
+	adapted := make([]llms.MessageContent, 0, len(messages))
+	for _, message := range messages {
+		if message.Role != llms.ChatMessageTypeAI || len(message.Parts) == 1 {
+			adapted = append(adapted, message)
+			continue
+		}
+		for _, part := range message.Parts {
+			adapted = append(adapted, llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{part}})
+		}
+	}
+	return adapted, nil
+}
+
+// GoogleAdapter is a MessageAdapter for Google Gemini. On top of pairing
+// tool calls and results as OpenAIAdapter does, it merges the system
+// messages into one, as Gemini takes a single system instruction, drops
+// empty text, and merges consecutive messages of the same role, so that the
+// results of parallel tool calls form one turn.
+type GoogleAdapter struct{}
+
+// Adapt implements MessageAdapter.
+func (GoogleAdapter) Adapt(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	messages = withoutEmptyText(pairToolCalls(mergeSystemMessages(messages)))
+	adapted := make([]llms.MessageContent, 0, len(messages))
+	for _, message := range messages {
+		if last := len(adapted) - 1; last >= 0 && adapted[last].Role == message.Role {
+			adapted[last].Parts = append(adapted[last].Parts, message.Parts...)
+			continue
+		}
+		adapted = append(adapted, llms.MessageContent{Role: message.Role, Parts: append([]llms.ContentPart(nil), message.Parts...)})
+	}
+	return adapted, nil
+}
+
+// OllamaAdapter is a MessageAdapter for Ollama, whose langchaingo client
+// sends a single text and images per message. Tool calls, tool results and
+// image URLs are written out as text, and the text parts of each message
+// are joined; binary parts are kept.
+type OllamaAdapter struct{}
+
+// Adapt implements MessageAdapter.
+func (OllamaAdapter) Adapt(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	adapted := make([]llms.MessageContent, 0, len(messages))
+	for _, message := range messages {
+		var (
+			texts []string
+			parts []llms.ContentPart
+		)
+		for _, part := range message.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				if p.Text != "" {
+					texts = append(texts, p.Text)
+				}
+			case llms.ImageURLContent:
+				texts = append(texts, fmt.Sprintf("[image: %s]", p.URL))
+			case llms.ToolCall:
+				if p.FunctionCall != nil {
+					texts = append(texts, fmt.Sprintf("Calling tool %s with %s", p.FunctionCall.Name, p.FunctionCall.Arguments))
+				}
+			case llms.ToolCallResponse:
+				texts = append(texts, fmt.Sprintf("Result of tool %s: %s", p.Name, p.Content))
+			default:
+				parts = append(parts, part)
+			}
+		}
+		if len(texts) > 0 {
+			parts = append([]llms.ContentPart{llms.TextContent{Text: strings.Join(texts, "\n")}}, parts...)
+		}
+		if len(parts) > 0 {
+			adapted = append(adapted, llms.MessageContent{Role: message.Role, Parts: parts})
+		}
+	}
+	return adapted, nil
+}
+
+// pairToolCalls returns messages with each tool result in a tool message of
+// its own, without the tool results that answer no earlier tool call and
+// without the tool calls that get no result.
+func pairToolCalls(messages []llms.MessageContent) []llms.MessageContent {
+	answered := make(map[string]bool)
+	for _, message := range messages {
+		for _, part := range message.Parts {
+			if response, ok := part.(llms.ToolCallResponse); ok {
+				answered[response.ToolCallID] = true
+			}
+		}
+	}
+
+	// called holds the tool calls waiting for their result
+	called := make(map[string]bool)
+	paired := make([]llms.MessageContent, 0, len(messages))
+	for _, message := range messages {
+		switch message.Role {
+		case llms.ChatMessageTypeAI:
+			parts := make([]llms.ContentPart, 0, len(message.Parts))
+			for _, part := range message.Parts {
+				if call, ok := part.(llms.ToolCall); ok {
+					if call.FunctionCall == nil || !answered[call.ID] {
+						continue
+					}
+					called[call.ID] = true
+				}
+				parts = append(parts, part)
+			}
+			if len(parts) > 0 {
+				paired = append(paired, llms.MessageContent{Role: message.Role, Parts: parts})
+			}
+		case llms.ChatMessageTypeTool:
+			for _, part := range message.Parts {
+				if response, ok := part.(llms.ToolCallResponse); ok && called[response.ToolCallID] {
+					delete(called, response.ToolCallID)
+					paired = append(paired, llms.MessageContent{Role: message.Role, Parts: []llms.ContentPart{response}})
+				}
+			}
+		default:
+			paired = append(paired, message)
+		}
+	}
+	return paired
+}
+
+// mergeSystemMessages returns messages with the text of all system messages
+// joined in a single system message at the start.
+func mergeSystemMessages(messages []llms.MessageContent) []llms.MessageContent {
+	var (
+		texts []string
+		rest  = make([]llms.MessageContent, 0, len(messages))
+	)
+	for _, message := range messages {
+		if message.Role != llms.ChatMessageTypeSystem {
+			rest = append(rest, message)
+			continue
+		}
+		for _, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok && text.Text != "" {
+				texts = append(texts, text.Text)
+			}
+		}
+	}
+	if len(texts) == 0 {
+		return rest
+	}
+	return append([]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, strings.Join(texts, "\n\n"))}, rest...)
+}
+
+// withoutEmptyText returns messages without empty text parts and without the
+// messages left with no parts.
+func withoutEmptyText(messages []llms.MessageContent) []llms.MessageContent {
+	kept := make([]llms.MessageContent, 0, len(messages))
+	for _, message := range messages {
+		parts := make([]llms.ContentPart, 0, len(message.Parts))
+		for _, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok && text.Text == "" {
+				continue
+			}
+			parts = append(parts, part)
+		}
+		if len(parts) > 0 {
+			kept = append(kept, llms.MessageContent{Role: message.Role, Parts: parts})
+		}
+	}
+	return kept
+}
+
+// messageAdapterKey is the context key for the message adapter of the
+// running agent.
+type messageAdapterKey struct{}
+
+// withMessageAdapter returns a context in which model calls are adapted by
+// adapter.
+func withMessageAdapter(ctx context.Context, adapter MessageAdapter) context.Context {
+	return context.WithValue(ctx, messageAdapterKey{}, adapter)
+}
+
+// adaptMessages applies the running agent's message adapter, if any, to the
+// messages of a model call.
+func adaptMessages(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	adapter, ok := ctx.Value(messageAdapterKey{}).(MessageAdapter)
+	if !ok {
+		return messages, nil
+	}
+	adapted, err := adapter.Adapt(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("message adapter: %w", err)
+	}
+	return adapted, nil
+}
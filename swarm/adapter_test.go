@@ -0,0 +1,157 @@
+package swarm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// adapterConversation returns a conversation with parallel tool calls, an
+// orphaned tool result and system messages in the middle.
+func adapterConversation() []llms.MessageContent {
+	return []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are Alice."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris and Rome?"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "Checking."},
+			llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}},
+			llms.ToolCall{ID: "call_2", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Rome"}`}},
+			llms.ToolCall{ID: "call_3", Type: "function", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Oslo"}`}},
+		}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: "call_1", Name: "weather", Content: "sunny"},
+			llms.ToolCallResponse{ToolCallID: "call_2", Name: "weather", Content: "rainy"},
+		}},
+		llms.TextParts(llms.ChatMessageTypeTool, "Successfully transferred to Bob"),
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are Bob."),
+		llms.TextParts(llms.ChatMessageTypeAI, ""),
+	}
+}
+
+func TestOpenAIAdapter(t *testing.T) {
+	input := adapterConversation()
+	got, err := OpenAIAdapter{}.Adapt(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Adapt() error = %v", err)
+	}
+	want := []llms.MessageContent{
+		input[0],
+		input[1],
+		{Role: llms.ChatMessageTypeAI, Parts: input[2].Parts[:3]},
+		{Role: llms.ChatMessageTypeTool, Parts: input[3].Parts[:1]},
+		{Role: llms.ChatMessageTypeTool, Parts: input[3].Parts[1:]},
+		input[5],
+		input[6],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Adapt() = %v\nwant %v", got, want)
+	}
+	if !reflect.DeepEqual(input, adapterConversation()) {
+		t.Error("Adapt() modified its input")
+	}
+}
+
+func TestAnthropicAdapter(t *testing.T) {
+	input := adapterConversation()
+	got, err := AnthropicAdapter{}.Adapt(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Adapt() error = %v", err)
+	}
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are Alice.\n\nYou are Bob."),
+		input[1],
+		{Role: llms.ChatMessageTypeAI, Parts: input[2].Parts[0:1]},
+		{Role: llms.ChatMessageTypeAI, Parts: input[2].Parts[1:2]},
+		{Role: llms.ChatMessageTypeAI, Parts: input[2].Parts[2:3]},
+		{Role: llms.ChatMessageTypeTool, Parts: input[3].Parts[:1]},
+		{Role: llms.ChatMessageTypeTool, Parts: input[3].Parts[1:]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Adapt() = %v\nwant %v", got, want)
+	}
+}
+
+func TestGoogleAdapter(t *testing.T) {
+	input := adapterConversation()
+	got, err := GoogleAdapter{}.Adapt(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Adapt() error = %v", err)
+	}
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are Alice.\n\nYou are Bob."),
+		input[1],
+		{Role: llms.ChatMessageTypeAI, Parts: input[2].Parts[:3]},
+		{Role: llms.ChatMessageTypeTool, Parts: input[3].Parts},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Adapt() = %v\nwant %v", got, want)
+	}
+}
+
+func TestOllamaAdapter(t *testing.T) {
+	input := []llms.MessageContent{
+		{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "What is this?"},
+			llms.ImageURLContent{URL: "https://example.com/cat.png"},
+			llms.BinaryContent{MIMEType: "image/png", Data: []byte{1}},
+		}},
+		adapterConversation()[2],
+		adapterConversation()[3],
+		llms.TextParts(llms.ChatMessageTypeAI, ""),
+	}
+	got, err := OllamaAdapter{}.Adapt(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Adapt() error = %v", err)
+	}
+	want := []llms.MessageContent{
+		{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+			llms.TextContent{Text: "What is this?\n[image: https://example.com/cat.png]"},
+			llms.BinaryContent{MIMEType: "image/png", Data: []byte{1}},
+		}},
+		llms.TextParts(llms.ChatMessageTypeAI, "Checking.\n"+
+			`Calling tool weather with {"city":"Paris"}`+"\n"+
+			`Calling tool weather with {"city":"Rome"}`+"\n"+
+			`Calling tool weather with {"city":"Oslo"}`),
+		llms.TextParts(llms.ChatMessageTypeTool, "Result of tool weather: sunny\nResult of tool weather: rainy"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Adapt() = %v\nwant %v", got, want)
+	}
+}
+
+func TestAgentMessageAdapter(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hello"}}}
+	alice, err := CreateReactAgent(model, []tools.Tool{}, WithSystemPrompt("Be brief."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:           "Alice",
+			Runnable:       alice,
+			SystemPrompt:   "You are Alice.",
+			MessageAdapter: AnthropicAdapter{},
+		}},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	want := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Be brief.\n\nYou are Alice."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+	}
+	if !reflect.DeepEqual(model.calls[0], want) {
+		t.Errorf("model call = %v, want %v", model.calls[0], want)
+	}
+	if len(result.Messages) != 2 || result.Messages[0].Role != llms.ChatMessageTypeHuman {
+		t.Errorf("conversation = %v, want the human message and the answer", result.Messages)
+	}
+}
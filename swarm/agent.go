@@ -0,0 +1,336 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// defaultMaxIterations is the default number of model calls a ReAct agent
+	// may make in a single invocation.
+	defaultMaxIterations = 20
+
+	// reactAgentNode is the name of the model node in a ReAct agent graph.
+	reactAgentNode = "agent"
+	// reactToolsNode is the name of the tool execution node in a ReAct agent graph.
+	reactToolsNode = "tools"
+	// reactStartNode is the name of the entry node in a ReAct agent graph.
+	reactStartNode = "__start__"
+)
+
+// AgentOption configures an agent created by CreateReactAgent.
+type AgentOption func(*agentOptions)
+
+// agentOptions holds the settings applied by AgentOption values.
+type agentOptions struct {
+	systemPrompt  string
+	maxIterations int
+	callOptions   []llms.CallOption
+	retriever     *retriever
+}
+
+// WithSystemPrompt sets the system message prepended to every model call.
+func WithSystemPrompt(prompt string) AgentOption {
+	return func(o *agentOptions) {
+		o.systemPrompt = prompt
+	}
+}
+
+// WithMaxIterations limits the number of model calls of each run of the
+// agent (default: 20).
+func WithMaxIterations(n int) AgentOption {
+	return func(o *agentOptions) {
+		o.maxIterations = n
+	}
+}
+
+// WithCallOptions sets options passed to every model call, such as
+// llms.WithTemperature or llms.WithMaxTokens.
+func WithCallOptions(opts ...llms.CallOption) AgentOption {
+	return func(o *agentOptions) {
+		o.callOptions = append(o.callOptions, opts...)
+	}
+}
+
+// ReactAgent is a prebuilt agent created by CreateReactAgent.
+type ReactAgent struct {
+	runnable *graph.StateRunnable[SwarmState]
+	toolNode *ToolNode
+}
+
+// Invoke runs the agent on the given state and returns the resulting state.
+func (a *ReactAgent) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return a.runnable.Invoke(context.WithValue(ctx, reactRunStartKey{}, len(state.Messages)), state)
+}
+
+// Runnable returns the underlying compiled graph.
+func (a *ReactAgent) Runnable() *graph.StateRunnable[SwarmState] {
+	return a.runnable
+}
+
+// InvokeCommand runs the agent like Invoke and returns a Command whose Update
+// is the resulting state. When a handoff tool fired, Goto is the agent it
+// transferred to; otherwise it is graph.END. A handoff ending the turn (see
+// HandoffEndTurn) leaves Goto nil, with the target active in the Update. It
+// implements CommandRunnable.
+func (a *ReactAgent) InvokeCommand(ctx context.Context, state SwarmState) (*graph.Command, error) {
+	result, err := a.Invoke(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	cmd := &graph.Command{Update: result, Goto: graph.END}
+	if n := len(result.Handoffs); n > len(state.Handoffs) && result.Handoffs[n-1].To == result.ActiveAgent {
+		cmd.Goto = result.ActiveAgent
+		if result.Handoffs[n-1].Mode == HandoffEndTurn {
+			cmd.Goto = nil
+		}
+	}
+	return cmd, nil
+}
+
+// HandoffDestinations returns the agents the agent's handoff tools transfer to.
+// It implements HandoffDestinationsProvider.
+func (a *ReactAgent) HandoffDestinations() []string {
+	return a.toolNode.HandoffDestinations()
+}
+
+// CreateReactAgent creates a compiled ReAct agent for use in a swarm.
+//
+// The agent loops between a model node and a tool execution node until the
+// model stops requesting tools. When a handoff tool (see CreateHandoffTool)
+// fires, the agent records the new active agent in the state and stops;
+// the swarm runs it through InvokeCommand and routes to the Command's Goto.
+//
+// Args:
+//   - model: The model to call, unless Agent.Model replaces it in a swarm
+//   - agentTools: Tools the model may call, including handoff tools
+//   - opts: Optional settings such as WithSystemPrompt or WithCallOptions
+//
+// Returns:
+//   - A ReactAgent usable as Agent.Runnable
+//
+// Example:
+//
+//	alice, err := swarm.CreateReactAgent(model,
+//	    []tools.Tool{addTool, swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: "Bob"})},
+//	    swarm.WithSystemPrompt("You are Alice, an addition expert."),
+//	)
+func CreateReactAgent(model llms.Model, agentTools []tools.Tool, opts ...AgentOption) (*ReactAgent, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model cannot be nil")
+	}
+
+	options := agentOptions{maxIterations: defaultMaxIterations}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	seen := make(map[string]bool, len(agentTools))
+	toolDefs := make([]llms.Tool, 0, len(agentTools))
+	for _, t := range agentTools {
+		if seen[t.Name()] {
+			return nil, fmt.Errorf("duplicate tool name '%s'", t.Name())
+		}
+		seen[t.Name()] = true
+		toolDefs = append(toolDefs, ToolDefinition(t))
+	}
+	toolNode := NewToolNode(agentTools)
+
+	g := graph.NewStateGraph[SwarmState]()
+
+	g.AddNode(reactAgentNode, "Call the model", func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		messages := state.Messages
+		if options.systemPrompt != "" {
+			messages = append([]llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, options.systemPrompt),
+			}, messages...)
+		}
+
+		if options.retriever != nil {
+			var err error
+			if messages, err = options.retriever.inject(ctx, messages); err != nil {
+				return state, err
+			}
+		}
+
+		messages, err := adaptMessages(ctx, messages)
+		if err != nil {
+			return state, err
+		}
+
+		model, callOpts, defs := model, slices.Clip(options.callOptions), toolDefs
+		if override, ok := ctx.Value(agentModelKey{}).(agentModel); ok {
+			if override.model != nil {
+				model = override.model
+			}
+			callOpts = append(callOpts, override.callOptions...)
+			// The agent's own tools win over Agent.Tools of the same name
+			for _, t := range override.tools {
+				if !seen[t.Name()] {
+					defs = append(slices.Clip(defs), ToolDefinition(t))
+				}
+			}
+		}
+		if len(defs) > 0 {
+			callOpts = append(callOpts, llms.WithTools(defs))
+		}
+		if stream := streamingOption(ctx); stream != nil {
+			callOpts = append(callOpts, stream)
+		}
+
+		// A stopped run ends the agent's turn instead of calling the model
+		if cause := runStopped(ctx); cause != nil {
+			state.Messages = append(state.Messages, stopMessage(cause))
+			return state, nil
+		}
+
+		release, err := acquireModel(ctx)
+		if err != nil {
+			return state, err
+		}
+		genCtx, span := tracer(ctx, nil).Start(ctx, "model.generate")
+		start := time.Now()
+		response, err := model.GenerateContent(genCtx, messages, callOpts...)
+		release()
+		if err == nil && len(response.Choices) == 0 {
+			err = fmt.Errorf("model returned no choices")
+		}
+		if err == nil {
+			span.SetAttributes(tokenUsage(response.Choices[0].GenerationInfo)...)
+			recordUsage(ctx, response)
+			recordModelCall(ctx, start, messages, response)
+		}
+		endSpan(span, err)
+		if err != nil {
+			return state, err
+		}
+
+		state.Messages = append(state.Messages, aiMessage(response.Choices[0]))
+		return postProcess(ctx, state, response.Choices[0])
+	})
+
+	g.AddNode(reactToolsNode, "Execute tool calls", toolNode.Invoke)
+
+	// Resume pending tool calls (e.g. after an interrupt) before calling
+	// the model again
+	g.AddNode(reactStartNode, "Route to the model or pending tool calls", func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		return state, nil
+	})
+	g.SetEntryPoint(reactStartNode)
+	g.AddConditionalEdge(reactStartNode, func(ctx context.Context, state SwarmState) string {
+		if len(pendingToolCalls(state)) > 0 {
+			return reactToolsNode
+		}
+		return reactAgentNode
+	})
+
+	g.AddConditionalEdge(reactAgentNode, func(ctx context.Context, state SwarmState) string {
+		last := state.Messages[len(state.Messages)-1]
+		if len(toolCalls(last)) == 0 {
+			return graph.END
+		}
+		return reactToolsNode
+	})
+
+	g.AddConditionalEdge(reactToolsNode, func(ctx context.Context, state SwarmState) string {
+		// Stop after a handoff so the swarm can route to the new agent,
+		// unless the handoff waits for the next turn: then the agent
+		// answers the user first
+		if toolNode.handedOff(ctx, state) && !endsTurn(state) {
+			return graph.END
+		}
+		// Iterations are counted from the conversation rather than a
+		// counter so the compiled agent holds no per-run state.
+		if countIterations(ctx, state.Messages) >= options.maxIterations {
+			return graph.END
+		}
+		return reactAgentNode
+	})
+
+	runnable, err := g.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return &ReactAgent{runnable: runnable, toolNode: toolNode}, nil
+}
+
+// agentModelKey is the context key for the model settings of the running
+// agent (Agent.Model, Agent.CallOptions, Agent.Tools and Agent.PostProcess).
+type agentModelKey struct{}
+
+// agentModel holds the model settings of an agent.
+type agentModel struct {
+	model       llms.Model
+	callOptions []llms.CallOption
+	tools       []tools.Tool
+	postProcess PostProcessFunc
+}
+
+// withAgentModel returns a context in which prebuilt agents call the model
+// of agent with its call options and extra tools, and post-process its
+// responses.
+func withAgentModel(ctx context.Context, agent Agent) context.Context {
+	return context.WithValue(ctx, agentModelKey{}, agentModel{
+		model:       agent.Model,
+		callOptions: agent.CallOptions,
+		tools:       agent.Tools,
+		postProcess: agent.PostProcess,
+	})
+}
+
+// aiMessage converts a model choice into an AI message including its tool calls.
+func aiMessage(choice *llms.ContentChoice) llms.MessageContent {
+	msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+	if choice.Content != "" {
+		msg.Parts = append(msg.Parts, llms.TextPart(choice.Content))
+	}
+	for _, tc := range choice.ToolCalls {
+		msg.Parts = append(msg.Parts, tc)
+	}
+	return msg
+}
+
+// toolCalls returns the tool calls contained in a message.
+func toolCalls(msg llms.MessageContent) []llms.ToolCall {
+	var calls []llms.ToolCall
+	for _, part := range msg.Parts {
+		if tc, ok := part.(llms.ToolCall); ok {
+			calls = append(calls, tc)
+		}
+	}
+	return calls
+}
+
+// reactRunStartKey is the context key of the number of messages a
+// ReactAgent run started with.
+type reactRunStartKey struct{}
+
+// countIterations counts the model calls of the running ReactAgent: the AI
+// messages it added to the conversation, ignoring those of the agents that
+// ran before it.
+func countIterations(ctx context.Context, messages []llms.MessageContent) int {
+	if start, ok := ctx.Value(reactRunStartKey{}).(int); ok && start <= len(messages) {
+		messages = messages[start:]
+	}
+	return countAIMessagesSinceUser(messages)
+}
+
+// countAIMessagesSinceUser counts the AI messages after the last human message.
+func countAIMessagesSinceUser(messages []llms.MessageContent) int {
+	count := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Role {
+		case llms.ChatMessageTypeHuman:
+			return count
+		case llms.ChatMessageTypeAI:
+			count++
+		}
+	}
+	return count
+}
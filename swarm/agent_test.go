@@ -0,0 +1,283 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// scriptedModel is an llms.Model that returns canned responses in order.
+type scriptedModel struct {
+	responses []*llms.ContentChoice
+	calls     [][]llms.MessageContent
+	// options are the options of each call
+	options []llms.CallOptions
+}
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls = append(m.calls, messages)
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.options = append(m.options, opts)
+	if len(m.calls) > len(m.responses) {
+		return nil, fmt.Errorf("unexpected model call %d", len(m.calls))
+	}
+	choice := m.responses[len(m.calls)-1]
+	if opts.StreamingFunc != nil && choice.Content != "" {
+		if err := opts.StreamingFunc(ctx, []byte(choice.Content)); err != nil {
+			return nil, err
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+func (m *scriptedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// toolCallChoice returns a model choice requesting a single tool call.
+func toolCallChoice(id, name, arguments string) *llms.ContentChoice {
+	return &llms.ContentChoice{
+		ToolCalls: []llms.ToolCall{
+			{ID: id, Type: "function", FunctionCall: &llms.FunctionCall{Name: name, Arguments: arguments}},
+		},
+	}
+}
+
+// upperTool upper-cases its input.
+type upperTool struct{}
+
+func (upperTool) Name() string        { return "upper" }
+func (upperTool) Description() string { return "Upper-case the input" }
+func (upperTool) Call(ctx context.Context, input string) (string, error) {
+	return strings.ToUpper(input), nil
+}
+
+func TestCreateReactAgentToolLoop(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "upper", `{"input":"hello"}`),
+		{Content: "The answer is HELLO"},
+	}}
+
+	agent, err := CreateReactAgent(model, []tools.Tool{upperTool{}}, WithSystemPrompt("You are helpful."))
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	result, err := agent.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "shout hello")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if len(result.Messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(result.Messages))
+	}
+	resp, ok := result.Messages[2].Parts[0].(llms.ToolCallResponse)
+	if !ok || resp.ToolCallID != "call_1" || resp.Content != "HELLO" {
+		t.Errorf("Unexpected tool response %#v", result.Messages[2].Parts[0])
+	}
+	if model.calls[0][0].Role != llms.ChatMessageTypeSystem {
+		t.Errorf("Expected system prompt first, got role %s", model.calls[0][0].Role)
+	}
+}
+
+func TestCreateReactAgentHandoff(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_bob", `{}`),
+	}}
+
+	agent, err := CreateReactAgent(model, []tools.Tool{
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"}),
+	})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	result, err := agent.Invoke(context.Background(), SwarmState{
+		Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "talk to Bob")},
+		ActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if result.ActiveAgent != "Bob" {
+		t.Errorf("Expected active agent Bob, got %q", result.ActiveAgent)
+	}
+	if len(model.calls) != 1 {
+		t.Errorf("Expected the agent to stop after the handoff, got %d model calls", len(model.calls))
+	}
+}
+
+func TestCreateReactAgentMaxIterations(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "upper", `{"input":"a"}`),
+		toolCallChoice("call_2", "upper", `{"input":"b"}`),
+	}}
+
+	agent, err := CreateReactAgent(model, []tools.Tool{upperTool{}}, WithMaxIterations(2))
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	if _, err := agent.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "loop")},
+	}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(model.calls) != 2 {
+		t.Errorf("Expected 2 model calls, got %d", len(model.calls))
+	}
+}
+
+func TestCreateReactAgentMaxIterationsAfterHandoff(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_2", "upper", `{"input":"b"}`),
+		{Content: "B"},
+	}}
+	agent, err := CreateReactAgent(model, []tools.Tool{upperTool{}}, WithMaxIterations(2))
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	// The AI messages of the agent that handed off do not count
+	history := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "shout b"),
+		llms.TextParts(llms.ChatMessageTypeAI, "Let me check."),
+		llms.TextParts(llms.ChatMessageTypeAI, "Transferring you to Bob."),
+	}
+	result, err := agent.Invoke(context.Background(), SwarmState{Messages: history})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(model.calls) != 2 || len(result.Messages) != 6 {
+		t.Errorf("got %d model calls and %d messages, want the agent to finish its loop", len(model.calls), len(result.Messages))
+	}
+}
+
+func TestCreateReactAgentCallOptions(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hi"}}}
+	agent, err := CreateReactAgent(model, []tools.Tool{upperTool{}},
+		WithCallOptions(llms.WithTemperature(0.3), llms.WithMaxTokens(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agent.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+	}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	opts := model.options[0]
+	if opts.Temperature != 0.3 || opts.MaxTokens != 100 || len(opts.Tools) != 1 {
+		t.Errorf("call options = %+v, want temperature, max tokens and tools", opts)
+	}
+}
+
+func TestAgentModel(t *testing.T) {
+	builtWith := &scriptedModel{}
+	writerModel := &scriptedModel{responses: []*llms.ContentChoice{{Content: "A poem"}}}
+	writer, err := CreateReactAgent(builtWith, nil, WithCallOptions(llms.WithTemperature(0.9), llms.WithMaxTokens(50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:        "Writer",
+			Runnable:    writer,
+			Model:       writerModel,
+			CallOptions: []llms.CallOption{llms.WithTemperature(0.2)},
+		}},
+		DefaultActiveAgent: "Writer",
+	})
+
+	if _, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Write")},
+	}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(builtWith.calls) != 0 || len(writerModel.calls) != 1 {
+		t.Fatalf("calls = %d to the constructor's model, %d to Agent.Model", len(builtWith.calls), len(writerModel.calls))
+	}
+	if opts := writerModel.options[0]; opts.Temperature != 0.2 || opts.MaxTokens != 50 {
+		t.Errorf("call options = %+v, want Agent.CallOptions over the agent's own", opts)
+	}
+}
+
+func TestAgentPostProcess(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		{Content: "<think>The user wants shouting</think>\nhello", ToolCalls: []llms.ToolCall{{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "upper", Arguments: `{"text":"hello"}`}}}},
+		{Content: "<think>Done</think>HELLO"},
+	}}
+	agent, err := CreateReactAgent(model, []tools.Tool{upperTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var responses int
+	strip := StripTags("think")
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:     "Alice",
+			Runnable: agent,
+			PostProcess: func(ctx context.Context, state SwarmState, response *llms.ContentChoice) (SwarmState, error) {
+				responses++
+				state, err := strip(ctx, state, response)
+				if len(toolCalls(state.Messages[len(state.Messages)-1])) == 0 {
+					state.Messages[len(state.Messages)-1].Parts = append(state.Messages[len(state.Messages)-1].Parts, llms.TextPart(" [1]"))
+				}
+				return state, err
+			},
+		}},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Shout hello")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if responses != 2 {
+		t.Errorf("PostProcess called %d times, want once per model call", responses)
+	}
+	if first := result.Messages[1]; first.Parts[0] != (llms.TextContent{Text: "hello"}) || len(toolCalls(first)) != 1 {
+		t.Errorf("first answer = %+v, want the reasoning stripped and the tool call kept", first)
+	}
+	if parts := result.Messages[len(result.Messages)-1].Parts; len(parts) != 2 || parts[0] != (llms.TextContent{Text: "HELLO"}) || parts[1] != (llms.TextContent{Text: " [1]"}) {
+		t.Errorf("last answer = %+v, want the reasoning stripped and a citation", parts)
+	}
+
+	failing, _ := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{{Content: "Hi"}}}, nil)
+	app = compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:     "Alice",
+			Runnable: failing,
+			PostProcess: func(ctx context.Context, state SwarmState, response *llms.ContentChoice) (SwarmState, error) {
+				return state, errors.New("missing citation")
+			},
+		}},
+		DefaultActiveAgent: "Alice",
+	})
+	if _, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	}); err == nil || !strings.Contains(err.Error(), "missing citation") {
+		t.Errorf("Invoke() error = %v, want the post-processing error", err)
+	}
+}
+
+func TestCreateReactAgentValidation(t *testing.T) {
+	if _, err := CreateReactAgent(nil, nil); err == nil {
+		t.Error("Expected error for nil model")
+	}
+	if _, err := CreateReactAgent(&scriptedModel{}, []tools.Tool{upperTool{}, upperTool{}}); err == nil {
+		t.Error("Expected error for duplicate tool names")
+	}
+}
@@ -0,0 +1,86 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// AddAgent registers an agent with a running swarm. The swarm's routing is
+// rebuilt, and compiled swarms use the new agent from their next
+// invocation; invocations in progress are not affected.
+//
+// The agent is validated like the agents passed to CreateSwarm. Agents
+// without Destinations can hand off to it right away; other agents need to
+// be re-added with it among their destinations.
+//
+// AddAgent is safe to call concurrently with invocations. It is not
+// supported by supervisor swarms.
+//
+// Example:
+//
+//	err := workflow.AddAgent(ctx, swarm.Agent{Name: "Refunds", Runnable: refunds})
+func (s *Swarm) AddAgent(ctx context.Context, agent Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.build == nil {
+		return fmt.Errorf("add agent '%s': the agents of this swarm cannot be changed", agent.Name)
+	}
+	config := s.config
+	config.Agents = append(slices.Clip(config.Agents), agent)
+	if err := s.reconfigure(config); err != nil {
+		return fmt.Errorf("add agent '%s': %w", agent.Name, err)
+	}
+
+	if s.config.Logger != nil {
+		s.config.Logger.LogAttrs(ctx, s.config.LogLevel, "agent added", slog.String("agent", agent.Name))
+	}
+	return nil
+}
+
+// RemoveAgent retires an agent from a running swarm. It fails if the agent
+// is the default active agent, or if a remaining agent lists it among its
+// Destinations or as its Fallback. Compiled swarms stop routing to the agent
+// from their next invocation; a thread whose active agent was removed must
+// be given a new ActiveAgent.
+//
+// RemoveAgent is safe to call concurrently with invocations. It is not
+// supported by supervisor swarms.
+func (s *Swarm) RemoveAgent(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.build == nil {
+		return fmt.Errorf("remove agent '%s': the agents of this swarm cannot be changed", name)
+	}
+	if _, ok := s.agents[name]; !ok {
+		return fmt.Errorf("remove agent '%s': %w", name, ErrUnknownAgent)
+	}
+	config := s.config
+	config.Agents = slices.DeleteFunc(slices.Clone(config.Agents), func(agent Agent) bool {
+		return agent.Name == name
+	})
+	if err := s.reconfigure(config); err != nil {
+		return fmt.Errorf("remove agent '%s': %w", name, err)
+	}
+
+	if s.config.Logger != nil {
+		s.config.Logger.LogAttrs(context.Background(), s.config.LogLevel, "agent removed", slog.String("agent", name))
+	}
+	return nil
+}
+
+// reconfigure validates config and rebuilds the graph of s from it. s.mu
+// must be held.
+func (s *Swarm) reconfigure(config SwarmConfig) error {
+	agentNames, err := validateConfig(&config)
+	if err != nil {
+		return err
+	}
+	s.setAgents(config, agentNames)
+	s.graph = s.build(s)
+	s.version++
+	return nil
+}
@@ -0,0 +1,128 @@
+package swarm
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// handoffAgent hands off to target without replying.
+func handoffAgent(target string) invokerFunc {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		state.ActiveAgent = target
+		return state, nil
+	}
+}
+
+func TestAddAgent(t *testing.T) {
+	workflow, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: handoffAgent("Carol")}},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	input := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")}}
+
+	result, err := app.Invoke(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("Expected no reply before Carol is added, got %d messages", len(result.Messages))
+	}
+
+	if err := workflow.AddAgent(context.Background(), Agent{Name: "Carol", Runnable: createMockAgent("Carol", "Hello")}); err != nil {
+		t.Fatalf("AddAgent() error = %v", err)
+	}
+	result, err = app.Invoke(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(result.Messages) != 2 || result.ActiveAgent != "Carol" {
+		t.Errorf("Expected Carol to reply, got %d messages and active agent %q", len(result.Messages), result.ActiveAgent)
+	}
+
+	if err := workflow.AddAgent(context.Background(), Agent{Name: "Carol", Runnable: createMockAgent("Carol", "Hello")}); err == nil {
+		t.Error("Expected an error for a duplicate agent")
+	}
+	if got := workflow.AgentNames(); len(got) != 2 {
+		t.Errorf("AgentNames() = %v after a failed AddAgent", got)
+	}
+}
+
+func TestRemoveAgent(t *testing.T) {
+	workflow, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi"), Destinations: []string{"Bob"}},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+			{Name: "Carol", Runnable: createMockAgent("Carol", "Hello")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+
+	for _, name := range []string{"Alice", "Bob", "Dave"} {
+		if err := workflow.RemoveAgent(name); err == nil {
+			t.Errorf("RemoveAgent(%s) succeeded", name)
+		}
+	}
+	if err := workflow.RemoveAgent("Carol"); err != nil {
+		t.Fatalf("RemoveAgent(Carol) error = %v", err)
+	}
+	if _, ok := workflow.Agent("Carol"); ok {
+		t.Error("Carol is still registered")
+	}
+	if got := workflow.AgentNames(); len(got) != 2 {
+		t.Errorf("AgentNames() = %v", got)
+	}
+}
+
+func TestAddAgentConcurrentInvoke(t *testing.T) {
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")}},
+		DefaultActiveAgent: "Alice",
+	})
+	workflow := app.Swarm()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := app.Invoke(context.Background(), SwarmState{}); err != nil {
+				t.Errorf("Invoke() error = %v", err)
+			}
+			name := string(rune('A' + i))
+			if err := workflow.AddAgent(context.Background(), Agent{Name: name, Runnable: createMockAgent(name, "Hi")}); err != nil {
+				t.Errorf("AddAgent() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(workflow.AgentNames()); got != 9 {
+		t.Errorf("Expected 9 agents, got %d", got)
+	}
+}
+
+func TestSupervisorAgentsFixed(t *testing.T) {
+	workflow, err := CreateSupervisor(SupervisorConfig{
+		Supervisor: Agent{Name: "supervisor", Runnable: createMockAgent("supervisor", "Done")},
+		Workers:    []Agent{{Name: "worker", Runnable: createMockAgent("worker", "Hi")}},
+	})
+	if err != nil {
+		t.Fatalf("CreateSupervisor() error = %v", err)
+	}
+	if err := workflow.AddAgent(context.Background(), Agent{Name: "other", Runnable: createMockAgent("other", "Hi")}); err == nil {
+		t.Error("Expected AddAgent to fail on a supervisor swarm")
+	}
+}
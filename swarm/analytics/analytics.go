@@ -0,0 +1,458 @@
+// Package analytics aggregates the handoffs of many swarm runs, to show how
+// conversations actually flow through a topology: which agents hand off to
+// which, how many hops a conversation takes, and where conversations dead
+// end. For prompt experiments (see swarm.PromptRegistry) it also counts the
+// runs and dead ends of each prompt version and experiment flag.
+//
+// A Collector is a swarm.TraceExporter; add it to SwarmConfig.TraceExporters
+// and read its Report at any time, or serve it as JSON with Handler:
+//
+//	collector, err := analytics.New(analytics.WithFile("handoffs.json"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	s, err := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents:         agents,
+//	    TraceExporters: []swarm.TraceExporter{collector},
+//	})
+//	...
+//	report := collector.Report()
+//	report.WriteText(os.Stdout)
+package analytics
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// Stats are the counts a Collector aggregates. They are saved as JSON by
+// WithFile.
+type Stats struct {
+	// Runs is the number of runs recorded
+	Runs int `json:"runs"`
+	// Handoffs counts the handoffs from one agent (first key) to another
+	Handoffs map[string]map[string]int `json:"handoffs"`
+	// Threads counts the handoffs of each thread
+	Threads map[string]int `json:"threads"`
+	// Unthreaded is the number of runs without a thread, each a
+	// conversation of its own
+	Unthreaded int `json:"unthreaded"`
+	// Endings counts the runs ending at each agent
+	Endings map[string]int `json:"endings"`
+	// DeadEnds counts the dead-end runs ending at each agent
+	DeadEnds map[string]int `json:"dead_ends"`
+	// Prompts counts the runs of each agent (first key) by prompt version
+	// (see swarm.TraceStep.PromptVersion)
+	Prompts map[string]map[string]int `json:"prompts,omitempty"`
+	// PromptDeadEnds counts the dead-end runs ending at each agent (first
+	// key) by the prompt version of its last run
+	PromptDeadEnds map[string]map[string]int `json:"prompt_dead_ends,omitempty"`
+	// Experiments counts the runs with each experiment flag
+	Experiments map[string]int `json:"experiments,omitempty"`
+	// ExperimentDeadEnds counts the dead-end runs with each experiment flag
+	ExperimentDeadEnds map[string]int `json:"experiment_dead_ends,omitempty"`
+}
+
+// Option configures a Collector created by New.
+type Option func(*Collector)
+
+// WithFile persists the stats of the collector to the JSON file at path:
+// New loads them if the file exists, and each recorded run saves them.
+func WithFile(path string) Option {
+	return func(c *Collector) {
+		c.path = path
+	}
+}
+
+// Collector aggregates the handoffs of swarm runs. It is safe for
+// concurrent use.
+type Collector struct {
+	path string
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New returns a Collector, with the stats saved in its file if WithFile is
+// set.
+func New(opts ...Option) (*Collector, error) {
+	c := &Collector{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.path != "" {
+		data, err := os.ReadFile(c.path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("failed to read stats: %w", err)
+		default:
+			if err := json.Unmarshal(data, &c.stats); err != nil {
+				return nil, fmt.Errorf("invalid stats file %s: %w", c.path, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// ExportTrace records the run of trace, and saves the stats when WithFile
+// is set.
+func (c *Collector) ExportTrace(ctx context.Context, trace *swarm.Trace) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(trace)
+	if c.path == "" {
+		return nil
+	}
+	return c.save()
+}
+
+// Record adds the run of trace to the stats. Runs paused for a human's
+// approval are not counted until they finish.
+func (c *Collector) Record(trace *swarm.Trace) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record(trace)
+}
+
+// record adds the run of trace to the stats; c.mu must be held.
+func (c *Collector) record(trace *swarm.Trace) {
+	if trace.Interrupted {
+		return
+	}
+	s := &c.stats
+	s.Runs++
+	var hops int
+	for _, step := range trace.Steps {
+		if step.PromptVersion != "" {
+			s.Prompts = incrementVersion(s.Prompts, step.Agent, step.PromptVersion)
+		}
+		if step.HandoffTo == "" {
+			continue
+		}
+		hops++
+		if s.Handoffs == nil {
+			s.Handoffs = make(map[string]map[string]int)
+		}
+		if s.Handoffs[step.Agent] == nil {
+			s.Handoffs[step.Agent] = make(map[string]int)
+		}
+		s.Handoffs[step.Agent][step.HandoffTo]++
+	}
+	if trace.ThreadID == "" {
+		s.Unthreaded++
+	} else {
+		s.Threads = increment(s.Threads, trace.ThreadID, hops)
+	}
+
+	final := trace.FinalAgent
+	if final == "" && len(trace.Steps) > 0 {
+		final = trace.Steps[len(trace.Steps)-1].Agent
+	}
+	s.Endings = increment(s.Endings, final, 1)
+	deadEnd := DeadEnd(trace)
+	if deadEnd {
+		s.DeadEnds = increment(s.DeadEnds, final, 1)
+		if n := len(trace.Steps); n > 0 && trace.Steps[n-1].PromptVersion != "" {
+			last := trace.Steps[n-1]
+			s.PromptDeadEnds = incrementVersion(s.PromptDeadEnds, last.Agent, last.PromptVersion)
+		}
+	}
+	for _, flag := range trace.Experiments {
+		s.Experiments = increment(s.Experiments, flag, 1)
+		if deadEnd {
+			s.ExperimentDeadEnds = increment(s.ExperimentDeadEnds, flag, 1)
+		}
+	}
+}
+
+// DeadEnd reports whether the run of trace is a dead end: it failed, or it
+// ended without answering the user.
+func DeadEnd(trace *swarm.Trace) bool {
+	return !trace.Interrupted && (trace.Error != "" || trace.Output == "")
+}
+
+// increment adds n to counts[key], allocating counts if needed.
+func increment(counts map[string]int, key string, n int) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[key] += n
+	return counts
+}
+
+// incrementVersion adds 1 to counts[agent][version], allocating counts if
+// needed.
+func incrementVersion(counts map[string]map[string]int, agent, version string) map[string]map[string]int {
+	if counts == nil {
+		counts = make(map[string]map[string]int)
+	}
+	counts[agent] = increment(counts[agent], version, 1)
+	return counts
+}
+
+// save writes the stats to the collector's file; c.mu must be held.
+func (c *Collector) save() error {
+	data, err := json.Marshal(c.stats)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename for atomic replacement
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create stats file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save stats: %w", err)
+	}
+	return nil
+}
+
+// Stats returns a copy of the stats collected so far.
+func (c *Collector) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Handoffs = cloneCounts(c.stats.Handoffs)
+	stats.Threads = maps.Clone(c.stats.Threads)
+	stats.Endings = maps.Clone(c.stats.Endings)
+	stats.DeadEnds = maps.Clone(c.stats.DeadEnds)
+	stats.Prompts = cloneCounts(c.stats.Prompts)
+	stats.PromptDeadEnds = cloneCounts(c.stats.PromptDeadEnds)
+	stats.Experiments = maps.Clone(c.stats.Experiments)
+	stats.ExperimentDeadEnds = maps.Clone(c.stats.ExperimentDeadEnds)
+	return stats
+}
+
+// cloneCounts returns a deep copy of counts.
+func cloneCounts(counts map[string]map[string]int) map[string]map[string]int {
+	if counts == nil {
+		return nil
+	}
+	clone := make(map[string]map[string]int, len(counts))
+	for key, inner := range counts {
+		clone[key] = maps.Clone(inner)
+	}
+	return clone
+}
+
+// Report summarizes the stats of a Collector.
+type Report struct {
+	// Runs is the number of runs recorded
+	Runs int `json:"runs"`
+	// Conversations is the number of threads, plus the runs without one
+	Conversations int `json:"conversations"`
+	// Handoffs is the handoff matrix: the number of handoffs from one agent
+	// (first key) to another
+	Handoffs map[string]map[string]int `json:"handoffs"`
+	// AverageHops is the average number of handoffs per conversation
+	AverageHops float64 `json:"average_hops"`
+	// DeadEnds is the number of dead-end runs (see DeadEnd)
+	DeadEnds int `json:"dead_ends"`
+	// DeadEndRate is the share of runs that are dead ends
+	DeadEndRate float64 `json:"dead_end_rate"`
+	// Agents are the figures of each agent, by name
+	Agents []AgentReport `json:"agents"`
+	// Prompts are the figures of each prompt version, by agent and version
+	Prompts []PromptReport `json:"prompts,omitempty"`
+	// Experiments are the figures of each experiment flag, by flag
+	Experiments []ExperimentReport `json:"experiments,omitempty"`
+}
+
+// AgentReport holds the figures of an agent in a Report.
+type AgentReport struct {
+	Name string `json:"name"`
+	// HandoffsIn and HandoffsOut count the handoffs to and from the agent
+	HandoffsIn  int `json:"handoffs_in"`
+	HandoffsOut int `json:"handoffs_out"`
+	// Endings is the number of runs ending at the agent
+	Endings int `json:"endings"`
+	// DeadEnds is the number of those runs that are dead ends
+	DeadEnds int `json:"dead_ends"`
+	// DeadEndRate is the share of the runs ending at the agent that are
+	// dead ends
+	DeadEndRate float64 `json:"dead_end_rate"`
+}
+
+// PromptReport holds the figures of a version of an agent's prompt in a
+// Report.
+type PromptReport struct {
+	Agent   string `json:"agent"`
+	Version string `json:"version"`
+	// Runs is the number of runs of the agent with the version
+	Runs int `json:"runs"`
+	// DeadEnds is the number of dead-end runs of the swarm ending with such
+	// a run
+	DeadEnds int `json:"dead_ends"`
+	// DeadEndRate is DeadEnds per run of the agent with the version
+	DeadEndRate float64 `json:"dead_end_rate"`
+}
+
+// ExperimentReport holds the figures of an experiment flag in a Report.
+type ExperimentReport struct {
+	Flag string `json:"flag"`
+	// Runs is the number of runs with the flag
+	Runs int `json:"runs"`
+	// DeadEnds is the number of those runs that are dead ends
+	DeadEnds int `json:"dead_ends"`
+	// DeadEndRate is the share of the runs with the flag that are dead ends
+	DeadEndRate float64 `json:"dead_end_rate"`
+}
+
+// Report returns the report of the stats collected so far.
+func (c *Collector) Report() *Report {
+	stats := c.Stats()
+	report := &Report{
+		Runs:          stats.Runs,
+		Conversations: len(stats.Threads) + stats.Unthreaded,
+		Handoffs:      stats.Handoffs,
+	}
+	agents := make(map[string]*AgentReport)
+	agent := func(name string) *AgentReport {
+		if agents[name] == nil {
+			agents[name] = &AgentReport{Name: name}
+		}
+		return agents[name]
+	}
+	var hops int
+	for from, counts := range stats.Handoffs {
+		for to, n := range counts {
+			hops += n
+			agent(from).HandoffsOut += n
+			agent(to).HandoffsIn += n
+		}
+	}
+	for name, n := range stats.Endings {
+		agent(name).Endings = n
+	}
+	for name, n := range stats.DeadEnds {
+		agent(name).DeadEnds = n
+		agent(name).DeadEndRate = ratio(n, stats.Endings[name])
+		report.DeadEnds += n
+	}
+	report.AverageHops = ratio(hops, report.Conversations)
+	report.DeadEndRate = ratio(report.DeadEnds, report.Runs)
+
+	for _, a := range agents {
+		report.Agents = append(report.Agents, *a)
+	}
+	slices.SortFunc(report.Agents, func(a, b AgentReport) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for agent, versions := range stats.Prompts {
+		for version, runs := range versions {
+			deadEnds := stats.PromptDeadEnds[agent][version]
+			report.Prompts = append(report.Prompts, PromptReport{
+				Agent: agent, Version: version, Runs: runs, DeadEnds: deadEnds, DeadEndRate: ratio(deadEnds, runs),
+			})
+		}
+	}
+	slices.SortFunc(report.Prompts, func(a, b PromptReport) int {
+		return cmp.Or(strings.Compare(a.Agent, b.Agent), strings.Compare(a.Version, b.Version))
+	})
+	for flag, runs := range stats.Experiments {
+		deadEnds := stats.ExperimentDeadEnds[flag]
+		report.Experiments = append(report.Experiments, ExperimentReport{
+			Flag: flag, Runs: runs, DeadEnds: deadEnds, DeadEndRate: ratio(deadEnds, runs),
+		})
+	}
+	slices.SortFunc(report.Experiments, func(a, b ExperimentReport) int {
+		return strings.Compare(a.Flag, b.Flag)
+	})
+	return report
+}
+
+// ratio returns n/total, or 0 when total is 0.
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// WriteText writes the report to w as a summary followed by the handoff
+// matrix, with a row per source agent and a column per destination.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d runs, %d conversations, %.2f hops per conversation, %d dead ends (%.1f%%)\n",
+		r.Runs, r.Conversations, r.AverageHops, r.DeadEnds, 100*r.DeadEndRate)
+	if len(r.Agents) > 0 {
+		b.WriteString("\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		header := []string{"from \\ to"}
+		for _, to := range r.Agents {
+			header = append(header, to.Name)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t")+"\tdead ends")
+		for _, from := range r.Agents {
+			row := []string{from.Name}
+			for _, to := range r.Agents {
+				row = append(row, fmt.Sprint(r.Handoffs[from.Name][to.Name]))
+			}
+			row = append(row, fmt.Sprintf("%d/%d", from.DeadEnds, from.Endings))
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		tw.Flush()
+	}
+	if len(r.Prompts) > 0 {
+		b.WriteString("\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "agent\tprompt\truns\tdead ends")
+		for _, p := range r.Prompts {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d (%.1f%%)\n", p.Agent, p.Version, p.Runs, p.DeadEnds, 100*p.DeadEndRate)
+		}
+		tw.Flush()
+	}
+	if len(r.Experiments) > 0 {
+		b.WriteString("\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "experiment\truns\tdead ends")
+		for _, e := range r.Experiments {
+			fmt.Fprintf(tw, "%s\t%d\t%d (%.1f%%)\n", e.Flag, e.Runs, e.DeadEnds, 100*e.DeadEndRate)
+		}
+		tw.Flush()
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Handler returns an http.Handler serving the collector's Report as JSON.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		c.Report().WriteJSON(w)
+	})
+}
@@ -0,0 +1,167 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+)
+
+// run returns the trace of a run on thread that went through agents, in
+// order, and answered unless answer is empty.
+func run(thread, answer string, agents ...string) *swarm.Trace {
+	trace := &swarm.Trace{ThreadID: thread, Output: answer, FinalAgent: agents[len(agents)-1]}
+	for i, agent := range agents {
+		step := swarm.TraceStep{Agent: agent}
+		if i+1 < len(agents) {
+			step.HandoffTo = agents[i+1]
+		}
+		trace.Steps = append(trace.Steps, step)
+	}
+	return trace
+}
+
+func TestCollectorReport(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Record(run("t1", "Hi", "Alice", "Bob"))
+	c.Record(run("t1", "", "Bob", "Alice"))
+	c.Record(run("", "Hi", "Alice", "Bob", "Carol"))
+	c.Record(&swarm.Trace{Interrupted: true, Steps: []swarm.TraceStep{{Agent: "Alice", HandoffTo: "Bob"}}})
+
+	report := c.Report()
+	if report.Runs != 3 || report.Conversations != 2 || report.AverageHops != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.Handoffs["Alice"]["Bob"] != 2 || report.Handoffs["Bob"]["Alice"] != 1 || report.Handoffs["Bob"]["Carol"] != 1 {
+		t.Errorf("handoffs = %v", report.Handoffs)
+	}
+	if report.DeadEnds != 1 || report.DeadEndRate != 1.0/3 {
+		t.Errorf("dead ends = %d (%v)", report.DeadEnds, report.DeadEndRate)
+	}
+	want := []AgentReport{
+		{Name: "Alice", HandoffsIn: 1, HandoffsOut: 2, Endings: 1, DeadEnds: 1, DeadEndRate: 1},
+		{Name: "Bob", HandoffsIn: 2, HandoffsOut: 2, Endings: 1},
+		{Name: "Carol", HandoffsIn: 1, Endings: 1},
+	}
+	if len(report.Agents) != len(want) {
+		t.Fatalf("agents = %+v", report.Agents)
+	}
+	for i := range want {
+		if report.Agents[i] != want[i] {
+			t.Errorf("agents[%d] = %+v, want %+v", i, report.Agents[i], want[i])
+		}
+	}
+
+	var text strings.Builder
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"3 runs, 2 conversations, 2.00 hops per conversation, 1 dead ends (33.3%)", "Alice      0      2    0      1/1"} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("WriteText() = %s, want %q", text.String(), line)
+		}
+	}
+}
+
+func TestCollectorPromptExperiments(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	withVersion := func(trace *swarm.Trace, version string, flags ...string) *swarm.Trace {
+		for i := range trace.Steps {
+			trace.Steps[i].PromptVersion = version
+		}
+		trace.Experiments = flags
+		return trace
+	}
+	c.Record(withVersion(run("t1", "Hi", "Alice"), "v1"))
+	c.Record(withVersion(run("t2", "", "Alice"), "v1"))
+	c.Record(withVersion(run("t3", "Hi", "Alice"), "v2", "terse"))
+	c.Record(withVersion(run("t4", "Hi", "Alice"), "v2", "terse"))
+
+	report := c.Report()
+	wantPrompts := []PromptReport{
+		{Agent: "Alice", Version: "v1", Runs: 2, DeadEnds: 1, DeadEndRate: 0.5},
+		{Agent: "Alice", Version: "v2", Runs: 2},
+	}
+	if len(report.Prompts) != len(wantPrompts) || report.Prompts[0] != wantPrompts[0] || report.Prompts[1] != wantPrompts[1] {
+		t.Errorf("prompts = %+v, want %+v", report.Prompts, wantPrompts)
+	}
+	if len(report.Experiments) != 1 || report.Experiments[0] != (ExperimentReport{Flag: "terse", Runs: 2}) {
+		t.Errorf("experiments = %+v", report.Experiments)
+	}
+
+	var text strings.Builder
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Alice  v1      2     1 (50.0%)", "terse       2     0 (0.0%)"} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("WriteText() = %s, want %q", text.String(), line)
+		}
+	}
+}
+
+func TestCollectorFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handoffs.json")
+	c, err := New(WithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ExportTrace(context.Background(), run("t1", "Hi", "Alice", "Bob")); err != nil {
+		t.Fatalf("ExportTrace() error = %v", err)
+	}
+
+	reloaded, err := New(WithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.ExportTrace(context.Background(), run("t2", "Hi", "Alice", "Bob")); err != nil {
+		t.Fatalf("ExportTrace() error = %v", err)
+	}
+	if report := reloaded.Report(); report.Runs != 2 || report.Handoffs["Alice"]["Bob"] != 2 {
+		t.Errorf("report = %+v, want the saved runs", report)
+	}
+}
+
+func TestCollectorExportsSwarmRuns(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := swarmtest.Compile(t, swarm.SwarmConfig{
+		Agents: []swarm.Agent{
+			{Name: "Alice", Runnable: swarmtest.NewMockAgent(swarmtest.Reply{HandoffTo: "Bob"})},
+			{Name: "Bob", Runnable: swarmtest.NewMockAgent(swarmtest.Reply{Text: "Ahoy"})},
+		},
+		DefaultActiveAgent: "Alice",
+		TraceExporters:     []swarm.TraceExporter{c},
+	})
+	if _, err := app.Invoke(context.Background(), swarmtest.UserMessage("Hi")); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	server := httptest.NewServer(c.Handler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if report.Runs != 1 || report.Handoffs["Alice"]["Bob"] != 1 || report.DeadEnds != 0 {
+		t.Errorf("report = %+v", report)
+	}
+}
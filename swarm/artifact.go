@@ -0,0 +1,251 @@
+package swarm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrArtifactNotFound is matched (with errors.Is) by the errors of an
+// ArtifactStore, and of LoadArtifact, about an artifact it does not have.
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// ErrNoArtifactStore is returned by SaveArtifact and LoadArtifact in runs
+// of a swarm without SwarmConfig.Artifacts.
+var ErrNoArtifactStore = errors.New("no artifact store configured")
+
+// ArtifactURIScheme prefixes the references to artifacts that agents pass to
+// tools, e.g. "artifact://report".
+const ArtifactURIScheme = "artifact://"
+
+// ArtifactStore keeps the content of large artifacts (fetched documents,
+// generated reports) out of the swarm state, which only holds ArtifactRefs
+// to them. The swarm/artifact package provides stores over S3 and Google
+// Cloud Storage.
+type ArtifactStore interface {
+	// Put saves data under key, replacing any artifact saved under it
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns the artifact saved under key, or an error matching
+	// ErrArtifactNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the artifact saved under key, if any
+	Delete(ctx context.Context, key string) error
+}
+
+// ArtifactRef refers to an artifact of SwarmState.Artifacts kept in the
+// swarm's ArtifactStore.
+type ArtifactRef struct {
+	// Name is the name agents know the artifact by
+	Name string `json:"name"`
+	// Key is the key of the content in the ArtifactStore
+	Key string `json:"key"`
+	// ContentType is the media type of the content, e.g. "text/html"
+	ContentType string `json:"content_type,omitempty"`
+	// Size is the size of the content in bytes
+	Size int `json:"size"`
+}
+
+// URI returns the reference to the artifact that agents pass to tools,
+// which ToolNodes replace with its content.
+func (r ArtifactRef) URI() string {
+	return ArtifactURIScheme + r.Name
+}
+
+// String describes the artifact for a model, e.g. as the result of the tool
+// that saved it.
+func (r ArtifactRef) String() string {
+	if r.ContentType == "" {
+		return fmt.Sprintf("%s (%d bytes)", r.URI(), r.Size)
+	}
+	return fmt.Sprintf("%s (%s, %d bytes)", r.URI(), r.ContentType, r.Size)
+}
+
+// SetArtifact adds ref to the artifacts of the state, replacing any
+// artifact of the same name. The map is copied before it is written, so
+// states sharing it are not affected.
+func (s *SwarmState) SetArtifact(ref ArtifactRef) {
+	artifacts := make(map[string]ArtifactRef, len(s.Artifacts)+1)
+	maps.Copy(artifacts, s.Artifacts)
+	artifacts[ref.Name] = ref
+	s.Artifacts = artifacts
+}
+
+// mergeArtifacts is the default "artifacts" reducer: it adds the artifacts
+// of update to those of current, replacing those of the same name.
+func mergeArtifacts(current, update any) (any, error) {
+	cur, _ := current.(map[string]ArtifactRef)
+	upd, ok := update.(map[string]ArtifactRef)
+	if !ok && update != nil {
+		return nil, fmt.Errorf("artifacts reducer: unexpected update type %T", update)
+	}
+	if len(upd) == 0 {
+		return cur, nil
+	}
+	merged := make(map[string]ArtifactRef, len(cur)+len(upd))
+	maps.Copy(merged, cur)
+	maps.Copy(merged, upd)
+	return merged, nil
+}
+
+// artifactStoreKey is the context key of the swarm's ArtifactStore.
+type artifactStoreKey struct{}
+
+// withArtifactStore returns a copy of ctx carrying the swarm's artifact
+// store.
+func withArtifactStore(ctx context.Context, store ArtifactStore) context.Context {
+	return context.WithValue(ctx, artifactStoreKey{}, store)
+}
+
+// ArtifactStoreFromContext returns the ArtifactStore of the swarm running
+// in ctx, if it has one.
+func ArtifactStoreFromContext(ctx context.Context) (ArtifactStore, bool) {
+	store, ok := ctx.Value(artifactStoreKey{}).(ArtifactStore)
+	return store, ok
+}
+
+// SaveArtifact saves data in the swarm's ArtifactStore and adds a reference
+// to it, under name, to the state of the tool call. Tools call it to keep
+// large results out of the conversation, and return the reference instead:
+//
+//	ref, err := swarm.SaveArtifact(ctx, "report", report, "text/markdown")
+//	if err != nil {
+//	    return "", err
+//	}
+//	return "Saved the report as " + ref.String(), nil
+//
+// The content is stored under its SHA-256 digest, so saving the same
+// content twice stores it once.
+func SaveArtifact(ctx context.Context, name string, data []byte, contentType string) (ArtifactRef, error) {
+	store, ok := ArtifactStoreFromContext(ctx)
+	if !ok {
+		return ArtifactRef{}, ErrNoArtifactStore
+	}
+	ref, err := putArtifact(ctx, store, name, data, contentType)
+	if err != nil {
+		return ArtifactRef{}, err
+	}
+	if ts, ok := toolStateFromContext(ctx); ok {
+		ts.mu.Lock()
+		ts.state.SetArtifact(ref)
+		ts.mu.Unlock()
+	}
+	return ref, nil
+}
+
+// putArtifact saves data in store under its SHA-256 digest and returns a
+// reference to it named name.
+func putArtifact(ctx context.Context, store ArtifactStore, name string, data []byte, contentType string) (ArtifactRef, error) {
+	digest := sha256.Sum256(data)
+	ref := ArtifactRef{Name: name, Key: "sha256/" + hex.EncodeToString(digest[:]), ContentType: contentType, Size: len(data)}
+	if err := store.Put(ctx, ref.Key, data, contentType); err != nil {
+		return ArtifactRef{}, fmt.Errorf("save artifact '%s': %w", name, err)
+	}
+	return ref, nil
+}
+
+// LoadArtifact returns the content of the artifact named name of the state
+// of the tool call.
+func LoadArtifact(ctx context.Context, name string) ([]byte, error) {
+	store, ok := ArtifactStoreFromContext(ctx)
+	if !ok {
+		return nil, ErrNoArtifactStore
+	}
+	ts, ok := toolStateFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, name)
+	}
+	ts.mu.Lock()
+	ref, ok := ts.state.Artifacts[name]
+	ts.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, name)
+	}
+	return store.Get(ctx, ref.Key)
+}
+
+// artifactURIPattern matches the artifact references in tool arguments.
+var artifactURIPattern = regexp.MustCompile(regexp.QuoteMeta(ArtifactURIScheme) + `[\w.\-]+`)
+
+// hydrateArtifacts replaces the references to the artifacts of state in the
+// JSON arguments of a tool call with their content, so that tools receive
+// the artifacts agents refer to. References to unknown artifacts are left
+// as they are.
+func hydrateArtifacts(ctx context.Context, state SwarmState, arguments string) (string, error) {
+	store, ok := ArtifactStoreFromContext(ctx)
+	if !ok || len(state.Artifacts) == 0 || !strings.Contains(arguments, ArtifactURIScheme) {
+		return arguments, nil
+	}
+	var err error
+	hydrated := artifactURIPattern.ReplaceAllStringFunc(arguments, func(uri string) string {
+		ref, ok := state.Artifacts[strings.TrimPrefix(uri, ArtifactURIScheme)]
+		if !ok || err != nil {
+			return uri
+		}
+		var data []byte
+		if data, err = store.Get(ctx, ref.Key); err != nil {
+			err = fmt.Errorf("load artifact '%s': %w", ref.Name, err)
+			return uri
+		}
+		// The content is escaped to stay inside the JSON string it replaces
+		quoted, _ := json.Marshal(string(data))
+		return string(quoted[1 : len(quoted)-1])
+	})
+	if err != nil {
+		return arguments, err
+	}
+	return hydrated, nil
+}
+
+// MemoryArtifactStore is an ArtifactStore keeping artifacts in memory, for
+// tests and single-process deployments. It is safe for concurrent use.
+type MemoryArtifactStore struct {
+	mu        sync.RWMutex
+	artifacts map[string][]byte
+}
+
+// NewMemoryArtifactStore returns an empty MemoryArtifactStore.
+func NewMemoryArtifactStore() *MemoryArtifactStore {
+	return &MemoryArtifactStore{artifacts: make(map[string][]byte)}
+}
+
+// Put saves a copy of data under key.
+func (m *MemoryArtifactStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.artifacts[key] = slices.Clone(data)
+	return nil
+}
+
+// Get returns a copy of the artifact saved under key.
+func (m *MemoryArtifactStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.artifacts[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, key)
+	}
+	return slices.Clone(data), nil
+}
+
+// Delete removes the artifact saved under key.
+func (m *MemoryArtifactStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.artifacts, key)
+	return nil
+}
+
+// Keys returns the keys of the stored artifacts, sorted.
+func (m *MemoryArtifactStore) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Sorted(maps.Keys(m.artifacts))
+}
@@ -0,0 +1,123 @@
+// Package artifact provides swarm.ArtifactStores over object storage, so
+// that the large artifacts agents produce (fetched documents, generated
+// reports) are kept out of checkpoints: the swarm state only holds
+// references to them, which tools get replaced with the content.
+//
+// S3 works with Amazon S3 and S3-compatible services (MinIO, Cloudflare R2)
+// and GCS with Google Cloud Storage:
+//
+//	store := artifact.NewS3("my-bucket", "eu-west-1", artifact.S3Credentials{
+//	    AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+//	    SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+//	}, artifact.WithPrefix("swarm/"))
+//	s, err := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents:    agents,
+//	    Artifacts: store,
+//	})
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// ErrArtifactTooLarge is returned for objects larger than the maximum size
+// of a store (see WithMaxSize).
+var ErrArtifactTooLarge = errors.New("artifact too large")
+
+const (
+	// maxErrorBody is the number of bytes of an error response quoted in a
+	// store error.
+	maxErrorBody = 512
+
+	defaultTimeout = time.Minute
+	defaultMaxSize = 64 << 20
+)
+
+// Option configures an S3 or GCS store.
+type Option func(*store)
+
+// WithEndpoint sends requests to endpoint instead of the service's public
+// API, e.g. to a MinIO server or an emulator. S3 stores address buckets by
+// path on custom endpoints.
+func WithEndpoint(endpoint string) Option {
+	return func(s *store) {
+		s.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithHTTPClient sends requests with client (default: a client with a
+// one-minute timeout). Use it to set timeouts or transports.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *store) {
+		s.client = client
+	}
+}
+
+// WithMaxSize sets the number of bytes of the largest artifact read from
+// the store (default: 64 MiB). Larger objects fail with an error matching
+// ErrArtifactTooLarge.
+func WithMaxSize(n int64) Option {
+	return func(s *store) {
+		s.maxSize = n
+	}
+}
+
+// WithPrefix stores artifacts under keys starting with prefix, e.g.
+// "swarm/", to share a bucket with other data.
+func WithPrefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+// store holds the settings shared by the S3 and GCS stores.
+type store struct {
+	endpoint string
+	client   *http.Client
+	prefix   string
+	maxSize  int64
+}
+
+// newStore returns the settings of a store whose public API is at endpoint.
+func newStore(endpoint string, opts []Option) store {
+	s := store{endpoint: endpoint, client: &http.Client{Timeout: defaultTimeout}, maxSize: defaultMaxSize}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// do sends req and returns the body of its response. Responses with status
+// 404 yield an error matching swarm.ErrArtifactNotFound, and bodies larger
+// than the maximum size one matching ErrArtifactTooLarge.
+func (s *store) do(req *http.Request, key string) ([]byte, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > s.maxSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrArtifactTooLarge, key, s.maxSize)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", swarm.ErrArtifactNotFound, key)
+	case resp.StatusCode >= 300:
+		if len(body) > maxErrorBody {
+			body = body[:maxErrorBody]
+		}
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,101 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// DefaultGCSEndpoint is the Google Cloud Storage API.
+const DefaultGCSEndpoint = "https://storage.googleapis.com"
+
+// TokenFunc returns an OAuth 2.0 access token for the requests of a GCS
+// store, e.g. from an oauth2.TokenSource. It is called for every request,
+// so it should cache its tokens.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenFunc always returning token. A nil TokenFunc
+// sends unauthenticated requests, e.g. to an emulator.
+func StaticToken(token string) TokenFunc {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// GCS is a swarm.ArtifactStore keeping artifacts as objects of a Google
+// Cloud Storage bucket, through its JSON API.
+type GCS struct {
+	store
+	bucket string
+	token  TokenFunc
+}
+
+var _ swarm.ArtifactStore = (*GCS)(nil)
+
+// NewGCS returns a store over bucket, authenticated with the tokens of
+// token.
+func NewGCS(bucket string, token TokenFunc, opts ...Option) *GCS {
+	return &GCS{store: newStore(DefaultGCSEndpoint, opts), bucket: bucket, token: token}
+}
+
+// Put uploads data as the object key.
+func (g *GCS) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	query := url.Values{"uploadType": {"media"}, "name": {g.prefix + key}}
+	req, err := g.request(ctx, http.MethodPost, "/upload/storage/v1/b/"+url.PathEscape(g.bucket)+"/o?"+query.Encode(), data)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	_, err = g.do(req, key)
+	return err
+}
+
+// Get downloads the object key.
+func (g *GCS) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := g.request(ctx, http.MethodGet, g.objectPath(key)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	return g.do(req, key)
+}
+
+// Delete deletes the object key.
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	req, err := g.request(ctx, http.MethodDelete, g.objectPath(key), nil)
+	if err != nil {
+		return err
+	}
+	if _, err := g.do(req, key); err != nil && !errors.Is(err, swarm.ErrArtifactNotFound) {
+		return err
+	}
+	return nil
+}
+
+// objectPath returns the API path of the object key.
+func (g *GCS) objectPath(key string) string {
+	return "/storage/v1/b/" + url.PathEscape(g.bucket) + "/o/" + url.PathEscape(g.prefix+key)
+}
+
+// request returns an authenticated request to the API path.
+func (g *GCS) request(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, g.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if g.token != nil {
+		token, err := g.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("gcs token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
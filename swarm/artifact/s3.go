@@ -0,0 +1,189 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// S3Credentials are the AWS credentials an S3 store signs its requests
+// with.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials
+	SessionToken string
+}
+
+// S3 is a swarm.ArtifactStore keeping artifacts as objects of an S3 bucket.
+// Requests are signed with AWS Signature Version 4.
+type S3 struct {
+	store
+	bucket      string
+	region      string
+	credentials S3Credentials
+	// pathStyle addresses the bucket in the path, on custom endpoints
+	pathStyle bool
+}
+
+var _ swarm.ArtifactStore = (*S3)(nil)
+
+// NewS3 returns a store over bucket, in region, authenticated with
+// credentials.
+func NewS3(bucket, region string, credentials S3Credentials, opts ...Option) *S3 {
+	s := &S3{
+		store:       newStore(fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region), opts),
+		bucket:      bucket,
+		region:      region,
+		credentials: credentials,
+	}
+	s.pathStyle = !strings.HasPrefix(s.endpoint, "https://"+bucket+".")
+	return s
+}
+
+// Put uploads data as the object key.
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, data, time.Now())
+	_, err = s.do(req, key)
+	return err
+}
+
+// Get downloads the object key.
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, nil, time.Now())
+	return s.do(req, key)
+}
+
+// Delete deletes the object key.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, nil, time.Now())
+	if _, err := s.do(req, key); err != nil && !errors.Is(err, swarm.ErrArtifactNotFound) {
+		return err
+	}
+	return nil
+}
+
+// request returns an unsigned request for the object key.
+func (s *S3) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	path := "/" + s.prefix + key
+	if s.pathStyle {
+		path = "/" + s.bucket + path
+	}
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	u.Path, u.RawPath = path, uriEncode(path, false)
+	return http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+}
+
+// sign adds the AWS Signature Version 4 of req, whose body is payload, made
+// at t, to its headers. Every header of req is signed.
+func (s *S3) sign(req *http.Request, payload []byte, t time.Time) {
+	digest := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(digest[:])
+	amzDate := t.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.credentials.SecretAccessKey)
+	for _, part := range []string{amzDate[:8], s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the query of a request as signed: parameters
+// sorted by name and value, with names and values URI-encoded.
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode encodes s as AWS signatures expect: every byte but unreserved
+// characters is percent-encoded, and so is "/" if encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestArtifacts(t *testing.T) {
+	fetch := NewStructTool("fetch_page", "Fetches a page.", func(ctx context.Context, args struct {
+		URL string `json:"url"`
+	}) (string, error) {
+		ref, err := SaveArtifact(ctx, "page", []byte(`<p>"Quarterly" results</p>`), "text/html")
+		if err != nil {
+			return "", err
+		}
+		return "Saved the page as " + ref.String(), nil
+	})
+	var summarized string
+	summarize := NewStructTool("summarize", "Summarizes a document.", func(ctx context.Context, args struct {
+		Document string `json:"document"`
+	}) (string, error) {
+		summarized = args.Document
+		return "Results are up.", nil
+	})
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "fetch_page", `{"url":"https://example.com"}`),
+		toolCallChoice("call_2", "summarize", `{"document":"artifact://page"}`),
+		{Content: "Results are up."},
+	}}
+	agent, err := CreateReactAgent(model, []tools.Tool{fetch, summarize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryArtifactStore()
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Researcher", Runnable: agent}},
+		DefaultActiveAgent: "Researcher",
+		Artifacts:          store,
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Summarize the results"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	ref, ok := result.Artifacts["page"]
+	if !ok || ref.ContentType != "text/html" || ref.Size != 26 || !strings.HasPrefix(ref.Key, "sha256/") {
+		t.Fatalf("Artifacts = %+v", result.Artifacts)
+	}
+	if summarized != `<p>"Quarterly" results</p>` {
+		t.Errorf("summarize received %q, want the hydrated artifact", summarized)
+	}
+	if keys := store.Keys(); len(keys) != 1 || keys[0] != ref.Key {
+		t.Errorf("store keys = %v", keys)
+	}
+	for _, message := range result.Messages {
+		for _, part := range message.Parts {
+			if response, ok := part.(llms.ToolCallResponse); ok && strings.Contains(response.Content, "Quarterly") {
+				t.Errorf("the artifact content is in the conversation: %q", response.Content)
+			}
+		}
+	}
+
+	data, err := MarshalState(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Artifacts["page"] != ref {
+		t.Errorf("restored Artifacts = %+v", restored.Artifacts)
+	}
+}
+
+func TestArtifactErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := SaveArtifact(ctx, "page", []byte("x"), ""); !errors.Is(err, ErrNoArtifactStore) {
+		t.Errorf("SaveArtifact() without store error = %v, want ErrNoArtifactStore", err)
+	}
+	store := NewMemoryArtifactStore()
+	ctx = withArtifactStore(ctx, store)
+	if _, err := LoadArtifact(ctx, "page"); !errors.Is(err, ErrArtifactNotFound) {
+		t.Errorf("LoadArtifact() of a missing artifact error = %v, want ErrArtifactNotFound", err)
+	}
+
+	state := SwarmState{}
+	state.SetArtifact(ArtifactRef{Name: "page", Key: "sha256/missing"})
+	if _, err := hydrateArtifacts(ctx, state, `{"input":"artifact://page"}`); !errors.Is(err, ErrArtifactNotFound) {
+		t.Errorf("hydrateArtifacts() of a lost artifact error = %v, want ErrArtifactNotFound", err)
+	}
+	if got, err := hydrateArtifacts(ctx, state, `{"input":"artifact://other"}`); err != nil || got != `{"input":"artifact://other"}` {
+		t.Errorf("hydrateArtifacts() of an unknown reference = %q, %v, want it unchanged", got, err)
+	}
+}
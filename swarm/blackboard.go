@@ -0,0 +1,197 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ErrBlackboardKeyNotFound is returned by BlackboardValue when the blackboard
+// has no entry for the key.
+var ErrBlackboardKeyNotFound = errors.New("blackboard key not found")
+
+// ErrNoBlackboard is returned by blackboard tools called outside of a
+// ToolNode, which gives them the blackboard of the state.
+var ErrNoBlackboard = errors.New("no blackboard in context")
+
+// SetBlackboard sets the blackboard entry for key. The map is copied before
+// it is written, so states sharing it (such as the one given to an agent) are
+// not affected.
+func (s *SwarmState) SetBlackboard(key string, value any) {
+	board := make(map[string]any, len(s.Blackboard)+1)
+	maps.Copy(board, s.Blackboard)
+	board[key] = value
+	s.Blackboard = board
+}
+
+// BlackboardValue returns the blackboard entry of state for key as a T.
+// Entries of another type, such as the maps a state restored from JSON
+// holds, are converted through their JSON encoding.
+//
+// Example:
+//
+//	plan, err := swarm.BlackboardValue[TravelPlan](result, "plan")
+func BlackboardValue[T any](state SwarmState, key string) (T, error) {
+	var zero T
+	value, ok := state.Blackboard[key]
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrBlackboardKeyNotFound, key)
+	}
+	return convertValue[T](key, value)
+}
+
+// convertValue returns value as a T, converting it through its JSON encoding
+// when it has another type.
+func convertValue[T any](key string, value any) (T, error) {
+	var result T
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return result, fmt.Errorf("blackboard '%s': %w", key, err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("blackboard '%s' is not a %T: %w", key, result, err)
+	}
+	return result, nil
+}
+
+// AppendSlices is a ReducerFunc for blackboard or Values entries holding
+// slices: it appends the elements of update to those of current. Both must
+// be slices of the same type.
+//
+// Example:
+//
+//	swarm.SwarmConfig{
+//	    BlackboardReducers: map[string]swarm.ReducerFunc{"search_results": swarm.AppendSlices},
+//	}
+func AppendSlices(current, update any) (any, error) {
+	if current == nil {
+		return update, nil
+	}
+	if update == nil {
+		return current, nil
+	}
+	cur, upd := reflect.ValueOf(current), reflect.ValueOf(update)
+	if cur.Kind() != reflect.Slice || cur.Type() != upd.Type() {
+		return nil, fmt.Errorf("append reducer: cannot append %T to %T", update, current)
+	}
+	merged := reflect.MakeSlice(cur.Type(), 0, cur.Len()+upd.Len())
+	return reflect.AppendSlice(reflect.AppendSlice(merged, cur), upd).Interface(), nil
+}
+
+// reduceBlackboard merges the blackboard returned by an agent into the one
+// it was given. Only the entries the agent added or changed are merged, with
+// the reducer configured for their key (LastWriteWins by default); entries
+// the agent dropped are kept.
+func reduceBlackboard(reducers map[string]ReducerFunc, current, update map[string]any) (map[string]any, error) {
+	var result map[string]any
+	for key, value := range update {
+		old, ok := current[key]
+		if ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		reducer := LastWriteWins
+		if r, ok := reducers[key]; ok {
+			reducer = r
+		}
+		merged, err := reducer(old, value)
+		if err != nil {
+			return current, fmt.Errorf("blackboard '%s': %w", key, err)
+		}
+		if result == nil {
+			result = maps.Clone(current)
+			if result == nil {
+				result = make(map[string]any)
+			}
+		}
+		result[key] = merged
+	}
+	if result == nil {
+		return current, nil
+	}
+	return result, nil
+}
+
+// blackboardFromContext returns the state of a tool call, whose blackboard
+// the blackboard tools read and write.
+func blackboardFromContext(ctx context.Context) (*toolState, error) {
+	board, ok := toolStateFromContext(ctx)
+	if !ok {
+		return nil, ErrNoBlackboard
+	}
+	return board, nil
+}
+
+// blackboardWriteArgs are the arguments of a write_<key> tool.
+type blackboardWriteArgs[T any] struct {
+	Value T `json:"value" jsonschema:"description=The new value"`
+}
+
+// BlackboardTools returns the read_<key> and write_<key> tools, with which
+// agents exchange a structured artifact (a plan, search results, a draft)
+// through the blackboard entry for key instead of chat messages. The
+// description says what the entry holds, e.g. "the travel plan".
+//
+// Example:
+//
+//	type Plan struct {
+//	    Steps []string `json:"steps"`
+//	}
+//
+//	planTools := swarm.BlackboardTools[Plan]("plan", "the plan of the trip")
+//	planner, _ := swarm.CreateReactAgent(model, planTools)
+func BlackboardTools[T any](key, description string) []tools.Tool {
+	return []tools.Tool{BlackboardReadTool[T](key, description), BlackboardWriteTool[T](key, description)}
+}
+
+// BlackboardReadTool returns the read_<key> tool, which returns the
+// blackboard entry for key as JSON.
+func BlackboardReadTool[T any](key, description string) tools.Tool {
+	return NewStructTool("read_"+key, fmt.Sprintf("Read %s from the shared blackboard.", description),
+		func(ctx context.Context, _ struct{}) (string, error) {
+			board, err := blackboardFromContext(ctx)
+			if err != nil {
+				return "", err
+			}
+			board.mu.Lock()
+			value, ok := board.state.Blackboard[key]
+			board.mu.Unlock()
+			if !ok {
+				return fmt.Sprintf("The blackboard has no %s yet.", key), nil
+			}
+			typed, err := convertValue[T](key, value)
+			if err != nil {
+				return "", err
+			}
+			data, err := json.Marshal(typed)
+			if err != nil {
+				return "", fmt.Errorf("blackboard '%s': %w", key, err)
+			}
+			return string(data), nil
+		})
+}
+
+// BlackboardWriteTool returns the write_<key> tool, which replaces the
+// blackboard entry for key with the value given by the model. The entry is
+// merged into the swarm state with the reducer configured for key in
+// SwarmConfig.BlackboardReducers.
+func BlackboardWriteTool[T any](key, description string) tools.Tool {
+	return NewStructTool("write_"+key, fmt.Sprintf("Write %s to the shared blackboard for the other agents.", description),
+		func(ctx context.Context, args blackboardWriteArgs[T]) (string, error) {
+			board, err := blackboardFromContext(ctx)
+			if err != nil {
+				return "", err
+			}
+			board.mu.Lock()
+			board.state.SetBlackboard(key, args.Value)
+			board.mu.Unlock()
+			return fmt.Sprintf("Saved %s to the blackboard.", key), nil
+		})
+}
@@ -0,0 +1,108 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+type testPlan struct {
+	Steps []string `json:"steps"`
+}
+
+func TestBlackboardTools(t *testing.T) {
+	planner := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "write_plan", `{"value":{"steps":["book flight","book hotel"]}}`),
+		toolCallChoice("call_2", "transfer_to_writer", `{}`),
+	}}
+	writer := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_3", "read_plan", `{}`),
+		{Content: "Your trip is planned."},
+	}}
+	planTools := BlackboardTools[testPlan]("plan", "the plan of the trip")
+	plannerAgent, err := CreateReactAgent(planner, append([]tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "writer"})}, planTools...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writerAgent, err := CreateReactAgent(writer, planTools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "planner", Runnable: plannerAgent},
+			{Name: "writer", Runnable: writerAgent},
+		},
+		DefaultActiveAgent: "planner",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Plan my trip"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	plan, err := BlackboardValue[testPlan](result, "plan")
+	if err != nil || !reflect.DeepEqual(plan.Steps, []string{"book flight", "book hotel"}) {
+		t.Errorf("plan = %+v, %v", plan, err)
+	}
+	read := writer.calls[1][len(writer.calls[1])-1].Parts[0].(llms.ToolCallResponse)
+	if read.Content != `{"steps":["book flight","book hotel"]}` {
+		t.Errorf("read_plan = %q", read.Content)
+	}
+
+	if _, err := planTools[0].Call(context.Background(), `{}`); !errors.Is(err, ErrNoBlackboard) {
+		t.Errorf("Call() outside a tool node error = %v, want ErrNoBlackboard", err)
+	}
+}
+
+func TestBlackboardValue(t *testing.T) {
+	var state SwarmState
+	state.SetBlackboard("plan", testPlan{Steps: []string{"a"}})
+	data, err := MarshalState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan, err := BlackboardValue[testPlan](restored, "plan"); err != nil || plan.Steps[0] != "a" {
+		t.Errorf("BlackboardValue() = %+v, %v", plan, err)
+	}
+	if _, err := BlackboardValue[testPlan](restored, "draft"); !errors.Is(err, ErrBlackboardKeyNotFound) {
+		t.Errorf("BlackboardValue() of a missing key error = %v", err)
+	}
+	if _, err := BlackboardValue[int](restored, "plan"); err == nil {
+		t.Error("BlackboardValue() of a mistyped entry should return an error")
+	}
+}
+
+func TestReduceBlackboard(t *testing.T) {
+	current := map[string]any{"results": []string{"a"}, "draft": "v1", "plan": "p"}
+	update := map[string]any{"results": []string{"b"}, "draft": "v2", "plan": "p"}
+
+	merged, err := reduceBlackboard(map[string]ReducerFunc{"results": AppendSlices}, current, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"results": []string{"a", "b"}, "draft": "v2", "plan": "p"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged = %v, want %v", merged, want)
+	}
+	if current["draft"] != "v1" {
+		t.Error("reduceBlackboard() modified the current blackboard")
+	}
+
+	// Unchanged entries are not reduced again
+	if merged, _ := reduceBlackboard(map[string]ReducerFunc{"results": AppendSlices}, want, want); !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged = %v, want %v", merged, want)
+	}
+	if _, err := reduceBlackboard(map[string]ReducerFunc{"draft": AppendSlices}, current, update); err == nil {
+		t.Error("reduceBlackboard() appending strings should return an error")
+	}
+}
@@ -0,0 +1,179 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrBudgetExceeded is matched (with errors.Is) by the *BudgetExceededError
+// a run stopped for when it exceeded SwarmConfig.Budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget limits the resources a single run may use across all its agents.
+// A zero limit is no limit.
+//
+// The budget is checked before each model call and handoff, never in the
+// middle of one: a run over budget finishes the tool calls in progress, then
+// stops with a message telling the user it was cut short, and the result
+// keeps what the agents did so far (see InvokeResult.Truncated). A single
+// model call may therefore take a run past its limits.
+type Budget struct {
+	// MaxTokens limits the input and output tokens of the run's model calls
+	MaxTokens int
+	// MaxUSD limits the cost of the run's model calls, as computed by Cost
+	MaxUSD float64
+	// MaxDuration limits the wall-clock time of the run
+	MaxDuration time.Duration
+	// Cost returns the cost in USD of the tokens a model call of agent used,
+	// e.g. TokenPrice(2.5, 10). It is required with MaxUSD.
+	Cost func(agent string, usage Usage) float64
+}
+
+// unlimited reports whether the budget sets no limit.
+func (b Budget) unlimited() bool {
+	return b.MaxTokens <= 0 && b.MaxUSD <= 0 && b.MaxDuration <= 0
+}
+
+// TokenPrice returns a Budget.Cost charging the same price for every agent,
+// given in USD per million input and output tokens.
+//
+// Example:
+//
+//	Budget: swarm.Budget{MaxUSD: 0.50, Cost: swarm.TokenPrice(2.5, 10)}
+func TokenPrice(inputPerMillion, outputPerMillion float64) func(agent string, usage Usage) float64 {
+	return func(agent string, usage Usage) float64 {
+		return (float64(usage.InputTokens)*inputPerMillion + float64(usage.OutputTokens)*outputPerMillion) / 1e6
+	}
+}
+
+// BudgetExceededError reports a run stopped by its Budget.
+type BudgetExceededError struct {
+	// Limit is the exceeded limit: "tokens", "usd" or "duration"
+	Limit string
+	// Usage counts the model calls of the run when it stopped
+	Usage Usage
+	// Cost is the cost of the run in USD when it stopped, if it has a Cost
+	Cost float64
+	// Elapsed is how long the run had taken when it stopped
+	Elapsed time.Duration
+	// Budget is the exceeded budget
+	Budget Budget
+}
+
+// Error describes the exceeded limit.
+func (e *BudgetExceededError) Error() string {
+	switch e.Limit {
+	case "tokens":
+		return fmt.Sprintf("%v: %d tokens used of %d", ErrBudgetExceeded, e.Usage.TotalTokens(), e.Budget.MaxTokens)
+	case "usd":
+		return fmt.Sprintf("%v: $%.4f spent of $%.4f", ErrBudgetExceeded, e.Cost, e.Budget.MaxUSD)
+	default:
+		return fmt.Sprintf("%v: ran for %s of %s", ErrBudgetExceeded, e.Elapsed.Round(time.Millisecond), e.Budget.MaxDuration)
+	}
+}
+
+// Is reports whether target is ErrBudgetExceeded.
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// budgetKey is the context key of the budgetTracker of a run.
+type budgetKey struct{}
+
+// budgetTracker accounts for the resources used by a run against its
+// budget.
+type budgetTracker struct {
+	budget Budget
+	start  time.Time
+
+	mu    sync.Mutex
+	usage Usage
+	cost  float64
+	// exceeded is set once the budget is exceeded, and stays set
+	exceeded *BudgetExceededError
+}
+
+// withBudget returns a context accounting for the run's resources against
+// budget, starting now.
+func withBudget(ctx context.Context, budget Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &budgetTracker{budget: budget, start: time.Now()})
+}
+
+// recordBudget adds the usage of a model call of agent to the run's budget,
+// if it has one.
+func recordBudget(ctx context.Context, agent string, usage Usage) {
+	t, ok := ctx.Value(budgetKey{}).(*budgetTracker)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage = t.usage.add(usage)
+	if t.budget.Cost != nil {
+		t.cost += t.budget.Cost(agent, usage)
+	}
+}
+
+// budgetExceeded returns the error of the run's exceeded budget, or nil
+// while it is within budget.
+func budgetExceeded(ctx context.Context) error {
+	t, ok := ctx.Value(budgetKey{}).(*budgetTracker)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+
+	elapsed := time.Since(t.start)
+	var limit string
+	switch {
+	case t.budget.MaxTokens > 0 && t.usage.TotalTokens() >= t.budget.MaxTokens:
+		limit = "tokens"
+	case t.budget.MaxUSD > 0 && t.cost >= t.budget.MaxUSD:
+		limit = "usd"
+	case t.budget.MaxDuration > 0 && elapsed >= t.budget.MaxDuration:
+		limit = "duration"
+	default:
+		return nil
+	}
+	t.exceeded = &BudgetExceededError{Limit: limit, Usage: t.usage, Cost: t.cost, Elapsed: elapsed, Budget: t.budget}
+	return t.exceeded
+}
+
+// runStopped returns the reason the run must not start another model call
+// or agent, or nil.
+func runStopped(ctx context.Context) error {
+	if err := deadlineExceeded(ctx); err != nil {
+		return err
+	}
+	return budgetExceeded(ctx)
+}
+
+// runTruncated returns the reason the run was stopped early, or nil if it
+// was not. Unlike runStopped, it does not check the limits again.
+func runTruncated(ctx context.Context) error {
+	if err := deadlineTruncated(ctx); err != nil {
+		return err
+	}
+	if t, ok := ctx.Value(budgetKey{}).(*budgetTracker); ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.exceeded != nil {
+			return t.exceeded
+		}
+	}
+	return nil
+}
+
+// stopMessage returns the AI message ending a run stopped for cause.
+func stopMessage(cause error) llms.MessageContent {
+	return llms.TextParts(llms.ChatMessageTypeAI, fmt.Sprintf("I had to stop before finishing: %v.", cause))
+}
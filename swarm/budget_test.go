@@ -0,0 +1,112 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// withTokens sets the token usage reported for a model response.
+func withTokens(choice *llms.ContentChoice, input, output int) *llms.ContentChoice {
+	choice.GenerationInfo = map[string]any{"PromptTokens": input, "CompletionTokens": output}
+	return choice
+}
+
+func TestBudgetTokens(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		withTokens(toolCallChoice("call_1", "upper", `{"input":"hello"}`), 50, 10),
+		{Content: "HELLO"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{upperTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}},
+		DefaultActiveAgent: "Alice",
+		Budget:             Budget{MaxTokens: 50},
+	})
+
+	result, err := app.InvokeWithResult(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Shout hello"),
+	}})
+	if err != nil {
+		t.Fatalf("InvokeWithResult() error = %v", err)
+	}
+	var exceeded *BudgetExceededError
+	if !errors.As(result.Truncated, &exceeded) || exceeded.Limit != "tokens" || !errors.Is(result.Truncated, ErrBudgetExceeded) {
+		t.Fatalf("Truncated = %v, want a token budget error", result.Truncated)
+	}
+	if len(model.calls) != 1 {
+		t.Errorf("model calls = %d, want none after the budget is exceeded", len(model.calls))
+	}
+	// The tool call in progress finishes before the run stops
+	if len(result.NewMessages) != 3 || result.NewMessages[1].Role != llms.ChatMessageTypeTool {
+		t.Fatalf("new messages = %+v", result.NewMessages)
+	}
+	if got := lastText(result.State); !strings.Contains(got, "60 tokens used of 50") {
+		t.Errorf("last message = %q", got)
+	}
+	if result.Trace().Truncated == "" {
+		t.Error("trace does not tell the run was truncated")
+	}
+}
+
+func TestBudgetStopsHandoffs(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		withTokens(toolCallChoice("call_1", "transfer_to_bob", `{"task_description":"Help"}`), 8, 2),
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Hi from Bob")},
+		},
+		DefaultActiveAgent: "Alice",
+		Budget:             Budget{MaxUSD: 0.01, Cost: TokenPrice(1000, 1000)},
+	})
+
+	result, err := app.InvokeWithResult(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Get Bob"),
+	}})
+	if err != nil {
+		t.Fatalf("InvokeWithResult() error = %v", err)
+	}
+	if got := lastText(result.State); !strings.Contains(got, "$0.0100 spent of $0.0100") {
+		t.Errorf("last message = %q, want Bob not to run", got)
+	}
+	if result.FinalAgent != "Bob" || len(result.Handoffs) != 1 {
+		t.Errorf("final agent = %s, handoffs = %+v, want the handoff kept for the next turn", result.FinalAgent, result.Handoffs)
+	}
+
+	// Within budget, the run is not truncated
+	app = compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Bob", Runnable: createMockAgent("Bob", "Hi from Bob")}},
+		DefaultActiveAgent: "Bob",
+		Budget:             Budget{MaxTokens: 1},
+	})
+	result, err = app.InvokeWithResult(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+	}})
+	if err != nil || result.Truncated != nil {
+		t.Errorf("InvokeWithResult() truncated = %v, error = %v", result.Truncated, err)
+	}
+}
+
+func TestBudgetValidation(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Bob", Runnable: createMockAgent("Bob", "Hi")}},
+		DefaultActiveAgent: "Bob",
+		Budget:             Budget{MaxUSD: 1},
+	})
+	if err == nil {
+		t.Error("CreateSwarm() with a MaxUSD and no Cost should return an error")
+	}
+}
@@ -0,0 +1,107 @@
+// Package cache serves repeated tool calls and model prompts of a swarm from
+// a cache, cutting the latency and cost of identical work, such as fetching
+// the same URL twice during a research run.
+//
+// Tool wraps a tool so that calls with the same arguments (compared as JSON,
+// whatever the key order or spacing) return the cached result; Model wraps a
+// model so that identical prompts with identical call options return the
+// cached response. Wrap the tools or model of the agents that should cache:
+//
+//	c := cache.NewMemory(1000)
+//	researcher, _ := swarm.CreateReactAgent(cache.Model(model, "gpt-4o", c, time.Hour),
+//	    cache.Tools([]tools.Tool{fetchDoc, search}, c, 24*time.Hour))
+//
+// Memory keeps entries in process; Redis shares them between replicas.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache stores values by key for a time. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, and false if there is none or
+	// it expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl; a ttl of 0 never expires
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Memory is an in-process Cache that evicts the least recently used entries
+// beyond its capacity.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// memoryEntry is an entry of a Memory cache.
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory creates a Memory cache holding up to maxEntries entries, or any
+// number when maxEntries is 0.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the value stored under key, unless it expired.
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for ttl, evicting the least recently used
+// entry when the cache is full.
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	if element, ok := m.entries[key]; ok {
+		element.Value = &memoryEntry{key: key, value: value, expires: expires}
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired ones
+// not yet evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
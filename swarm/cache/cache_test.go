@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// countingTool echoes its input and counts its calls.
+type countingTool struct {
+	calls int
+}
+
+func (t *countingTool) Name() string        { return "fetch_doc" }
+func (t *countingTool) Description() string { return "Fetches a document" }
+func (t *countingTool) Call(ctx context.Context, input string) (string, error) {
+	t.calls++
+	return "doc " + input, nil
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(2)
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	c.Get(ctx, "a")
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if value, ok, _ := c.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("Get(a) = %q, %v", value, ok)
+	}
+
+	_ = c.Set(ctx, "d", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "d"); ok {
+		t.Error("expired entry was returned")
+	}
+}
+
+func TestTool(t *testing.T) {
+	fetch := &countingTool{}
+	cached := Tool(fetch, NewMemory(0), time.Hour)
+	first, err := cached.Call(context.Background(), `{"url": "https://example.com", "raw": false}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cached.Call(context.Background(), `{"raw":false,"url":"https://example.com"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetch.calls != 1 || second != first {
+		t.Errorf("%d calls, results %q and %q", fetch.calls, first, second)
+	}
+	if _, _ = cached.Call(context.Background(), `{"url":"https://example.org"}`); fetch.calls != 2 {
+		t.Errorf("different arguments were served from the cache")
+	}
+
+	handoff := swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: "Bob"})
+	if Tool(handoff, NewMemory(0), time.Hour) != handoff {
+		t.Error("handoff tools must not be cached")
+	}
+}
+
+func TestModel(t *testing.T) {
+	mock := swarmtest.NewMockModel(swarmtest.Text("Paris"), swarmtest.Text("Lyon"))
+	model := Model(mock, "mock", NewMemory(0), time.Hour)
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Capital of France?")}
+
+	if _, err := model.GenerateContent(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+	var streamed string
+	response, err := model.GenerateContent(context.Background(), messages, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.Calls()) != 1 || response.Choices[0].Content != "Paris" || streamed != "Paris" {
+		t.Errorf("%d model calls, content %q, streamed %q", len(mock.Calls()), response.Choices[0].Content, streamed)
+	}
+
+	if response, _ := model.GenerateContent(context.Background(), messages, llms.WithTemperature(0.5)); response.Choices[0].Content != "Lyon" {
+		t.Errorf("different call options were served from the cache")
+	}
+}
+
+func TestModelNames(t *testing.T) {
+	c := NewMemory(0)
+	large := Model(swarmtest.NewMockModel(swarmtest.Text("Paris")), "gpt-4o", c, time.Hour)
+	small := Model(swarmtest.NewMockModel(swarmtest.Text("Lyon")), "gpt-4o-mini", c, time.Hour)
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Capital of France?")}
+
+	if _, err := large.GenerateContent(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+	if response, _ := small.GenerateContent(context.Background(), messages); response.Choices[0].Content != "Lyon" {
+		t.Errorf("another model's response was served from the shared cache")
+	}
+}
+
+func TestModelInSwarm(t *testing.T) {
+	mock := swarmtest.NewMockModel(
+		swarmtest.ToolCall("fetch_doc", `{"url":"https://example.com"}`),
+		swarmtest.Text("Summary"),
+	)
+	c := NewMemory(0)
+	fetch := &countingTool{}
+	agent, err := swarm.CreateReactAgent(Model(mock, "mock", c, time.Hour), Tools([]tools.Tool{fetch}, c, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := swarmtest.Compile(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "researcher", Runnable: agent}},
+		DefaultActiveAgent: "researcher",
+	})
+	for range 2 {
+		result, err := app.Invoke(context.Background(), swarmtest.UserMessage("Summarize example.com"))
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		swarmtest.AssertLastMessageContains(t, result, "Summary")
+	}
+	if len(mock.Calls()) != 2 || fetch.calls != 1 {
+		t.Errorf("%d model calls and %d tool calls for two identical runs", len(mock.Calls()), fetch.calls)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	c := NewRedis(client, WithPrefix("test:"))
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "a"); ok || err != nil {
+		t.Errorf("Get() of a missing key = %v, %v", ok, err)
+	}
+	if err := c.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := c.Get(ctx, "a"); !ok || err != nil || string(value) != "1" {
+		t.Errorf("Get() = %q, %v, %v", value, ok, err)
+	}
+	if !server.Exists("test:a") {
+		t.Error("key was not prefixed")
+	}
+	server.FastForward(2 * time.Minute)
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Error("expired key was returned")
+	}
+}
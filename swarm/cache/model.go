@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// cachedModel is a model whose responses are cached.
+type cachedModel struct {
+	model llms.Model
+	name  string
+	cache Cache
+	ttl   time.Duration
+}
+
+// Model returns m with its responses cached in c for ttl, keyed by name,
+// the prompt messages and the call options, including the tools offered to
+// the model. A failing cache is bypassed.
+//
+// name identifies the model m is configured for, such as "gpt-4o": clients
+// of the same type configured for different models must use different
+// names to share a cache.
+//
+// Cached responses report no token usage, as they cost none. A streaming
+// call served from the cache receives the whole content as a single chunk.
+func Model(m llms.Model, name string, c Cache, ttl time.Duration) llms.Model {
+	return &cachedModel{model: m, name: name, cache: c, ttl: ttl}
+}
+
+// GenerateContent returns the cached response to messages, or asks the
+// model and caches its response.
+func (m *cachedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	key, keyErr := m.key(messages, opts)
+	if keyErr == nil {
+		if value, ok, err := m.cache.Get(ctx, key); err == nil && ok {
+			var choices []choiceJSON
+			if err := json.Unmarshal(value, &choices); err == nil && len(choices) > 0 {
+				response := &llms.ContentResponse{Choices: make([]*llms.ContentChoice, len(choices))}
+				for i, choice := range choices {
+					response.Choices[i] = choice.decode()
+				}
+				if opts.StreamingFunc != nil && choices[0].Content != "" {
+					if err := opts.StreamingFunc(ctx, []byte(choices[0].Content)); err != nil {
+						return nil, err
+					}
+				}
+				return response, nil
+			}
+		}
+	}
+
+	response, err := m.model.GenerateContent(ctx, messages, options...)
+	if err != nil || keyErr != nil {
+		return response, err
+	}
+	choices := make([]choiceJSON, len(response.Choices))
+	for i, choice := range response.Choices {
+		choices[i] = encodeChoice(choice)
+	}
+	if value, err := json.Marshal(choices); err == nil {
+		_ = m.cache.Set(ctx, key, value, m.ttl)
+	}
+	return response, nil
+}
+
+// Call implements the single-prompt interface of llms.Model.
+func (m *cachedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// key returns the cache key of a model call, which covers the model type
+// and name, the messages exactly as swarm.MarshalState encodes them, and
+// the call options.
+func (m *cachedModel) key(messages []llms.MessageContent, opts llms.CallOptions) (string, error) {
+	encoded, err := swarm.MarshalState(swarm.SwarmState{Messages: messages})
+	if err != nil {
+		return "", err
+	}
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%T\n%q\n%s\n%s", m.model, m.name, encoded, options)
+	return "model:" + hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// choiceJSON is the cached form of a response choice. Generation info, such
+// as token usage, is left out.
+type choiceJSON struct {
+	Content          string             `json:"content,omitempty"`
+	StopReason       string             `json:"stop_reason,omitempty"`
+	ReasoningContent string             `json:"reasoning_content,omitempty"`
+	FuncCall         *llms.FunctionCall `json:"func_call,omitempty"`
+	ToolCalls        []toolCallJSON     `json:"tool_calls,omitempty"`
+}
+
+// toolCallJSON is the cached form of a tool call, as llms.ToolCall does
+// not decode the JSON it encodes.
+type toolCallJSON struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function *llms.FunctionCall `json:"function,omitempty"`
+}
+
+// encodeChoice returns the cached form of choice.
+func encodeChoice(choice *llms.ContentChoice) choiceJSON {
+	encoded := choiceJSON{
+		Content:          choice.Content,
+		StopReason:       choice.StopReason,
+		ReasoningContent: choice.ReasoningContent,
+		FuncCall:         choice.FuncCall,
+	}
+	for _, call := range choice.ToolCalls {
+		encoded.ToolCalls = append(encoded.ToolCalls, toolCallJSON{ID: call.ID, Type: call.Type, Function: call.FunctionCall})
+	}
+	return encoded
+}
+
+// decode returns the response choice c was encoded from.
+func (c choiceJSON) decode() *llms.ContentChoice {
+	choice := &llms.ContentChoice{
+		Content:          c.Content,
+		StopReason:       c.StopReason,
+		ReasoningContent: c.ReasoningContent,
+		FuncCall:         c.FuncCall,
+	}
+	for _, call := range c.ToolCalls {
+		choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{ID: call.ID, Type: call.Type, FunctionCall: call.Function})
+	}
+	return choice
+}
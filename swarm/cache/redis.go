@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultRedisPrefix is the prefix of the keys of a Redis cache created
+// without WithPrefix.
+const defaultRedisPrefix = "swarm:cache:"
+
+// Redis is a Cache stored in Redis, shared by every process using the same
+// server and prefix.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// RedisOption configures a Redis cache.
+type RedisOption func(*Redis)
+
+// WithPrefix sets the prefix of the keys the cache stores (default:
+// "swarm:cache:").
+func WithPrefix(prefix string) RedisOption {
+	return func(r *Redis) {
+		r.prefix = prefix
+	}
+}
+
+// NewRedis creates a Redis cache using client.
+func NewRedis(client redis.UniversalClient, opts ...RedisOption) *Redis {
+	r := &Redis{client: client, prefix: defaultRedisPrefix}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Get returns the value stored under key.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, letting Redis expire it after ttl.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
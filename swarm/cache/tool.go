@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// cachedTool is a tool whose results are cached.
+type cachedTool struct {
+	tools.Tool
+	cache Cache
+	ttl   time.Duration
+}
+
+// cachedSchemaTool is a cachedTool describing its arguments with a schema.
+type cachedSchemaTool struct {
+	cachedTool
+	schema swarm.SchemaProvider
+}
+
+// Schema returns the schema of the wrapped tool.
+func (t *cachedSchemaTool) Schema() map[string]any {
+	return t.schema.Schema()
+}
+
+// Tool returns t with its results cached in c for ttl, keyed by the tool
+// name and its arguments. Only successful results are cached; a failing
+// cache is bypassed. Handoff tools are returned unchanged.
+//
+// Cache only tools whose result depends on their arguments alone, such as
+// fetching a URL: tools reading the swarm state, like blackboard and task
+// tools, would return stale results.
+func Tool(t tools.Tool, c Cache, ttl time.Duration) tools.Tool {
+	switch t.(type) {
+	case swarm.HandoffTool, swarm.HandoffDestinationsProvider:
+		return t
+	}
+	cached := cachedTool{Tool: t, cache: c, ttl: ttl}
+	if schema, ok := t.(swarm.SchemaProvider); ok {
+		return &cachedSchemaTool{cachedTool: cached, schema: schema}
+	}
+	return &cached
+}
+
+// Tools returns ts with their results cached (see Tool).
+func Tools(ts []tools.Tool, c Cache, ttl time.Duration) []tools.Tool {
+	cached := make([]tools.Tool, len(ts))
+	for i, t := range ts {
+		cached[i] = Tool(t, c, ttl)
+	}
+	return cached
+}
+
+// Call returns the cached result of the call with input, or calls the tool
+// and caches its result.
+func (t *cachedTool) Call(ctx context.Context, input string) (string, error) {
+	key := toolKey(t.Name(), input)
+	if value, ok, err := t.cache.Get(ctx, key); err == nil && ok {
+		return string(value), nil
+	}
+	result, err := t.Tool.Call(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	_ = t.cache.Set(ctx, key, []byte(result), t.ttl)
+	return result, nil
+}
+
+// toolKey returns the cache key of a call of the tool named name with
+// input. JSON arguments are re-encoded, which sorts object keys, so that
+// equal arguments share a key.
+func toolKey(name, input string) string {
+	normalized := strings.TrimSpace(input)
+	var args any
+	if err := json.Unmarshal([]byte(normalized), &args); err == nil {
+		if data, err := json.Marshal(args); err == nil {
+			normalized = string(data)
+		}
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return "tool:" + name + ":" + hex.EncodeToString(sum[:])
+}
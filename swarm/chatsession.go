@@ -0,0 +1,119 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ChatSessionOption configures a ChatSession created by NewChatSession.
+type ChatSessionOption func(*ChatSession)
+
+// WithThread keeps the session's conversation in a thread of the swarm's
+// Checkpointer: each Send continues from the thread's latest checkpoint and
+// saves the result to it, so the conversation survives restarts. Without
+// it the conversation is kept in memory.
+func WithThread(threadID string) ChatSessionOption {
+	return func(s *ChatSession) {
+		s.threadID = threadID
+	}
+}
+
+// WithSessionInvokeOptions passes opts, e.g. WithContext, to every
+// invocation of the session.
+func WithSessionInvokeOptions(opts ...InvokeOption) ChatSessionOption {
+	return func(s *ChatSession) {
+		s.invokeOptions = append(s.invokeOptions, opts...)
+	}
+}
+
+// ChatSession is a multi-turn conversation with a compiled swarm that
+// carries the state from one turn to the next. Its methods may be called
+// concurrently; turns are handled one at a time.
+type ChatSession struct {
+	app           *CompiledSwarm
+	threadID      string
+	invokeOptions []InvokeOption
+
+	mu    sync.Mutex
+	state SwarmState
+}
+
+// Reply is the outcome of a turn of a ChatSession.
+type Reply struct {
+	// Messages are the messages the swarm added during the turn: answers,
+	// tool calls and tool results
+	Messages []llms.MessageContent
+	// Text is the text of the last AI message of the turn
+	Text string
+	// ActiveAgent is the agent that will handle the next turn
+	ActiveAgent string
+}
+
+// NewChatSession creates a session with app.
+//
+// Example:
+//
+//	session := swarm.NewChatSession(app, swarm.WithThread("user_123"))
+//	reply, err := session.Send(ctx, "i'd like to speak to Bob")
+//	fmt.Println(reply.ActiveAgent, reply.Text)
+//	reply, err = session.Send(ctx, "what's 5 + 7?")
+func NewChatSession(app *CompiledSwarm, opts ...ChatSessionOption) *ChatSession {
+	s := &ChatSession{app: app}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Send adds a user message to the conversation, runs the swarm and keeps
+// the resulting state for the next turn. With WithThread the state is
+// loaded from and saved to the thread. Attachments, such as images from
+// ImageFile, are added to the message after text.
+//
+// If the run is interrupted for an approval (see InterruptError), Send
+// returns the turn so far with the error; continue it with
+// CompiledSwarm.Resume on the session's thread.
+func (s *ChatSession) Send(ctx context.Context, text string, attachments ...llms.ContentPart) (Reply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state
+	if s.threadID != "" {
+		if store := s.app.Checkpointer(); store != nil {
+			checkpoint, err := store.Latest(ctx, s.threadID)
+			switch {
+			case errors.Is(err, ErrCheckpointNotFound):
+			case err != nil:
+				return Reply{}, err
+			default:
+				state = checkpoint.State
+			}
+		}
+	}
+	state = state.Clone()
+	state.Messages = append(state.Messages, UserMessage(text, attachments...))
+
+	opts := s.invokeOptions
+	if s.threadID != "" {
+		opts = append(opts[:len(opts):len(opts)], WithThreadID(s.threadID))
+	}
+	result, err := s.app.InvokeWithResult(ctx, state, opts...)
+	if result == nil {
+		return Reply{}, err
+	}
+	s.state = result.State
+
+	reply := Reply{Messages: result.NewMessages, ActiveAgent: result.FinalAgent}
+	_, reply.Text = lastAnswer(reply.Messages)
+	return reply, err
+}
+
+// State returns the conversation's state after the last turn.
+func (s *ChatSession) State() SwarmState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Clone()
+}
@@ -0,0 +1,73 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestChatSession(t *testing.T) {
+	var seen [][]llms.MessageContent
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: echoAgent(&seen)}},
+		DefaultActiveAgent: "Alice",
+	})
+	session := NewChatSession(app)
+	ctx := context.Background()
+
+	reply, err := session.Send(ctx, "Hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if reply.Text != "You said: Hello" || reply.ActiveAgent != "Alice" || len(reply.Messages) != 1 {
+		t.Errorf("first reply = %+v", reply)
+	}
+	reply, err = session.Send(ctx, "Again")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if reply.Text != "You said: Again" || len(reply.Messages) != 1 {
+		t.Errorf("second reply = %+v", reply)
+	}
+	if len(seen[1]) != 3 {
+		t.Errorf("second turn saw %d messages, want the first turn carried over", len(seen[1]))
+	}
+	if got := len(session.State().Messages); got != 4 {
+		t.Errorf("state holds %d messages, want 4", got)
+	}
+}
+
+func TestChatSessionThread(t *testing.T) {
+	var seen [][]llms.MessageContent
+	store := NewMemorySaver()
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: echoAgent(&seen)}},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       store,
+	})
+	ctx := context.Background()
+
+	if _, err := NewChatSession(app, WithThread("user_1")).Send(ctx, "Hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	// A new session on the same thread continues the conversation
+	if _, err := NewChatSession(app, WithThread("user_1")).Send(ctx, "Again"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	checkpoint, err := store.Latest(ctx, "user_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(checkpoint.State.Messages); got != 4 {
+		t.Errorf("thread holds %d messages, want 4", got)
+	}
+
+	noStore := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: echoAgent(&seen)}},
+		DefaultActiveAgent: "Alice",
+	})
+	if _, err := NewChatSession(noStore, WithThread("user_1")).Send(ctx, "Hello"); err == nil {
+		t.Error("Send() on a thread without checkpointer succeeded")
+	}
+}
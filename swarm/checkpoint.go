@@ -0,0 +1,398 @@
+package swarm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCheckpointNotFound is returned when a thread or checkpoint does not exist.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is a saved swarm state for a conversation thread.
+type Checkpoint struct {
+	// ID uniquely identifies the checkpoint; Put assigns one if empty
+	ID string `json:"id"`
+	// ThreadID is the conversation thread the checkpoint belongs to
+	ThreadID string `json:"thread_id"`
+	// State is the swarm state at the time of the checkpoint
+	State SwarmState `json:"state"`
+	// Metadata holds optional application data
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// CreatedAt is set by Put if zero
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CheckpointStore persists swarm checkpoints per conversation thread.
+//
+// Implementations must be safe for concurrent use.
+type CheckpointStore interface {
+	// Put saves a checkpoint, assigning its ID and CreatedAt if unset
+	Put(ctx context.Context, checkpoint *Checkpoint) error
+	// Get returns a specific checkpoint of a thread
+	Get(ctx context.Context, threadID, checkpointID string) (*Checkpoint, error)
+	// Latest returns the most recent checkpoint of a thread
+	Latest(ctx context.Context, threadID string) (*Checkpoint, error)
+	// List returns all checkpoints of a thread, oldest first
+	List(ctx context.Context, threadID string) ([]*Checkpoint, error)
+	// Delete removes all checkpoints of a thread
+	Delete(ctx context.Context, threadID string) error
+}
+
+// ThreadLister is implemented by checkpoint stores that can enumerate their
+// threads. MemorySaver, FileSaver and the SQL store implement it.
+type ThreadLister interface {
+	// Threads returns the IDs of the threads with checkpoints, sorted
+	Threads(ctx context.Context) ([]string, error)
+}
+
+// prepareCheckpoint fills in the ID and timestamp of a checkpoint before it is stored.
+func prepareCheckpoint(checkpoint *Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if checkpoint.ThreadID == "" {
+		return fmt.Errorf("checkpoint thread ID cannot be empty")
+	}
+	if checkpoint.ID == "" {
+		checkpoint.ID = newCheckpointID()
+	}
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+// newCheckpointID returns a random checkpoint identifier.
+func newCheckpointID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// copyCheckpoint returns a copy of checkpoint that shares no slices or maps
+// with it, so callers cannot mutate stored data.
+func copyCheckpoint(checkpoint *Checkpoint) *Checkpoint {
+	cp := *checkpoint
+	cp.State = checkpoint.State.Clone()
+	cp.Metadata = maps.Clone(checkpoint.Metadata)
+	return &cp
+}
+
+// MemorySaver is an in-memory CheckpointStore.
+// Checkpoints are lost when the process exits.
+type MemorySaver struct {
+	mu      sync.RWMutex
+	threads map[string][]*Checkpoint
+}
+
+// NewMemorySaver creates an empty in-memory checkpoint store.
+func NewMemorySaver() *MemorySaver {
+	return &MemorySaver{threads: make(map[string][]*Checkpoint)}
+}
+
+// Put saves a checkpoint.
+func (m *MemorySaver) Put(ctx context.Context, checkpoint *Checkpoint) error {
+	if err := prepareCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.threads[checkpoint.ThreadID] = append(m.threads[checkpoint.ThreadID], copyCheckpoint(checkpoint))
+	return nil
+}
+
+// Get returns a specific checkpoint of a thread.
+func (m *MemorySaver) Get(ctx context.Context, threadID, checkpointID string) (*Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, cp := range m.threads[threadID] {
+		if cp.ID == checkpointID {
+			return copyCheckpoint(cp), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: thread %s, checkpoint %s", ErrCheckpointNotFound, threadID, checkpointID)
+}
+
+// Latest returns the most recent checkpoint of a thread.
+func (m *MemorySaver) Latest(ctx context.Context, threadID string) (*Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	checkpoints := m.threads[threadID]
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("%w: thread %s", ErrCheckpointNotFound, threadID)
+	}
+	return copyCheckpoint(checkpoints[len(checkpoints)-1]), nil
+}
+
+// List returns all checkpoints of a thread, oldest first.
+func (m *MemorySaver) List(ctx context.Context, threadID string) ([]*Checkpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	checkpoints := make([]*Checkpoint, 0, len(m.threads[threadID]))
+	for _, cp := range m.threads[threadID] {
+		checkpoints = append(checkpoints, copyCheckpoint(cp))
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all checkpoints of a thread.
+func (m *MemorySaver) Delete(ctx context.Context, threadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.threads, threadID)
+	return nil
+}
+
+// Threads returns the IDs of the threads with checkpoints, sorted.
+func (m *MemorySaver) Threads(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	threads := make([]string, 0, len(m.threads))
+	for threadID, checkpoints := range m.threads {
+		if len(checkpoints) > 0 {
+			threads = append(threads, threadID)
+		}
+	}
+	slices.Sort(threads)
+	return threads, nil
+}
+
+// FileSaver is a CheckpointStore that writes each checkpoint as a JSON file.
+//
+// Checkpoints are stored under dir/<thread>/, one file per checkpoint, so
+// conversations survive process restarts.
+type FileSaver struct {
+	dir string
+	mu  sync.Mutex
+	seq uint64
+}
+
+// checkpointFile is the content of a FileSaver checkpoint file. The state
+// is written with MarshalState; files written before it hold the state as
+// plain JSON, which UnmarshalState still reads.
+type checkpointFile struct {
+	ID        string          `json:"id"`
+	ThreadID  string          `json:"thread_id"`
+	State     json.RawMessage `json:"state"`
+	Metadata  map[string]any  `json:"metadata,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewFileSaver creates a file-backed checkpoint store rooted at dir,
+// creating the directory if needed.
+func NewFileSaver(dir string) (*FileSaver, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return &FileSaver{dir: dir}, nil
+}
+
+// threadDir returns the directory holding a thread's checkpoints. Thread
+// IDs that would escape the store's directory once escaped ("", "." and
+// "..") are rejected.
+func (f *FileSaver) threadDir(threadID string) (string, error) {
+	name := url.PathEscape(threadID)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid checkpoint thread ID %q", threadID)
+	}
+	return filepath.Join(f.dir, name), nil
+}
+
+// Put saves a checkpoint.
+func (f *FileSaver) Put(ctx context.Context, checkpoint *Checkpoint) error {
+	if err := prepareCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	state, err := MarshalState(checkpoint.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint state: %w", err)
+	}
+	data, err := json.Marshal(checkpointFile{
+		ID:        checkpoint.ID,
+		ThreadID:  checkpoint.ThreadID,
+		State:     state,
+		Metadata:  checkpoint.Metadata,
+		CreatedAt: checkpoint.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dir, err := f.threadDir(checkpoint.ThreadID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create thread directory: %w", err)
+	}
+
+	// File names sort by creation time so List can return them in order
+	f.seq++
+	name := fmt.Sprintf("%020d-%06d-%s.json", checkpoint.CreatedAt.UnixNano(), f.seq%1000000, checkpoint.ID)
+
+	// Write to a temporary file and rename for atomic replacement
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// files returns the checkpoint file paths of a thread, oldest first.
+func (f *FileSaver) files(threadID string) ([]string, error) {
+	dir, err := f.threadDir(threadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read thread directory: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// read loads a checkpoint file.
+func (f *FileSaver) read(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint %s: %w", filepath.Base(path), err)
+	}
+	state, err := UnmarshalState(file.State)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint %s: %w", filepath.Base(path), err)
+	}
+	return &Checkpoint{
+		ID:        file.ID,
+		ThreadID:  file.ThreadID,
+		State:     state,
+		Metadata:  file.Metadata,
+		CreatedAt: file.CreatedAt,
+	}, nil
+}
+
+// Get returns a specific checkpoint of a thread.
+func (f *FileSaver) Get(ctx context.Context, threadID, checkpointID string) (*Checkpoint, error) {
+	paths, err := f.files(threadID)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "-"+checkpointID+".json") {
+			return f.read(path)
+		}
+	}
+	return nil, fmt.Errorf("%w: thread %s, checkpoint %s", ErrCheckpointNotFound, threadID, checkpointID)
+}
+
+// Latest returns the most recent checkpoint of a thread.
+func (f *FileSaver) Latest(ctx context.Context, threadID string) (*Checkpoint, error) {
+	paths, err := f.files(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: thread %s", ErrCheckpointNotFound, threadID)
+	}
+	return f.read(paths[len(paths)-1])
+}
+
+// List returns all checkpoints of a thread, oldest first.
+func (f *FileSaver) List(ctx context.Context, threadID string) ([]*Checkpoint, error) {
+	paths, err := f.files(threadID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]*Checkpoint, 0, len(paths))
+	for _, path := range paths {
+		checkpoint, err := f.read(path)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all checkpoints of a thread.
+func (f *FileSaver) Delete(ctx context.Context, threadID string) error {
+	dir, err := f.threadDir(threadID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete thread: %w", err)
+	}
+	return nil
+}
+
+// Threads returns the IDs of the threads with checkpoints, sorted.
+func (f *FileSaver) Threads(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
+	}
+
+	threads := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		threadID, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		if paths, err := f.files(threadID); err != nil || len(paths) == 0 {
+			continue
+		}
+		threads = append(threads, threadID)
+	}
+	sort.Strings(threads)
+	return threads, nil
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Default lease and polling interval of a Locker.
+const (
+	defaultLease         = 30 * time.Second
+	defaultRetryInterval = 50 * time.Millisecond
+)
+
+// unlockScript deletes a lock if it still holds the caller's token.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// extendScript extends the lease of a lock if it still holds the caller's
+// token.
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// LockerOption configures a Locker created by NewLocker.
+type LockerOption func(*Locker)
+
+// WithLockPrefix sets the prefix of the lock keys (default: "swarm:").
+func WithLockPrefix(prefix string) LockerOption {
+	return func(l *Locker) {
+		l.prefix = prefix
+	}
+}
+
+// WithLease sets how long a lock outlives a holder that stopped renewing
+// it, e.g. because its process crashed (default: 30s). Holders renew their
+// lock while they hold it.
+func WithLease(lease time.Duration) LockerOption {
+	return func(l *Locker) {
+		if lease > 0 {
+			l.lease = lease
+		}
+	}
+}
+
+// WithRetryInterval sets how often a waiting Lock tries to take a held
+// lock (default: 50ms).
+func WithRetryInterval(d time.Duration) LockerOption {
+	return func(l *Locker) {
+		if d > 0 {
+			l.retry = d
+		}
+	}
+}
+
+// Locker serializes the requests of each thread across every process
+// sharing the server and prefix. It implements session.Locker.
+type Locker struct {
+	client redis.UniversalClient
+	prefix string
+	lease  time.Duration
+	retry  time.Duration
+}
+
+// NewLocker creates a Locker using client.
+func NewLocker(client redis.UniversalClient, opts ...LockerOption) *Locker {
+	l := &Locker{client: client, prefix: defaultPrefix, lease: defaultLease, retry: defaultRetryInterval}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Lock waits until the caller holds the lock of key, or ctx is done. The
+// lock is renewed until the returned function releases it.
+func (l *Locker) Lock(ctx context.Context, key string) (func(), error) {
+	key = l.prefix + "lock:" + key
+	token := newID()
+	for {
+		ok, err := l.client.SetNX(ctx, key, token, l.lease).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retry):
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(l.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				extendScript.Run(context.Background(), l.client, []string{key}, token, l.lease.Milliseconds())
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		unlockScript.Run(context.Background(), l.client, []string{key}, token)
+	}, nil
+}
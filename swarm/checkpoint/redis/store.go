@@ -0,0 +1,248 @@
+// Package redis provides a swarm.CheckpointStore backed by Redis, and
+// per-thread locks, so that several replicas of an API can serve the same
+// swarm: every replica sees the threads the others saved, and a Locker
+// passed to session.WithLocker keeps two replicas from running the same
+// thread at once.
+//
+// Threads that receive no checkpoint for longer than the store's TTL are
+// expired by Redis, which reclaims abandoned conversations.
+//
+// Example:
+//
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	workflow, _ := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents:       agents,
+//	    Checkpointer: redisstore.New(client, redisstore.WithTTL(7*24*time.Hour)),
+//	})
+//	app, _ := workflow.Compile()
+//	manager, err := session.New(app, session.WithLocker(redisstore.NewLocker(client)))
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultPrefix is the prefix of the keys of a store created without
+// WithPrefix, and of a Locker created without WithLockPrefix.
+const defaultPrefix = "swarm:"
+
+// Option configures a Store created by New.
+type Option func(*Store)
+
+// WithPrefix sets the prefix of the keys the store uses (default:
+// "swarm:").
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithTTL expires threads that received no checkpoint for longer than ttl.
+// The default 0 keeps threads until they are deleted.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.ttl = ttl
+	}
+}
+
+// Store is a swarm.CheckpointStore keeping the checkpoints of each thread
+// in a Redis list. It is safe for concurrent use, by any number of
+// processes sharing the server and prefix.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+var (
+	_ swarm.CheckpointStore = (*Store)(nil)
+	_ swarm.ThreadLister    = (*Store)(nil)
+)
+
+// New creates a checkpoint store using client.
+func New(client redis.UniversalClient, opts ...Option) *Store {
+	s := &Store{client: client, prefix: defaultPrefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// checkpointJSON is the stored form of a checkpoint.
+type checkpointJSON struct {
+	ID        string          `json:"id"`
+	ThreadID  string          `json:"thread_id"`
+	State     json.RawMessage `json:"state"`
+	Metadata  map[string]any  `json:"metadata,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// threadKey returns the key of the list of a thread's checkpoints.
+func (s *Store) threadKey(threadID string) string {
+	return s.prefix + "thread:" + threadID
+}
+
+// threadsKey returns the key of the set of thread IDs.
+func (s *Store) threadsKey() string {
+	return s.prefix + "threads"
+}
+
+// Put saves a checkpoint, and restarts the TTL of its thread.
+func (s *Store) Put(ctx context.Context, checkpoint *swarm.Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if checkpoint.ThreadID == "" {
+		return fmt.Errorf("checkpoint thread ID cannot be empty")
+	}
+	if checkpoint.ID == "" {
+		checkpoint.ID = newID()
+	}
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now().UTC()
+	}
+
+	state, err := swarm.MarshalState(checkpoint.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint state: %w", err)
+	}
+	data, err := json.Marshal(checkpointJSON{
+		ID:        checkpoint.ID,
+		ThreadID:  checkpoint.ThreadID,
+		State:     state,
+		Metadata:  checkpoint.Metadata,
+		CreatedAt: checkpoint.CreatedAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint metadata: %w", err)
+	}
+
+	key := s.threadKey(checkpoint.ThreadID)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, data)
+		if s.ttl > 0 {
+			pipe.PExpire(ctx, key, s.ttl)
+		}
+		pipe.SAdd(ctx, s.threadsKey(), checkpoint.ThreadID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Get returns a specific checkpoint of a thread.
+func (s *Store) Get(ctx context.Context, threadID, checkpointID string) (*swarm.Checkpoint, error) {
+	checkpoints, err := s.List(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	for _, checkpoint := range checkpoints {
+		if checkpoint.ID == checkpointID {
+			return checkpoint, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: thread %s, checkpoint %s", swarm.ErrCheckpointNotFound, threadID, checkpointID)
+}
+
+// Latest returns the most recent checkpoint of a thread.
+func (s *Store) Latest(ctx context.Context, threadID string) (*swarm.Checkpoint, error) {
+	data, err := s.client.LIndex(ctx, s.threadKey(threadID), -1).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: thread %s", swarm.ErrCheckpointNotFound, threadID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+	return decodeCheckpoint(data)
+}
+
+// List returns all checkpoints of a thread, oldest first.
+func (s *Store) List(ctx context.Context, threadID string) ([]*swarm.Checkpoint, error) {
+	entries, err := s.client.LRange(ctx, s.threadKey(threadID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+	checkpoints := make([]*swarm.Checkpoint, 0, len(entries))
+	for _, entry := range entries {
+		checkpoint, err := decodeCheckpoint([]byte(entry))
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+// Delete removes all checkpoints of a thread.
+func (s *Store) Delete(ctx context.Context, threadID string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.threadKey(threadID))
+		pipe.SRem(ctx, s.threadsKey(), threadID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete thread: %w", err)
+	}
+	return nil
+}
+
+// Threads returns the IDs of the threads with checkpoints, sorted. Threads
+// expired by their TTL are dropped from the list.
+func (s *Store) Threads(ctx context.Context) ([]string, error) {
+	members, err := s.client.SMembers(ctx, s.threadsKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query threads: %w", err)
+	}
+	threads := []string{}
+	for _, threadID := range members {
+		n, err := s.client.Exists(ctx, s.threadKey(threadID)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query threads: %w", err)
+		}
+		if n == 0 {
+			s.client.SRem(ctx, s.threadsKey(), threadID)
+			continue
+		}
+		threads = append(threads, threadID)
+	}
+	slices.Sort(threads)
+	return threads, nil
+}
+
+// decodeCheckpoint decodes a stored checkpoint.
+func decodeCheckpoint(data []byte) (*swarm.Checkpoint, error) {
+	var stored checkpointJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	state, err := swarm.UnmarshalState(stored.State)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint %s: %w", stored.ID, err)
+	}
+	return &swarm.Checkpoint{
+		ID:        stored.ID,
+		ThreadID:  stored.ThreadID,
+		State:     state,
+		Metadata:  stored.Metadata,
+		CreatedAt: stored.CreatedAt.UTC(),
+	}, nil
+}
+
+// newID returns a random identifier, for checkpoints and lock tokens.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
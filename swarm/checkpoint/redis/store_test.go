@@ -0,0 +1,177 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/redis/go-redis/v9"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func newClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func checkpoint(threadID, text string) *swarm.Checkpoint {
+	return &swarm.Checkpoint{
+		ThreadID: threadID,
+		State: swarm.SwarmState{
+			Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, text)},
+			ActiveAgent: "Alice",
+		},
+		Metadata: map[string]any{"tenant": "acme"},
+	}
+}
+
+func TestStore(t *testing.T) {
+	_, client := newClient(t)
+	store := New(client, WithPrefix("test:"))
+	ctx := context.Background()
+
+	if _, err := store.Latest(ctx, "t1"); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+		t.Errorf("Latest() of a missing thread error = %v, want ErrCheckpointNotFound", err)
+	}
+	first, second := checkpoint("t1", "Hello"), checkpoint("t1", "Hello again")
+	for _, c := range []*swarm.Checkpoint{first, second, checkpoint("t2", "Hi")} {
+		if err := store.Put(ctx, c); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	if first.ID == "" || first.ID == second.ID {
+		t.Errorf("Put() assigned IDs %q and %q", first.ID, second.ID)
+	}
+
+	latest, err := store.Latest(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if latest.ID != second.ID || latest.State.ActiveAgent != "Alice" || latest.Metadata["tenant"] != "acme" {
+		t.Errorf("Latest() = %+v, want the second checkpoint", latest)
+	}
+	got, err := store.Get(ctx, "t1", first.ID)
+	if err != nil || got.ID != first.ID {
+		t.Errorf("Get() = %v, %v", got, err)
+	}
+	if _, err := store.Get(ctx, "t1", "missing"); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+		t.Errorf("Get() of a missing checkpoint error = %v, want ErrCheckpointNotFound", err)
+	}
+	if checkpoints, err := store.List(ctx, "t1"); err != nil || len(checkpoints) != 2 || checkpoints[0].ID != first.ID {
+		t.Errorf("List() = %v, %v, want both checkpoints oldest first", checkpoints, err)
+	}
+
+	if threads, err := store.Threads(ctx); err != nil || !slices.Equal(threads, []string{"t1", "t2"}) {
+		t.Errorf("Threads() = %v, %v", threads, err)
+	}
+	if err := store.Delete(ctx, "t1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if threads, _ := store.Threads(ctx); !slices.Equal(threads, []string{"t2"}) {
+		t.Errorf("Threads() after Delete() = %v", threads)
+	}
+	if err := store.Put(ctx, &swarm.Checkpoint{}); err == nil {
+		t.Error("Put() of a checkpoint without thread succeeded")
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	server, client := newClient(t)
+	store := New(client, WithTTL(time.Hour))
+	ctx := context.Background()
+
+	for _, threadID := range []string{"idle", "active"} {
+		if err := store.Put(ctx, checkpoint(threadID, "Hello")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.FastForward(45 * time.Minute)
+	if err := store.Put(ctx, checkpoint("active", "Still here")); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(45 * time.Minute)
+
+	if _, err := store.Latest(ctx, "idle"); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+		t.Errorf("Latest() of an expired thread error = %v, want ErrCheckpointNotFound", err)
+	}
+	if checkpoints, err := store.List(ctx, "active"); err != nil || len(checkpoints) != 2 {
+		t.Errorf("List() of an active thread = %d checkpoints, %v, want 2", len(checkpoints), err)
+	}
+	if threads, err := store.Threads(ctx); err != nil || !slices.Equal(threads, []string{"active"}) {
+		t.Errorf("Threads() = %v, %v, want the expired thread dropped", threads, err)
+	}
+}
+
+func TestLocker(t *testing.T) {
+	server, client := newClient(t)
+	// Two lockers stand for two replicas sharing the server
+	a := NewLocker(client, WithRetryInterval(time.Millisecond))
+	b := NewLocker(client, WithRetryInterval(time.Millisecond))
+	ctx := context.Background()
+
+	unlock, err := a.Lock(ctx, "acme/t1")
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if !server.Exists("swarm:lock:acme/t1") {
+		t.Error("lock key was not set")
+	}
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Lock(timeout, "acme/t1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock() of a held lock error = %v, want context.DeadlineExceeded", err)
+	}
+	other, err := b.Lock(ctx, "acme/t2")
+	if err != nil {
+		t.Fatalf("Lock() of another thread error = %v", err)
+	}
+	other()
+
+	acquired := make(chan func())
+	go func() {
+		unlock, err := b.Lock(ctx, "acme/t1")
+		if err != nil {
+			t.Errorf("Lock() error = %v", err)
+		}
+		acquired <- unlock
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Lock() returned while the lock was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case unlock := <-acquired:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lock() did not return after the lock was released")
+	}
+	if server.Exists("swarm:lock:acme/t1") {
+		t.Error("lock key was not deleted")
+	}
+}
+
+func TestLockerLease(t *testing.T) {
+	server, client := newClient(t)
+	locker := NewLocker(client, WithLease(time.Minute), WithRetryInterval(time.Millisecond))
+	ctx := context.Background()
+
+	if _, err := locker.Lock(ctx, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	// The holder stops renewing, e.g. because its process crashed
+	server.FastForward(2 * time.Minute)
+	unlock, err := locker.Lock(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Lock() of an expired lock error = %v", err)
+	}
+	unlock()
+}
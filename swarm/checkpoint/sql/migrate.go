@@ -0,0 +1,123 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migration is a versioned schema change. Statements may reference the
+// checkpoint table as {{table}}.
+type migration struct {
+	version    int
+	statements map[Dialect][]string
+}
+
+// migrations lists the schema changes in the order they are applied.
+// Append new versions; never edit released ones.
+var migrations = []migration{
+	{
+		version: 1,
+		statements: map[Dialect][]string{
+			SQLite: {
+				`CREATE TABLE IF NOT EXISTS {{table}} (
+					seq INTEGER PRIMARY KEY AUTOINCREMENT,
+					thread_id TEXT NOT NULL,
+					checkpoint_id TEXT NOT NULL,
+					state TEXT NOT NULL,
+					metadata TEXT,
+					created_at TIMESTAMP NOT NULL,
+					UNIQUE (thread_id, checkpoint_id)
+				)`,
+				`CREATE INDEX IF NOT EXISTS {{table}}_thread_idx ON {{table}} (thread_id, seq)`,
+			},
+			Postgres: {
+				`CREATE TABLE IF NOT EXISTS {{table}} (
+					seq BIGSERIAL PRIMARY KEY,
+					thread_id TEXT NOT NULL,
+					checkpoint_id TEXT NOT NULL,
+					state JSONB NOT NULL,
+					metadata JSONB,
+					created_at TIMESTAMPTZ NOT NULL,
+					UNIQUE (thread_id, checkpoint_id)
+				)`,
+				`CREATE INDEX IF NOT EXISTS {{table}}_thread_idx ON {{table}} (thread_id, seq)`,
+			},
+		},
+	},
+}
+
+// migrationsTable returns the name of the table recording applied migrations.
+func (s *Store) migrationsTable() string {
+	return s.table + "_migrations"
+}
+
+// SchemaVersion returns the latest migration applied to the database,
+// or 0 if Migrate has never run.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+s.migrationsTable()).Scan(&version)
+	if isMissingTable(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Migrate creates the checkpoint schema or upgrades it to the latest version.
+// Each migration runs in its own transaction and is recorded so that Migrate
+// is safe to call on every start-up.
+func (s *Store) Migrate(ctx context.Context) error {
+	createMigrations := `CREATE TABLE IF NOT EXISTS ` + s.migrationsTable() +
+		` (version INTEGER PRIMARY KEY, applied_at TIMESTAMP NOT NULL)`
+	if s.dialect == Postgres {
+		createMigrations = `CREATE TABLE IF NOT EXISTS ` + s.migrationsTable() +
+			` (version INTEGER PRIMARY KEY, applied_at TIMESTAMPTZ NOT NULL)`
+	}
+	if _, err := s.db.ExecContext(ctx, createMigrations); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.apply(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply runs a single migration and records it.
+func (s *Store) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements[s.dialect] {
+		if _, err := tx.ExecContext(ctx, strings.ReplaceAll(stmt, "{{table}}", s.table)); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", m.version, err)
+		}
+	}
+
+	record := s.rebind(`INSERT INTO ` + s.migrationsTable() + ` (version, applied_at) VALUES (?, ?)`)
+	if _, err := tx.ExecContext(ctx, record, m.version, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+	}
+	return nil
+}
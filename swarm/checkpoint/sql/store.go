@@ -0,0 +1,290 @@
+// Package sql provides a swarm.CheckpointStore backed by database/sql.
+//
+// The store works with SQLite and PostgreSQL. It does not import a driver;
+// register one in the application (for example github.com/mattn/go-sqlite3
+// or github.com/jackc/pgx/v5/stdlib) and pass the opened *sql.DB to New.
+//
+// Example:
+//
+//	db, _ := sql.Open("sqlite3", "swarm.db")
+//	store, err := sqlstore.New(db, sqlstore.WithDialect(sqlstore.SQLite))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := store.Migrate(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+package sql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// defaultTableName is the table checkpoints are stored in unless
+// WithTableName is given.
+const defaultTableName = "swarm_checkpoints"
+
+// tableNamePattern restricts table names to plain identifiers, since they
+// are interpolated into queries.
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Dialect selects the SQL flavour used by a Store.
+type Dialect int
+
+const (
+	// SQLite uses "?" placeholders and TEXT columns for JSON data.
+	SQLite Dialect = iota
+	// Postgres uses "$n" placeholders and JSONB columns for JSON data.
+	Postgres
+)
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+	switch d {
+	case SQLite:
+		return "sqlite"
+	case Postgres:
+		return "postgres"
+	default:
+		return fmt.Sprintf("Dialect(%d)", int(d))
+	}
+}
+
+// Option configures a Store created by New.
+type Option func(*Store)
+
+// WithDialect sets the SQL dialect (default: SQLite).
+func WithDialect(dialect Dialect) Option {
+	return func(s *Store) {
+		s.dialect = dialect
+	}
+}
+
+// WithTableName sets the checkpoint table name (default: "swarm_checkpoints").
+// The migrations table is named after it with a "_migrations" suffix.
+func WithTableName(name string) Option {
+	return func(s *Store) {
+		s.table = name
+	}
+}
+
+// Store is a swarm.CheckpointStore that keeps checkpoints in a SQL database.
+//
+// Call Migrate before first use to create or upgrade the schema. Store is
+// safe for concurrent use.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+}
+
+var (
+	_ swarm.CheckpointStore = (*Store)(nil)
+	_ swarm.ThreadLister    = (*Store)(nil)
+)
+
+// New creates a checkpoint store on db. It does not touch the database;
+// call Migrate to create the schema.
+func New(db *sql.DB, opts ...Option) (*Store, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db cannot be nil")
+	}
+
+	s := &Store{db: db, dialect: SQLite, table: defaultTableName}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.dialect != SQLite && s.dialect != Postgres {
+		return nil, fmt.Errorf("unsupported dialect %v", s.dialect)
+	}
+	if !tableNamePattern.MatchString(s.table) {
+		return nil, fmt.Errorf("invalid table name '%s'", s.table)
+	}
+	return s, nil
+}
+
+// rebind rewrites "?" placeholders for the store's dialect.
+func (s *Store) rebind(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Put saves a checkpoint.
+func (s *Store) Put(ctx context.Context, checkpoint *swarm.Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if checkpoint.ThreadID == "" {
+		return fmt.Errorf("checkpoint thread ID cannot be empty")
+	}
+	if checkpoint.ID == "" {
+		checkpoint.ID = newCheckpointID()
+	}
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now().UTC()
+	}
+
+	state, err := swarm.MarshalState(checkpoint.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint state: %w", err)
+	}
+	var metadata []byte
+	if checkpoint.Metadata != nil {
+		if metadata, err = json.Marshal(checkpoint.Metadata); err != nil {
+			return fmt.Errorf("failed to marshal checkpoint metadata: %w", err)
+		}
+	}
+
+	query := s.rebind(`INSERT INTO ` + s.table +
+		` (thread_id, checkpoint_id, state, metadata, created_at) VALUES (?, ?, ?, ?, ?)`)
+	if _, err := s.db.ExecContext(ctx, query,
+		checkpoint.ThreadID, checkpoint.ID, string(state), nullString(metadata), checkpoint.CreatedAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Get returns a specific checkpoint of a thread.
+func (s *Store) Get(ctx context.Context, threadID, checkpointID string) (*swarm.Checkpoint, error) {
+	checkpoints, err := s.query(ctx, `WHERE thread_id = ? AND checkpoint_id = ?`, threadID, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("%w: thread %s, checkpoint %s", swarm.ErrCheckpointNotFound, threadID, checkpointID)
+	}
+	return checkpoints[0], nil
+}
+
+// Latest returns the most recent checkpoint of a thread.
+func (s *Store) Latest(ctx context.Context, threadID string) (*swarm.Checkpoint, error) {
+	checkpoints, err := s.query(ctx, `WHERE thread_id = ? ORDER BY seq DESC LIMIT 1`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, fmt.Errorf("%w: thread %s", swarm.ErrCheckpointNotFound, threadID)
+	}
+	return checkpoints[0], nil
+}
+
+// List returns all checkpoints of a thread, oldest first.
+func (s *Store) List(ctx context.Context, threadID string) ([]*swarm.Checkpoint, error) {
+	return s.query(ctx, `WHERE thread_id = ? ORDER BY seq`, threadID)
+}
+
+// Delete removes all checkpoints of a thread.
+func (s *Store) Delete(ctx context.Context, threadID string) error {
+	query := s.rebind(`DELETE FROM ` + s.table + ` WHERE thread_id = ?`)
+	if _, err := s.db.ExecContext(ctx, query, threadID); err != nil {
+		return fmt.Errorf("failed to delete thread: %w", err)
+	}
+	return nil
+}
+
+// Threads returns the IDs of the threads with checkpoints, sorted.
+func (s *Store) Threads(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT thread_id FROM `+s.table+` ORDER BY thread_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query threads: %w", err)
+	}
+	defer rows.Close()
+
+	threads := []string{}
+	for rows.Next() {
+		var threadID string
+		if err := rows.Scan(&threadID); err != nil {
+			return nil, fmt.Errorf("failed to scan thread: %w", err)
+		}
+		threads = append(threads, threadID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query threads: %w", err)
+	}
+	return threads, nil
+}
+
+// query selects checkpoints matching the given clause.
+func (s *Store) query(ctx context.Context, clause string, args ...any) ([]*swarm.Checkpoint, error) {
+	query := s.rebind(`SELECT thread_id, checkpoint_id, state, metadata, created_at FROM ` + s.table + ` ` + clause)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	checkpoints := []*swarm.Checkpoint{}
+	for rows.Next() {
+		var (
+			checkpoint swarm.Checkpoint
+			state      []byte
+			metadata   []byte
+			err        error
+		)
+		if err = rows.Scan(&checkpoint.ThreadID, &checkpoint.ID, &state, &metadata, &checkpoint.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan checkpoint: %w", err)
+		}
+		if checkpoint.State, err = swarm.UnmarshalState(state); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal checkpoint %s: %w", checkpoint.ID, err)
+		}
+		if len(metadata) > 0 {
+			if err = json.Unmarshal(metadata, &checkpoint.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal checkpoint %s metadata: %w", checkpoint.ID, err)
+			}
+		}
+		checkpoint.CreatedAt = checkpoint.CreatedAt.UTC()
+		checkpoints = append(checkpoints, &checkpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// nullString converts optional JSON data to a nullable column value.
+func nullString(data []byte) sql.NullString {
+	return sql.NullString{String: string(data), Valid: data != nil}
+}
+
+// newCheckpointID returns a random checkpoint identifier.
+func newCheckpointID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isMissingTable reports whether err indicates the queried table does not exist.
+func isMissingTable(err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "no such table") || strings.Contains(msg, "does not exist")
+}
@@ -0,0 +1,227 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// postgresDSNEnv names the environment variable holding the DSN of a
+// Postgres database for tests; Postgres tests are skipped when it is unset.
+const postgresDSNEnv = "SWARM_TEST_POSTGRES_DSN"
+
+func openStores(t *testing.T) map[string]*Store {
+	t.Helper()
+	stores := make(map[string]*Store)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "swarm.db"))
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := New(db)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	stores["sqlite"] = store
+
+	if dsn := os.Getenv(postgresDSNEnv); dsn != "" {
+		pg, err := sql.Open("pgx", dsn)
+		if err != nil {
+			t.Fatalf("sql.Open() error = %v", err)
+		}
+		t.Cleanup(func() { pg.Close() })
+
+		// Each run uses a fresh table so tests do not see earlier data
+		table := "swarm_checkpoints_test"
+		for _, name := range []string{table, table + "_migrations"} {
+			if _, err := pg.Exec(`DROP TABLE IF EXISTS ` + name); err != nil {
+				t.Fatalf("failed to reset %s: %v", name, err)
+			}
+		}
+		store, err := New(pg, WithDialect(Postgres), WithTableName(table))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		stores["postgres"] = store
+	}
+
+	return stores
+}
+
+func TestNew(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		db      *sql.DB
+		opts    []Option
+		wantErr bool
+	}{
+		{"defaults", db, nil, false},
+		{"postgres", db, []Option{WithDialect(Postgres)}, false},
+		{"nil db", nil, nil, true},
+		{"unknown dialect", db, []Option{WithDialect(Dialect(42))}, true},
+		{"invalid table name", db, []Option{WithTableName("checkpoints; DROP TABLE x")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.db, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	sqlite := &Store{dialect: SQLite}
+	postgres := &Store{dialect: Postgres}
+	query := `SELECT a FROM t WHERE b = ? AND c = ?`
+
+	if got := sqlite.rebind(query); got != query {
+		t.Errorf("rebind() = %q, want %q", got, query)
+	}
+	if got, want := postgres.rebind(query), `SELECT a FROM t WHERE b = $1 AND c = $2`; got != want {
+		t.Errorf("rebind() = %q, want %q", got, want)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	for name, store := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			version, err := store.SchemaVersion(ctx)
+			if err != nil {
+				t.Fatalf("SchemaVersion() error = %v", err)
+			}
+			if version != 0 {
+				t.Errorf("SchemaVersion() before Migrate = %d, want 0", version)
+			}
+
+			// Migrate is idempotent
+			for i := 0; i < 2; i++ {
+				if err := store.Migrate(ctx); err != nil {
+					t.Fatalf("Migrate() error = %v", err)
+				}
+			}
+
+			version, err = store.SchemaVersion(ctx)
+			if err != nil {
+				t.Fatalf("SchemaVersion() error = %v", err)
+			}
+			if want := migrations[len(migrations)-1].version; version != want {
+				t.Errorf("SchemaVersion() = %d, want %d", version, want)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := store.Migrate(ctx); err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+
+			if _, err := store.Latest(ctx, "thread-1"); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+				t.Errorf("Latest() on empty thread error = %v, want ErrCheckpointNotFound", err)
+			}
+
+			first := &swarm.Checkpoint{ThreadID: "thread-1", State: swarm.SwarmState{
+				Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+				ActiveAgent: "Alice",
+			}}
+			second := &swarm.Checkpoint{ThreadID: "thread-1", State: swarm.SwarmState{
+				Messages: []llms.MessageContent{
+					llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+					llms.TextParts(llms.ChatMessageTypeAI, "Ahoy"),
+				},
+				ActiveAgent: "Bob",
+			}, Metadata: map[string]any{"step": "bob"}}
+			other := &swarm.Checkpoint{ThreadID: "thread-2", State: swarm.SwarmState{ActiveAgent: "Carol"}}
+
+			for _, cp := range []*swarm.Checkpoint{first, second, other} {
+				if err := store.Put(ctx, cp); err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+				if cp.ID == "" || cp.CreatedAt.IsZero() {
+					t.Errorf("Put() should assign ID and CreatedAt, got %+v", cp)
+				}
+			}
+
+			if err := store.Put(ctx, &swarm.Checkpoint{}); err == nil {
+				t.Error("Put() without thread ID should return an error")
+			}
+			if err := store.Put(ctx, &swarm.Checkpoint{ID: first.ID, ThreadID: "thread-1"}); err == nil {
+				t.Error("Put() with a duplicate ID should return an error")
+			}
+
+			latest, err := store.Latest(ctx, "thread-1")
+			if err != nil {
+				t.Fatalf("Latest() error = %v", err)
+			}
+			if latest.ID != second.ID || latest.State.ActiveAgent != "Bob" || len(latest.State.Messages) != 2 {
+				t.Errorf("Latest() = %+v, want second checkpoint", latest)
+			}
+			if latest.Metadata["step"] != "bob" {
+				t.Errorf("Expected metadata to round-trip, got %v", latest.Metadata)
+			}
+			if latest.State.Messages[1].Parts[0] != (llms.TextContent{Text: "Ahoy"}) {
+				t.Errorf("Expected messages to round-trip, got %+v", latest.State.Messages)
+			}
+
+			got, err := store.Get(ctx, "thread-1", first.ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.State.ActiveAgent != "Alice" || got.Metadata != nil {
+				t.Errorf("Get() = %+v, want first checkpoint", got)
+			}
+			if _, err := store.Get(ctx, "thread-1", other.ID); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+				t.Errorf("Get() from another thread error = %v, want ErrCheckpointNotFound", err)
+			}
+
+			list, err := store.List(ctx, "thread-1")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(list) != 2 || list[0].ID != first.ID || list[1].ID != second.ID {
+				t.Errorf("List() returned %d checkpoints, want first and second in order", len(list))
+			}
+
+			threads, err := store.Threads(ctx)
+			if err != nil {
+				t.Fatalf("Threads() error = %v", err)
+			}
+			if len(threads) != 2 || threads[0] != "thread-1" || threads[1] != "thread-2" {
+				t.Errorf("Threads() = %q, want thread-1 and thread-2", threads)
+			}
+
+			if err := store.Delete(ctx, "thread-1"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Latest(ctx, "thread-1"); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+				t.Errorf("Latest() after Delete() error = %v, want ErrCheckpointNotFound", err)
+			}
+			if _, err := store.Latest(ctx, "thread-2"); err != nil {
+				t.Errorf("Delete() should not affect other threads, got %v", err)
+			}
+		})
+	}
+}
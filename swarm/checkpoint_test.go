@@ -0,0 +1,159 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestCheckpointStores(t *testing.T) {
+	fileSaver, err := NewFileSaver(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSaver() error = %v", err)
+	}
+
+	stores := []struct {
+		name  string
+		store CheckpointStore
+	}{
+		{"memory", NewMemorySaver()},
+		{"file", fileSaver},
+	}
+
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := tt.store
+
+			if _, err := store.Latest(ctx, "thread/1"); !errors.Is(err, ErrCheckpointNotFound) {
+				t.Errorf("Latest() on empty thread error = %v, want ErrCheckpointNotFound", err)
+			}
+
+			first := &Checkpoint{ThreadID: "thread/1", State: SwarmState{
+				Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+				ActiveAgent: "Alice",
+			}}
+			second := &Checkpoint{ThreadID: "thread/1", State: SwarmState{
+				Messages: []llms.MessageContent{
+					llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+					llms.TextParts(llms.ChatMessageTypeAI, "Ahoy"),
+				},
+				ActiveAgent: "Bob",
+			}, Metadata: map[string]any{"step": "bob"}}
+
+			for _, cp := range []*Checkpoint{first, second} {
+				if err := store.Put(ctx, cp); err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+				if cp.ID == "" || cp.CreatedAt.IsZero() {
+					t.Errorf("Put() should assign ID and CreatedAt, got %+v", cp)
+				}
+			}
+
+			latest, err := store.Latest(ctx, "thread/1")
+			if err != nil {
+				t.Fatalf("Latest() error = %v", err)
+			}
+			if latest.ID != second.ID || latest.State.ActiveAgent != "Bob" || len(latest.State.Messages) != 2 {
+				t.Errorf("Latest() = %+v, want second checkpoint", latest)
+			}
+			if latest.Metadata["step"] != "bob" {
+				t.Errorf("Expected metadata to round-trip, got %v", latest.Metadata)
+			}
+
+			got, err := store.Get(ctx, "thread/1", first.ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.State.ActiveAgent != "Alice" {
+				t.Errorf("Get() = %+v, want first checkpoint", got)
+			}
+			if _, err := store.Get(ctx, "thread/1", "missing"); !errors.Is(err, ErrCheckpointNotFound) {
+				t.Errorf("Get() missing error = %v, want ErrCheckpointNotFound", err)
+			}
+
+			list, err := store.List(ctx, "thread/1")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(list) != 2 || list[0].ID != first.ID || list[1].ID != second.ID {
+				t.Errorf("List() returned %d checkpoints in unexpected order", len(list))
+			}
+
+			if err := store.Put(ctx, &Checkpoint{ThreadID: "thread 0"}); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			threads, err := store.(ThreadLister).Threads(ctx)
+			if err != nil {
+				t.Fatalf("Threads() error = %v", err)
+			}
+			if len(threads) != 2 || threads[0] != "thread 0" || threads[1] != "thread/1" {
+				t.Errorf("Threads() = %q, want both threads in order", threads)
+			}
+
+			if err := store.Delete(ctx, "thread/1"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if list, _ := store.List(ctx, "thread/1"); len(list) != 0 {
+				t.Errorf("Expected no checkpoints after Delete(), got %d", len(list))
+			}
+			if threads, _ := store.(ThreadLister).Threads(ctx); len(threads) != 1 {
+				t.Errorf("Threads() after Delete() = %q", threads)
+			}
+
+			if err := store.Put(ctx, &Checkpoint{}); err == nil {
+				t.Error("Put() should reject a checkpoint without thread ID")
+			}
+		})
+	}
+}
+
+func TestMemorySaverCopiesState(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySaver()
+
+	cp := &Checkpoint{ThreadID: "t", State: SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+	}}
+	if err := store.Put(ctx, cp); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	cp.State.Messages[0] = llms.TextParts(llms.ChatMessageTypeHuman, "Mutated")
+
+	latest, _ := store.Latest(ctx, "t")
+	if text := latest.State.Messages[0].Parts[0].(llms.TextContent).Text; text != "Hello" {
+		t.Errorf("Stored state was mutated: %q", text)
+	}
+}
+
+func TestFileSaverStaysInItsDirectory(t *testing.T) {
+	ctx := context.Background()
+	parent := t.TempDir()
+	sibling := filepath.Join(parent, "sibling.txt")
+	if err := os.WriteFile(sibling, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileSaver(filepath.Join(parent, "checkpoints"))
+	if err != nil {
+		t.Fatalf("NewFileSaver() error = %v", err)
+	}
+
+	for _, threadID := range []string{".", ".."} {
+		if err := store.Put(ctx, &Checkpoint{ThreadID: threadID}); err == nil {
+			t.Errorf("Put(%q) should be rejected", threadID)
+		}
+		if err := store.Delete(ctx, threadID); err == nil {
+			t.Errorf("Delete(%q) should be rejected", threadID)
+		}
+	}
+	if _, err := os.Stat(sibling); err != nil {
+		t.Errorf("file next to the store was removed: %v", err)
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 2 {
+		t.Errorf("store wrote outside its directory: %v", entries)
+	}
+}
@@ -0,0 +1,85 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+// CommandRunnable is implemented by agent runnables that report where
+// control goes next with a LangGraphGo Command, as ReactAgent does, instead
+// of setting SwarmState.ActiveAgent. The swarm prefers InvokeCommand over
+// Invoke.
+//
+// The Command's Update is the agent's new state, a SwarmState or
+// *SwarmState; nil keeps the state the agent was given. Goto is the name of
+// the agent to hand off to, or graph.END to end the agent's run without a
+// handoff; a nil Goto leaves the active agent of the Update as it is.
+type CommandRunnable interface {
+	InvokeCommand(ctx context.Context, state SwarmState) (*graph.Command, error)
+}
+
+// CommandRunnableFunc adapts a function to the CommandRunnable interface, to
+// write agents that route with Commands as in the Python version.
+//
+// Example:
+//
+//	triage := swarm.CommandRunnableFunc(func(ctx context.Context, state swarm.SwarmState) (*graph.Command, error) {
+//	    if strings.Contains(lastUserText(state), "refund") {
+//	        return &graph.Command{Goto: "Refunds"}, nil
+//	    }
+//	    return &graph.Command{Update: answer(state), Goto: graph.END}, nil
+//	})
+type CommandRunnableFunc func(ctx context.Context, state SwarmState) (*graph.Command, error)
+
+// InvokeCommand calls f.
+func (f CommandRunnableFunc) InvokeCommand(ctx context.Context, state SwarmState) (*graph.Command, error) {
+	return f(ctx, state)
+}
+
+// Invoke calls f and applies its Command to state, making f an
+// AgentRunnable.
+func (f CommandRunnableFunc) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return invokeCommand(ctx, f, state)
+}
+
+// invokeCommand invokes r and applies its Command to state.
+func invokeCommand(ctx context.Context, r CommandRunnable, state SwarmState) (SwarmState, error) {
+	cmd, err := r.InvokeCommand(ctx, state)
+	if err != nil || cmd == nil {
+		return state, err
+	}
+	return applyCommand(state, cmd)
+}
+
+// applyCommand returns the state an agent given state reported with cmd:
+// its Update, with the active agent set to its Goto. The agent node records
+// the handoff, as for agents setting the active agent themselves.
+func applyCommand(state SwarmState, cmd *graph.Command) (SwarmState, error) {
+	result := state
+	switch update := cmd.Update.(type) {
+	case nil:
+	case SwarmState:
+		result = update
+	case *SwarmState:
+		if update != nil {
+			result = *update
+		}
+	default:
+		return state, fmt.Errorf("command update is %T, want SwarmState", cmd.Update)
+	}
+
+	switch goTo := cmd.Goto.(type) {
+	case nil:
+	case string:
+		if goTo == graph.END || goTo == "" {
+			result.ActiveAgent = state.ActiveAgent
+		} else {
+			result.ActiveAgent = goTo
+		}
+	default:
+		return state, fmt.Errorf("command goto is %T, want an agent name", cmd.Goto)
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestReactAgentInvokeCommand(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_bob", `{"task_description":"Talk like a pirate"}`),
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")}}
+	cmd, err := alice.InvokeCommand(context.Background(), state)
+	if err != nil {
+		t.Fatalf("InvokeCommand() error = %v", err)
+	}
+	if cmd.Goto != "Bob" || cmd.Update.(SwarmState).ActiveAgent != "Bob" {
+		t.Errorf("command = %+v", cmd)
+	}
+
+	model = &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hello"}}}
+	alice, _ = CreateReactAgent(model, nil)
+	if cmd, _ := alice.InvokeCommand(context.Background(), state); cmd.Goto != graph.END {
+		t.Errorf("Goto = %v without a handoff, want END", cmd.Goto)
+	}
+}
+
+func TestCommandRunnable(t *testing.T) {
+	triage := CommandRunnableFunc(func(ctx context.Context, state SwarmState) (*graph.Command, error) {
+		return &graph.Command{Goto: "Bob"}, nil
+	})
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: triage},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "Bob" || len(result.Handoffs) != 1 || result.Handoffs[0].From != "Alice" {
+		t.Errorf("active agent = %s, handoffs = %+v", result.ActiveAgent, result.Handoffs)
+	}
+	if last := result.Messages[len(result.Messages)-1]; last.Parts[0].(llms.TextContent).Text != "Ahoy" {
+		t.Errorf("last message = %+v", last)
+	}
+
+	if _, err := applyCommand(SwarmState{}, &graph.Command{Update: map[string]any{"active_agent": "Bob"}}); err == nil {
+		t.Error("applyCommand() with a map update should return an error")
+	}
+}
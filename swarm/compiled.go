@@ -0,0 +1,263 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/smallnest/langgraphgo/graph"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CompiledSwarm is a compiled swarm ready to be invoked.
+// It is created by Swarm.Compile.
+//
+// A CompiledSwarm is safe for concurrent use: any number of goroutines may
+// call Invoke and Resume at once, including with the same input state.
+// Agents, tools, models and handlers shared by the swarm's agents must be
+// safe for concurrent use as well.
+type CompiledSwarm struct {
+	swarm *Swarm
+	// mu guards runnable, which is recompiled when the swarm's agents change
+	mu       sync.Mutex
+	runnable *graph.StateRunnable[SwarmState]
+	version  int
+	// runs tracks the runs in progress for Shutdown
+	runs runTracker
+}
+
+// current returns the compiled graph for the swarm's current agents, and
+// the config it was built from.
+func (c *CompiledSwarm) current() (*graph.StateRunnable[SwarmState], SwarmConfig, error) {
+	c.swarm.mu.RLock()
+	defer c.swarm.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version != c.swarm.version {
+		runnable, err := c.swarm.graph.Compile()
+		if err != nil {
+			return c.runnable, c.swarm.config, fmt.Errorf("failed to compile swarm: %w", err)
+		}
+		c.runnable, c.version = runnable, c.swarm.version
+	}
+	return c.runnable, c.swarm.config, nil
+}
+
+// Invoke runs the swarm on the given state and returns the resulting state.
+// The run starts with state.ActiveAgent, or the swarm's default active agent
+// when none is set.
+//
+// When the run pauses before a tool listed in SwarmConfig.InterruptBefore,
+// Invoke returns the paused state and an *InterruptError.
+//
+// Cancelling ctx aborts the model calls and tools in progress. Invoke then
+// returns the state after the last agent run that completed and the
+// context's error; with WithThreadID that state is saved to the thread,
+// marked with CancelledMetadataKey.
+//
+// Invoke does not modify state: the run works on a copy of it, and the
+// returned state belongs to the caller. Concurrent invocations on the same
+// thread are not serialized; see the session package for that.
+//
+// Example:
+//
+//	result, err := app.Invoke(ctx, swarm.SwarmState{
+//	    Messages: []llms.MessageContent{llms.TextParts("user", "Hello")},
+//	})
+func (c *CompiledSwarm) Invoke(ctx context.Context, state SwarmState, opts ...InvokeOption) (SwarmState, error) {
+	result, _, err := c.invoke(ctx, state, opts...)
+	return result, err
+}
+
+// invoke runs the swarm like Invoke and also returns the trace of the run,
+// or nil when it failed to start.
+func (c *CompiledSwarm) invoke(ctx context.Context, state SwarmState, opts ...InvokeOption) (SwarmState, *Trace, error) {
+	runnable, config, err := c.current()
+	if err != nil {
+		return state, nil, err
+	}
+	ctx, done, err := c.runs.start(ctx)
+	if err != nil {
+		return state, nil, err
+	}
+	defer done()
+	state = state.Clone()
+
+	var options RunConfig
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.RecursionLimit == 0 {
+		options.RecursionLimit = config.RecursionLimit
+	}
+	if options.RecursionLimit == 0 {
+		options.RecursionLimit = DefaultRecursionLimit
+	}
+	if options.Deadline == 0 {
+		options.Deadline = config.Deadline
+	}
+	if options.ThreadID != "" && config.Checkpointer == nil {
+		return state, nil, fmt.Errorf("thread '%s': no checkpointer configured", options.ThreadID)
+	}
+	ctx, err = withRunContext(ctx, config.ContextSchema, options.Context)
+	if err != nil {
+		return state, nil, err
+	}
+	ctx = withRunConfig(ctx, options)
+	ctx, recorder := withTrace(ctx)
+
+	bus := events.BusFromContext(ctx)
+	if bus == nil && config.Events != nil {
+		bus = config.Events
+		ctx = events.WithBus(ctx, bus)
+	}
+
+	ctx = withHandoffTrail(ctx)
+	if config.Artifacts != nil {
+		ctx = withArtifactStore(ctx, config.Artifacts)
+	}
+	if !config.Budget.unlimited() {
+		ctx = withBudget(ctx, config.Budget)
+	}
+	if options.Deadline > 0 {
+		ctx = withDeadline(ctx, options.Deadline)
+	}
+	ctx, span := tracer(ctx, config.TracerProvider).Start(ctx, "swarm.invoke",
+		trace.WithAttributes(attrActiveAgent.String(state.ActiveAgent), attrMessages.Int(len(state.Messages))))
+
+	start := time.Now()
+	result, err := runnable.Invoke(ctx, state)
+	var interrupt *InterruptError
+	if errors.As(err, &interrupt) {
+		interrupt.State.ActiveAgent = interrupt.Agent
+		result, err = interrupt.State, interrupt
+	}
+	cancelled := err != nil && interrupt == nil && ctx.Err() != nil
+	if cancelled {
+		result = state
+		if progress, ok := runProgress(ctx); ok {
+			result = progress
+		}
+		if cause := context.Cause(ctx); !errors.Is(err, cause) {
+			err = errors.Join(err, cause)
+		}
+	}
+	truncated := runTruncated(ctx)
+	if err == nil && truncated == nil && config.ResponseFormat != nil {
+		result, err = config.ResponseFormat.finalize(ctx, result)
+	}
+	if options.ThreadID != "" && (err == nil || interrupt != nil || cancelled) {
+		checkpoint := &Checkpoint{ThreadID: options.ThreadID, State: result, Metadata: maps.Clone(options.Metadata)}
+		if interrupt != nil || cancelled || truncated != nil {
+			if checkpoint.Metadata == nil {
+				checkpoint.Metadata = make(map[string]any, 1)
+			}
+		}
+		switch {
+		case interrupt != nil && interrupt.awaitingReply:
+			checkpoint.Metadata[awaitingReplyMetadataKey] = interrupt.Agent
+		case interrupt != nil:
+			checkpoint.Metadata[interruptedMetadataKey] = interrupt.Agent
+		}
+		if cancelled {
+			checkpoint.Metadata[CancelledMetadataKey] = context.Cause(ctx).Error()
+		}
+		if truncated != nil && err == nil {
+			checkpoint.Metadata[TruncatedMetadataKey] = truncated.Error()
+		}
+		// A cancelled run's checkpoint is saved all the same
+		if putErr := config.Checkpointer.Put(context.WithoutCancel(ctx), checkpoint); putErr != nil {
+			err = errors.Join(err, fmt.Errorf("save checkpoint: %w", putErr))
+		}
+	}
+	span.SetAttributes(attrActiveAgent.String(result.ActiveAgent), attrMessages.Int(len(result.Messages)))
+	endSpan(span, err)
+	if bus != nil {
+		bus.Publish(ctx, events.TurnCompleted{
+			Time:        time.Now(),
+			ActiveAgent: result.ActiveAgent,
+			Messages:    len(result.Messages),
+			Duration:    time.Since(start),
+			Err:         err,
+		})
+	}
+
+	runTrace := recorder.finish(options.ThreadID, state, result, err)
+	runTrace.Experiments = slices.Clone(options.ExperimentFlags)
+	if truncated != nil {
+		runTrace.Truncated, runTrace.truncated = truncated.Error(), truncated
+		if config.Logger != nil {
+			config.Logger.LogAttrs(ctx, slog.LevelWarn, "run truncated", slog.Any("reason", truncated))
+		}
+	}
+	for _, exporter := range config.TraceExporters {
+		if exportErr := exporter.ExportTrace(context.WithoutCancel(ctx), runTrace); exportErr != nil && config.Logger != nil {
+			config.Logger.LogAttrs(ctx, slog.LevelWarn, "trace export failed", slog.Any("error", exportErr))
+		}
+	}
+	return result, runTrace, err
+}
+
+// Resume continues an interrupted thread with a human's input. A thread
+// interrupted before tool calls that needed approval is resumed with an
+// Approval: if approval.Approved is true the pending tool calls are
+// executed; otherwise the agent receives a rejection, including
+// approval.Feedback, in place of their results. The run then continues as
+// usual and its state is saved to the thread. Options such as WithContext
+// apply to the resumed run. Inspect and edit the thread before resuming it
+// with GetState and UpdateState.
+//
+// Example:
+//
+//	_, err := app.Invoke(ctx, state, swarm.WithThreadID("user_123"))
+//	if errors.Is(err, swarm.ErrInterrupted) {
+//	    // ask a human, then:
+//	    result, err = app.Resume(ctx, "user_123", swarm.Approval{Approved: true})
+//	}
+func (c *CompiledSwarm) Resume(ctx context.Context, threadID string, input ResumeInput, opts ...InvokeOption) (SwarmState, error) {
+	_, config, err := c.current()
+	if err != nil {
+		return SwarmState{}, err
+	}
+	store := config.Checkpointer
+	if store == nil {
+		return SwarmState{}, fmt.Errorf("thread '%s': no checkpointer configured", threadID)
+	}
+	checkpoint, err := store.Latest(ctx, threadID)
+	if err != nil {
+		return SwarmState{}, err
+	}
+	if input == nil {
+		return checkpoint.State, fmt.Errorf("thread '%s': no resume input", threadID)
+	}
+	ctx, state, err := input.resume(ctx, checkpoint)
+	if err != nil {
+		return state, err
+	}
+	return c.Invoke(ctx, state, append(opts, WithThreadID(threadID))...)
+}
+
+// Checkpointer returns the swarm's SwarmConfig.Checkpointer, or nil.
+func (c *CompiledSwarm) Checkpointer() CheckpointStore {
+	_, config, _ := c.current()
+	return config.Checkpointer
+}
+
+// Swarm returns the swarm this CompiledSwarm was compiled from.
+func (c *CompiledSwarm) Swarm() *Swarm {
+	return c.swarm
+}
+
+// Runnable returns the underlying compiled graph for the swarm's current
+// agents.
+func (c *CompiledSwarm) Runnable() *graph.StateRunnable[SwarmState] {
+	runnable, _, _ := c.current()
+	return runnable
+}
@@ -0,0 +1,150 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// These tests run parallel invocations of one CompiledSwarm and are meant
+// to be run with the race detector (go test -race).
+
+// parallelRuns is the number of concurrent invocations per test.
+const parallelRuns = 16
+
+// transferModel is a stateless model that is safe for concurrent use. It
+// hands off to target when the last message is from the user, and replies
+// with its name otherwise.
+type transferModel struct {
+	name   string
+	target string
+}
+
+func (m transferModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	last := messages[len(messages)-1]
+	if m.target != "" && last.Role == llms.ChatMessageTypeHuman {
+		call := toolCallChoice(fmt.Sprintf("call_%p", &last), "transfer_to_"+m.target, `{}`)
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{call}}, nil
+	}
+	if opts.StreamingFunc != nil {
+		if err := opts.StreamingFunc(ctx, []byte(m.name)); err != nil {
+			return nil, err
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: m.name}}}, nil
+}
+
+func (m transferModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// concurrentSwarm returns a swarm in which Alice hands off to Bob, who
+// replies and counts his runs in Values.
+func concurrentSwarm(t *testing.T, store CheckpointStore) *CompiledSwarm {
+	t.Helper()
+	alice, err := CreateReactAgent(transferModel{name: "Alice", target: "bob"},
+		[]tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+	bob, err := CreateReactAgent(transferModel{name: "Bob"}, nil)
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+	counter := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		state, err := bob.Invoke(ctx, state)
+		if state.Values == nil {
+			state.Values = map[string]any{}
+		}
+		runs, _ := state.Values["bob_runs"].(int)
+		state.Values["bob_runs"] = runs + 1
+		return state, err
+	})
+	return compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: counter},
+		},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       store,
+		StreamHandler:      StreamHandlerFuncs{Token: func(ctx context.Context, agent, token string) {}},
+	})
+}
+
+// parallel runs fn parallelRuns times concurrently.
+func parallel(fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := range parallelRuns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrentInvokeSharedState(t *testing.T) {
+	app := concurrentSwarm(t, nil)
+
+	// A shared state with room to grow in place and a shared Values map
+	messages := make([]llms.MessageContent, 1, 16)
+	messages[0] = llms.TextParts(llms.ChatMessageTypeHuman, "Hello")
+	shared := SwarmState{Messages: messages, Values: map[string]any{"bob_runs": 0}}
+
+	results := make([]SwarmState, parallelRuns)
+	parallel(func(i int) {
+		result, err := app.Invoke(context.Background(), shared)
+		if err != nil {
+			t.Errorf("Invoke() error = %v", err)
+		}
+		results[i] = result
+	})
+
+	for _, result := range results {
+		if len(result.Messages) != 4 || result.ActiveAgent != "Bob" || result.Values["bob_runs"] != 1 {
+			t.Errorf("Unexpected result %+v", result)
+		}
+	}
+	if len(shared.Messages) != 1 || shared.Values["bob_runs"] != 0 {
+		t.Errorf("Invoke() modified the caller's state: %+v", shared)
+	}
+}
+
+func TestConcurrentThreads(t *testing.T) {
+	store := NewMemorySaver()
+	app := concurrentSwarm(t, store)
+
+	parallel(func(i int) {
+		ctx := context.Background()
+		thread := WithThreadID(fmt.Sprintf("thread-%d", i))
+		state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}}
+		for range 2 {
+			result, err := app.Invoke(ctx, state, thread)
+			if err != nil {
+				t.Errorf("Invoke() error = %v", err)
+				return
+			}
+			// Mutating a result must not affect the saved checkpoint
+			result.Values["bob_runs"] = -1
+			checkpoint, err := store.Latest(ctx, fmt.Sprintf("thread-%d", i))
+			if err != nil {
+				t.Errorf("Latest() error = %v", err)
+				return
+			}
+			state = checkpoint.State
+			state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Again"))
+		}
+		if runs := state.Values["bob_runs"]; runs != 2 {
+			t.Errorf("Thread %d: expected Bob to run twice, got %v", i, runs)
+		}
+	})
+}
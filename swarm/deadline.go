@@ -0,0 +1,158 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrDeadlineExceeded is matched (with errors.Is) by the
+// *DeadlineExceededError a run stopped for when it ran past its deadline
+// (see WithDeadline).
+var ErrDeadlineExceeded = errors.New("turn deadline exceeded")
+
+// TruncatedMetadataKey is the checkpoint metadata key marking the
+// checkpoint saved when a threaded run was stopped before it finished, e.g.
+// by its deadline or Budget. Its value is the reason the run was stopped;
+// CompiledSwarm.Continue picks the turn up where it stopped.
+const TruncatedMetadataKey = "truncated"
+
+// DeadlineExceededError reports a run stopped by its deadline.
+type DeadlineExceededError struct {
+	// Deadline is the time the run was allowed
+	Deadline time.Duration
+	// Elapsed is how long the run had taken when it stopped
+	Elapsed time.Duration
+}
+
+// Error describes the exceeded deadline.
+func (e *DeadlineExceededError) Error() string {
+	return fmt.Sprintf("%v: ran for %s of %s", ErrDeadlineExceeded, e.Elapsed.Round(time.Millisecond), e.Deadline)
+}
+
+// Is reports whether target is ErrDeadlineExceeded.
+func (e *DeadlineExceededError) Is(target error) bool {
+	return target == ErrDeadlineExceeded
+}
+
+// WithDeadline stops the run once it has taken longer than d. Unlike a
+// context deadline it does not abort the model or tool calls in progress:
+// no model call, tool call or handoff starts after d, and the run returns
+// its partial result with InvokeResult.Truncated set. With WithThreadID the
+// state is saved to the thread, marked with TruncatedMetadataKey, so that
+// CompiledSwarm.Continue can finish the turn later. It overrides
+// SwarmConfig.Deadline; a negative d disables it.
+func WithDeadline(d time.Duration) InvokeOption {
+	return func(c *RunConfig) {
+		c.Deadline = d
+	}
+}
+
+// deadlineKey is the context key of the deadlineTracker of a run.
+type deadlineKey struct{}
+
+// deadlineTracker checks a run against its deadline.
+type deadlineTracker struct {
+	deadline time.Duration
+	start    time.Time
+
+	mu sync.Mutex
+	// exceeded is set once the deadline is exceeded, and stays set
+	exceeded *DeadlineExceededError
+}
+
+// withDeadline returns a context checking the run against deadline,
+// starting now.
+func withDeadline(ctx context.Context, deadline time.Duration) context.Context {
+	return context.WithValue(ctx, deadlineKey{}, &deadlineTracker{deadline: deadline, start: time.Now()})
+}
+
+// deadlineExceeded returns the error of the run's exceeded deadline, or nil
+// while there is time left.
+func deadlineExceeded(ctx context.Context) error {
+	t, ok := ctx.Value(deadlineKey{}).(*deadlineTracker)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+	if elapsed := time.Since(t.start); elapsed >= t.deadline {
+		t.exceeded = &DeadlineExceededError{Deadline: t.deadline, Elapsed: elapsed}
+		return t.exceeded
+	}
+	return nil
+}
+
+// deadlineTruncated returns the error of the run's exceeded deadline if it
+// was already found exceeded, without checking the time again.
+func deadlineTruncated(ctx context.Context) error {
+	t, ok := ctx.Value(deadlineKey{}).(*deadlineTracker)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exceeded != nil {
+		return t.exceeded
+	}
+	return nil
+}
+
+// skippedToolCall is the tool result reported for a tool call not run
+// because the run was stopped.
+func skippedToolCall(cause error) string {
+	return fmt.Sprintf("Error: tool call not run: %v", cause)
+}
+
+// Continue finishes a turn of a thread whose last run was stopped before it
+// finished, by its deadline or Budget (see TruncatedMetadataKey). The
+// message telling the user the run was stopped is dropped, the agent that
+// was stopped carries on from the saved state, and the new state is saved
+// to the thread. It can run asynchronously, long after the truncated run
+// returned; options such as WithDeadline apply to the continued run.
+//
+// Example:
+//
+//	result, err := app.InvokeWithResult(ctx, state, swarm.WithThreadID("user_123"), swarm.WithDeadline(10*time.Second))
+//	if err == nil && result.Truncated != nil {
+//	    go app.Continue(context.WithoutCancel(ctx), "user_123")
+//	}
+func (c *CompiledSwarm) Continue(ctx context.Context, threadID string, opts ...InvokeOption) (SwarmState, error) {
+	_, config, err := c.current()
+	if err != nil {
+		return SwarmState{}, err
+	}
+	store := config.Checkpointer
+	if store == nil {
+		return SwarmState{}, fmt.Errorf("thread '%s': no checkpointer configured", threadID)
+	}
+	checkpoint, err := store.Latest(ctx, threadID)
+	if err != nil {
+		return SwarmState{}, err
+	}
+
+	reason, _ := checkpoint.Metadata[TruncatedMetadataKey].(string)
+	if reason == "" {
+		return checkpoint.State, fmt.Errorf("thread '%s' is not truncated", threadID)
+	}
+
+	state := checkpoint.State
+	if n := len(state.Messages); n > 0 && isStopMessage(state.Messages[n-1], reason) {
+		state.Messages = state.Messages[:n-1]
+	}
+	return c.Invoke(ctx, state, append(opts, WithThreadID(threadID))...)
+}
+
+// isStopMessage reports whether msg is the stopMessage of a run stopped
+// for reason.
+func isStopMessage(msg llms.MessageContent, reason string) bool {
+	stop := stopMessage(errors.New(reason))
+	return msg.Role == stop.Role && len(msg.Parts) == 1 && msg.Parts[0] == stop.Parts[0]
+}
@@ -0,0 +1,91 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// sleepTool sleeps for its duration before answering.
+type sleepTool struct{ duration time.Duration }
+
+func (sleepTool) Name() string        { return "sleep" }
+func (sleepTool) Description() string { return "Take a while" }
+func (s sleepTool) Call(ctx context.Context, input string) (string, error) {
+	time.Sleep(s.duration)
+	return "done", nil
+}
+
+func TestDeadlineStopsAndContinues(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		{ToolCalls: []llms.ToolCall{
+			{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "sleep", Arguments: `{}`}},
+			{ID: "call_2", Type: "function", FunctionCall: &llms.FunctionCall{Name: "upper", Arguments: `{"input":"hi"}`}},
+		}},
+		{Content: "Finished"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{sleepTool{duration: 30 * time.Millisecond}, upperTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemorySaver()
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       store,
+		Deadline:           time.Hour,
+	})
+
+	result, err := app.InvokeWithResult(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Take your time"),
+	}}, WithThreadID("t1"), WithDeadline(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("InvokeWithResult() error = %v", err)
+	}
+	var exceeded *DeadlineExceededError
+	if !errors.As(result.Truncated, &exceeded) || !errors.Is(result.Truncated, ErrDeadlineExceeded) {
+		t.Fatalf("Truncated = %v, want a deadline error", result.Truncated)
+	}
+	if len(model.calls) != 1 {
+		t.Errorf("model calls = %d, want none after the deadline", len(model.calls))
+	}
+	// The tool call in progress finishes; the next one is not started
+	if len(result.NewMessages) != 4 {
+		t.Fatalf("new messages = %+v", result.NewMessages)
+	}
+	skipped := result.NewMessages[2].Parts[0].(llms.ToolCallResponse)
+	if !strings.Contains(skipped.Content, "not run") {
+		t.Errorf("second tool result = %q, want it skipped", skipped.Content)
+	}
+	if got := lastText(result.State); !strings.Contains(got, "deadline exceeded") {
+		t.Errorf("last message = %q", got)
+	}
+
+	checkpoint, err := store.Latest(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Metadata[TruncatedMetadataKey] == nil {
+		t.Errorf("checkpoint metadata = %v, want it marked truncated", checkpoint.Metadata)
+	}
+
+	// Continuing the thread drops the stop message and lets Alice finish
+	final, err := app.Continue(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("Continue() error = %v", err)
+	}
+	if got := lastText(final); got != "Finished" {
+		t.Errorf("last message = %q, want Finished", got)
+	}
+	if len(final.Messages) != 5 {
+		t.Errorf("messages = %d, want the stop message dropped", len(final.Messages))
+	}
+	if _, err := app.Continue(context.Background(), "t1"); err == nil {
+		t.Error("Continue() of a finished thread should return an error")
+	}
+}
@@ -0,0 +1,206 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// defaultDynamicHandoffToolName is the name of tools created by
+	// CreateDynamicHandoffTool without a Name.
+	defaultDynamicHandoffToolName = "transfer_to_agent"
+
+	// HandoffAgentNameKey is the argument of a dynamic handoff tool naming
+	// the agent to hand off to.
+	HandoffAgentNameKey = "agent_name"
+)
+
+// DynamicHandoffToolConfig holds configuration for creating a dynamic
+// handoff tool.
+type DynamicHandoffToolConfig struct {
+	// Name is the optional name of the tool (default: transfer_to_agent)
+	Name string
+	// Description is the optional description for the tool
+	Description string
+	// Agents optionally restricts the agents the tool hands off to. They are
+	// listed to the model in the tool's schema.
+	Agents []string
+	// Mode tells whether the target agent runs within the same invocation
+	// (HandoffContinue, the default) or on the next one (HandoffEndTurn)
+	Mode HandoffMode
+}
+
+// dynamicHandoffTool hands off to the agent named in its arguments.
+type dynamicHandoffTool struct {
+	name        string
+	description string
+	agents      []string
+	mode        HandoffMode
+}
+
+// CreateDynamicHandoffTool creates a single tool that hands off to the agent
+// named in its "agent_name" argument, replacing one CreateHandoffTool per
+// destination in large swarms. Like other handoff tools, it takes a
+// "task_description" for the receiving agent.
+//
+// Inside a swarm, the target must be one of the calling agent's
+// Destinations or, when it declares none, any other agent of the swarm; the
+// config's Agents restrict it further. Unknown or forbidden targets are
+// reported to the model with the agents it may choose from. Agent names are
+// matched case-insensitively, with spaces and underscores alike.
+//
+// Example:
+//
+//	transfer := swarm.CreateDynamicHandoffTool(swarm.DynamicHandoffToolConfig{})
+//	agent, _ := swarm.CreateReactAgent(model, []tools.Tool{transfer})
+func CreateDynamicHandoffTool(config DynamicHandoffToolConfig) tools.Tool {
+	name := config.Name
+	if name == "" {
+		name = defaultDynamicHandoffToolName
+	}
+
+	description := config.Description
+	if description == "" {
+		description = "Transfer the conversation to another agent that can help"
+		if len(config.Agents) > 0 {
+			description += ": one of " + strings.Join(config.Agents, ", ")
+		}
+	}
+
+	return &dynamicHandoffTool{
+		name:        name,
+		description: description,
+		agents:      slices.Clone(config.Agents),
+		mode:        config.Mode,
+	}
+}
+
+// Name returns the name of the tool.
+func (t *dynamicHandoffTool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool.
+func (t *dynamicHandoffTool) Description() string {
+	return t.description
+}
+
+// Schema returns the JSON schema of the tool's arguments, listing the
+// configured agents as the allowed values of "agent_name".
+func (t *dynamicHandoffTool) Schema() map[string]any {
+	agentName := map[string]any{
+		"type":        "string",
+		"description": "Name of the agent to transfer to",
+	}
+	if len(t.agents) > 0 {
+		agentName["enum"] = slices.Clone(t.agents)
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			HandoffAgentNameKey: agentName,
+			HandoffTaskDescriptionKey: map[string]any{
+				"type":        "string",
+				"description": "What the agent should do next, with the context it needs",
+			},
+		},
+		"required": []string{HandoffAgentNameKey, HandoffTaskDescriptionKey},
+	}
+}
+
+// HandoffDestinations returns the configured agents, so that
+// SwarmConfig.InferDestinations can derive an agent's Destinations from
+// them.
+func (t *dynamicHandoffTool) HandoffDestinations() []string {
+	return slices.Clone(t.agents)
+}
+
+// Call validates the target agent, records the handoff in the context's
+// HandoffCapture and returns the transfer confirmation shown to the model.
+func (t *dynamicHandoffTool) Call(ctx context.Context, input string) (string, error) {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(input), &payload); err != nil {
+		return "", fmt.Errorf("invalid handoff arguments for %s: %w", t.name, err)
+	}
+	requested, _ := payload[HandoffAgentNameKey].(string)
+	if strings.TrimSpace(requested) == "" {
+		return "", fmt.Errorf("invalid handoff arguments for %s: missing required argument '%s'", t.name, HandoffAgentNameKey)
+	}
+	target, err := t.resolve(ctx, requested)
+	if err != nil {
+		return "", err
+	}
+
+	delete(payload, HandoffAgentNameKey)
+	if len(payload) == 0 {
+		payload = nil
+	}
+	result := HandoffResult{AgentName: target, ToolName: t.name, Payload: payload, Mode: t.mode}
+	if RecordHandoff(ctx, result) {
+		return handoffMessage(result), nil
+	}
+	return handoffPrefix + target, nil
+}
+
+// resolve returns the agent matching requested among those the calling
+// agent may hand off to.
+func (t *dynamicHandoffTool) resolve(ctx context.Context, requested string) (string, error) {
+	allowed := t.agents
+	if scope, ok := ctx.Value(handoffScopeKey{}).(handoffScope); ok {
+		candidates := scope.destinations
+		if len(candidates) == 0 {
+			candidates = slices.DeleteFunc(slices.Clone(scope.agents), func(name string) bool { return name == scope.agent })
+		}
+		if len(allowed) > 0 {
+			candidates = slices.DeleteFunc(slices.Clone(candidates), func(name string) bool { return !slices.Contains(allowed, name) })
+		}
+		allowed = candidates
+	} else if len(allowed) == 0 {
+		// Outside a swarm there is no registry to check against
+		return strings.TrimSpace(requested), nil
+	}
+
+	for _, name := range allowed {
+		if normalizeAgentName(name) == normalizeAgentName(requested) {
+			return name, nil
+		}
+	}
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("%w: cannot transfer to '%s': no agent is available", ErrInvalidDestination, requested)
+	}
+	return "", fmt.Errorf("%w: cannot transfer to '%s': choose one of %s", ErrInvalidDestination, requested, strings.Join(allowed, ", "))
+}
+
+// handoffScopeKey is the context key of the handoffScope of an agent run.
+type handoffScopeKey struct{}
+
+// handoffScope holds the agents the running agent may hand off to.
+type handoffScope struct {
+	agent        string
+	destinations []string
+	agents       []string
+}
+
+// withHandoffScope returns a context in which dynamic handoff tools accept
+// the destinations of agent, or any of agents when it declares none.
+func withHandoffScope(ctx context.Context, agent Agent, agents []string) context.Context {
+	return context.WithValue(ctx, handoffScopeKey{}, handoffScope{
+		agent:        agent.Name,
+		destinations: agent.Destinations,
+		agents:       agents,
+	})
+}
+
+// isHandoffTool reports whether t transfers control to another agent.
+func isHandoffTool(t tools.Tool) bool {
+	switch t.(type) {
+	case HandoffTool, *dynamicHandoffTool:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestDynamicHandoffTool(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_agent", `{"agent_name":"Carol","task_description":"Book a hotel"}`),
+		toolCallChoice("call_2", "transfer_to_agent", `{"agent_name":"bob","task_description":"Book a hotel"}`),
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{CreateDynamicHandoffTool(DynamicHandoffToolConfig{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice, Destinations: []string{"Bob"}},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Booked")},
+			{Name: "Carol", Runnable: createMockAgent("Carol", "Hi")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "I need a hotel"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	refused := model.calls[1][len(model.calls[1])-1].Parts[0].(llms.ToolCallResponse)
+	if refused.Content != "Error: invalid handoff destination: cannot transfer to 'Carol': choose one of Bob" {
+		t.Errorf("refused transfer = %q", refused.Content)
+	}
+	if result.ActiveAgent != "Bob" || len(result.Handoffs) != 1 || result.Handoffs[0].Reason != "Book a hotel" ||
+		result.HandoffPayload[HandoffTaskDescriptionKey] != "Book a hotel" {
+		t.Errorf("active agent = %s, handoffs = %+v, payload = %v", result.ActiveAgent, result.Handoffs, result.HandoffPayload)
+	}
+	if last := result.Messages[len(result.Messages)-1]; last.Parts[0].(llms.TextContent).Text != "Booked" {
+		t.Errorf("last message = %+v", last)
+	}
+}
+
+func TestDynamicHandoffToolAgents(t *testing.T) {
+	transfer := CreateDynamicHandoffTool(DynamicHandoffToolConfig{Agents: []string{"Flight Agent", "Hotel Agent"}})
+	schema := transfer.(SchemaProvider).Schema()
+	agentName := schema["properties"].(map[string]any)[HandoffAgentNameKey].(map[string]any)
+	if enum := agentName["enum"].([]string); len(enum) != 2 {
+		t.Errorf("enum = %v", enum)
+	}
+	if !strings.HasSuffix(transfer.Description(), "one of Flight Agent, Hotel Agent") {
+		t.Errorf("Description() = %q", transfer.Description())
+	}
+
+	ctx, capture := WithHandoffCapture(context.Background())
+	if _, err := transfer.Call(ctx, `{"agent_name":"hotel_agent","task_description":"Book"}`); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if handoff, _ := capture.Last(); handoff.AgentName != "Hotel Agent" || handoff.Payload[HandoffAgentNameKey] != nil {
+		t.Errorf("handoff = %+v", handoff)
+	}
+	if _, err := transfer.Call(ctx, `{"agent_name":"Car Agent"}`); err == nil {
+		t.Error("Call() with an unknown agent should return an error")
+	}
+	if got := NewToolNode([]tools.Tool{transfer}).HandoffDestinations(); len(got) != 2 {
+		t.Errorf("HandoffDestinations() = %v", got)
+	}
+}
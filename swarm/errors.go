@@ -0,0 +1,104 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Failure classes of a swarm, matched with errors.Is. The errors of failed
+// runs and configurations wrap one of them, or one of the sentinels of the
+// feature they concern, such as ErrInterrupted or ErrRecursionLimit.
+var (
+	// ErrUnknownAgent is matched by errors naming an agent the swarm does
+	// not have, e.g. as its active agent or an agent's fallback
+	ErrUnknownAgent = errors.New("unknown agent")
+	// ErrInvalidDestination is matched by errors about a handoff destination
+	// an agent cannot hand off to
+	ErrInvalidDestination = errors.New("invalid handoff destination")
+	// ErrAgentInvoke is matched by the *AgentInvokeError of a failed agent
+	// run
+	ErrAgentInvoke = errors.New("agent failed")
+	// ErrToolExecution is matched by the *ToolExecutionError of a failed
+	// tool call
+	ErrToolExecution = errors.New("tool failed")
+	// ErrHandoffDenied is matched by errors about a handoff denied by
+	// SwarmConfig.OnHandoff. A HandoffApprover may also return it to deny a
+	// handoff.
+	ErrHandoffDenied = errors.New("handoff denied")
+)
+
+// AgentInvokeError reports a failed agent run: its runnable, prompt,
+// context policy, middleware or state reducers failed. It matches
+// ErrAgentInvoke, and its Cause with errors.Is and errors.As.
+//
+// Example:
+//
+//	var agentErr *swarm.AgentInvokeError
+//	if errors.As(err, &agentErr) {
+//	    log.Printf("agent %s failed: %v", agentErr.Agent, agentErr.Cause)
+//	}
+type AgentInvokeError struct {
+	// Agent is the agent whose run failed
+	Agent string
+	// Cause is the error the run failed with
+	Cause error
+}
+
+// Error names the agent and the cause of the failure.
+func (e *AgentInvokeError) Error() string {
+	return fmt.Sprintf("agent '%s': %v", e.Agent, e.Cause)
+}
+
+// Unwrap returns the cause of the failure.
+func (e *AgentInvokeError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is ErrAgentInvoke.
+func (e *AgentInvokeError) Is(target error) bool {
+	return target == ErrAgentInvoke
+}
+
+// ToolExecutionError reports a failed tool call. A ToolNode tells the
+// model about the failure and carries on; the error is reported in
+// events.ToolCalled, and to callers when the tool call aborts the run, e.g.
+// because its context was cancelled. It matches ErrToolExecution, and its
+// Cause with errors.Is and errors.As.
+type ToolExecutionError struct {
+	// Tool is the name of the tool that failed
+	Tool string
+	// ToolCallID is the ID of the failed call
+	ToolCallID string
+	// Cause is the error returned by the tool
+	Cause error
+}
+
+// Error names the tool and the cause of the failure.
+func (e *ToolExecutionError) Error() string {
+	return fmt.Sprintf("tool '%s': %v", e.Tool, e.Cause)
+}
+
+// Unwrap returns the cause of the failure.
+func (e *ToolExecutionError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is ErrToolExecution.
+func (e *ToolExecutionError) Is(target error) bool {
+	return target == ErrToolExecution
+}
+
+// agentInvokeError wraps the error of agent's run in an *AgentInvokeError,
+// unless it is nil, already one, or stops the run rather than failing it
+// (an interrupt, limit or cancellation).
+func agentInvokeError(agent string, err error) error {
+	var invokeErr *AgentInvokeError
+	switch {
+	case err == nil, errors.As(err, &invokeErr),
+		errors.Is(err, ErrInterrupted), errors.Is(err, ErrRecursionLimit), errors.Is(err, ErrHandoffLimitExceeded),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrShutdown):
+		return err
+	}
+	return &AgentInvokeError{Agent: agent, Cause: err}
+}
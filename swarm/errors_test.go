@@ -0,0 +1,105 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// failingTool fails every call with its error.
+type failingTool struct{ err error }
+
+func (failingTool) Name() string        { return "fail" }
+func (failingTool) Description() string { return "Always fails" }
+func (f failingTool) Call(ctx context.Context, input string) (string, error) {
+	return "", f.err
+}
+
+func TestAgentInvokeError(t *testing.T) {
+	errUnavailable := errors.New("model unavailable")
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{Name: "Alice", Runnable: invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+			return state, errUnavailable
+		})}},
+		DefaultActiveAgent: "Alice",
+	})
+
+	_, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+	}})
+	var invokeErr *AgentInvokeError
+	if !errors.As(err, &invokeErr) || invokeErr.Agent != "Alice" || invokeErr.Cause != errUnavailable {
+		t.Fatalf("Invoke() error = %v, want an *AgentInvokeError of Alice", err)
+	}
+	if !errors.Is(err, ErrAgentInvoke) || !errors.Is(err, errUnavailable) {
+		t.Errorf("Invoke() error = %v, want it to match ErrAgentInvoke and its cause", err)
+	}
+	if invokeErr.Error() != "agent 'Alice': model unavailable" {
+		t.Errorf("Error() = %q", invokeErr.Error())
+	}
+}
+
+func TestUnknownAgentErrors(t *testing.T) {
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")}},
+		DefaultActiveAgent: "Alice",
+	})
+	_, err := app.Invoke(context.Background(), SwarmState{ActiveAgent: "Mallory"})
+	if !errors.Is(err, ErrUnknownAgent) {
+		t.Errorf("Invoke() error = %v, want ErrUnknownAgent", err)
+	}
+	if err := app.Swarm().RemoveAgent("Mallory"); !errors.Is(err, ErrUnknownAgent) {
+		t.Errorf("RemoveAgent() error = %v, want ErrUnknownAgent", err)
+	}
+
+	_, err = CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi"), Destinations: []string{"Bob"}}},
+		DefaultActiveAgent: "Alice",
+	})
+	if !errors.Is(err, ErrInvalidDestination) || errors.Is(err, ErrUnknownAgent) {
+		t.Errorf("CreateSwarm() error = %v, want ErrInvalidDestination", err)
+	}
+}
+
+func TestToolExecutionError(t *testing.T) {
+	errDown := errors.New("service down")
+	alice, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "fail", `{}`),
+		{Content: "The service is down"},
+	}}, []tools.Tool{failingTool{err: errDown}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := events.NewBus()
+	var toolErr error
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		if called, ok := e.(events.ToolCalled); ok {
+			toolErr = called.Err
+		}
+	})
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}},
+		DefaultActiveAgent: "Alice",
+		Events:             bus,
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Call the service"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	var execErr *ToolExecutionError
+	if !errors.As(toolErr, &execErr) || execErr.Tool != "fail" || execErr.ToolCallID != "call_1" ||
+		!errors.Is(toolErr, ErrToolExecution) || !errors.Is(toolErr, errDown) {
+		t.Errorf("ToolCalled error = %v, want a *ToolExecutionError of fail", toolErr)
+	}
+	// The model is told the cause only
+	if response := result.Messages[2].Parts[0].(llms.ToolCallResponse); response.Content != "Error: service down" {
+		t.Errorf("tool result = %q", response.Content)
+	}
+}
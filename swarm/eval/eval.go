@@ -0,0 +1,384 @@
+// Package eval runs scripted conversations against a swarm and scores their
+// outcomes, so prompt changes can be regression-tested.
+//
+// A Scenario sends user messages to the swarm, in order, and applies its
+// checks to the resulting conversation: the final agent, the tools called,
+// the final answer, or a rubric graded by a model (LLMJudge). Run returns a
+// Report that can be printed or saved as JSON:
+//
+//	report, err := eval.Run(ctx, app, []eval.Scenario{{
+//	    Name:  "refund",
+//	    Turns: []string{"I want a refund for order 42"},
+//	    Checks: []eval.Check{
+//	        eval.FinalAgent("Refunds"),
+//	        eval.ToolCalled("lookup_order"),
+//	        eval.AnswerContains("refund"),
+//	        &eval.LLMJudge{Model: judge, Rubric: "The answer is polite and gives a date."},
+//	    },
+//	}})
+//	report.WriteText(os.Stdout)
+//	if report.Failed > 0 {
+//	    t.Errorf("%d scenarios failed", report.Failed)
+//	}
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// defaultPassScore is the score an LLMJudge without PassScore requires.
+const defaultPassScore = 0.5
+
+// Scenario is a scripted conversation and the checks its outcome must pass.
+type Scenario struct {
+	// Name identifies the scenario in the report
+	Name string
+	// State is the state the conversation starts from, e.g. to start with
+	// another agent than the default one
+	State swarm.SwarmState
+	// Turns are the user messages, sent one invocation at a time
+	Turns []string
+	// Checks score the conversation after the last turn
+	Checks []Check
+	// Options are passed to every invocation
+	Options []swarm.InvokeOption
+}
+
+// Check scores the outcome of a scenario.
+type Check interface {
+	Evaluate(ctx context.Context, state swarm.SwarmState) CheckResult
+}
+
+// CheckFunc adapts a function to the Check interface.
+type CheckFunc func(ctx context.Context, state swarm.SwarmState) CheckResult
+
+// Evaluate calls f.
+func (f CheckFunc) Evaluate(ctx context.Context, state swarm.SwarmState) CheckResult {
+	return f(ctx, state)
+}
+
+// CheckResult is the score given by a Check.
+type CheckResult struct {
+	// Name describes the check, e.g. "final agent is Bob"
+	Name string `json:"name"`
+	// Passed reports whether the outcome is acceptable
+	Passed bool `json:"passed"`
+	// Score grades the outcome from 0 to 1
+	Score float64 `json:"score"`
+	// Detail explains a failure, or the judge's reasoning
+	Detail string `json:"detail,omitempty"`
+}
+
+// ScenarioResult is the outcome of a scenario.
+type ScenarioResult struct {
+	Scenario string `json:"scenario"`
+	// Passed reports whether the conversation completed and every check
+	// passed
+	Passed bool `json:"passed"`
+	// Score is the mean score of the checks
+	Score float64 `json:"score"`
+	// FinalAgent is the active agent after the last turn
+	FinalAgent string `json:"final_agent"`
+	// Answer is the text of the last AI message
+	Answer string        `json:"answer"`
+	Checks []CheckResult `json:"checks"`
+	// Error is the error that ended the conversation early, if any
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the outcome of a run of scenarios.
+type Report struct {
+	Results  []ScenarioResult `json:"results"`
+	Passed   int              `json:"passed"`
+	Failed   int              `json:"failed"`
+	Duration time.Duration    `json:"duration"`
+}
+
+// Run plays the scenarios against app, one after the other, and scores
+// them. A scenario whose invocation fails is reported as failed without
+// running its checks. Run only returns an error when ctx is done.
+func Run(ctx context.Context, app *swarm.CompiledSwarm, scenarios []Scenario) (*Report, error) {
+	report := &Report{}
+	start := time.Now()
+	for _, scenario := range scenarios {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		result := runScenario(ctx, app, scenario)
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// runScenario plays a scenario and applies its checks.
+func runScenario(ctx context.Context, app *swarm.CompiledSwarm, scenario Scenario) ScenarioResult {
+	result := ScenarioResult{Scenario: scenario.Name}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	state := scenario.State.Clone()
+	for _, turn := range scenario.Turns {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, turn))
+		var err error
+		if state, err = app.Invoke(ctx, state, scenario.Options...); err != nil {
+			result.Error = err.Error()
+			break
+		}
+	}
+	result.FinalAgent = state.ActiveAgent
+	result.Answer = answer(state)
+	if result.Error != "" {
+		return result
+	}
+
+	result.Passed = true
+	for _, check := range scenario.Checks {
+		checkResult := check.Evaluate(ctx, state)
+		result.Checks = append(result.Checks, checkResult)
+		result.Passed = result.Passed && checkResult.Passed
+		result.Score += checkResult.Score
+	}
+	if len(result.Checks) > 0 {
+		result.Score /= float64(len(result.Checks))
+	} else {
+		result.Score = 1
+	}
+	return result
+}
+
+// WriteText writes a human-readable summary of the report to w.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s %s (score %.2f, final agent %s, %s)\n",
+			status, result.Scenario, result.Score, result.FinalAgent, result.Duration.Round(time.Millisecond))
+		if result.Error != "" {
+			fmt.Fprintf(&b, "    error: %s\n", result.Error)
+		}
+		for _, check := range result.Checks {
+			mark := "ok  "
+			if !check.Passed {
+				mark = "FAIL"
+			}
+			fmt.Fprintf(&b, "    %s %s", mark, check.Name)
+			if check.Detail != "" {
+				fmt.Fprintf(&b, ": %s", check.Detail)
+			}
+			b.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed\n", r.Passed, r.Failed)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// FinalAgent checks that agent is the active agent after the last turn.
+func FinalAgent(agent string) Check {
+	return CheckFunc(func(ctx context.Context, state swarm.SwarmState) CheckResult {
+		result := CheckResult{Name: fmt.Sprintf("final agent is %s", agent)}
+		if state.ActiveAgent != agent {
+			result.Detail = fmt.Sprintf("final agent is %q", state.ActiveAgent)
+			return result
+		}
+		return pass(result)
+	})
+}
+
+// ToolCalled checks that the conversation calls the tool name.
+func ToolCalled(name string) Check {
+	return CheckFunc(func(ctx context.Context, state swarm.SwarmState) CheckResult {
+		result := CheckResult{Name: fmt.Sprintf("tool %s called", name)}
+		called := toolsCalled(state)
+		if !slices.Contains(called, name) {
+			result.Detail = fmt.Sprintf("called tools: %v", called)
+			return result
+		}
+		return pass(result)
+	})
+}
+
+// AnswerContains checks that the final answer contains substr, ignoring
+// case.
+func AnswerContains(substr string) Check {
+	return CheckFunc(func(ctx context.Context, state swarm.SwarmState) CheckResult {
+		result := CheckResult{Name: fmt.Sprintf("answer contains %q", substr)}
+		if text := answer(state); !strings.Contains(strings.ToLower(text), strings.ToLower(substr)) {
+			result.Detail = fmt.Sprintf("answer is %q", text)
+			return result
+		}
+		return pass(result)
+	})
+}
+
+// AnswerMatches checks that the final answer matches the regular expression
+// pattern. It panics if pattern does not compile.
+func AnswerMatches(pattern string) Check {
+	re := regexp.MustCompile(pattern)
+	return CheckFunc(func(ctx context.Context, state swarm.SwarmState) CheckResult {
+		result := CheckResult{Name: fmt.Sprintf("answer matches %s", pattern)}
+		if text := answer(state); !re.MatchString(text) {
+			result.Detail = fmt.Sprintf("answer is %q", text)
+			return result
+		}
+		return pass(result)
+	})
+}
+
+// pass marks result as passed with a full score.
+func pass(result CheckResult) CheckResult {
+	result.Passed, result.Score = true, 1
+	return result
+}
+
+// LLMJudge is a Check in which a model grades the conversation against a
+// rubric, such as "The answer apologizes and gives a delivery date".
+type LLMJudge struct {
+	// Model grades the conversation; a model different from the agents'
+	// gives more independent grades
+	Model llms.Model
+	// Rubric describes a good outcome
+	Rubric string
+	// PassScore is the minimum score, from 0 to 1, to pass (default: 0.5)
+	PassScore float64
+}
+
+// judgePrompt instructs the judge model.
+const judgePrompt = `You grade conversations between a user and an AI assistant made of several agents.
+Grade the conversation against the rubric with a score from 0 (fails the rubric) to 1 (fully meets it).
+Reply with a JSON object only: {"score": <number from 0 to 1>, "reason": "<one sentence>"}`
+
+// Evaluate asks the judge model for a grade. A failed model call or an
+// unreadable grade fails the check.
+func (j *LLMJudge) Evaluate(ctx context.Context, state swarm.SwarmState) CheckResult {
+	result := CheckResult{Name: fmt.Sprintf("judge: %s", j.Rubric)}
+	response, err := j.Model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, judgePrompt),
+		llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf("Rubric: %s\n\nConversation:\n%s", j.Rubric, transcript(state))),
+	}, llms.WithTemperature(0))
+	if err != nil {
+		result.Detail = fmt.Sprintf("judge failed: %v", err)
+		return result
+	}
+	if len(response.Choices) == 0 {
+		result.Detail = "judge failed: no response"
+		return result
+	}
+
+	var grade struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+	content := response.Choices[0].Content
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start || json.Unmarshal([]byte(content[start:end+1]), &grade) != nil {
+		result.Detail = fmt.Sprintf("judge gave no grade: %q", content)
+		return result
+	}
+
+	passScore := j.PassScore
+	if passScore == 0 {
+		passScore = defaultPassScore
+	}
+	result.Score = min(max(grade.Score, 0), 1)
+	result.Passed = result.Score >= passScore
+	result.Detail = grade.Reason
+	return result
+}
+
+// answer returns the text of the last AI message of state.
+func answer(state swarm.SwarmState) string {
+	for i := len(state.Messages) - 1; i >= 0; i-- {
+		if state.Messages[i].Role != llms.ChatMessageTypeAI {
+			continue
+		}
+		if text := messageText(state.Messages[i]); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// toolsCalled returns the names of the tools called in state, in order.
+func toolsCalled(state swarm.SwarmState) []string {
+	var names []string
+	for _, message := range state.Messages {
+		for _, part := range message.Parts {
+			if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+				names = append(names, call.FunctionCall.Name)
+			}
+		}
+	}
+	return names
+}
+
+// transcript renders the conversation of state for the judge.
+func transcript(state swarm.SwarmState) string {
+	var b strings.Builder
+	for _, message := range state.Messages {
+		if text := messageText(message); text != "" {
+			fmt.Fprintf(&b, "%s: %s\n", speaker(message.Role), text)
+		}
+		for _, part := range message.Parts {
+			switch part := part.(type) {
+			case llms.ToolCall:
+				if part.FunctionCall != nil {
+					fmt.Fprintf(&b, "%s: [calls %s %s]\n", speaker(message.Role), part.FunctionCall.Name, part.FunctionCall.Arguments)
+				}
+			case llms.ToolCallResponse:
+				fmt.Fprintf(&b, "tool %s: %s\n", part.Name, part.Content)
+			}
+		}
+	}
+	return b.String()
+}
+
+// speaker names the author of messages with role in a transcript.
+func speaker(role llms.ChatMessageType) string {
+	switch role {
+	case llms.ChatMessageTypeHuman:
+		return "user"
+	case llms.ChatMessageTypeAI:
+		return "assistant"
+	default:
+		return string(role)
+	}
+}
+
+// messageText returns the text parts of message, joined by newlines.
+func messageText(message llms.MessageContent) string {
+	var texts []string
+	for _, part := range message.Parts {
+		if text, ok := part.(llms.TextContent); ok && text.Text != "" {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
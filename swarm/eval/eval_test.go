@@ -0,0 +1,112 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+)
+
+func TestRun(t *testing.T) {
+	alice := swarmtest.NewMockAgent(swarmtest.Reply{Text: "Let me get Refunds", HandoffTo: "Refunds"})
+	refunds := swarmtest.NewMockAgent(
+		swarmtest.Reply{Text: "Your refund is on its way, expect it by Friday."},
+		swarmtest.Reply{Err: errors.New("refunds are down")},
+	)
+	app := swarmtest.Compile(t, swarm.SwarmConfig{
+		Agents: []swarm.Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Refunds", Runnable: refunds},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	judge := swarmtest.NewMockModel(swarmtest.Text(`Grade: {"score": 0.8, "reason": "Polite and gives a date."}`))
+
+	report, err := Run(context.Background(), app, []Scenario{
+		{
+			Name:  "refund",
+			Turns: []string{"I want a refund"},
+			Checks: []Check{
+				FinalAgent("Refunds"),
+				ToolCalled("transfer_to_refunds"),
+				AnswerContains("REFUND"),
+				AnswerMatches(`by \w+day`),
+				&LLMJudge{Model: judge, Rubric: "The answer gives a date."},
+			},
+		},
+		{
+			Name:   "wrong expectations",
+			State:  swarm.SwarmState{ActiveAgent: "Refunds"},
+			Turns:  []string{"Hi"},
+			Checks: []Check{FinalAgent("Alice")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Passed != 1 || report.Failed != 1 {
+		t.Fatalf("report = %d passed, %d failed, want 1 and 1", report.Passed, report.Failed)
+	}
+
+	first := report.Results[0]
+	if !first.Passed || first.FinalAgent != "Refunds" || !strings.Contains(first.Answer, "Friday") {
+		t.Errorf("first result = %+v", first)
+	}
+	if judged := first.Checks[4]; judged.Score != 0.8 || judged.Detail != "Polite and gives a date." {
+		t.Errorf("judge result = %+v", judged)
+	}
+	if want := 4.8 / 5; first.Score != want {
+		t.Errorf("score = %v, want %v", first.Score, want)
+	}
+	if calls := judge.Calls(); len(calls) != 1 || !strings.Contains(swarmtest.MessageText(calls[0][1]), "user: I want a refund") {
+		t.Errorf("judge calls = %v", calls)
+	}
+
+	// Refunds fails on its second run
+	second := report.Results[1]
+	if second.Passed || second.Error == "" || len(second.Checks) != 0 {
+		t.Errorf("second result = %+v, want the invocation error", second)
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"PASS refund", "FAIL wrong expectations", "error: ", "1 passed, 1 failed"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report misses %q:\n%s", want, text.String())
+		}
+	}
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Results[0].Checks[0].Name != "final agent is Refunds" {
+		t.Errorf("JSON report = %s, error = %v", buf.String(), err)
+	}
+}
+
+func TestChecksFail(t *testing.T) {
+	state := swarmtest.UserMessage("Hi")
+	state.ActiveAgent = "Alice"
+	ctx := context.Background()
+
+	for _, check := range []Check{
+		FinalAgent("Bob"),
+		ToolCalled("lookup"),
+		AnswerContains("hello"),
+		AnswerMatches(`^Hello`),
+		&LLMJudge{Model: swarmtest.NewMockModel(swarmtest.Text("Looks fine")), Rubric: "Says hello"},
+		&LLMJudge{Model: swarmtest.NewMockModel(swarmtest.Text(`{"score": 0.6}`)), Rubric: "Says hello", PassScore: 0.9},
+	} {
+		if result := check.Evaluate(ctx, state); result.Passed || result.Name == "" {
+			t.Errorf("check %q passed, want a failure", result.Name)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package events provides a publish/subscribe bus for swarm lifecycle events.
+//
+// Attach a Bus to a swarm with SwarmConfig.Events and subscribe to it to
+// build audit logs, metrics or UI updates:
+//
+//	bus := events.NewBus()
+//	bus.Subscribe(func(ctx context.Context, e events.Event) {
+//	    if h, ok := e.(events.HandoffOccurred); ok {
+//	        log.Printf("%s -> %s", h.From, h.To)
+//	    }
+//	})
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Type identifies the kind of an event.
+type Type string
+
+const (
+	TypeHandoffOccurred Type = "handoff_occurred"
+	TypeAgentInvoked    Type = "agent_invoked"
+	TypeAgentRetried    Type = "agent_retried"
+	TypeToolCalled      Type = "tool_called"
+	TypeTurnCompleted   Type = "turn_completed"
+	TypeErrorRaised     Type = "error_raised"
+	TypePolicyViolated  Type = "policy_violated"
+	TypeStateOffloaded  Type = "state_offloaded"
+	TypeHumanNeeded     Type = "human_needed"
+)
+
+// Event is a swarm lifecycle event. The concrete types are
+// HandoffOccurred, AgentInvoked, AgentRetried, ToolCalled, TurnCompleted,
+// ErrorRaised, PolicyViolated, StateOffloaded and HumanNeeded.
+type Event interface {
+	// Type returns the kind of the event
+	Type() Type
+	// OccurredAt returns when the event happened
+	OccurredAt() time.Time
+}
+
+// HandoffOccurred is published when an agent hands off to another agent.
+type HandoffOccurred struct {
+	Time    time.Time
+	From    string
+	To      string
+	Payload map[string]any
+}
+
+// AgentInvoked is published after an agent has run.
+type AgentInvoked struct {
+	Time     time.Time
+	Agent    string
+	Duration time.Duration
+	// Err is the error returned by the agent, if any
+	Err error
+}
+
+// AgentRetried is published when a failed agent run is retried under the
+// agent's retry policy.
+type AgentRetried struct {
+	Time  time.Time
+	Agent string
+	// Attempt is the number of the failed attempt, starting at 1
+	Attempt int
+	// Delay is the time waited before the next attempt
+	Delay time.Duration
+	// Err is the error of the failed attempt
+	Err error
+}
+
+// ToolCalled is published after an agent's tool call has been executed.
+type ToolCalled struct {
+	Time       time.Time
+	Agent      string
+	Tool       string
+	ToolCallID string
+	Arguments  string
+	Result     string
+	Duration   time.Duration
+	// Err is the error returned by the tool, if any
+	Err error
+}
+
+// TurnCompleted is published when an invocation of the swarm finishes.
+type TurnCompleted struct {
+	Time        time.Time
+	ActiveAgent string
+	Messages    int
+	Duration    time.Duration
+	// Err is the error that ended the turn, if any
+	Err error
+}
+
+// ErrorRaised is published when an agent fails.
+type ErrorRaised struct {
+	Time  time.Time
+	Agent string
+	Err   error
+}
+
+// PolicyViolated is published when a tool call is refused because the
+// agent's tool policy does not permit it.
+type PolicyViolated struct {
+	Time       time.Time
+	Agent      string
+	Tool       string
+	ToolCallID string
+	Reason     string
+}
+
+// StateOffloaded is published when message parts were offloaded or
+// summarized after an agent run, to keep the state under the swarm's
+// StateLimit.
+type StateOffloaded struct {
+	Time  time.Time
+	Agent string
+	// SizeBefore and SizeAfter are the encoded sizes of the state, in bytes
+	SizeBefore int
+	SizeAfter  int
+	// Artifacts names the artifacts the parts were offloaded to
+	Artifacts []string
+	// Summarized is the number of parts replaced with their summary
+	Summarized int
+}
+
+// HumanNeeded is published when a human agent becomes active and the run
+// pauses for a person's reply.
+type HumanNeeded struct {
+	Time  time.Time
+	Agent string
+	// ThreadID is the thread to resume with the reply
+	ThreadID string
+	// Messages is the conversation so far
+	Messages []llms.MessageContent
+	// Payload holds the arguments of the handoff to the agent, such as its
+	// task description, if any
+	Payload map[string]any
+}
+
+func (e HandoffOccurred) Type() Type            { return TypeHandoffOccurred }
+func (e HandoffOccurred) OccurredAt() time.Time { return e.Time }
+func (e AgentInvoked) Type() Type               { return TypeAgentInvoked }
+func (e AgentInvoked) OccurredAt() time.Time    { return e.Time }
+func (e AgentRetried) Type() Type               { return TypeAgentRetried }
+func (e AgentRetried) OccurredAt() time.Time    { return e.Time }
+func (e ToolCalled) Type() Type                 { return TypeToolCalled }
+func (e ToolCalled) OccurredAt() time.Time      { return e.Time }
+func (e TurnCompleted) Type() Type              { return TypeTurnCompleted }
+func (e TurnCompleted) OccurredAt() time.Time   { return e.Time }
+func (e ErrorRaised) Type() Type                { return TypeErrorRaised }
+func (e ErrorRaised) OccurredAt() time.Time     { return e.Time }
+func (e PolicyViolated) Type() Type             { return TypePolicyViolated }
+func (e PolicyViolated) OccurredAt() time.Time  { return e.Time }
+func (e StateOffloaded) Type() Type             { return TypeStateOffloaded }
+func (e StateOffloaded) OccurredAt() time.Time  { return e.Time }
+func (e HumanNeeded) Type() Type                { return TypeHumanNeeded }
+func (e HumanNeeded) OccurredAt() time.Time     { return e.Time }
+
+// Subscriber handles published events.
+type Subscriber func(ctx context.Context, event Event)
+
+// Bus delivers events to its subscribers.
+//
+// Publish calls subscribers synchronously, in subscription order, on the
+// publishing goroutine; subscribers that do slow work should hand events off
+// to their own goroutine. Bus is safe for concurrent use.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []subscription
+	nextID      int
+}
+
+// subscription is a registered subscriber.
+type subscription struct {
+	id    int
+	types []Type
+	fn    Subscriber
+}
+
+// NewBus creates an event bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn for events of the given types, or for all events
+// when no types are given. It returns a function that removes the subscription.
+func (b *Bus) Subscribe(fn Subscriber, types ...Type) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subscribers = append(b.subscribers, subscription{id: id, types: types, fn: fn})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.subscribers {
+			if sub.id == id {
+				b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers event to the matching subscribers.
+// Publishing on a nil Bus does nothing.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	for _, sub := range subscribers {
+		if sub.matches(event.Type()) {
+			sub.fn(ctx, event)
+		}
+	}
+}
+
+// matches reports whether the subscription wants events of type t.
+func (s subscription) matches(t Type) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	for _, want := range s.types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// busKey is the context key for the Bus of a run.
+type busKey struct{}
+
+// WithBus returns a context carrying bus, so components deeper in a run
+// (such as tool nodes) can publish to it.
+func WithBus(ctx context.Context, bus *Bus) context.Context {
+	return context.WithValue(ctx, busKey{}, bus)
+}
+
+// BusFromContext returns the Bus carried by ctx, or nil.
+func BusFromContext(ctx context.Context) *Bus {
+	bus, _ := ctx.Value(busKey{}).(*Bus)
+	return bus
+}
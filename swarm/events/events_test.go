@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBus(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+
+	var all, handoffs []Type
+	bus.Subscribe(func(ctx context.Context, e Event) { all = append(all, e.Type()) })
+	unsubscribe := bus.Subscribe(func(ctx context.Context, e Event) {
+		handoffs = append(handoffs, e.Type())
+	}, TypeHandoffOccurred)
+
+	bus.Publish(ctx, AgentInvoked{Time: time.Now(), Agent: "Alice"})
+	bus.Publish(ctx, HandoffOccurred{Time: time.Now(), From: "Alice", To: "Bob"})
+	unsubscribe()
+	bus.Publish(ctx, HandoffOccurred{Time: time.Now(), From: "Bob", To: "Alice"})
+
+	if len(all) != 3 {
+		t.Errorf("Expected 3 events for the catch-all subscriber, got %v", all)
+	}
+	if len(handoffs) != 1 || handoffs[0] != TypeHandoffOccurred {
+		t.Errorf("Expected 1 handoff before unsubscribing, got %v", handoffs)
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), ErrorRaised{Time: time.Now()})
+
+	if got := BusFromContext(context.Background()); got != nil {
+		t.Errorf("BusFromContext() = %v, want nil", got)
+	}
+	bus = NewBus()
+	if got := BusFromContext(WithBus(context.Background(), bus)); got != bus {
+		t.Errorf("BusFromContext() = %v, want %v", got, bus)
+	}
+}
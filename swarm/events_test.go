@@ -0,0 +1,68 @@
+package swarm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestSwarmEvents(t *testing.T) {
+	alice, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "upper", `{"input":"hi"}`),
+		toolCallChoice("call_2", "transfer_to_bob", `{}`),
+	}}, []tools.Tool{upperTool{}, CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	bus := events.NewBus()
+	var got []events.Type
+	var tool events.ToolCalled
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		got = append(got, e.Type())
+		if tc, ok := e.(events.ToolCalled); ok && tc.Tool == "upper" {
+			tool = tc
+		}
+	})
+
+	s, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+		Events:             bus,
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := s.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	if _, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")},
+	}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	want := []events.Type{
+		events.TypeToolCalled,
+		events.TypeToolCalled,
+		events.TypeAgentInvoked,
+		events.TypeHandoffOccurred,
+		events.TypeAgentInvoked,
+		events.TypeTurnCompleted,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Events = %v, want %v", got, want)
+	}
+	if tool.Agent != "Alice" || tool.ToolCallID != "call_1" || tool.Result != "HI" {
+		t.Errorf("Unexpected ToolCalled event %+v", tool)
+	}
+}
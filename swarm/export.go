@@ -0,0 +1,88 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExportMermaid renders the swarm topology as a Mermaid flowchart: agents
+// are nodes, handoff destinations are edges and fallbacks are dashed edges.
+// The default active agent is drawn with a thick border.
+//
+// Agents without Destinations may hand off to any agent and are drawn
+// without handoff edges; set SwarmConfig.InferDestinations to derive them
+// from the agents' handoff tools.
+//
+// Example:
+//
+//	fmt.Println("```mermaid\n" + workflow.ExportMermaid() + "```")
+func (s *Swarm) ExportMermaid() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make(map[string]string, len(s.config.Agents))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, agent := range s.config.Agents {
+		ids[agent.Name] = fmt.Sprintf("agent%d", i)
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", ids[agent.Name], mermaidEscape(agent.Name))
+	}
+	for _, agent := range s.config.Agents {
+		for _, dest := range agent.Destinations {
+			fmt.Fprintf(&b, "    %s --> %s\n", ids[agent.Name], ids[dest])
+		}
+		if agent.Fallback != "" {
+			fmt.Fprintf(&b, "    %s -.->|fallback| %s\n", ids[agent.Name], ids[agent.Fallback])
+		}
+	}
+	if id, ok := ids[s.config.DefaultActiveAgent]; ok {
+		b.WriteString("    classDef defaultAgent stroke-width:3px\n")
+		fmt.Fprintf(&b, "    class %s defaultAgent\n", id)
+	}
+	return b.String()
+}
+
+// ExportDOT renders the swarm topology in the Graphviz DOT language, with
+// the same nodes and edges as ExportMermaid. The default active agent is
+// drawn bold and filled.
+//
+// Example:
+//
+//	os.WriteFile("swarm.dot", []byte(workflow.ExportDOT()), 0o644)
+//	// dot -Tsvg swarm.dot -o swarm.svg
+func (s *Swarm) ExportDOT() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var b strings.Builder
+	b.WriteString("digraph swarm {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=box];\n")
+	for _, agent := range s.config.Agents {
+		if agent.Name == s.config.DefaultActiveAgent {
+			fmt.Fprintf(&b, "    %s [style=\"bold,filled\", fillcolor=lightblue];\n", dotQuote(agent.Name))
+		} else {
+			fmt.Fprintf(&b, "    %s;\n", dotQuote(agent.Name))
+		}
+	}
+	for _, agent := range s.config.Agents {
+		for _, dest := range agent.Destinations {
+			fmt.Fprintf(&b, "    %s -> %s;\n", dotQuote(agent.Name), dotQuote(dest))
+		}
+		if agent.Fallback != "" {
+			fmt.Fprintf(&b, "    %s -> %s [style=dashed, label=\"fallback\"];\n", dotQuote(agent.Name), dotQuote(agent.Fallback))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaidEscape escapes a node label for use in double quotes.
+func mermaidEscape(label string) string {
+	return strings.ReplaceAll(label, `"`, "#quot;")
+}
+
+// dotQuote returns name as a quoted DOT identifier.
+func dotQuote(name string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
@@ -0,0 +1,52 @@
+package swarm
+
+import "testing"
+
+func exportTestSwarm(t *testing.T) *Swarm {
+	t.Helper()
+	s, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi"), Destinations: []string{"Bob"}},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy"), Destinations: []string{"Alice"}, Fallback: `Human "desk"`},
+			{Name: `Human "desk"`, Runnable: createMockAgent("Human", "Hello")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	return s
+}
+
+func TestExportMermaid(t *testing.T) {
+	want := `flowchart LR
+    agent0["Alice"]
+    agent1["Bob"]
+    agent2["Human #quot;desk#quot;"]
+    agent0 --> agent1
+    agent1 --> agent0
+    agent1 -.->|fallback| agent2
+    classDef defaultAgent stroke-width:3px
+    class agent0 defaultAgent
+`
+	if got := exportTestSwarm(t).ExportMermaid(); got != want {
+		t.Errorf("ExportMermaid() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportDOT(t *testing.T) {
+	want := `digraph swarm {
+    rankdir=LR;
+    node [shape=box];
+    "Alice" [style="bold,filled", fillcolor=lightblue];
+    "Bob";
+    "Human \"desk\"";
+    "Alice" -> "Bob";
+    "Bob" -> "Alice";
+    "Bob" -> "Human \"desk\"" [style=dashed, label="fallback"];
+}
+`
+	if got := exportTestSwarm(t).ExportDOT(); got != want {
+		t.Errorf("ExportDOT() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// canFallback reports whether an agent that failed with err may hand the
+// conversation to its fallback agent. Interrupts, limits, moderation blocks
+// and a cancelled run are returned to the caller instead.
+func canFallback(ctx context.Context, err error) bool {
+	return ctx.Err() == nil &&
+		!errors.Is(err, ErrInterrupted) &&
+		!errors.Is(err, ErrHandoffLimitExceeded) &&
+		!errors.Is(err, ErrRecursionLimit) &&
+		!errors.Is(err, ErrContentFlagged)
+}
+
+// fallbackState returns state handed off from the failed agent to its
+// fallback agent. Messages produced by the failed run are discarded; a
+// system message tells the fallback agent what went wrong.
+func fallbackState(agent Agent, state SwarmState, err error) SwarmState {
+	reason := fmt.Sprintf("agent '%s' failed: %v", agent.Name, err)
+	state.Messages = append(slices.Clip(state.Messages), llms.TextParts(llms.ChatMessageTypeSystem,
+		fmt.Sprintf("Agent '%s' failed with an error and the conversation was transferred to you. Error: %v", agent.Name, err)))
+	state.ActiveAgent = agent.Fallback
+	state.HandoffPayload = map[string]any{"reason": reason, "error": err.Error()}
+	state.Handoffs = append(slices.Clip(state.Handoffs), HandoffRecord{
+		From:      agent.Name,
+		To:        agent.Fallback,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	})
+	return state
+}
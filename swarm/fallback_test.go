@@ -0,0 +1,99 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestFallback(t *testing.T) {
+	errUnavailable := errors.New("model unavailable")
+	input := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Book a flight")}}
+
+	t.Run("reroutes after retries", func(t *testing.T) {
+		calls := 0
+		var seen []llms.MessageContent
+		escalation := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+			seen = state.Messages
+			state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "A human will help you"))
+			return state, nil
+		})
+
+		bus := events.NewBus()
+		var raised []error
+		bus.Subscribe(func(ctx context.Context, e events.Event) {
+			raised = append(raised, e.(events.ErrorRaised).Err)
+		}, events.TypeErrorRaised)
+
+		app := compileSwarm(t, SwarmConfig{
+			Agents: []Agent{
+				{
+					Name:         "Flight",
+					Runnable:     flakyAgent(5, errUnavailable, &calls),
+					Destinations: []string{"Hotel"},
+					RetryPolicy:  RetryPolicy{MaxAttempts: 2, Backoff: noBackoff},
+					Fallback:     "human_escalation",
+				},
+				{Name: "Hotel", Runnable: createMockAgent("Hotel", "Hi")},
+				{Name: "human_escalation", Runnable: escalation},
+			},
+			DefaultActiveAgent: "Flight",
+			Events:             bus,
+		})
+		result, err := app.Invoke(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+
+		if calls != 2 {
+			t.Errorf("Expected 2 attempts before falling back, got %d", calls)
+		}
+		if result.ActiveAgent != "human_escalation" {
+			t.Errorf("Expected human_escalation to be active, got %q", result.ActiveAgent)
+		}
+		if len(seen) != 2 || seen[1].Role != llms.ChatMessageTypeSystem ||
+			!strings.Contains(seen[1].Parts[0].(llms.TextContent).Text, "model unavailable") {
+			t.Errorf("Expected the fallback agent to see the error, got %v", seen)
+		}
+		if len(result.Handoffs) != 1 || result.Handoffs[0].From != "Flight" || result.Handoffs[0].To != "human_escalation" {
+			t.Errorf("Unexpected handoffs %+v", result.Handoffs)
+		}
+		if len(raised) != 1 || !errors.Is(raised[0], errUnavailable) {
+			t.Errorf("Expected the failure to be reported, got %v", raised)
+		}
+	})
+
+	t.Run("not for interrupts", func(t *testing.T) {
+		failing := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+			return state, &InterruptError{Agent: "Flight", State: state}
+		})
+		app := compileSwarm(t, SwarmConfig{
+			Agents: []Agent{
+				{Name: "Flight", Runnable: failing, Fallback: "human_escalation"},
+				{Name: "human_escalation", Runnable: createMockAgent("human_escalation", "Hi")},
+			},
+			DefaultActiveAgent: "Flight",
+		})
+		if _, err := app.Invoke(context.Background(), input); !errors.Is(err, ErrInterrupted) {
+			t.Errorf("Invoke() error = %v, want ErrInterrupted", err)
+		}
+	})
+}
+
+func TestFallbackValidation(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi"), Fallback: "Alice"},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Hi"), Fallback: "Carol"},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 2 {
+		t.Errorf("CreateSwarm() error = %v, want 2 problems", err)
+	}
+}
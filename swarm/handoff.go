@@ -2,9 +2,14 @@ package swarm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/tmc/langchaingo/llms"
@@ -14,6 +19,15 @@ import (
 const (
 	// MetadataKeyHandoffDestination is the metadata key for handoff destination
 	MetadataKeyHandoffDestination = "__handoff_destination"
+
+	// HandoffTaskDescriptionKey is the argument of the default handoff tool
+	// schema in which the model describes the task for the receiving agent.
+	// It is available to that agent in SwarmState.HandoffPayload.
+	HandoffTaskDescriptionKey = "task_description"
+
+	// handoffPrefix is the legacy marker returned by handoff tools called
+	// without a HandoffCapture.
+	handoffPrefix = "__HANDOFF__"
 )
 
 var whitespaceRe = regexp.MustCompile(`\s+`)
@@ -25,6 +39,40 @@ func normalizeAgentName(agentName string) string {
 	return strings.ToLower(normalized)
 }
 
+// HandoffMode tells whether the agent receiving a handoff runs right away or
+// on the user's next message.
+type HandoffMode int
+
+const (
+	// HandoffContinue runs the target agent within the same invocation. This
+	// is the default.
+	HandoffContinue HandoffMode = iota
+
+	// HandoffEndTurn ends the turn once the handing-off agent has answered
+	// the user, e.g. "I'm transferring you to billing, please confirm". The
+	// target agent is active and starts the next turn.
+	HandoffEndTurn
+)
+
+// String returns the name of the handoff mode.
+func (m HandoffMode) String() string {
+	switch m {
+	case HandoffContinue:
+		return "Continue"
+	case HandoffEndTurn:
+		return "EndTurn"
+	default:
+		return "HandoffMode(unknown)"
+	}
+}
+
+// endsTurn reports whether the last handoff of state was made with
+// HandoffEndTurn and is still in effect.
+func endsTurn(state SwarmState) bool {
+	n := len(state.Handoffs)
+	return n > 0 && state.Handoffs[n-1].Mode == HandoffEndTurn && state.Handoffs[n-1].To == state.ActiveAgent
+}
+
 // HandoffToolConfig holds configuration for creating a handoff tool
 type HandoffToolConfig struct {
 	// AgentName is the name of the agent to handoff control to
@@ -33,13 +81,231 @@ type HandoffToolConfig struct {
 	Name string
 	// Description is the optional description for the handoff tool
 	Description string
+	// InputSchema is an optional JSON schema (an object schema) for arguments
+	// the model passes along with the handoff. The parsed arguments are written
+	// to SwarmState.HandoffPayload so the receiving agent sees why it was invoked.
+	// By default the tool takes a required "task_description" string; use an
+	// object schema without properties for a tool that takes no arguments.
+	InputSchema map[string]any
+	// Mode tells whether the target agent runs within the same invocation
+	// (HandoffContinue, the default) or on the next one (HandoffEndTurn)
+	Mode HandoffMode
+}
+
+// HandoffTool is implemented by tools that transfer control to another agent.
+// ToolNode passes handoff tools their raw JSON arguments.
+type HandoffTool interface {
+	tools.Tool
+	// HandoffDestination returns the name of the agent to hand off to.
+	HandoffDestination() string
+}
+
+// HandoffResult describes a handoff requested by a tool.
+type HandoffResult struct {
+	// AgentName is the agent to hand off control to
+	AgentName string
+	// ToolName is the name of the tool that requested the handoff
+	ToolName string
+	// Payload holds the arguments passed to the target agent, if any
+	Payload map[string]any
+	// Mode tells whether the target agent runs within the same invocation
+	Mode HandoffMode
+}
+
+// HandoffRecord describes a transfer of control between two agents.
+type HandoffRecord struct {
+	// From is the agent that handed off
+	From string `json:"from"`
+	// To is the agent that received control
+	To string `json:"to"`
+	// Reason is the "reason" (or else "task_description") argument of the
+	// handoff tool call, if any
+	Reason string `json:"reason,omitempty"`
+	// ToolCallID is the ID of the handoff tool call, if the handoff was
+	// made through a tool
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Mode is HandoffEndTurn when the target agent starts the next turn
+	// rather than running within the invocation
+	Mode HandoffMode `json:"mode,omitempty"`
+	// Timestamp is when the handoff happened
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HandoffCapture collects the handoffs requested by tools called with a
+// context returned from WithHandoffCapture.
+type HandoffCapture struct {
+	mu       sync.Mutex
+	handoffs []HandoffResult
+}
+
+// Handoffs returns the handoffs recorded so far, in order.
+func (c *HandoffCapture) Handoffs() []HandoffResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.handoffs)
+}
+
+// Last returns the most recently recorded handoff.
+func (c *HandoffCapture) Last() (HandoffResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.handoffs) == 0 {
+		return HandoffResult{}, false
+	}
+	return c.handoffs[len(c.handoffs)-1], true
+}
+
+type handoffCaptureKey struct{}
+
+// WithHandoffCapture returns a copy of ctx in which handoff tools report the
+// handoffs they request to the returned capture.
+//
+// ToolNode and CreateReactAgent do this automatically; use it directly when
+// executing tool calls by hand.
+//
+// Example:
+//
+//	ctx, capture := swarm.WithHandoffCapture(ctx)
+//	result, _ := tool.Call(ctx, input)
+//	if handoff, ok := capture.Last(); ok {
+//	    state.ActiveAgent = handoff.AgentName
+//	}
+func WithHandoffCapture(ctx context.Context) (context.Context, *HandoffCapture) {
+	capture := &HandoffCapture{}
+	return context.WithValue(ctx, handoffCaptureKey{}, capture), capture
+}
+
+// RecordHandoff reports a handoff from inside a tool's Call method.
+// It returns false if ctx carries no HandoffCapture.
+func RecordHandoff(ctx context.Context, result HandoffResult) bool {
+	capture, ok := ctx.Value(handoffCaptureKey{}).(*HandoffCapture)
+	if !ok {
+		return false
+	}
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	capture.handoffs = append(capture.handoffs, result)
+	return true
+}
+
+// HandoffApprover decides on the handoffs requested by handoff tools (see
+// SwarmConfig.OnHandoff). It returns false, or an error matching
+// ErrHandoffDenied, to deny a handoff, or an error from RedirectHandoff to
+// redirect it. Other errors fail the run.
+type HandoffApprover func(ctx context.Context, from, to string, state SwarmState) (bool, error)
+
+// handoffRedirect is the error returned by RedirectHandoff.
+type handoffRedirect struct {
+	to string
+}
+
+func (r *handoffRedirect) Error() string {
+	return fmt.Sprintf("handoff redirected to '%s'", r.to)
+}
+
+// RedirectHandoff returns the error a HandoffApprover returns to send a
+// handoff to agent instead of the requested one.
+//
+// Example:
+//
+//	OnHandoff: func(ctx context.Context, from, to string, state swarm.SwarmState) (bool, error) {
+//	    if to == "refund_agent" && !isPremium(ctx) {
+//	        return false, swarm.RedirectHandoff("billing_agent")
+//	    }
+//	    return true, nil
+//	}
+func RedirectHandoff(agent string) error {
+	return &handoffRedirect{to: agent}
+}
+
+// handoffApproverKey is the context key for the HandoffApprover of a run.
+type handoffApproverKey struct{}
+
+// withHandoffApprover returns a context in which tool nodes ask approve
+// before each handoff.
+func withHandoffApprover(ctx context.Context, approve HandoffApprover) context.Context {
+	return context.WithValue(ctx, handoffApproverKey{}, approve)
+}
+
+// approveHandoff asks the HandoffApprover of ctx, if any, about a handoff
+// from one agent to another. It returns the agent to hand off to, or "" when
+// the handoff is denied. Handoffs away from the state's pinned agent are
+// always denied.
+func approveHandoff(ctx context.Context, from, to string, state SwarmState) (string, error) {
+	if state.PinnedAgent != "" && to != state.PinnedAgent {
+		return "", nil
+	}
+	approve, ok := ctx.Value(handoffApproverKey{}).(HandoffApprover)
+	if !ok {
+		return to, nil
+	}
+	allowed, err := approve(ctx, from, to, state)
+	var redirect *handoffRedirect
+	switch {
+	case errors.As(err, &redirect):
+		return redirect.to, nil
+	case errors.Is(err, ErrHandoffDenied):
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("handoff from '%s' to '%s': %w", from, to, err)
+	case !allowed:
+		return "", nil
+	}
+	return to, nil
+}
+
+// deniedTransferMessage is the tool message content refusing a handoff.
+func deniedTransferMessage(agentName string) string {
+	return fmt.Sprintf("Transfer to %s was denied. Continue without handing off to %s.", agentName, agentName)
+}
+
+// handoffMessage is the tool message content confirming a handoff, with the
+// task description passed along and, when the handoff ends the turn, a
+// reminder to tell the user.
+func handoffMessage(result HandoffResult) string {
+	message := transferMessage(result.AgentName)
+	if task, _ := result.Payload[HandoffTaskDescriptionKey].(string); task != "" {
+		message += "\n\nTask description: " + task
+	}
+	if result.Mode == HandoffEndTurn {
+		message += fmt.Sprintf("\n\n%s takes over from the user's next message. Let the user know.", result.AgentName)
+	}
+	return message
+}
+
+// defaultHandoffToolName is the name of handoff tools created without a
+// Name.
+func defaultHandoffToolName(agentName string) string {
+	return fmt.Sprintf("transfer_to_%s", normalizeAgentName(agentName))
 }
 
-// handoffTool implements the tools.Tool interface for agent handoffs
+// transferMessage is the tool message content confirming a handoff.
+func transferMessage(agentName string) string {
+	return fmt.Sprintf("Successfully transferred to %s", agentName)
+}
+
+// defaultHandoffSchema is the argument schema of handoff tools created
+// without an InputSchema.
+func defaultHandoffSchema(agentName string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			HandoffTaskDescriptionKey: map[string]any{
+				"type":        "string",
+				"description": fmt.Sprintf("What '%s' should do next, with the context it needs", agentName),
+			},
+		},
+		"required": []string{HandoffTaskDescriptionKey},
+	}
+}
+
+// handoffTool implements the HandoffTool interface for agent handoffs
 type handoffTool struct {
 	name        string
 	description string
 	agentName   string
+	inputSchema map[string]any
+	mode        HandoffMode
 }
 
 func (t *handoffTool) Name() string {
@@ -50,17 +316,48 @@ func (t *handoffTool) Description() string {
 	return t.description
 }
 
+// Call records the handoff in the context's HandoffCapture and returns the
+// transfer confirmation shown to the model. Without a capture it falls back to
+// the deprecated "__HANDOFF__<agent_name>" marker for ParseHandoffResult.
 func (t *handoffTool) Call(ctx context.Context, input string) (string, error) {
-	// Return a special marker that the agent node will detect and convert to Command
-	// The marker format is: __HANDOFF__<agent_name>
-	// The agent node should parse this and return graph.Command{Goto: agent_name, Update: ...}
-	return fmt.Sprintf("__HANDOFF__%s", t.agentName), nil
+	result := HandoffResult{AgentName: t.agentName, ToolName: t.name, Mode: t.mode}
+	if strings.TrimSpace(input) != "" {
+		if err := json.Unmarshal([]byte(input), &result.Payload); err != nil {
+			return "", fmt.Errorf("invalid handoff arguments for %s: %w", t.name, err)
+		}
+		if len(result.Payload) == 0 {
+			result.Payload = nil
+		}
+	}
+
+	if RecordHandoff(ctx, result) {
+		return handoffMessage(result), nil
+	}
+	return handoffPrefix + t.agentName, nil
+}
+
+// HandoffDestination returns the agent this tool hands off to.
+func (t *handoffTool) HandoffDestination() string {
+	return t.agentName
+}
+
+// Schema returns the JSON schema advertised for the tool's arguments.
+func (t *handoffTool) Schema() map[string]any {
+	if t.inputSchema != nil {
+		return t.inputSchema
+	}
+	return defaultHandoffSchema(t.agentName)
 }
 
 // CreateHandoffTool creates a tool that can handoff control to the requested agent.
 //
-// The tool returns a marker that indicates a handoff should occur.
-// The swarm system will detect this and update the active agent accordingly.
+// When called, the tool records a HandoffResult in the context's
+// HandoffCapture (see WithHandoffCapture). ToolNode and CreateReactAgent use
+// it to update the active agent accordingly.
+//
+// By default the model must pass a "task_description" telling the receiving
+// agent why it got control; it is added to the transfer tool message and to
+// SwarmState.HandoffPayload.
 //
 // Args:
 //   - config: Configuration for the handoff tool
@@ -74,10 +371,22 @@ func (t *handoffTool) Call(ctx context.Context, input string) (string, error) {
 //	    AgentName: "Bob",
 //	    Description: "Transfer to Bob for pirate speak",
 //	})
+//
+//	// With arguments passed to the target agent:
+//	transferToHotel := swarm.CreateHandoffTool(swarm.HandoffToolConfig{
+//	    AgentName: "hotel_assistant",
+//	    InputSchema: map[string]any{
+//	        "type": "object",
+//	        "properties": map[string]any{
+//	            "city": map[string]any{"type": "string"},
+//	        },
+//	        "required": []string{"city"},
+//	    },
+//	})
 func CreateHandoffTool(config HandoffToolConfig) tools.Tool {
 	name := config.Name
 	if name == "" {
-		name = fmt.Sprintf("transfer_to_%s", normalizeAgentName(config.AgentName))
+		name = defaultHandoffToolName(config.AgentName)
 	}
 
 	description := config.Description
@@ -89,12 +398,21 @@ func CreateHandoffTool(config HandoffToolConfig) tools.Tool {
 		name:        name,
 		description: description,
 		agentName:   config.AgentName,
+		inputSchema: config.InputSchema,
+		mode:        config.Mode,
 	}
 }
 
 // CreateHandoffCommand creates a Command for handing off to another agent.
 // This function integrates with LangGraphGo's Command API for dynamic routing.
 //
+// The Command adds the tool message answering the handoff tool call: a
+// ToolCallResponse carrying toolCallID and the default handoff tool name, as
+// providers such as OpenAI and Anthropic reject tool calls left without a
+// response. Without a toolCallID there is no call to answer, so no message
+// is added. For handoffs captured from tools with a custom name, use
+// HandoffResult.Command, which answers with the tool's name.
+//
 // Args:
 //   - targetAgent: The name of the agent to handoff to
 //   - toolCallID: The ID of the tool call that triggered the handoff (optional)
@@ -104,56 +422,81 @@ func CreateHandoffTool(config HandoffToolConfig) tools.Tool {
 //
 // Example:
 //
-//	// In agent node after detecting handoff marker:
-//	if strings.HasPrefix(toolResult, "__HANDOFF__") {
-//	    targetAgent := strings.TrimPrefix(toolResult, "__HANDOFF__")
-//	    return CreateHandoffCommand(targetAgent, toolCallID), nil
-//	}
+//	cmd := CreateHandoffCommand("Bob", toolCallID)
 func CreateHandoffCommand(targetAgent, toolCallID string) *graph.Command {
-	// Create tool message
-	toolMessage := llms.TextParts("tool",
-		fmt.Sprintf("Successfully transferred to %s", targetAgent))
+	return HandoffResult{AgentName: targetAgent}.Command(toolCallID)
+}
 
-	// Set tool_call_id if provided
+// Command creates a Command handing off to r.AgentName, like
+// CreateHandoffCommand, whose tool message is named after r.ToolName (or
+// the default handoff tool name when it is empty). The handoff behaves as
+// when ToolNode runs the tool: the tool message carries the task
+// description, r.Payload becomes the state's HandoffPayload, and with
+// HandoffEndTurn Goto is nil, leaving the target active for the next turn.
+//
+// Example:
+//
+//	// In agent node after a handoff tool ran:
+//	if handoff, ok := capture.Last(); ok {
+//	    return handoff.Command(toolCallID), nil
+//	}
+func (r HandoffResult) Command(toolCallID string) *graph.Command {
+	update := map[string]any{
+		"active_agent": r.AgentName,
+	}
+	if r.Payload != nil {
+		update["handoff_payload"] = r.Payload
+	}
 	if toolCallID != "" {
-		// Add tool call ID to message metadata if needed
-		// This depends on how langchaingo handles tool message IDs
-	}
-
-	// Return Command with dynamic routing and state update
-	// The "messages" field will be processed by graph.AddMessages reducer
-	// which provides intelligent merging with ID-based deduplication
-	return &graph.Command{
-		Goto: targetAgent,
-		Update: map[string]any{
-			"messages":     []llms.MessageContent{toolMessage},
-			"active_agent": targetAgent,
-		},
+		toolName := r.ToolName
+		if toolName == "" {
+			toolName = defaultHandoffToolName(r.AgentName)
+		}
+		// The "messages" field will be processed by graph.AddMessages reducer
+		// which provides intelligent merging with ID-based deduplication
+		update["messages"] = []llms.MessageContent{{
+			Role: llms.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{
+				llms.ToolCallResponse{
+					ToolCallID: toolCallID,
+					Name:       toolName,
+					Content:    handoffMessage(r),
+				},
+			},
+		}}
+	}
+	cmd := &graph.Command{Update: update}
+	if r.Mode != HandoffEndTurn {
+		cmd.Goto = r.AgentName
 	}
+	return cmd
 }
 
 // ParseHandoffResult checks if a tool result is a handoff marker and returns the target agent.
 // Returns the target agent name and true if it's a handoff, empty string and false otherwise.
 //
-// Example:
-//
-//	if targetAgent, isHandoff := ParseHandoffResult(toolResult); isHandoff {
-//	    return CreateHandoffCommand(targetAgent, toolCallID), nil
-//	}
+// Deprecated: handoff tools report handoffs through HandoffCapture and only
+// return the marker when called without one. Use WithHandoffCapture instead.
 func ParseHandoffResult(result string) (targetAgent string, isHandoff bool) {
-	const handoffPrefix = "__HANDOFF__"
 	if strings.HasPrefix(result, handoffPrefix) {
 		return strings.TrimPrefix(result, handoffPrefix), true
 	}
 	return "", false
 }
 
-// GetHandoffDestinationsFromAgent extracts handoff destinations from a compiled agent.
-// This analyzes the agent's graph to find tools with handoff metadata.
+// HandoffDestinationsProvider is implemented by agent runnables that can
+// report which agents they hand off to, such as ReactAgent and ToolNode.
+// CreateSwarm uses it when SwarmConfig.InferDestinations is set.
+type HandoffDestinationsProvider interface {
+	HandoffDestinations() []string
+}
+
+// GetHandoffDestinationsFromAgent returns the handoff destinations of a
+// compiled agent.
 //
 // Args:
-//   - agent: The compiled agent graph to analyze
-//   - toolNodeName: Name of the tool node to inspect (default: "tools")
+//   - agent: The agent runnable to inspect
+//   - toolNodeName: Unused; kept for compatibility
 //
 // Returns:
 //   - List of agent names that can be handed off to
@@ -163,51 +506,13 @@ func ParseHandoffResult(result string) (targetAgent string, isHandoff bool) {
 //	destinations := swarm.GetHandoffDestinationsFromAgent(aliceAgent, "tools")
 //	// Returns: ["Bob", "Charlie"] if Alice has handoff tools to Bob and Charlie
 //
-// Note: This requires the agent graph to expose its tool nodes, which may not be
-// available in all LangGraphGo versions. Returns empty list if inspection is not possible.
+// Note: Only agents implementing HandoffDestinationsProvider can be inspected;
+// an empty list is returned for others.
 func GetHandoffDestinationsFromAgent(agent any, toolNodeName string) []string {
-	if agent == nil {
-		return []string{}
+	if provider, ok := agent.(HandoffDestinationsProvider); ok {
+		if destinations := provider.HandoffDestinations(); destinations != nil {
+			return destinations
+		}
 	}
-
-	// For now, return empty list - this requires introspection of the CompiledGraph
-	// In a full implementation, we would:
-	// 1. Get the graph structure from agent.GetGraph()
-	// 2. Find the tool node by name
-	// 3. Extract tools from that node
-	// 4. Call GetHandoffDestinations on those tools
-
-	// TODO: Implement graph introspection when LangGraphGo exposes graph structure API
 	return []string{}
 }
-
-// isHandoffResponse checks if a tool response indicates a handoff (private helper)
-func isHandoffResponse(response string) (bool, string) {
-	if strings.HasPrefix(response, "__HANDOFF__") {
-		agentName := strings.TrimPrefix(response, "__HANDOFF__")
-		return true, agentName
-	}
-	return false, ""
-}
-
-// processHandoff processes a handoff in the agent execution flow (private helper).
-// This should be called after tool execution to handle handoffs.
-//
-// Args:
-//   - state: Current swarm state
-//   - toolResponse: Response from tool execution
-//
-// Returns:
-//   - Updated state with handoff processed
-//   - Boolean indicating if handoff occurred
-func processHandoff(state SwarmState, toolResponse string) (SwarmState, bool) {
-	if isHandoff, agentName := isHandoffResponse(toolResponse); isHandoff {
-		// Add tool message
-		toolMessage := llms.TextParts("tool",
-			fmt.Sprintf("Successfully transferred to %s", agentName))
-		state.Messages = append(state.Messages, toolMessage)
-		state.ActiveAgent = agentName
-		return state, true
-	}
-	return state, false
-}
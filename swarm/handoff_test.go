@@ -1,7 +1,14 @@
 package swarm
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
 )
 
 func TestCreateHandoffTool(t *testing.T) {
@@ -62,3 +69,373 @@ func TestNormalizeAgentName(t *testing.T) {
 		})
 	}
 }
+
+func TestHandoffToolRecordsHandoff(t *testing.T) {
+	tool := CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})
+
+	ctx, capture := WithHandoffCapture(context.Background())
+	result, err := tool.Call(ctx, "")
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if result != "Successfully transferred to Bob" {
+		t.Errorf("Unexpected result %q", result)
+	}
+	if _, isHandoff := ParseHandoffResult(result); isHandoff {
+		t.Error("Result should not contain the legacy marker when captured")
+	}
+
+	handoff, ok := capture.Last()
+	if !ok {
+		t.Fatal("Expected a recorded handoff")
+	}
+	if handoff.AgentName != "Bob" || handoff.ToolName != "transfer_to_bob" {
+		t.Errorf("Unexpected handoff %+v", handoff)
+	}
+
+	if ht, ok := tool.(HandoffTool); !ok || ht.HandoffDestination() != "Bob" {
+		t.Error("Handoff tools should implement HandoffTool")
+	}
+}
+
+func TestHandoffToolLegacyMarker(t *testing.T) {
+	tool := CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})
+
+	result, err := tool.Call(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	target, isHandoff := ParseHandoffResult(result)
+	if !isHandoff || target != "Bob" {
+		t.Errorf("ParseHandoffResult(%q) = %q, %v", result, target, isHandoff)
+	}
+
+	if RecordHandoff(context.Background(), HandoffResult{AgentName: "Bob"}) {
+		t.Error("RecordHandoff() should report false without a capture")
+	}
+}
+
+func TestHandoffToolPayload(t *testing.T) {
+	tool := CreateHandoffTool(HandoffToolConfig{
+		AgentName: "hotel_assistant",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string"},
+			},
+		},
+	})
+
+	node := NewToolNode([]tools.Tool{tool})
+	state := SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{
+			Name:      "transfer_to_hotel_assistant",
+			Arguments: `{"city":"New York"}`,
+		}}),
+	}}
+
+	result, err := node.Invoke(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "hotel_assistant" {
+		t.Errorf("Expected active agent hotel_assistant, got %q", result.ActiveAgent)
+	}
+	if result.HandoffPayload["city"] != "New York" {
+		t.Errorf("Expected payload city New York, got %v", result.HandoffPayload)
+	}
+
+	if def := ToolDefinition(tool); def.Function.Parameters.(map[string]any)["properties"].(map[string]any)["city"] == nil {
+		t.Errorf("Expected the input schema to be advertised, got %v", def.Function.Parameters)
+	}
+
+	if _, err := tool.Call(context.Background(), "not json"); err == nil {
+		t.Error("Expected error for invalid handoff arguments")
+	}
+}
+
+func TestHandoffToolTaskDescription(t *testing.T) {
+	tool := CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})
+
+	params := ToolDefinition(tool).Function.Parameters.(map[string]any)
+	if params["properties"].(map[string]any)[HandoffTaskDescriptionKey] == nil {
+		t.Errorf("Expected a task_description argument, got %v", params)
+	}
+	if required, _ := params["required"].([]string); !slices.Contains(required, HandoffTaskDescriptionKey) {
+		t.Errorf("Expected task_description to be required, got %v", params["required"])
+	}
+
+	node := NewToolNode([]tools.Tool{tool})
+	result, err := node.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{
+			Name:      "transfer_to_bob",
+			Arguments: `{"task_description":"Translate the answer into pirate speak"}`,
+		}}),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	response := result.Messages[1].Parts[0].(llms.ToolCallResponse)
+	want := "Successfully transferred to Bob\n\nTask description: Translate the answer into pirate speak"
+	if response.Content != want {
+		t.Errorf("Tool message = %q, want %q", response.Content, want)
+	}
+	if result.HandoffPayload[HandoffTaskDescriptionKey] != "Translate the answer into pirate speak" {
+		t.Errorf("Unexpected payload %v", result.HandoffPayload)
+	}
+	if len(result.Handoffs) != 1 || result.Handoffs[0].Reason != "Translate the answer into pirate speak" {
+		t.Errorf("Unexpected handoffs %+v", result.Handoffs)
+	}
+}
+
+func TestCreateHandoffCommand(t *testing.T) {
+	cmd := CreateHandoffCommand("Hotel Agent", "call_42")
+	if cmd.Goto != "Hotel Agent" {
+		t.Errorf("Goto = %v, want Hotel Agent", cmd.Goto)
+	}
+	update := cmd.Update.(map[string]any)
+	if update["active_agent"] != "Hotel Agent" {
+		t.Errorf("active_agent = %v", update["active_agent"])
+	}
+	messages := update["messages"].([]llms.MessageContent)
+	if len(messages) != 1 || messages[0].Role != llms.ChatMessageTypeTool || len(messages[0].Parts) != 1 {
+		t.Fatalf("messages = %+v, want one tool message", messages)
+	}
+	response, ok := messages[0].Parts[0].(llms.ToolCallResponse)
+	if !ok {
+		t.Fatalf("part = %T, want llms.ToolCallResponse", messages[0].Parts[0])
+	}
+	want := llms.ToolCallResponse{
+		ToolCallID: "call_42",
+		Name:       "transfer_to_hotel_agent",
+		Content:    "Successfully transferred to Hotel Agent",
+	}
+	if response != want {
+		t.Errorf("response = %+v, want %+v", response, want)
+	}
+}
+
+func TestHandoffResultCommand(t *testing.T) {
+	tool := CreateHandoffTool(HandoffToolConfig{AgentName: "Bob", Name: "escalate"})
+	ctx, capture := WithHandoffCapture(context.Background())
+	if _, err := tool.Call(ctx, `{}`); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	handoff, ok := capture.Last()
+	if !ok {
+		t.Fatal("no handoff captured")
+	}
+	update := handoff.Command("call_7").Update.(map[string]any)
+	response := update["messages"].([]llms.MessageContent)[0].Parts[0].(llms.ToolCallResponse)
+	if response.Name != "escalate" || response.ToolCallID != "call_7" {
+		t.Errorf("response = %+v, want one answering the escalate call", response)
+	}
+}
+
+func TestHandoffResultCommandModes(t *testing.T) {
+	payload := map[string]any{HandoffTaskDescriptionKey: "Refund order 42"}
+	for _, tt := range []struct {
+		mode     HandoffMode
+		wantGoto any
+		wantText string
+	}{
+		{HandoffContinue, "Bob", "Successfully transferred to Bob\n\nTask description: Refund order 42"},
+		{HandoffEndTurn, nil, "Bob takes over from the user's next message."},
+	} {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			cmd := HandoffResult{AgentName: "Bob", Payload: payload, Mode: tt.mode}.Command("call_1")
+			if cmd.Goto != tt.wantGoto {
+				t.Errorf("Goto = %v, want %v", cmd.Goto, tt.wantGoto)
+			}
+			update := cmd.Update.(map[string]any)
+			if update["active_agent"] != "Bob" || update["handoff_payload"].(map[string]any)[HandoffTaskDescriptionKey] != "Refund order 42" {
+				t.Errorf("Update = %v", update)
+			}
+			response := update["messages"].([]llms.MessageContent)[0].Parts[0].(llms.ToolCallResponse)
+			if !strings.Contains(response.Content, tt.wantText) {
+				t.Errorf("tool message = %q, want it to contain %q", response.Content, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCreateHandoffCommandWithoutToolCall(t *testing.T) {
+	cmd := CreateHandoffCommand("Bob", "")
+	update := cmd.Update.(map[string]any)
+	if _, ok := update["messages"]; ok {
+		t.Errorf("messages = %v, want none without a tool call to answer", update["messages"])
+	}
+	if update["active_agent"] != "Bob" {
+		t.Errorf("active_agent = %v", update["active_agent"])
+	}
+}
+
+func TestGetHandoffDestinationsFromAgent(t *testing.T) {
+	agent, err := CreateReactAgent(&scriptedModel{}, []tools.Tool{
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Carol"}),
+		upperTool{},
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"}),
+	})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	destinations := GetHandoffDestinationsFromAgent(agent, "tools")
+	if len(destinations) != 2 || destinations[0] != "Bob" || destinations[1] != "Carol" {
+		t.Errorf("GetHandoffDestinationsFromAgent() = %v, want [Bob Carol]", destinations)
+	}
+	if destinations := GetHandoffDestinationsFromAgent(createMockAgent("Alice", "Hi"), "tools"); len(destinations) != 0 {
+		t.Errorf("GetHandoffDestinationsFromAgent() on a plain graph = %v, want empty", destinations)
+	}
+}
+
+func TestOnHandoff(t *testing.T) {
+	errNoRules := errors.New("rules unavailable")
+	tests := []struct {
+		name       string
+		decide     func(to string) (bool, error)
+		wantErr    error
+		wantAgent  string
+		wantResult string
+		// wantCalls is the number of model calls of the triage agent
+		wantCalls int
+	}{
+		{
+			name:       "allowed",
+			decide:     func(to string) (bool, error) { return true, nil },
+			wantAgent:  "refund_agent",
+			wantResult: "Successfully transferred to refund_agent",
+			wantCalls:  1,
+		},
+		{
+			name:       "denied",
+			decide:     func(to string) (bool, error) { return false, nil },
+			wantAgent:  "triage",
+			wantResult: "Transfer to refund_agent was denied. Continue without handing off to refund_agent.",
+			wantCalls:  2,
+		},
+		{
+			name:       "denied with an error",
+			decide:     func(to string) (bool, error) { return false, ErrHandoffDenied },
+			wantAgent:  "triage",
+			wantResult: "Transfer to refund_agent was denied. Continue without handing off to refund_agent.",
+			wantCalls:  2,
+		},
+		{
+			name:       "redirected",
+			decide:     func(to string) (bool, error) { return false, RedirectHandoff("billing_agent") },
+			wantAgent:  "billing_agent",
+			wantResult: "Successfully transferred to billing_agent",
+			wantCalls:  1,
+		},
+		{
+			name:    "failed",
+			decide:  func(to string) (bool, error) { return false, errNoRules },
+			wantErr: errNoRules,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &scriptedModel{responses: []*llms.ContentChoice{
+				toolCallChoice("call_1", "transfer_to_refund_agent", `{}`),
+				{Content: "Refunds are for premium users; I can help otherwise."},
+			}}
+			triage, err := CreateReactAgent(model, []tools.Tool{
+				CreateHandoffTool(HandoffToolConfig{AgentName: "refund_agent"}),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var calls []string
+			app := compileSwarm(t, SwarmConfig{
+				Agents: []Agent{
+					{Name: "triage", Runnable: triage},
+					{Name: "refund_agent", Runnable: createMockAgent("refund_agent", "Refund issued")},
+					{Name: "billing_agent", Runnable: createMockAgent("billing_agent", "Billing here")},
+				},
+				DefaultActiveAgent: "triage",
+				OnHandoff: func(ctx context.Context, from, to string, state SwarmState) (bool, error) {
+					calls = append(calls, from+"->"+to)
+					return tt.decide(to)
+				},
+			})
+
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "I want a refund")},
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Invoke() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if !slices.Equal(calls, []string{"triage->refund_agent"}) {
+				t.Errorf("OnHandoff calls = %v", calls)
+			}
+			response := result.Messages[2].Parts[0].(llms.ToolCallResponse)
+			if response.Content != tt.wantResult {
+				t.Errorf("tool result = %q, want %q", response.Content, tt.wantResult)
+			}
+			activeAgent := result.ActiveAgent
+			if activeAgent == "" {
+				activeAgent = "triage"
+			}
+			if activeAgent != tt.wantAgent {
+				t.Errorf("active agent = %q, want %q", activeAgent, tt.wantAgent)
+			}
+			if len(model.calls) != tt.wantCalls {
+				t.Errorf("model calls = %d, want %d", len(model.calls), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHandoffEndTurn(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_bob", `{"task_description":"Billing question"}`),
+		{Content: "I'm transferring you to Bob."},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Bob", Mode: HandoffEndTurn}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "My bill is wrong"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "Bob" || len(result.Handoffs) != 1 || result.Handoffs[0].Mode != HandoffEndTurn {
+		t.Errorf("active agent = %s, handoffs = %+v", result.ActiveAgent, result.Handoffs)
+	}
+	if got := lastText(result); got != "I'm transferring you to Bob." {
+		t.Errorf("last message = %q, want Alice's answer", got)
+	}
+	transfer := result.Messages[2].Parts[0].(llms.ToolCallResponse).Content
+	if transfer != "Successfully transferred to Bob\n\nTask description: Billing question\n\nBob takes over from the user's next message. Let the user know." {
+		t.Errorf("tool result = %q", transfer)
+	}
+
+	result.Messages = append(result.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "OK"))
+	result, err = app.Invoke(context.Background(), result)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got := lastText(result); got != "Ahoy" || len(model.calls) != 2 {
+		t.Errorf("last message = %q after %d model calls, want Bob's answer", got, len(model.calls))
+	}
+}
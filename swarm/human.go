@@ -0,0 +1,131 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// HumanAgent is the runnable of an agent played by a person, such as a
+// support representative taking over a conversation. When it becomes
+// active, the run pauses with an *InterruptError and publishes an
+// events.HumanNeeded event carrying the conversation. The person's
+// HumanReply resumes the thread with CompiledSwarm.Resume: the reply is
+// added as the agent's answer, and the run continues from there.
+//
+// Runs with a human agent need a thread ID to be resumed.
+//
+// Example:
+//
+//	agents := []swarm.Agent{
+//	    {Name: "support", Runnable: support},
+//	    {Name: "human_escalation", Runnable: swarm.NewHumanAgent()},
+//	}
+//	...
+//	bus.Subscribe(func(ctx context.Context, e events.Event) {
+//	    notifySupervisors(e.(events.HumanNeeded))
+//	}, events.TypeHumanNeeded)
+//	...
+//	result, err := app.Resume(ctx, "user_123", swarm.HumanReply{Content: "I've refunded your order."})
+type HumanAgent struct{}
+
+// NewHumanAgent returns a HumanAgent.
+func NewHumanAgent() *HumanAgent {
+	return &HumanAgent{}
+}
+
+// Invoke answers with the human's reply the run was resumed with, or else
+// pauses the run for one.
+func (h *HumanAgent) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	agent := AgentNameFromContext(ctx)
+	if reply, ok := humanReplyFromContext(ctx, agent); ok {
+		return reply.apply(ctx, state)
+	}
+
+	if bus := events.BusFromContext(ctx); bus != nil {
+		config, _ := RunConfigFromContext(ctx)
+		bus.Publish(ctx, events.HumanNeeded{
+			Time:     time.Now(),
+			Agent:    agent,
+			ThreadID: config.ThreadID,
+			Messages: slices.Clone(state.Messages),
+			Payload:  state.HandoffPayload,
+		})
+	}
+	return state, &InterruptError{Agent: agent, State: state, awaitingReply: true}
+}
+
+// awaitingReplyMetadataKey is the checkpoint metadata key holding the name
+// of the human agent a thread is waiting for.
+const awaitingReplyMetadataKey = "awaiting_reply"
+
+// HumanReply is the reply of the person playing a HumanAgent, with which
+// CompiledSwarm.Resume continues the thread waiting for it.
+type HumanReply struct {
+	// Content is the reply, added to the conversation as the agent's
+	// answer. An empty reply adds no message.
+	Content string
+	// HandoffTo hands the conversation over to another agent, which runs
+	// next; by default the human agent stays active
+	HandoffTo string
+}
+
+// resume runs the human agent again, with the reply.
+func (r HumanReply) resume(ctx context.Context, checkpoint *Checkpoint) (context.Context, SwarmState, error) {
+	agent, ok := checkpoint.PendingReply()
+	if !ok {
+		return ctx, checkpoint.State, fmt.Errorf("thread '%s' is not waiting for a human reply", checkpoint.ThreadID)
+	}
+	state := checkpoint.State
+	if state.PinnedAgent != "" && state.PinnedAgent != agent {
+		return ctx, state, fmt.Errorf("thread '%s' is pinned to '%s'", checkpoint.ThreadID, state.PinnedAgent)
+	}
+	state.ActiveAgent = agent
+	return context.WithValue(ctx, humanReplyKey{}, &humanReply{agent: agent, reply: r}), state, nil
+}
+
+// apply adds the reply to state and hands off if the reply asks to.
+func (r HumanReply) apply(ctx context.Context, state SwarmState) (SwarmState, error) {
+	if r.HandoffTo != "" {
+		if scope, ok := ctx.Value(handoffScopeKey{}).(handoffScope); ok && !slices.Contains(scope.agents, r.HandoffTo) {
+			return state, fmt.Errorf("%w: human reply hands off to '%s'", ErrUnknownAgent, r.HandoffTo)
+		}
+		state.ActiveAgent = r.HandoffTo
+	}
+	if r.Content != "" {
+		state.Messages = append(slices.Clip(state.Messages), llms.TextParts(llms.ChatMessageTypeAI, r.Content))
+	}
+	return state, nil
+}
+
+// PendingReply reports whether the checkpoint was saved by a run that is
+// waiting for the reply of a human agent, and returns the agent.
+func (c *Checkpoint) PendingReply() (agent string, ok bool) {
+	agent, _ = c.Metadata[awaitingReplyMetadataKey].(string)
+	return agent, agent != ""
+}
+
+// humanReplyKey is the context key of the humanReply a run was resumed with.
+type humanReplyKey struct{}
+
+// humanReply is the reply to a human agent, used by its first run only.
+type humanReply struct {
+	agent string
+	reply HumanReply
+	used  atomic.Bool
+}
+
+// humanReplyFromContext returns the reply to agent the run of ctx was
+// resumed with, unless the agent already answered with it.
+func humanReplyFromContext(ctx context.Context, agent string) (HumanReply, bool) {
+	r, ok := ctx.Value(humanReplyKey{}).(*humanReply)
+	if !ok || r.agent != agent || r.used.Swap(true) {
+		return HumanReply{}, false
+	}
+	return r.reply, true
+}
@@ -0,0 +1,93 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestHumanAgent(t *testing.T) {
+	ctx := context.Background()
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_human_escalation", `{"task_description":"Refund over the limit"}`),
+		{Content: "Anything else I can do for you?"},
+	}}
+	support, err := CreateReactAgent(model, []tools.Tool{
+		CreateHandoffTool(HandoffToolConfig{AgentName: "human_escalation"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var needed []events.HumanNeeded
+	bus := events.NewBus()
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		needed = append(needed, e.(events.HumanNeeded))
+	}, events.TypeHumanNeeded)
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "support", Runnable: support},
+			{Name: "human_escalation", Runnable: NewHumanAgent()},
+		},
+		DefaultActiveAgent: "support",
+		Checkpointer:       NewMemorySaver(),
+		Events:             bus,
+	})
+
+	_, err = app.Invoke(ctx, SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Refund my $900 order")},
+	}, WithThreadID("thread-1"))
+	var interrupt *InterruptError
+	if !errors.As(err, &interrupt) || interrupt.Agent != "human_escalation" || len(interrupt.ToolCalls) != 0 {
+		t.Fatalf("Invoke() error = %v, want an interrupt for a human reply", err)
+	}
+	if len(needed) != 1 {
+		t.Fatalf("published %d HumanNeeded events, want 1", len(needed))
+	}
+	if e := needed[0]; e.Agent != "human_escalation" || e.ThreadID != "thread-1" || len(e.Messages) != 3 ||
+		e.Payload[HandoffTaskDescriptionKey] != "Refund over the limit" {
+		t.Errorf("event = %+v", e)
+	}
+
+	paused, err := app.GetState(ctx, "thread-1")
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if !paused.Interrupted() || !paused.PendingReply || paused.Next != "human_escalation" {
+		t.Errorf("GetState() = %+v", paused)
+	}
+	if _, err := app.Resume(ctx, "thread-1", Approval{Approved: true}); err == nil {
+		t.Error("Resume() with an approval: expected error")
+	}
+	if _, err := app.SetActiveAgent(ctx, "thread-1", "support"); !errors.Is(err, ErrThreadInterrupted) {
+		t.Errorf("SetActiveAgent() of a thread waiting for a reply error = %v, want ErrThreadInterrupted", err)
+	}
+
+	result, err := app.Resume(ctx, "thread-1", HumanReply{Content: "I've refunded your order."})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if last := result.Messages[len(result.Messages)-1]; last.Role != llms.ChatMessageTypeAI ||
+		last.Parts[0].(llms.TextContent).Text != "I've refunded your order." || result.ActiveAgent != "human_escalation" {
+		t.Errorf("Resume() = %+v, want the human's reply", result)
+	}
+	if _, err := app.Resume(ctx, "thread-1", HumanReply{Content: "Again"}); err == nil {
+		t.Error("Resume() of an answered thread: expected error")
+	}
+
+	// The human agent stays active: the next message waits for a reply too
+	result.Messages = append(result.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Thanks!"))
+	if _, err := app.Invoke(ctx, result, WithThreadID("thread-1")); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Invoke() error = %v, want ErrInterrupted", err)
+	}
+	result, err = app.Resume(ctx, "thread-1", HumanReply{HandoffTo: "support"})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if result.ActiveAgent != "support" || result.Messages[len(result.Messages)-1].Parts[0].(llms.TextContent).Text != "Anything else I can do for you?" {
+		t.Errorf("Resume() handing off = %+v", result)
+	}
+}
@@ -0,0 +1,35 @@
+// Package limitbuf provides a buffer keeping the first bytes of the output
+// of a program, for tools returning it to a model.
+package limitbuf
+
+import "bytes"
+
+// Buffer keeps the first bytes written to it.
+type Buffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// New returns a Buffer keeping up to limit bytes.
+func New(limit int) *Buffer {
+	return &Buffer{limit: limit}
+}
+
+// Write keeps what fits within the limit and discards the rest.
+func (b *Buffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String returns the bytes kept, marking a truncation.
+func (b *Buffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "...(truncated)"
+	}
+	return b.buf.String()
+}
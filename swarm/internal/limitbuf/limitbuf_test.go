@@ -0,0 +1,20 @@
+package limitbuf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	b := New(5)
+	fmt.Fprint(b, "abc")
+	if b.String() != "abc" {
+		t.Errorf("String() = %q", b)
+	}
+	if n, err := fmt.Fprint(b, "defgh"); n != 5 || err != nil {
+		t.Errorf("Write() = %d, %v, want the whole write accepted", n, err)
+	}
+	if b.String() != "abcde...(truncated)" {
+		t.Errorf("String() = %q", b)
+	}
+}
@@ -0,0 +1,166 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ErrInterrupted is matched (with errors.Is) by the error returned when a
+// run pauses for human approval (see SwarmConfig.InterruptBefore).
+var ErrInterrupted = errors.New("swarm run interrupted")
+
+// interruptedMetadataKey is the checkpoint metadata key holding the name of
+// the agent that was interrupted.
+const interruptedMetadataKey = "interrupted_agent"
+
+// InterruptError reports a run paused before tool calls that need approval,
+// or for the reply of a HumanAgent. Resume the run with CompiledSwarm.Resume.
+type InterruptError struct {
+	// Agent is the agent whose tool calls are awaiting approval, or the
+	// human agent awaiting a reply
+	Agent string
+	// ToolCalls are the tool calls awaiting approval; there are none when a
+	// human agent awaits a reply
+	ToolCalls []llms.ToolCall
+	// State is the swarm state at the time of the interrupt; its last
+	// message is the AI message requesting the tool calls
+	State SwarmState
+
+	// awaitingReply is set by human agents pausing for a reply
+	awaitingReply bool
+}
+
+// Error lists the tool calls awaiting approval.
+func (e *InterruptError) Error() string {
+	if len(e.ToolCalls) == 0 {
+		return fmt.Sprintf("%v: agent '%s' is waiting for a human reply", ErrInterrupted, e.Agent)
+	}
+	names := make([]string, 0, len(e.ToolCalls))
+	for _, tc := range e.ToolCalls {
+		if tc.FunctionCall != nil {
+			names = append(names, tc.FunctionCall.Name)
+		}
+	}
+	return fmt.Sprintf("%v: agent '%s' is waiting for approval of %s", ErrInterrupted, e.Agent, strings.Join(names, ", "))
+}
+
+// Is reports whether target is ErrInterrupted.
+func (e *InterruptError) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+// ResumeInput is the human input with which CompiledSwarm.Resume continues
+// an interrupted thread: an Approval of its pending tool calls, or the
+// HumanReply of the human agent it waits for.
+type ResumeInput interface {
+	// resume returns the context and state with which the thread saved in
+	// checkpoint continues, or an error if the input does not answer
+	// what the thread is waiting for
+	resume(ctx context.Context, checkpoint *Checkpoint) (context.Context, SwarmState, error)
+}
+
+// Approval is a human decision on the tool calls of an interrupted run.
+type Approval struct {
+	// Approved executes the pending tool calls; otherwise they are rejected
+	// and the agent is told so
+	Approved bool
+	// Feedback is passed to the agent when the tool calls are rejected
+	Feedback string
+}
+
+// resume runs the interrupted agent again, with the decision on its
+// pending tool calls.
+func (a Approval) resume(ctx context.Context, checkpoint *Checkpoint) (context.Context, SwarmState, error) {
+	agent, pending, ok := checkpoint.PendingApproval()
+	if !ok {
+		return ctx, checkpoint.State, fmt.Errorf("thread '%s' is not waiting for an approval", checkpoint.ThreadID)
+	}
+	state := checkpoint.State
+	state.ActiveAgent = agent
+	return withApprovals(ctx, pending, a), state, nil
+}
+
+// interruptBeforeKey is the context key for the tools requiring approval.
+type interruptBeforeKey struct{}
+
+// approvalsKey is the context key for the approvals of pending tool calls,
+// keyed by tool call ID.
+type approvalsKey struct{}
+
+// withInterruptBefore returns a context in which tool nodes pause before
+// the given tools or handoff destinations.
+func withInterruptBefore(ctx context.Context, names []string) context.Context {
+	return context.WithValue(ctx, interruptBeforeKey{}, names)
+}
+
+// withApprovals returns a context approving or rejecting the given tool calls.
+func withApprovals(ctx context.Context, calls []llms.ToolCall, approval Approval) context.Context {
+	approvals := make(map[string]Approval, len(calls))
+	for _, tc := range calls {
+		approvals[tc.ID] = approval
+	}
+	return context.WithValue(ctx, approvalsKey{}, approvals)
+}
+
+// approvalFor returns the decision on a tool call, if one was made.
+func approvalFor(ctx context.Context, toolCallID string) (Approval, bool) {
+	approvals, _ := ctx.Value(approvalsKey{}).(map[string]Approval)
+	approval, ok := approvals[toolCallID]
+	return approval, ok
+}
+
+// requiresApproval reports whether calling t must wait for approval in ctx.
+// A tool requires approval when its name is listed in InterruptBefore, or
+// when it is a handoff tool whose destination agent is listed.
+func requiresApproval(ctx context.Context, name string, t tools.Tool) bool {
+	before, _ := ctx.Value(interruptBeforeKey{}).([]string)
+	if len(before) == 0 {
+		return false
+	}
+	if slices.Contains(before, name) {
+		return true
+	}
+	if h, ok := t.(HandoffTool); ok {
+		return slices.Contains(before, h.HandoffDestination())
+	}
+	return false
+}
+
+// rejectionMessage is the tool result reported for a rejected tool call.
+func rejectionMessage(approval Approval) string {
+	if approval.Feedback == "" {
+		return "Tool call rejected by a human"
+	}
+	return "Tool call rejected by a human: " + approval.Feedback
+}
+
+// PendingApproval reports whether the checkpoint was saved by a run that is
+// waiting for a human's approval, and returns the interrupted agent and the
+// tool calls awaiting approval.
+func (c *Checkpoint) PendingApproval() (agent string, calls []llms.ToolCall, ok bool) {
+	agent, _ = c.Metadata[interruptedMetadataKey].(string)
+	calls = pendingToolCalls(c.State)
+	if agent == "" || len(calls) == 0 {
+		return "", nil, false
+	}
+	return agent, calls, true
+}
+
+// pendingToolCalls returns the tool calls of the last message if it is an
+// AI message whose tool calls have not been answered yet.
+func pendingToolCalls(state SwarmState) []llms.ToolCall {
+	if len(state.Messages) == 0 {
+		return nil
+	}
+	last := state.Messages[len(state.Messages)-1]
+	if last.Role != llms.ChatMessageTypeAI {
+		return nil
+	}
+	return toolCalls(last)
+}
@@ -0,0 +1,166 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// compileInterruptible compiles a swarm with a single agent, Alice, that
+// calls the upper tool once, which requires approval.
+func compileInterruptible(t *testing.T, model *scriptedModel, store CheckpointStore) *CompiledSwarm {
+	t.Helper()
+	alice, err := CreateReactAgent(model, []tools.Tool{upperTool{}})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+	workflow, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}},
+		DefaultActiveAgent: "Alice",
+		InterruptBefore:    []string{"upper"},
+		Checkpointer:       store,
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return app
+}
+
+func TestInterruptBeforeTool(t *testing.T) {
+	tests := []struct {
+		name        string
+		approval    Approval
+		wantContent string
+	}{
+		{name: "approved", approval: Approval{Approved: true}, wantContent: "HELLO"},
+		{name: "rejected", approval: Approval{Feedback: "not today"}, wantContent: "Tool call rejected by a human: not today"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			model := &scriptedModel{responses: []*llms.ContentChoice{
+				toolCallChoice("call_1", "upper", `{"input":"hello"}`),
+				{Content: "Done"},
+			}}
+			store := NewMemorySaver()
+			app := compileInterruptible(t, model, store)
+
+			paused, err := app.Invoke(ctx, SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "shout hello")},
+			}, WithThreadID("thread-1"))
+			var interrupt *InterruptError
+			if !errors.As(err, &interrupt) || !errors.Is(err, ErrInterrupted) {
+				t.Fatalf("Invoke() error = %v, want *InterruptError", err)
+			}
+			if interrupt.Agent != "Alice" || len(interrupt.ToolCalls) != 1 || interrupt.ToolCalls[0].ID != "call_1" {
+				t.Errorf("interrupt = %+v", interrupt)
+			}
+			if len(paused.Messages) != 2 || paused.ActiveAgent != "Alice" {
+				t.Errorf("paused state = %+v", paused)
+			}
+
+			checkpoint, err := store.Latest(ctx, "thread-1")
+			if err != nil {
+				t.Fatalf("Latest() error = %v", err)
+			}
+			if checkpoint.Metadata[interruptedMetadataKey] != "Alice" {
+				t.Errorf("checkpoint metadata = %v", checkpoint.Metadata)
+			}
+
+			result, err := app.Resume(ctx, "thread-1", tt.approval)
+			if err != nil {
+				t.Fatalf("Resume() error = %v", err)
+			}
+			if len(model.calls) != 2 {
+				t.Errorf("model called %d times, want 2", len(model.calls))
+			}
+			if len(result.Messages) != 4 {
+				t.Fatalf("got %d messages, want 4", len(result.Messages))
+			}
+			response, ok := result.Messages[2].Parts[0].(llms.ToolCallResponse)
+			if !ok || response.Content != tt.wantContent {
+				t.Errorf("tool response = %+v, want content %q", result.Messages[2].Parts[0], tt.wantContent)
+			}
+
+			// The completed run replaces the interrupt as the latest checkpoint
+			if _, err := app.Resume(ctx, "thread-1", tt.approval); err == nil {
+				t.Error("Resume() of a completed thread: expected error")
+			}
+		})
+	}
+}
+
+func TestInterruptBeforeHandoff(t *testing.T) {
+	ctx := context.Background()
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_bob", `{}`),
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+	bob := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Bob here"))
+		return state, nil
+	})
+
+	workflow, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}, {Name: "Bob", Runnable: bob}},
+		DefaultActiveAgent: "Alice",
+		InterruptBefore:    []string{"Bob"},
+		Checkpointer:       NewMemorySaver(),
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	_, err = app.Invoke(ctx, SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "I need Bob")},
+	}, WithThreadID("thread-1"))
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Invoke() error = %v, want ErrInterrupted", err)
+	}
+
+	result, err := app.Resume(ctx, "thread-1", Approval{Approved: true})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if result.ActiveAgent != "Bob" {
+		t.Errorf("ActiveAgent = %q, want Bob", result.ActiveAgent)
+	}
+	last := result.Messages[len(result.Messages)-1]
+	if text, _ := last.Parts[0].(llms.TextContent); text.Text != "Bob here" {
+		t.Errorf("last message = %+v", last)
+	}
+}
+
+func TestInterruptErrors(t *testing.T) {
+	ctx := context.Background()
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hi"}}}
+
+	app := compileInterruptible(t, model, nil)
+	if _, err := app.Invoke(ctx, SwarmState{}, WithThreadID("thread-1")); err == nil || !strings.Contains(err.Error(), "no checkpointer") {
+		t.Errorf("Invoke() without checkpointer error = %v", err)
+	}
+	if _, err := app.Resume(ctx, "thread-1", Approval{Approved: true}); err == nil {
+		t.Error("Resume() without checkpointer: expected error")
+	}
+
+	app = compileInterruptible(t, model, NewMemorySaver())
+	if _, err := app.Resume(ctx, "missing", Approval{Approved: true}); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("Resume() of unknown thread error = %v, want ErrCheckpointNotFound", err)
+	}
+}
@@ -0,0 +1,105 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrHandoffLimitExceeded is matched (with errors.Is) by the error returned
+// when a run exceeds SwarmConfig.MaxHandoffs or SwarmConfig.MaxHandoffCycles.
+var ErrHandoffLimitExceeded = errors.New("handoff limit exceeded")
+
+// HandoffLimitError reports a run stopped by a handoff limit. It carries the
+// handoffs made during the run for debugging.
+type HandoffLimitError struct {
+	// Limit is the limit that was exceeded
+	Limit int
+	// Cycle is true when the run was stopped for two agents handing off
+	// back and forth, and false when the total number of handoffs was exceeded
+	Cycle bool
+	// Trail lists the handoffs of the run, oldest first
+	Trail []HandoffRecord
+}
+
+// Error describes the exceeded limit and the handoff trail.
+func (e *HandoffLimitError) Error() string {
+	hops := make([]string, 0, len(e.Trail)+1)
+	if len(e.Trail) > 0 {
+		hops = append(hops, e.Trail[0].From)
+	}
+	for _, h := range e.Trail {
+		hops = append(hops, h.To)
+	}
+	trail := strings.Join(hops, " -> ")
+
+	if e.Cycle {
+		return fmt.Sprintf("%v: agents alternated more than %d times: %s", ErrHandoffLimitExceeded, e.Limit, trail)
+	}
+	return fmt.Sprintf("%v: more than %d handoffs: %s", ErrHandoffLimitExceeded, e.Limit, trail)
+}
+
+// Is reports whether target is ErrHandoffLimitExceeded.
+func (e *HandoffLimitError) Is(target error) bool {
+	return target == ErrHandoffLimitExceeded
+}
+
+// handoffTrail records the handoffs made during a single invocation.
+type handoffTrail struct {
+	mu      sync.Mutex
+	records []HandoffRecord
+}
+
+// handoffTrailKey is the context key for the handoff trail of a run.
+type handoffTrailKey struct{}
+
+// withHandoffTrail returns a context carrying a new, empty handoff trail.
+func withHandoffTrail(ctx context.Context) context.Context {
+	return context.WithValue(ctx, handoffTrailKey{}, &handoffTrail{})
+}
+
+// handoffTrailFromContext returns the handoff trail of the run, or nil.
+func handoffTrailFromContext(ctx context.Context) *handoffTrail {
+	trail, _ := ctx.Value(handoffTrailKey{}).(*handoffTrail)
+	return trail
+}
+
+// add records a handoff and checks it against the configured limits.
+func (t *handoffTrail) add(config SwarmConfig, record HandoffRecord) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.records = append(t.records, record)
+
+	if config.MaxHandoffs > 0 && len(t.records) > config.MaxHandoffs {
+		return &HandoffLimitError{Limit: config.MaxHandoffs, Trail: append([]HandoffRecord(nil), t.records...)}
+	}
+	if config.MaxHandoffCycles > 0 && alternations(t.records) > config.MaxHandoffCycles {
+		return &HandoffLimitError{Limit: config.MaxHandoffCycles, Cycle: true, Trail: append([]HandoffRecord(nil), t.records...)}
+	}
+	return nil
+}
+
+// alternations counts how many times the trailing handoffs bounced back to
+// the same agent between the same pair of agents, e.g. 2 for A->B->A->B->A.
+func alternations(records []HandoffRecord) int {
+	if len(records) < 2 {
+		return 0
+	}
+	last := records[len(records)-1]
+	hops := 1
+	for i := len(records) - 2; i >= 0; i-- {
+		r, next := records[i], records[i+1]
+		if r.To != next.From || r.From != next.To || !samePair(r, last) {
+			break
+		}
+		hops++
+	}
+	return hops / 2
+}
+
+// samePair reports whether two handoffs are between the same two agents.
+func samePair(a, b HandoffRecord) bool {
+	return (a.From == b.From && a.To == b.To) || (a.From == b.To && a.To == b.From)
+}
@@ -0,0 +1,92 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// handoffTo returns an agent that always hands off to target.
+func handoffTo(target string) invokerFunc {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "over to "+target))
+		state.ActiveAgent = target
+		return state, nil
+	}
+}
+
+func TestHandoffLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    SwarmConfig
+		wantCycle bool
+		wantTrail int
+	}{
+		{"max handoffs", SwarmConfig{MaxHandoffs: 3}, false, 4},
+		{"max cycles", SwarmConfig{MaxHandoffCycles: 2}, true, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Agents = []Agent{
+				{Name: "Alice", Runnable: handoffTo("Bob")},
+				{Name: "Bob", Runnable: handoffTo("Alice")},
+			}
+			config.DefaultActiveAgent = "Alice"
+
+			s, err := CreateSwarm(config)
+			if err != nil {
+				t.Fatalf("CreateSwarm() error = %v", err)
+			}
+			app, err := s.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			_, err = app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+			})
+			if !errors.Is(err, ErrHandoffLimitExceeded) {
+				t.Fatalf("Invoke() error = %v, want ErrHandoffLimitExceeded", err)
+			}
+			var limitErr *HandoffLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Invoke() error = %v, want *HandoffLimitError", err)
+			}
+			if limitErr.Cycle != tt.wantCycle || len(limitErr.Trail) != tt.wantTrail {
+				t.Errorf("HandoffLimitError = %+v, want cycle %v with %d handoffs", limitErr, tt.wantCycle, tt.wantTrail)
+			}
+			if limitErr.Trail[0].From != "Alice" || limitErr.Trail[0].To != "Bob" {
+				t.Errorf("Unexpected first handoff %+v", limitErr.Trail[0])
+			}
+		})
+	}
+}
+
+func TestAlternations(t *testing.T) {
+	hop := func(from, to string) HandoffRecord { return HandoffRecord{From: from, To: to} }
+
+	tests := []struct {
+		name    string
+		records []HandoffRecord
+		want    int
+	}{
+		{"none", nil, 0},
+		{"single", []HandoffRecord{hop("A", "B")}, 0},
+		{"round trip", []HandoffRecord{hop("A", "B"), hop("B", "A")}, 1},
+		{"ping-pong", []HandoffRecord{hop("A", "B"), hop("B", "A"), hop("A", "B"), hop("B", "A")}, 2},
+		{"chain", []HandoffRecord{hop("A", "B"), hop("B", "C"), hop("C", "A")}, 0},
+		{"ping-pong after chain", []HandoffRecord{hop("A", "B"), hop("B", "C"), hop("C", "B"), hop("B", "C")}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alternations(tt.records); got != tt.want {
+				t.Errorf("alternations() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// LLMRouter is a Router that asks a model which agent should handle the
+// user's message when no agent is active. It is created by CreateLLMRouter.
+type LLMRouter struct {
+	model  llms.Model
+	agents []Agent
+}
+
+// CreateLLMRouter creates a Router that classifies the last user message
+// with model, choosing among agents by their Name and Description. A small,
+// fast model is usually enough.
+//
+// The router keeps the state's ActiveAgent when it names one of agents, so
+// conversations stay with the agent they were handed off to. When the model
+// does not answer with exactly one of the agents, the swarm's
+// DefaultActiveAgent is used.
+//
+// Example:
+//
+//	agents := []swarm.Agent{
+//	    {Name: "Flights", Runnable: flights, Description: "Books and changes flights"},
+//	    {Name: "Hotels", Runnable: hotels, Description: "Books and changes hotel stays"},
+//	}
+//	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents:             agents,
+//	    DefaultActiveAgent: "Flights",
+//	    Router:             swarm.CreateLLMRouter(cheapModel, agents),
+//	})
+func CreateLLMRouter(model llms.Model, agents []Agent) *LLMRouter {
+	return &LLMRouter{model: model, agents: slices.Clone(agents)}
+}
+
+// Route returns the active agent, or the agent the model picks for the last
+// user message.
+func (r *LLMRouter) Route(ctx context.Context, state SwarmState) (string, error) {
+	if state.ActiveAgent != "" && slices.ContainsFunc(r.agents, func(agent Agent) bool { return agent.Name == state.ActiveAgent }) {
+		return state.ActiveAgent, nil
+	}
+	request := lastHumanText(state.Messages)
+	if request == "" {
+		return "", nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("You route user requests to the agent best suited to handle them. ")
+	prompt.WriteString("Reply with the name of exactly one agent and nothing else.\n\nAgents:\n")
+	for _, agent := range r.agents {
+		fmt.Fprintf(&prompt, "- %s", agent.Name)
+		if agent.Description != "" {
+			fmt.Fprintf(&prompt, ": %s", agent.Description)
+		}
+		prompt.WriteString("\n")
+	}
+
+	resp, err := r.model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, prompt.String()),
+		llms.TextParts(llms.ChatMessageTypeHuman, request),
+	}, llms.WithTemperature(0))
+	if err != nil {
+		return "", fmt.Errorf("llm router: %w", err)
+	}
+	recordUsage(ctx, resp)
+	if len(resp.Choices) == 0 {
+		return "", nil
+	}
+	return r.match(resp.Choices[0].Content), nil
+}
+
+// match returns the agent named in the model's answer, or "" if the answer
+// names none or several of them.
+func (r *LLMRouter) match(answer string) string {
+	answer = strings.Trim(strings.TrimSpace(answer), `."'`+"`")
+	var found string
+	for _, agent := range r.agents {
+		if strings.EqualFold(answer, agent.Name) {
+			return agent.Name
+		}
+		if strings.Contains(strings.ToLower(answer), strings.ToLower(agent.Name)) {
+			if found != "" {
+				return ""
+			}
+			found = agent.Name
+		}
+	}
+	return found
+}
+
+// lastHumanText returns the text of the last human message.
+func lastHumanText(messages []llms.MessageContent) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != llms.ChatMessageTypeHuman {
+			continue
+		}
+		var parts []string
+		for _, part := range messages[i].Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				parts = append(parts, text.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
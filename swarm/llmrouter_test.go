@@ -0,0 +1,60 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestLLMRouter(t *testing.T) {
+	agents := []Agent{
+		{Name: "Flights", Runnable: createMockAgent("Flights", "Which flight?"), Description: "Books flights"},
+		{Name: "Hotels", Runnable: createMockAgent("Hotels", "Which hotel?"), Description: "Books hotels"},
+	}
+	input := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "I need a room in Paris")}}
+
+	tests := []struct {
+		name   string
+		answer string
+		active string
+		reply  string
+		calls  int
+	}{
+		{name: "exact answer", answer: "Hotels", reply: "Which hotel?", calls: 1},
+		{name: "answer in a sentence", answer: "The hotels agent.", reply: "Which hotel?", calls: 1},
+		{name: "ambiguous answer", answer: "Flights or Hotels", reply: "Which flight?", calls: 1},
+		{name: "active agent kept", active: "Hotels", reply: "Which hotel?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &scriptedModel{responses: []*llms.ContentChoice{{Content: tt.answer}}}
+			app := compileSwarm(t, SwarmConfig{
+				Agents:             agents,
+				DefaultActiveAgent: "Flights",
+				Router:             CreateLLMRouter(model, agents),
+			})
+
+			state := input
+			state.ActiveAgent = tt.active
+			result, err := app.Invoke(context.Background(), state)
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			last := result.Messages[len(result.Messages)-1]
+			if got := last.Parts[0].(llms.TextContent).Text; got != tt.reply {
+				t.Errorf("Reply = %q, want %q", got, tt.reply)
+			}
+			if len(model.calls) != tt.calls {
+				t.Fatalf("Expected %d model calls, got %d", tt.calls, len(model.calls))
+			}
+			if tt.calls > 0 {
+				prompt := model.calls[0][0].Parts[0].(llms.TextContent).Text
+				if !strings.Contains(prompt, "- Hotels: Books hotels") {
+					t.Errorf("Expected agent descriptions in the prompt, got %q", prompt)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package swarm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// Registry holds the models and tools that a declarative swarm spec refers
+// to by name.
+type Registry struct {
+	// Models maps model names to models. Agents without a model use the
+	// model registered as "default".
+	Models map[string]llms.Model
+	// Tools maps tool names to tools
+	Tools map[string]tools.Tool
+	// Prompts holds the files named by AgentSpec.PromptFile, e.g.
+	// os.DirFS("prompts")
+	Prompts fs.FS
+}
+
+// SwarmSpec is the declarative definition of a swarm read by LoadConfig.
+type SwarmSpec struct {
+	Agents []AgentSpec `yaml:"agents" json:"agents"`
+	// DefaultAgent is the agent that is active before any handoff
+	DefaultAgent     string   `yaml:"default_agent" json:"default_agent"`
+	MaxHandoffs      int      `yaml:"max_handoffs,omitempty" json:"max_handoffs,omitempty"`
+	MaxHandoffCycles int      `yaml:"max_handoff_cycles,omitempty" json:"max_handoff_cycles,omitempty"`
+	RecursionLimit   int      `yaml:"recursion_limit,omitempty" json:"recursion_limit,omitempty"`
+	InterruptBefore  []string `yaml:"interrupt_before,omitempty" json:"interrupt_before,omitempty"`
+}
+
+// AgentSpec is the declarative definition of an agent built with
+// CreateReactAgent.
+type AgentSpec struct {
+	Name string `yaml:"name" json:"name"`
+	// Model is the name of the agent's model in the Registry (default: "default")
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// SystemPrompt is a fixed system prompt
+	SystemPrompt string `yaml:"system_prompt,omitempty" json:"system_prompt,omitempty"`
+	// PromptTemplate is a system prompt rendered with PromptTemplate on every
+	// run; it takes precedence over SystemPrompt
+	PromptTemplate string `yaml:"prompt_template,omitempty" json:"prompt_template,omitempty"`
+	// PromptFile names the file in Registry.Prompts holding the agent's
+	// prompt template; it takes precedence over PromptTemplate
+	PromptFile string `yaml:"prompt_file,omitempty" json:"prompt_file,omitempty"`
+	// PromptVersion is recorded as the agent's Agent.PromptVersion (default:
+	// a hash of the agent's prompt)
+	PromptVersion string `yaml:"prompt_version,omitempty" json:"prompt_version,omitempty"`
+	// Tools are the names of the agent's tools in the Registry
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Destinations are the agents this agent can hand off to. A handoff tool
+	// is created for each of them.
+	Destinations []string `yaml:"destinations,omitempty" json:"destinations,omitempty"`
+	// MessageVisibility is "shared_all" (default) or "shared_final_only"
+	MessageVisibility string `yaml:"message_visibility,omitempty" json:"message_visibility,omitempty"`
+	MaxIterations     int    `yaml:"max_iterations,omitempty" json:"max_iterations,omitempty"`
+	MaxAttempts       int    `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+	Fallback          string `yaml:"fallback,omitempty" json:"fallback,omitempty"`
+	// MessageAdapter is "openai", "anthropic", "google" or "ollama" to adapt
+	// the agent's model calls to that provider (default: none)
+	MessageAdapter string `yaml:"message_adapter,omitempty" json:"message_adapter,omitempty"`
+	// Temperature and MaxTokens are passed to the agent's model calls
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+}
+
+// LoadConfig reads a YAML or JSON swarm spec (see SwarmSpec) and builds its
+// agents from the models and tools in registry. The returned config can be
+// extended, e.g. with a Logger, before it is passed to CreateSwarm.
+//
+// Example spec:
+//
+//	default_agent: Alice
+//	max_handoffs: 10
+//	agents:
+//	  - name: Alice
+//	    system_prompt: You are Alice, an addition expert.
+//	    tools: [add]
+//	    destinations: [Bob]
+//	  - name: Bob
+//	    model: pirate
+//	    system_prompt: You are Bob, you speak like a pirate.
+//	    destinations: [Alice]
+//
+// Example:
+//
+//	config, err := swarm.LoadConfig(file, swarm.Registry{
+//	    Models: map[string]llms.Model{"default": model, "pirate": pirateModel},
+//	    Tools:  map[string]tools.Tool{"add": addTool},
+//	})
+//	workflow, err := swarm.CreateSwarm(config)
+func LoadConfig(r io.Reader, registry Registry) (SwarmConfig, error) {
+	spec, err := parseSpec(r)
+	if err != nil {
+		return SwarmConfig{}, err
+	}
+	return spec.Build(registry)
+}
+
+// parseSpec reads a YAML or JSON swarm spec.
+func parseSpec(r io.Reader) (SwarmSpec, error) {
+	var spec SwarmSpec
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return SwarmSpec{}, fmt.Errorf("parse swarm spec: %w", err)
+	}
+	return spec, nil
+}
+
+// Build creates the agents of the spec from the models and tools in
+// registry. Unknown models, tools and settings are reported together in a
+// *ConfigError; the swarm itself is validated by CreateSwarm.
+func (s SwarmSpec) Build(registry Registry) (SwarmConfig, error) {
+	config := SwarmConfig{
+		DefaultActiveAgent: s.DefaultAgent,
+		MaxHandoffs:        s.MaxHandoffs,
+		MaxHandoffCycles:   s.MaxHandoffCycles,
+		RecursionLimit:     s.RecursionLimit,
+		InterruptBefore:    s.InterruptBefore,
+	}
+
+	var problems []error
+	for _, spec := range s.Agents {
+		agent, errs := spec.build(registry)
+		if len(errs) > 0 {
+			problems = append(problems, errs...)
+			continue
+		}
+		config.Agents = append(config.Agents, agent)
+	}
+	if len(problems) > 0 {
+		return SwarmConfig{}, &ConfigError{Problems: problems}
+	}
+	return config, nil
+}
+
+// build creates the agent described by s, or returns the problems found.
+func (s AgentSpec) build(registry Registry) (Agent, []error) {
+	var problems []error
+
+	modelName := s.Model
+	if modelName == "" {
+		modelName = "default"
+	}
+	model, ok := registry.Models[modelName]
+	if !ok {
+		problems = append(problems, fmt.Errorf("agent '%s' uses unknown model '%s'", s.Name, modelName))
+	}
+
+	agentTools := make([]tools.Tool, 0, len(s.Tools)+len(s.Destinations))
+	for _, name := range s.Tools {
+		tool, ok := registry.Tools[name]
+		if !ok {
+			problems = append(problems, fmt.Errorf("agent '%s' uses unknown tool '%s'", s.Name, name))
+			continue
+		}
+		agentTools = append(agentTools, tool)
+	}
+	for _, dest := range s.Destinations {
+		agentTools = append(agentTools, CreateHandoffTool(HandoffToolConfig{AgentName: dest}))
+	}
+
+	agent := Agent{
+		Name:         s.Name,
+		Destinations: s.Destinations,
+		SystemPrompt: s.SystemPrompt,
+		RetryPolicy:  RetryPolicy{MaxAttempts: s.MaxAttempts},
+		Fallback:     s.Fallback,
+	}
+	switch s.MessageVisibility {
+	case "", "shared_all":
+		agent.MessageVisibility = SharedAll
+	case "shared_final_only":
+		agent.MessageVisibility = SharedFinalOnly
+	default:
+		problems = append(problems, fmt.Errorf("agent '%s' has unknown message visibility '%s'", s.Name, s.MessageVisibility))
+	}
+	switch s.MessageAdapter {
+	case "":
+	case "openai":
+		agent.MessageAdapter = OpenAIAdapter{}
+	case "anthropic":
+		agent.MessageAdapter = AnthropicAdapter{}
+	case "google":
+		agent.MessageAdapter = GoogleAdapter{}
+	case "ollama":
+		agent.MessageAdapter = OllamaAdapter{}
+	default:
+		problems = append(problems, fmt.Errorf("agent '%s' has unknown message adapter '%s'", s.Name, s.MessageAdapter))
+	}
+	text := s.PromptTemplate
+	if s.PromptFile != "" {
+		data, err := s.readPromptFile(registry)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		text = string(data)
+	}
+	if text != "" {
+		prompt, err := PromptTemplate(text)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("agent '%s': %w", s.Name, err))
+		}
+		agent.Prompt = prompt
+	} else {
+		text = s.SystemPrompt
+	}
+	agent.PromptVersion = s.PromptVersion
+	if agent.PromptVersion == "" && text != "" {
+		agent.PromptVersion = promptVersion(text)
+	}
+	if len(problems) > 0 {
+		return Agent{}, problems
+	}
+
+	var opts []AgentOption
+	if s.MaxIterations > 0 {
+		opts = append(opts, WithMaxIterations(s.MaxIterations))
+	}
+	if s.Temperature != nil {
+		opts = append(opts, WithCallOptions(llms.WithTemperature(*s.Temperature)))
+	}
+	if s.MaxTokens > 0 {
+		opts = append(opts, WithCallOptions(llms.WithMaxTokens(s.MaxTokens)))
+	}
+	runnable, err := CreateReactAgent(model, agentTools, opts...)
+	if err != nil {
+		return Agent{}, []error{fmt.Errorf("agent '%s': %w", s.Name, err)}
+	}
+	agent.Runnable = runnable
+	return agent, nil
+}
+
+// readPromptFile reads the PromptFile of s from registry.Prompts.
+func (s AgentSpec) readPromptFile(registry Registry) ([]byte, error) {
+	if registry.Prompts == nil {
+		return nil, fmt.Errorf("agent '%s' has prompt file '%s' but the registry has no prompts", s.Name, s.PromptFile)
+	}
+	data, err := fs.ReadFile(registry.Prompts, s.PromptFile)
+	if err != nil {
+		return nil, fmt.Errorf("agent '%s': read prompt file: %w", s.Name, err)
+	}
+	return data, nil
+}
+
+// promptVersion returns the default version of a prompt: the start of the
+// hex SHA-256 of its text.
+func promptVersion(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:6])
+}
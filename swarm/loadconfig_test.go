@@ -0,0 +1,104 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const testSpec = `
+default_agent: Alice
+max_handoffs: 3
+agents:
+  - name: Alice
+    system_prompt: You are Alice.
+    temperature: 0.1
+    max_tokens: 200
+    tools: [upper]
+    destinations: [Bob]
+  - name: Bob
+    model: pirate
+    message_visibility: shared_final_only
+    message_adapter: anthropic
+    destinations: [Alice]
+`
+
+func TestLoadConfig(t *testing.T) {
+	alice := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_bob", `{"task_description":"say hi"}`),
+	}}
+	bob := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Ahoy"}}}
+	registry := Registry{
+		Models: map[string]llms.Model{"default": alice, "pirate": bob},
+		Tools:  map[string]tools.Tool{"upper": upperTool{}},
+	}
+
+	config, err := LoadConfig(strings.NewReader(testSpec), registry)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.DefaultActiveAgent != "Alice" || config.MaxHandoffs != 3 || len(config.Agents) != 2 {
+		t.Fatalf("Unexpected config %+v", config)
+	}
+	if config.Agents[1].MessageVisibility != SharedFinalOnly {
+		t.Errorf("Expected Bob to share final messages only")
+	}
+	if _, ok := config.Agents[1].MessageAdapter.(AnthropicAdapter); !ok {
+		t.Errorf("Bob's message adapter = %T, want AnthropicAdapter", config.Agents[1].MessageAdapter)
+	}
+
+	app := compileSwarm(t, config)
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "Bob" || len(bob.calls) != 1 {
+		t.Errorf("Expected Bob to answer, active agent %q", result.ActiveAgent)
+	}
+	if got := alice.calls[0][0]; got.Role != llms.ChatMessageTypeSystem {
+		t.Errorf("Expected Alice's system prompt first, got %v", got)
+	}
+	if opts := alice.options[0]; opts.Temperature != 0.1 || opts.MaxTokens != 200 {
+		t.Errorf("Alice's call options = %+v, want the spec's temperature and max tokens", opts)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	registry := Registry{Models: map[string]llms.Model{"default": &scriptedModel{}}}
+	config, err := LoadConfig(strings.NewReader(`{"default_agent": "Alice", "agents": [{"name": "Alice"}]}`), registry)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(config.Agents) != 1 || config.Agents[0].Name != "Alice" {
+		t.Errorf("Unexpected agents %+v", config.Agents)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	registry := Registry{Models: map[string]llms.Model{"default": &scriptedModel{}}}
+
+	spec := `
+default_agent: Alice
+agents:
+  - name: Alice
+    model: missing
+    tools: [search]
+    message_visibility: private
+    message_adapter: claude
+`
+	_, err := LoadConfig(strings.NewReader(spec), registry)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 4 {
+		t.Errorf("LoadConfig() error = %v, want 4 problems", err)
+	}
+
+	if _, err := LoadConfig(strings.NewReader("agents: []\ncolour: red\n"), registry); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
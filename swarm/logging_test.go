@@ -0,0 +1,80 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestSwarmLogger(t *testing.T) {
+	failing := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		return state, errors.New("model unavailable")
+	})
+
+	tests := []struct {
+		name     string
+		runnable AgentRunnable
+		level    slog.Level
+		want     []string
+		wantNot  []string
+	}{
+		{
+			name:     "routine events",
+			runnable: createMockAgent("Alice", "Hi"),
+			level:    slog.LevelInfo,
+			want:     []string{`level=INFO msg="agent started" agent=Alice messages=1`, `msg="agent finished" agent=Alice`, "added=1"},
+		},
+		{
+			name:     "routine events below handler level",
+			runnable: createMockAgent("Alice", "Hi"),
+			level:    slog.LevelDebug,
+			wantNot:  []string{"agent started", "agent finished"},
+		},
+		{
+			name:     "errors",
+			runnable: failing,
+			level:    slog.LevelDebug,
+			want:     []string{`level=ERROR msg="agent failed" agent=Alice`, `error="model unavailable"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s, err := CreateSwarm(SwarmConfig{
+				Agents:             []Agent{{Name: "Alice", Runnable: tt.runnable}},
+				DefaultActiveAgent: "Alice",
+				Logger:             slog.New(slog.NewTextHandler(&buf, nil)),
+				LogLevel:           tt.level,
+			})
+			if err != nil {
+				t.Fatalf("CreateSwarm() error = %v", err)
+			}
+			app, err := s.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			_, _ = app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+			})
+
+			logs := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(logs, want) {
+					t.Errorf("Expected logs to contain %q, got:\n%s", want, logs)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(logs, unwanted) {
+					t.Errorf("Expected logs not to contain %q, got:\n%s", unwanted, logs)
+				}
+			}
+		})
+	}
+}
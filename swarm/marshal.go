@@ -0,0 +1,249 @@
+package swarm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// StateFormatVersion is the version of the format written by MarshalState.
+// UnmarshalState reads this version and every older one.
+const StateFormatVersion = 1
+
+// stateEnvelope is the top-level document written by MarshalState.
+type stateEnvelope struct {
+	Version int             `json:"version"`
+	State   json.RawMessage `json:"state"`
+}
+
+// stateJSON is the encoding of a SwarmState in version 1 of the format.
+type stateJSON struct {
+	Messages        []messageJSON            `json:"messages"`
+	ActiveAgent     string                   `json:"active_agent,omitempty"`
+	PinnedAgent     string                   `json:"pinned_agent,omitempty"`
+	HandoffPayload  map[string]any           `json:"handoff_payload,omitempty"`
+	PrivateMessages map[string][]messageJSON `json:"private_messages,omitempty"`
+	Handoffs        []HandoffRecord          `json:"handoffs,omitempty"`
+	Values          map[string]any           `json:"values,omitempty"`
+	Blackboard      map[string]any           `json:"blackboard,omitempty"`
+	Tasks           []Task                   `json:"tasks,omitempty"`
+	Artifacts       map[string]ArtifactRef   `json:"artifacts,omitempty"`
+}
+
+// messageJSON is the encoding of a message. Unlike the JSON encoding of
+// llms.MessageContent, it always lists the parts, so empty text parts and
+// empty messages survive.
+type messageJSON struct {
+	Role  llms.ChatMessageType `json:"role"`
+	Parts []partJSON           `json:"parts"`
+}
+
+// partJSON is the encoding of a message part; Type tells which fields are
+// set.
+type partJSON struct {
+	Type string `json:"type"`
+	// Text is set for text parts
+	Text string `json:"text,omitempty"`
+	// URL and Detail are set for image_url parts
+	URL    string `json:"url,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	// MIMEType and Data are set for binary parts
+	MIMEType string `json:"mime_type,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	// ID, ToolType and Function are set for tool_call parts
+	ID       string             `json:"id,omitempty"`
+	ToolType string             `json:"tool_type,omitempty"`
+	Function *llms.FunctionCall `json:"function,omitempty"`
+	// ToolCallID, Name and Content are set for tool_response parts
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Content    string `json:"content,omitempty"`
+}
+
+// MarshalState encodes a state as versioned JSON for persistence. Unlike
+// json.Marshal, it keeps every message part exactly: roles, tool call IDs,
+// tool responses, image and binary parts, and empty text parts. Values,
+// Blackboard and HandoffPayload entries are encoded with encoding/json.
+//
+// Example:
+//
+//	data, err := swarm.MarshalState(state)
+//	...
+//	restored, err := swarm.UnmarshalState(data)
+func MarshalState(state SwarmState) ([]byte, error) {
+	encoded := stateJSON{
+		ActiveAgent:    state.ActiveAgent,
+		PinnedAgent:    state.PinnedAgent,
+		HandoffPayload: state.HandoffPayload,
+		Handoffs:       state.Handoffs,
+		Values:         state.Values,
+		Blackboard:     state.Blackboard,
+		Tasks:          state.Tasks,
+		Artifacts:      state.Artifacts,
+	}
+	var err error
+	if encoded.Messages, err = encodeMessages(state.Messages); err != nil {
+		return nil, err
+	}
+	if state.PrivateMessages != nil {
+		encoded.PrivateMessages = make(map[string][]messageJSON, len(state.PrivateMessages))
+		for agent, messages := range state.PrivateMessages {
+			if encoded.PrivateMessages[agent], err = encodeMessages(messages); err != nil {
+				return nil, fmt.Errorf("private messages of '%s': %w", agent, err)
+			}
+		}
+	}
+
+	body, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return json.Marshal(stateEnvelope{Version: StateFormatVersion, State: body})
+}
+
+// UnmarshalState decodes a state written by MarshalState. Data without a
+// version, such as a SwarmState encoded with json.Marshal, is decoded with
+// encoding/json. Whole numbers in Values, Blackboard and HandoffPayload are
+// decoded as int when they fit, other numbers as float64.
+func UnmarshalState(data []byte) (SwarmState, error) {
+	var envelope stateEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return SwarmState{}, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	switch {
+	case envelope.Version == 0:
+		var state SwarmState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return SwarmState{}, fmt.Errorf("failed to unmarshal state: %w", err)
+		}
+		return state, nil
+	case envelope.Version > StateFormatVersion:
+		return SwarmState{}, fmt.Errorf("unsupported state format version %d (newest supported is %d)", envelope.Version, StateFormatVersion)
+	}
+
+	var encoded stateJSON
+	decoder := json.NewDecoder(bytes.NewReader(envelope.State))
+	decoder.UseNumber()
+	if err := decoder.Decode(&encoded); err != nil {
+		return SwarmState{}, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	state := SwarmState{
+		ActiveAgent: encoded.ActiveAgent,
+		PinnedAgent: encoded.PinnedAgent,
+		Handoffs:    encoded.Handoffs,
+		Tasks:       encoded.Tasks,
+		Artifacts:   encoded.Artifacts,
+	}
+	if encoded.HandoffPayload != nil {
+		state.HandoffPayload = decodeNumbers(encoded.HandoffPayload).(map[string]any)
+	}
+	if encoded.Values != nil {
+		state.Values = decodeNumbers(encoded.Values).(map[string]any)
+	}
+	if encoded.Blackboard != nil {
+		state.Blackboard = decodeNumbers(encoded.Blackboard).(map[string]any)
+	}
+	var err error
+	if state.Messages, err = decodeMessages(encoded.Messages); err != nil {
+		return SwarmState{}, err
+	}
+	if encoded.PrivateMessages != nil {
+		state.PrivateMessages = make(map[string][]llms.MessageContent, len(encoded.PrivateMessages))
+		for agent, messages := range encoded.PrivateMessages {
+			if state.PrivateMessages[agent], err = decodeMessages(messages); err != nil {
+				return SwarmState{}, fmt.Errorf("private messages of '%s': %w", agent, err)
+			}
+		}
+	}
+	return state, nil
+}
+
+// encodeMessages converts messages to their encoding.
+func encodeMessages(messages []llms.MessageContent) ([]messageJSON, error) {
+	if messages == nil {
+		return nil, nil
+	}
+	encoded := make([]messageJSON, len(messages))
+	for i, message := range messages {
+		encoded[i] = messageJSON{Role: message.Role}
+		if message.Parts == nil {
+			continue
+		}
+		encoded[i].Parts = make([]partJSON, len(message.Parts))
+		for j, part := range message.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				encoded[i].Parts[j] = partJSON{Type: "text", Text: p.Text}
+			case llms.ImageURLContent:
+				encoded[i].Parts[j] = partJSON{Type: "image_url", URL: p.URL, Detail: p.Detail}
+			case llms.BinaryContent:
+				encoded[i].Parts[j] = partJSON{Type: "binary", MIMEType: p.MIMEType, Data: p.Data}
+			case llms.ToolCall:
+				encoded[i].Parts[j] = partJSON{Type: "tool_call", ID: p.ID, ToolType: p.Type, Function: p.FunctionCall}
+			case llms.ToolCallResponse:
+				encoded[i].Parts[j] = partJSON{Type: "tool_response", ToolCallID: p.ToolCallID, Name: p.Name, Content: p.Content}
+			default:
+				return nil, fmt.Errorf("message %d: unsupported content part %T", i, part)
+			}
+		}
+	}
+	return encoded, nil
+}
+
+// decodeMessages converts encoded messages back.
+func decodeMessages(encoded []messageJSON) ([]llms.MessageContent, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	messages := make([]llms.MessageContent, len(encoded))
+	for i, message := range encoded {
+		messages[i] = llms.MessageContent{Role: message.Role}
+		if message.Parts == nil {
+			continue
+		}
+		messages[i].Parts = make([]llms.ContentPart, len(message.Parts))
+		for j, p := range message.Parts {
+			switch p.Type {
+			case "text":
+				messages[i].Parts[j] = llms.TextContent{Text: p.Text}
+			case "image_url":
+				messages[i].Parts[j] = llms.ImageURLContent{URL: p.URL, Detail: p.Detail}
+			case "binary":
+				messages[i].Parts[j] = llms.BinaryContent{MIMEType: p.MIMEType, Data: p.Data}
+			case "tool_call":
+				messages[i].Parts[j] = llms.ToolCall{ID: p.ID, Type: p.ToolType, FunctionCall: p.Function}
+			case "tool_response":
+				messages[i].Parts[j] = llms.ToolCallResponse{ToolCallID: p.ToolCallID, Name: p.Name, Content: p.Content}
+			default:
+				return nil, fmt.Errorf("message %d: unknown content part type '%s'", i, p.Type)
+			}
+		}
+	}
+	return messages, nil
+}
+
+// decodeNumbers replaces the json.Numbers of a decoded value with ints, or
+// float64s for numbers that are not whole or do not fit.
+func decodeNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, strconv.IntSize); err == nil {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = decodeNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = decodeNumbers(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMarshalStateRoundTrip(t *testing.T) {
+	state := SwarmState{
+		Messages: []llms.MessageContent{
+			{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.TextContent{Text: ""}}},
+			{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+				llms.TextContent{Text: "What is in this picture?"},
+				llms.ImageURLContent{URL: "https://example.com/cat.png", Detail: "low"},
+				llms.BinaryContent{MIMEType: "application/pdf", Data: []byte("%PDF")},
+			}},
+			{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+				llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "lookup", Arguments: `{"q":"cat"}`}},
+				llms.ToolCall{ID: "call_2", Type: "function"},
+			}},
+			{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+				llms.ToolCallResponse{ToolCallID: "call_1", Name: "lookup", Content: "a cat"},
+			}},
+			{Role: llms.ChatMessageTypeAI},
+		},
+		ActiveAgent:     "Alice",
+		HandoffPayload:  map[string]any{"priority": 2},
+		PrivateMessages: map[string][]llms.MessageContent{"Bob": {llms.TextParts(llms.ChatMessageTypeAI, "draft")}},
+		Handoffs:        []HandoffRecord{{From: "Bob", To: "Alice", ToolCallID: "call_0", Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		Values: map[string]any{
+			"count":  3,
+			"ratio":  0.5,
+			"big":    1e30,
+			"tags":   []any{"a", 1},
+			"nested": map[string]any{"n": 7},
+			"done":   true,
+		},
+	}
+
+	data, err := MarshalState(state)
+	if err != nil {
+		t.Fatalf("MarshalState() error = %v", err)
+	}
+	got, err := UnmarshalState(data)
+	if err != nil {
+		t.Fatalf("UnmarshalState() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Errorf("UnmarshalState() = %+v\nwant %+v", got, state)
+	}
+
+	var envelope struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Version != StateFormatVersion {
+		t.Errorf("version = %d (%v), want %d", envelope.Version, err, StateFormatVersion)
+	}
+}
+
+func TestUnmarshalStateVersions(t *testing.T) {
+	legacy, err := json.Marshal(SwarmState{
+		Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+		ActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := UnmarshalState(legacy)
+	if err != nil {
+		t.Fatalf("UnmarshalState() of plain JSON error = %v", err)
+	}
+	if state.ActiveAgent != "Alice" || len(state.Messages) != 1 {
+		t.Errorf("UnmarshalState() of plain JSON = %+v", state)
+	}
+
+	if _, err := UnmarshalState([]byte(`{"version":99,"state":{}}`)); err == nil || !strings.Contains(err.Error(), "99") {
+		t.Errorf("UnmarshalState() of a newer version error = %v", err)
+	}
+	if _, err := UnmarshalState([]byte(`{"version":1,"state":{"messages":[{"role":"ai","parts":[{"type":"video"}]}]}}`)); err == nil {
+		t.Error("UnmarshalState() should reject unknown part types")
+	}
+}
+
+func TestMarshalStateUnsupportedPart(t *testing.T) {
+	state := SwarmState{Messages: []llms.MessageContent{{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{&llms.TextContent{Text: "pointer"}}}}}
+	if _, err := MarshalState(state); err == nil {
+		t.Error("MarshalState() should reject unsupported parts")
+	}
+}
+
+func TestFileSaverReadsPlainJSONState(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileSaver(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(Checkpoint{
+		ID:       "old",
+		ThreadID: "t",
+		State:    SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "t"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "t", "00000000000000000001-000001-old.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint, err := store.Latest(context.Background(), "t")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if checkpoint.ID != "old" || len(checkpoint.State.Messages) != 1 {
+		t.Errorf("Latest() = %+v", checkpoint)
+	}
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// KVStore is an in-memory Store keeping facts by key. It recalls the facts
+// sharing the most words with the query.
+type KVStore struct {
+	mu    sync.RWMutex
+	facts map[string]map[string]Fact
+}
+
+// NewKVStore creates an empty KVStore.
+func NewKVStore() *KVStore {
+	return &KVStore{facts: make(map[string]map[string]Fact)}
+}
+
+// Remember saves fact in namespace, replacing the fact with its key.
+func (s *KVStore) Remember(ctx context.Context, namespace string, fact Fact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.facts[namespace] == nil {
+		s.facts[namespace] = make(map[string]Fact)
+	}
+	s.facts[namespace][fact.Key] = fact
+	return nil
+}
+
+// Get returns the fact of namespace with the given key.
+func (s *KVStore) Get(ctx context.Context, namespace, key string) (Fact, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fact, ok := s.facts[namespace][key]
+	return fact, ok, nil
+}
+
+// Forget deletes the fact of namespace with the given key, if any.
+func (s *KVStore) Forget(ctx context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.facts[namespace], key)
+	return nil
+}
+
+// Recall returns up to limit facts of namespace sharing words with query,
+// those sharing the most first, or the most recent facts when query is
+// empty. Ties go to the most recent fact.
+func (s *KVStore) Recall(ctx context.Context, namespace, query string, limit int) ([]Fact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type match struct {
+		fact  Fact
+		score int
+	}
+	queryWords := words(query)
+	var matches []match
+	for _, fact := range s.facts[namespace] {
+		var score int
+		factWords := words(fact.Key + " " + fact.Value)
+		for word := range queryWords {
+			if factWords[word] {
+				score++
+			}
+		}
+		if score > 0 || len(queryWords) == 0 {
+			matches = append(matches, match{fact, score})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), b.fact.Updated.Compare(a.fact.Updated), strings.Compare(a.fact.Key, b.fact.Key))
+	})
+
+	facts := make([]Fact, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		facts = append(facts, m.fact)
+	}
+	return facts, nil
+}
+
+// words returns the lower-cased words of text; underscores separate words
+// too, so keys match their words.
+func words(text string) map[string]bool {
+	result := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		result[word] = true
+	}
+	return result
+}
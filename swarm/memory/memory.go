@@ -0,0 +1,157 @@
+// Package memory gives the agents of a swarm a long-term memory of facts,
+// shared between agents and kept across conversations.
+//
+// Agents read and write it with the remember and recall tools returned by
+// Tools. Facts are scoped: ByMetadata("user_id") keeps a memory per user, so
+// the hotel agent knows the seat preference the flight agent learned in a
+// previous session, while ByThread keeps one per conversation:
+//
+//	store := memory.NewKVStore()
+//	memoryTools := memory.Tools(store, memory.ByMetadata("user_id"))
+//	flights, _ := swarm.CreateReactAgent(model, append(flightTools, memoryTools...))
+//	hotels, _ := swarm.CreateReactAgent(model, append(hotelTools, memoryTools...))
+//	...
+//	result, err := app.Invoke(ctx, state, swarm.WithMetadata(map[string]any{"user_id": "u123"}))
+//
+// KVStore keeps facts by key and recalls them by keyword; VectorStore keeps
+// them in a langchaingo vector store and recalls them by similarity.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// defaultRecallLimit is the number of facts the recall tool returns when
+// the model does not ask for a number.
+const defaultRecallLimit = 5
+
+// ErrNoScope is returned by a Scope when the run has no value to scope the
+// memory by, such as a thread ID.
+var ErrNoScope = errors.New("no memory scope")
+
+// Fact is a fact remembered by an agent.
+type Fact struct {
+	// Key names the fact, e.g. "seat_preference"; remembering a fact with
+	// the same key replaces it
+	Key string `json:"key"`
+	// Value is the fact itself
+	Value string `json:"value"`
+	// Agent is the agent that remembered the fact
+	Agent string `json:"agent,omitempty"`
+	// Updated is when the fact was remembered
+	Updated time.Time `json:"updated"`
+}
+
+// Store stores the facts of each namespace, the scope of a memory.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Remember saves fact in namespace, replacing the fact with its key
+	Remember(ctx context.Context, namespace string, fact Fact) error
+	// Recall returns up to limit facts of namespace, the most relevant to
+	// query first, or the most recent first when query is empty
+	Recall(ctx context.Context, namespace, query string, limit int) ([]Fact, error)
+}
+
+// Scope returns the namespace of the memory a tool call reads and writes,
+// from the run it belongs to.
+type Scope func(ctx context.Context) (string, error)
+
+// ByThread scopes memories to the thread of the run (see swarm.WithThreadID).
+func ByThread() Scope {
+	return func(ctx context.Context) (string, error) {
+		config, _ := swarm.RunConfigFromContext(ctx)
+		if config.ThreadID == "" {
+			return "", fmt.Errorf("%w: the run has no thread", ErrNoScope)
+		}
+		return "thread:" + config.ThreadID, nil
+	}
+}
+
+// ByMetadata scopes memories to the value of key in the metadata of the run
+// (see swarm.WithMetadata), such as a user ID.
+func ByMetadata(key string) Scope {
+	return func(ctx context.Context) (string, error) {
+		config, _ := swarm.RunConfigFromContext(ctx)
+		value, ok := config.Metadata[key]
+		if !ok || value == "" {
+			return "", fmt.Errorf("%w: the run has no %q metadata", ErrNoScope, key)
+		}
+		return fmt.Sprintf("%s:%v", key, value), nil
+	}
+}
+
+// rememberArgs are the arguments of the remember tool.
+type rememberArgs struct {
+	Key   string `json:"key" jsonschema:"description=Short snake_case name of the fact such as seat_preference"`
+	Value string `json:"value" jsonschema:"description=The fact to remember"`
+}
+
+// recallArgs are the arguments of the recall tool.
+type recallArgs struct {
+	Query string `json:"query,omitempty" jsonschema:"description=What to look for; leave empty for the latest facts"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Maximum number of facts,minimum=1"`
+}
+
+// Tools returns the remember and recall tools, reading and writing the
+// memory of store selected by scope.
+func Tools(store Store, scope Scope) []tools.Tool {
+	return []tools.Tool{RememberTool(store, scope), RecallTool(store, scope)}
+}
+
+// RememberTool returns the remember tool, which saves a fact for later
+// conversations.
+func RememberTool(store Store, scope Scope) tools.Tool {
+	return swarm.NewStructTool("remember",
+		"Remember a fact about the user for later conversations, such as a preference. A fact with the same key replaces the previous one.",
+		func(ctx context.Context, args rememberArgs) (string, error) {
+			namespace, err := scope(ctx)
+			if err != nil {
+				return "", err
+			}
+			fact := Fact{
+				Key:     strings.TrimSpace(args.Key),
+				Value:   args.Value,
+				Agent:   swarm.AgentNameFromContext(ctx),
+				Updated: time.Now().UTC(),
+			}
+			if err := store.Remember(ctx, namespace, fact); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Remembered %s.", fact.Key), nil
+		})
+}
+
+// RecallTool returns the recall tool, which looks up remembered facts.
+func RecallTool(store Store, scope Scope) tools.Tool {
+	return swarm.NewStructTool("recall",
+		"Recall facts remembered about the user in this or earlier conversations.",
+		func(ctx context.Context, args recallArgs) (string, error) {
+			namespace, err := scope(ctx)
+			if err != nil {
+				return "", err
+			}
+			limit := args.Limit
+			if limit <= 0 {
+				limit = defaultRecallLimit
+			}
+			facts, err := store.Recall(ctx, namespace, args.Query, limit)
+			if err != nil {
+				return "", err
+			}
+			if len(facts) == 0 {
+				return "No facts remembered.", nil
+			}
+			var b strings.Builder
+			for _, fact := range facts {
+				fmt.Fprintf(&b, "- %s: %s\n", fact.Key, fact.Value)
+			}
+			return b.String(), nil
+		})
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestKVStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewKVStore()
+	now := time.Now()
+	facts := []Fact{
+		{Key: "seat_preference", Value: "aisle", Updated: now},
+		{Key: "home_airport", Value: "SFO", Updated: now.Add(time.Second)},
+		{Key: "seat_preference", Value: "window", Updated: now.Add(2 * time.Second)},
+	}
+	for _, fact := range facts {
+		if err := store.Remember(ctx, "user:u1", fact); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recalled, _ := store.Recall(ctx, "user:u1", "Which seat?", 5)
+	if len(recalled) != 1 || recalled[0].Value != "window" {
+		t.Errorf("Recall(seat) = %+v, want the latest seat preference", recalled)
+	}
+	recalled, _ = store.Recall(ctx, "user:u1", "", 5)
+	if len(recalled) != 2 || recalled[0].Key != "seat_preference" || recalled[1].Key != "home_airport" {
+		t.Errorf("Recall() = %+v, want the most recent facts first", recalled)
+	}
+	if recalled, _ = store.Recall(ctx, "user:u2", "", 5); len(recalled) != 0 {
+		t.Errorf("Recall() in another namespace = %+v", recalled)
+	}
+
+	if err := store.Forget(ctx, "user:u1", "seat_preference"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Get(ctx, "user:u1", "seat_preference"); ok {
+		t.Error("Get() found a forgotten fact")
+	}
+}
+
+func TestToolsShareFactsAcrossThreads(t *testing.T) {
+	store := NewKVStore()
+	memoryTools := Tools(store, ByMetadata("user_id"))
+	flightModel := swarmtest.NewMockModel(
+		swarmtest.ToolCall("remember", `{"key":"seat_preference","value":"aisle seat"}`),
+		swarmtest.Text("Noted."),
+	)
+	flights, err := swarm.CreateReactAgent(flightModel, memoryTools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hotelModel := swarmtest.NewMockModel(
+		swarmtest.ToolCall("recall", `{"query":"seat"}`),
+		swarmtest.Text("You like aisle seats."),
+	)
+	hotels, err := swarm.CreateReactAgent(hotelModel, memoryTools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saver := swarm.NewMemorySaver()
+	newApp := func(agent string, runnable swarm.AgentRunnable) *swarm.CompiledSwarm {
+		return swarmtest.Compile(t, swarm.SwarmConfig{
+			Agents:             []swarm.Agent{{Name: agent, Runnable: runnable}},
+			DefaultActiveAgent: agent,
+			Checkpointer:       saver,
+		})
+	}
+	user := swarm.WithMetadata(map[string]any{"user_id": "u1"})
+
+	_, err = newApp("Flights", flights).Invoke(context.Background(), swarmtest.UserMessage("I prefer the aisle"), swarm.WithThreadID("t1"), user)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	fact, ok, _ := store.Get(context.Background(), "user_id:u1", "seat_preference")
+	if !ok || fact.Value != "aisle seat" || fact.Agent != "Flights" {
+		t.Fatalf("remembered fact = %+v, %v", fact, ok)
+	}
+
+	_, err = newApp("Hotels", hotels).Invoke(context.Background(), swarmtest.UserMessage("Book a hotel"), swarm.WithThreadID("t2"), user)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	calls := hotelModel.Calls()
+	last := calls[len(calls)-1]
+	result := last[len(last)-1].Parts[0].(llms.ToolCallResponse)
+	if !strings.Contains(result.Content, "seat_preference: aisle seat") {
+		t.Errorf("recall result = %q", result.Content)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	var thread, user string
+	var threadErr, userErr error
+	agent := swarmtest.NewMockAgent(swarmtest.Reply{Text: "Hi"})
+	app := swarmtest.Compile(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Alice", Runnable: agent}},
+		DefaultActiveAgent: "Alice",
+		Middlewares: []swarm.Middleware{swarm.MiddlewareFuncs{
+			BeforeAgentFunc: func(ctx context.Context, agent string, state swarm.SwarmState) (swarm.SwarmState, error) {
+				thread, threadErr = ByThread()(ctx)
+				user, userErr = ByMetadata("user_id")(ctx)
+				return state, nil
+			},
+		}},
+		Checkpointer: swarm.NewMemorySaver(),
+	})
+
+	if _, err := app.Invoke(context.Background(), swarmtest.UserMessage("Hi"), swarm.WithThreadID("t1")); err != nil {
+		t.Fatal(err)
+	}
+	if thread != "thread:t1" || threadErr != nil {
+		t.Errorf("ByThread() = %q, %v", thread, threadErr)
+	}
+	if !errors.Is(userErr, ErrNoScope) {
+		t.Errorf("ByMetadata() = %q, %v, want ErrNoScope", user, userErr)
+	}
+}
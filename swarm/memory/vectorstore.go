@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// Metadata keys of the documents a VectorStore saves.
+const (
+	namespaceMetadataKey = "memory_namespace"
+	keyMetadataKey       = "memory_key"
+	valueMetadataKey     = "memory_value"
+	agentMetadataKey     = "memory_agent"
+	updatedMetadataKey   = "memory_updated"
+)
+
+// searchFactor is how many more documents than asked for a VectorStore
+// searches, to make up for those of other namespaces and superseded facts.
+const searchFactor = 4
+
+// VectorStore is a Store keeping facts in a langchaingo vector store, which
+// recalls the facts most similar to the query.
+//
+// Vector stores cannot replace documents, so a fact with the key of an
+// earlier one is added next to it; Recall returns only the latest. The
+// namespace is passed to the vector store with vectorstores.WithNameSpace
+// and saved in the document metadata, so stores ignoring namespaces still
+// keep memories apart.
+type VectorStore struct {
+	store vectorstores.VectorStore
+}
+
+// NewVectorStore returns a Store keeping facts in store.
+func NewVectorStore(store vectorstores.VectorStore) *VectorStore {
+	return &VectorStore{store: store}
+}
+
+// Remember adds fact to the vector store.
+func (s *VectorStore) Remember(ctx context.Context, namespace string, fact Fact) error {
+	doc := schema.Document{
+		PageContent: fmt.Sprintf("%s: %s", fact.Key, fact.Value),
+		Metadata: map[string]any{
+			namespaceMetadataKey: namespace,
+			keyMetadataKey:       fact.Key,
+			valueMetadataKey:     fact.Value,
+			agentMetadataKey:     fact.Agent,
+			updatedMetadataKey:   fact.Updated.Format(time.RFC3339Nano),
+		},
+	}
+	if _, err := s.store.AddDocuments(ctx, []schema.Document{doc}, vectorstores.WithNameSpace(namespace)); err != nil {
+		return fmt.Errorf("failed to remember %s: %w", fact.Key, err)
+	}
+	return nil
+}
+
+// Recall returns up to limit facts of namespace, the most similar to query
+// first. The vector store decides the order when query is empty.
+func (s *VectorStore) Recall(ctx context.Context, namespace, query string, limit int) ([]Fact, error) {
+	docs, err := s.store.SimilaritySearch(ctx, query, limit*searchFactor, vectorstores.WithNameSpace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to recall: %w", err)
+	}
+
+	latest := make(map[string]Fact)
+	var keys []string
+	for _, doc := range docs {
+		if doc.Metadata[namespaceMetadataKey] != namespace {
+			continue
+		}
+		fact := Fact{}
+		fact.Key, _ = doc.Metadata[keyMetadataKey].(string)
+		fact.Value, _ = doc.Metadata[valueMetadataKey].(string)
+		fact.Agent, _ = doc.Metadata[agentMetadataKey].(string)
+		if updated, ok := doc.Metadata[updatedMetadataKey].(string); ok {
+			fact.Updated, _ = time.Parse(time.RFC3339Nano, updated)
+		}
+		previous, seen := latest[fact.Key]
+		if !seen {
+			keys = append(keys, fact.Key)
+		}
+		if !seen || fact.Updated.After(previous.Updated) {
+			latest[fact.Key] = fact
+		}
+	}
+
+	facts := make([]Fact, 0, min(limit, len(keys)))
+	for _, key := range keys[:min(limit, len(keys))] {
+		facts = append(facts, latest[key])
+	}
+	return facts, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// fakeVectorStore returns its documents in the order they were added,
+// ignoring namespaces, like stores without namespace support.
+type fakeVectorStore struct {
+	docs       []schema.Document
+	namespaces []string
+}
+
+func (s *fakeVectorStore) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) {
+	var opts vectorstores.Options
+	for _, opt := range options {
+		opt(&opts)
+	}
+	s.namespaces = append(s.namespaces, opts.NameSpace)
+	s.docs = append(s.docs, docs...)
+	return nil, nil
+}
+
+func (s *fakeVectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, options ...vectorstores.Option) ([]schema.Document, error) {
+	return s.docs[:min(numDocuments, len(s.docs))], nil
+}
+
+func TestVectorStore(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeVectorStore{}
+	store := NewVectorStore(fake)
+	now := time.Now()
+	facts := []struct {
+		namespace string
+		fact      Fact
+	}{
+		{"user:u1", Fact{Key: "seat_preference", Value: "aisle", Agent: "Flights", Updated: now}},
+		{"user:u2", Fact{Key: "seat_preference", Value: "middle", Updated: now}},
+		{"user:u1", Fact{Key: "home_airport", Value: "SFO", Updated: now.Add(time.Second)}},
+		{"user:u1", Fact{Key: "seat_preference", Value: "window", Updated: now.Add(2 * time.Second)}},
+	}
+	for _, f := range facts {
+		if err := store.Remember(ctx, f.namespace, f.fact); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fake.namespaces[0] != "user:u1" || fake.docs[0].PageContent != "seat_preference: aisle" {
+		t.Errorf("saved %q in namespace %q", fake.docs[0].PageContent, fake.namespaces[0])
+	}
+
+	recalled, err := store.Recall(ctx, "user:u1", "seat", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recalled) != 2 || recalled[0].Value != "window" || recalled[1].Value != "SFO" {
+		t.Errorf("Recall() = %+v, want the latest facts of the namespace", recalled)
+	}
+	if recalled, _ := store.Recall(ctx, "user:u1", "seat", 1); len(recalled) != 1 {
+		t.Errorf("Recall() with limit 1 = %+v", recalled)
+	}
+}
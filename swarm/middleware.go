@@ -0,0 +1,147 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Middleware hooks into every agent run and tool call of a swarm (see
+// SwarmConfig.Middlewares), so that guardrails such as content filters,
+// redaction, prompt-injection detection or output validation apply to all
+// agents without changing them. Embed MiddlewareFuncs or use it directly to
+// implement only some of the hooks.
+//
+// The Before hooks of the middlewares run in order and the After hooks in
+// reverse order, so that the first middleware sees the state the agent gets
+// last and its output first.
+type Middleware interface {
+	// BeforeAgent is called before an agent runs and returns the state it
+	// runs with, which is also kept in the conversation. An error fails the
+	// run.
+	BeforeAgent(ctx context.Context, agent string, state SwarmState) (SwarmState, error)
+	// AfterAgent is called with the state produced by a run and returns the
+	// state the swarm continues with. An error fails the run, which the
+	// agent's RetryPolicy may retry.
+	AfterAgent(ctx context.Context, agent string, state SwarmState) (SwarmState, error)
+	// BeforeTool is called before a tool call of a ToolNode and returns the
+	// arguments to call the tool with. An error blocks the call and is
+	// reported to the model as the tool's result.
+	BeforeTool(ctx context.Context, agent string, call llms.ToolCall) (string, error)
+	// AfterTool is called with the result of a tool call of a ToolNode and
+	// returns the result passed to the model. An error replaces the result.
+	AfterTool(ctx context.Context, agent string, call llms.ToolCall, result string) (string, error)
+}
+
+// MiddlewareFuncs is a Middleware built from optional functions. Nil fields
+// leave the state, arguments or result unchanged.
+//
+// Example:
+//
+//	noSecrets := swarm.MiddlewareFuncs{
+//	    AfterToolFunc: func(ctx context.Context, agent string, call llms.ToolCall, result string) (string, error) {
+//	        return strings.ReplaceAll(result, apiKey, "[REDACTED]"), nil
+//	    },
+//	}
+type MiddlewareFuncs struct {
+	BeforeAgentFunc func(ctx context.Context, agent string, state SwarmState) (SwarmState, error)
+	AfterAgentFunc  func(ctx context.Context, agent string, state SwarmState) (SwarmState, error)
+	BeforeToolFunc  func(ctx context.Context, agent string, call llms.ToolCall) (string, error)
+	AfterToolFunc   func(ctx context.Context, agent string, call llms.ToolCall, result string) (string, error)
+}
+
+// BeforeAgent calls f.BeforeAgentFunc if set.
+func (f MiddlewareFuncs) BeforeAgent(ctx context.Context, agent string, state SwarmState) (SwarmState, error) {
+	if f.BeforeAgentFunc == nil {
+		return state, nil
+	}
+	return f.BeforeAgentFunc(ctx, agent, state)
+}
+
+// AfterAgent calls f.AfterAgentFunc if set.
+func (f MiddlewareFuncs) AfterAgent(ctx context.Context, agent string, state SwarmState) (SwarmState, error) {
+	if f.AfterAgentFunc == nil {
+		return state, nil
+	}
+	return f.AfterAgentFunc(ctx, agent, state)
+}
+
+// BeforeTool calls f.BeforeToolFunc if set.
+func (f MiddlewareFuncs) BeforeTool(ctx context.Context, agent string, call llms.ToolCall) (string, error) {
+	if f.BeforeToolFunc == nil {
+		return call.FunctionCall.Arguments, nil
+	}
+	return f.BeforeToolFunc(ctx, agent, call)
+}
+
+// AfterTool calls f.AfterToolFunc if set.
+func (f MiddlewareFuncs) AfterTool(ctx context.Context, agent string, call llms.ToolCall, result string) (string, error) {
+	if f.AfterToolFunc == nil {
+		return result, nil
+	}
+	return f.AfterToolFunc(ctx, agent, call, result)
+}
+
+// middlewaresKey is the context key for the middlewares of a run.
+type middlewaresKey struct{}
+
+// withMiddlewares returns a context in which tool nodes call the tool hooks
+// of middlewares.
+func withMiddlewares(ctx context.Context, middlewares []Middleware) context.Context {
+	return context.WithValue(ctx, middlewaresKey{}, middlewares)
+}
+
+// beforeAgent runs the BeforeAgent hooks of middlewares.
+func beforeAgent(ctx context.Context, middlewares []Middleware, agent string, state SwarmState) (SwarmState, error) {
+	result := state
+	for _, m := range middlewares {
+		var err error
+		if result, err = m.BeforeAgent(ctx, agent, result); err != nil {
+			return state, fmt.Errorf("middleware: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// afterAgent runs the AfterAgent hooks of middlewares.
+func afterAgent(ctx context.Context, middlewares []Middleware, agent string, state SwarmState) (SwarmState, error) {
+	result := state
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		var err error
+		if result, err = middlewares[i].AfterAgent(ctx, agent, result); err != nil {
+			return state, fmt.Errorf("middleware: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// beforeTool runs the BeforeTool hooks of the middlewares in ctx and
+// returns the arguments of the call.
+func beforeTool(ctx context.Context, call llms.ToolCall) (string, error) {
+	middlewares, _ := ctx.Value(middlewaresKey{}).([]Middleware)
+	agent := AgentNameFromContext(ctx)
+	for _, m := range middlewares {
+		arguments, err := m.BeforeTool(ctx, agent, call)
+		if err != nil {
+			return "", err
+		}
+		function := *call.FunctionCall
+		function.Arguments = arguments
+		call.FunctionCall = &function
+	}
+	return call.FunctionCall.Arguments, nil
+}
+
+// afterTool runs the AfterTool hooks of the middlewares in ctx.
+func afterTool(ctx context.Context, call llms.ToolCall, result string) (string, error) {
+	middlewares, _ := ctx.Value(middlewaresKey{}).([]Middleware)
+	agent := AgentNameFromContext(ctx)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		var err error
+		if result, err = middlewares[i].AfterTool(ctx, agent, call, result); err != nil {
+			return "", err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,399 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrContentFlagged is matched (with errors.Is) by the error returned when
+// moderation blocks a message (see SwarmConfig.Moderation).
+var ErrContentFlagged = errors.New("content flagged by moderation")
+
+const (
+	// defaultRedactedText replaces flagged text under ModerationRedact.
+	defaultRedactedText = "[removed by moderation]"
+	// defaultOpenAIModerationModel is the model of an OpenAIModerator
+	// without Model.
+	defaultOpenAIModerationModel = "omni-moderation-latest"
+	// defaultOpenAIBaseURL is the API of an OpenAIModerator without BaseURL.
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+)
+
+// ModerationResult is the verdict of a Moderator on a text.
+type ModerationResult struct {
+	// Flagged reports whether the text breaks the content policy
+	Flagged bool
+	// Categories are the policy categories the text was flagged for, such
+	// as "harassment" or "self-harm"
+	Categories []string
+}
+
+// Moderator classifies text against a content policy, e.g. an
+// OpenAIModerator.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (ModerationResult, error)
+}
+
+// ModeratorFunc adapts a function to the Moderator interface.
+type ModeratorFunc func(ctx context.Context, text string) (ModerationResult, error)
+
+// Moderate calls f.
+func (f ModeratorFunc) Moderate(ctx context.Context, text string) (ModerationResult, error) {
+	return f(ctx, text)
+}
+
+// ModerationAction is what a swarm does with a flagged message.
+type ModerationAction int
+
+const (
+	// ModerationBlock fails the run with a *ModerationError. This is the
+	// default.
+	ModerationBlock ModerationAction = iota
+	// ModerationRedact replaces the flagged text and continues the run.
+	ModerationRedact
+	// ModerationEscalate hands the conversation off to Moderation.EscalateTo,
+	// e.g. a human agent. Flagged answers are discarded.
+	ModerationEscalate
+	// ModerationNone does not moderate the messages.
+	ModerationNone
+)
+
+// String returns the name of the action.
+func (a ModerationAction) String() string {
+	switch a {
+	case ModerationBlock:
+		return "ModerationBlock"
+	case ModerationRedact:
+		return "ModerationRedact"
+	case ModerationEscalate:
+		return "ModerationEscalate"
+	case ModerationNone:
+		return "ModerationNone"
+	default:
+		return "ModerationAction(unknown)"
+	}
+}
+
+// Moderation checks the user's messages before an agent answers them and
+// the answers of agents before they are added to the conversation (see
+// SwarmConfig.Moderation).
+//
+// Example:
+//
+//	config.Moderation = &swarm.Moderation{
+//	    Moderator:  &swarm.OpenAIModerator{APIKey: os.Getenv("OPENAI_API_KEY")},
+//	    Input:      swarm.ModerationEscalate,
+//	    Output:     swarm.ModerationRedact,
+//	    EscalateTo: "human",
+//	}
+type Moderation struct {
+	// Moderator classifies the messages
+	Moderator Moderator
+	// Input is the action on flagged user messages, which are moderated
+	// when an agent starts a turn (default: ModerationBlock)
+	Input ModerationAction
+	// Output is the action on flagged text of the AI messages an agent
+	// produces (default: ModerationBlock)
+	Output ModerationAction
+	// EscalateTo is the agent taking over flagged conversations under
+	// ModerationEscalate. Its own messages are not moderated. Workers of a
+	// supervisor swarm escalate to the supervisor, which they always
+	// return to.
+	EscalateTo string
+	// RedactedText replaces flagged text under ModerationRedact (default:
+	// "[removed by moderation]")
+	RedactedText string
+}
+
+// ModerationError reports a message blocked by moderation.
+type ModerationError struct {
+	// Agent is the agent about to answer the message, or that wrote it
+	Agent string
+	// Output reports whether the message is an answer of Agent rather than
+	// a user message
+	Output bool
+	// Categories are the categories the message was flagged for
+	Categories []string
+}
+
+// Error describes the blocked message.
+func (e *ModerationError) Error() string {
+	source := "user message"
+	if e.Output {
+		source = fmt.Sprintf("answer of agent '%s'", e.Agent)
+	}
+	return fmt.Sprintf("%v: %s (%s)", ErrContentFlagged, source, strings.Join(e.Categories, ", "))
+}
+
+// Is reports whether target is ErrContentFlagged.
+func (e *ModerationError) Is(target error) bool {
+	return target == ErrContentFlagged
+}
+
+// run moderates the user messages ending state, runs the agent with them
+// unless the conversation is escalated, and moderates the AI messages it
+// adds. The tokens the agent streams meanwhile are held back until its
+// messages pass moderation; the redacted text replaces them under
+// ModerationRedact, and none are streamed when the messages are blocked or
+// escalated. A nil Moderation only runs the agent.
+func (m *Moderation) run(ctx context.Context, agent string, state SwarmState, run func(ctx context.Context, state SwarmState) (SwarmState, error)) (SwarmState, error) {
+	if m == nil || agent == m.EscalateTo {
+		return run(ctx, state)
+	}
+
+	if m.Input != ModerationNone {
+		first := len(state.Messages)
+		for first > 0 && state.Messages[first-1].Role == llms.ChatMessageTypeHuman {
+			first--
+		}
+		input, flagged, err := m.moderate(ctx, state, first, llms.ChatMessageTypeHuman, m.Input == ModerationRedact)
+		if err != nil {
+			return state, err
+		}
+		if len(flagged) > 0 {
+			switch m.Input {
+			case ModerationBlock:
+				return state, &ModerationError{Agent: agent, Categories: flagged}
+			case ModerationEscalate:
+				return m.escalate(agent, state, "the user's message", flagged), nil
+			}
+		}
+		state = input
+	}
+
+	if m.Output == ModerationNone {
+		return run(ctx, state)
+	}
+	var buffer *streamBuffer
+	if handler := StreamHandlerFromContext(ctx); handler != nil {
+		buffer = &streamBuffer{StreamHandler: handler}
+		ctx = WithStreamHandler(ctx, buffer)
+	}
+	result, err := run(ctx, state)
+	if err != nil || len(result.Messages) < len(state.Messages) {
+		buffer.flush(ctx)
+		return result, err
+	}
+	output, flagged, err := m.moderate(ctx, result, len(state.Messages), llms.ChatMessageTypeAI, m.Output == ModerationRedact)
+	if err != nil {
+		return state, err
+	}
+	if len(flagged) > 0 {
+		switch m.Output {
+		case ModerationBlock:
+			return state, &ModerationError{Agent: agent, Output: true, Categories: flagged}
+		case ModerationEscalate:
+			return m.escalate(agent, state, fmt.Sprintf("the answer of agent '%s'", agent), flagged), nil
+		}
+		buffer.replace(ctx, agent, output.Messages[len(state.Messages):])
+		return output, nil
+	}
+	buffer.flush(ctx)
+	return output, nil
+}
+
+// streamBuffer is a StreamHandler holding back the tokens of an agent run
+// until its output is moderated. Other callbacks go through.
+type streamBuffer struct {
+	StreamHandler
+	mu     sync.Mutex
+	tokens [][2]string
+}
+
+// OnToken keeps the token, with the agent that streamed it.
+func (b *streamBuffer) OnToken(ctx context.Context, agent string, token string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = append(b.tokens, [2]string{agent, token})
+}
+
+// flush streams the tokens held back. It is a no-op on a nil streamBuffer.
+func (b *streamBuffer) flush(ctx context.Context) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, token := range b.tokens {
+		b.StreamHandler.OnToken(ctx, token[0], token[1])
+	}
+	b.tokens = nil
+}
+
+// replace streams the text of the AI messages added by agent, once
+// redacted, in place of the tokens held back. It is a no-op on a nil
+// streamBuffer.
+func (b *streamBuffer) replace(ctx context.Context, agent string, added []llms.MessageContent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = nil
+	for _, message := range added {
+		if message.Role != llms.ChatMessageTypeAI {
+			continue
+		}
+		for _, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok && text.Text != "" {
+				b.StreamHandler.OnToken(ctx, agent, text.Text)
+			}
+		}
+	}
+}
+
+// moderate checks the text of the messages of role from index first on,
+// and returns the categories they were flagged for. With redact, the
+// flagged text is replaced in the returned state.
+func (m *Moderation) moderate(ctx context.Context, state SwarmState, first int, role llms.ChatMessageType, redact bool) (SwarmState, []string, error) {
+	categories := make(map[string]bool)
+	flagged := false
+	for i := first; i < len(state.Messages); i++ {
+		message := state.Messages[i]
+		if message.Role != role {
+			continue
+		}
+		for j, part := range message.Parts {
+			text, ok := part.(llms.TextContent)
+			if !ok || strings.TrimSpace(text.Text) == "" {
+				continue
+			}
+			result, err := m.Moderator.Moderate(ctx, text.Text)
+			if err != nil {
+				return state, nil, fmt.Errorf("moderation: %w", err)
+			}
+			if !result.Flagged {
+				continue
+			}
+			flagged = true
+			for _, category := range result.Categories {
+				categories[category] = true
+			}
+			if redact {
+				replacement := m.RedactedText
+				if replacement == "" {
+					replacement = defaultRedactedText
+				}
+				state.Messages = slices.Clone(state.Messages)
+				parts := slices.Clone(message.Parts)
+				parts[j] = llms.TextContent{Text: replacement}
+				message = llms.MessageContent{Role: message.Role, Parts: parts}
+				state.Messages[i] = message
+			}
+		}
+	}
+	if !flagged {
+		return state, nil, nil
+	}
+	if len(categories) == 0 {
+		return state, []string{"unspecified"}, nil
+	}
+	return state, slices.Sorted(maps.Keys(categories)), nil
+}
+
+// escalate returns state handed off from agent to the escalation agent,
+// with a system message telling it why.
+func (m *Moderation) escalate(agent string, state SwarmState, source string, categories []string) SwarmState {
+	reason := fmt.Sprintf("%s was flagged by moderation (%s)", source, strings.Join(categories, ", "))
+	state.Messages = append(slices.Clip(state.Messages), llms.TextParts(llms.ChatMessageTypeSystem,
+		fmt.Sprintf("The conversation was escalated to you because %s.", reason)))
+	state.ActiveAgent = m.EscalateTo
+	state.HandoffPayload = map[string]any{"reason": reason, "categories": categories}
+	state.Handoffs = append(slices.Clip(state.Handoffs), HandoffRecord{
+		From:      agent,
+		To:        m.EscalateTo,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	})
+	return state
+}
+
+// escalationAgent returns the agent flagged conversations are handed off
+// to, or "".
+func (m *Moderation) escalationAgent() string {
+	if m == nil || (m.Input != ModerationEscalate && m.Output != ModerationEscalate) {
+		return ""
+	}
+	return m.EscalateTo
+}
+
+// OpenAIModerator is a Moderator using the OpenAI moderation endpoint.
+type OpenAIModerator struct {
+	// APIKey authenticates the requests
+	APIKey string
+	// Model is the moderation model (default: "omni-moderation-latest")
+	Model string
+	// BaseURL is the API's URL (default: "https://api.openai.com/v1")
+	BaseURL string
+	// HTTPClient sends the requests (default: http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+// Moderate implements Moderator.
+func (m *OpenAIModerator) Moderate(ctx context.Context, text string) (ModerationResult, error) {
+	model, baseURL, client := m.Model, m.BaseURL, m.HTTPClient
+	if model == "" {
+		model = defaultOpenAIModerationModel
+	}
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(map[string]string{"model": model, "input": text})
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.APIKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteErrorBody))
+		return ModerationResult{}, fmt.Errorf("openai moderation: %s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+
+	var response struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return ModerationResult{}, fmt.Errorf("openai moderation: invalid response: %w", err)
+	}
+	if len(response.Results) == 0 {
+		return ModerationResult{}, errors.New("openai moderation: no results")
+	}
+
+	result := ModerationResult{Flagged: response.Results[0].Flagged}
+	for category, flagged := range response.Results[0].Categories {
+		if flagged {
+			result.Categories = append(result.Categories, category)
+		}
+	}
+	slices.Sort(result.Categories)
+	return result, nil
+}
@@ -0,0 +1,244 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// keywordModerator flags texts containing "hate".
+var keywordModerator = ModeratorFunc(func(ctx context.Context, text string) (ModerationResult, error) {
+	if strings.Contains(text, "hate") {
+		return ModerationResult{Flagged: true, Categories: []string{"hate"}}, nil
+	}
+	return ModerationResult{}, nil
+})
+
+// echoAgent answers with the text of the last message, and records the
+// conversations it runs with.
+func echoAgent(seen *[][]llms.MessageContent) invokerFunc {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		*seen = append(*seen, state.Messages)
+		last := state.Messages[len(state.Messages)-1]
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI,
+			"You said: "+last.Parts[0].(llms.TextContent).Text))
+		return state, nil
+	}
+}
+
+func lastText(state SwarmState) string {
+	last := state.Messages[len(state.Messages)-1]
+	return last.Parts[0].(llms.TextContent).Text
+}
+
+func TestModerationInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     ModerationAction
+		wantErr    bool
+		wantAlice  bool
+		wantAnswer string
+	}{
+		{name: "block", action: ModerationBlock, wantErr: true},
+		{name: "redact", action: ModerationRedact, wantAlice: true, wantAnswer: "You said: [removed by moderation]"},
+		{name: "escalate", action: ModerationEscalate, wantAnswer: "A human will help you"},
+		{name: "none", action: ModerationNone, wantAlice: true, wantAnswer: "You said: I hate this"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen [][]llms.MessageContent
+			app := compileSwarm(t, SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: echoAgent(&seen), Destinations: []string{"Bob"}},
+					{Name: "Bob", Runnable: createMockAgent("Bob", "Hi from Bob")},
+					{Name: "human", Runnable: createMockAgent("human", "A human will help you")},
+				},
+				DefaultActiveAgent: "Alice",
+				Moderation:         &Moderation{Moderator: keywordModerator, Input: tt.action, Output: ModerationNone, EscalateTo: "human"},
+			})
+
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "I hate this")},
+			})
+			if tt.wantErr {
+				var moderationErr *ModerationError
+				if !errors.Is(err, ErrContentFlagged) || !errors.As(err, &moderationErr) || moderationErr.Output {
+					t.Fatalf("Invoke() error = %v, want a ModerationError for the input", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if ran := len(seen) > 0; ran != tt.wantAlice {
+				t.Errorf("Alice ran = %v, want %v", ran, tt.wantAlice)
+			}
+			if tt.wantAnswer != "" && lastText(result) != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", lastText(result), tt.wantAnswer)
+			}
+		})
+	}
+}
+
+func TestModerationEscalatesInput(t *testing.T) {
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hello")},
+			{Name: "human", Runnable: createMockAgent("human", "A human will help you")},
+		},
+		DefaultActiveAgent: "Alice",
+		Moderation:         &Moderation{Moderator: keywordModerator, Input: ModerationEscalate, EscalateTo: "human"},
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "I hate this")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "human" || len(result.Handoffs) != 1 || result.Handoffs[0].From != "Alice" {
+		t.Errorf("active agent = %q, handoffs = %+v; want an escalation from Alice", result.ActiveAgent, result.Handoffs)
+	}
+	want := "The conversation was escalated to you because the user's message was flagged by moderation (hate)."
+	if got := result.Messages[1].Parts[0].(llms.TextContent).Text; got != want {
+		t.Errorf("system message = %q, want %q", got, want)
+	}
+}
+
+func TestModerationOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     ModerationAction
+		wantErr    bool
+		wantAnswer string
+	}{
+		{name: "block", action: ModerationBlock, wantErr: true},
+		{name: "redact", action: ModerationRedact, wantAnswer: "[removed]"},
+		{name: "escalate", action: ModerationEscalate, wantAnswer: "A human will help you"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := compileSwarm(t, SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: createMockAgent("Alice", "I hate you")},
+					{Name: "human", Runnable: createMockAgent("human", "A human will help you")},
+				},
+				DefaultActiveAgent: "Alice",
+				Moderation: &Moderation{
+					Moderator:    keywordModerator,
+					Output:       tt.action,
+					EscalateTo:   "human",
+					RedactedText: "[removed]",
+				},
+			})
+
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+			})
+			if tt.wantErr {
+				var moderationErr *ModerationError
+				if !errors.As(err, &moderationErr) || !moderationErr.Output || moderationErr.Agent != "Alice" {
+					t.Fatalf("Invoke() error = %v, want a ModerationError for Alice's answer", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if lastText(result) != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", lastText(result), tt.wantAnswer)
+			}
+			for _, message := range result.Messages {
+				if text, ok := message.Parts[0].(llms.TextContent); ok && text.Text == "I hate you" {
+					t.Error("the flagged answer was kept")
+				}
+			}
+		})
+	}
+}
+
+func TestModerationOutputStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     ModerationAction
+		answer     string
+		wantTokens string
+	}{
+		{name: "clean", action: ModerationBlock, answer: "Hello", wantTokens: "Hello"},
+		{name: "block", action: ModerationBlock, answer: "I hate you"},
+		{name: "redact", action: ModerationRedact, answer: "I hate you", wantTokens: "[removed]"},
+		{name: "escalate", action: ModerationEscalate, answer: "I hate you"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alice, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{{Content: tt.answer}}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			app := compileSwarm(t, SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: alice},
+					{Name: "human", Runnable: createMockAgent("human", "A human will help you")},
+				},
+				DefaultActiveAgent: "Alice",
+				Moderation: &Moderation{
+					Moderator:    keywordModerator,
+					Output:       tt.action,
+					EscalateTo:   "human",
+					RedactedText: "[removed]",
+				},
+			})
+
+			// Flagged answers never reach streaming clients
+			var tokens strings.Builder
+			ctx := WithStreamHandler(context.Background(), StreamHandlerFuncs{
+				Token: func(ctx context.Context, agent, token string) { tokens.WriteString(token) },
+			})
+			app.Invoke(ctx, SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+			})
+			if tokens.String() != tt.wantTokens {
+				t.Errorf("streamed %q, want %q", tokens.String(), tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestModerationConfig(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")}},
+		DefaultActiveAgent: "Alice",
+		Moderation:         &Moderation{Moderator: keywordModerator, Input: ModerationEscalate, EscalateTo: "human"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown escalation agent 'human'") {
+		t.Errorf("CreateSwarm() error = %v, want unknown escalation agent", err)
+	}
+}
+
+func TestOpenAIModerator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if r.URL.Path != "/v1/moderations" || r.Header.Get("Authorization") != "Bearer sk-test" ||
+			body["model"] != "omni-moderation-latest" || body["input"] != "I hate you" {
+			t.Errorf("unexpected request %s %v %v", r.URL.Path, r.Header, body)
+		}
+		_, _ = w.Write([]byte(`{"results":[{"flagged":true,"categories":{"harassment":true,"hate":true,"violence":false}}]}`))
+	}))
+	defer server.Close()
+
+	moderator := &OpenAIModerator{APIKey: "sk-test", BaseURL: server.URL + "/v1"}
+	result, err := moderator.Moderate(context.Background(), "I hate you")
+	if err != nil {
+		t.Fatalf("Moderate() error = %v", err)
+	}
+	if !result.Flagged || strings.Join(result.Categories, ",") != "harassment,hate" {
+		t.Errorf("Moderate() = %+v", result)
+	}
+}
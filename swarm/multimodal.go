@@ -0,0 +1,129 @@
+package swarm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// imageTokenEstimate is the number of tokens a context window policy
+// counts for an image: the cost of a high-detail 512x512 tile and its base
+// in OpenAI's accounting, a middle ground between providers.
+const imageTokenEstimate = 255
+
+// UserMessage returns a user message with text followed by attachments,
+// such as images for vision-capable agents. Empty text is left out.
+//
+// Example:
+//
+//	photo, err := swarm.ImageFile("claim-1234/front.jpg")
+//	...
+//	state.Messages = append(state.Messages, swarm.UserMessage("Assess the damage", photo,
+//	    llms.ImageURLPart("https://example.com/claims/1234/side.jpg")))
+func UserMessage(text string, attachments ...llms.ContentPart) llms.MessageContent {
+	parts := make([]llms.ContentPart, 0, len(attachments)+1)
+	if text != "" || len(attachments) == 0 {
+		parts = append(parts, llms.TextContent{Text: text})
+	}
+	return llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: append(parts, attachments...)}
+}
+
+// ImageFile reads an image file into a binary part, with its MIME type
+// taken from the file's extension or, failing that, its content. Files that
+// are not images are rejected.
+func ImageFile(path string) (llms.BinaryContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return llms.BinaryContent{}, err
+	}
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return llms.BinaryContent{}, fmt.Errorf("%s is not an image: %s", path, mimeType)
+	}
+	return llms.BinaryContent{MIMEType: mimeType, Data: data}, nil
+}
+
+// ImageData returns a binary part holding an image, with its MIME type
+// detected from its content.
+func ImageData(data []byte) llms.BinaryContent {
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return llms.BinaryContent{MIMEType: mimeType, Data: data}
+}
+
+// attachment describes an image or binary part of a message, without its
+// data.
+type attachment struct {
+	// URL is the address of an image_url part
+	URL string `json:"url,omitempty"`
+	// MIMEType and Size describe a binary part
+	MIMEType string `json:"mime_type,omitempty"`
+	Size     int    `json:"size,omitempty"`
+	// data is the content of a binary image, embedded in HTML transcripts
+	data []byte
+}
+
+// attachments describes the image and binary parts of parts.
+func attachments(parts []llms.ContentPart) []attachment {
+	var found []attachment
+	for _, part := range parts {
+		switch p := part.(type) {
+		case llms.ImageURLContent:
+			found = append(found, attachment{URL: p.URL})
+		case llms.BinaryContent:
+			found = append(found, attachment{MIMEType: p.MIMEType, Size: len(p.Data), data: p.Data})
+		}
+	}
+	return found
+}
+
+// String describes the attachment in text.
+func (a attachment) String() string {
+	if a.URL != "" {
+		return "[image: " + a.URL + "]"
+	}
+	return fmt.Sprintf("[%s attachment, %s]", a.MIMEType, formatSize(a.Size))
+}
+
+// Src returns the address at which an HTML page shows the attachment: the
+// URL of an image, or a data URL embedding a binary image. It is empty for
+// other attachments and URLs with other schemes.
+func (a attachment) Src() template.URL {
+	switch {
+	case strings.HasPrefix(a.URL, "https://"), strings.HasPrefix(a.URL, "http://"), strings.HasPrefix(a.URL, "data:image/"):
+		return template.URL(a.URL)
+	case a.URL == "" && strings.HasPrefix(a.MIMEType, "image/"):
+		return template.URL("data:" + a.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(a.data))
+	}
+	return ""
+}
+
+// markdown renders the attachment in a Markdown transcript, showing images
+// at a URL.
+func (a attachment) markdown() string {
+	if strings.HasPrefix(a.URL, "https://") || strings.HasPrefix(a.URL, "http://") {
+		return "![image](" + a.URL + ")"
+	}
+	return a.String()
+}
+
+// formatSize formats a number of bytes for people.
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,92 @@
+package swarm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// pngHeader is the start of a PNG file.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestImageFile(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "photo")
+	if err := os.WriteFile(photo, pngHeader, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	image, err := ImageFile(photo)
+	if err != nil || image.MIMEType != "image/png" {
+		t.Errorf("ImageFile() = %v, %v", image.MIMEType, err)
+	}
+
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImageFile(notes); err == nil {
+		t.Error("ImageFile() of a text file should return an error")
+	}
+	if got := ImageData(pngHeader).MIMEType; got != "image/png" {
+		t.Errorf("ImageData() MIME type = %q", got)
+	}
+}
+
+func TestMultimodalMessages(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "The bumper is dented."}}}
+	assessor, err := CreateReactAgent(model, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileSaver(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "assessor", Runnable: assessor}},
+		DefaultActiveAgent: "assessor",
+		Checkpointer:       store,
+	})
+
+	message := UserMessage("Assess the damage", ImageData(pngHeader), llms.ImageURLPart("https://example.com/side.jpg"))
+	if len(message.Parts) != 3 || message.Role != llms.ChatMessageTypeHuman {
+		t.Fatalf("UserMessage() = %+v", message)
+	}
+	if _, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{message}}, WithThreadID("claim-1")); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got := model.calls[0][len(model.calls[0])-1]; !reflect.DeepEqual(got, message) {
+		t.Errorf("model received %+v, want %+v", got, message)
+	}
+	checkpoint, err := store.Latest(context.Background(), "claim-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := checkpoint.State.Messages[0]; !reflect.DeepEqual(got, message) {
+		t.Errorf("checkpointed message = %+v, want %+v", got, message)
+	}
+
+	markdown, err := ExportTranscript(checkpoint.State, TranscriptMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "[image/png attachment, 16 bytes]") || !strings.Contains(markdown, "![image](https://example.com/side.jpg)") {
+		t.Errorf("Markdown transcript = %s", markdown)
+	}
+	page, err := ExportTranscript(checkpoint.State, TranscriptHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, `<img src="data:image/png;base64,`) || !strings.Contains(page, `<img src="https://example.com/side.jpg"`) {
+		t.Errorf("HTML transcript = %s", page)
+	}
+
+	if got := estimateTokens(message); got != 4+2*imageTokenEstimate+1 {
+		t.Errorf("estimateTokens() = %d", got)
+	}
+}
@@ -0,0 +1,152 @@
+package swarm
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// PIIPattern is a kind of personal data masked by a PIIRedactor.
+type PIIPattern struct {
+	// Name labels the masked data, e.g. "EMAIL" masks it as "[EMAIL]"
+	Name string
+	// Regexp matches the data
+	Regexp *regexp.Regexp
+	// Valid, if set, filters the matches, e.g. with a checksum
+	Valid func(match string) bool
+}
+
+var (
+	emailRegexp      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phoneRegexp      = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?\(?\b\d{3}\)?[\s.-]?\d{3}[\s.-]?\d{4}\b`)
+	creditCardRegexp = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// DefaultPIIPatterns returns the patterns masked by a PIIRedactor without
+// Patterns: credit card numbers (checked with the Luhn algorithm), email
+// addresses and phone numbers. Append to it to mask other data as well.
+func DefaultPIIPatterns() []PIIPattern {
+	return []PIIPattern{
+		{Name: "CREDIT_CARD", Regexp: creditCardRegexp, Valid: luhnValid},
+		{Name: "EMAIL", Regexp: emailRegexp},
+		{Name: "PHONE", Regexp: phoneRegexp},
+	}
+}
+
+// PIIRedactor is a Middleware masking personal data in the conversation, so
+// that it is neither sent to models nor saved in checkpoints. It masks the
+// text, tool call arguments and tool results of the messages an agent runs
+// with and of those it produces, as well as tool results before the model
+// sees them. SwarmState.Values and handoff payloads are left as they are.
+//
+// Example:
+//
+//	ssn := swarm.PIIPattern{Name: "SSN", Regexp: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)}
+//	config.Middlewares = []swarm.Middleware{
+//	    &swarm.PIIRedactor{Patterns: append(swarm.DefaultPIIPatterns(), ssn)},
+//	}
+type PIIRedactor struct {
+	// Patterns are the kinds of data to mask, in order (default:
+	// DefaultPIIPatterns)
+	Patterns []PIIPattern
+}
+
+// Redact returns text with the data matching the redactor's patterns
+// replaced by their label in brackets.
+func (r *PIIRedactor) Redact(text string) string {
+	patterns := r.Patterns
+	if patterns == nil {
+		patterns = DefaultPIIPatterns()
+	}
+	for _, p := range patterns {
+		mask := "[" + p.Name + "]"
+		text = p.Regexp.ReplaceAllStringFunc(text, func(match string) string {
+			if p.Valid != nil && !p.Valid(match) {
+				return match
+			}
+			return mask
+		})
+	}
+	return text
+}
+
+// BeforeAgent masks the messages the agent runs with. It implements
+// Middleware.
+func (r *PIIRedactor) BeforeAgent(ctx context.Context, agent string, state SwarmState) (SwarmState, error) {
+	state.Messages = r.redactMessages(state.Messages)
+	return state, nil
+}
+
+// AfterAgent masks the messages of the agent's output. It implements
+// Middleware.
+func (r *PIIRedactor) AfterAgent(ctx context.Context, agent string, state SwarmState) (SwarmState, error) {
+	state.Messages = r.redactMessages(state.Messages)
+	return state, nil
+}
+
+// BeforeTool returns the arguments of the call unchanged. It implements
+// Middleware.
+func (r *PIIRedactor) BeforeTool(ctx context.Context, agent string, call llms.ToolCall) (string, error) {
+	return call.FunctionCall.Arguments, nil
+}
+
+// AfterTool masks the result of a tool call. It implements Middleware.
+func (r *PIIRedactor) AfterTool(ctx context.Context, agent string, call llms.ToolCall, result string) (string, error) {
+	return r.Redact(result), nil
+}
+
+// redactMessages returns a copy of messages with their text, tool call
+// arguments and tool results masked.
+func (r *PIIRedactor) redactMessages(messages []llms.MessageContent) []llms.MessageContent {
+	if messages == nil {
+		return nil
+	}
+	redacted := make([]llms.MessageContent, len(messages))
+	for i, message := range messages {
+		parts := make([]llms.ContentPart, len(message.Parts))
+		for j, part := range message.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				p.Text = r.Redact(p.Text)
+				parts[j] = p
+			case llms.ToolCall:
+				if p.FunctionCall != nil {
+					function := *p.FunctionCall
+					function.Arguments = r.Redact(function.Arguments)
+					p.FunctionCall = &function
+				}
+				parts[j] = p
+			case llms.ToolCallResponse:
+				p.Content = r.Redact(p.Content)
+				parts[j] = p
+			default:
+				parts[j] = part
+			}
+		}
+		if message.Parts == nil {
+			parts = nil
+		}
+		redacted[i] = llms.MessageContent{Role: message.Role, Parts: parts}
+	}
+	return redacted
+}
+
+// luhnValid reports whether the digits of a number pass the Luhn checksum
+// of payment card numbers.
+func luhnValid(number string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,93 @@
+package swarm
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestPIIRedactorRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"email", "Write to jane.doe+trips@example.co.uk today", "Write to [EMAIL] today"},
+		{"phone", "Call (555) 123-4567 or +1 555.987.6543", "Call [PHONE] or [PHONE]"},
+		{"credit card", "Card 4111 1111 1111 1111, exp 12/27", "Card [CREDIT_CARD], exp 12/27"},
+		{"invalid card number", "Order 4111111111111112", "Order 4111111111111112"},
+		{"nothing", "Book a flight to Paris", "Book a flight to Paris"},
+	}
+	r := &PIIRedactor{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPIIRedactorCustomPattern(t *testing.T) {
+	r := &PIIRedactor{Patterns: append(DefaultPIIPatterns(),
+		PIIPattern{Name: "BOOKING", Regexp: regexp.MustCompile(`\bBK-\d{6}\b`)})}
+	if got := r.Redact("Booking BK-123456 for bob@example.com"); got != "Booking [BOOKING] for [EMAIL]" {
+		t.Errorf("Redact() = %q", got)
+	}
+}
+
+// contactTool returns contact details.
+type contactTool struct{}
+
+func (contactTool) Name() string        { return "contact" }
+func (contactTool) Description() string { return "Look up the customer's contact details" }
+func (contactTool) Call(ctx context.Context, input string) (string, error) {
+	return "contact: bob@example.com", nil
+}
+
+func TestPIIRedactorMiddleware(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "contact", `{"input":"me"}`),
+		{Content: "Sent to jane@example.com"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{contactTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saver := NewMemorySaver()
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       saver,
+		Middlewares:        []Middleware{&PIIRedactor{}},
+	})
+
+	input := SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "My card is 4111-1111-1111-1111"),
+	}}
+	if _, err := app.Invoke(context.Background(), input, WithThreadID("thread-1")); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if got := model.calls[0][0].Parts[0].(llms.TextContent).Text; got != "My card is [CREDIT_CARD]" {
+		t.Errorf("model saw %q", got)
+	}
+	if got := model.calls[1][2].Parts[0].(llms.ToolCallResponse).Content; got != "contact: [EMAIL]" {
+		t.Errorf("model saw tool result %q", got)
+	}
+	if got := input.Messages[0].Parts[0].(llms.TextContent).Text; got != "My card is 4111-1111-1111-1111" {
+		t.Errorf("input modified: %q", got)
+	}
+
+	checkpoint, err := saver.Latest(context.Background(), "thread-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := checkpoint.State.Messages
+	if got := messages[len(messages)-1].Parts[0].(llms.TextContent).Text; got != "Sent to [EMAIL]" {
+		t.Errorf("checkpoint saved %q", got)
+	}
+}
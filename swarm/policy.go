@@ -0,0 +1,100 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// PolicyViolation describes a tool call refused by an agent's tool policy
+// (see Agent.AllowedTools). The model receives it, encoded as JSON, as the
+// result of the call.
+type PolicyViolation struct {
+	// Error is always "policy_violation"
+	Error  string `json:"error"`
+	Agent  string `json:"agent"`
+	Tool   string `json:"tool"`
+	Reason string `json:"reason"`
+}
+
+// toolPolicyKey is the context key for the tool policy of the running agent.
+type toolPolicyKey struct{}
+
+// toolPolicy holds the tools and handoff destinations an agent may use.
+type toolPolicy struct {
+	agent        string
+	allowed      []string
+	destinations []string
+	logger       *slog.Logger
+}
+
+// withToolPolicy returns a context in which tool nodes refuse the tool calls
+// of agent that its AllowedTools and Destinations do not permit. Refusals are
+// logged to logger, if not nil.
+func withToolPolicy(ctx context.Context, agent Agent, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, toolPolicyKey{}, toolPolicy{
+		agent:        agent.Name,
+		allowed:      agent.AllowedTools,
+		destinations: agent.Destinations,
+		logger:       logger,
+	})
+}
+
+// toolPolicyViolation returns the violation of calling the tool named name,
+// implemented by t (nil if unknown), or nil if the running agent may call it.
+// Handoff tools are allowed when they transfer to one of the agent's
+// destinations, or to any agent when it declares none.
+func toolPolicyViolation(ctx context.Context, name string, t tools.Tool) *PolicyViolation {
+	policy, ok := ctx.Value(toolPolicyKey{}).(toolPolicy)
+	if !ok {
+		return nil
+	}
+	var reason string
+	if h, isHandoff := t.(HandoffTool); isHandoff {
+		if len(policy.destinations) == 0 || slices.Contains(policy.destinations, h.HandoffDestination()) {
+			return nil
+		}
+		reason = fmt.Sprintf("agent '%s' may not hand off to '%s'; its destinations are %v",
+			policy.agent, h.HandoffDestination(), policy.destinations)
+	} else {
+		if slices.Contains(policy.allowed, name) {
+			return nil
+		}
+		reason = fmt.Sprintf("agent '%s' is not allowed to call tool '%s'", policy.agent, name)
+	}
+	return &PolicyViolation{Error: "policy_violation", Agent: policy.agent, Tool: name, Reason: reason}
+}
+
+// refuseToolCall reports a refused tool call to the logger and event bus of
+// ctx and returns the tool result telling the model about the violation.
+func refuseToolCall(ctx context.Context, call llms.ToolCall, violation *PolicyViolation) string {
+	policy, _ := ctx.Value(toolPolicyKey{}).(toolPolicy)
+	if policy.logger != nil {
+		policy.logger.LogAttrs(ctx, slog.LevelWarn, "tool call refused",
+			slog.String("agent", violation.Agent),
+			slog.String("tool", violation.Tool),
+			slog.String("tool_call_id", call.ID),
+			slog.String("reason", violation.Reason))
+	}
+	if bus := events.BusFromContext(ctx); bus != nil {
+		bus.Publish(ctx, events.PolicyViolated{
+			Time:       time.Now(),
+			Agent:      violation.Agent,
+			Tool:       violation.Tool,
+			ToolCallID: call.ID,
+			Reason:     violation.Reason,
+		})
+	}
+	content, err := json.Marshal(violation)
+	if err != nil {
+		return "Error: " + violation.Reason
+	}
+	return string(content)
+}
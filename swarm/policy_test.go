@@ -0,0 +1,99 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestAllowedTools(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "contact", `{"input":"me"}`),
+		toolCallChoice("call_2", "upper", `{"input":"hi"}`),
+		{Content: "HI"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{upperTool{}, contactTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	bus := events.NewBus()
+	var violations []events.PolicyViolated
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		violations = append(violations, e.(events.PolicyViolated))
+	}, events.TypePolicyViolated)
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice, AllowedTools: []string{"upper"}}},
+		DefaultActiveAgent: "Alice",
+		Logger:             slog.New(slog.NewTextHandler(&logs, nil)),
+		Events:             bus,
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	var violation PolicyViolation
+	refused := result.Messages[2].Parts[0].(llms.ToolCallResponse)
+	if err := json.Unmarshal([]byte(refused.Content), &violation); err != nil {
+		t.Fatalf("tool result %q is not a PolicyViolation: %v", refused.Content, err)
+	}
+	if violation.Error != "policy_violation" || violation.Agent != "Alice" || violation.Tool != "contact" {
+		t.Errorf("violation = %+v", violation)
+	}
+	if allowed := result.Messages[4].Parts[0].(llms.ToolCallResponse); allowed.Content != "HI" {
+		t.Errorf("allowed tool result = %q", allowed.Content)
+	}
+	if !strings.Contains(logs.String(), `level=WARN msg="tool call refused" agent=Alice tool=contact tool_call_id=call_1`) {
+		t.Errorf("logs = %s", logs.String())
+	}
+	if len(violations) != 1 || violations[0].ToolCallID != "call_1" {
+		t.Errorf("PolicyViolated events = %+v", violations)
+	}
+}
+
+func TestAllowedToolsRefusesHandoffOutsideDestinations(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_carol", `{}`),
+		{Content: "I can't reach Carol"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"}),
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Carol"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice, Destinations: []string{"Bob"}, AllowedTools: []string{}},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Hi from Bob")},
+			{Name: "Carol", Runnable: createMockAgent("Carol", "Hi from Carol")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Talk to Carol")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent == "Carol" || len(result.Handoffs) != 0 {
+		t.Errorf("active agent = %q, handoffs = %v; want no handoff", result.ActiveAgent, result.Handoffs)
+	}
+	refused := result.Messages[2].Parts[0].(llms.ToolCallResponse)
+	if !strings.Contains(refused.Content, `"reason":"agent 'Alice' may not hand off to 'Carol'`) {
+		t.Errorf("tool result = %q", refused.Content)
+	}
+}
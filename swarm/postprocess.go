@@ -0,0 +1,63 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// PostProcessFunc rewrites the state after a model call of a prebuilt agent
+// (see Agent.PostProcess). The state ends with the AI message built from
+// response; the returned state replaces it, so the function can edit or
+// replace that message, e.g. to strip reasoning, enforce a format or append
+// citations. Tool calls left in the last message are executed as usual.
+type PostProcessFunc func(ctx context.Context, state SwarmState, response *llms.ContentChoice) (SwarmState, error)
+
+// StripTags returns a PostProcessFunc removing the elements with the given
+// tag names, such as the <think>...</think> reasoning of some models, from
+// the text of the agent's answers.
+//
+// Example:
+//
+//	swarm.Agent{Name: "Alice", Runnable: alice, PostProcess: swarm.StripTags("think")}
+func StripTags(tags ...string) PostProcessFunc {
+	patterns := make([]*regexp.Regexp, len(tags))
+	for i, tag := range tags {
+		name := regexp.QuoteMeta(tag)
+		patterns[i] = regexp.MustCompile(`(?s)<` + name + `(\s[^>]*)?>.*?</` + name + `>\s*`)
+	}
+	return func(ctx context.Context, state SwarmState, response *llms.ContentChoice) (SwarmState, error) {
+		last := &state.Messages[len(state.Messages)-1]
+		parts := make([]llms.ContentPart, 0, len(last.Parts))
+		for _, part := range last.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				for _, pattern := range patterns {
+					text.Text = pattern.ReplaceAllString(text.Text, "")
+				}
+				if text.Text == "" {
+					continue
+				}
+				part = text
+			}
+			parts = append(parts, part)
+		}
+		last.Parts = parts
+		return state, nil
+	}
+}
+
+// postProcess applies the running agent's post-processing, if any, to the
+// state after a model call.
+func postProcess(ctx context.Context, state SwarmState, response *llms.ContentChoice) (SwarmState, error) {
+	settings, ok := ctx.Value(agentModelKey{}).(agentModel)
+	if !ok || settings.postProcess == nil {
+		return state, nil
+	}
+	processed, err := settings.postProcess(ctx, state, response)
+	if err != nil {
+		return state, fmt.Errorf("post-process: %w", err)
+	}
+	return processed, nil
+}
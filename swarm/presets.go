@@ -0,0 +1,229 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// defaultDebateRounds is the number of proposals a debate makes before the
+// judge decides, unless set with WithDebateRounds.
+const defaultDebateRounds = 1
+
+// NewTriage returns the configuration of a swarm in which a triage agent
+// hands each conversation to the specialist that can help. The triage agent
+// starts conversations and is given a handoff tool for each specialist it
+// cannot already transfer to, described with the specialist's Description;
+// each specialist is given a tool handing back to the triage agent for
+// requests outside its expertise. A conversation stays with the agent it
+// was handed off to. Agents that declare Destinations keep them.
+//
+// The tools are added to the agents' Tools, so the triage agent and the
+// specialists should be prebuilt agents such as those of CreateReactAgent.
+// Set other fields, such as Checkpointer, on the configuration before
+// passing it to CreateSwarm.
+//
+// Example:
+//
+//	config := swarm.NewTriage(
+//	    swarm.Agent{Name: "Triage", Runnable: triage},
+//	    swarm.Agent{Name: "Billing", Runnable: billing, Description: "Invoices, refunds and payment methods"},
+//	    swarm.Agent{Name: "Technical", Runnable: technical, Description: "Errors, outages and how-to questions"},
+//	)
+//	config.Checkpointer = swarm.NewMemorySaver()
+//	workflow, err := swarm.CreateSwarm(config)
+func NewTriage(triage Agent, specialists ...Agent) SwarmConfig {
+	names := make([]string, len(specialists))
+	agents := make([]Agent, 0, len(specialists)+1)
+	for i, specialist := range specialists {
+		names[i] = specialist.Name
+		if !handsOffTo(specialist, triage.Name) {
+			specialist.Tools = append(slices.Clip(specialist.Tools), CreateHandoffTool(HandoffToolConfig{
+				AgentName:   triage.Name,
+				Description: fmt.Sprintf("Transfer back to '%s' when the request is outside your expertise", triage.Name),
+			}))
+		}
+		if len(specialist.Destinations) == 0 {
+			specialist.Destinations = []string{triage.Name}
+		}
+		agents = append(agents, specialist)
+	}
+
+	for _, specialist := range specialists {
+		if handsOffTo(triage, specialist.Name) {
+			continue
+		}
+		config := HandoffToolConfig{AgentName: specialist.Name}
+		if specialist.Description != "" {
+			config.Description = fmt.Sprintf("Transfer to '%s': %s", specialist.Name, specialist.Description)
+		}
+		triage.Tools = append(slices.Clip(triage.Tools), CreateHandoffTool(config))
+	}
+	if len(triage.Destinations) == 0 {
+		triage.Destinations = names
+	}
+
+	return SwarmConfig{
+		Agents:             append([]Agent{triage}, agents...),
+		DefaultActiveAgent: triage.Name,
+	}
+}
+
+// NewPipeline returns the configuration of a swarm running its stages in
+// order on every turn. Each stage sees the messages of the stages before it
+// and hands off to the next one when it is done, whatever its own tools do;
+// the turn ends with the last stage's answer.
+//
+// Set other fields, such as Checkpointer, on the configuration before
+// passing it to CreateSwarm.
+//
+// Example:
+//
+//	config := swarm.NewPipeline(
+//	    swarm.Agent{Name: "Researcher", Runnable: researcher},
+//	    swarm.Agent{Name: "Writer", Runnable: writer},
+//	    swarm.Agent{Name: "Editor", Runnable: editor},
+//	)
+func NewPipeline(stages ...Agent) SwarmConfig {
+	if len(stages) == 0 {
+		return SwarmConfig{}
+	}
+	agents := make([]Agent, len(stages))
+	for i, stage := range stages {
+		if i < len(stages)-1 {
+			next := stages[i+1].Name
+			stage.Runnable = handOffAfter(stage.Runnable, func(SwarmState) string { return next })
+			stage.Destinations = []string{next}
+		}
+		agents[i] = stage
+	}
+	return SwarmConfig{
+		Agents:             agents,
+		DefaultActiveAgent: stages[0].Name,
+		Router:             startWith(stages[0].Name),
+	}
+}
+
+// DebateOption configures a debate created by NewDebate.
+type DebateOption func(*debateOptions)
+
+// debateOptions holds the settings of a debate.
+type debateOptions struct {
+	rounds int
+}
+
+// WithDebateRounds sets the number of proposals made before the judge
+// decides (default: 1). Each proposal after the first revises the previous
+// one in the light of the critic's review.
+func WithDebateRounds(rounds int) DebateOption {
+	return func(o *debateOptions) {
+		o.rounds = rounds
+	}
+}
+
+// NewDebate returns the configuration of a swarm in which, on every turn, a
+// proposer answers, a critic reviews the answer and a judge, having heard
+// both, gives the final answer. With WithDebateRounds, the proposer revises
+// its answer after each review until the rounds are over.
+//
+// Set other fields, such as Checkpointer, on the configuration before
+// passing it to CreateSwarm.
+//
+// Example:
+//
+//	config := swarm.NewDebate(
+//	    swarm.Agent{Name: "Proposer", Runnable: proposer},
+//	    swarm.Agent{Name: "Critic", Runnable: critic},
+//	    swarm.Agent{Name: "Judge", Runnable: judge},
+//	    swarm.WithDebateRounds(2),
+//	)
+func NewDebate(proposer, critic, judge Agent, opts ...DebateOption) SwarmConfig {
+	options := debateOptions{rounds: defaultDebateRounds}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	proposer.Runnable = handOffAfter(proposer.Runnable, func(SwarmState) string { return critic.Name })
+	proposer.Destinations = []string{critic.Name}
+	critic.Runnable = handOffAfter(critic.Runnable, func(state SwarmState) string {
+		if debateRounds(state, proposer.Name, critic.Name, judge.Name) < options.rounds {
+			return proposer.Name
+		}
+		return judge.Name
+	})
+	critic.Destinations = []string{proposer.Name, judge.Name}
+
+	return SwarmConfig{
+		Agents:             []Agent{proposer, critic, judge},
+		DefaultActiveAgent: proposer.Name,
+		Router:             startWith(proposer.Name),
+	}
+}
+
+// debateRounds returns the number of proposals of the debate in progress:
+// one, plus one per review sent back to the proposer since the judge last
+// decided.
+func debateRounds(state SwarmState, proposer, critic, judge string) int {
+	rounds := 1
+	for i := len(state.Handoffs) - 1; i >= 0 && state.Handoffs[i].To != judge; i-- {
+		if h := state.Handoffs[i]; h.From == critic && h.To == proposer {
+			rounds++
+		}
+	}
+	return rounds
+}
+
+// presetRunnable is the runnable of a preset agent that hands off to the
+// agent chosen by next once its own runnable is done.
+type presetRunnable struct {
+	runnable AgentRunnable
+	next     func(state SwarmState) string
+}
+
+// handOffAfter returns a runnable running runnable and then handing off to
+// the agent chosen by next.
+func handOffAfter(runnable AgentRunnable, next func(state SwarmState) string) AgentRunnable {
+	return presetRunnable{runnable: runnable, next: next}
+}
+
+// InvokeCommand runs the wrapped runnable and hands off to the next agent.
+func (r presetRunnable) InvokeCommand(ctx context.Context, state SwarmState) (*graph.Command, error) {
+	result, err := invokeRunnable(ctx, r.runnable, state)
+	if err != nil {
+		return nil, err
+	}
+	return &graph.Command{Update: result, Goto: r.next(result)}, nil
+}
+
+// Invoke runs the wrapped runnable and hands off to the next agent.
+func (r presetRunnable) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return invokeCommand(ctx, r, state)
+}
+
+// startRouter is a Router starting every turn with the agent it names.
+type startRouter string
+
+// startWith returns a Router starting every turn with agent.
+func startWith(agent string) Router {
+	return startRouter(agent)
+}
+
+// Route returns the agent of r.
+func (r startRouter) Route(ctx context.Context, state SwarmState) (string, error) {
+	return string(r), nil
+}
+
+// handsOffTo reports whether the tools of agent, its own or its Tools,
+// already include a handoff to target.
+func handsOffTo(agent Agent, target string) bool {
+	if provider, ok := agent.Runnable.(HandoffDestinationsProvider); ok && slices.Contains(provider.HandoffDestinations(), target) {
+		return true
+	}
+	return slices.ContainsFunc(agent.Tools, func(t tools.Tool) bool {
+		h, ok := t.(HandoffTool)
+		return ok && h.HandoffDestination() == target
+	})
+}
@@ -0,0 +1,115 @@
+package swarm
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestNewTriage(t *testing.T) {
+	triageModel := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_billing", `{"task_description":"Refund order 42"}`),
+	}}
+	triage, err := CreateReactAgent(triageModel, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	billingModel := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Refunded"}}}
+	billing, err := CreateReactAgent(billingModel, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := NewTriage(
+		Agent{Name: "Triage", Runnable: triage},
+		Agent{Name: "Billing", Runnable: billing, Description: "Invoices and refunds"},
+		Agent{Name: "Technical", Runnable: createMockAgent("Technical", "Have you tried turning it off and on?")},
+	)
+	if config.DefaultActiveAgent != "Triage" || !slices.Equal(config.Agents[0].Destinations, []string{"Billing", "Technical"}) {
+		t.Fatalf("config = %+v", config)
+	}
+	app := compileSwarm(t, config)
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "I want a refund"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "Billing" || lastText(result) != "Refunded" {
+		t.Errorf("active agent = %s, answer = %q", result.ActiveAgent, lastText(result))
+	}
+
+	var triageTools, billingTools []string
+	for _, tool := range triageModel.options[0].Tools {
+		triageTools = append(triageTools, tool.Function.Name)
+		if tool.Function.Name == "transfer_to_billing" && tool.Function.Description != "Transfer to 'Billing': Invoices and refunds" {
+			t.Errorf("description = %q", tool.Function.Description)
+		}
+	}
+	for _, tool := range billingModel.options[0].Tools {
+		billingTools = append(billingTools, tool.Function.Name)
+	}
+	if !slices.Equal(triageTools, []string{"transfer_to_billing", "transfer_to_technical"}) || !slices.Equal(billingTools, []string{"transfer_to_triage"}) {
+		t.Errorf("tools = %v for the triage agent, %v for the specialist", triageTools, billingTools)
+	}
+}
+
+func TestNewPipeline(t *testing.T) {
+	app := compileSwarm(t, NewPipeline(
+		Agent{Name: "Researcher", Runnable: createMockAgent("Researcher", "Facts")},
+		Agent{Name: "Writer", Runnable: createMockAgent("Writer", "Draft")},
+		Agent{Name: "Editor", Runnable: createMockAgent("Editor", "Final")},
+	))
+
+	state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Write about Go")}}
+	for turn := range 2 {
+		result, err := app.Invoke(context.Background(), state)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		added := result.Messages[len(state.Messages):]
+		if len(added) != 3 || lastText(result) != "Final" {
+			t.Fatalf("turn %d added %+v", turn, added)
+		}
+		if result.ActiveAgent != "Editor" || len(result.Handoffs) != 2*(turn+1) {
+			t.Errorf("turn %d: active agent = %s, handoffs = %+v", turn, result.ActiveAgent, result.Handoffs)
+		}
+		state = result
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Again"))
+	}
+}
+
+func TestNewDebate(t *testing.T) {
+	for _, rounds := range []int{1, 2} {
+		app := compileSwarm(t, NewDebate(
+			Agent{Name: "Proposer", Runnable: createMockAgent("Proposer", "Use Go")},
+			Agent{Name: "Critic", Runnable: createMockAgent("Critic", "Why?")},
+			Agent{Name: "Judge", Runnable: createMockAgent("Judge", "Go it is")},
+			WithDebateRounds(rounds),
+		))
+
+		state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Which language?")}}
+		for turn := range 2 {
+			result, err := app.Invoke(context.Background(), state)
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			var path []string
+			for _, h := range result.Handoffs[len(state.Handoffs):] {
+				path = append(path, h.To)
+			}
+			want := []string{"Critic"}
+			for range rounds - 1 {
+				want = append(want, "Proposer", "Critic")
+			}
+			want = append(want, "Judge")
+			if !slices.Equal(path, want) || lastText(result) != "Go it is" {
+				t.Errorf("rounds %d, turn %d: path = %v, answer = %q", rounds, turn, path, lastText(result))
+			}
+			state = result
+			state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "And now?"))
+		}
+	}
+}
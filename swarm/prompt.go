@@ -0,0 +1,169 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// PromptFunc builds an agent's system prompt for a run from the state the
+// agent is invoked with. An empty prompt adds no system message.
+type PromptFunc func(ctx context.Context, state SwarmState) (string, error)
+
+// PromptOption configures a prompt template created by PromptTemplate.
+type PromptOption func(*promptOptions)
+
+// promptOptions holds the settings applied by PromptOption values.
+type promptOptions struct {
+	data  []func(ctx context.Context, state SwarmState) (map[string]any, error)
+	funcs template.FuncMap
+}
+
+// WithPromptData adds fields computed on every run to the template data,
+// such as records looked up for the current user. Later sources override
+// earlier ones.
+func WithPromptData(fn func(ctx context.Context, state SwarmState) (map[string]any, error)) PromptOption {
+	return func(o *promptOptions) {
+		o.data = append(o.data, fn)
+	}
+}
+
+// WithPromptFuncs makes functions available to the template.
+func WithPromptFuncs(funcs template.FuncMap) PromptOption {
+	return func(o *promptOptions) {
+		if o.funcs == nil {
+			o.funcs = template.FuncMap{}
+		}
+		for name, fn := range funcs {
+			o.funcs[name] = fn
+		}
+	}
+}
+
+// PromptTemplate parses a text/template and returns a PromptFunc that
+// renders it on every run, for use as Agent.Prompt.
+//
+// The template data holds:
+//   - Today: the current date as YYYY-MM-DD
+//   - Now: the current time.Time
+//   - ActiveAgent: the agent being run
+//   - State: the SwarmState the agent is invoked with
+//   - every entry of SwarmState.Values, by key
+//   - the fields added with WithPromptData
+//
+// Later entries in the list override earlier ones. Referring to a field that
+// is not present fails the run.
+//
+// Example:
+//
+//	prompt, err := swarm.PromptTemplate(
+//	    "You are a flight assistant. Current reservation: {{.Reservation}} Today is {{.Today}}",
+//	    swarm.WithPromptData(func(ctx context.Context, state swarm.SwarmState) (map[string]any, error) {
+//	        return map[string]any{"Reservation": reservations.Lookup(ctx)}, nil
+//	    }),
+//	)
+func PromptTemplate(text string, opts ...PromptOption) (PromptFunc, error) {
+	var options promptOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	tmpl, err := template.New("prompt").Option("missingkey=error").Funcs(options.funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt template: %w", err)
+	}
+
+	return func(ctx context.Context, state SwarmState) (string, error) {
+		now := time.Now()
+		data := map[string]any{
+			"Today":       now.Format(time.DateOnly),
+			"Now":         now,
+			"ActiveAgent": AgentNameFromContext(ctx),
+			"State":       state,
+		}
+		for key, value := range state.Values {
+			data[key] = value
+		}
+		for _, fn := range options.data {
+			extra, err := fn(ctx, state)
+			if err != nil {
+				return "", err
+			}
+			for key, value := range extra {
+				data[key] = value
+			}
+		}
+
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("render prompt template: %w", err)
+		}
+		return b.String(), nil
+	}, nil
+}
+
+// MustPromptTemplate is like PromptTemplate but panics if the template
+// cannot be parsed.
+func MustPromptTemplate(text string, opts ...PromptOption) PromptFunc {
+	prompt, err := PromptTemplate(text, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return prompt
+}
+
+// systemPrompt returns the system message to prepend for agent, if any.
+// Agent.Prompt takes precedence over Agent.SystemPrompt.
+func systemPrompt(ctx context.Context, agent Agent, state SwarmState) (*llms.MessageContent, error) {
+	text := agent.SystemPrompt
+	if agent.Prompt != nil {
+		var err error
+		if text, err = agent.Prompt(ctx, state); err != nil {
+			return nil, fmt.Errorf("system prompt: %w", err)
+		}
+	}
+	if text == "" {
+		return nil, nil
+	}
+	msg := llms.TextParts(llms.ChatMessageTypeSystem, text)
+	return &msg, nil
+}
+
+// invokeWithPrompt invokes the agent's runnable with its system prompt
+// prepended to the conversation. The prompt is removed from the result
+// (including the state of an interrupted run) so that it never enters the
+// shared history.
+func invokeWithPrompt(ctx context.Context, agent Agent, state SwarmState) (SwarmState, error) {
+	prompt, err := systemPrompt(ctx, agent, state)
+	if err != nil {
+		return state, err
+	}
+	if prompt == nil {
+		return invokeRunnable(ctx, agent.Runnable, state)
+	}
+
+	input := state
+	input.Messages = append([]llms.MessageContent{*prompt}, state.Messages...)
+	result, err := invokeRunnable(ctx, agent.Runnable, input)
+	result.Messages = withoutPrompt(result.Messages, *prompt)
+
+	var interrupt *InterruptError
+	if errors.As(err, &interrupt) {
+		interrupt.State.Messages = withoutPrompt(interrupt.State.Messages, *prompt)
+	}
+	return result, err
+}
+
+// withoutPrompt removes the leading system prompt from messages.
+func withoutPrompt(messages []llms.MessageContent, prompt llms.MessageContent) []llms.MessageContent {
+	if len(messages) > 0 && reflect.DeepEqual(messages[0], prompt) {
+		return messages[1:]
+	}
+	return messages
+}
@@ -0,0 +1,126 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// promptRecorder is an agent that records the messages it was invoked with
+// and answers.
+func promptRecorder(seen *[]llms.MessageContent) invokerFunc {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		*seen = state.Messages
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Hi"))
+		return state, nil
+	}
+}
+
+func TestAgentSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name  string
+		agent Agent
+		want  string
+	}{
+		{name: "static", agent: Agent{SystemPrompt: "You are Alice."}, want: "You are Alice."},
+		{
+			name: "func",
+			agent: Agent{
+				SystemPrompt: "ignored",
+				Prompt: func(ctx context.Context, state SwarmState) (string, error) {
+					return fmt.Sprintf("You are Alice. %d messages so far.", len(state.Messages)), nil
+				},
+			},
+			want: "You are Alice. 1 messages so far.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []llms.MessageContent
+			agent := tt.agent
+			agent.Name = "Alice"
+			agent.Runnable = promptRecorder(&seen)
+
+			app := compileSwarm(t, SwarmConfig{Agents: []Agent{agent}, DefaultActiveAgent: "Alice"})
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+			})
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+
+			if len(seen) != 2 || seen[0].Role != llms.ChatMessageTypeSystem {
+				t.Fatalf("Agent saw %+v, want the system prompt first", seen)
+			}
+			if text := seen[0].Parts[0].(llms.TextContent).Text; text != tt.want {
+				t.Errorf("System prompt = %q, want %q", text, tt.want)
+			}
+
+			if len(result.Messages) != 2 {
+				t.Fatalf("Got %d messages, want 2", len(result.Messages))
+			}
+			for _, msg := range result.Messages {
+				if msg.Role == llms.ChatMessageTypeSystem {
+					t.Error("The system prompt leaked into the shared history")
+				}
+			}
+		})
+	}
+}
+
+func TestAgentPromptError(t *testing.T) {
+	errPrompt := errors.New("no reservation")
+	var seen []llms.MessageContent
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:     "Alice",
+			Runnable: promptRecorder(&seen),
+			Prompt: func(ctx context.Context, state SwarmState) (string, error) {
+				return "", errPrompt
+			},
+		}},
+		DefaultActiveAgent: "Alice",
+	})
+
+	if _, err := app.Invoke(context.Background(), SwarmState{}); !errors.Is(err, errPrompt) {
+		t.Errorf("Invoke() error = %v, want %v", err, errPrompt)
+	}
+	if seen != nil {
+		t.Error("The agent should not run when its prompt fails")
+	}
+}
+
+func TestPromptTemplate(t *testing.T) {
+	prompt, err := PromptTemplate(
+		"You are {{.ActiveAgent}}. Reservation: {{.Reservation}}. Today is {{.Today}}. {{shout .Locale}}",
+		WithPromptData(func(ctx context.Context, state SwarmState) (map[string]any, error) {
+			return map[string]any{"Reservation": "BOS-JFK"}, nil
+		}),
+		WithPromptFuncs(map[string]any{"shout": strings.ToUpper}),
+	)
+	if err != nil {
+		t.Fatalf("PromptTemplate() error = %v", err)
+	}
+
+	state := SwarmState{Values: map[string]any{"Today": "2025-01-02", "Locale": "en", "Reservation": "stale"}}
+	got, err := prompt(withAgentName(context.Background(), "flight_assistant"), state)
+	if err != nil {
+		t.Fatalf("prompt() error = %v", err)
+	}
+	want := "You are flight_assistant. Reservation: BOS-JFK. Today is 2025-01-02. EN"
+	if got != want {
+		t.Errorf("prompt() = %q, want %q", got, want)
+	}
+
+	if _, err := prompt(context.Background(), SwarmState{}); err == nil {
+		t.Error("Expected an error for a missing template field")
+	}
+	if _, err := PromptTemplate("{{.Unclosed"); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
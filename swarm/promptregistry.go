@@ -0,0 +1,171 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+)
+
+// ErrUnknownPromptVersion is matched (with errors.Is) by the errors of a
+// PromptRegistry about a prompt version it does not have.
+var ErrUnknownPromptVersion = errors.New("unknown prompt version")
+
+// PromptRegistry holds versions of the agents' system prompts, for
+// SwarmConfig.Prompts. Each agent runs the version pinned with Pin, the
+// first one registered by default, unless an experiment flag of the run
+// (see WithExperimentFlags) selects another one. The version an agent runs
+// is recorded as the PromptVersion of its TraceSteps, and the flags of the
+// run as Trace.Experiments, so that the analytics package can compare
+// versions.
+//
+// The prompts of the registry take precedence over Agent.Prompt and
+// Agent.SystemPrompt. A PromptRegistry is safe for concurrent use; versions
+// can be pinned while the swarm runs.
+//
+// Example:
+//
+//	prompts := swarm.NewPromptRegistry()
+//	prompts.RegisterText("Alice", "v1", "You are Alice, an addition expert.")
+//	prompts.RegisterText("Alice", "v2", "You are Alice. Answer with the sum only.")
+//	prompts.AddExperiment("terse-alice", "Alice", "v2")
+//
+//	flag := swarm.AssignVariant(userID, "", "terse-alice") // 50/50 split
+//	result, err := app.Invoke(ctx, state, swarm.WithExperimentFlags(flag))
+type PromptRegistry struct {
+	mu sync.RWMutex
+	// prompts holds the versions of each agent's prompt
+	prompts map[string]map[string]PromptFunc
+	// pinned is the version each agent runs by default
+	pinned map[string]string
+	// experiments maps experiment flags to the versions they select, by agent
+	experiments map[string]map[string]string
+}
+
+// NewPromptRegistry returns an empty PromptRegistry.
+func NewPromptRegistry() *PromptRegistry {
+	return &PromptRegistry{
+		prompts:     make(map[string]map[string]PromptFunc),
+		pinned:      make(map[string]string),
+		experiments: make(map[string]map[string]string),
+	}
+}
+
+// Register adds version of agent's prompt, replacing any prompt registered
+// under the same version. The first version registered for an agent is
+// pinned.
+func (r *PromptRegistry) Register(agent, version string, prompt PromptFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.prompts[agent] == nil {
+		r.prompts[agent] = make(map[string]PromptFunc)
+		r.pinned[agent] = version
+	}
+	r.prompts[agent][version] = prompt
+}
+
+// RegisterText adds version of agent's prompt as a fixed text.
+func (r *PromptRegistry) RegisterText(agent, version, text string) {
+	r.Register(agent, version, func(ctx context.Context, state SwarmState) (string, error) {
+		return text, nil
+	})
+}
+
+// Pin makes version the prompt agent runs outside of experiments.
+func (r *PromptRegistry) Pin(agent, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.prompts[agent][version]; !ok {
+		return fmt.Errorf("pin agent '%s': %w '%s'", agent, ErrUnknownPromptVersion, version)
+	}
+	r.pinned[agent] = version
+	return nil
+}
+
+// Pinned returns the version agent runs outside of experiments, if it has
+// any prompt.
+func (r *PromptRegistry) Pinned(agent string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	version, ok := r.pinned[agent]
+	return version, ok
+}
+
+// Versions returns the versions of agent's prompt, sorted.
+func (r *PromptRegistry) Versions(agent string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]string, 0, len(r.prompts[agent]))
+	for version := range r.prompts[agent] {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// AddExperiment makes the runs with the experiment flag run version of
+// agent's prompt. A flag can select versions of several agents' prompts.
+func (r *PromptRegistry) AddExperiment(flag, agent, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.prompts[agent][version]; !ok {
+		return fmt.Errorf("experiment '%s': agent '%s': %w '%s'", flag, agent, ErrUnknownPromptVersion, version)
+	}
+	if r.experiments[flag] == nil {
+		r.experiments[flag] = make(map[string]string)
+	}
+	r.experiments[flag][agent] = version
+	return nil
+}
+
+// prompt returns the prompt agent runs with the experiment flags of ctx's
+// run, and its version. The last flag selecting a version of the agent's
+// prompt wins. It reports false if r has no prompt for agent.
+func (r *PromptRegistry) prompt(ctx context.Context, agent string) (PromptFunc, string, bool) {
+	if r == nil {
+		return nil, "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	version, ok := r.pinned[agent]
+	if !ok {
+		return nil, "", false
+	}
+	if config, ok := RunConfigFromContext(ctx); ok {
+		for _, flag := range config.ExperimentFlags {
+			if v, ok := r.experiments[flag][agent]; ok {
+				version = v
+			}
+		}
+	}
+	return r.prompts[agent][version], version, true
+}
+
+// WithExperimentFlags sets the experiment flags of the run, which select
+// the prompt versions of SwarmConfig.Prompts registered with AddExperiment.
+// The flags are recorded as Trace.Experiments. Empty flags are ignored, so
+// that the control group of AssignVariant can be passed as it is.
+func WithExperimentFlags(flags ...string) InvokeOption {
+	return func(c *RunConfig) {
+		for _, flag := range flags {
+			if flag != "" && !slices.Contains(c.ExperimentFlags, flag) {
+				c.ExperimentFlags = append(c.ExperimentFlags, flag)
+			}
+		}
+	}
+}
+
+// AssignVariant splits traffic evenly among variants: it returns the
+// variant of key, such as a user or thread ID, which is the same on every
+// call so that a conversation keeps its variant. It returns "" when there
+// are no variants.
+func AssignVariant(key string, variants ...string) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return variants[h.Sum32()%uint32(len(variants))]
+}
@@ -0,0 +1,84 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestPromptRegistry(t *testing.T) {
+	prompts := NewPromptRegistry()
+	prompts.RegisterText("Alice", "v1", "You are Alice.")
+	prompts.RegisterText("Alice", "v2", "You are Alice. Be terse.")
+	if err := prompts.AddExperiment("terse", "Alice", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := prompts.AddExperiment("verbose", "Alice", "v3"); !errors.Is(err, ErrUnknownPromptVersion) {
+		t.Errorf("AddExperiment() error = %v, want ErrUnknownPromptVersion", err)
+	}
+
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hi"}, {Content: "Hi"}, {Content: "Hi"}}}
+	alice, err := CreateReactAgent(model, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice, SystemPrompt: "Overridden"}},
+		DefaultActiveAgent: "Alice",
+		Prompts:            prompts,
+	})
+	run := func(opts ...InvokeOption) *Trace {
+		t.Helper()
+		result, err := app.InvokeWithResult(context.Background(), SwarmState{Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+		}}, opts...)
+		if err != nil {
+			t.Fatalf("InvokeWithResult() error = %v", err)
+		}
+		return result.Trace()
+	}
+	systemPrompt := func(call int) string {
+		return model.calls[call][0].Parts[0].(llms.TextContent).Text
+	}
+
+	if trace := run(); trace.Steps[0].PromptVersion != "v1" || trace.Experiments != nil || systemPrompt(0) != "You are Alice." {
+		t.Errorf("pinned run: version %q, experiments %v, prompt %q", trace.Steps[0].PromptVersion, trace.Experiments, systemPrompt(0))
+	}
+	trace := run(WithExperimentFlags("terse", ""))
+	if trace.Steps[0].PromptVersion != "v2" || !slices.Equal(trace.Experiments, []string{"terse"}) || systemPrompt(1) != "You are Alice. Be terse." {
+		t.Errorf("experiment run: version %q, experiments %v, prompt %q", trace.Steps[0].PromptVersion, trace.Experiments, systemPrompt(1))
+	}
+
+	if err := prompts.Pin("Alice", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if trace := run(); trace.Steps[0].PromptVersion != "v2" {
+		t.Errorf("PromptVersion after Pin = %q, want v2", trace.Steps[0].PromptVersion)
+	}
+	if err := prompts.Pin("Alice", "v9"); !errors.Is(err, ErrUnknownPromptVersion) {
+		t.Errorf("Pin() error = %v, want ErrUnknownPromptVersion", err)
+	}
+	if versions := prompts.Versions("Alice"); !slices.Equal(versions, []string{"v1", "v2"}) {
+		t.Errorf("Versions() = %v", versions)
+	}
+}
+
+func TestAssignVariant(t *testing.T) {
+	if AssignVariant("user-1") != "" {
+		t.Error("AssignVariant() without variants is not empty")
+	}
+	seen := make(map[string]bool)
+	for _, user := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		variant := AssignVariant(user, "control", "treatment")
+		if AssignVariant(user, "control", "treatment") != variant {
+			t.Errorf("AssignVariant(%q) is not stable", user)
+		}
+		seen[variant] = true
+	}
+	if !seen["control"] || !seen["treatment"] {
+		t.Errorf("variants assigned = %v, want both", seen)
+	}
+}
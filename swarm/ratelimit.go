@@ -0,0 +1,126 @@
+package swarm
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter throttles the model calls of agents, e.g. to stay below a
+// provider's rate limits. Agents built by CreateReactAgent acquire it around
+// every GenerateContent call when it is set in SwarmConfig.RateLimiter.
+type RateLimiter interface {
+	// Acquire blocks until agent may call its model, or ctx is done. The
+	// returned release function is called when the model call returns.
+	Acquire(ctx context.Context, agent string) (release func(), err error)
+}
+
+// RateLimit configures a RateLimiter created by NewRateLimiter.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate of model calls (0: unlimited)
+	RequestsPerSecond float64
+	// Burst is the number of calls that may be made at once before the rate
+	// applies (default: 1)
+	Burst int
+	// MaxConcurrent caps the number of model calls in flight (0: no cap)
+	MaxConcurrent int
+	// PerAgent gives every agent its own budget instead of one shared by all
+	// agents
+	PerAgent bool
+}
+
+// NewRateLimiter returns a token-bucket RateLimiter with an optional cap on
+// concurrent calls.
+//
+// Example:
+//
+//	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents:      agents,
+//	    RateLimiter: swarm.NewRateLimiter(swarm.RateLimit{RequestsPerSecond: 5, Burst: 10}),
+//	})
+func NewRateLimiter(limit RateLimit) RateLimiter {
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+	return &rateLimiter{limit: limit, buckets: make(map[string]*bucket)}
+}
+
+// rateLimiter implements RateLimiter with a token bucket and a semaphore
+// per budget.
+type rateLimiter struct {
+	limit   RateLimit
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket is the budget of one agent, or of all agents.
+type bucket struct {
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+// bucketFor returns the budget used by agent.
+func (r *rateLimiter) bucketFor(agent string) *bucket {
+	if !r.limit.PerAgent {
+		agent = ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.buckets[agent]
+	if !ok {
+		b = &bucket{}
+		if r.limit.RequestsPerSecond > 0 {
+			b.limiter = rate.NewLimiter(rate.Limit(r.limit.RequestsPerSecond), r.limit.Burst)
+		}
+		if r.limit.MaxConcurrent > 0 {
+			b.slots = make(chan struct{}, r.limit.MaxConcurrent)
+		}
+		r.buckets[agent] = b
+	}
+	return b
+}
+
+// Acquire waits for a token and a free slot in agent's budget.
+func (r *rateLimiter) Acquire(ctx context.Context, agent string) (func(), error) {
+	b := r.bucketFor(agent)
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if b.slots != nil {
+			<-b.slots
+		}
+	}
+
+	if b.limiter != nil {
+		if err := b.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// rateLimiterKey is the context key for the rate limiter of a run.
+type rateLimiterKey struct{}
+
+// withRateLimiter returns a context in which model calls are throttled by limiter.
+func withRateLimiter(ctx context.Context, limiter RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, limiter)
+}
+
+// acquireModel acquires the run's rate limiter, if any, for a model call by
+// the current agent.
+func acquireModel(ctx context.Context) (func(), error) {
+	limiter, ok := ctx.Value(rateLimiterKey{}).(RateLimiter)
+	if !ok {
+		return func() {}, nil
+	}
+	return limiter.Acquire(ctx, AgentNameFromContext(ctx))
+}
@@ -0,0 +1,101 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// recordingLimiter records the agents that acquired it.
+type recordingLimiter struct {
+	mu       sync.Mutex
+	agents   []string
+	released int
+}
+
+func (l *recordingLimiter) Acquire(ctx context.Context, agent string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.agents = append(l.agents, agent)
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.released++
+	}, nil
+}
+
+func TestRateLimiterWrapsModelCalls(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hi"}}}
+	alice, err := CreateReactAgent(model, nil)
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	limiter := &recordingLimiter{}
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: alice}},
+		DefaultActiveAgent: "Alice",
+		RateLimiter:        limiter,
+	})
+	if _, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+	}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if len(limiter.agents) != 1 || limiter.agents[0] != "Alice" || limiter.released != 1 {
+		t.Errorf("limiter acquired by %v, released %d times", limiter.agents, limiter.released)
+	}
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	t.Run("rate", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 0.001, Burst: 1})
+		release, err := limiter.Acquire(context.Background(), "Alice")
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := limiter.Acquire(ctx, "Alice"); err == nil {
+			t.Error("Expected the second call to exceed the rate")
+		}
+	})
+
+	t.Run("per agent", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 0.001, Burst: 1, PerAgent: true})
+		for _, agent := range []string{"Alice", "Bob"} {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			_, err := limiter.Acquire(ctx, agent)
+			cancel()
+			if err != nil {
+				t.Errorf("Acquire(%s) error = %v; agents should not share a budget", agent, err)
+			}
+		}
+	})
+
+	t.Run("max concurrent", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimit{MaxConcurrent: 1})
+		release, err := limiter.Acquire(context.Background(), "Alice")
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := limiter.Acquire(ctx, "Bob"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Acquire() while the slot is taken error = %v", err)
+		}
+
+		release()
+		if _, err := limiter.Acquire(context.Background(), "Bob"); err != nil {
+			t.Errorf("Acquire() after release error = %v", err)
+		}
+	})
+}
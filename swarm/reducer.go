@@ -0,0 +1,267 @@
+package swarm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Reducer keys for the built-in SwarmState fields. Any other key in
+// SwarmConfig.Reducers applies to the SwarmState.Values entry of that name.
+const (
+	ReducerKeyMessages        = "messages"
+	ReducerKeyActiveAgent     = "active_agent"
+	ReducerKeyPinnedAgent     = "pinned_agent"
+	ReducerKeyHandoffPayload  = "handoff_payload"
+	ReducerKeyPrivateMessages = "private_messages"
+	ReducerKeyHandoffs        = "handoffs"
+	ReducerKeyTasks           = "tasks"
+	ReducerKeyArtifacts       = "artifacts"
+)
+
+// ReducerFunc merges the value an agent returned for a state field into the
+// current value. For built-in fields the arguments and result have the
+// field's type (for example []llms.MessageContent for "messages").
+type ReducerFunc func(current, update any) (any, error)
+
+// defaultReducers are applied to fields without a configured reducer.
+var defaultReducers = map[string]ReducerFunc{
+	ReducerKeyMessages:        AppendMessages,
+	ReducerKeyActiveAgent:     LastWriteWins,
+	ReducerKeyPinnedAgent:     LastWriteWins,
+	ReducerKeyHandoffPayload:  LastWriteWins,
+	ReducerKeyPrivateMessages: mergePrivateMessages,
+	ReducerKeyHandoffs:        appendHandoffs,
+	ReducerKeyTasks:           mergeTasks,
+	ReducerKeyArtifacts:       mergeArtifacts,
+}
+
+// LastWriteWins is a ReducerFunc that keeps the update unless it is the
+// zero value, in which case the current value is kept.
+func LastWriteWins(current, update any) (any, error) {
+	if update == nil || reflect.ValueOf(update).IsZero() {
+		return current, nil
+	}
+	return update, nil
+}
+
+// AppendMessages is the default "messages" reducer. It appends the messages
+// of update that are not already in current.
+//
+// Agents may return either the whole conversation or only their new
+// messages. Messages matching the start of current are skipped, as are
+// tool calls and tool responses whose tool call ID is already present.
+func AppendMessages(current, update any) (any, error) {
+	cur, ok := current.([]llms.MessageContent)
+	if !ok && current != nil {
+		return nil, fmt.Errorf("messages reducer: unexpected current type %T", current)
+	}
+	upd, ok := update.([]llms.MessageContent)
+	if !ok && update != nil {
+		return nil, fmt.Errorf("messages reducer: unexpected update type %T", update)
+	}
+
+	// Skip the part of the conversation the agent echoed back
+	prefix := 0
+	for prefix < len(cur) && prefix < len(upd) && reflect.DeepEqual(cur[prefix], upd[prefix]) {
+		prefix++
+	}
+	if prefix == len(cur) && prefix > 0 {
+		return append(cur[:len(cur):len(cur)], upd[prefix:]...), nil
+	}
+
+	seen := make(map[string]bool)
+	for _, msg := range cur {
+		if id := messageID(msg); id != "" {
+			seen[id] = true
+		}
+	}
+
+	merged := cur[:len(cur):len(cur)]
+	for _, msg := range upd[prefix:] {
+		id := messageID(msg)
+		if id != "" && seen[id] {
+			continue
+		}
+		if id != "" {
+			seen[id] = true
+		}
+		merged = append(merged, msg)
+	}
+	return merged, nil
+}
+
+// messageID identifies a message by its first tool call or tool response ID.
+// Plain text messages carry no ID and return "".
+func messageID(msg llms.MessageContent) string {
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.ToolCall:
+			if p.ID != "" {
+				return string(msg.Role) + ":" + p.ID
+			}
+		case llms.ToolCallResponse:
+			if p.ToolCallID != "" {
+				return string(msg.Role) + ":" + p.ToolCallID
+			}
+		}
+	}
+	return ""
+}
+
+// appendHandoffs appends the handoff records of update that follow the
+// records already in current.
+func appendHandoffs(current, update any) (any, error) {
+	cur, _ := current.([]HandoffRecord)
+	upd, ok := update.([]HandoffRecord)
+	if !ok && update != nil {
+		return nil, fmt.Errorf("handoffs reducer: unexpected update type %T", update)
+	}
+
+	prefix := 0
+	for prefix < len(cur) && prefix < len(upd) && cur[prefix] == upd[prefix] {
+		prefix++
+	}
+	return append(cur[:len(cur):len(cur)], upd[prefix:]...), nil
+}
+
+// mergePrivateMessages replaces the scratchpads present in update and keeps
+// the others.
+func mergePrivateMessages(current, update any) (any, error) {
+	cur, _ := current.(map[string][]llms.MessageContent)
+	upd, ok := update.(map[string][]llms.MessageContent)
+	if !ok && update != nil {
+		return nil, fmt.Errorf("private_messages reducer: unexpected update type %T", update)
+	}
+	if len(upd) == 0 {
+		return cur, nil
+	}
+
+	merged := make(map[string][]llms.MessageContent, len(cur)+len(upd))
+	for name, messages := range cur {
+		merged[name] = messages
+	}
+	for name, messages := range upd {
+		merged[name] = messages
+	}
+	return merged, nil
+}
+
+// reduceState merges the state returned by an agent into the state it was
+// given, field by field.
+//
+// Args:
+//   - reducers: Configured reducers, overriding the defaults by key
+//   - current: The state passed to the agent
+//   - update: The state returned by the agent
+//
+// Returns:
+//   - The merged state
+func reduceState(reducers map[string]ReducerFunc, current, update SwarmState) (SwarmState, error) {
+	reducer := func(key string) ReducerFunc {
+		if r, ok := reducers[key]; ok {
+			return r
+		}
+		if r, ok := defaultReducers[key]; ok {
+			return r
+		}
+		return LastWriteWins
+	}
+
+	var result SwarmState
+
+	messages, err := reducer(ReducerKeyMessages)(current.Messages, update.Messages)
+	if err != nil {
+		return current, err
+	}
+	if result.Messages, err = asType[[]llms.MessageContent](ReducerKeyMessages, messages); err != nil {
+		return current, err
+	}
+
+	activeAgent, err := reducer(ReducerKeyActiveAgent)(current.ActiveAgent, update.ActiveAgent)
+	if err != nil {
+		return current, err
+	}
+	if result.ActiveAgent, err = asType[string](ReducerKeyActiveAgent, activeAgent); err != nil {
+		return current, err
+	}
+
+	pinnedAgent, err := reducer(ReducerKeyPinnedAgent)(current.PinnedAgent, update.PinnedAgent)
+	if err != nil {
+		return current, err
+	}
+	if result.PinnedAgent, err = asType[string](ReducerKeyPinnedAgent, pinnedAgent); err != nil {
+		return current, err
+	}
+
+	payload, err := reducer(ReducerKeyHandoffPayload)(current.HandoffPayload, update.HandoffPayload)
+	if err != nil {
+		return current, err
+	}
+	if result.HandoffPayload, err = asType[map[string]any](ReducerKeyHandoffPayload, payload); err != nil {
+		return current, err
+	}
+
+	private, err := reducer(ReducerKeyPrivateMessages)(current.PrivateMessages, update.PrivateMessages)
+	if err != nil {
+		return current, err
+	}
+	if result.PrivateMessages, err = asType[map[string][]llms.MessageContent](ReducerKeyPrivateMessages, private); err != nil {
+		return current, err
+	}
+
+	handoffs, err := reducer(ReducerKeyHandoffs)(current.Handoffs, update.Handoffs)
+	if err != nil {
+		return current, err
+	}
+	if result.Handoffs, err = asType[[]HandoffRecord](ReducerKeyHandoffs, handoffs); err != nil {
+		return current, err
+	}
+
+	tasks, err := reducer(ReducerKeyTasks)(current.Tasks, update.Tasks)
+	if err != nil {
+		return current, err
+	}
+	if result.Tasks, err = asType[[]Task](ReducerKeyTasks, tasks); err != nil {
+		return current, err
+	}
+
+	artifacts, err := reducer(ReducerKeyArtifacts)(current.Artifacts, update.Artifacts)
+	if err != nil {
+		return current, err
+	}
+	if result.Artifacts, err = asType[map[string]ArtifactRef](ReducerKeyArtifacts, artifacts); err != nil {
+		return current, err
+	}
+
+	if len(current.Values) > 0 || len(update.Values) > 0 {
+		result.Values = make(map[string]any, len(current.Values)+len(update.Values))
+		for key, value := range current.Values {
+			result.Values[key] = value
+		}
+		for key, value := range update.Values {
+			merged, err := reducer(key)(current.Values[key], value)
+			if err != nil {
+				return current, err
+			}
+			result.Values[key] = merged
+		}
+	}
+
+	return result, nil
+}
+
+// asType converts a reducer result back to the field's type. A nil result
+// yields the zero value.
+func asType[T any](key string, value any) (T, error) {
+	var zero T
+	if value == nil {
+		return zero, nil
+	}
+	v, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("reducer for '%s' returned %T, want %T", key, value, zero)
+	}
+	return v, nil
+}
@@ -0,0 +1,144 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestAppendMessages(t *testing.T) {
+	user := llms.TextParts(llms.ChatMessageTypeHuman, "shout hello")
+	toolCall := aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "upper"}})
+	toolResult := llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call_1", Name: "upper", Content: "HELLO"}},
+	}
+	answer := llms.TextParts(llms.ChatMessageTypeAI, "HELLO")
+
+	tests := []struct {
+		name    string
+		current []llms.MessageContent
+		update  []llms.MessageContent
+		want    int
+	}{
+		{"full conversation", []llms.MessageContent{user}, []llms.MessageContent{user, toolCall, toolResult, answer}, 4},
+		{"new messages only", []llms.MessageContent{user}, []llms.MessageContent{answer}, 2},
+		{"repeated tool call", []llms.MessageContent{user, toolCall, toolResult}, []llms.MessageContent{toolCall, toolResult, answer}, 4},
+		{"empty update", []llms.MessageContent{user}, nil, 1},
+		{"empty current", nil, []llms.MessageContent{user}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendMessages(tt.current, tt.update)
+			if err != nil {
+				t.Fatalf("AppendMessages() error = %v", err)
+			}
+			if messages := got.([]llms.MessageContent); len(messages) != tt.want {
+				t.Errorf("AppendMessages() returned %d messages, want %d", len(messages), tt.want)
+			}
+		})
+	}
+
+	if _, err := AppendMessages("oops", nil); err == nil {
+		t.Error("AppendMessages() with a non-message value should return an error")
+	}
+}
+
+func TestReduceState(t *testing.T) {
+	current := SwarmState{
+		Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+		ActiveAgent: "Alice",
+		Values:      map[string]any{"count": 1, "topic": "math"},
+	}
+	update := SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeAI, "Hello")},
+		Values:   map[string]any{"count": 2},
+	}
+
+	sum := func(current, update any) (any, error) {
+		c, _ := current.(int)
+		return c + update.(int), nil
+	}
+
+	result, err := reduceState(map[string]ReducerFunc{"count": sum}, current, update)
+	if err != nil {
+		t.Fatalf("reduceState() error = %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Errorf("Expected messages to be appended, got %d", len(result.Messages))
+	}
+	if result.ActiveAgent != "Alice" {
+		t.Errorf("Expected unset active agent to keep 'Alice', got '%s'", result.ActiveAgent)
+	}
+	if result.Values["count"] != 3 {
+		t.Errorf("Expected custom reducer to sum counts to 3, got %v", result.Values["count"])
+	}
+	if result.Values["topic"] != "math" {
+		t.Errorf("Expected untouched value to be kept, got %v", result.Values["topic"])
+	}
+
+	update.ActiveAgent = "Bob"
+	result, err = reduceState(nil, current, update)
+	if err != nil {
+		t.Fatalf("reduceState() error = %v", err)
+	}
+	if result.ActiveAgent != "Bob" {
+		t.Errorf("Expected last write 'Bob' to win, got '%s'", result.ActiveAgent)
+	}
+
+	badType := func(current, update any) (any, error) { return 42, nil }
+	if _, err := reduceState(map[string]ReducerFunc{ReducerKeyActiveAgent: badType}, current, update); err == nil {
+		t.Error("reduceState() with a mistyped reducer result should return an error")
+	}
+}
+
+func TestSwarmReducers(t *testing.T) {
+	// The agent returns only its reply rather than the whole conversation
+	deltaAgent := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		return SwarmState{
+			Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeAI, "Hello")},
+			Values:   map[string]any{"turns": 1},
+		}, nil
+	})
+
+	countTurns := func(current, update any) (any, error) {
+		c, _ := current.(int)
+		return c + update.(int), nil
+	}
+
+	s, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: deltaAgent}},
+		DefaultActiveAgent: "Alice",
+		Reducers:           map[string]ReducerFunc{"turns": countTurns},
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := s.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+		Values:   map[string]any{"turns": 4},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Errorf("Expected the reply to be appended to the conversation, got %d messages", len(result.Messages))
+	}
+	if result.Values["turns"] != 5 {
+		t.Errorf("Expected turns to be reduced to 5, got %v", result.Values["turns"])
+	}
+}
+
+// invokerFunc adapts a function to the typed runnable interface.
+type invokerFunc func(ctx context.Context, state SwarmState) (SwarmState, error)
+
+func (f invokerFunc) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return f(ctx, state)
+}
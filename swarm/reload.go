@@ -0,0 +1,277 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConfigSource provides the YAML or JSON spec of a swarm (see SwarmSpec) to
+// a ConfigWatcher.
+type ConfigSource interface {
+	ReadConfig(ctx context.Context) ([]byte, error)
+}
+
+// ConfigSourceFunc adapts a function to the ConfigSource interface.
+type ConfigSourceFunc func(ctx context.Context) ([]byte, error)
+
+// ReadConfig calls f.
+func (f ConfigSourceFunc) ReadConfig(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// FileConfig returns a ConfigSource reading the spec from the file at path.
+func FileConfig(path string) ConfigSource {
+	return ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// EnvConfig returns a ConfigSource reading the spec from the environment
+// variable name, which must be set.
+func EnvConfig(name string) ConfigSource {
+	return ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		spec, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return []byte(spec), nil
+	})
+}
+
+// RemoteConfig returns a ConfigSource fetching the spec from url with GET
+// requests, e.g. from a config service. A nil client uses
+// http.DefaultClient.
+func RemoteConfig(url string, client *http.Client) ConfigSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch swarm spec: %s", resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	})
+}
+
+// defaultWatchInterval is how often a ConfigWatcher checks its source by
+// default.
+const defaultWatchInterval = 5 * time.Second
+
+// WatchOption configures a ConfigWatcher.
+type WatchOption func(*ConfigWatcher)
+
+// WithWatchInterval sets how often Watch checks the source (default: 5s).
+func WithWatchInterval(d time.Duration) WatchOption {
+	return func(w *ConfigWatcher) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// WithReloadHook calls fn after every reload Watch attempts, with the error
+// it failed with, if any.
+func WithReloadHook(fn func(err error)) WatchOption {
+	return func(w *ConfigWatcher) {
+		w.onReload = fn
+	}
+}
+
+// ConfigWatcher hot-reloads a swarm from a spec (see SwarmSpec) and the
+// prompt files it names: when either changes, the swarm's agents and
+// routing settings are rebuilt with Swarm.Reload, without restarting the
+// process. Agents record the version of their prompt (see
+// AgentSpec.PromptVersion) in the TraceSteps of their runs, so traces tell
+// which prompt produced a response.
+//
+// A spec that fails to load or to validate leaves the swarm as it is.
+//
+// Example:
+//
+//	watcher := swarm.NewConfigWatcher(swarm.FileConfig("swarm.yaml"), swarm.Registry{
+//	    Models:  map[string]llms.Model{"default": model},
+//	    Prompts: os.DirFS("prompts"),
+//	})
+//	config, err := watcher.Load(ctx)
+//	workflow, err := swarm.CreateSwarm(config)
+//	go watcher.Watch(ctx, workflow)
+type ConfigWatcher struct {
+	source   ConfigSource
+	registry Registry
+	interval time.Duration
+	onReload func(err error)
+
+	// mu guards fingerprint, the hash of the last spec and prompt files
+	// loaded
+	mu          sync.Mutex
+	fingerprint string
+}
+
+// NewConfigWatcher returns a ConfigWatcher building swarms from the spec of
+// source and the models, tools and prompts of registry.
+func NewConfigWatcher(source ConfigSource, registry Registry, opts ...WatchOption) *ConfigWatcher {
+	w := &ConfigWatcher{source: source, registry: registry, interval: defaultWatchInterval}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Load reads the spec and builds its config, to create the swarm Watch
+// keeps up to date.
+func (w *ConfigWatcher) Load(ctx context.Context) (SwarmConfig, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	spec, fingerprint, err := w.read(ctx)
+	if err != nil {
+		return SwarmConfig{}, err
+	}
+	config, err := spec.Build(w.registry)
+	if err != nil {
+		return SwarmConfig{}, err
+	}
+	w.fingerprint = fingerprint
+	return config, nil
+}
+
+// Check reads the spec once and reloads s if the spec or its prompt files
+// changed since they were last loaded. It reports whether s was reloaded.
+func (w *ConfigWatcher) Check(ctx context.Context, s *Swarm) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	spec, fingerprint, err := w.read(ctx)
+	if err != nil {
+		return false, err
+	}
+	if fingerprint == w.fingerprint {
+		return false, nil
+	}
+	config, err := spec.Build(w.registry)
+	if err != nil {
+		return false, err
+	}
+	if err := s.Reload(ctx, config); err != nil {
+		return false, err
+	}
+	w.fingerprint = fingerprint
+	return true, nil
+}
+
+// Watch checks the spec at the watcher's interval until ctx is done, and
+// returns the context's error. Failed reloads are logged with the swarm's
+// Logger and passed to the hook set with WithReloadHook; the next change
+// is tried again.
+func (w *ConfigWatcher) Watch(ctx context.Context, s *Swarm) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		reloaded, err := w.Check(ctx, s)
+		if err != nil {
+			if logger := s.logger(); logger != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "config reload failed", slog.Any("error", err))
+			}
+		}
+		if (reloaded || err != nil) && w.onReload != nil {
+			w.onReload(err)
+		}
+	}
+}
+
+// read reads and parses the spec, and returns it with the fingerprint of
+// the spec and of the prompt files it names. w.mu must be held.
+func (w *ConfigWatcher) read(ctx context.Context) (SwarmSpec, string, error) {
+	data, err := w.source.ReadConfig(ctx)
+	if err != nil {
+		return SwarmSpec{}, "", fmt.Errorf("read swarm spec: %w", err)
+	}
+	spec, err := parseSpec(bytes.NewReader(data))
+	if err != nil {
+		return SwarmSpec{}, "", err
+	}
+
+	hash := sha256.New()
+	hash.Write(data)
+	for _, agent := range spec.Agents {
+		if agent.PromptFile == "" || w.registry.Prompts == nil {
+			continue
+		}
+		// A missing file is reported by SwarmSpec.Build
+		prompt, _ := fs.ReadFile(w.registry.Prompts, agent.PromptFile)
+		hash.Write(prompt)
+	}
+	return spec, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Reload replaces the agents of a running swarm, and its routing settings
+// DefaultActiveAgent, MaxHandoffs, MaxHandoffCycles, RecursionLimit and
+// InterruptBefore, with those of config; its other settings, such as its
+// Logger and Checkpointer, are kept. Compiled swarms use the new agents
+// from their next invocation; invocations in progress are not affected.
+//
+// config is validated like the config passed to CreateSwarm, and the swarm
+// is left as it is if it is invalid. Reload is safe to call concurrently
+// with invocations. It is not supported by supervisor swarms.
+func (s *Swarm) Reload(ctx context.Context, config SwarmConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.build == nil {
+		return fmt.Errorf("reload: the agents of this swarm cannot be changed")
+	}
+	reloaded := s.config
+	reloaded.Agents = config.Agents
+	reloaded.DefaultActiveAgent = config.DefaultActiveAgent
+	reloaded.MaxHandoffs = config.MaxHandoffs
+	reloaded.MaxHandoffCycles = config.MaxHandoffCycles
+	reloaded.RecursionLimit = config.RecursionLimit
+	reloaded.InterruptBefore = config.InterruptBefore
+	if err := s.reconfigure(reloaded); err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	if s.config.Logger != nil {
+		var versions []slog.Attr
+		for _, agent := range config.Agents {
+			if agent.PromptVersion != "" {
+				versions = append(versions, slog.String(agent.Name, agent.PromptVersion))
+			}
+		}
+		s.config.Logger.LogAttrs(ctx, s.config.LogLevel, "config reloaded",
+			slog.Int("agents", len(config.Agents)),
+			slog.Attr{Key: "prompt_versions", Value: slog.GroupValue(versions...)})
+	}
+	return nil
+}
+
+// logger returns the Logger of the swarm's config.
+func (s *Swarm) logger() *slog.Logger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.Logger
+}
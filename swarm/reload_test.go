@@ -0,0 +1,157 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const reloadSpec = `
+default_agent: Alice
+agents:
+  - name: Alice
+    prompt_file: alice.txt
+`
+
+func TestConfigWatcher(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{{Content: "Hi"}, {Content: "Hi again"}, {Content: "Still here"}}}
+	prompts := fstest.MapFS{"alice.txt": {Data: []byte("You are Alice.")}}
+	var mu sync.Mutex
+	spec := reloadSpec
+	source := ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(spec), nil
+	})
+	watcher := NewConfigWatcher(source, Registry{Models: map[string]llms.Model{"default": model}, Prompts: prompts})
+
+	ctx := context.Background()
+	config, err := watcher.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	app := compileSwarm(t, config)
+	run := func() *Trace {
+		t.Helper()
+		result, err := app.InvokeWithResult(ctx, SwarmState{Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+		}})
+		if err != nil {
+			t.Fatalf("InvokeWithResult() error = %v", err)
+		}
+		return result.Trace()
+	}
+	if got, want := run().Steps[0].PromptVersion, promptVersion("You are Alice."); got != want {
+		t.Errorf("PromptVersion = %q, want %q", got, want)
+	}
+	if reloaded, err := watcher.Check(ctx, app.Swarm()); reloaded || err != nil {
+		t.Errorf("Check() = %v, %v, want no reload of an unchanged spec", reloaded, err)
+	}
+
+	prompts["alice.txt"] = &fstest.MapFile{Data: []byte("You are Alice, v2.")}
+	if reloaded, err := watcher.Check(ctx, app.Swarm()); !reloaded || err != nil {
+		t.Fatalf("Check() = %v, %v, want a reload of the changed prompt", reloaded, err)
+	}
+	if got, want := run().Steps[0].PromptVersion, promptVersion("You are Alice, v2."); got != want {
+		t.Errorf("PromptVersion = %q, want %q", got, want)
+	}
+	if prompt := model.calls[1][0].Parts[0].(llms.TextContent).Text; prompt != "You are Alice, v2." {
+		t.Errorf("system prompt = %q, want the reloaded prompt", prompt)
+	}
+
+	// An invalid spec leaves the swarm as it is
+	mu.Lock()
+	spec = reloadSpec + "    model: missing\n"
+	mu.Unlock()
+	var configErr *ConfigError
+	if _, err := watcher.Check(ctx, app.Swarm()); !errors.As(err, &configErr) {
+		t.Errorf("Check() error = %v, want a *ConfigError", err)
+	}
+	if got, want := run().Steps[0].PromptVersion, promptVersion("You are Alice, v2."); got != want {
+		t.Errorf("PromptVersion after a failed reload = %q, want %q", got, want)
+	}
+}
+
+func TestConfigWatcherWatch(t *testing.T) {
+	var mu sync.Mutex
+	spec := "default_agent: Alice\nagents:\n  - name: Alice\n    system_prompt: You are Alice.\n"
+	source := ConfigSourceFunc(func(ctx context.Context) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(spec), nil
+	})
+	reloads := make(chan error, 1)
+	watcher := NewConfigWatcher(source, Registry{Models: map[string]llms.Model{"default": &scriptedModel{}}},
+		WithWatchInterval(time.Millisecond), WithReloadHook(func(err error) { reloads <- err }))
+	config, err := watcher.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := CreateSwarm(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watcher.Watch(ctx, workflow) }()
+
+	mu.Lock()
+	spec += "    prompt_version: v2\n"
+	mu.Unlock()
+	select {
+	case err := <-reloads:
+		if err != nil {
+			t.Fatalf("reload error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the changed spec was not reloaded")
+	}
+	if version := workflow.config.Agents[0].PromptVersion; version != "v2" {
+		t.Errorf("PromptVersion = %q, want v2", version)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() = %v, want context.Canceled", err)
+	}
+}
+
+func TestConfigSources(t *testing.T) {
+	const spec = "default_agent: Alice\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/swarm.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(spec))
+	}))
+	defer server.Close()
+	t.Setenv("SWARM_SPEC", spec)
+
+	for name, source := range map[string]ConfigSource{
+		"env":    EnvConfig("SWARM_SPEC"),
+		"remote": RemoteConfig(server.URL+"/swarm.yaml", nil),
+	} {
+		data, err := source.ReadConfig(context.Background())
+		if err != nil || string(data) != spec {
+			t.Errorf("%s: ReadConfig() = %q, %v", name, data, err)
+		}
+	}
+	for name, source := range map[string]ConfigSource{
+		"unset env":      EnvConfig("SWARM_SPEC_UNSET"),
+		"missing remote": RemoteConfig(server.URL+"/missing.yaml", nil),
+		"missing file":   FileConfig("testdata/missing.yaml"),
+	} {
+		if _, err := source.ReadConfig(context.Background()); err == nil {
+			t.Errorf("%s: ReadConfig() succeeded", name)
+		}
+	}
+}
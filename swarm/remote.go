@@ -0,0 +1,198 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxRemoteErrorBody is the number of bytes of an error response quoted in
+// the error of a remote agent.
+const maxRemoteErrorBody = 512
+
+// RemoteAuth authenticates the requests of a remote agent, typically by
+// setting a header. A nil RemoteAuth sends requests as they are.
+type RemoteAuth func(req *http.Request) error
+
+// BearerToken returns a RemoteAuth sending token in the Authorization header.
+func BearerToken(token string) RemoteAuth {
+	return func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// RemoteRequest is the body POSTed by a RemoteAgent.
+type RemoteRequest struct {
+	// Agent is the name of the agent in the swarm
+	Agent string `json:"agent"`
+	// State is the swarm state, or the view of it chosen with WithRemoteView
+	State SwarmState `json:"state"`
+}
+
+// RemoteResponse is the body a remote agent service answers with. The
+// fields are merged into the swarm state; empty fields leave it unchanged.
+type RemoteResponse struct {
+	// Messages are the messages the agent adds to the conversation
+	Messages []llms.MessageContent `json:"messages,omitempty"`
+	// ActiveAgent hands off to another agent when set
+	ActiveAgent string `json:"active_agent,omitempty"`
+	// HandoffPayload holds the arguments of the handoff
+	HandoffPayload map[string]any `json:"handoff_payload,omitempty"`
+	// Values are set in SwarmState.Values
+	Values map[string]any `json:"values,omitempty"`
+}
+
+// RemoteAgentOption configures an agent created by NewRemoteAgent.
+type RemoteAgentOption func(*RemoteAgent)
+
+// WithRemoteHTTPClient sets the HTTP client of a remote agent (default:
+// http.DefaultClient). Use it to set timeouts or transports.
+func WithRemoteHTTPClient(client *http.Client) RemoteAgentOption {
+	return func(a *RemoteAgent) {
+		a.client = client
+	}
+}
+
+// WithRemoteView sets a function trimming the state sent to the remote
+// service, e.g. to the last messages, or without PrivateMessages. The
+// response is merged into the full state.
+func WithRemoteView(view func(state SwarmState) SwarmState) RemoteAgentOption {
+	return func(a *RemoteAgent) {
+		a.view = view
+	}
+}
+
+// RemoteAgent is a swarm agent running in another process, created by
+// NewRemoteAgent.
+type RemoteAgent struct {
+	name     string
+	endpoint string
+	auth     RemoteAuth
+	client   *http.Client
+	view     func(state SwarmState) SwarmState
+}
+
+// NewRemoteAgent creates an agent that runs by POSTing a RemoteRequest as
+// JSON to endpoint and merging the RemoteResponse it gets back, so parts of
+// a swarm can run in other processes or languages. Any status other than
+// 200 OK fails the run. NewRemoteAgentHandler serves a Go agent this way.
+//
+// Example:
+//
+//	billing := swarm.NewRemoteAgent("Billing", "https://billing.internal/agent", swarm.BearerToken(token))
+//	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents: []swarm.Agent{
+//	        {Name: "Support", Runnable: support},
+//	        {Name: "Billing", Runnable: billing},
+//	    },
+//	    DefaultActiveAgent: "Support",
+//	})
+func NewRemoteAgent(name, endpoint string, auth RemoteAuth, opts ...RemoteAgentOption) *RemoteAgent {
+	a := &RemoteAgent{name: name, endpoint: endpoint, auth: auth, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Invoke sends the state to the remote service and returns it merged with
+// the response.
+func (a *RemoteAgent) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	input := state
+	if a.view != nil {
+		input = a.view(state)
+	}
+	body, err := json.Marshal(RemoteRequest{Agent: a.name, State: input})
+	if err != nil {
+		return state, fmt.Errorf("remote agent '%s': failed to marshal state: %w", a.name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return state, fmt.Errorf("remote agent '%s': %w", a.name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.auth != nil {
+		if err := a.auth(req); err != nil {
+			return state, fmt.Errorf("remote agent '%s': auth: %w", a.name, err)
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return state, fmt.Errorf("remote agent '%s': %w", a.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteErrorBody))
+		return state, fmt.Errorf("remote agent '%s': %s: %s", a.name, resp.Status, strings.TrimSpace(string(text)))
+	}
+
+	var response RemoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return state, fmt.Errorf("remote agent '%s': invalid response: %w", a.name, err)
+	}
+	return response.merge(state), nil
+}
+
+// merge returns state updated with the response.
+func (r RemoteResponse) merge(state SwarmState) SwarmState {
+	state.Messages = append(slices.Clip(state.Messages), r.Messages...)
+	if r.ActiveAgent != "" {
+		state.ActiveAgent = r.ActiveAgent
+		state.HandoffPayload = r.HandoffPayload
+	}
+	if len(r.Values) > 0 {
+		values := maps.Clone(state.Values)
+		if values == nil {
+			values = make(map[string]any, len(r.Values))
+		}
+		maps.Copy(values, r.Values)
+		state.Values = values
+	}
+	return state
+}
+
+// NewRemoteAgentHandler returns an http.Handler serving an agent runnable
+// to RemoteAgent clients. The response carries the messages the runnable
+// added to the request's state, its handoff and its values.
+func NewRemoteAgentHandler(runnable AgentRunnable) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req RemoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := withAgentName(r.Context(), req.Agent)
+		result, err := invokeRunnable(ctx, runnable, req.State)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := RemoteResponse{Values: result.Values}
+		if len(result.Messages) > len(req.State.Messages) {
+			response.Messages = result.Messages[len(req.State.Messages):]
+		}
+		if result.ActiveAgent != req.State.ActiveAgent {
+			response.ActiveAgent = result.ActiveAgent
+			response.HandoffPayload = result.HandoffPayload
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
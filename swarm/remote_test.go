@@ -0,0 +1,68 @@
+package swarm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestRemoteAgent(t *testing.T) {
+	var seen RemoteRequest
+	remote := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		seen = RemoteRequest{Agent: AgentNameFromContext(ctx), State: state}
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Transferring you to Bob"))
+		state.ActiveAgent = "Bob"
+		state.HandoffPayload = map[string]any{"reason": "billing"}
+		state.Values = map[string]any{"plan": "pro"}
+		return state, nil
+	})
+	handler := NewRemoteAgentHandler(remote)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	lastMessage := WithRemoteView(func(state SwarmState) SwarmState {
+		state.Messages = state.Messages[len(state.Messages)-1:]
+		return state
+	})
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: NewRemoteAgent("Alice", ts.URL, BearerToken("secret"), lastMessage)},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Bob here")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "My invoice is wrong"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if seen.Agent != "Alice" || len(seen.State.Messages) != 1 {
+		t.Errorf("Remote agent got %+v, want Alice with the last message", seen)
+	}
+	if len(result.Messages) != 4 || result.ActiveAgent != "Bob" || result.Values["plan"] != "pro" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if len(result.Handoffs) != 1 || result.HandoffPayload["reason"] != "billing" {
+		t.Errorf("Expected the remote handoff to be recorded, got %+v", result.Handoffs)
+	}
+
+	unauthorized := NewRemoteAgent("Alice", ts.URL, nil)
+	_, err = unauthorized.Invoke(context.Background(), SwarmState{})
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized: unauthorized") {
+		t.Errorf("Invoke() without auth error = %v", err)
+	}
+}
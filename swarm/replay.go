@@ -0,0 +1,214 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ForkedFromMetadataKey is the checkpoint metadata key recording the thread
+// and checkpoint a forked thread was copied from (see ForkThread), as a map
+// with "thread_id" and "checkpoint_id" keys.
+const ForkedFromMetadataKey = "forked_from"
+
+// ErrThreadExists is matched (with errors.Is) by the error of ForkThread when
+// the new thread already has checkpoints.
+var ErrThreadExists = errors.New("thread already exists")
+
+// ForkThread starts thread newThreadID from checkpoint checkpointID of
+// thread threadID ("": its latest checkpoint), leaving the original thread
+// unchanged. The fork's first checkpoint is a copy of that checkpoint,
+// recording its origin in its metadata, and can be continued with Invoke, or
+// with Resume if the run was interrupted there. Use it to explore what would
+// have happened with a different answer at any past turn.
+//
+// Example:
+//
+//	checkpoints, err := app.Checkpointer().List(ctx, "user_123")
+//	fork, err := app.ForkThread(ctx, "user_123", checkpoints[2].ID, "user_123-debug")
+//	state := fork.State
+//	state.Messages = append(state.Messages, llms.TextParts("user", "Try Bob instead"))
+//	result, err := app.Invoke(ctx, state, swarm.WithThreadID("user_123-debug"))
+func (c *CompiledSwarm) ForkThread(ctx context.Context, threadID, checkpointID, newThreadID string) (*Checkpoint, error) {
+	store := c.Checkpointer()
+	if store == nil {
+		return nil, fmt.Errorf("thread '%s': no checkpointer configured", threadID)
+	}
+	if newThreadID == "" {
+		return nil, fmt.Errorf("fork of thread '%s' needs a thread ID", threadID)
+	}
+	var source *Checkpoint
+	var err error
+	if checkpointID == "" {
+		source, err = store.Latest(ctx, threadID)
+	} else {
+		source, err = store.Get(ctx, threadID, checkpointID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := store.Latest(ctx, newThreadID); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrThreadExists, newThreadID)
+	} else if !errors.Is(err, ErrCheckpointNotFound) {
+		return nil, err
+	}
+
+	metadata := maps.Clone(source.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]any, 1)
+	}
+	metadata[ForkedFromMetadataKey] = map[string]any{"thread_id": threadID, "checkpoint_id": source.ID}
+	fork := &Checkpoint{ThreadID: newThreadID, State: source.State, Metadata: metadata}
+	if err := store.Put(ctx, fork); err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// Replay re-runs the conversation of thread threadID after checkpoint
+// fromCheckpoint ("" replays it from the start): each later turn's user
+// messages are sent again, in order, with the swarm's current agents and
+// prompts. Tools are not executed; every tool call is answered with the
+// output recorded in the thread for a call of the same tool with the same
+// arguments, so the replay has no side effects and only the models' answers
+// can change. Calls without a recorded output get an error result. Handoff
+// tools run as usual, and tool calls are not interrupted for approval.
+//
+// The thread is not modified. Pass WithThreadID to save the replayed turns
+// to another thread, e.g. to compare the handoffs of both runs. Replay
+// returns the state after the last turn.
+//
+// Example:
+//
+//	result, err := app.Replay(ctx, "user_123", "", swarm.WithThreadID("user_123-replay"))
+func (c *CompiledSwarm) Replay(ctx context.Context, threadID, fromCheckpoint string, opts ...InvokeOption) (SwarmState, error) {
+	store := c.Checkpointer()
+	if store == nil {
+		return SwarmState{}, fmt.Errorf("thread '%s': no checkpointer configured", threadID)
+	}
+	checkpoints, err := store.List(ctx, threadID)
+	if err != nil {
+		return SwarmState{}, err
+	}
+	if len(checkpoints) == 0 {
+		return SwarmState{}, fmt.Errorf("%w: thread %s", ErrCheckpointNotFound, threadID)
+	}
+
+	first := 0
+	var state SwarmState
+	if fromCheckpoint != "" {
+		i := slices.IndexFunc(checkpoints, func(cp *Checkpoint) bool { return cp.ID == fromCheckpoint })
+		if i < 0 {
+			return SwarmState{}, fmt.Errorf("%w: thread %s, checkpoint %s", ErrCheckpointNotFound, threadID, fromCheckpoint)
+		}
+		first, state = i+1, checkpoints[i].State
+	}
+
+	ctx = withToolRecording(ctx, recordToolOutputs(checkpoints[first:]))
+	previous := state
+	for _, checkpoint := range checkpoints[first:] {
+		input := turnInput(previous, checkpoint.State)
+		previous = checkpoint.State
+		// Turns resumed after an approval have no input of their own; the
+		// turn they continue ran to completion without interruption
+		if len(input) == 0 {
+			continue
+		}
+		state.Messages = append(slices.Clip(state.Messages), input...)
+		if state, err = c.Invoke(ctx, state, opts...); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// turnInput returns the user messages that started the turn saved as after,
+// following the turn saved as before.
+func turnInput(before, after SwarmState) []llms.MessageContent {
+	if len(after.Messages) < len(before.Messages) {
+		return nil
+	}
+	added := after.Messages[len(before.Messages):]
+	n := 0
+	for n < len(added) && added[n].Role == llms.ChatMessageTypeHuman {
+		n++
+	}
+	return added[:n]
+}
+
+// toolRecordingKey is the context key for the tool outputs served during a
+// replay.
+type toolRecordingKey struct{}
+
+// toolRecording holds recorded tool outputs, in order, by tool call.
+type toolRecording struct {
+	mu      sync.Mutex
+	outputs map[toolCallKey][]string
+}
+
+// toolCallKey identifies the tool calls that share a recorded output.
+type toolCallKey struct {
+	name      string
+	arguments string
+}
+
+// recordToolOutputs collects the tool results of the conversations saved in
+// checkpoints, once per tool call.
+func recordToolOutputs(checkpoints []*Checkpoint) *toolRecording {
+	recording := &toolRecording{outputs: make(map[toolCallKey][]string)}
+	calls := make(map[string]llms.ToolCall)
+	answered := make(map[string]bool)
+	for _, checkpoint := range checkpoints {
+		for _, message := range checkpoint.State.Messages {
+			for _, part := range message.Parts {
+				switch part := part.(type) {
+				case llms.ToolCall:
+					calls[part.ID] = part
+				case llms.ToolCallResponse:
+					call, ok := calls[part.ToolCallID]
+					if !ok || call.FunctionCall == nil || answered[part.ToolCallID] {
+						continue
+					}
+					answered[part.ToolCallID] = true
+					key := toolCallKey{name: call.FunctionCall.Name, arguments: call.FunctionCall.Arguments}
+					recording.outputs[key] = append(recording.outputs[key], part.Content)
+				}
+			}
+		}
+	}
+	return recording
+}
+
+// withToolRecording returns a context in which tool nodes answer tool calls
+// from recording instead of executing them.
+func withToolRecording(ctx context.Context, recording *toolRecording) context.Context {
+	return context.WithValue(ctx, toolRecordingKey{}, recording)
+}
+
+// toolRecordingFromContext returns the recording of a replay, or nil.
+func toolRecordingFromContext(ctx context.Context) *toolRecording {
+	recording, _ := ctx.Value(toolRecordingKey{}).(*toolRecording)
+	return recording
+}
+
+// output returns the next recorded output of call. Outputs are served in
+// the order they were recorded; the last one is repeated once all were
+// served.
+func (r *toolRecording) output(call llms.ToolCall) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := toolCallKey{name: call.FunctionCall.Name, arguments: call.FunctionCall.Arguments}
+	outputs := r.outputs[key]
+	if len(outputs) == 0 {
+		return "", fmt.Errorf("no recorded output for this call of tool '%s'", key.name)
+	}
+	if len(outputs) > 1 {
+		r.outputs[key] = outputs[1:]
+	}
+	return outputs[0], nil
+}
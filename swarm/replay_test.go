@@ -0,0 +1,146 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// countingTool upper-cases its input and counts its calls.
+type countingTool struct {
+	calls *int
+}
+
+func (countingTool) Name() string        { return "upper" }
+func (countingTool) Description() string { return "Upper-case the input" }
+func (t countingTool) Call(ctx context.Context, input string) (string, error) {
+	*t.calls++
+	return strings.ToUpper(input), nil
+}
+
+func TestForkThread(t *testing.T) {
+	saver := NewMemorySaver()
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")}},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       saver,
+	})
+	ctx := context.Background()
+	state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}}
+	state, err := app.Invoke(ctx, state, WithThreadID("t1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Again"))
+	if _, err := app.Invoke(ctx, state, WithThreadID("t1")); err != nil {
+		t.Fatal(err)
+	}
+	checkpoints, err := saver.List(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fork, err := app.ForkThread(ctx, "t1", checkpoints[0].ID, "t2")
+	if err != nil {
+		t.Fatalf("ForkThread() error = %v", err)
+	}
+	latest, err := saver.Latest(ctx, "t2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != fork.ID || len(latest.State.Messages) != 2 {
+		t.Errorf("fork has %d messages, want 2", len(latest.State.Messages))
+	}
+	origin, _ := latest.Metadata[ForkedFromMetadataKey].(map[string]any)
+	if origin["thread_id"] != "t1" || origin["checkpoint_id"] != checkpoints[0].ID {
+		t.Errorf("fork metadata = %v", latest.Metadata)
+	}
+	if original, _ := saver.List(ctx, "t1"); len(original) != 2 {
+		t.Errorf("original thread has %d checkpoints, want 2", len(original))
+	}
+
+	if _, err := app.ForkThread(ctx, "t1", "", "t2"); !errors.Is(err, ErrThreadExists) {
+		t.Errorf("ForkThread() into an existing thread error = %v, want ErrThreadExists", err)
+	}
+	if _, err := app.ForkThread(ctx, "t1", "unknown", "t3"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("ForkThread() error = %v, want ErrCheckpointNotFound", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var calls int
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		// Original run: the call to upper is interrupted, then approved
+		toolCallChoice("call_1", "upper", `{"input":"hi"}`),
+		{Content: "It says HI"},
+		toolCallChoice("call_2", "transfer_to_bob", `{}`),
+		// Replay: same tool call, then an unrecorded one
+		toolCallChoice("call_3", "upper", `{"input":"hi"}`),
+		toolCallChoice("call_4", "upper", `{"input":"bye"}`),
+		{Content: "Done"},
+		{Content: "Staying with Alice"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{
+		countingTool{calls: &calls},
+		CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saver := NewMemorySaver()
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Hi from Bob")},
+		},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       saver,
+		InterruptBefore:    []string{"upper"},
+	})
+	ctx := context.Background()
+
+	state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Shout hi")}}
+	if _, err := app.Invoke(ctx, state, WithThreadID("t1")); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Invoke() error = %v, want ErrInterrupted", err)
+	}
+	state, err = app.Resume(ctx, "t1", Approval{Approved: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Get Bob"))
+	if state, err = app.Invoke(ctx, state, WithThreadID("t1")); err != nil || state.ActiveAgent != "Bob" {
+		t.Fatalf("Invoke() = %q, %v; want a handoff to Bob", state.ActiveAgent, err)
+	}
+
+	result, err := app.Replay(ctx, "t1", "", WithThreadID("t1-replay"))
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("tool executed %d times, want once", calls)
+	}
+	if result.ActiveAgent == "Bob" || len(result.Handoffs) != 0 {
+		t.Errorf("replay handed off to %q, want no handoff", result.ActiveAgent)
+	}
+
+	var outputs []string
+	for _, message := range result.Messages {
+		if response, ok := message.Parts[0].(llms.ToolCallResponse); ok {
+			outputs = append(outputs, response.Content)
+		}
+	}
+	want := []string{"HI", "Error: no recorded output for this call of tool 'upper'"}
+	if strings.Join(outputs, "|") != strings.Join(want, "|") {
+		t.Errorf("tool outputs = %q, want %q", outputs, want)
+	}
+	if replayed, _ := saver.List(ctx, "t1-replay"); len(replayed) != 2 {
+		t.Errorf("replay saved %d checkpoints, want 2", len(replayed))
+	}
+	if original, _ := saver.List(ctx, "t1"); len(original) != 3 {
+		t.Errorf("original thread has %d checkpoints, want 3", len(original))
+	}
+}
@@ -0,0 +1,152 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LoadBalancing selects the replica that runs an agent (see Agent.Replicas).
+type LoadBalancing int
+
+const (
+	// RoundRobin runs the replicas in turn. This is the default.
+	RoundRobin LoadBalancing = iota
+
+	// LeastLatency runs the replica whose recent runs were the fastest,
+	// trying every replica once first. Failed runs count as the slowest.
+	LeastLatency
+)
+
+// String returns the name of the load balancing strategy.
+func (b LoadBalancing) String() string {
+	switch b {
+	case RoundRobin:
+		return "RoundRobin"
+	case LeastLatency:
+		return "LeastLatency"
+	default:
+		return "LoadBalancing(unknown)"
+	}
+}
+
+// latencyWeight is the weight of the latest run in the moving average of a
+// replica's latency.
+const latencyWeight = 0.3
+
+// Replica is an instance of an agent, such as the same agent on another
+// model. The swarm sees the agent's name whichever replica runs it.
+type Replica struct {
+	// Name identifies the replica in logs and traces (default: the agent's
+	// name followed by "#" and the replica's index)
+	Name string
+	// Runnable runs the replica; nil uses the agent's Runnable
+	Runnable AgentRunnable
+	// Model replaces the model of a prebuilt agent for the replica (see
+	// Agent.Model); nil uses the agent's Model
+	Model llms.Model
+}
+
+// replicaSet distributes the runs of an agent among its replicas.
+type replicaSet struct {
+	replicas []Replica
+	strategy LoadBalancing
+
+	mu   sync.Mutex
+	next int
+	// latency is the moving average of each replica's run time; zero until
+	// the replica has run
+	latency []time.Duration
+}
+
+// newReplicaSet returns the replica set of agent, or nil if it has no
+// replicas.
+func newReplicaSet(agent Agent) *replicaSet {
+	if len(agent.Replicas) == 0 {
+		return nil
+	}
+	replicas := make([]Replica, len(agent.Replicas))
+	for i, replica := range agent.Replicas {
+		if replica.Name == "" {
+			replica.Name = fmt.Sprintf("%s#%d", agent.Name, i)
+		}
+		if replica.Runnable == nil {
+			replica.Runnable = agent.Runnable
+		}
+		if replica.Model == nil {
+			replica.Model = agent.Model
+		}
+		replicas[i] = replica
+	}
+	return &replicaSet{replicas: replicas, strategy: agent.LoadBalancing, latency: make([]time.Duration, len(replicas))}
+}
+
+// run runs fn with the agent on the replica picked by the set's strategy:
+// the agent has the replica's runnable and model, and the context carries
+// them. A nil set runs fn with agent as it is.
+func (s *replicaSet) run(ctx context.Context, agent Agent, fn func(ctx context.Context, agent Agent) (SwarmState, error)) (SwarmState, error) {
+	if s == nil {
+		return fn(ctx, agent)
+	}
+	i := s.pick()
+	replica := s.replicas[i]
+	agent.Runnable = replica.Runnable
+	agent.Model = replica.Model
+	if agent.Model != nil || len(agent.CallOptions) > 0 || len(agent.Tools) > 0 || agent.PostProcess != nil {
+		ctx = withAgentModel(ctx, agent)
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrReplica.String(replica.Name))
+
+	start := time.Now()
+	result, err := fn(ctx, agent)
+	s.observe(i, time.Since(start), err)
+	return result, err
+}
+
+// pick returns the index of the replica to run.
+func (s *replicaSet) pick() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.next
+	s.next = (s.next + 1) % len(s.replicas)
+	if s.strategy != LeastLatency {
+		return i
+	}
+	// Scan from the round-robin position so that ties, such as replicas
+	// that have not run yet, take turns
+	best := i
+	for offset := range len(s.replicas) {
+		j := (i + offset) % len(s.replicas)
+		if s.latency[j] < s.latency[best] {
+			best = j
+		}
+	}
+	return best
+}
+
+// observe records the run time of a replica. A failed run counts as twice
+// the slowest of its run time and the replicas' averages, however fast it
+// failed; an interrupted run is not counted.
+func (s *replicaSet) observe(i int, elapsed time.Duration, err error) {
+	if errors.Is(err, ErrInterrupted) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A zero latency marks a replica that has not run
+	elapsed = max(elapsed, time.Nanosecond)
+	if err != nil {
+		elapsed = 2 * max(elapsed, slices.Max(s.latency))
+	}
+	if s.latency[i] == 0 {
+		s.latency[i] = elapsed
+		return
+	}
+	s.latency[i] = time.Duration(latencyWeight*float64(elapsed) + (1-latencyWeight)*float64(s.latency[i]))
+}
@@ -0,0 +1,100 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestAgentReplicasRoundRobin(t *testing.T) {
+	researcher, err := CreateReactAgent(&scriptedModel{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	models := []*scriptedModel{
+		{responses: []*llms.ContentChoice{{Content: "A"}, {Content: "A"}}},
+		{responses: []*llms.ContentChoice{{Content: "B"}, {Content: "B"}}},
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:     "researcher",
+			Runnable: researcher,
+			Replicas: []Replica{
+				{Name: "researcher-a", Model: models[0]},
+				{Name: "researcher-b", Model: models[1]},
+				{Name: "researcher-mock", Runnable: createMockAgent("researcher", "C")},
+			},
+		}},
+		DefaultActiveAgent: "researcher",
+	})
+
+	var answers []string
+	for range 4 {
+		result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Research"),
+		}})
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		answers = append(answers, lastText(result))
+	}
+	if !slices.Equal(answers, []string{"A", "B", "C", "A"}) {
+		t.Errorf("answers = %v, want the replicas in turn", answers)
+	}
+}
+
+func TestAgentReplicasLeastLatency(t *testing.T) {
+	slow := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		time.Sleep(20 * time.Millisecond)
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "slow"))
+		return state, nil
+	})
+	var failures int
+	failing := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		failures++
+		return state, errors.New("overloaded")
+	})
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name: "researcher",
+			Replicas: []Replica{
+				{Runnable: slow},
+				{Runnable: failing},
+				{Runnable: createMockAgent("researcher", "fast")},
+			},
+			LoadBalancing: LeastLatency,
+			RetryPolicy:   RetryPolicy{MaxAttempts: 2, Backoff: func(int) time.Duration { return 0 }},
+		}},
+		DefaultActiveAgent: "researcher",
+	})
+
+	var answers []string
+	for range 4 {
+		result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Research"),
+		}})
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		answers = append(answers, lastText(result))
+	}
+	// Every replica runs once, the failure being retried on the next one,
+	// then the fastest takes the runs
+	if !slices.Equal(answers, []string{"slow", "fast", "fast", "fast"}) || failures != 1 {
+		t.Errorf("answers = %v, failures = %d", answers, failures)
+	}
+}
+
+func TestAgentReplicasValidation(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "researcher", Replicas: []Replica{{Name: "a"}}}},
+		DefaultActiveAgent: "researcher",
+	})
+	if err == nil {
+		t.Error("CreateSwarm() with a replica without runnable should return an error")
+	}
+}
@@ -0,0 +1,337 @@
+package swarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrInvalidResponse is returned by runs whose final answer does not match
+// SwarmConfig.ResponseFormat, even after repairs.
+var ErrInvalidResponse = errors.New("final answer does not match the response format")
+
+// defaultMaxRepairs is the number of repair attempts of a ResponseFormat
+// with a RepairModel and no MaxRepairs.
+const defaultMaxRepairs = 1
+
+// ResponseFormat requires the final answer of every run to be JSON matching
+// a schema, so that programs can parse it (see SwarmConfig.ResponseFormat).
+// The agents should be prompted to answer in that format; the swarm checks
+// the answer, and asks RepairModel to fix it if it does not match.
+type ResponseFormat struct {
+	// Schema is the JSON schema of the answer. The type, properties,
+	// required, additionalProperties, items, enum, minimum and maximum
+	// keywords are checked. See ResponseFormatFor to derive it from a struct.
+	Schema map[string]any
+	// RepairModel rewrites answers that do not match the schema. Without
+	// it, such runs fail with ErrInvalidResponse.
+	RepairModel llms.Model
+	// MaxRepairs is the number of repair attempts (default: 1)
+	MaxRepairs int
+}
+
+// ResponseFormatFor returns a ResponseFormat whose schema is derived from T
+// as for NewStructTool. Decode the answers with DecodeResponse.
+//
+// Example:
+//
+//	type Ticket struct {
+//	    Category string `json:"category" jsonschema:"enum=billing,enum=technical"`
+//	    Summary  string `json:"summary"`
+//	}
+//
+//	config.ResponseFormat = swarm.ResponseFormatFor[Ticket]()
+//	result, err := app.Invoke(ctx, state)
+//	var ticket Ticket
+//	err = swarm.DecodeResponse(result, &ticket)
+func ResponseFormatFor[T any]() *ResponseFormat {
+	return &ResponseFormat{Schema: typeSchema(reflect.TypeFor[T]())}
+}
+
+// DecodeResponse decodes the final answer of a run, the last AI message
+// with text, into v.
+func DecodeResponse(state SwarmState, v any) error {
+	i, answer := lastAnswer(state.Messages)
+	if i < 0 {
+		return errors.New("the conversation has no final answer")
+	}
+	return json.Unmarshal([]byte(answer), v)
+}
+
+// finalize checks the final answer of state against the format, repairing
+// it if needed. The answer is replaced by the JSON it contains.
+func (f *ResponseFormat) finalize(ctx context.Context, state SwarmState) (SwarmState, error) {
+	i, answer := lastAnswer(state.Messages)
+	if i < 0 {
+		return state, fmt.Errorf("%w: the run has no final answer", ErrInvalidResponse)
+	}
+
+	text, err := f.check(answer)
+	if err != nil && f.RepairModel != nil {
+		repairs := f.MaxRepairs
+		if repairs <= 0 {
+			repairs = defaultMaxRepairs
+		}
+		for attempt := 0; attempt < repairs && err != nil; attempt++ {
+			var repaired string
+			if repaired, err = f.repair(ctx, answer, err); err == nil {
+				text, err = f.check(repaired)
+				answer = repaired
+			}
+		}
+	}
+	if err != nil {
+		return state, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	state.Messages = slices.Clone(state.Messages)
+	state.Messages[i] = llms.TextParts(llms.ChatMessageTypeAI, text)
+	return state, nil
+}
+
+// check returns the JSON of an answer if it matches the schema.
+func (f *ResponseFormat) check(answer string) (string, error) {
+	text := extractJSON(answer)
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("the answer is not JSON: %w", err)
+	}
+	if decoder.More() {
+		return "", errors.New("the answer holds more than one JSON value")
+	}
+	if err := validateSchema(f.Schema, value, "$"); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// repair asks the repair model to fix an answer.
+func (f *ResponseFormat) repair(ctx context.Context, answer string, problem error) (string, error) {
+	schema, err := json.Marshal(f.Schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the response schema: %w", err)
+	}
+	ctx, span := tracer(ctx, nil).Start(ctx, "response.repair")
+	response, err := f.RepairModel.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Rewrite the answer you are given as JSON matching this JSON schema:\n"+
+			string(schema)+"\nKeep its content. Reply with the JSON only."),
+		llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf("Answer:\n%s\n\nProblem: %v", answer, problem)),
+	})
+	if err == nil && len(response.Choices) == 0 {
+		err = fmt.Errorf("model returned no choices")
+	}
+	endSpan(span, err)
+	if err != nil {
+		return "", fmt.Errorf("repair failed: %w", err)
+	}
+	recordUsage(ctx, response)
+	return response.Choices[0].Content, nil
+}
+
+// lastAnswer returns the index and text of the last AI message with text,
+// or -1.
+func lastAnswer(messages []llms.MessageContent) (int, string) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != llms.ChatMessageTypeAI {
+			continue
+		}
+		var text strings.Builder
+		for _, part := range messages[i].Parts {
+			if t, ok := part.(llms.TextContent); ok {
+				text.WriteString(t.Text)
+			}
+		}
+		if text.Len() > 0 {
+			return i, text.String()
+		}
+	}
+	return -1, ""
+}
+
+// extractJSON returns the JSON of an answer: the answer itself, the content
+// of a fenced code block, or the text from the first '{' or '[' to the last
+// '}' or ']'.
+func extractJSON(answer string) string {
+	text := strings.TrimSpace(answer)
+	if json.Valid([]byte(text)) {
+		return text
+	}
+	if start := strings.Index(text, "```"); start >= 0 {
+		block := text[start+3:]
+		if newline := strings.IndexByte(block, '\n'); newline >= 0 {
+			block = block[newline+1:]
+		}
+		if end := strings.Index(block, "```"); end >= 0 {
+			return strings.TrimSpace(block[:end])
+		}
+	}
+	start := strings.IndexAny(text, "{[")
+	end := strings.LastIndexAny(text, "}]")
+	if start >= 0 && end > start {
+		return text[start : end+1]
+	}
+	return text
+}
+
+// validateSchema checks value, decoded with json.Decoder.UseNumber, against
+// a JSON schema. path locates value in the answer for error messages.
+func validateSchema(schema map[string]any, value any, path string) error {
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool {
+		return hasType(value, t)
+	}) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonType(value))
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if !slices.ContainsFunc(toSlice(enum), func(allowed any) bool { return sameJSON(allowed, value) }) {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+
+	if number, ok := value.(json.Number); ok {
+		n, _ := number.Float64()
+		if minimum, ok := toFloat(schema["minimum"]); ok && n < minimum {
+			return fmt.Errorf("%s: %v is less than the minimum %v", path, number, minimum)
+		}
+		if maximum, ok := toFloat(schema["maximum"]); ok && n > maximum {
+			return fmt.Errorf("%s: %v is greater than the maximum %v", path, number, maximum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range toSlice(schema["required"]) {
+			if _, ok := v[fmt.Sprint(name)]; !ok {
+				return fmt.Errorf("%s: missing required property '%v'", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			if property, ok := properties[name].(map[string]any); ok {
+				if err := validateSchema(property, v[name], path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: unexpected property '%s'", path, name)
+				}
+			case map[string]any:
+				if err := validateSchema(additional, v[name], path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the types allowed by the type keyword of a schema.
+func schemaTypes(t any) []string {
+	var types []string
+	for _, item := range toSlice(t) {
+		types = append(types, fmt.Sprint(item))
+	}
+	return types
+}
+
+// hasType reports whether a decoded JSON value has a JSON schema type.
+func hasType(value any, t string) bool {
+	switch t {
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		n, err := number.Float64()
+		return err == nil && n == math.Trunc(n)
+	default:
+		return jsonType(value) == t
+	}
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value.
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// toSlice returns the elements of a schema keyword holding a list, or the
+// keyword itself as a single element.
+func toSlice(v any) []any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []any{v}
+	}
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// toFloat converts a numeric schema keyword.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case nil:
+		return 0, false
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// sameJSON reports whether a and b encode to the same JSON.
+func sameJSON(a, b any) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
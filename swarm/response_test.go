@@ -0,0 +1,141 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+type ticket struct {
+	Category string   `json:"category" jsonschema:"enum=billing,enum=technical"`
+	Priority int      `json:"priority" jsonschema:"minimum=1,maximum=3"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// answerSwarm compiles a swarm whose single agent answers with text.
+func answerSwarm(t *testing.T, text string, format *ResponseFormat) *CompiledSwarm {
+	t.Helper()
+	return compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", text)}},
+		DefaultActiveAgent: "Alice",
+		ResponseFormat:     format,
+	})
+}
+
+func TestResponseFormat(t *testing.T) {
+	app := answerSwarm(t, "Here you go:\n```json\n{\"category\": \"billing\", \"priority\": 2}\n```", ResponseFormatFor[ticket]())
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "I was charged twice")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	last := result.Messages[len(result.Messages)-1]
+	if got := last.Parts[0].(llms.TextContent).Text; got != `{"category": "billing", "priority": 2}` {
+		t.Errorf("final answer = %q, want the JSON only", got)
+	}
+
+	var got ticket
+	if err := DecodeResponse(result, &got); err != nil {
+		t.Fatalf("DecodeResponse() error = %v", err)
+	}
+	if got.Category != "billing" || got.Priority != 2 {
+		t.Errorf("DecodeResponse() = %+v", got)
+	}
+}
+
+func TestResponseFormatInvalid(t *testing.T) {
+	app := answerSwarm(t, `{"category": "sales", "priority": 2}`, ResponseFormatFor[ticket]())
+	_, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	})
+	if !errors.Is(err, ErrInvalidResponse) || !strings.Contains(err.Error(), "$.category") {
+		t.Errorf("Invoke() error = %v, want ErrInvalidResponse about $.category", err)
+	}
+}
+
+func TestResponseFormatRepair(t *testing.T) {
+	repairModel := &scriptedModel{responses: []*llms.ContentChoice{
+		{Content: "still not JSON"},
+		{Content: `{"category": "technical", "priority": 1}`},
+	}}
+	format := ResponseFormatFor[ticket]()
+	format.RepairModel = repairModel
+	format.MaxRepairs = 2
+	app := answerSwarm(t, "It is a technical issue, low priority.", format)
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "My app crashes")},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(repairModel.calls) != 2 {
+		t.Fatalf("repair calls = %d, want 2", len(repairModel.calls))
+	}
+	if prompt := repairModel.calls[0][1].Parts[0].(llms.TextContent).Text; !strings.Contains(prompt, "It is a technical issue") {
+		t.Errorf("repair prompt = %q, want the answer", prompt)
+	}
+	var got ticket
+	if err := DecodeResponse(result, &got); err != nil || got.Category != "technical" {
+		t.Errorf("DecodeResponse() = %+v, %v", got, err)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := ResponseFormatFor[ticket]().Schema
+	closed := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"n": map[string]any{"type": "integer"}},
+		"additionalProperties": false,
+	}
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		answer  string
+		wantErr string
+	}{
+		{"valid", schema, `{"category": "technical", "priority": 3, "tags": ["a"]}`, ""},
+		{"not an object", schema, `["billing"]`, "expected object, got array"},
+		{"missing required", schema, `{"category": "billing"}`, "missing required property 'priority'"},
+		{"not in enum", schema, `{"category": "other", "priority": 1}`, "$.category"},
+		{"below minimum", schema, `{"category": "billing", "priority": 0}`, "less than the minimum"},
+		{"above maximum", schema, `{"category": "billing", "priority": 4}`, "greater than the maximum"},
+		{"not an integer", schema, `{"category": "billing", "priority": 1.5}`, "expected integer"},
+		{"wrong item", schema, `{"category": "billing", "priority": 1, "tags": [1]}`, "$.tags[0]"},
+		{"additional property", closed, `{"n": 1, "m": 2}`, "unexpected property 'm'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(tt.answer))
+			decoder.UseNumber()
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				t.Fatal(err)
+			}
+			err := validateSchema(tt.schema, value, "$")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("validateSchema() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("validateSchema() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResponseFormatWithoutSchema(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")}},
+		DefaultActiveAgent: "Alice",
+		ResponseFormat:     &ResponseFormat{},
+	})
+	if err == nil {
+		t.Error("CreateSwarm() should reject a response format without schema")
+	}
+}
@@ -0,0 +1,169 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Generation info keys under which providers report token counts.
+var (
+	inputTokenKeys  = []string{"PromptTokens", "InputTokens"}
+	outputTokenKeys = []string{"CompletionTokens", "OutputTokens"}
+)
+
+// Usage counts the model calls of a run and the tokens they used. Only
+// models called by the swarm itself are counted: ReactAgent models, the
+// LLMRouter and the ResponseFormat repair model.
+type Usage struct {
+	ModelCalls   int `json:"model_calls"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// TotalTokens returns the input and output tokens together.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// InvokeResult is the outcome of CompiledSwarm.InvokeWithResult.
+type InvokeResult struct {
+	// State is the resulting state, as returned by Invoke
+	State SwarmState
+	// NewMessages are the messages the run added to the input state
+	NewMessages []llms.MessageContent
+	// FinalAgent is the agent that will handle the next turn
+	FinalAgent string
+	// Handoffs are the handoffs made during the run
+	Handoffs []HandoffRecord
+	// Usage counts the model calls of the run
+	Usage Usage
+	// Truncated is the reason the run was stopped before it finished, such
+	// as a *BudgetExceededError, or nil when it ran to completion. A
+	// truncated run ends with a message telling the user so.
+	Truncated error
+
+	trace *Trace
+}
+
+// Trace returns the structured record of the run: its agent runs with
+// their tool calls, handoffs, durations and token usage.
+//
+// Example:
+//
+//	data, err := json.Marshal(result.Trace())
+func (r *InvokeResult) Trace() *Trace {
+	return r.trace
+}
+
+// InvokeWithResult runs the swarm like Invoke, and tells what the run did
+// apart from the input state: the messages and handoffs it added, the
+// agent it ended with and the tokens it used, with a Trace of each agent
+// run. When Invoke would return an *InterruptError, the result describes the
+// run up to the interruption.
+//
+// Example:
+//
+//	result, err := app.InvokeWithResult(ctx, state)
+//	for _, message := range result.NewMessages {
+//	    fmt.Println(message.Role, message.Parts)
+//	}
+func (c *CompiledSwarm) InvokeWithResult(ctx context.Context, state SwarmState, opts ...InvokeOption) (*InvokeResult, error) {
+	ctx, usage := withUsage(ctx)
+	final, trace, err := c.invoke(ctx, state, opts...)
+	var interrupt *InterruptError
+	if err != nil && !errors.As(err, &interrupt) {
+		return nil, err
+	}
+
+	result := &InvokeResult{State: final, FinalAgent: final.ActiveAgent, Usage: usage.total(), Truncated: trace.truncated}
+	// The trace only counts the agents' own model calls
+	result.trace = trace
+	result.trace.Usage = result.Usage
+	if result.FinalAgent == "" {
+		result.FinalAgent = c.Swarm().DefaultActiveAgent()
+	}
+	// Reducers may rewrite the conversation; only an extended one has new
+	// messages to tell apart
+	if len(final.Messages) > len(state.Messages) {
+		result.NewMessages = final.Messages[len(state.Messages):]
+	}
+	if len(final.Handoffs) > len(state.Handoffs) {
+		result.Handoffs = final.Handoffs[len(state.Handoffs):]
+	}
+	return result, err
+}
+
+// usageKey is the context key of the run's usageCounter.
+type usageKey struct{}
+
+// usageCounter sums the usage of the model calls of a run.
+type usageCounter struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// withUsage returns a context counting the usage of model calls in the
+// returned counter.
+func withUsage(ctx context.Context) (context.Context, *usageCounter) {
+	counter := &usageCounter{}
+	return context.WithValue(ctx, usageKey{}, counter), counter
+}
+
+// recordUsage adds a model response to the usage counted in ctx and to the
+// traced agent run ctx belongs to, if any.
+func recordUsage(ctx context.Context, response *llms.ContentResponse) {
+	if response == nil {
+		return
+	}
+	usage := responseUsage(response)
+	traceStepFromContext(ctx).update(func(step *TraceStep) {
+		step.Usage = step.Usage.add(usage)
+	})
+	recordBudget(ctx, AgentNameFromContext(ctx), usage)
+	counter, ok := ctx.Value(usageKey{}).(*usageCounter)
+	if !ok {
+		return
+	}
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	counter.usage = counter.usage.add(usage)
+}
+
+// responseUsage returns the usage of a model response.
+func responseUsage(response *llms.ContentResponse) Usage {
+	usage := Usage{ModelCalls: 1}
+	for _, choice := range response.Choices {
+		usage.InputTokens += tokenCount(choice.GenerationInfo, inputTokenKeys)
+		usage.OutputTokens += tokenCount(choice.GenerationInfo, outputTokenKeys)
+	}
+	return usage
+}
+
+// add returns the sum of u and other.
+func (u Usage) add(other Usage) Usage {
+	return Usage{
+		ModelCalls:   u.ModelCalls + other.ModelCalls,
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+	}
+}
+
+// total returns the usage counted so far.
+func (c *usageCounter) total() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// tokenCount returns the first token count of info found under keys, or 0.
+func tokenCount(info map[string]any, keys []string) int {
+	for _, key := range keys {
+		if n, ok := info[key].(int); ok {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestInvokeWithResult(t *testing.T) {
+	handoff := toolCallChoice("call_1", "transfer_to_bob", "{}")
+	handoff.GenerationInfo = map[string]any{"PromptTokens": 10, "CompletionTokens": 3}
+	model := &scriptedModel{responses: []*llms.ContentChoice{handoff}}
+	alice, err := CreateReactAgent(model, []tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	state := SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Earlier"),
+		llms.TextParts(llms.ChatMessageTypeAI, "Earlier answer"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Get Bob"),
+	}}
+	result, err := app.InvokeWithResult(context.Background(), state)
+	if err != nil {
+		t.Fatalf("InvokeWithResult() error = %v", err)
+	}
+	// Alice's tool call, the handoff tool result and Bob's answer
+	if len(result.NewMessages) != 3 || lastText(result.State) != "Ahoy" {
+		t.Errorf("new messages = %v", result.NewMessages)
+	}
+	if result.FinalAgent != "Bob" || len(result.Handoffs) != 1 || result.Handoffs[0].To != "Bob" {
+		t.Errorf("final agent = %q, handoffs = %+v", result.FinalAgent, result.Handoffs)
+	}
+	want := Usage{ModelCalls: 1, InputTokens: 10, OutputTokens: 3}
+	if result.Usage != want || result.Usage.TotalTokens() != 13 {
+		t.Errorf("usage = %+v, want %+v", result.Usage, want)
+	}
+
+	// The next turn only reports its own handoffs
+	next := result.State
+	next.Messages = append(next.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Thanks"))
+	result, err = app.InvokeWithResult(context.Background(), next)
+	if err != nil {
+		t.Fatalf("InvokeWithResult() error = %v", err)
+	}
+	if len(result.NewMessages) != 1 || len(result.Handoffs) != 0 || result.Usage.ModelCalls != 0 {
+		t.Errorf("second result = %+v", result)
+	}
+}
@@ -0,0 +1,161 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// Defaults of retrieval tools.
+const (
+	defaultRetrievalToolName        = "search_documents"
+	defaultRetrievalToolDescription = "Search the knowledge base for passages relevant to a query."
+)
+
+// retrievedContextHeader introduces the documents WithRetrieval adds to the
+// system prompt.
+const retrievedContextHeader = "Use the following context to answer, if relevant:"
+
+// RetrievalOption configures retrieval from a vector store, by
+// NewRetrievalTool or WithRetrieval.
+type RetrievalOption func(*retrievalOptions)
+
+// retrievalOptions holds the settings applied by RetrievalOption values.
+type retrievalOptions struct {
+	name          string
+	description   string
+	searchOptions []vectorstores.Option
+}
+
+// WithRetrievalTool names and describes the tool created by
+// NewRetrievalTool (default "search_documents"). The description tells the
+// model what the documents are about.
+func WithRetrievalTool(name, description string) RetrievalOption {
+	return func(o *retrievalOptions) {
+		o.name = name
+		o.description = description
+	}
+}
+
+// WithSearchOptions passes options to the searches of the vector store,
+// such as vectorstores.WithScoreThreshold or vectorstores.WithFilters.
+func WithSearchOptions(opts ...vectorstores.Option) RetrievalOption {
+	return func(o *retrievalOptions) {
+		o.searchOptions = append(o.searchOptions, opts...)
+	}
+}
+
+// retriever searches a vector store for the k documents most similar to a
+// query.
+type retriever struct {
+	store   vectorstores.VectorStore
+	k       int
+	options retrievalOptions
+}
+
+// newRetriever returns a retriever with opts applied.
+func newRetriever(store vectorstores.VectorStore, k int, opts []RetrievalOption) *retriever {
+	r := &retriever{
+		store:   store,
+		k:       k,
+		options: retrievalOptions{name: defaultRetrievalToolName, description: defaultRetrievalToolDescription},
+	}
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	return r
+}
+
+// search returns the documents relevant to query, formatted for a model,
+// or "" when there are none.
+func (r *retriever) search(ctx context.Context, query string) (string, error) {
+	docs, err := r.store.SimilaritySearch(ctx, query, r.k, r.options.searchOptions...)
+	if err != nil {
+		return "", fmt.Errorf("retrieve documents: %w", err)
+	}
+	return formatDocuments(docs), nil
+}
+
+// formatDocuments numbers docs and appends their source, if known.
+func formatDocuments(docs []schema.Document) string {
+	var b strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%d] %s", i+1, strings.TrimSpace(doc.PageContent))
+		if source, ok := doc.Metadata["source"]; ok {
+			fmt.Fprintf(&b, "\nSource: %v", source)
+		}
+	}
+	return b.String()
+}
+
+// retrievalArgs are the arguments of a retrieval tool.
+type retrievalArgs struct {
+	Query string `json:"query" jsonschema:"description=What to search for"`
+}
+
+// NewRetrievalTool wraps a langchaingo vector store as a tool returning the
+// k documents most similar to the model's query, numbered and with their
+// "source" metadata.
+//
+// Example:
+//
+//	search := swarm.NewRetrievalTool(store, 4,
+//	    swarm.WithRetrievalTool("search_policies", "Search the travel policies."))
+//	agent, err := swarm.CreateReactAgent(model, []tools.Tool{search})
+func NewRetrievalTool(store vectorstores.VectorStore, k int, opts ...RetrievalOption) tools.Tool {
+	r := newRetriever(store, k, opts)
+	return NewStructTool(r.options.name, r.options.description, func(ctx context.Context, args retrievalArgs) (string, error) {
+		result, err := r.search(ctx, args.Query)
+		if err != nil {
+			return "", err
+		}
+		if result == "" {
+			return "No relevant documents found.", nil
+		}
+		return result, nil
+	})
+}
+
+// WithRetrieval makes the agent a RAG agent: before each model call, the k
+// documents of store most similar to the last user message are added to the
+// system prompt (which is created if the agent has none). Unlike
+// NewRetrievalTool, the model gets the context without asking for it.
+func WithRetrieval(store vectorstores.VectorStore, k int, opts ...RetrievalOption) AgentOption {
+	return func(o *agentOptions) {
+		o.retriever = newRetriever(store, k, opts)
+	}
+}
+
+// inject adds the documents relevant to the last user message of messages
+// to their system prompt.
+func (r *retriever) inject(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	query := lastHumanText(messages)
+	if query == "" {
+		return messages, nil
+	}
+	documents, err := r.search(ctx, query)
+	if err != nil || documents == "" {
+		return messages, err
+	}
+
+	retrieved := retrievedContextHeader + "\n\n" + documents
+	if len(messages) > 0 && messages[0].Role == llms.ChatMessageTypeSystem {
+		var prompt strings.Builder
+		for _, part := range messages[0].Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				prompt.WriteString(text.Text)
+			}
+		}
+		system := llms.TextParts(llms.ChatMessageTypeSystem, prompt.String()+"\n\n"+retrieved)
+		return append([]llms.MessageContent{system}, messages[1:]...), nil
+	}
+	return append([]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, retrieved)}, messages...), nil
+}
@@ -0,0 +1,112 @@
+package swarm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// fakeVectorStore returns its documents for any query and records the
+// searches.
+type fakeVectorStore struct {
+	docs    []schema.Document
+	queries []string
+	options vectorstores.Options
+}
+
+func (s *fakeVectorStore) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) {
+	s.docs = append(s.docs, docs...)
+	return nil, nil
+}
+
+func (s *fakeVectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, options ...vectorstores.Option) ([]schema.Document, error) {
+	s.queries = append(s.queries, query)
+	for _, opt := range options {
+		opt(&s.options)
+	}
+	return s.docs[:min(numDocuments, len(s.docs))], nil
+}
+
+func policyStore() *fakeVectorStore {
+	return &fakeVectorStore{docs: []schema.Document{
+		{PageContent: "Refunds are issued within 14 days.", Metadata: map[string]any{"source": "refunds.md"}},
+		{PageContent: "Changes cost $50."},
+		{PageContent: "Pets fly in the cabin."},
+	}}
+}
+
+func TestNewRetrievalTool(t *testing.T) {
+	store := policyStore()
+	tool := NewRetrievalTool(store, 2,
+		WithRetrievalTool("search_policies", "Search the travel policies."),
+		WithSearchOptions(vectorstores.WithScoreThreshold(0.5)))
+	if tool.Name() != "search_policies" || tool.Description() != "Search the travel policies." {
+		t.Errorf("tool = %s: %s", tool.Name(), tool.Description())
+	}
+	if definition := ToolDefinition(tool); definition.Function.Parameters.(map[string]any)["required"].([]string)[0] != "query" {
+		t.Errorf("parameters = %v", definition.Function.Parameters)
+	}
+
+	result, err := tool.Call(context.Background(), `{"query":"refund delay"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[1] Refunds are issued within 14 days.\nSource: refunds.md\n\n[2] Changes cost $50."
+	if result != want {
+		t.Errorf("Call() = %q, want %q", result, want)
+	}
+	if store.queries[0] != "refund delay" || store.options.ScoreThreshold != 0.5 {
+		t.Errorf("search = %q with %+v", store.queries[0], store.options)
+	}
+
+	empty := NewRetrievalTool(&fakeVectorStore{}, 2)
+	if result, _ := empty.Call(context.Background(), `{"query":"refund"}`); result != "No relevant documents found." {
+		t.Errorf("Call() without documents = %q", result)
+	}
+}
+
+func TestWithRetrieval(t *testing.T) {
+	store := policyStore()
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "upper", `{"input":"x"}`),
+		{Content: "Within 14 days."},
+	}}
+	agent, err := CreateReactAgent(model, []tools.Tool{upperTool{}},
+		WithSystemPrompt("You are a travel agent."),
+		WithRetrieval(store, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = agent.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "When do I get my refund?"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.queries) != 2 || store.queries[1] != "When do I get my refund?" {
+		t.Errorf("queries = %q, want one per model call", store.queries)
+	}
+	for _, call := range model.calls {
+		system := call[0].Parts[0].(llms.TextContent).Text
+		if !strings.HasPrefix(system, "You are a travel agent.\n\n") || !strings.Contains(system, "[1] Refunds are issued within 14 days.") ||
+			strings.Contains(system, "Changes cost") {
+			t.Errorf("system prompt = %q", system)
+		}
+	}
+
+	// Without a system prompt, one is created
+	model = &scriptedModel{responses: []*llms.ContentChoice{{Content: "Within 14 days."}}}
+	agent, _ = CreateReactAgent(model, nil, WithRetrieval(store, 1))
+	_, _ = agent.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Refund?"),
+	}})
+	if call := model.calls[0]; len(call) != 2 || call[0].Role != llms.ChatMessageTypeSystem {
+		t.Errorf("messages = %+v", call)
+	}
+}
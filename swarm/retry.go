@@ -0,0 +1,92 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy retries an agent whose run fails, e.g. with a transient model
+// error, instead of failing the whole invocation. Each attempt runs the
+// agent on the state it was first invoked with.
+//
+// Example:
+//
+//	swarm.Agent{
+//	    Name:        "Alice",
+//	    Runnable:    alice,
+//	    RetryPolicy: swarm.RetryPolicy{MaxAttempts: 3},
+//	}
+type RetryPolicy struct {
+	// MaxAttempts is the number of runs including the first (0 or 1: no retries)
+	MaxAttempts int
+	// Backoff returns the delay before the given retry, starting at 1
+	// (default: ExponentialBackoff(500*time.Millisecond, 30*time.Second))
+	Backoff func(retry int) time.Duration
+	// RetryOn reports whether a failed run should be retried (default: all
+	// errors except interrupts, limits and cancellation)
+	RetryOn func(err error) bool
+}
+
+// ExponentialBackoff returns a backoff that doubles the delay on every
+// retry, starting at initial and capped at maxDelay, with up to 20% of jitter.
+func ExponentialBackoff(initial, maxDelay time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		delay := initial
+		for i := 1; i < retry && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, maxDelay)
+		if jitter := int64(delay) / 5; jitter > 0 {
+			delay -= time.Duration(rand.Int64N(jitter))
+		}
+		return delay
+	}
+}
+
+// defaultBackoff is the backoff used when RetryPolicy.Backoff is nil.
+var defaultBackoff = ExponentialBackoff(500*time.Millisecond, 30*time.Second)
+
+// retryable reports whether err may be retried under policy p.
+func (p RetryPolicy) retryable(err error) bool {
+	switch {
+	case errors.Is(err, ErrInterrupted),
+		errors.Is(err, ErrHandoffLimitExceeded),
+		errors.Is(err, ErrRecursionLimit),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	case p.RetryOn != nil:
+		return p.RetryOn(err)
+	default:
+		return true
+	}
+}
+
+// retry calls run until it succeeds, the policy gives up or ctx is done.
+// onRetry is called before each retry with the retry number, the delay and
+// the error of the failed attempt.
+func (p RetryPolicy) retry(ctx context.Context, run func() (SwarmState, error), onRetry func(retry int, delay time.Duration, err error)) (SwarmState, error) {
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = defaultBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := run()
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+			return result, err
+		}
+
+		delay := backoff(attempt)
+		onRetry(attempt, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result, errors.Join(err, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// flakyAgent fails its first failures runs with err.
+func flakyAgent(failures int, err error, calls *int) invokerFunc {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		*calls++
+		if *calls <= failures {
+			return state, err
+		}
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Hi"))
+		return state, nil
+	}
+}
+
+// noBackoff retries immediately.
+func noBackoff(int) time.Duration { return 0 }
+
+func TestRetryPolicy(t *testing.T) {
+	errUnavailable := errors.New("model unavailable")
+	input := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}}
+
+	t.Run("retries until success", func(t *testing.T) {
+		calls := 0
+		bus := events.NewBus()
+		var retries []events.AgentRetried
+		bus.Subscribe(func(ctx context.Context, e events.Event) {
+			retries = append(retries, e.(events.AgentRetried))
+		}, events.TypeAgentRetried)
+
+		app := compileSwarm(t, SwarmConfig{
+			Agents: []Agent{{
+				Name:        "Alice",
+				Runnable:    flakyAgent(2, errUnavailable, &calls),
+				RetryPolicy: RetryPolicy{MaxAttempts: 3, Backoff: noBackoff},
+			}},
+			DefaultActiveAgent: "Alice",
+			Events:             bus,
+		})
+		result, err := app.Invoke(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		if calls != 3 || len(result.Messages) != 2 {
+			t.Errorf("Expected 3 attempts and one reply, got %d attempts and %d messages", calls, len(result.Messages))
+		}
+		if len(retries) != 2 || retries[1].Attempt != 2 || !errors.Is(retries[1].Err, errUnavailable) {
+			t.Errorf("Unexpected retry events %+v", retries)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		app := compileSwarm(t, SwarmConfig{
+			Agents: []Agent{{
+				Name:        "Alice",
+				Runnable:    flakyAgent(5, errUnavailable, &calls),
+				RetryPolicy: RetryPolicy{MaxAttempts: 3, Backoff: noBackoff},
+			}},
+			DefaultActiveAgent: "Alice",
+		})
+		if _, err := app.Invoke(context.Background(), input); !errors.Is(err, errUnavailable) || calls != 3 {
+			t.Errorf("Invoke() error = %v after %d attempts", err, calls)
+		}
+	})
+
+	t.Run("RetryOn", func(t *testing.T) {
+		calls := 0
+		app := compileSwarm(t, SwarmConfig{
+			Agents: []Agent{{
+				Name:     "Alice",
+				Runnable: flakyAgent(1, errUnavailable, &calls),
+				RetryPolicy: RetryPolicy{MaxAttempts: 3, Backoff: noBackoff, RetryOn: func(err error) bool {
+					return !errors.Is(err, errUnavailable)
+				}},
+			}},
+			DefaultActiveAgent: "Alice",
+		})
+		if _, err := app.Invoke(context.Background(), input); !errors.Is(err, errUnavailable) || calls != 1 {
+			t.Errorf("Invoke() error = %v after %d attempts", err, calls)
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		calls := 0
+		app := compileSwarm(t, SwarmConfig{
+			Agents: []Agent{{
+				Name:        "Alice",
+				Runnable:    flakyAgent(1, errUnavailable, &calls),
+				RetryPolicy: RetryPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return time.Hour }},
+			}},
+			DefaultActiveAgent: "Alice",
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := app.Invoke(ctx, input); !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+			t.Errorf("Invoke() error = %v after %d attempts", err, calls)
+		}
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second)
+	for retry, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		if got := backoff(retry); got > want || got < want*4/5 {
+			t.Errorf("backoff(%d) = %v, want about %v", retry, got, want)
+		}
+	}
+}
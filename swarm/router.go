@@ -0,0 +1,66 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Router selects the agent that starts a turn. Set it in SwarmConfig.Router
+// to route by keywords, business rules or experiments; without one, a turn
+// starts with the state's ActiveAgent, or DefaultActiveAgent when none is
+// set.
+type Router interface {
+	// Route returns the name of the agent to run, or "" to keep the state's
+	// ActiveAgent (or the swarm's DefaultActiveAgent when none is set).
+	Route(ctx context.Context, state SwarmState) (string, error)
+}
+
+// RouterFunc adapts a function to the Router interface.
+//
+// Example:
+//
+//	router := swarm.RouterFunc(func(ctx context.Context, state swarm.SwarmState) (string, error) {
+//	    if state.Values["plan"] == "enterprise" {
+//	        return "PrioritySupport", nil
+//	    }
+//	    return "", nil
+//	})
+type RouterFunc func(ctx context.Context, state SwarmState) (string, error)
+
+// Route calls f.
+func (f RouterFunc) Route(ctx context.Context, state SwarmState) (string, error) {
+	return f(ctx, state)
+}
+
+// KeepActiveAgent returns a Router that keeps the state's ActiveAgent and
+// asks next only when no agent is active, so that a conversation stays with
+// the agent it was handed off to.
+//
+// Example:
+//
+//	Router: swarm.KeepActiveAgent(swarm.RouterFunc(abTest)),
+func KeepActiveAgent(next Router) Router {
+	return RouterFunc(func(ctx context.Context, state SwarmState) (string, error) {
+		if state.ActiveAgent != "" {
+			return state.ActiveAgent, nil
+		}
+		return next.Route(ctx, state)
+	})
+}
+
+// applyRouter sets state.ActiveAgent to the agent selected by router.
+func applyRouter(ctx context.Context, router Router, agentNames []string, state SwarmState) (SwarmState, error) {
+	name, err := router.Route(ctx, state)
+	if err != nil {
+		return state, fmt.Errorf("route: %w", err)
+	}
+	if name == "" {
+		return state, nil
+	}
+	if !slices.Contains(agentNames, name) {
+		return state, fmt.Errorf("route: %w '%s'", ErrUnknownAgent, name)
+	}
+	state.ActiveAgent = name
+	return state, nil
+}
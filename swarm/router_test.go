@@ -0,0 +1,69 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestRouter(t *testing.T) {
+	keywords := RouterFunc(func(ctx context.Context, state SwarmState) (string, error) {
+		switch text := lastHumanText(state.Messages); {
+		case strings.Contains(text, "refund"):
+			return "Refunds", nil
+		case strings.Contains(text, "upgrade"):
+			return "Upgrades", nil
+		case strings.Contains(text, "fail"):
+			return "", errors.New("rules unavailable")
+		}
+		return "", nil
+	})
+
+	tests := []struct {
+		name    string
+		router  Router
+		active  string
+		message string
+		reply   string
+		wantErr bool
+	}{
+		{name: "keyword", router: keywords, message: "I want a refund", reply: "Refund issued"},
+		{name: "no match", router: keywords, message: "Hello", reply: "How can I help?"},
+		{name: "router overrides active agent", router: keywords, active: "Support", message: "refund please", reply: "Refund issued"},
+		{name: "kept active agent", router: KeepActiveAgent(keywords), active: "Support", message: "refund please", reply: "How can I help?"},
+		{name: "unknown agent", router: keywords, message: "upgrade me", wantErr: true},
+		{name: "router error", router: keywords, message: "fail", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := compileSwarm(t, SwarmConfig{
+				Agents: []Agent{
+					{Name: "Support", Runnable: createMockAgent("Support", "How can I help?")},
+					{Name: "Refunds", Runnable: createMockAgent("Refunds", "Refund issued")},
+				},
+				DefaultActiveAgent: "Support",
+				Router:             tt.router,
+			})
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, tt.message)},
+				ActiveAgent: tt.active,
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			last := result.Messages[len(result.Messages)-1]
+			if got := last.Parts[0].(llms.TextContent).Text; got != tt.reply {
+				t.Errorf("Reply = %q, want %q", got, tt.reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRecursionLimit is matched (with errors.Is) by the error returned when a
+// run exceeds its recursion limit (see WithRecursionLimit).
+var ErrRecursionLimit = errors.New("recursion limit reached")
+
+// DefaultRecursionLimit is the maximum number of agent runs of an
+// invocation when neither WithRecursionLimit nor SwarmConfig.RecursionLimit
+// sets one.
+const DefaultRecursionLimit = 25
+
+// RecursionLimitError is the error returned when a run exceeds its
+// recursion limit. It matches ErrRecursionLimit.
+type RecursionLimitError struct {
+	// Limit is the limit that was exceeded
+	Limit int
+	// Trace lists the agents run, in order, including the one that exceeded
+	// the limit
+	Trace []string
+}
+
+// Error describes the exceeded limit and the agents run.
+func (e *RecursionLimitError) Error() string {
+	return fmt.Sprintf("%v: more than %d agent runs: %s", ErrRecursionLimit, e.Limit, strings.Join(e.Trace, " -> "))
+}
+
+// Is reports whether target is ErrRecursionLimit.
+func (e *RecursionLimitError) Is(target error) bool {
+	return target == ErrRecursionLimit
+}
+
+// RunConfig holds the parameters of a single invocation, set with
+// InvokeOption values. Agents and tools can read it with
+// RunConfigFromContext.
+type RunConfig struct {
+	// ThreadID is the conversation thread the run's state is saved to
+	ThreadID string
+	// RecursionLimit is the maximum number of agent runs in the invocation
+	// (0: SwarmConfig.RecursionLimit; negative: no limit)
+	RecursionLimit int
+	// Metadata is application data describing the run, such as a request ID.
+	// It is stored with the run's checkpoints.
+	Metadata map[string]any
+	// Context is the run context passed with WithContext
+	Context any
+	// Deadline is the time the run may take before it stops with a partial
+	// result (0: SwarmConfig.Deadline; negative: no deadline)
+	Deadline time.Duration
+	// ExperimentFlags select prompt versions of SwarmConfig.Prompts (see
+	// WithExperimentFlags)
+	ExperimentFlags []string
+}
+
+// InvokeOption configures a single invocation of a CompiledSwarm.
+type InvokeOption func(*RunConfig)
+
+// WithThreadID saves the state of the run to the swarm's Checkpointer under
+// the given conversation thread, both when the run completes and when it is
+// interrupted.
+func WithThreadID(threadID string) InvokeOption {
+	return func(c *RunConfig) {
+		c.ThreadID = threadID
+	}
+}
+
+// WithRecursionLimit fails the run with a *RecursionLimitError when more
+// than limit agent runs are needed, e.g. because agents keep handing off. It
+// overrides SwarmConfig.RecursionLimit; a negative limit disables it.
+func WithRecursionLimit(limit int) InvokeOption {
+	return func(c *RunConfig) {
+		c.RecursionLimit = limit
+	}
+}
+
+// WithMetadata attaches application data to the run. Repeated calls merge
+// their entries.
+func WithMetadata(metadata map[string]any) InvokeOption {
+	return func(c *RunConfig) {
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]any, len(metadata))
+		}
+		maps.Copy(c.Metadata, metadata)
+	}
+}
+
+// runConfigKey is the context key for the run configuration.
+type runConfigKey struct{}
+
+// run is the configuration and progress of an invocation.
+type run struct {
+	config RunConfig
+
+	// trace lists the agents run so far, for RecursionLimitError
+	traceMu sync.Mutex
+	trace   []string
+
+	// mu guards progress, the state after the last agent run that
+	// completed, kept for threaded runs to checkpoint if they are cancelled
+	mu       sync.Mutex
+	progress *SwarmState
+}
+
+// withRunConfig returns a context carrying the configuration of a new run.
+func withRunConfig(ctx context.Context, config RunConfig) context.Context {
+	return context.WithValue(ctx, runConfigKey{}, &run{config: config})
+}
+
+// RunConfigFromContext returns the configuration of the invocation ctx
+// belongs to. It reports false outside of an invocation.
+func RunConfigFromContext(ctx context.Context) (RunConfig, bool) {
+	r, ok := ctx.Value(runConfigKey{}).(*run)
+	if !ok {
+		return RunConfig{}, false
+	}
+	return r.config, true
+}
+
+// recordProgress keeps state as the progress of a threaded run.
+func recordProgress(ctx context.Context, state SwarmState) {
+	r, ok := ctx.Value(runConfigKey{}).(*run)
+	if !ok || r.config.ThreadID == "" {
+		return
+	}
+	state = state.Clone()
+	r.mu.Lock()
+	r.progress = &state
+	r.mu.Unlock()
+}
+
+// runProgress returns the state recorded by recordProgress, if any.
+func runProgress(ctx context.Context) (SwarmState, bool) {
+	r, ok := ctx.Value(runConfigKey{}).(*run)
+	if !ok {
+		return SwarmState{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.progress == nil {
+		return SwarmState{}, false
+	}
+	return *r.progress, true
+}
+
+// countStep records a run of agent and checks it against the recursion
+// limit.
+func countStep(ctx context.Context, agent string) error {
+	r, ok := ctx.Value(runConfigKey{}).(*run)
+	if !ok {
+		return nil
+	}
+	r.traceMu.Lock()
+	defer r.traceMu.Unlock()
+	r.trace = append(r.trace, agent)
+	if limit := r.config.RecursionLimit; limit > 0 && len(r.trace) > limit {
+		return &RecursionLimitError{Limit: limit, Trace: slices.Clone(r.trace)}
+	}
+	return nil
+}
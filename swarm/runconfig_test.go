@@ -0,0 +1,94 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithRecursionLimit(t *testing.T) {
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: handoffTo("Bob")},
+			{Name: "Bob", Runnable: handoffTo("Alice")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	_, err := app.Invoke(context.Background(), SwarmState{}, WithRecursionLimit(5))
+	if !errors.Is(err, ErrRecursionLimit) {
+		t.Fatalf("Invoke() error = %v, want ErrRecursionLimit", err)
+	}
+	var limitErr *RecursionLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != 5 ||
+		strings.Join(limitErr.Trace, " ") != "Alice Bob Alice Bob Alice Bob" {
+		t.Errorf("Invoke() error = %#v, want the agents run in its trace", err)
+	}
+
+	// Without a limit the default one applies
+	_, err = app.Invoke(context.Background(), SwarmState{})
+	if !errors.As(err, &limitErr) || limitErr.Limit != DefaultRecursionLimit {
+		t.Errorf("Invoke() error = %v, want the default limit", err)
+	}
+}
+
+func TestSwarmRecursionLimit(t *testing.T) {
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: handoffTo("Bob")},
+			{Name: "Bob", Runnable: handoffTo("Alice")},
+		},
+		DefaultActiveAgent: "Alice",
+		RecursionLimit:     3,
+	})
+
+	var limitErr *RecursionLimitError
+	if _, err := app.Invoke(context.Background(), SwarmState{}); !errors.As(err, &limitErr) || limitErr.Limit != 3 {
+		t.Errorf("Invoke() error = %v, want the swarm's limit", err)
+	}
+	// A run's own limit takes precedence
+	if _, err := app.Invoke(context.Background(), SwarmState{}, WithRecursionLimit(4)); !errors.As(err, &limitErr) || limitErr.Limit != 4 {
+		t.Errorf("Invoke() error = %v, want the run's limit", err)
+	}
+}
+
+func TestRunConfigFromContext(t *testing.T) {
+	var seen RunConfig
+	agent := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		seen, _ = RunConfigFromContext(ctx)
+		return state, nil
+	})
+	store := NewMemorySaver()
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: agent}},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       store,
+	})
+
+	_, err := app.Invoke(context.Background(), SwarmState{},
+		WithThreadID("thread-1"),
+		WithRecursionLimit(25),
+		WithMetadata(map[string]any{"request_id": "req-1"}),
+		WithMetadata(map[string]any{"channel": "web"}),
+	)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if seen.ThreadID != "thread-1" || seen.RecursionLimit != 25 || seen.Metadata["request_id"] != "req-1" || seen.Metadata["channel"] != "web" {
+		t.Errorf("RunConfigFromContext() = %+v", seen)
+	}
+
+	checkpoint, err := store.Latest(context.Background(), "thread-1")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if checkpoint.Metadata["request_id"] != "req-1" {
+		t.Errorf("Checkpoint metadata = %v", checkpoint.Metadata)
+	}
+
+	if _, ok := RunConfigFromContext(context.Background()); ok {
+		t.Error("RunConfigFromContext() outside a run should report false")
+	}
+}
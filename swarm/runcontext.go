@@ -0,0 +1,77 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidContext is matched (with errors.Is) by the error returned when
+// the run context passed with WithContext does not match
+// SwarmConfig.ContextSchema.
+var ErrInvalidContext = errors.New("invalid run context")
+
+// ContextValidator is implemented by run context types that check their own
+// fields, such as a required user ID. Validate is called before each run.
+type ContextValidator interface {
+	Validate() error
+}
+
+// runContextKey is the context key for the run context of an invocation.
+type runContextKey struct{}
+
+// WithContext passes a run context (user ID, tenant, locale, configuration)
+// to the agents and tools of an invocation, which read it with
+// ContextFromCtx or ContextAs. It must match SwarmConfig.ContextSchema.
+//
+// Example:
+//
+//	result, err := app.Invoke(ctx, state, swarm.WithContext(UserContext{UserID: "user_123"}))
+func WithContext(value any) InvokeOption {
+	return func(c *RunConfig) {
+		c.Context = value
+	}
+}
+
+// ContextFromCtx returns the run context passed to the invocation with
+// WithContext, or nil.
+func ContextFromCtx(ctx context.Context) any {
+	return ctx.Value(runContextKey{})
+}
+
+// ContextAs returns the run context of the invocation as a T. It reports
+// false if there is none or it is not a T.
+//
+// Example:
+//
+//	if uc, ok := swarm.ContextAs[UserContext](ctx); ok {
+//	    reservation := reservations[uc.UserID]
+//	}
+func ContextAs[T any](ctx context.Context) (T, bool) {
+	value, ok := ContextFromCtx(ctx).(T)
+	return value, ok
+}
+
+// withRunContext validates value against schema and returns a context
+// carrying it.
+func withRunContext(ctx context.Context, schema, value any) (context.Context, error) {
+	if schema != nil {
+		if value == nil {
+			return ctx, fmt.Errorf("%w: a %T is required", ErrInvalidContext, schema)
+		}
+		want, got := reflect.TypeOf(schema), reflect.TypeOf(value)
+		if got != want && !(got.Kind() == reflect.Pointer && got.Elem() == want) {
+			return ctx, fmt.Errorf("%w: got %T, want %T", ErrInvalidContext, value, schema)
+		}
+	}
+	if value == nil {
+		return ctx, nil
+	}
+	if v, ok := value.(ContextValidator); ok {
+		if err := v.Validate(); err != nil {
+			return ctx, fmt.Errorf("%w: %w", ErrInvalidContext, err)
+		}
+	}
+	return context.WithValue(ctx, runContextKey{}, value), nil
+}
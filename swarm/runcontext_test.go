@@ -0,0 +1,104 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+type userContext struct {
+	UserID string
+	Locale string
+}
+
+func (c userContext) Validate() error {
+	if c.UserID == "" {
+		return errors.New("user ID is required")
+	}
+	return nil
+}
+
+// whoAmITool answers with the user ID of the run context.
+type whoAmITool struct{}
+
+func (whoAmITool) Name() string        { return "whoami" }
+func (whoAmITool) Description() string { return "Return the current user" }
+func (whoAmITool) Call(ctx context.Context, input string) (string, error) {
+	uc, ok := ContextAs[userContext](ctx)
+	if !ok {
+		return "", errors.New("no user context")
+	}
+	return uc.UserID, nil
+}
+
+func TestRunContext(t *testing.T) {
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "whoami", `{}`),
+		{Content: "You are user_123"},
+	}}
+	alice, err := CreateReactAgent(model, []tools.Tool{whoAmITool{}})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	var seen any
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:     "Alice",
+			Runnable: alice,
+			Prompt: func(ctx context.Context, state SwarmState) (string, error) {
+				seen = ContextFromCtx(ctx)
+				return "", nil
+			},
+		}},
+		DefaultActiveAgent: "Alice",
+		ContextSchema:      userContext{},
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Who am I?")},
+	}, WithContext(userContext{UserID: "user_123", Locale: "en"}))
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if seen != (userContext{UserID: "user_123", Locale: "en"}) {
+		t.Errorf("Agent saw run context %v", seen)
+	}
+	if got := result.Messages[2].Parts[0].(llms.ToolCallResponse).Content; got != "user_123" {
+		t.Errorf("Tool saw user %q, want user_123", got)
+	}
+}
+
+func TestRunContextValidation(t *testing.T) {
+	agent := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		return state, nil
+	})
+	app := compileSwarm(t, SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: agent}},
+		DefaultActiveAgent: "Alice",
+		ContextSchema:      userContext{},
+	})
+
+	tests := []struct {
+		name    string
+		opts    []InvokeOption
+		wantErr bool
+	}{
+		{name: "missing", wantErr: true},
+		{name: "wrong type", opts: []InvokeOption{WithContext("user_123")}, wantErr: true},
+		{name: "fails validation", opts: []InvokeOption{WithContext(userContext{})}, wantErr: true},
+		{name: "value", opts: []InvokeOption{WithContext(userContext{UserID: "u"})}},
+		{name: "pointer", opts: []InvokeOption{WithContext(&userContext{UserID: "u"})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := app.Invoke(context.Background(), SwarmState{}, tt.opts...)
+			if tt.wantErr != errors.Is(err, ErrInvalidContext) {
+				t.Errorf("Invoke() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
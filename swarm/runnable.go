@@ -0,0 +1,228 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// AgentRunnable runs an agent: it takes the swarm's state and returns the
+// agent's new state, with ActiveAgent set to the agent to hand off to, if
+// any. ReactAgent, ToolNode, RemoteAgent, *graph.StateRunnable[SwarmState]
+// and RunnableFunc implement it; AdaptRunnable adapts the other shapes of
+// runnable the swarm accepted before Agent.Runnable had a type.
+type AgentRunnable interface {
+	Invoke(ctx context.Context, state SwarmState) (SwarmState, error)
+}
+
+// RunnableFunc adapts a function, such as a graph node function, to the
+// AgentRunnable interface.
+//
+// Example:
+//
+//	echo := swarm.RunnableFunc(func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
+//	    state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Got it"))
+//	    return state, nil
+//	})
+type RunnableFunc func(ctx context.Context, state SwarmState) (SwarmState, error)
+
+// Invoke calls f.
+func (f RunnableFunc) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return f(ctx, state)
+}
+
+// ErrUnsupportedRunnable is matched (with errors.Is) by the error
+// AdaptRunnable returns for a runnable of a shape the swarm cannot invoke,
+// and by the error CreateSwarm returns for an agent without a runnable.
+var ErrUnsupportedRunnable = errors.New("unsupported agent runnable")
+
+// AdaptRunnable returns runnable as an AgentRunnable, or an error matching
+// ErrUnsupportedRunnable if it has none of these shapes:
+//
+//   - AgentRunnable, returned as it is
+//   - CommandRunnable, such as a CommandRunnableFunc
+//   - Invoke(context.Context, SwarmState) (any, error), returning a
+//     SwarmState, *SwarmState or map[string]any
+//   - Invoke(context.Context, map[string]any) (map[string]any, error), such
+//     as *graph.StateRunnable[map[string]any]
+//   - func(context.Context, SwarmState) (SwarmState, error), such as a graph
+//     node function
+//   - func(context.Context, map[string]any) (map[string]any, error)
+//
+// Runnables over map[string]any see the state as a map keyed like
+// SwarmConfig.Reducers: "messages", "active_agent" and the other built-in
+// fields, and the SwarmState.Values entries under their own keys.
+func AdaptRunnable(runnable any) (AgentRunnable, error) {
+	switch r := runnable.(type) {
+	case AgentRunnable:
+		return r, nil
+	case CommandRunnable:
+		return CommandRunnableFunc(r.InvokeCommand), nil
+	}
+	invoke, err := agentInvoker(runnable)
+	if err != nil {
+		return nil, err
+	}
+	return RunnableFunc(invoke), nil
+}
+
+// MustAdaptRunnable is like AdaptRunnable but panics if runnable has none of
+// the supported shapes.
+func MustAdaptRunnable(runnable any) AgentRunnable {
+	adapted, err := AdaptRunnable(runnable)
+	if err != nil {
+		panic(err)
+	}
+	return adapted
+}
+
+// agentInvoker returns the function invoking runnable with a SwarmState, or
+// an error matching ErrUnsupportedRunnable if runnable has none of the
+// shapes AdaptRunnable supports. A CommandRunnable is invoked through
+// InvokeCommand, even if it has an Invoke method.
+func agentInvoker(runnable any) (func(ctx context.Context, state SwarmState) (SwarmState, error), error) {
+	switch r := runnable.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: no runnable", ErrUnsupportedRunnable)
+	case CommandRunnable:
+		return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+			return invokeCommand(ctx, r, state)
+		}, nil
+	case AgentRunnable:
+		return r.Invoke, nil
+	case interface {
+		Invoke(context.Context, SwarmState) (any, error)
+	}:
+		return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+			result, err := r.Invoke(ctx, state)
+			if err != nil {
+				return state, err
+			}
+			return resultState(state, result)
+		}, nil
+	case interface {
+		Invoke(context.Context, map[string]any) (map[string]any, error)
+	}:
+		return mapInvoker(r.Invoke), nil
+	case func(context.Context, SwarmState) (SwarmState, error):
+		return r, nil
+	case func(context.Context, map[string]any) (map[string]any, error):
+		return mapInvoker(r), nil
+	}
+	return nil, fmt.Errorf("%w: %T has no Invoke(context.Context, SwarmState) method", ErrUnsupportedRunnable, runnable)
+}
+
+// invokeRunnable invokes an agent runnable with the given state.
+func invokeRunnable(ctx context.Context, runnable AgentRunnable, state SwarmState) (SwarmState, error) {
+	invoke, err := agentInvoker(runnable)
+	if err != nil {
+		return state, err
+	}
+	return invoke(ctx, state)
+}
+
+// mapInvoker adapts a runnable over map[string]any to SwarmState.
+func mapInvoker(invoke func(context.Context, map[string]any) (map[string]any, error)) func(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		result, err := invoke(ctx, stateToMap(state))
+		if err != nil {
+			return state, err
+		}
+		return stateFromMap(result)
+	}
+}
+
+// resultState returns the SwarmState held by the result of a runnable.
+func resultState(state SwarmState, result any) (SwarmState, error) {
+	switch r := result.(type) {
+	case SwarmState:
+		return r, nil
+	case *SwarmState:
+		if r == nil {
+			return state, nil
+		}
+		return *r, nil
+	case map[string]any:
+		return stateFromMap(r)
+	case nil:
+		return state, nil
+	}
+	return state, fmt.Errorf("runnable returned %T, want a SwarmState", result)
+}
+
+// mapKeyBlackboard is the key of SwarmState.Blackboard in the state of
+// runnables over map[string]any.
+const mapKeyBlackboard = "blackboard"
+
+// stateToMap returns the fields of state keyed like SwarmConfig.Reducers,
+// with its Values entries alongside.
+func stateToMap(state SwarmState) map[string]any {
+	m := make(map[string]any, len(state.Values)+10)
+	for key, value := range state.Values {
+		m[key] = value
+	}
+	m[ReducerKeyMessages] = state.Messages
+	m[ReducerKeyActiveAgent] = state.ActiveAgent
+	m[ReducerKeyPinnedAgent] = state.PinnedAgent
+	m[ReducerKeyHandoffPayload] = state.HandoffPayload
+	m[ReducerKeyPrivateMessages] = state.PrivateMessages
+	m[ReducerKeyHandoffs] = state.Handoffs
+	m[ReducerKeyTasks] = state.Tasks
+	m[ReducerKeyArtifacts] = state.Artifacts
+	m[mapKeyBlackboard] = state.Blackboard
+	return m
+}
+
+// stateFromMap is the inverse of stateToMap. Keys other than the built-in
+// fields go to SwarmState.Values.
+func stateFromMap(m map[string]any) (SwarmState, error) {
+	var state SwarmState
+	var err error
+	for key, value := range m {
+		switch key {
+		case ReducerKeyMessages:
+			state.Messages, err = mapField[[]llms.MessageContent](key, value)
+		case ReducerKeyActiveAgent:
+			state.ActiveAgent, err = mapField[string](key, value)
+		case ReducerKeyPinnedAgent:
+			state.PinnedAgent, err = mapField[string](key, value)
+		case ReducerKeyHandoffPayload:
+			state.HandoffPayload, err = mapField[map[string]any](key, value)
+		case ReducerKeyPrivateMessages:
+			state.PrivateMessages, err = mapField[map[string][]llms.MessageContent](key, value)
+		case ReducerKeyHandoffs:
+			state.Handoffs, err = mapField[[]HandoffRecord](key, value)
+		case ReducerKeyTasks:
+			state.Tasks, err = mapField[[]Task](key, value)
+		case ReducerKeyArtifacts:
+			state.Artifacts, err = mapField[map[string]ArtifactRef](key, value)
+		case mapKeyBlackboard:
+			state.Blackboard, err = mapField[map[string]any](key, value)
+		default:
+			if state.Values == nil {
+				state.Values = make(map[string]any)
+			}
+			state.Values[key] = value
+		}
+		if err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// mapField converts the value of a built-in field in a map state to the
+// field's type. A nil value yields the zero value.
+func mapField[T any](key string, value any) (T, error) {
+	var zero T
+	if value == nil {
+		return zero, nil
+	}
+	v, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("state field '%s' is %T, want %T", key, value, zero)
+	}
+	return v, nil
+}
@@ -0,0 +1,109 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// anyRunnable returns its state as any, like runnables of older graph
+// versions.
+type anyRunnable struct{ reply string }
+
+func (r anyRunnable) Invoke(ctx context.Context, state SwarmState) (any, error) {
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, r.reply))
+	return &state, nil
+}
+
+func TestAgentRunnableShapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		runnable any
+	}{
+		{name: "agent runnable", runnable: createMockAgent("Alice", "Hi")},
+		{
+			name: "command runnable",
+			runnable: struct{ CommandRunnable }{CommandRunnableFunc(func(ctx context.Context, state SwarmState) (*graph.Command, error) {
+				state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Hi"))
+				return &graph.Command{Update: state, Goto: graph.END}, nil
+			})},
+		},
+		{name: "invoker returning any", runnable: anyRunnable{reply: "Hi"}},
+		{
+			name: "node function",
+			runnable: func(ctx context.Context, state SwarmState) (SwarmState, error) {
+				state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Hi"))
+				return state, nil
+			},
+		},
+		{
+			name: "map function",
+			runnable: func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				messages := state[ReducerKeyMessages].([]llms.MessageContent)
+				state[ReducerKeyMessages] = append(messages, llms.TextParts(llms.ChatMessageTypeAI, "Hi"))
+				state["greeted"] = true
+				return state, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runnable, err := AdaptRunnable(tt.runnable)
+			if err != nil {
+				t.Fatalf("AdaptRunnable() error = %v", err)
+			}
+			app := compileSwarm(t, SwarmConfig{
+				Agents:             []Agent{{Name: "Alice", Runnable: runnable}},
+				DefaultActiveAgent: "Alice",
+			})
+			result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+			}})
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+			if len(result.Messages) != 2 || result.Messages[1].Parts[0].(llms.TextContent).Text != "Hi" {
+				t.Errorf("Messages = %v, want the reply of Alice", result.Messages)
+			}
+		})
+	}
+}
+
+func TestUnsupportedRunnable(t *testing.T) {
+	for _, runnable := range []any{nil, "Alice", func(SwarmState) SwarmState { return SwarmState{} }} {
+		if _, err := AdaptRunnable(runnable); !errors.Is(err, ErrUnsupportedRunnable) {
+			t.Errorf("AdaptRunnable(%T) error = %v, want ErrUnsupportedRunnable", runnable, err)
+		}
+	}
+
+	_, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice"}},
+		DefaultActiveAgent: "Alice",
+	})
+	if !errors.Is(err, ErrUnsupportedRunnable) {
+		t.Errorf("CreateSwarm() error = %v, want ErrUnsupportedRunnable", err)
+	}
+}
+
+func TestStateMapRoundTrip(t *testing.T) {
+	state := SwarmState{
+		Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+		ActiveAgent: "Alice",
+		Blackboard:  map[string]any{"plan": "draft"},
+		Values:      map[string]any{"score": 3},
+	}
+	got, err := stateFromMap(stateToMap(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 1 || got.ActiveAgent != "Alice" || got.Blackboard["plan"] != "draft" || got.Values["score"] != 3 {
+		t.Errorf("stateFromMap(stateToMap()) = %+v", got)
+	}
+
+	if _, err := stateFromMap(map[string]any{ReducerKeyActiveAgent: 1}); err == nil {
+		t.Error("stateFromMap() with an int active agent succeeded")
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// threadInfo describes a thread in management responses.
+type threadInfo struct {
+	ID          string    `json:"id"`
+	ActiveAgent string    `json:"active_agent"`
+	Messages    int       `json:"messages"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// CheckpointID is the latest checkpoint of the thread
+	CheckpointID string `json:"checkpoint_id"`
+	// WaitingForApproval is set while a run waits for an approval
+	WaitingForApproval bool `json:"waiting_for_approval"`
+	// WaitingForReply is set while a run waits for the reply of a human
+	// agent
+	WaitingForReply bool `json:"waiting_for_reply"`
+	// Running is set while a run of the thread is in progress
+	Running bool `json:"running"`
+}
+
+// checkpointInfo describes a checkpoint of a thread.
+type checkpointInfo struct {
+	ID          string         `json:"id"`
+	ActiveAgent string         `json:"active_agent"`
+	Messages    int            `json:"messages"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// historyResponse is the body of GET /threads/{id}/messages.
+type historyResponse struct {
+	ActiveAgent string                `json:"active_agent"`
+	Messages    []llms.MessageContent `json:"messages"`
+	Handoffs    []swarm.HandoffRecord `json:"handoffs"`
+}
+
+// forkRequest is the body of POST /threads/{id}/fork.
+type forkRequest struct {
+	// CheckpointID is the checkpoint to copy; the latest one by default
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+	// ThreadID is the new thread; a random ID by default
+	ThreadID string `json:"thread_id,omitempty"`
+}
+
+// handleListThreads lists the threads of the checkpointer, if it can
+// enumerate them (see swarm.ThreadLister).
+func (s *Server) handleListThreads(w http.ResponseWriter, r *http.Request) {
+	lister, ok := s.store.(swarm.ThreadLister)
+	if !ok {
+		http.Error(w, "the checkpointer cannot list threads", http.StatusNotImplemented)
+		return
+	}
+	threadIDs, err := lister.Threads(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	threads := make([]threadInfo, 0, len(threadIDs))
+	for _, threadID := range threadIDs {
+		checkpoint, err := s.store.Latest(r.Context(), threadID)
+		if errors.Is(err, swarm.ErrCheckpointNotFound) {
+			// Deleted since it was listed
+			continue
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		threads = append(threads, s.threadInfo(checkpoint))
+	}
+	writeJSON(w, http.StatusOK, threads)
+}
+
+// handleGetThread describes a thread, including its ActiveAgent.
+func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request) {
+	checkpoint, err := s.latest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.threadInfo(checkpoint))
+}
+
+// handleHistory returns the conversation of a thread.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	checkpoint, err := s.latest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, historyResponse{
+		ActiveAgent: checkpoint.State.ActiveAgent,
+		Messages:    checkpoint.State.Messages,
+		Handoffs:    checkpoint.State.Handoffs,
+	})
+}
+
+// handleListCheckpoints lists the checkpoints of a thread, oldest first.
+func (s *Server) handleListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	checkpoints, err := s.store.List(r.Context(), threadID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(checkpoints) == 0 {
+		writeError(w, threadNotFound(threadID))
+		return
+	}
+
+	infos := make([]checkpointInfo, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		infos[i] = checkpointInfo{
+			ID:          checkpoint.ID,
+			ActiveAgent: checkpoint.State.ActiveAgent,
+			Messages:    len(checkpoint.State.Messages),
+			CreatedAt:   checkpoint.CreatedAt,
+			Metadata:    checkpoint.Metadata,
+		}
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handleCancel cancels the run in progress on a thread. The client
+// streaming the run receives an error event.
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	s.mu.Lock()
+	cancel, ok := s.runs[threadID]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("thread '%s' has no run in progress", threadID), http.StatusConflict)
+		return
+	}
+	cancel()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleFork copies a checkpoint of a thread into a new thread, which can
+// then take messages independently of the original.
+func (s *Server) handleFork(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	var req forkRequest
+	if err := decodeRequest(w, r, &req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	if req.ThreadID == "" {
+		req.ThreadID = newThreadID()
+	}
+	fork, err := s.app.ForkThread(r.Context(), threadID, req.CheckpointID, req.ThreadID)
+	switch {
+	case errors.Is(err, swarm.ErrCheckpointNotFound):
+		err = &requestError{http.StatusNotFound, err.Error()}
+	case errors.Is(err, swarm.ErrThreadExists):
+		err = &requestError{http.StatusConflict, err.Error()}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.threadInfo(fork))
+}
+
+// latest returns the latest checkpoint of the thread of a request.
+func (s *Server) latest(r *http.Request) (*swarm.Checkpoint, error) {
+	threadID := r.PathValue("id")
+	checkpoint, err := s.store.Latest(r.Context(), threadID)
+	if errors.Is(err, swarm.ErrCheckpointNotFound) {
+		return nil, threadNotFound(threadID)
+	}
+	return checkpoint, err
+}
+
+// threadInfo describes the thread of its latest checkpoint.
+func (s *Server) threadInfo(checkpoint *swarm.Checkpoint) threadInfo {
+	_, _, waiting := checkpoint.PendingApproval()
+	_, replying := checkpoint.PendingReply()
+	s.mu.Lock()
+	_, running := s.runs[checkpoint.ThreadID]
+	s.mu.Unlock()
+	return threadInfo{
+		ID:                 checkpoint.ThreadID,
+		ActiveAgent:        checkpoint.State.ActiveAgent,
+		Messages:           len(checkpoint.State.Messages),
+		UpdatedAt:          checkpoint.CreatedAt,
+		CheckpointID:       checkpoint.ID,
+		WaitingForApproval: waiting,
+		WaitingForReply:    replying,
+		Running:            running,
+	}
+}
+
+// threadNotFound returns the error answering requests for a missing thread.
+func threadNotFound(threadID string) error {
+	return &requestError{http.StatusNotFound, fmt.Sprintf("thread '%s' not found", threadID)}
+}
+
+// writeJSON answers a request with a JSON body.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newThreadID returns a random thread identifier.
+func newThreadID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
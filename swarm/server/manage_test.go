@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// blockingModel blocks every call until its context is cancelled.
+type blockingModel struct {
+	started chan struct{}
+}
+
+func (m *blockingModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	close(m.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (m *blockingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// getJSON decodes the JSON response to a GET request into v and returns the
+// status.
+func getJSON(t *testing.T, url string, v any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Invalid response to GET %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServerManagement(t *testing.T) {
+	model := swarmtest.NewMockModel(swarmtest.Text("Hi there"), swarmtest.Text("Paris"))
+	ts := newTestServer(t, model)
+	post(t, ts.URL+"/threads/t1/messages", `{"content": "Hello"}`)
+
+	var threads []threadInfo
+	if status := getJSON(t, ts.URL+"/threads", &threads); status != http.StatusOK {
+		t.Fatalf("GET /threads status = %d", status)
+	}
+	if len(threads) != 1 || threads[0].ID != "t1" || threads[0].Messages != 2 || threads[0].Running {
+		t.Errorf("Threads = %+v", threads)
+	}
+
+	var history historyResponse
+	getJSON(t, ts.URL+"/threads/t1/messages", &history)
+	if len(history.Messages) != 2 || history.Messages[1].Parts[0] != (llms.TextContent{Text: "Hi there"}) {
+		t.Errorf("History = %+v", history.Messages)
+	}
+	if status := getJSON(t, ts.URL+"/threads/missing", &threadInfo{}); status != http.StatusNotFound {
+		t.Errorf("Status for an unknown thread = %d", status)
+	}
+
+	var checkpoints []checkpointInfo
+	getJSON(t, ts.URL+"/threads/t1/checkpoints", &checkpoints)
+	if len(checkpoints) == 0 {
+		t.Fatal("Expected checkpoints")
+	}
+
+	body := `{"checkpoint_id": "` + checkpoints[0].ID + `", "thread_id": "t2"}`
+	resp, err := http.Post(ts.URL+"/threads/t1/fork", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST fork error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Fork status = %d", resp.StatusCode)
+	}
+	var fork threadInfo
+	getJSON(t, ts.URL+"/threads/t2", &fork)
+	if fork.Messages != checkpoints[0].Messages {
+		t.Errorf("Forked thread = %+v, want the state of %+v", fork, checkpoints[0])
+	}
+	if resp, _ := http.Post(ts.URL+"/threads/t1/fork", "application/json", strings.NewReader(body)); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Status for forking onto an existing thread = %d", resp.StatusCode)
+	}
+	if resp, _ := http.Post(ts.URL+"/threads/t1/fork", "application/json", strings.NewReader(`{"checkpoint_id": "missing"}`)); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status for forking an unknown checkpoint = %d", resp.StatusCode)
+	}
+
+	// The fork continues on its own
+	post(t, ts.URL+"/threads/t2/messages", `{"content": "Where to?"}`)
+	var original threadInfo
+	getJSON(t, ts.URL+"/threads/t1", &original)
+	if original.Messages != 2 {
+		t.Errorf("Expected the original thread to be unchanged, got %+v", original)
+	}
+}
+
+func TestServerCancel(t *testing.T) {
+	model := &blockingModel{started: make(chan struct{})}
+	ts := newTestServer(t, model)
+
+	if resp, _ := http.Post(ts.URL+"/threads/t1/cancel", "", nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Status without a run in progress = %d", resp.StatusCode)
+	}
+
+	done := make(chan []event)
+	go func() {
+		_, events := post(t, ts.URL+"/threads/t1/messages", `{"content": "Hello"}`)
+		done <- events
+	}()
+	<-model.started
+
+	resp, err := http.Post(ts.URL+"/threads/t1/cancel", "", nil)
+	if err != nil {
+		t.Fatalf("POST cancel error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Cancel status = %d", resp.StatusCode)
+	}
+
+	events := <-done
+	if last := events[len(events)-1]; last.name != "error" || !strings.Contains(last.data["error"].(string), "canceled") {
+		t.Errorf("Expected the run to end with a cancellation error, got %+v", last)
+	}
+	if resp, _ := http.Post(ts.URL+"/threads/t1/cancel", "", nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected the run to be over, got status %d", resp.StatusCode)
+	}
+}
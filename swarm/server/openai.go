@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// defaultModelName is the model reported by the chat completions endpoint.
+const defaultModelName = "swarm"
+
+// WithModelName sets the model name reported in chat completion responses.
+// The default is "swarm"; the model requested by clients is ignored.
+func WithModelName(name string) Option {
+	return func(s *Server) {
+		s.modelName = name
+	}
+}
+
+// chatRequest is the body of POST /v1/chat/completions. Parameters that
+// belong to the agents' models, such as temperature, are ignored.
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+// chatMessage is a message of the OpenAI Chat Completions API.
+type chatMessage struct {
+	Role string `json:"role"`
+	// Content is a string or an array of content parts
+	Content json.RawMessage `json:"content,omitempty"`
+	// Name is set on assistant messages to the agent that wrote them
+	Name string `json:"name,omitempty"`
+}
+
+// chatContentPart is an element of an array content.
+type chatContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+}
+
+// chatResponseMessage is the assistant message of a response.
+type chatResponseMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+	Name    string `json:"name,omitempty"`
+}
+
+// chatChoice is a choice of a non-streaming response.
+type chatChoice struct {
+	Index        int                 `json:"index"`
+	Message      chatResponseMessage `json:"message"`
+	FinishReason string              `json:"finish_reason"`
+}
+
+// chatResponse is a non-streaming chat completion.
+type chatResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+}
+
+// chatChunkChoice is a choice of a streamed chunk.
+type chatChunkChoice struct {
+	Index        int                 `json:"index"`
+	Delta        chatResponseMessage `json:"delta"`
+	FinishReason *string             `json:"finish_reason"`
+}
+
+// chatChunk is a streamed chat completion chunk.
+type chatChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"`
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []chatChunkChoice `json:"choices"`
+}
+
+// chatError is the body of error responses.
+type chatError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// handleChatCompletions serves the swarm as an OpenAI Chat Completions
+// endpoint. The conversation comes from the request, so no thread is kept;
+// the agent that wrote the last assistant message (its name) continues it.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		writeChatError(w, http.StatusBadRequest, "request body must be a JSON object")
+		return
+	}
+	state, err := chatState(req.Messages)
+	if err != nil {
+		writeChatError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := s.app.Swarm().Agent(state.ActiveAgent); state.ActiveAgent != "" && !ok {
+		writeChatError(w, http.StatusBadRequest, fmt.Sprintf("unknown agent '%s' in assistant message name", state.ActiveAgent))
+		return
+	}
+	var opts []swarm.InvokeOption
+	if s.invokeOptions != nil {
+		if opts, err = s.invokeOptions(r); err != nil {
+			writeChatError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	id := newCompletionID()
+	created := time.Now().Unix()
+	if !req.Stream {
+		result, err := s.app.Invoke(r.Context(), state, opts...)
+		if err != nil {
+			writeChatError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		answer := finalAnswer(result.Messages[min(len(state.Messages), len(result.Messages)):])
+		writeJSON(w, http.StatusOK, chatResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   s.modelName,
+			Choices: []chatChoice{{
+				Message:      chatResponseMessage{Role: "assistant", Content: answer, Name: result.ActiveAgent},
+				FinishReason: "stop",
+			}},
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeChatError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	sse := &sseWriter{w: w, flusher: flusher}
+
+	chunk := func(delta chatResponseMessage, finishReason *string) chatChunk {
+		return chatChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   s.modelName,
+			Choices: []chatChunkChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+	sse.data(chunk(chatResponseMessage{Role: "assistant"}, nil))
+
+	ctx := swarm.WithStreamHandler(r.Context(), swarm.StreamHandlerFuncs{
+		Token: func(ctx context.Context, agent, token string) {
+			sse.data(chunk(chatResponseMessage{Content: token, Name: agent}, nil))
+		},
+	})
+	if _, err := s.app.Invoke(ctx, state, opts...); err != nil {
+		var body chatError
+		body.Error.Message = err.Error()
+		body.Error.Type = "server_error"
+		sse.data(body)
+		return
+	}
+	stop := "stop"
+	sse.data(chunk(chatResponseMessage{}, &stop))
+	sse.done()
+}
+
+// chatState converts the messages of a chat completion request to a swarm
+// state. Client-side tools are not supported, so tool messages are rejected.
+func chatState(messages []chatMessage) (swarm.SwarmState, error) {
+	if len(messages) == 0 {
+		return swarm.SwarmState{}, errors.New("messages cannot be empty")
+	}
+
+	var state swarm.SwarmState
+	for i, message := range messages {
+		var role llms.ChatMessageType
+		switch message.Role {
+		case "system", "developer":
+			role = llms.ChatMessageTypeSystem
+		case "user":
+			role = llms.ChatMessageTypeHuman
+		case "assistant":
+			role = llms.ChatMessageTypeAI
+			if message.Name != "" {
+				state.ActiveAgent = message.Name
+			}
+		default:
+			return swarm.SwarmState{}, fmt.Errorf("messages[%d]: unsupported role '%s'", i, message.Role)
+		}
+
+		parts, err := chatParts(message.Content)
+		if err != nil {
+			return swarm.SwarmState{}, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		state.Messages = append(state.Messages, llms.MessageContent{Role: role, Parts: parts})
+	}
+	return state, nil
+}
+
+// chatParts converts a message content, a string or an array of text and
+// image parts.
+func chatParts(content json.RawMessage) ([]llms.ContentPart, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return []llms.ContentPart{llms.TextContent{Text: text}}, nil
+	}
+
+	var elements []chatContentPart
+	if err := json.Unmarshal(content, &elements); err != nil {
+		return nil, errors.New("content must be a string or an array of content parts")
+	}
+	parts := make([]llms.ContentPart, 0, len(elements))
+	for _, element := range elements {
+		switch {
+		case element.Type == "text":
+			parts = append(parts, llms.TextContent{Text: element.Text})
+		case element.Type == "image_url" && element.ImageURL != nil:
+			parts = append(parts, llms.ImageURLContent{URL: element.ImageURL.URL})
+		default:
+			return nil, fmt.Errorf("unsupported content part '%s'", element.Type)
+		}
+	}
+	return parts, nil
+}
+
+// finalAnswer returns the text of the last AI message with text among the
+// messages added by a run.
+func finalAnswer(added []llms.MessageContent) string {
+	for i := len(added) - 1; i >= 0; i-- {
+		if added[i].Role != llms.ChatMessageTypeAI {
+			continue
+		}
+		var text strings.Builder
+		for _, part := range added[i].Parts {
+			if t, ok := part.(llms.TextContent); ok {
+				text.WriteString(t.Text)
+			}
+		}
+		if text.Len() > 0 {
+			return text.String()
+		}
+	}
+	return ""
+}
+
+// writeChatError answers a chat completion request with an OpenAI error.
+func writeChatError(w http.ResponseWriter, status int, message string) {
+	var body chatError
+	body.Error.Message = message
+	body.Error.Type = "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		body.Error.Type = "server_error"
+	}
+	writeJSON(w, status, body)
+}
+
+// newCompletionID returns a random completion identifier.
+func newCompletionID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+)
+
+// postChat posts a chat completion request and returns the response.
+func postChat(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /v1/chat/completions error = %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServerChatCompletions(t *testing.T) {
+	alice := swarmtest.NewMockModel(swarmtest.Text("Hi, I am Alice"))
+	bob := swarmtest.NewMockModel(swarmtest.Text("Ahoy"))
+	ts := serve(t, swarm.SwarmConfig{
+		Agents: []swarm.Agent{
+			{Name: "Alice", Runnable: reactAgent(t, alice)},
+			{Name: "Bob", Runnable: reactAgent(t, bob)},
+		},
+		DefaultActiveAgent: "Alice",
+	}, WithModelName("travel-swarm"))
+
+	resp := postChat(t, ts.URL, `{"model": "gpt-4o", "messages": [
+		{"role": "system", "content": "Be brief"},
+		{"role": "user", "content": [{"type": "text", "text": "Hello"}]}
+	]}`)
+	var completion chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if completion.Object != "chat.completion" || completion.Model != "travel-swarm" || len(completion.Choices) != 1 {
+		t.Fatalf("Unexpected completion %+v", completion)
+	}
+	if got := completion.Choices[0].Message; got.Role != "assistant" || got.Content != "Hi, I am Alice" {
+		t.Errorf("Message = %+v", got)
+	}
+
+	// The agent named on the last assistant message continues the conversation
+	resp = postChat(t, ts.URL, `{"messages": [
+		{"role": "user", "content": "Hello"},
+		{"role": "assistant", "name": "Bob", "content": "Ahoy"},
+		{"role": "user", "content": "Who are you?"}
+	], "stream": true}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var content strings.Builder
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		lines = append(lines, data)
+		var chunk chatChunk
+		if data != "[DONE]" && json.Unmarshal([]byte(data), &chunk) == nil && len(chunk.Choices) > 0 {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if content.String() != "Ahoy" || len(alice.Calls()) != 1 {
+		t.Errorf("Streamed %q, want Bob's answer", content.String())
+	}
+	if len(lines) == 0 || lines[len(lines)-1] != "[DONE]" {
+		t.Errorf("Expected the stream to end with [DONE], got %v", lines)
+	}
+
+	if resp := postChat(t, ts.URL, `{"messages": [{"role": "tool", "content": "42"}]}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status for a tool message = %d", resp.StatusCode)
+	}
+	if resp := postChat(t, ts.URL, `{"messages": []}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status without messages = %d", resp.StatusCode)
+	}
+	if resp := postChat(t, ts.URL, `{"messages": [
+		{"role": "assistant", "name": "Mallory", "content": "Hi"},
+		{"role": "user", "content": "Hello"}
+	]}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status for an unknown agent = %d", resp.StatusCode)
+	}
+}
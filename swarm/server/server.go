@@ -0,0 +1,442 @@
+// Package server exposes a compiled swarm over HTTP.
+//
+// Every conversation is a thread whose state is kept in the swarm's
+// checkpointer (SwarmConfig.Checkpointer). Posting a message to a thread
+// runs the swarm and streams its progress as Server-Sent Events:
+//
+//	POST /threads/{id}/messages   {"content": "Book me a flight"}
+//	POST /threads/{id}/approval   {"approved": true}
+//	POST /threads/{id}/reply      {"content": "I've refunded your order", "handoff_to": "support"}
+//
+// A reply answers a thread waiting for the person playing a
+// swarm.HumanAgent; handoff_to is optional.
+//
+// Messages may attach images for vision-capable agents as URLs or data
+// URLs: {"content": "What's damaged?", "images": ["https://..."]}.
+//
+// With WithVoice, clients can also talk to the swarm: the body of an audio
+// message is the raw recording, which is transcribed and run as the user
+// message. Its stream starts with the transcript and ends with the spoken
+// answer before the done event:
+//
+//	POST /threads/{id}/audio      Content-Type: audio/wav
+//
+// Interactive clients can instead open a WebSocket session on a thread and
+// send the same requests as frames, receiving the events on the same
+// connection as {"type": event, "data": payload} frames:
+//
+//	GET /threads/{id}/ws          {"type": "message", "content": "Book me a flight"}
+//	                              {"type": "approval", "approved": true}
+//	                              {"type": "reply", "content": "I've refunded your order"}
+//	                              {"type": "audio", "mime_type": "audio/wav", "audio": "<base64>"}
+//
+// Both transports carry these events, each with a JSON payload:
+//
+//	agent_start  {"agent"}
+//	token        {"agent", "token"}
+//	tool_call    {"agent", "id", "name", "arguments"}
+//	handoff      {"from", "to"}
+//	agent_end    {"agent", "error"}
+//	interrupt    {"agent", "tool_calls"}   the run waits for an approval, or a reply without tool_calls
+//	transcript   {"text"}                  the text of an audio message
+//	audio        {"mime_type", "data"}     the spoken answer, base64-encoded, or {"error"}
+//	done         {"active_agent", "messages"}
+//	error        {"error"}
+//
+// Operators can inspect and manage threads with JSON endpoints:
+//
+//	GET  /threads                    threads with their active agent and status
+//	GET  /threads/{id}               a thread's active agent and status
+//	GET  /threads/{id}/messages      the conversation and its handoffs
+//	GET  /threads/{id}/checkpoints   the thread's checkpoints, oldest first
+//	POST /threads/{id}/cancel        cancels the run in progress
+//	POST /threads/{id}/fork          {"checkpoint_id", "thread_id"} copies a checkpoint to a new thread
+//
+// Listing threads needs a checkpointer implementing swarm.ThreadLister.
+//
+// Call Shutdown before shutting the http.Server down: it rejects new runs
+// with 503 Service Unavailable and waits for the runs in progress.
+//
+// The swarm is also served as an OpenAI-compatible Chat Completions
+// endpoint, with or without streaming, so OpenAI clients can talk to it
+// unchanged. The client sends the whole conversation, and the swarm
+// decides which agent answers:
+//
+//	POST /v1/chat/completions     {"messages": [{"role": "user", "content": "Hi"}], "stream": true}
+//
+// Example:
+//
+//	app, _ := workflow.Compile()
+//	srv, err := server.New(app)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Fatal(http.ListenAndServe(":8080", srv))
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/voice"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Option configures a Server created by New.
+type Option func(*Server)
+
+// WithInvokeOptions sets a function returning extra options for every run
+// started by a request, e.g. a swarm.WithContext built from the
+// authenticated user. An error rejects the request with 400 Bad Request.
+func WithInvokeOptions(fn func(r *http.Request) ([]swarm.InvokeOption, error)) Option {
+	return func(s *Server) {
+		s.invokeOptions = fn
+	}
+}
+
+// Server is an http.Handler serving a compiled swarm.
+type Server struct {
+	app           *swarm.CompiledSwarm
+	store         swarm.CheckpointStore
+	mux           *http.ServeMux
+	invokeOptions func(r *http.Request) ([]swarm.InvokeOption, error)
+	// originPatterns are the cross origins allowed to open WebSocket sessions
+	originPatterns []string
+	// modelName is the model reported by the chat completions endpoint
+	modelName string
+	// transcriber and synthesizer serve audio messages
+	transcriber voice.Transcriber
+	synthesizer voice.Synthesizer
+
+	mu       sync.Mutex
+	runs     map[string]context.CancelFunc
+	shutdown bool
+}
+
+// New creates a Server for app. The swarm must have a Checkpointer to keep
+// the threads' state.
+func New(app *swarm.CompiledSwarm, opts ...Option) (*Server, error) {
+	store := app.Checkpointer()
+	if store == nil {
+		return nil, errors.New("server: the swarm has no checkpointer")
+	}
+
+	s := &Server{app: app, store: store, mux: http.NewServeMux(), runs: make(map[string]context.CancelFunc), modelName: defaultModelName}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("POST /threads/{id}/messages", s.handleMessage)
+	s.mux.HandleFunc("POST /threads/{id}/approval", s.handleApproval)
+	s.mux.HandleFunc("POST /threads/{id}/reply", s.handleReply)
+	if s.transcriber != nil {
+		s.mux.HandleFunc("POST /threads/{id}/audio", s.handleAudio)
+	}
+	s.mux.HandleFunc("GET /threads/{id}/ws", s.handleSession)
+	s.mux.HandleFunc("GET /threads", s.handleListThreads)
+	s.mux.HandleFunc("GET /threads/{id}", s.handleGetThread)
+	s.mux.HandleFunc("GET /threads/{id}/messages", s.handleHistory)
+	s.mux.HandleFunc("GET /threads/{id}/checkpoints", s.handleListCheckpoints)
+	s.mux.HandleFunc("POST /threads/{id}/cancel", s.handleCancel)
+	s.mux.HandleFunc("POST /threads/{id}/fork", s.handleFork)
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	return s, nil
+}
+
+// ServeHTTP routes the request to the server's endpoints.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// messageRequest is the body of POST /threads/{id}/messages.
+type messageRequest struct {
+	Content string `json:"content"`
+	// Images are the URLs, or data URLs, of images attached to the message
+	Images []string `json:"images,omitempty"`
+}
+
+// approvalRequest is the body of POST /threads/{id}/approval.
+type approvalRequest struct {
+	Approved bool   `json:"approved"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// replyRequest is the body of POST /threads/{id}/reply.
+type replyRequest struct {
+	Content   string `json:"content"`
+	HandoffTo string `json:"handoff_to,omitempty"`
+}
+
+// handleMessage adds a user message to a thread and streams the run.
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	var req messageRequest
+	if err := decodeRequest(w, r, &req); err != nil || (req.Content == "" && len(req.Images) == 0) {
+		http.Error(w, "request body must be a JSON object with a non-empty content or images", http.StatusBadRequest)
+		return
+	}
+	opts, ok := s.runOptions(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, ok := s.beginRun(w, r, threadID)
+	if !ok {
+		return
+	}
+	defer s.endRun(threadID)
+
+	run, err := s.messageRun(ctx, threadID, userMessage(req.Content, req.Images), opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.stream(ctx, w, run)
+}
+
+// handleApproval answers the pending approval of a thread and streams the
+// resumed run.
+func (s *Server) handleApproval(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	var req approvalRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		http.Error(w, "request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+	opts, ok := s.runOptions(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, ok := s.beginRun(w, r, threadID)
+	if !ok {
+		return
+	}
+	defer s.endRun(threadID)
+
+	run, err := s.approvalRun(ctx, threadID, swarm.Approval{Approved: req.Approved, Feedback: req.Feedback}, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.stream(ctx, w, run)
+}
+
+// handleReply answers the human agent a thread is waiting for and streams
+// the resumed run.
+func (s *Server) handleReply(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	var req replyRequest
+	if err := decodeRequest(w, r, &req); err != nil || (req.Content == "" && req.HandoffTo == "") {
+		http.Error(w, "request body must be a JSON object with a non-empty content or handoff_to", http.StatusBadRequest)
+		return
+	}
+	opts, ok := s.runOptions(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, ok := s.beginRun(w, r, threadID)
+	if !ok {
+		return
+	}
+	defer s.endRun(threadID)
+
+	run, err := s.replyRun(ctx, threadID, swarm.HumanReply{Content: req.Content, HandoffTo: req.HandoffTo}, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.stream(ctx, w, run)
+}
+
+// runOptions returns the invoke options for the request, or writes an error
+// response and returns false.
+func (s *Server) runOptions(w http.ResponseWriter, r *http.Request) ([]swarm.InvokeOption, bool) {
+	if s.invokeOptions == nil {
+		return nil, true
+	}
+	opts, err := s.invokeOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return opts, true
+}
+
+// maxRequestSize is the largest JSON request body accepted, leaving room
+// for images sent as data URLs.
+const maxRequestSize = 20 << 20
+
+// decodeRequest decodes the JSON body of r into v, reading at most
+// maxRequestSize bytes of it.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(v)
+}
+
+// requestError is an error answered with a specific HTTP status.
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// writeError answers a request with err, using its status if it is a
+// *requestError, 503 Service Unavailable after a shutdown and 500 Internal
+// Server Error otherwise.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		status = reqErr.status
+	case errors.Is(err, swarm.ErrShutdown):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// run is a prepared run of a thread.
+type run struct {
+	// before is the number of messages of the state the run starts from
+	before int
+	invoke func(ctx context.Context) (swarm.SwarmState, error)
+	// transcript is the text of the audio message starting the run, and
+	// synthesizer speaks its answer
+	transcript  string
+	synthesizer voice.Synthesizer
+}
+
+// messageRun prepares a run adding a user message to a thread. A new thread
+// starts from an empty state; a thread waiting for an approval or a human
+// reply cannot take new messages.
+func (s *Server) messageRun(ctx context.Context, threadID string, message llms.MessageContent, opts []swarm.InvokeOption) (run, error) {
+	var state swarm.SwarmState
+	checkpoint, err := s.store.Latest(ctx, threadID)
+	switch {
+	case errors.Is(err, swarm.ErrCheckpointNotFound):
+	case err != nil:
+		return run{}, err
+	default:
+		if _, _, ok := checkpoint.PendingApproval(); ok {
+			return run{}, &requestError{http.StatusConflict, fmt.Sprintf("thread '%s' is waiting for an approval", threadID)}
+		}
+		if _, ok := checkpoint.PendingReply(); ok {
+			return run{}, &requestError{http.StatusConflict, fmt.Sprintf("thread '%s' is waiting for a human reply", threadID)}
+		}
+		state = checkpoint.State
+	}
+	state.Messages = append(state.Messages, message)
+
+	return run{
+		before: len(state.Messages),
+		invoke: func(ctx context.Context) (swarm.SwarmState, error) {
+			return s.app.Invoke(ctx, state, append(opts, swarm.WithThreadID(threadID))...)
+		},
+	}, nil
+}
+
+// approvalRun prepares a run resuming a thread waiting for an approval.
+func (s *Server) approvalRun(ctx context.Context, threadID string, approval swarm.Approval, opts []swarm.InvokeOption) (run, error) {
+	checkpoint, err := s.store.Latest(ctx, threadID)
+	if errors.Is(err, swarm.ErrCheckpointNotFound) {
+		return run{}, threadNotFound(threadID)
+	}
+	if err != nil {
+		return run{}, err
+	}
+	if _, _, ok := checkpoint.PendingApproval(); !ok {
+		return run{}, &requestError{http.StatusConflict, fmt.Sprintf("thread '%s' is not waiting for an approval", threadID)}
+	}
+
+	return run{
+		before: len(checkpoint.State.Messages),
+		invoke: func(ctx context.Context) (swarm.SwarmState, error) {
+			return s.app.Resume(ctx, threadID, approval, opts...)
+		},
+	}, nil
+}
+
+// replyRun prepares a run resuming a thread waiting for a human reply.
+func (s *Server) replyRun(ctx context.Context, threadID string, reply swarm.HumanReply, opts []swarm.InvokeOption) (run, error) {
+	checkpoint, err := s.store.Latest(ctx, threadID)
+	if errors.Is(err, swarm.ErrCheckpointNotFound) {
+		return run{}, threadNotFound(threadID)
+	}
+	if err != nil {
+		return run{}, err
+	}
+	if _, ok := checkpoint.PendingReply(); !ok {
+		return run{}, &requestError{http.StatusConflict, fmt.Sprintf("thread '%s' is not waiting for a human reply", threadID)}
+	}
+
+	return run{
+		before: len(checkpoint.State.Messages),
+		invoke: func(ctx context.Context) (swarm.SwarmState, error) {
+			return s.app.Resume(ctx, threadID, reply, opts...)
+		},
+	}, nil
+}
+
+// beginRun registers a run of a thread and returns its context, which is
+// cancelled when the client disconnects. If the thread already has a run in
+// progress, it writes 409 Conflict and reports false. Call endRun when the
+// run is over.
+func (s *Server) beginRun(w http.ResponseWriter, r *http.Request, threadID string) (context.Context, bool) {
+	ctx, err := s.claimRun(r.Context(), threadID)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return ctx, true
+}
+
+// claimRun registers a run of a thread and returns a context derived from
+// parent for it. It fails if the thread already has a run in progress.
+func (s *Server) claimRun(parent context.Context, threadID string) (context.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdown {
+		return nil, &requestError{http.StatusServiceUnavailable, "the server is shutting down"}
+	}
+	if _, busy := s.runs[threadID]; busy {
+		return nil, &requestError{http.StatusConflict, fmt.Sprintf("thread '%s' has a run in progress", threadID)}
+	}
+	ctx, cancel := context.WithCancel(parent)
+	s.runs[threadID] = cancel
+	return ctx, nil
+}
+
+// Shutdown rejects new runs and drains the runs in progress of the swarm
+// (see swarm.CompiledSwarm.Shutdown). If ctx is done first, the runs are
+// cancelled and their threads checkpointed as interrupted.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	srv.Shutdown(ctx)
+//	httpServer.Shutdown(ctx)
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shutdown = true
+	s.mu.Unlock()
+	return s.app.Shutdown(ctx)
+}
+
+// endRun unregisters the run of a thread and releases its context.
+func (s *Server) endRun(threadID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.runs[threadID]; ok {
+		cancel()
+		delete(s.runs, threadID)
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// bookTool books whatever it is asked to.
+type bookTool struct{}
+
+func (bookTool) Name() string        { return "book" }
+func (bookTool) Description() string { return "Book a flight" }
+func (bookTool) Call(ctx context.Context, input string) (string, error) {
+	return "booked", nil
+}
+
+// event is a parsed Server-Sent Event.
+type event struct {
+	name string
+	data map[string]any
+}
+
+// newTestServer returns a server for a single agent backed by model.
+func newTestServer(t *testing.T, model llms.Model, interruptBefore ...string) *httptest.Server {
+	t.Helper()
+	return serve(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Alice", Runnable: reactAgent(t, model)}},
+		DefaultActiveAgent: "Alice",
+		InterruptBefore:    interruptBefore,
+	})
+}
+
+// reactAgent returns a ReAct agent backed by model with the book tool.
+func reactAgent(t *testing.T, model llms.Model) *swarm.ReactAgent {
+	t.Helper()
+	agent, err := swarm.CreateReactAgent(model, []tools.Tool{bookTool{}})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+	return agent
+}
+
+// serve returns a test server for a swarm with an in-memory checkpointer.
+func serve(t *testing.T, config swarm.SwarmConfig, opts ...Option) *httptest.Server {
+	t.Helper()
+	config.Checkpointer = swarm.NewMemorySaver()
+	workflow, err := swarm.CreateSwarm(config)
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	srv, err := New(app, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// post sends a JSON request and returns the status and the streamed events.
+func post(t *testing.T, url, body string) (int, []event) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	var events []event
+	var current event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.data); err != nil {
+				t.Fatalf("Invalid event data %q: %v", line, err)
+			}
+		case line == "" && current.name != "":
+			events = append(events, current)
+			current = event{}
+		}
+	}
+	return resp.StatusCode, events
+}
+
+// names returns the names of events.
+func names(events []event) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.name
+	}
+	return names
+}
+
+func TestServerMessages(t *testing.T) {
+	model := swarmtest.NewMockModel(swarmtest.Text("Hi there"), swarmtest.Text("Paris"))
+	ts := newTestServer(t, model)
+
+	status, events := post(t, ts.URL+"/threads/t1/messages", `{"content": "Hello"}`)
+	if status != http.StatusOK {
+		t.Fatalf("Status = %d", status)
+	}
+	want := []string{"agent_start", "token", "agent_end", "done"}
+	if got := names(events); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Events = %v, want %v", got, want)
+	}
+	if events[1].data["token"] != "Hi there" || events[1].data["agent"] != "Alice" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+	if messages, _ := events[3].data["messages"].([]any); len(messages) != 1 {
+		t.Errorf("Expected the reply in the done event, got %v", events[3].data["messages"])
+	}
+
+	// The thread continues from its checkpoint
+	if _, events := post(t, ts.URL+"/threads/t1/messages", `{"content": "Where to?"}`); events[len(events)-1].name != "done" {
+		t.Fatalf("Events = %v", names(events))
+	}
+	if got := len(model.Calls()[1]); got != 3 {
+		t.Errorf("Expected the second run to see 3 messages, got %d", got)
+	}
+
+	if status, _ := post(t, ts.URL+"/threads/t1/messages", `{}`); status != http.StatusBadRequest {
+		t.Errorf("Status for an empty message = %d", status)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t1/messages", `{"content": "`+strings.Repeat("a", maxRequestSize)+`"}`); status != http.StatusBadRequest {
+		t.Errorf("Status for an oversized message = %d", status)
+	}
+}
+
+func TestServerApproval(t *testing.T) {
+	model := swarmtest.NewMockModel(
+		swarmtest.ToolCall("book", `{}`),
+		swarmtest.Text("Booked"),
+	)
+	ts := newTestServer(t, model, "book")
+
+	_, events := post(t, ts.URL+"/threads/t1/messages", `{"content": "Book it"}`)
+	last := events[len(events)-1]
+	if last.name != "interrupt" || last.data["agent"] != "Alice" {
+		t.Fatalf("Expected an interrupt, got %v", names(events))
+	}
+
+	if status, _ := post(t, ts.URL+"/threads/t1/messages", `{"content": "Hello?"}`); status != http.StatusConflict {
+		t.Errorf("Status while waiting for an approval = %d", status)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t2/approval", `{"approved": true}`); status != http.StatusNotFound {
+		t.Errorf("Status for an unknown thread = %d", status)
+	}
+
+	_, events = post(t, ts.URL+"/threads/t1/approval", `{"approved": true}`)
+	if got := names(events); !strings.Contains(strings.Join(got, ","), "tool_call") || got[len(got)-1] != "done" {
+		t.Errorf("Events after approval = %v", got)
+	}
+}
+
+func TestServerHumanReply(t *testing.T) {
+	ts := serve(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Carol", Runnable: swarm.NewHumanAgent()}},
+		DefaultActiveAgent: "Carol",
+	})
+
+	_, events := post(t, ts.URL+"/threads/t1/messages", `{"content": "Refund my order"}`)
+	if last := events[len(events)-1]; last.name != "interrupt" || last.data["agent"] != "Carol" {
+		t.Fatalf("Expected an interrupt, got %v", names(events))
+	}
+	var info threadInfo
+	getJSON(t, ts.URL+"/threads/t1", &info)
+	if !info.WaitingForReply || info.WaitingForApproval {
+		t.Errorf("Thread = %+v, want it waiting for a reply", info)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t1/messages", `{"content": "Hello?"}`); status != http.StatusConflict {
+		t.Errorf("Status while waiting for a reply = %d", status)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t1/approval", `{"approved": true}`); status != http.StatusConflict {
+		t.Errorf("Status of an approval while waiting for a reply = %d", status)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t1/reply", `{}`); status != http.StatusBadRequest {
+		t.Errorf("Status of an empty reply = %d", status)
+	}
+
+	_, events = post(t, ts.URL+"/threads/t1/reply", `{"content": "I've refunded your order."}`)
+	last := events[len(events)-1]
+	if last.name != "done" {
+		t.Fatalf("Events after the reply = %v", names(events))
+	}
+	if messages, _ := last.data["messages"].([]any); len(messages) != 1 {
+		t.Errorf("Expected the reply in the done event, got %v", last.data["messages"])
+	}
+	if status, _ := post(t, ts.URL+"/threads/t1/reply", `{"content": "Again"}`); status != http.StatusConflict {
+		t.Errorf("Status of a reply to an answered thread = %d", status)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t2/reply", `{"content": "Hi"}`); status != http.StatusNotFound {
+		t.Errorf("Status for an unknown thread = %d", status)
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Alice", Runnable: reactAgent(t, swarmtest.NewMockModel())}},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       swarm.NewMemorySaver(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if status, _ := post(t, ts.URL+"/threads/t1/messages", `{"content": "Hello"}`); status != http.StatusServiceUnavailable {
+		t.Errorf("Status after Shutdown = %d, want 503", status)
+	}
+}
+
+func TestServerMessageImages(t *testing.T) {
+	model := swarmtest.NewMockModel(swarmtest.Text("A cat"))
+	ts := newTestServer(t, model)
+
+	status, _ := post(t, ts.URL+"/threads/t1/messages", `{"images": ["https://example.com/cat.jpg"]}`)
+	if status != http.StatusOK {
+		t.Fatalf("Status = %d", status)
+	}
+	parts := model.Calls()[0][len(model.Calls()[0])-1].Parts
+	if len(parts) != 1 || parts[0] != (llms.ImageURLContent{URL: "https://example.com/cat.jpg"}) {
+		t.Errorf("Parts = %+v", parts)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// sseWriter writes Server-Sent Events frames. Stream handler callbacks may
+// come from several goroutines, so writes are serialized.
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// send writes an event with a JSON payload and flushes it to the client.
+func (s *sseWriter) send(event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		payload, _ = json.Marshal(map[string]string{"error": err.Error()})
+		event = "error"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+	s.flusher.Flush()
+}
+
+// data writes an unnamed event with a JSON payload, as the OpenAI streaming
+// format uses.
+func (s *sseWriter) data(data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, "data: %s\n\n", payload)
+	s.flusher.Flush()
+}
+
+// done writes the OpenAI end-of-stream marker.
+func (s *sseWriter) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.w, "data: [DONE]\n\n")
+	s.flusher.Flush()
+}
+
+// toolCallEvent is the payload of tool_call events.
+type toolCallEvent struct {
+	Agent     string `json:"agent"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// interruptEvent is the payload of interrupt events.
+type interruptEvent struct {
+	Agent     string          `json:"agent"`
+	ToolCalls []toolCallEvent `json:"tool_calls"`
+}
+
+// doneEvent is the payload of done events.
+type doneEvent struct {
+	ActiveAgent string `json:"active_agent"`
+	// Messages are the messages added by the run
+	Messages []llms.MessageContent `json:"messages"`
+}
+
+// stream executes a run and streams its progress to the client as
+// Server-Sent Events.
+func (s *Server) stream(ctx context.Context, w http.ResponseWriter, r run) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	sse := &sseWriter{w: w, flusher: flusher}
+	flusher.Flush()
+
+	execute(ctx, r, sse.send)
+}
+
+// execute executes a run, passing its events to send, and ends with an
+// interrupt, error or done event. The done event carries the messages added
+// by the run. Runs of audio messages start with a transcript event and speak
+// their answer before done.
+func execute(ctx context.Context, r run, send func(event string, data any)) {
+	if r.transcript != "" {
+		send("transcript", map[string]string{"text": r.transcript})
+	}
+	result, err := r.invoke(swarm.WithStreamHandler(ctx, streamHandler(send)))
+
+	var interrupt *swarm.InterruptError
+	switch {
+	case errors.As(err, &interrupt):
+		send("interrupt", interruptEvent{Agent: interrupt.Agent, ToolCalls: toolCallEvents(interrupt.Agent, interrupt.ToolCalls)})
+	case err != nil:
+		send("error", map[string]string{"error": err.Error()})
+	default:
+		added := result.Messages[min(r.before, len(result.Messages)):]
+		speak(ctx, r, added, send)
+		send("done", doneEvent{ActiveAgent: result.ActiveAgent, Messages: added})
+	}
+}
+
+// streamHandler returns a swarm.StreamHandler passing run events to send.
+func streamHandler(send func(event string, data any)) swarm.StreamHandler {
+	return swarm.StreamHandlerFuncs{
+		AgentStart: func(ctx context.Context, agent string) {
+			send("agent_start", map[string]string{"agent": agent})
+		},
+		AgentEnd: func(ctx context.Context, agent string, err error) {
+			payload := map[string]string{"agent": agent}
+			if err != nil {
+				payload["error"] = err.Error()
+			}
+			send("agent_end", payload)
+		},
+		Token: func(ctx context.Context, agent, token string) {
+			send("token", map[string]string{"agent": agent, "token": token})
+		},
+		ToolCall: func(ctx context.Context, agent string, call llms.ToolCall) {
+			send("tool_call", toolCallEvents(agent, []llms.ToolCall{call})[0])
+		},
+		Handoff: func(ctx context.Context, from, to string) {
+			send("handoff", map[string]string{"from": from, "to": to})
+		},
+	}
+}
+
+// toolCallEvents converts tool calls to event payloads.
+func toolCallEvents(agent string, calls []llms.ToolCall) []toolCallEvent {
+	events := make([]toolCallEvent, len(calls))
+	for i, call := range calls {
+		events[i] = toolCallEvent{Agent: agent, ID: call.ID}
+		if call.FunctionCall != nil {
+			events[i].Name = call.FunctionCall.Name
+			events[i].Arguments = call.FunctionCall.Arguments
+		}
+	}
+	return events
+}
+
+// userMessage returns a human message with the given text and image URLs.
+func userMessage(content string, images []string) llms.MessageContent {
+	attachments := make([]llms.ContentPart, len(images))
+	for i, url := range images {
+		attachments[i] = llms.ImageURLContent{URL: url}
+	}
+	return swarm.UserMessage(content, attachments...)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/voice"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxAudioSize is the largest audio message accepted, the limit of common
+// transcription APIs.
+const maxAudioSize = 25 << 20
+
+// WithVoice lets clients talk to the swarm: audio messages are transcribed
+// by transcriber and run as text, and the final answer of their runs is
+// spoken by synthesizer, which may be nil to answer in text only. It adds
+// POST /threads/{id}/audio and "audio" WebSocket frames.
+//
+// Example:
+//
+//	speech := voice.NewOpenAI(os.Getenv("OPENAI_API_KEY"))
+//	srv, err := server.New(app, server.WithVoice(speech, speech))
+func WithVoice(transcriber voice.Transcriber, synthesizer voice.Synthesizer) Option {
+	return func(s *Server) {
+		s.transcriber = transcriber
+		s.synthesizer = synthesizer
+	}
+}
+
+// audioEvent is the payload of audio events: the spoken answer, or the
+// error that prevented it.
+type audioEvent struct {
+	MIMEType string `json:"mime_type,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// handleAudio transcribes an audio message, adds it to a thread and streams
+// the run. The body is the raw audio, described by its Content-Type.
+func (s *Server) handleAudio(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAudioSize))
+	if err != nil || len(data) == 0 {
+		http.Error(w, "request body must be audio of at most 25 MiB", http.StatusBadRequest)
+		return
+	}
+	opts, ok := s.runOptions(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, ok := s.beginRun(w, r, threadID)
+	if !ok {
+		return
+	}
+	defer s.endRun(threadID)
+
+	run, err := s.audioRun(ctx, threadID, voice.Audio{MIMEType: r.Header.Get("Content-Type"), Data: data}, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.stream(ctx, w, run)
+}
+
+// audioRun prepares a run adding the transcript of audio to a thread as a
+// user message, and speaking its answer.
+func (s *Server) audioRun(ctx context.Context, threadID string, audio voice.Audio, opts []swarm.InvokeOption) (run, error) {
+	if s.transcriber == nil {
+		return run{}, &requestError{http.StatusNotImplemented, "the server does not accept audio"}
+	}
+	transcript, err := voice.Transcribe(ctx, s.transcriber, audio)
+	switch {
+	case errors.Is(err, voice.ErrNoSpeech):
+		return run{}, &requestError{http.StatusUnprocessableEntity, err.Error()}
+	case err != nil:
+		return run{}, &requestError{http.StatusBadGateway, err.Error()}
+	}
+
+	r, err := s.messageRun(ctx, threadID, userMessage(transcript, nil), opts)
+	if err != nil {
+		return run{}, err
+	}
+	r.transcript = transcript
+	r.synthesizer = s.synthesizer
+	return r, nil
+}
+
+// speak sends the spoken answer of a run as an audio event. Nothing is sent
+// when the run has no synthesizer or no answer.
+func speak(ctx context.Context, r run, added []llms.MessageContent, send func(event string, data any)) {
+	answer := finalAnswer(added)
+	if r.synthesizer == nil || answer == "" {
+		return
+	}
+	audio, err := r.synthesizer.Synthesize(ctx, answer)
+	if err != nil {
+		send("audio", audioEvent{Error: err.Error()})
+		return
+	}
+	send("audio", audioEvent{MIMEType: audio.MIMEType, Data: audio.Data})
+}
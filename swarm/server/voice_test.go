@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/go-hare/langchaingo_swarm/swarm/voice"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// echoTranscriber transcribes audio as its bytes.
+var echoTranscriber = voice.TranscriberFunc(func(ctx context.Context, audio voice.Audio) (string, error) {
+	return string(audio.Data), nil
+})
+
+// upperSynthesizer speaks text in upper case.
+var upperSynthesizer = voice.SynthesizerFunc(func(ctx context.Context, text string) (voice.Audio, error) {
+	return voice.Audio{MIMEType: "audio/mpeg", Data: []byte(strings.ToUpper(text))}, nil
+})
+
+// newVoiceServer returns a server for a single agent backed by model, with
+// voice enabled.
+func newVoiceServer(t *testing.T, model llms.Model, synthesizer voice.Synthesizer) string {
+	t.Helper()
+	ts := serve(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Alice", Runnable: reactAgent(t, model)}},
+		DefaultActiveAgent: "Alice",
+	}, WithVoice(echoTranscriber, synthesizer))
+	return ts.URL
+}
+
+func TestServerAudio(t *testing.T) {
+	model := swarmtest.NewMockModel(swarmtest.Text("Booked"))
+	url := newVoiceServer(t, model, upperSynthesizer)
+
+	status, events := post(t, url+"/threads/t1/audio", " Book me a flight ")
+	if status != http.StatusOK {
+		t.Fatalf("Status = %d", status)
+	}
+	got := names(events)
+	if got[0] != "transcript" || got[len(got)-2] != "audio" || got[len(got)-1] != "done" {
+		t.Fatalf("Events = %v", got)
+	}
+	if text := events[0].data["text"]; text != "Book me a flight" {
+		t.Errorf("Transcript = %v", text)
+	}
+	audio := events[len(events)-2].data
+	if data, _ := base64.StdEncoding.DecodeString(audio["data"].(string)); string(data) != "BOOKED" || audio["mime_type"] != "audio/mpeg" {
+		t.Errorf("Audio = %v", audio)
+	}
+	if last := model.Calls()[0][len(model.Calls()[0])-1]; last.Parts[0] != (llms.TextContent{Text: "Book me a flight"}) {
+		t.Errorf("User message = %+v", last)
+	}
+
+	if status, _ := post(t, url+"/threads/t2/audio", "   "); status != http.StatusUnprocessableEntity {
+		t.Errorf("Status without speech = %d, want 422", status)
+	}
+	if status, _ := post(t, newTestServer(t, model).URL+"/threads/t1/audio", "hi"); status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+		t.Errorf("Status without voice = %d", status)
+	}
+}
+
+func TestServerAudioSynthesisError(t *testing.T) {
+	model := swarmtest.NewMockModel(swarmtest.Text("Booked"))
+	failing := voice.SynthesizerFunc(func(ctx context.Context, text string) (voice.Audio, error) {
+		return voice.Audio{}, errors.New("quota exceeded")
+	})
+	url := newVoiceServer(t, model, failing)
+
+	_, events := post(t, url+"/threads/t1/audio", "Book it")
+	got := names(events)
+	if got[len(got)-1] != "done" || events[len(events)-2].data["error"] != "quota exceeded" {
+		t.Errorf("Events = %v, audio = %v", got, events[len(events)-2].data)
+	}
+}
+
+func TestServerSessionAudio(t *testing.T) {
+	model := swarmtest.NewMockModel(swarmtest.Text("Booked"))
+	url := newVoiceServer(t, model, upperSynthesizer)
+
+	ctx := context.Background()
+	conn, _, err := websocket.Dial(ctx, url+"/threads/t1/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "audio", "mime_type": "audio/wav", "audio": []byte("Book it")}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, last := readUntil(t, ctx, conn, "done", "error")
+	if last.Type != "done" || read[0] != "transcript" || read[len(read)-2] != "audio" {
+		t.Errorf("Frames = %v", read)
+	}
+}
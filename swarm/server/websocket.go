@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/voice"
+)
+
+// WithOriginPatterns allows WebSocket sessions from browsers on other
+// origins, given as host patterns such as "app.example.com" or
+// "*.example.com". By default only same-origin sessions are accepted.
+func WithOriginPatterns(patterns ...string) Option {
+	return func(s *Server) {
+		s.originPatterns = patterns
+	}
+}
+
+// clientFrame is a message sent by a WebSocket client.
+type clientFrame struct {
+	// Type is "message", "approval", "reply" or "audio"
+	Type string `json:"type"`
+	// Content and Images are the user message of "message" frames; Content
+	// is also the human reply of "reply" frames
+	Content string   `json:"content,omitempty"`
+	Images  []string `json:"images,omitempty"`
+	// HandoffTo is the agent a "reply" frame hands off to, if any
+	HandoffTo string `json:"handoff_to,omitempty"`
+	// Approved and Feedback answer a pending approval in "approval" frames
+	Approved bool   `json:"approved,omitempty"`
+	Feedback string `json:"feedback,omitempty"`
+	// Audio and MIMEType are the recording of "audio" frames, base64-encoded
+	Audio    []byte `json:"audio,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// serverFrame is an event sent to a WebSocket client; Type and Data are the
+// event and payload of the matching Server-Sent Event.
+type serverFrame struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// handleSession serves an interactive session on a thread over a WebSocket.
+// The client sends message, approval and reply frames, and each starts a run whose
+// events are sent back on the same connection. Frames are handled in order,
+// one run at a time; a frame that cannot be handled is answered with an
+// error event and the session goes on.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("id")
+	opts, ok := s.runOptions(w, r)
+	if !ok {
+		return
+	}
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: s.originPatterns})
+	if err != nil {
+		// Accept has answered the request
+		return
+	}
+	defer conn.CloseNow()
+
+	ctx := r.Context()
+	send := func(event string, data any) {
+		// A failed write means the client is gone; the next read reports it
+		_ = wsjson.Write(ctx, conn, serverFrame{Type: event, Data: data})
+	}
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var frame clientFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			send("error", map[string]string{"error": "frames must be JSON objects"})
+			continue
+		}
+		if err := s.sessionRun(ctx, threadID, frame, opts, send); err != nil {
+			send("error", map[string]string{"error": err.Error()})
+		}
+	}
+}
+
+// sessionRun executes the run requested by a client frame.
+func (s *Server) sessionRun(ctx context.Context, threadID string, frame clientFrame, opts []swarm.InvokeOption, send func(event string, data any)) error {
+	ctx, err := s.claimRun(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	defer s.endRun(threadID)
+
+	var r run
+	switch frame.Type {
+	case "message":
+		if frame.Content == "" && len(frame.Images) == 0 {
+			return &requestError{http.StatusBadRequest, "message frames must have a non-empty content or images"}
+		}
+		r, err = s.messageRun(ctx, threadID, userMessage(frame.Content, frame.Images), opts)
+	case "audio":
+		if len(frame.Audio) == 0 {
+			return &requestError{http.StatusBadRequest, "audio frames must have a non-empty audio"}
+		}
+		r, err = s.audioRun(ctx, threadID, voice.Audio{MIMEType: frame.MIMEType, Data: frame.Audio}, opts)
+	case "approval":
+		r, err = s.approvalRun(ctx, threadID, swarm.Approval{Approved: frame.Approved, Feedback: frame.Feedback}, opts)
+	case "reply":
+		if frame.Content == "" && frame.HandoffTo == "" {
+			return &requestError{http.StatusBadRequest, "reply frames must have a non-empty content or handoff_to"}
+		}
+		r, err = s.replyRun(ctx, threadID, swarm.HumanReply{Content: frame.Content, HandoffTo: frame.HandoffTo}, opts)
+	default:
+		return &requestError{http.StatusBadRequest, fmt.Sprintf("unknown frame type '%s'", frame.Type)}
+	}
+	if err != nil {
+		return err
+	}
+	execute(ctx, r, send)
+	return nil
+}
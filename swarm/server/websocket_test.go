@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+)
+
+// frame is a decoded server frame.
+type frame struct {
+	Type string         `json:"type"`
+	Data map[string]any `json:"data"`
+}
+
+// readUntil reads frames until one of the given types, and returns the types
+// read and the last frame.
+func readUntil(t *testing.T, ctx context.Context, conn *websocket.Conn, types ...string) ([]string, frame) {
+	t.Helper()
+	var read []string
+	for {
+		var f frame
+		if err := wsjson.Read(ctx, conn, &f); err != nil {
+			t.Fatalf("Read() error = %v (after %v)", err, read)
+		}
+		read = append(read, f.Type)
+		for _, typ := range types {
+			if f.Type == typ {
+				return read, f
+			}
+		}
+	}
+}
+
+func TestServerSession(t *testing.T) {
+	model := swarmtest.NewMockModel(
+		swarmtest.ToolCall("book", `{}`),
+		swarmtest.Text("Booked"),
+	)
+	ts := newTestServer(t, model, "book")
+
+	ctx := context.Background()
+	conn, _, err := websocket.Dial(ctx, ts.URL+"/threads/t1/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "message", "content": "Book it"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, last := readUntil(t, ctx, conn, "interrupt", "done", "error")
+	if last.Type != "interrupt" || last.Data["agent"] != "Alice" {
+		t.Fatalf("Expected an interrupt, got %v", read)
+	}
+
+	// Bad frames are answered without closing the session
+	if err := conn.Write(ctx, websocket.MessageText, []byte("not json")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, last := readUntil(t, ctx, conn, "error"); !strings.Contains(last.Data["error"].(string), "JSON") {
+		t.Errorf("Unexpected error %v", last.Data)
+	}
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "message", "content": "Hello?"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, last := readUntil(t, ctx, conn, "error"); !strings.Contains(last.Data["error"].(string), "waiting for an approval") {
+		t.Errorf("Unexpected error %v", last.Data)
+	}
+
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "approval", "approved": true}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, last = readUntil(t, ctx, conn, "interrupt", "done", "error")
+	if last.Type != "done" || !strings.Contains(strings.Join(read, ","), "tool_call,") || !strings.Contains(strings.Join(read, ","), "token") {
+		t.Errorf("Frames after approval = %v", read)
+	}
+}
+
+func TestServerSessionReply(t *testing.T) {
+	ts := serve(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Carol", Runnable: swarm.NewHumanAgent()}},
+		DefaultActiveAgent: "Carol",
+	})
+
+	ctx := context.Background()
+	conn, _, err := websocket.Dial(ctx, ts.URL+"/threads/t1/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "message", "content": "Refund my order"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if read, last := readUntil(t, ctx, conn, "interrupt", "done", "error"); last.Type != "interrupt" {
+		t.Fatalf("Expected an interrupt, got %v", read)
+	}
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "message", "content": "Hello?"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, last := readUntil(t, ctx, conn, "error"); !strings.Contains(last.Data["error"].(string), "waiting for a human reply") {
+		t.Errorf("Unexpected error %v", last.Data)
+	}
+
+	if err := wsjson.Write(ctx, conn, map[string]any{"type": "reply", "content": "Done!"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if read, last := readUntil(t, ctx, conn, "interrupt", "done", "error"); last.Type != "done" || last.Data["active_agent"] != "Carol" {
+		t.Errorf("Frames after the reply = %v, last = %v", read, last.Data)
+	}
+}
@@ -0,0 +1,361 @@
+// Package session manages concurrent conversations with a compiled swarm.
+//
+// A Manager hands out Sessions, each a conversation thread of a tenant.
+// Requests on the same session are serialized so their state never
+// interleaves, threads of different tenants are kept apart in the swarm's
+// checkpointer, and sessions idle for longer than their TTL expire:
+//
+//	manager, err := session.New(app, session.WithTTL(24*time.Hour))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	s, err := manager.Session("acme", "user_123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := s.Send(ctx, llms.TextParts(llms.ChatMessageTypeHuman, "Hello"))
+//
+// Requests are serialized within the Manager's process by default. When
+// several processes serve the same swarm, pass a distributed Locker, such
+// as the one of the checkpoint/redis package, with WithLocker.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// TenantMetadataKey is the checkpoint metadata key holding the tenant of a
+// session's checkpoints.
+const TenantMetadataKey = "tenant"
+
+// Option configures a Manager created by New.
+type Option func(*Manager)
+
+// WithTTL expires sessions that have been idle for longer than ttl: their
+// next message starts a new conversation, and Sweep deletes them. The
+// default 0 keeps sessions forever.
+func WithTTL(ttl time.Duration) Option {
+	return func(m *Manager) {
+		m.ttl = ttl
+	}
+}
+
+// WithLocker serializes the requests of each thread with locker instead of
+// in-process locks, so that several processes can serve the same threads.
+func WithLocker(locker Locker) Option {
+	return func(m *Manager) {
+		m.locker = locker
+	}
+}
+
+// Locker serializes the requests of each thread. Lock waits until the caller
+// holds the lock of key, or ctx is done; the caller releases the lock by
+// calling unlock.
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// Manager manages the sessions of a compiled swarm. It is safe for
+// concurrent use.
+type Manager struct {
+	app    *swarm.CompiledSwarm
+	store  swarm.CheckpointStore
+	ttl    time.Duration
+	locker Locker
+}
+
+// localLocker is the default Locker, serializing the requests of a thread
+// within the process.
+type localLocker struct {
+	mu    sync.Mutex
+	locks map[string]*threadLock
+}
+
+// threadLock serializes the requests of a thread. refs counts the requests
+// holding or waiting for it, so idle locks can be dropped.
+type threadLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// New creates a Manager for app. The swarm must have a Checkpointer to keep
+// the sessions' state.
+func New(app *swarm.CompiledSwarm, opts ...Option) (*Manager, error) {
+	store := app.Checkpointer()
+	if store == nil {
+		return nil, errors.New("session: the swarm has no checkpointer")
+	}
+	m := &Manager{app: app, store: store, locker: &localLocker{locks: make(map[string]*threadLock)}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Session returns the session of a tenant's thread. Sessions are cheap
+// handles; the conversation is created by its first message.
+func (m *Manager) Session(tenant, threadID string) (*Session, error) {
+	if tenant == "" {
+		return nil, errors.New("session: tenant cannot be empty")
+	}
+	if threadID == "" {
+		return nil, errors.New("session: thread ID cannot be empty")
+	}
+	return &Session{manager: m, tenant: tenant, threadID: threadID, key: threadKey(tenant, threadID)}, nil
+}
+
+// Threads returns the thread IDs of a tenant's live sessions, sorted. It
+// needs a checkpointer implementing swarm.ThreadLister.
+func (m *Manager) Threads(ctx context.Context, tenant string) ([]string, error) {
+	keys, err := m.threadKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var threads []string
+	for _, key := range keys {
+		keyTenant, threadID, ok := splitThreadKey(key)
+		if !ok || keyTenant != tenant {
+			continue
+		}
+		checkpoint, err := m.store.Latest(ctx, key)
+		if errors.Is(err, swarm.ErrCheckpointNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !m.expired(checkpoint) {
+			threads = append(threads, threadID)
+		}
+	}
+	return threads, nil
+}
+
+// Sweep deletes the sessions that expired (see WithTTL) and returns how
+// many it deleted. Call it periodically to reclaim storage; it needs a
+// checkpointer implementing swarm.ThreadLister.
+func (m *Manager) Sweep(ctx context.Context) (int, error) {
+	if m.ttl <= 0 {
+		return 0, nil
+	}
+	keys, err := m.threadKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, key := range keys {
+		if _, _, ok := splitThreadKey(key); !ok {
+			continue
+		}
+		unlock, err := m.lock(ctx, key)
+		if err != nil {
+			return deleted, err
+		}
+		checkpoint, err := m.store.Latest(ctx, key)
+		if err == nil && m.expired(checkpoint) {
+			if err = m.store.Delete(ctx, key); err == nil {
+				deleted++
+			}
+		}
+		unlock()
+		if err != nil && !errors.Is(err, swarm.ErrCheckpointNotFound) {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// threadKeys lists the threads of the checkpointer.
+func (m *Manager) threadKeys(ctx context.Context) ([]string, error) {
+	lister, ok := m.store.(swarm.ThreadLister)
+	if !ok {
+		return nil, errors.New("session: the checkpointer cannot list threads")
+	}
+	return lister.Threads(ctx)
+}
+
+// latest returns the latest checkpoint of a thread, deleting the thread
+// and reporting swarm.ErrCheckpointNotFound if it expired. The thread's
+// lock must be held.
+func (m *Manager) latest(ctx context.Context, key string) (*swarm.Checkpoint, error) {
+	checkpoint, err := m.store.Latest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if m.expired(checkpoint) {
+		if err := m.store.Delete(ctx, key); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: thread %s expired", swarm.ErrCheckpointNotFound, key)
+	}
+	return checkpoint, nil
+}
+
+// expired reports whether a thread whose latest checkpoint is checkpoint
+// has been idle for longer than the TTL.
+func (m *Manager) expired(checkpoint *swarm.Checkpoint) bool {
+	return m.ttl > 0 && time.Since(checkpoint.CreatedAt) > m.ttl
+}
+
+// lock waits until the caller holds the lock of a thread, or ctx is done.
+// Call the returned function to release it.
+func (m *Manager) lock(ctx context.Context, key string) (func(), error) {
+	return m.locker.Lock(ctx, key)
+}
+
+// Lock implements Locker.
+func (ll *localLocker) Lock(ctx context.Context, key string) (func(), error) {
+	ll.mu.Lock()
+	l, ok := ll.locks[key]
+	if !ok {
+		l = &threadLock{ch: make(chan struct{}, 1)}
+		ll.locks[key] = l
+	}
+	l.refs++
+	ll.mu.Unlock()
+
+	release := func() {
+		ll.mu.Lock()
+		defer ll.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(ll.locks, key)
+		}
+	}
+	select {
+	case l.ch <- struct{}{}:
+		return func() {
+			<-l.ch
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// threadKey returns the checkpointer thread of a tenant's thread. Tenants
+// are escaped so that they cannot contain the separator.
+func threadKey(tenant, threadID string) string {
+	return url.PathEscape(tenant) + "/" + threadID
+}
+
+// splitThreadKey returns the tenant and thread of a checkpointer thread.
+func splitThreadKey(key string) (tenant, threadID string, ok bool) {
+	escaped, threadID, ok := strings.Cut(key, "/")
+	if !ok {
+		return "", "", false
+	}
+	tenant, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", "", false
+	}
+	return tenant, threadID, true
+}
+
+// Session is a conversation thread of a tenant, created by
+// Manager.Session. Its methods may be called concurrently; requests are
+// handled one at a time, in the order they acquire the session.
+type Session struct {
+	manager  *Manager
+	tenant   string
+	threadID string
+	key      string
+}
+
+// Tenant returns the tenant of the session.
+func (s *Session) Tenant() string {
+	return s.tenant
+}
+
+// ThreadID returns the thread of the session within its tenant.
+func (s *Session) ThreadID() string {
+	return s.threadID
+}
+
+// Send adds a message to the conversation and runs the swarm, saving the
+// result. A new or expired session starts from an empty state; a session
+// waiting for an approval or a human reply must be resumed instead. It
+// waits for the session's other requests to finish first.
+func (s *Session) Send(ctx context.Context, message llms.MessageContent, opts ...swarm.InvokeOption) (swarm.SwarmState, error) {
+	unlock, err := s.manager.lock(ctx, s.key)
+	if err != nil {
+		return swarm.SwarmState{}, err
+	}
+	defer unlock()
+
+	var state swarm.SwarmState
+	checkpoint, err := s.manager.latest(ctx, s.key)
+	switch {
+	case errors.Is(err, swarm.ErrCheckpointNotFound):
+	case err != nil:
+		return state, err
+	default:
+		if _, _, ok := checkpoint.PendingApproval(); ok {
+			return checkpoint.State, fmt.Errorf("session '%s' is waiting for an approval", s.threadID)
+		}
+		if _, ok := checkpoint.PendingReply(); ok {
+			return checkpoint.State, fmt.Errorf("session '%s' is waiting for a human reply", s.threadID)
+		}
+		state = checkpoint.State
+	}
+	state.Messages = append(state.Messages, message)
+	return s.manager.app.Invoke(ctx, state, s.options(opts)...)
+}
+
+// Resume answers the pending approval, or human reply, of the session (see
+// swarm.CompiledSwarm.Resume).
+func (s *Session) Resume(ctx context.Context, input swarm.ResumeInput, opts ...swarm.InvokeOption) (swarm.SwarmState, error) {
+	unlock, err := s.manager.lock(ctx, s.key)
+	if err != nil {
+		return swarm.SwarmState{}, err
+	}
+	defer unlock()
+
+	if _, err := s.manager.latest(ctx, s.key); err != nil {
+		return swarm.SwarmState{}, err
+	}
+	return s.manager.app.Resume(ctx, s.key, input, s.options(opts)...)
+}
+
+// State returns the current state of the session. It returns an error
+// matching swarm.ErrCheckpointNotFound for new and expired sessions.
+func (s *Session) State(ctx context.Context) (swarm.SwarmState, error) {
+	unlock, err := s.manager.lock(ctx, s.key)
+	if err != nil {
+		return swarm.SwarmState{}, err
+	}
+	defer unlock()
+
+	checkpoint, err := s.manager.latest(ctx, s.key)
+	if err != nil {
+		return swarm.SwarmState{}, err
+	}
+	return checkpoint.State, nil
+}
+
+// Delete ends the session and deletes its state.
+func (s *Session) Delete(ctx context.Context) error {
+	unlock, err := s.manager.lock(ctx, s.key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.manager.store.Delete(ctx, s.key)
+}
+
+// options returns the invoke options of a run of the session.
+func (s *Session) options(opts []swarm.InvokeOption) []swarm.InvokeOption {
+	return append(slices.Clip(opts),
+		swarm.WithThreadID(s.key),
+		swarm.WithMetadata(map[string]any{TenantMetadataKey: s.tenant}))
+}
@@ -0,0 +1,238 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// invokerFunc is an agent runnable backed by a function.
+type invokerFunc func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error)
+
+func (f invokerFunc) Invoke(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
+	return f(ctx, state)
+}
+
+// echoAgent answers every message with its number in the conversation.
+var echoAgent = invokerFunc(func(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, fmt.Sprintf("message %d", len(state.Messages))))
+	return state, nil
+})
+
+// newManager returns a manager for a single echo agent.
+func newManager(t *testing.T, opts ...Option) *Manager {
+	t.Helper()
+	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Echo", Runnable: echoAgent}},
+		DefaultActiveAgent: "Echo",
+		Checkpointer:       swarm.NewMemorySaver(),
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	m, err := New(app, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return m
+}
+
+// session returns a session, failing the test on error.
+func session(t *testing.T, m *Manager, tenant, threadID string) *Session {
+	t.Helper()
+	s, err := m.Session(tenant, threadID)
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+	return s
+}
+
+// hello is a user message.
+var hello = llms.TextParts(llms.ChatMessageTypeHuman, "Hello")
+
+func TestSessionTenants(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(t)
+
+	acme := session(t, m, "acme", "user/1")
+	globex := session(t, m, "globex/eu", "user/1")
+	for range 2 {
+		if _, err := acme.Send(ctx, hello); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if _, err := globex.Send(ctx, hello); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	state, err := acme.State(ctx)
+	if err != nil {
+		t.Fatalf("State() error = %v", err)
+	}
+	if len(state.Messages) != 4 {
+		t.Errorf("Expected acme's thread to have 4 messages, got %d", len(state.Messages))
+	}
+	if state, _ := globex.State(ctx); len(state.Messages) != 2 {
+		t.Errorf("Expected globex's thread to have 2 messages, got %d", len(state.Messages))
+	}
+
+	threads, err := m.Threads(ctx, "globex/eu")
+	if err != nil {
+		t.Fatalf("Threads() error = %v", err)
+	}
+	if len(threads) != 1 || threads[0] != "user/1" {
+		t.Errorf("Threads() = %q", threads)
+	}
+	checkpoint, _ := m.store.Latest(ctx, globex.key)
+	if checkpoint.Metadata[TenantMetadataKey] != "globex/eu" {
+		t.Errorf("Expected the tenant in the checkpoint metadata, got %v", checkpoint.Metadata)
+	}
+
+	if err := acme.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := acme.State(ctx); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+		t.Errorf("State() after Delete() error = %v", err)
+	}
+	if _, err := m.Session("", "user/1"); err == nil {
+		t.Error("Session() without tenant should fail")
+	}
+}
+
+func TestSessionSerializesRequests(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(t)
+	s := session(t, m, "acme", "shared")
+
+	const requests = 20
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Send(ctx, hello); err != nil {
+				t.Errorf("Send() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	state, err := s.State(ctx)
+	if err != nil {
+		t.Fatalf("State() error = %v", err)
+	}
+	if len(state.Messages) != 2*requests {
+		t.Errorf("Expected %d messages, got %d: requests interleaved", 2*requests, len(state.Messages))
+	}
+	if locks := m.locker.(*localLocker).locks; len(locks) != 0 {
+		t.Errorf("Expected idle locks to be dropped, got %d", len(locks))
+	}
+}
+
+func TestSessionTTL(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(t, WithTTL(time.Hour))
+
+	// Sessions last used two hours ago
+	for _, threadID := range []string{"old", "stale"} {
+		if err := m.store.Put(ctx, &swarm.Checkpoint{
+			ThreadID:  threadKey("acme", threadID),
+			State:     swarm.SwarmState{Messages: []llms.MessageContent{hello, hello}},
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+		}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	if _, err := session(t, m, "acme", "fresh").Send(ctx, hello); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if threads, _ := m.Threads(ctx, "acme"); len(threads) != 1 || threads[0] != "fresh" {
+		t.Errorf("Threads() = %q, want only the fresh session", threads)
+	}
+
+	// An expired session starts a new conversation
+	stale := session(t, m, "acme", "stale")
+	if _, err := stale.State(ctx); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+		t.Errorf("State() of an expired session error = %v", err)
+	}
+	state, err := stale.Send(ctx, hello)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(state.Messages) != 2 {
+		t.Errorf("Expected a new conversation, got %d messages", len(state.Messages))
+	}
+
+	deleted, err := m.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Sweep() deleted %d sessions, want 1", deleted)
+	}
+	if _, err := m.store.Latest(ctx, threadKey("acme", "old")); !errors.Is(err, swarm.ErrCheckpointNotFound) {
+		t.Errorf("Expected the old session to be deleted, got %v", err)
+	}
+}
+
+func TestSessionLockCancellation(t *testing.T) {
+	m := newManager(t)
+	unlock, err := m.lock(context.Background(), "acme/busy")
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s := session(t, m, "acme", "busy")
+	if _, err := s.Send(ctx, hello); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send() on a busy session error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestSessionHumanReply(t *testing.T) {
+	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Carol", Runnable: swarm.NewHumanAgent()}},
+		DefaultActiveAgent: "Carol",
+		Checkpointer:       swarm.NewMemorySaver(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := session(t, m, "acme", "t1")
+	ctx := context.Background()
+
+	if _, err := s.Send(ctx, hello); !errors.Is(err, swarm.ErrInterrupted) {
+		t.Fatalf("Send() error = %v, want ErrInterrupted", err)
+	}
+	if _, err := s.Send(ctx, hello); err == nil || errors.Is(err, swarm.ErrInterrupted) {
+		t.Errorf("Send() while waiting for a reply error = %v, want a refusal", err)
+	}
+	state, err := s.Resume(ctx, swarm.HumanReply{Content: "Hi, I'm Carol"})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if len(state.Messages) != 2 {
+		t.Errorf("Resume() = %+v, want the message and the reply", state.Messages)
+	}
+}
@@ -0,0 +1,105 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// CancelledMetadataKey is the checkpoint metadata key marking the
+// checkpoint saved when a threaded run was cancelled, e.g. because the
+// client went away or the swarm shut down. Its value is the cause of the
+// cancellation, and the checkpoint holds the state after the last agent run
+// that completed. Unlike interrupted threads (see ThreadState.Interrupted),
+// cancelled threads continue with their next message.
+const CancelledMetadataKey = "cancelled"
+
+// ErrShutdown is returned by Invoke and Resume once Shutdown was called,
+// and is the cancellation cause of the runs Shutdown cancels.
+var ErrShutdown = errors.New("swarm is shut down")
+
+// runTracker tracks the runs in progress of a CompiledSwarm.
+type runTracker struct {
+	mu      sync.Mutex
+	closed  bool
+	nextID  int
+	cancels map[int]context.CancelCauseFunc
+	// drained is closed once the tracker is closed and no run remains
+	drained chan struct{}
+}
+
+// start registers a run and returns its context, derived from ctx, and the
+// function to call when the run is over. It fails with ErrShutdown once the
+// tracker is closed.
+func (t *runTracker) start(ctx context.Context) (context.Context, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ctx, nil, ErrShutdown
+	}
+	if t.cancels == nil {
+		t.cancels = make(map[int]context.CancelCauseFunc)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	id := t.nextID
+	t.nextID++
+	t.cancels[id] = cancel
+	return ctx, func() {
+		cancel(nil)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.cancels, id)
+		if t.closed && len(t.cancels) == 0 {
+			close(t.drained)
+		}
+	}, nil
+}
+
+// close stops new runs and returns a channel closed once the runs in
+// progress are over.
+func (t *runTracker) close() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		t.drained = make(chan struct{})
+		if len(t.cancels) == 0 {
+			close(t.drained)
+		}
+	}
+	return t.drained
+}
+
+// cancelAll cancels the runs in progress with cause.
+func (t *runTracker) cancelAll(cause error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, cancel := range t.cancels {
+		cancel(cause)
+	}
+}
+
+// Shutdown stops the swarm from starting new runs and waits for the runs
+// in progress to finish. If ctx is done first, it cancels them, waits for
+// them to return, and returns ctx's error; cancelled threaded runs save a
+// checkpoint marked with CancelledMetadataKey, so no turn is lost. Tools
+// that ignore their context delay the return of Shutdown.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	if err := app.Shutdown(ctx); err != nil {
+//	    log.Printf("runs cancelled at shutdown: %v", err)
+//	}
+func (c *CompiledSwarm) Shutdown(ctx context.Context) error {
+	drained := c.runs.close()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		c.runs.cancelAll(ErrShutdown)
+		<-drained
+		return ctx.Err()
+	}
+}
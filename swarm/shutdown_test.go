@@ -0,0 +1,153 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// blockingSwarm is a swarm where Alice hands off to Bob, who signals
+// started and then waits for release or for his context to be done.
+func blockingSwarm(t *testing.T, store CheckpointStore, started, release chan struct{}) *CompiledSwarm {
+	t.Helper()
+	alice := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Getting Bob"))
+		state.ActiveAgent = "Bob"
+		return state, nil
+	})
+	bob := invokerFunc(func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		close(started)
+		select {
+		case <-release:
+			state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Ahoy"))
+			return state, nil
+		case <-ctx.Done():
+			return state, ctx.Err()
+		}
+	})
+	return compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: bob},
+		},
+		DefaultActiveAgent: "Alice",
+		Checkpointer:       store,
+	})
+}
+
+func TestCancelledRunIsCheckpointed(t *testing.T) {
+	store := NewMemorySaver()
+	started := make(chan struct{})
+	app := blockingSwarm(t, store, started, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	result, err := app.Invoke(ctx, SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	}, WithThreadID("t1"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Invoke() error = %v, want context.Canceled", err)
+	}
+	if result.ActiveAgent != "Bob" || lastText(result) != "Getting Bob" {
+		t.Errorf("result = %+v, want Alice's completed run", result)
+	}
+
+	checkpoint, err := store.Latest(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if checkpoint.Metadata[CancelledMetadataKey] != "context canceled" {
+		t.Errorf("metadata = %v, want the run marked interrupted", checkpoint.Metadata)
+	}
+	if len(checkpoint.State.Messages) != 2 || checkpoint.State.ActiveAgent != "Bob" {
+		t.Errorf("checkpointed state = %+v", checkpoint.State)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	app := blockingSwarm(t, nil, started, release)
+	input := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")}}
+
+	// Shutdown waits for the run in progress
+	runErr := make(chan error, 1)
+	go func() {
+		_, err := app.Invoke(context.Background(), input)
+		runErr <- err
+	}()
+	<-started
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- app.Shutdown(context.Background()) }()
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown() returned %v before the run finished", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if err := <-runErr; err != nil {
+		t.Errorf("Invoke() error = %v", err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if _, err := app.Invoke(context.Background(), input); !errors.Is(err, ErrShutdown) {
+		t.Errorf("Invoke() after Shutdown error = %v, want ErrShutdown", err)
+	}
+}
+
+func TestShutdownCancelsRuns(t *testing.T) {
+	started := make(chan struct{})
+	app := blockingSwarm(t, nil, started, nil)
+	runErr := make(chan error, 1)
+	go func() {
+		_, err := app.Invoke(context.Background(), SwarmState{
+			Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+		})
+		runErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := app.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+	if err := <-runErr; !errors.Is(err, ErrShutdown) {
+		t.Errorf("Invoke() error = %v, want ErrShutdown", err)
+	}
+}
+
+func TestToolNodeStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var second bool
+	node := NewToolNode([]tools.Tool{
+		NewStructTool("first", "Cancels the run", func(ctx context.Context, args struct{}) (string, error) {
+			cancel()
+			return "", ctx.Err()
+		}),
+		NewStructTool("second", "Runs after first", func(ctx context.Context, args struct{}) (string, error) {
+			second = true
+			return "done", nil
+		}),
+	})
+	state := SwarmState{Messages: []llms.MessageContent{{
+		Role: llms.ChatMessageTypeAI,
+		Parts: []llms.ContentPart{
+			toolCall("call_1", "first", "{}"),
+			toolCall("call_2", "second", "{}"),
+		},
+	}}}
+	if _, err := node.Invoke(ctx, state); !errors.Is(err, context.Canceled) {
+		t.Errorf("Invoke() error = %v, want context.Canceled", err)
+	}
+	if second {
+		t.Error("second tool ran after the run was cancelled")
+	}
+}
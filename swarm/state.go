@@ -0,0 +1,189 @@
+package swarm
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Clone returns a deep copy of the state that shares no slices, maps or
+// message parts with it, so that either can be modified without affecting
+// the other. Values and Blackboard entries are copied as values: a pointer
+// or map stored in them is shared by both states.
+func (s SwarmState) Clone() SwarmState {
+	cp := s
+	cp.Messages = cloneMessages(s.Messages)
+	cp.HandoffPayload = maps.Clone(s.HandoffPayload)
+	if s.PrivateMessages != nil {
+		cp.PrivateMessages = make(map[string][]llms.MessageContent, len(s.PrivateMessages))
+		for agent, messages := range s.PrivateMessages {
+			cp.PrivateMessages[agent] = cloneMessages(messages)
+		}
+	}
+	cp.Handoffs = slices.Clone(s.Handoffs)
+	cp.Tasks = slices.Clone(s.Tasks)
+	cp.Values = maps.Clone(s.Values)
+	cp.Blackboard = maps.Clone(s.Blackboard)
+	cp.Artifacts = maps.Clone(s.Artifacts)
+	return cp
+}
+
+// cloneMessages returns a deep copy of messages.
+func cloneMessages(messages []llms.MessageContent) []llms.MessageContent {
+	if messages == nil {
+		return nil
+	}
+	cp := make([]llms.MessageContent, len(messages))
+	for i, message := range messages {
+		cp[i] = llms.MessageContent{Role: message.Role, Parts: cloneParts(message.Parts)}
+	}
+	return cp
+}
+
+// cloneParts returns a copy of parts, copying the function calls of tool
+// calls and the data of binary parts, which parts would otherwise share.
+func cloneParts(parts []llms.ContentPart) []llms.ContentPart {
+	if parts == nil {
+		return nil
+	}
+	cp := make([]llms.ContentPart, len(parts))
+	for i, part := range parts {
+		switch p := part.(type) {
+		case llms.ToolCall:
+			if p.FunctionCall != nil {
+				call := *p.FunctionCall
+				p.FunctionCall = &call
+			}
+			part = p
+		case llms.BinaryContent:
+			p.Data = slices.Clone(p.Data)
+			part = p
+		}
+		cp[i] = part
+	}
+	return cp
+}
+
+// StateSnapshot is a point-in-time copy of a swarm state, taken with
+// SwarmState.Snapshot.
+type StateSnapshot struct {
+	// State is the copy of the state
+	State SwarmState
+	// TakenAt is when the snapshot was taken
+	TakenAt time.Time
+}
+
+// Snapshot returns a copy of the state as it is now. Middlewares and
+// checkpointers can keep it across turns and goroutines: later changes to
+// the state do not show in the snapshot.
+func (s SwarmState) Snapshot() StateSnapshot {
+	return StateSnapshot{State: s.Clone(), TakenAt: time.Now()}
+}
+
+// Diff returns the changes from the snapshot to state.
+func (s StateSnapshot) Diff(state SwarmState) StateDiff {
+	return DiffStates(s.State, state)
+}
+
+// StateDiff describes the changes between two swarm states, as returned by
+// DiffStates.
+type StateDiff struct {
+	// AddedMessages are the messages of the new state after those it has
+	// in common with the old one
+	AddedMessages []llms.MessageContent
+	// RemovedMessages is the number of messages of the old state that the
+	// new one does not start with, e.g. after a context policy trimmed the
+	// conversation. It is 0 when messages were only appended.
+	RemovedMessages int
+	// PreviousActiveAgent and ActiveAgent are the active agents of the old
+	// and new states
+	PreviousActiveAgent string
+	ActiveAgent         string
+	// AddedHandoffs are the handoffs recorded after those of the old state
+	AddedHandoffs []HandoffRecord
+	// ChangedValues holds the Values entries that were added or changed
+	ChangedValues map[string]any
+	// RemovedValues lists the Values keys that were removed, sorted
+	RemovedValues []string
+	// ChangedBlackboard holds the Blackboard entries that were added or
+	// changed
+	ChangedBlackboard map[string]any
+	// RemovedBlackboard lists the Blackboard keys that were removed, sorted
+	RemovedBlackboard []string
+}
+
+// ActiveAgentChanged reports whether the active agent changed.
+func (d StateDiff) ActiveAgentChanged() bool {
+	return d.PreviousActiveAgent != d.ActiveAgent
+}
+
+// IsEmpty reports whether the diff holds no changes.
+func (d StateDiff) IsEmpty() bool {
+	return len(d.AddedMessages) == 0 && d.RemovedMessages == 0 && !d.ActiveAgentChanged() &&
+		len(d.AddedHandoffs) == 0 && len(d.ChangedValues) == 0 && len(d.RemovedValues) == 0 &&
+		len(d.ChangedBlackboard) == 0 && len(d.RemovedBlackboard) == 0
+}
+
+// DiffStates returns the changes from before to after: the messages and
+// handoffs after added, the active agent change and the Values and
+// Blackboard changes.
+// Messages are compared by value, so a message rewritten in place counts as
+// removed and added again with those following it. The returned slices and
+// maps are copies.
+//
+// Example:
+//
+//	before := state.Snapshot()
+//	result, err := app.Invoke(ctx, state)
+//	diff := before.Diff(result)
+//	if diff.ActiveAgentChanged() {
+//	    log.Printf("handed off to %s", diff.ActiveAgent)
+//	}
+func DiffStates(before, after SwarmState) StateDiff {
+	diff := StateDiff{
+		PreviousActiveAgent: before.ActiveAgent,
+		ActiveAgent:         after.ActiveAgent,
+	}
+
+	common := 0
+	for common < len(before.Messages) && common < len(after.Messages) &&
+		reflect.DeepEqual(before.Messages[common], after.Messages[common]) {
+		common++
+	}
+	diff.AddedMessages = cloneMessages(after.Messages[common:])
+	diff.RemovedMessages = len(before.Messages) - common
+	if len(diff.AddedMessages) == 0 {
+		diff.AddedMessages = nil
+	}
+
+	if len(after.Handoffs) > len(before.Handoffs) {
+		diff.AddedHandoffs = slices.Clone(after.Handoffs[len(before.Handoffs):])
+	}
+
+	diff.ChangedValues, diff.RemovedValues = diffMaps(before.Values, after.Values)
+	diff.ChangedBlackboard, diff.RemovedBlackboard = diffMaps(before.Blackboard, after.Blackboard)
+	return diff
+}
+
+// diffMaps returns the entries of after that are not in before or differ,
+// and the sorted keys of before that are not in after.
+func diffMaps(before, after map[string]any) (changed map[string]any, removed []string) {
+	for key, value := range after {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			if changed == nil {
+				changed = make(map[string]any)
+			}
+			changed[key] = value
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	slices.Sort(removed)
+	return changed, removed
+}
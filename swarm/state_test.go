@@ -0,0 +1,146 @@
+package swarm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestStateClone(t *testing.T) {
+	state := SwarmState{
+		Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Hello"),
+			{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{
+				llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "lookup", Arguments: "{}"}},
+				llms.BinaryContent{MIMEType: "image/png", Data: []byte{1, 2}},
+			}},
+		},
+		ActiveAgent:     "Alice",
+		HandoffPayload:  map[string]any{"topic": "billing"},
+		PrivateMessages: map[string][]llms.MessageContent{"Alice": {llms.TextParts(llms.ChatMessageTypeAI, "draft")}},
+		Handoffs:        []HandoffRecord{{From: "Alice", To: "Bob"}},
+		Values:          map[string]any{"count": 1},
+	}
+	clone := state.Clone()
+	if !reflect.DeepEqual(clone, state) {
+		t.Fatalf("clone = %+v, want %+v", clone, state)
+	}
+
+	clone.Messages[0].Parts[0] = llms.TextContent{Text: "changed"}
+	clone.Messages[1].Parts[0].(llms.ToolCall).FunctionCall.Arguments = `{"x":1}`
+	clone.Messages[1].Parts[1].(llms.BinaryContent).Data[0] = 9
+	clone.Messages = append(clone.Messages, llms.TextParts(llms.ChatMessageTypeAI, "more"))
+	clone.HandoffPayload["topic"] = "sales"
+	clone.PrivateMessages["Alice"][0].Parts[0] = llms.TextContent{Text: "changed"}
+	clone.Handoffs[0].To = "Carol"
+	clone.Values["count"] = 2
+
+	if got := state.Messages[0].Parts[0].(llms.TextContent).Text; got != "Hello" {
+		t.Errorf("original message = %q", got)
+	}
+	if got := state.Messages[1].Parts[0].(llms.ToolCall).FunctionCall.Arguments; got != "{}" {
+		t.Errorf("original tool call arguments = %q", got)
+	}
+	if got := state.Messages[1].Parts[1].(llms.BinaryContent).Data[0]; got != 1 {
+		t.Errorf("original binary data = %d", got)
+	}
+	if len(state.Messages) != 2 {
+		t.Errorf("original has %d messages", len(state.Messages))
+	}
+	if state.HandoffPayload["topic"] != "billing" {
+		t.Errorf("original payload = %v", state.HandoffPayload)
+	}
+	if got := state.PrivateMessages["Alice"][0].Parts[0].(llms.TextContent).Text; got != "draft" {
+		t.Errorf("original private message = %q", got)
+	}
+	if state.Handoffs[0].To != "Bob" {
+		t.Errorf("original handoff = %+v", state.Handoffs[0])
+	}
+	if state.Values["count"] != 1 {
+		t.Errorf("original values = %v", state.Values)
+	}
+}
+
+func TestStateSnapshot(t *testing.T) {
+	state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")}}
+	snapshot := state.Snapshot()
+	if snapshot.TakenAt.IsZero() {
+		t.Error("TakenAt is not set")
+	}
+
+	state.Messages[0].Parts[0] = llms.TextContent{Text: "changed"}
+	state.ActiveAgent = "Bob"
+	if got := snapshot.State.Messages[0].Parts[0].(llms.TextContent).Text; got != "Hello" {
+		t.Errorf("snapshot message = %q", got)
+	}
+
+	diff := snapshot.Diff(state)
+	if diff.RemovedMessages != 1 || len(diff.AddedMessages) != 1 || !diff.ActiveAgentChanged() {
+		t.Errorf("diff = %+v", diff)
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	hello := llms.TextParts(llms.ChatMessageTypeHuman, "Hello")
+	answer := llms.TextParts(llms.ChatMessageTypeAI, "Hi")
+	before := SwarmState{
+		Messages:    []llms.MessageContent{hello},
+		ActiveAgent: "Alice",
+		Values:      map[string]any{"kept": 1, "changed": "a", "removed": true},
+	}
+
+	tests := []struct {
+		name  string
+		after SwarmState
+		want  StateDiff
+	}{
+		{
+			name:  "unchanged",
+			after: before.Clone(),
+			want:  StateDiff{PreviousActiveAgent: "Alice", ActiveAgent: "Alice"},
+		},
+		{
+			name: "appended and handed off",
+			after: SwarmState{
+				Messages:    []llms.MessageContent{hello, answer},
+				ActiveAgent: "Bob",
+				Handoffs:    []HandoffRecord{{From: "Alice", To: "Bob"}},
+				Values:      map[string]any{"kept": 1, "changed": "b", "added": 2},
+			},
+			want: StateDiff{
+				AddedMessages:       []llms.MessageContent{answer},
+				PreviousActiveAgent: "Alice",
+				ActiveAgent:         "Bob",
+				AddedHandoffs:       []HandoffRecord{{From: "Alice", To: "Bob"}},
+				ChangedValues:       map[string]any{"changed": "b", "added": 2},
+				RemovedValues:       []string{"removed"},
+			},
+		},
+		{
+			name: "rewritten",
+			after: SwarmState{
+				Messages:    []llms.MessageContent{answer},
+				ActiveAgent: "Alice",
+				Values:      before.Values,
+			},
+			want: StateDiff{
+				AddedMessages:       []llms.MessageContent{answer},
+				RemovedMessages:     1,
+				PreviousActiveAgent: "Alice",
+				ActiveAgent:         "Alice",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffStates(before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffStates() = %+v, want %+v", got, tt.want)
+			}
+			if got.IsEmpty() != (tt.name == "unchanged") {
+				t.Errorf("IsEmpty() = %v", got.IsEmpty())
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package swarm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// DefaultMinOffloadBytes is the size below which message parts are not
+// offloaded, for a StateLimit without MinPartBytes.
+const DefaultMinOffloadBytes = 1024
+
+// offloadPreview is the number of bytes of an offloaded text kept in the
+// conversation, so that agents know what the artifact holds.
+const offloadPreview = 200
+
+// offloadedPrefix starts the text replacing an offloaded or summarized
+// part, which is never offloaded again.
+const offloadedPrefix = "[Offloaded"
+
+// summaryPrefix starts the text replacing a summarized part.
+const summaryPrefix = "[Offloaded, summary] "
+
+// SummarizeFunc summarizes the text of a message part.
+type SummarizeFunc func(ctx context.Context, text string) (string, error)
+
+// SummarizeWithModel returns a SummarizeFunc asking model for a summary.
+func SummarizeWithModel(model llms.Model) SummarizeFunc {
+	return func(ctx context.Context, text string) (string, error) {
+		return llms.GenerateFromSinglePrompt(ctx, model,
+			"Summarize the following content in a few sentences, keeping names, figures and facts an assistant may need later:\n\n"+text)
+	}
+}
+
+// StateLimit bounds the size of the swarm state over long threads, for
+// SwarmConfig.StateLimit. When the state grows past MaxBytes after an agent
+// run, its oldest large message parts are offloaded to SwarmConfig.Artifacts,
+// leaving a reference and a preview in the conversation, or replaced with
+// their summary when the swarm has no artifact store. An
+// events.StateOffloaded event reports each offload.
+//
+// Example:
+//
+//	swarm.SwarmConfig{
+//	    Agents:     agents,
+//	    Artifacts:  artifact.NewS3("my-bucket", "eu-west-1", credentials),
+//	    StateLimit: &swarm.StateLimit{MaxBytes: 256 << 10},
+//	}
+type StateLimit struct {
+	// MaxBytes is the size, as encoded by MarshalState, past which parts
+	// are offloaded
+	MaxBytes int
+	// MinPartBytes is the size below which parts are kept (default:
+	// DefaultMinOffloadBytes). The state may stay over MaxBytes when only
+	// small parts are left.
+	MinPartBytes int
+	// Summarize replaces the parts with their summary when the swarm has
+	// no artifact store, e.g. SummarizeWithModel(model)
+	Summarize SummarizeFunc
+}
+
+// enforce offloads the oldest large message parts of state until it is
+// under the limit, after agent's run. It is a no-op on a nil StateLimit.
+func (l *StateLimit) enforce(ctx context.Context, config SwarmConfig, agent string, state SwarmState) (SwarmState, error) {
+	if l == nil {
+		return state, nil
+	}
+	data, err := MarshalState(state)
+	if err != nil {
+		return state, fmt.Errorf("state limit: %w", err)
+	}
+	sizeBefore := len(data)
+	if sizeBefore <= l.MaxBytes {
+		return state, nil
+	}
+	minBytes := l.MinPartBytes
+	if minBytes <= 0 {
+		minBytes = DefaultMinOffloadBytes
+	}
+
+	// Messages and parts are copied before they are replaced, so states
+	// sharing them are not affected
+	size := sizeBefore
+	messages := slices.Clone(state.Messages)
+	var artifacts []string
+	summarized := 0
+offload:
+	for i := range messages {
+		for j, part := range messages[i].Parts {
+			if size <= l.MaxBytes {
+				break offload
+			}
+			content, contentType, ok := partContent(part)
+			if !ok || len(content) < minBytes || strings.HasPrefix(string(content), offloadedPrefix) {
+				continue
+			}
+			var replacement string
+			switch {
+			case config.Artifacts != nil:
+				digest := sha256.Sum256(content)
+				ref, err := putArtifact(ctx, config.Artifacts, "offloaded-"+hex.EncodeToString(digest[:6]), content, contentType)
+				if err != nil {
+					return state, fmt.Errorf("state limit: %w", err)
+				}
+				state.SetArtifact(ref)
+				artifacts = append(artifacts, ref.Name)
+				replacement = fmt.Sprintf("%s to %s] %s", offloadedPrefix, ref, preview(content, contentType))
+			case l.Summarize != nil && strings.HasPrefix(contentType, "text/"):
+				summary, err := l.Summarize(ctx, string(content))
+				if err != nil {
+					return state, fmt.Errorf("state limit: summarize: %w", err)
+				}
+				summarized++
+				replacement = summaryPrefix + summary
+			default:
+				continue
+			}
+			parts := slices.Clone(messages[i].Parts)
+			parts[j] = replacePartContent(part, replacement)
+			messages[i].Parts = parts
+			size -= len(content) - len(replacement)
+		}
+	}
+	if len(artifacts) == 0 && summarized == 0 {
+		return state, nil
+	}
+	state.Messages = messages
+
+	if data, err = MarshalState(state); err == nil {
+		size = len(data)
+	}
+	if bus := events.BusFromContext(ctx); bus != nil {
+		bus.Publish(ctx, events.StateOffloaded{
+			Time:       time.Now(),
+			Agent:      agent,
+			SizeBefore: sizeBefore,
+			SizeAfter:  size,
+			Artifacts:  artifacts,
+			Summarized: summarized,
+		})
+	}
+	if config.Logger != nil {
+		config.Logger.LogAttrs(ctx, config.LogLevel, "state offloaded",
+			slog.String("agent", agent),
+			slog.Int("size_before", sizeBefore),
+			slog.Int("size_after", size),
+			slog.Int("artifacts", len(artifacts)),
+			slog.Int("summarized", summarized))
+	}
+	return state, nil
+}
+
+// partContent returns the content of a text, tool response or binary part,
+// and its media type. It reports false for other parts.
+func partContent(part llms.ContentPart) ([]byte, string, bool) {
+	switch p := part.(type) {
+	case llms.TextContent:
+		return []byte(p.Text), "text/plain", true
+	case llms.ToolCallResponse:
+		return []byte(p.Content), "text/plain", true
+	case llms.BinaryContent:
+		return p.Data, p.MIMEType, true
+	}
+	return nil, "", false
+}
+
+// replacePartContent returns part with its content replaced by text. Binary
+// parts become text parts.
+func replacePartContent(part llms.ContentPart, text string) llms.ContentPart {
+	if p, ok := part.(llms.ToolCallResponse); ok {
+		p.Content = text
+		return p
+	}
+	return llms.TextContent{Text: text}
+}
+
+// preview returns the start of an offloaded text, or nothing for other
+// content.
+func preview(content []byte, contentType string) string {
+	if !strings.HasPrefix(contentType, "text/") {
+		return ""
+	}
+	if len(content) <= offloadPreview {
+		return string(content)
+	}
+	return strings.ToValidUTF8(string(content[:offloadPreview]), "") + "..."
+}
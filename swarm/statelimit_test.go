@@ -0,0 +1,120 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// stateLimitSwarm returns a swarm whose agent fetches a 3.8 KB document,
+// under config's artifact store and state limit, and the offload events of
+// its runs.
+func stateLimitSwarm(t *testing.T, config SwarmConfig) (*CompiledSwarm, *[]events.StateOffloaded) {
+	t.Helper()
+	fetch := NewStructTool("fetch", "Fetches a document.", func(ctx context.Context, _ struct{}) (string, error) {
+		return strings.Repeat("Quarterly results. ", 200), nil
+	})
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "fetch", `{}`),
+		{Content: "Results are up."},
+	}}
+	agent, err := CreateReactAgent(model, []tools.Tool{fetch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offloads []events.StateOffloaded
+	bus := events.NewBus()
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		offloads = append(offloads, e.(events.StateOffloaded))
+	}, events.TypeStateOffloaded)
+
+	config.Agents = []Agent{{Name: "Researcher", Runnable: agent}}
+	config.DefaultActiveAgent = "Researcher"
+	config.Events = bus
+	return compileSwarm(t, config), &offloads
+}
+
+func TestStateLimitOffloadsToArtifacts(t *testing.T) {
+	store := NewMemoryArtifactStore()
+	app, offloads := stateLimitSwarm(t, SwarmConfig{Artifacts: store, StateLimit: &StateLimit{MaxBytes: 2000}})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "How are the results?"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	response := result.Messages[2].Parts[0].(llms.ToolCallResponse)
+	if !strings.HasPrefix(response.Content, "[Offloaded to artifact://offloaded-") || !strings.Contains(response.Content, "Quarterly results.") {
+		t.Errorf("tool response = %q, want a reference and a preview", response.Content)
+	}
+	if len(result.Artifacts) != 1 || len(store.Keys()) != 1 {
+		t.Fatalf("Artifacts = %v, stored %v", result.Artifacts, store.Keys())
+	}
+	for _, ref := range result.Artifacts {
+		if data, _ := store.Get(context.Background(), ref.Key); len(data) != 3800 {
+			t.Errorf("offloaded %d bytes, want the whole response", len(data))
+		}
+	}
+	if data, _ := MarshalState(result); len(data) > 2000 {
+		t.Errorf("state is %d bytes, want at most 2000", len(data))
+	}
+
+	if len(*offloads) != 1 {
+		t.Fatalf("published %d StateOffloaded events, want 1", len(*offloads))
+	}
+	e := (*offloads)[0]
+	if e.Agent != "Researcher" || e.SizeBefore <= 2000 || e.SizeAfter > 2000 || len(e.Artifacts) != 1 || e.Summarized != 0 {
+		t.Errorf("event = %+v", e)
+	}
+}
+
+func TestStateLimitSummarizes(t *testing.T) {
+	summarize := func(ctx context.Context, text string) (string, error) {
+		return "Results are up.", nil
+	}
+	app, offloads := stateLimitSwarm(t, SwarmConfig{StateLimit: &StateLimit{MaxBytes: 2000, Summarize: summarize}})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "How are the results?"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if response := result.Messages[2].Parts[0].(llms.ToolCallResponse); response.Content != summaryPrefix+"Results are up." {
+		t.Errorf("tool response = %q, want its summary", response.Content)
+	}
+	if len(*offloads) != 1 || (*offloads)[0].Summarized != 1 {
+		t.Errorf("events = %+v", *offloads)
+	}
+}
+
+func TestStateLimitUnderLimit(t *testing.T) {
+	app, offloads := stateLimitSwarm(t, SwarmConfig{Artifacts: NewMemoryArtifactStore(), StateLimit: &StateLimit{MaxBytes: 1 << 20}})
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "How are the results?"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Artifacts) != 0 || len(*offloads) != 0 {
+		t.Errorf("offloaded a state under its limit: %v, %v", result.Artifacts, *offloads)
+	}
+}
+
+func TestStateLimitValidation(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")}},
+		DefaultActiveAgent: "Alice",
+		StateLimit:         &StateLimit{},
+	})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 2 {
+		t.Errorf("CreateSwarm() error = %v, want the missing MaxBytes and artifact store", err)
+	}
+}
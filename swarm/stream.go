@@ -0,0 +1,121 @@
+package swarm
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// StreamHandler receives real-time events from a running swarm, such as
+// model tokens, so UIs can render partial output.
+//
+// Attach a handler to a run with WithStreamHandler, or to every run with
+// SwarmConfig.StreamHandler. Methods are called from the goroutine running
+// the swarm and should return quickly.
+type StreamHandler interface {
+	// OnAgentStart is called before an agent runs
+	OnAgentStart(ctx context.Context, agent string)
+	// OnAgentEnd is called after an agent ran; err is its error, if any
+	OnAgentEnd(ctx context.Context, agent string, err error)
+	// OnToken is called for each chunk of text streamed by an agent's model
+	OnToken(ctx context.Context, agent string, token string)
+	// OnToolCall is called before an agent's tool call is executed
+	OnToolCall(ctx context.Context, agent string, call llms.ToolCall)
+	// OnHandoff is called when an agent hands off to another agent
+	OnHandoff(ctx context.Context, from, to string)
+}
+
+// StreamHandlerFuncs is a StreamHandler built from optional functions.
+// Nil fields are ignored.
+//
+// Example:
+//
+//	ctx = swarm.WithStreamHandler(ctx, swarm.StreamHandlerFuncs{
+//	    Token: func(ctx context.Context, agent, token string) { fmt.Print(token) },
+//	})
+type StreamHandlerFuncs struct {
+	AgentStart func(ctx context.Context, agent string)
+	AgentEnd   func(ctx context.Context, agent string, err error)
+	Token      func(ctx context.Context, agent string, token string)
+	ToolCall   func(ctx context.Context, agent string, call llms.ToolCall)
+	Handoff    func(ctx context.Context, from, to string)
+}
+
+// OnAgentStart calls f.AgentStart if set.
+func (f StreamHandlerFuncs) OnAgentStart(ctx context.Context, agent string) {
+	if f.AgentStart != nil {
+		f.AgentStart(ctx, agent)
+	}
+}
+
+// OnAgentEnd calls f.AgentEnd if set.
+func (f StreamHandlerFuncs) OnAgentEnd(ctx context.Context, agent string, err error) {
+	if f.AgentEnd != nil {
+		f.AgentEnd(ctx, agent, err)
+	}
+}
+
+// OnToken calls f.Token if set.
+func (f StreamHandlerFuncs) OnToken(ctx context.Context, agent string, token string) {
+	if f.Token != nil {
+		f.Token(ctx, agent, token)
+	}
+}
+
+// OnToolCall calls f.ToolCall if set.
+func (f StreamHandlerFuncs) OnToolCall(ctx context.Context, agent string, call llms.ToolCall) {
+	if f.ToolCall != nil {
+		f.ToolCall(ctx, agent, call)
+	}
+}
+
+// OnHandoff calls f.Handoff if set.
+func (f StreamHandlerFuncs) OnHandoff(ctx context.Context, from, to string) {
+	if f.Handoff != nil {
+		f.Handoff(ctx, from, to)
+	}
+}
+
+// streamHandlerKey is the context key for the StreamHandler of a run.
+type streamHandlerKey struct{}
+
+// agentNameKey is the context key for the name of the running agent.
+type agentNameKey struct{}
+
+// WithStreamHandler returns a context that delivers swarm events to h.
+// It takes precedence over SwarmConfig.StreamHandler.
+func WithStreamHandler(ctx context.Context, h StreamHandler) context.Context {
+	return context.WithValue(ctx, streamHandlerKey{}, h)
+}
+
+// StreamHandlerFromContext returns the StreamHandler attached to ctx, or nil.
+func StreamHandlerFromContext(ctx context.Context) StreamHandler {
+	h, _ := ctx.Value(streamHandlerKey{}).(StreamHandler)
+	return h
+}
+
+// AgentNameFromContext returns the name of the swarm agent running with ctx,
+// or "" outside of a swarm agent.
+func AgentNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(agentNameKey{}).(string)
+	return name
+}
+
+// withAgentName returns a context recording the running agent.
+func withAgentName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, agentNameKey{}, name)
+}
+
+// streamingOption returns a call option forwarding model tokens to the
+// StreamHandler in ctx, or nil when there is none.
+func streamingOption(ctx context.Context) llms.CallOption {
+	h := StreamHandlerFromContext(ctx)
+	if h == nil {
+		return nil
+	}
+	agent := AgentNameFromContext(ctx)
+	return llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+		h.OnToken(ctx, agent, string(chunk))
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestStreamHandler(t *testing.T) {
+	var events []string
+	handler := StreamHandlerFuncs{
+		AgentStart: func(ctx context.Context, agent string) { events = append(events, "start "+agent) },
+		AgentEnd:   func(ctx context.Context, agent string, err error) { events = append(events, "end "+agent) },
+		Token: func(ctx context.Context, agent, token string) {
+			events = append(events, fmt.Sprintf("token %s %s", agent, token))
+		},
+		ToolCall: func(ctx context.Context, agent string, call llms.ToolCall) {
+			events = append(events, fmt.Sprintf("tool %s %s", agent, call.FunctionCall.Name))
+		},
+		Handoff: func(ctx context.Context, from, to string) { events = append(events, "handoff "+from+" "+to) },
+	}
+
+	tests := []struct {
+		name   string
+		config func(*SwarmConfig)
+		ctx    func(context.Context) context.Context
+	}{
+		{"context handler", func(*SwarmConfig) {}, func(ctx context.Context) context.Context { return WithStreamHandler(ctx, handler) }},
+		{"config handler", func(c *SwarmConfig) { c.StreamHandler = handler }, func(ctx context.Context) context.Context { return ctx }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			alice, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+				toolCallChoice("call_1", "upper", `{"input":"hi"}`),
+				toolCallChoice("call_2", "transfer_to_bob", `{}`),
+			}}, []tools.Tool{upperTool{}, CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+			if err != nil {
+				t.Fatalf("CreateReactAgent() error = %v", err)
+			}
+			bob, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+				{Content: "Ahoy"},
+			}}, nil)
+			if err != nil {
+				t.Fatalf("CreateReactAgent() error = %v", err)
+			}
+
+			config := SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: alice},
+					{Name: "Bob", Runnable: bob},
+				},
+				DefaultActiveAgent: "Alice",
+			}
+			tt.config(&config)
+
+			s, err := CreateSwarm(config)
+			if err != nil {
+				t.Fatalf("CreateSwarm() error = %v", err)
+			}
+			app, err := s.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			if _, err := app.Invoke(tt.ctx(context.Background()), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")},
+			}); err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+
+			want := []string{
+				"start Alice",
+				"tool Alice upper",
+				"tool Alice transfer_to_bob",
+				"end Alice",
+				"handoff Alice Bob",
+				"start Bob",
+				"token Bob Ahoy",
+				"end Bob",
+			}
+			if !reflect.DeepEqual(events, want) {
+				t.Errorf("Events = %v, want %v", events, want)
+			}
+		})
+	}
+}
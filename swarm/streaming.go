@@ -1,14 +1,12 @@
 package swarm
 
 import (
-	"context"
-	"fmt"
-
 	"github.com/smallnest/langgraphgo/graph"
 )
 
 // CreateStreamingSwarm creates a multi-agent swarm graph with streaming support.
-// This is the streaming version of CreateSwarm.
+// This is the streaming version of CreateSwarm: each stream starts with the
+// state's ActiveAgent, or with DefaultActiveAgent when none is set.
 //
 // Returns:
 //   - A StreamingStateGraph ready to be compiled with CompileStreaming()
@@ -25,86 +23,29 @@ import (
 //	streamingApp, _ := workflow.CompileStreaming()
 //	streamResult := streamingApp.Stream(ctx, initialState)
 func CreateStreamingSwarm(config SwarmConfig) (*graph.StreamingStateGraph[SwarmState], error) {
-	if len(config.Agents) == 0 {
-		return nil, fmt.Errorf("agents list cannot be empty")
-	}
-
-	agentNames := make([]string, len(config.Agents))
-	for i, agent := range config.Agents {
-		agentNames[i] = agent.Name
-	}
-
-	// Validate default active agent
-	found := false
-	for _, name := range agentNames {
-		if name == config.DefaultActiveAgent {
-			found = true
-			break
-		}
-	}
-	if !found {
-		return nil, fmt.Errorf("default active agent '%s' not found in agent names %v",
-			config.DefaultActiveAgent, agentNames)
+	agentNames, err := validateConfig(&config)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create STREAMING state graph (key difference!)
 	g := graph.NewStreamingStateGraph[SwarmState]()
 
-	// Set entry point to default active agent
-	g.SetEntryPoint(config.DefaultActiveAgent)
+	// Enter through the same start router as CreateSwarm, so conversations
+	// resume with their active agent. It is added to the listenable graph so
+	// that it is streamed like the agent nodes.
+	g.AddNode(startNode, "Route to the active agent", routerNodeFunc(config.Router, agentNames))
+	g.SetEntryPoint(startNode)
+	g.AddConditionalEdge(startNode, activeAgentRoute(config.DefaultActiveAgent))
 
 	// Add nodes for each agent
 	for _, agent := range config.Agents {
-		agentCopy := agent
-
-		nodeFunc := func(ctx context.Context, state SwarmState) (SwarmState, error) {
-			// Try typed Invoke first (returns SwarmState directly)
-			if invoker, ok := agentCopy.Runnable.(interface {
-				Invoke(context.Context, SwarmState) (SwarmState, error)
-			}); ok {
-				return invoker.Invoke(ctx, state)
-			}
-
-			// Fallback to any return type
-			if invoker, ok := agentCopy.Runnable.(interface {
-				Invoke(context.Context, SwarmState) (any, error)
-			}); ok {
-				result, err := invoker.Invoke(ctx, state)
-				if err != nil {
-					return state, err
-				}
-				if resultState, ok := result.(SwarmState); ok {
-					return resultState, nil
-				}
-			}
-
-			return state, nil
-		}
-
-		g.AddNode(agentCopy.Name, "", nodeFunc)
+		g.AddNode(agent.Name, "", agentNode(agent, config))
 	}
 
-	// Add edges
+	// Route to the new active agent after a handoff
 	for _, agent := range config.Agents {
-		if len(agent.Destinations) > 0 {
-			// Has destinations - add conditional edge for routing
-			agentCopy := agent
-			g.AddConditionalEdge(agentCopy.Name, func(ctx context.Context, state SwarmState) string {
-				// If active agent changed, route to new agent
-				if state.ActiveAgent != "" && state.ActiveAgent != agentCopy.Name {
-					// Check if destination is valid
-					for _, dest := range agentCopy.Destinations {
-						if dest == state.ActiveAgent {
-							return state.ActiveAgent
-						}
-					}
-				}
-				return graph.END
-			})
-		} else {
-			// No destinations - go to END
-			g.AddEdge(agent.Name, graph.END)
-		}
+		g.AddConditionalEdge(agent.Name, handoffRoute(agent, config, agentNames))
 	}
 
 	return g, nil
@@ -0,0 +1,44 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestStreamingSwarmResumesActiveAgent(t *testing.T) {
+	workflow, err := CreateStreamingSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("CreateStreamingSwarm() error = %v", err)
+	}
+	app, err := workflow.CompileStreaming()
+	if err != nil {
+		t.Fatalf("CompileStreaming() error = %v", err)
+	}
+
+	for _, tt := range []struct{ active, reply string }{{"", "Hi"}, {"Bob", "Ahoy"}} {
+		stream := app.Stream(context.Background(), SwarmState{
+			Messages:    []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hello")},
+			ActiveAgent: tt.active,
+		})
+		for range stream.Events {
+		}
+		if err := <-stream.Errors; err != nil {
+			t.Fatalf("Stream() error = %v", err)
+		}
+		result := <-stream.Result
+		if len(result.Messages) != 2 {
+			t.Fatalf("ActiveAgent %q: expected a single reply, got %d messages", tt.active, len(result.Messages))
+		}
+		if got := result.Messages[1].Parts[0].(llms.TextContent).Text; got != tt.reply {
+			t.Errorf("ActiveAgent %q: reply = %q, want %q", tt.active, got, tt.reply)
+		}
+	}
+}
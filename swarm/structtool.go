@@ -0,0 +1,216 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StructTool is a tool whose arguments are the fields of a struct. It is
+// created by NewStructTool.
+type StructTool[T any] struct {
+	name        string
+	description string
+	fn          func(ctx context.Context, args T) (string, error)
+	schema      map[string]any
+	required    []string
+}
+
+// NewStructTool creates a tool that takes the fields of T as its arguments.
+//
+// The JSON schema advertised to the model (see ToolDefinition) is derived
+// from T: property names follow the json tags, fields tagged omitempty are
+// optional and all others are required. A jsonschema tag adds details to a
+// property as comma-separated options:
+//
+//   - description=...: describes the argument to the model
+//   - enum=...: an allowed value; repeat for several
+//   - minimum=..., maximum=...: bounds of a number
+//   - format=...: a string format such as "date"
+//   - required / optional: overrides the omitempty rule
+//
+// When called, the JSON arguments are decoded into a T and passed to fn.
+// T must be a struct type; NewStructTool panics otherwise.
+//
+// Example:
+//
+//	type addArgs struct {
+//	    A int `json:"a" jsonschema:"description=First number"`
+//	    B int `json:"b" jsonschema:"description=Second number"`
+//	}
+//
+//	add := swarm.NewStructTool("add", "Add two numbers", func(ctx context.Context, args addArgs) (string, error) {
+//	    return strconv.Itoa(args.A + args.B), nil
+//	})
+func NewStructTool[T any](name, description string, fn func(ctx context.Context, args T) (string, error)) *StructTool[T] {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("swarm: NewStructTool argument type must be a struct, got %s", t))
+	}
+
+	schema := typeSchema(t)
+	required, _ := schema["required"].([]string)
+	return &StructTool[T]{
+		name:        name,
+		description: description,
+		fn:          fn,
+		schema:      schema,
+		required:    required,
+	}
+}
+
+// Name returns the name of the tool.
+func (t *StructTool[T]) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool.
+func (t *StructTool[T]) Description() string {
+	return t.description
+}
+
+// Schema returns the JSON schema derived from T.
+func (t *StructTool[T]) Schema() map[string]any {
+	return t.schema
+}
+
+// Call decodes the JSON arguments into a T and calls the tool's function.
+// Missing required arguments are reported as an error.
+func (t *StructTool[T]) Call(ctx context.Context, input string) (string, error) {
+	if strings.TrimSpace(input) == "" {
+		input = "{}"
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(input), &present); err != nil {
+		return "", fmt.Errorf("invalid arguments for %s: %w", t.name, err)
+	}
+	for _, name := range t.required {
+		if _, ok := present[name]; !ok {
+			return "", fmt.Errorf("invalid arguments for %s: missing required argument '%s'", t.name, name)
+		}
+	}
+
+	var args T
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments for %s: %w", t.name, err)
+	}
+	return t.fn(ctx, args)
+}
+
+// typeSchema returns the JSON schema of values of type t.
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		addStructFields(t, properties, &required)
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+// addStructFields adds the schemas of the exported fields of t to
+// properties, flattening embedded structs as encoding/json does.
+func addStructFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := typeSchema(field.Type)
+		isRequired := !slices.Contains(strings.Split(opts, ","), "omitempty")
+		for _, option := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+			key, value, _ := strings.Cut(option, "=")
+			switch key {
+			case "description", "format":
+				schema[key] = value
+			case "enum":
+				enum, _ := schema["enum"].([]any)
+				schema["enum"] = append(enum, enumValue(schema, value))
+			case "minimum", "maximum":
+				if n, err := strconv.ParseFloat(value, 64); err == nil {
+					schema[key] = n
+				}
+			case "required":
+				isRequired = true
+			case "optional":
+				isRequired = false
+			}
+		}
+
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// enumValue converts an enum tag value to the type of the property.
+func enumValue(schema map[string]any, value string) any {
+	switch schema["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
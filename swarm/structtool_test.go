@@ -0,0 +1,132 @@
+package swarm
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+type addArgs struct {
+	A int `json:"a" jsonschema:"description=First number"`
+	B int `json:"b" jsonschema:"description=Second number"`
+}
+
+type bookingArgs struct {
+	addressArgs
+	City     string          `json:"city" jsonschema:"enum=Paris,enum=London"`
+	Nights   int             `json:"nights,omitempty" jsonschema:"minimum=1,maximum=30"`
+	Guests   []string        `json:"guests,omitempty"`
+	Checkin  time.Time       `json:"checkin"`
+	Notes    *string         `json:"notes" jsonschema:"optional"`
+	Extras   map[string]bool `json:"extras,omitempty"`
+	Internal string          `json:"-"`
+	private  string
+	Tags     map[string]string `json:"tags,omitempty" jsonschema:"required"`
+}
+
+type addressArgs struct {
+	Street string `json:"street,omitempty"`
+}
+
+func TestNewStructToolSchema(t *testing.T) {
+	tool := NewStructTool("book", "Book a hotel", func(ctx context.Context, args bookingArgs) (string, error) {
+		return "", nil
+	})
+
+	schema := tool.Schema()
+	properties := schema["properties"].(map[string]any)
+
+	want := map[string]map[string]any{
+		"street":  {"type": "string"},
+		"city":    {"type": "string", "enum": []any{"Paris", "London"}},
+		"nights":  {"type": "integer", "minimum": 1.0, "maximum": 30.0},
+		"guests":  {"type": "array", "items": map[string]any{"type": "string"}},
+		"checkin": {"type": "string", "format": "date-time"},
+		"notes":   {"type": "string"},
+		"extras":  {"type": "object", "additionalProperties": map[string]any{"type": "boolean"}},
+		"tags":    {"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+	}
+	if len(properties) != len(want) {
+		t.Errorf("Got properties %v, want %d", properties, len(want))
+	}
+	for name, prop := range want {
+		if !reflect.DeepEqual(properties[name], prop) {
+			t.Errorf("Property %s = %v, want %v", name, properties[name], prop)
+		}
+	}
+
+	if required := schema["required"]; !reflect.DeepEqual(required, []string{"city", "checkin", "tags"}) {
+		t.Errorf("required = %v", required)
+	}
+
+	if def := ToolDefinition(tool); !reflect.DeepEqual(def.Function.Parameters, schema) {
+		t.Errorf("ToolDefinition() should advertise the derived schema, got %v", def.Function.Parameters)
+	}
+}
+
+func TestNewStructToolCall(t *testing.T) {
+	add := NewStructTool("add", "Add two numbers", func(ctx context.Context, args addArgs) (string, error) {
+		return strconv.Itoa(args.A + args.B), nil
+	})
+
+	result, err := add.Call(context.Background(), `{"a":5,"b":7}`)
+	if err != nil || result != "12" {
+		t.Errorf("Call() = %q, %v; want 12", result, err)
+	}
+
+	if _, err := add.Call(context.Background(), `{"a":5}`); err == nil || !strings.Contains(err.Error(), "'b'") {
+		t.Errorf("Call() with a missing argument error = %v", err)
+	}
+	if _, err := add.Call(context.Background(), `{"a":"five","b":7}`); err == nil {
+		t.Error("Call() with a mistyped argument: expected error")
+	}
+
+	// Through a ToolNode the arguments are passed as-is
+	node := NewToolNode([]tools.Tool{add})
+	state, err := node.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "add", Arguments: `{"a":1,"b":2}`}}),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got := state.Messages[1].Parts[0].(llms.ToolCallResponse).Content; got != "3" {
+		t.Errorf("Tool message = %q, want 3", got)
+	}
+}
+
+func TestNewStructToolInputField(t *testing.T) {
+	type searchArgs struct {
+		Input string `json:"input"`
+		Limit int    `json:"limit,omitempty"`
+	}
+	search := NewStructTool("search", "Search the docs", func(ctx context.Context, args searchArgs) (string, error) {
+		return args.Input + "/" + strconv.Itoa(args.Limit), nil
+	})
+
+	// An "input" argument is not unwrapped for schema tools
+	node := NewToolNode([]tools.Tool{search})
+	state, err := node.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{"input":"hotels","limit":3}`}}),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got := state.Messages[1].Parts[0].(llms.ToolCallResponse).Content; got != "hotels/3" {
+		t.Errorf("Tool message = %q, want hotels/3", got)
+	}
+}
+
+func TestNewStructToolRequiresStruct(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a non-struct argument type")
+		}
+	}()
+	NewStructTool("bad", "", func(ctx context.Context, args string) (string, error) { return args, nil })
+}
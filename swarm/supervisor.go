@@ -0,0 +1,148 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+// OutputMode controls which worker messages a supervisor sees.
+type OutputMode int
+
+const (
+	// OutputFullHistory adds every message a worker produces, including tool
+	// calls and tool results, to the shared conversation. This is the default.
+	OutputFullHistory OutputMode = iota
+
+	// OutputLastMessage adds only the worker's final response to the shared
+	// conversation; the rest is kept in the worker's private scratchpad.
+	OutputLastMessage
+)
+
+// String returns the name of the output mode.
+func (m OutputMode) String() string {
+	switch m {
+	case OutputFullHistory:
+		return "OutputFullHistory"
+	case OutputLastMessage:
+		return "OutputLastMessage"
+	default:
+		return "OutputMode(unknown)"
+	}
+}
+
+// SupervisorConfig holds configuration for creating a supervisor.
+type SupervisorConfig struct {
+	// Supervisor is the agent that delegates tasks to workers, typically a
+	// ReactAgent with a handoff tool for each worker
+	Supervisor Agent
+	// Workers are the agents the supervisor can delegate to
+	Workers []Agent
+	// OutputMode controls which worker messages the supervisor sees
+	// (default: OutputFullHistory)
+	OutputMode OutputMode
+	// Options holds other swarm settings such as Logger, Events or
+	// MaxHandoffs. Its Agents and DefaultActiveAgent are ignored.
+	Options SwarmConfig
+}
+
+// CreateSupervisor creates a hub-and-spoke swarm in which a supervisor agent
+// delegates to workers.
+//
+// Every invocation starts with the supervisor. When it hands off to a
+// worker, the worker runs and control returns to the supervisor, which sees
+// the worker's output and either delegates again or answers. The run ends
+// when the supervisor answers without handing off.
+//
+// Args:
+//   - config: The supervisor, its workers and how worker output is shared
+//
+// Returns:
+//   - A Swarm ready to be compiled
+//
+// Example:
+//
+//	supervisor, _ := swarm.CreateReactAgent(model, []tools.Tool{
+//	    swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: "researcher"}),
+//	    swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: "writer"}),
+//	})
+//	workflow, err := swarm.CreateSupervisor(swarm.SupervisorConfig{
+//	    Supervisor: swarm.Agent{Name: "supervisor", Runnable: supervisor},
+//	    Workers: []swarm.Agent{
+//	        {Name: "researcher", Runnable: researcher},
+//	        {Name: "writer", Runnable: writer},
+//	    },
+//	    OutputMode: swarm.OutputLastMessage,
+//	})
+func CreateSupervisor(config SupervisorConfig) (*Swarm, error) {
+	if len(config.Workers) == 0 {
+		return nil, fmt.Errorf("workers list cannot be empty")
+	}
+
+	supervisor := config.Supervisor
+	workerNames := make([]string, len(config.Workers))
+	workers := make([]Agent, len(config.Workers))
+	for i, worker := range config.Workers {
+		workerNames[i] = worker.Name
+		worker.Destinations = []string{supervisor.Name}
+		if config.OutputMode == OutputLastMessage {
+			worker.MessageVisibility = SharedFinalOnly
+		}
+		workers[i] = worker
+	}
+	if len(supervisor.Destinations) == 0 {
+		supervisor.Destinations = workerNames
+	}
+
+	swarmConfig := config.Options
+	swarmConfig.Agents = append([]Agent{supervisor}, workers...)
+	swarmConfig.DefaultActiveAgent = supervisor.Name
+
+	s, err := newSwarm(swarmConfig)
+	if err != nil {
+		return nil, err
+	}
+	swarmConfig = s.config
+	supervisor = swarmConfig.Agents[0]
+
+	// Each turn starts with the supervisor, whichever agent was last active
+	s.router = func(ctx context.Context, state SwarmState) string {
+		return supervisor.Name
+	}
+
+	g := graph.NewStateGraph[SwarmState]()
+	addRouterNode(g, s.router, nil, nil)
+
+	g.AddNode(supervisor.Name, "", agentNode(supervisor, swarmConfig))
+	g.AddConditionalEdge(supervisor.Name, handoffRoute(supervisor, swarmConfig, s.agentNames))
+
+	for _, worker := range swarmConfig.Agents[1:] {
+		g.AddNode(worker.Name, "", returnToSupervisor(worker, supervisor.Name, agentNode(worker, swarmConfig)))
+		g.AddEdge(worker.Name, supervisor.Name)
+	}
+
+	s.graph = g
+	return s, nil
+}
+
+// returnToSupervisor wraps a worker node so that control always returns to
+// the supervisor once the worker is done.
+func returnToSupervisor(worker Agent, supervisor string, node func(ctx context.Context, state SwarmState) (SwarmState, error)) func(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		result, err := node(ctx, state)
+		if err != nil {
+			return result, err
+		}
+
+		result.ActiveAgent = supervisor
+		result.Handoffs = append(slices.Clip(result.Handoffs), HandoffRecord{
+			From:      worker.Name,
+			To:        supervisor,
+			Timestamp: time.Now().UTC(),
+		})
+		return result, nil
+	}
+}
@@ -0,0 +1,103 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestCreateSupervisor(t *testing.T) {
+	tests := []struct {
+		name             string
+		mode             OutputMode
+		wantSupervisorIn int
+	}{
+		// user, delegation call, delegation result, worker tool call, tool result, worker answer
+		{"full history", OutputFullHistory, 6},
+		// user, delegation call, delegation result, worker answer
+		{"last message", OutputLastMessage, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supervisorModel := &scriptedModel{responses: []*llms.ContentChoice{
+				toolCallChoice("call_1", "transfer_to_researcher", `{}`),
+				{Content: "Summary: HELLO"},
+			}}
+			supervisor, err := CreateReactAgent(supervisorModel, []tools.Tool{
+				CreateHandoffTool(HandoffToolConfig{AgentName: "researcher"}),
+			})
+			if err != nil {
+				t.Fatalf("CreateReactAgent() error = %v", err)
+			}
+			researcher, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+				toolCallChoice("call_2", "upper", `{"input":"hello"}`),
+				{Content: "HELLO"},
+			}}, []tools.Tool{upperTool{}})
+			if err != nil {
+				t.Fatalf("CreateReactAgent() error = %v", err)
+			}
+
+			s, err := CreateSupervisor(SupervisorConfig{
+				Supervisor: Agent{Name: "supervisor", Runnable: supervisor},
+				Workers:    []Agent{{Name: "researcher", Runnable: researcher}},
+				OutputMode: tt.mode,
+			})
+			if err != nil {
+				t.Fatalf("CreateSupervisor() error = %v", err)
+			}
+			app, err := s.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "research hello")},
+			})
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+
+			if result.ActiveAgent != "supervisor" {
+				t.Errorf("Expected control to return to the supervisor, got %q", result.ActiveAgent)
+			}
+			last := result.Messages[len(result.Messages)-1].Parts[0]
+			if last != (llms.TextContent{Text: "Summary: HELLO"}) {
+				t.Errorf("Expected the supervisor to answer last, got %v", last)
+			}
+			if len(supervisorModel.calls) != 2 || len(supervisorModel.calls[1]) != tt.wantSupervisorIn {
+				t.Errorf("Expected the supervisor's second call to see %d messages, got %d", tt.wantSupervisorIn, len(supervisorModel.calls[1]))
+			}
+			if len(result.Handoffs) != 2 || result.Handoffs[1].From != "researcher" || result.Handoffs[1].To != "supervisor" {
+				t.Errorf("Unexpected handoffs %+v", result.Handoffs)
+			}
+		})
+	}
+}
+
+func TestCreateSupervisorValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SupervisorConfig
+	}{
+		{"no workers", SupervisorConfig{Supervisor: Agent{Name: "supervisor"}}},
+		{"worker named like supervisor", SupervisorConfig{
+			Supervisor: Agent{Name: "supervisor"},
+			Workers:    []Agent{{Name: "supervisor"}},
+		}},
+		{"unknown supervisor destination", SupervisorConfig{
+			Supervisor: Agent{Name: "supervisor", Destinations: []string{"writer"}},
+			Workers:    []Agent{{Name: "researcher"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateSupervisor(tt.config); err == nil {
+				t.Error("CreateSupervisor() should return an error")
+			}
+		})
+	}
+}
@@ -2,10 +2,19 @@ package swarm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SwarmState represents the state schema for the multi-agent swarm.
@@ -13,6 +22,32 @@ import (
 type SwarmState struct {
 	Messages    []llms.MessageContent `json:"messages"`
 	ActiveAgent string                `json:"active_agent,omitempty"`
+	// PinnedAgent, when set, is the agent the conversation is pinned to:
+	// every turn starts with it and handoffs to other agents are denied
+	// (see CompiledSwarm.SetActiveAgent).
+	PinnedAgent string `json:"pinned_agent,omitempty"`
+	// HandoffPayload holds the arguments passed with the most recent handoff
+	// (see HandoffToolConfig.InputSchema). It is nil if the handoff carried none.
+	HandoffPayload map[string]any `json:"handoff_payload,omitempty"`
+	// PrivateMessages holds each agent's private scratchpad, keyed by agent
+	// name, for agents using SharedFinalOnly visibility.
+	PrivateMessages map[string][]llms.MessageContent `json:"private_messages,omitempty"`
+	// Handoffs lists the handoffs of the conversation, oldest first.
+	Handoffs []HandoffRecord `json:"handoffs,omitempty"`
+	// Values holds application-defined fields. They are merged with the
+	// reducer configured under their key in SwarmConfig.Reducers.
+	Values map[string]any `json:"values,omitempty"`
+	// Blackboard holds the artifacts agents share, such as plans, search
+	// results and drafts, by key. Agents read and write it with the tools of
+	// BlackboardTools; entries are merged with the reducer configured under
+	// their key in SwarmConfig.BlackboardReducers.
+	Blackboard map[string]any `json:"blackboard,omitempty"`
+	// Tasks lists the tasks agents assigned to each other (see TaskTools),
+	// oldest first.
+	Tasks []Task `json:"tasks,omitempty"`
+	// Artifacts holds references to the large artifacts kept in
+	// SwarmConfig.Artifacts, by name (see SaveArtifact).
+	Artifacts map[string]ArtifactRef `json:"artifacts,omitempty"`
 }
 
 // SwarmConfig holds configuration for creating a swarm
@@ -21,26 +56,209 @@ type SwarmConfig struct {
 	Agents []Agent
 	// DefaultActiveAgent is the name of the agent to start with
 	DefaultActiveAgent string
-	// ContextSchema specifies the schema for the context object passed to the workflow (optional)
-	// This is useful for passing additional configuration or shared data to agents
+	// ContextSchema is a value of the type of the run context (user ID,
+	// tenant, locale, configuration) that every invocation must pass with
+	// WithContext, e.g. UserContext{}. Agents and tools read the run
+	// context with ContextFromCtx or ContextAs. Optional.
 	ContextSchema interface{}
+	// Reducers overrides how the state returned by an agent is merged into
+	// the swarm state, keyed by field (see ReducerKeyMessages) or by
+	// Values key. By default messages are appended without duplicates,
+	// other fields are last-write-wins.
+	Reducers map[string]ReducerFunc
+	// BlackboardReducers merges the blackboard entries an agent added or
+	// changed into the swarm state, keyed by blackboard key (default:
+	// LastWriteWins). AppendSlices accumulates entries such as search
+	// results.
+	BlackboardReducers map[string]ReducerFunc
+	// DispatchTasks runs the assignees of pending tasks (see TaskTools)
+	// within the invocation: an agent that ends its run without a handoff
+	// hands off to the assignee of the oldest pending task, and the agent
+	// completing the last task hands back to its creator. Dispatch respects
+	// the agent's Destinations.
+	DispatchTasks bool
+	// InferDestinations fills in each agent's Destinations from its handoff
+	// tools when the runnable implements HandoffDestinationsProvider (as
+	// ReactAgent does). Declared destinations must match the tools.
+	InferDestinations bool
+	// StreamHandler receives agent, token, tool call and handoff events for
+	// every run, unless the run's context carries its own (see WithStreamHandler)
+	StreamHandler StreamHandler
+	// Logger receives structured logs of agent runs, handoffs and errors.
+	// Nothing is logged when it is nil.
+	Logger *slog.Logger
+	// LogLevel is the level of routine events such as agent start and end
+	// (default: slog.LevelInfo). Errors are always logged at slog.LevelError.
+	LogLevel slog.Level
+	// Events receives lifecycle events (agent runs, tool calls, handoffs,
+	// errors and completed turns) for every run, unless the run's context
+	// carries its own bus (see events.WithBus)
+	Events *events.Bus
+	// TraceExporters receive the Trace of every run once it is over, to
+	// ship it to platforms such as LangSmith or Langfuse (see the
+	// traceexport package). Export errors are logged to Logger.
+	TraceExporters []TraceExporter
+	// TracerProvider records OpenTelemetry spans for swarm invocations,
+	// agent runs, model calls, tool calls and handoffs. When nil, spans join
+	// the trace of the context passed to Invoke, if any.
+	TracerProvider trace.TracerProvider
+	// RecursionLimit is the maximum number of agent runs in a single
+	// invocation, unless the invocation sets one with WithRecursionLimit;
+	// exceeding it fails the run with a *RecursionLimitError (0:
+	// DefaultRecursionLimit; negative: no limit)
+	RecursionLimit int
+	// MaxHandoffs limits the number of handoffs in a single invocation;
+	// exceeding it fails the run with ErrHandoffLimitExceeded (0: no limit)
+	MaxHandoffs int
+	// MaxHandoffCycles limits how many times in a row two agents may hand
+	// back to each other in a single invocation (0: no limit)
+	MaxHandoffCycles int
+	// Budget limits the tokens, cost and time of each invocation across all
+	// its agents; a run over budget stops gracefully with partial results
+	// (see InvokeResult.Truncated)
+	Budget Budget
+	// Deadline is the time each invocation may take, unless it sets one
+	// with WithDeadline; a run past its deadline starts no further model
+	// call, tool call or handoff and returns its partial result (0 or
+	// negative: no deadline)
+	Deadline time.Duration
+	// Prompts holds versioned prompts of the agents, selected per run by
+	// experiment flags (see PromptRegistry). They take precedence over the
+	// agents' own prompts.
+	Prompts *PromptRegistry
+	// InterruptBefore pauses a run before any of the named tools is called,
+	// or before a handoff to any of the named agents, until a human approves
+	// (see CompiledSwarm.Resume). Only agents using a ToolNode, such as
+	// ReactAgent, can be interrupted.
+	InterruptBefore []string
+	// Checkpointer saves the state of runs invoked with WithThreadID, and is
+	// required to resume interrupted runs
+	Checkpointer CheckpointStore
+	// Artifacts keeps the content of large artifacts, which tools save with
+	// SaveArtifact, out of the state. ToolNodes replace the references to
+	// them (e.g. "artifact://report") in tool arguments with their content.
+	// Optional.
+	Artifacts ArtifactStore
+	// StateLimit offloads the oldest large message parts to Artifacts, or
+	// summarizes them, when the state outgrows it after an agent run.
+	// Optional.
+	StateLimit *StateLimit
+	// RateLimiter throttles the model calls of agents built by
+	// CreateReactAgent (see NewRateLimiter)
+	RateLimiter RateLimiter
+	// Router selects the agent that starts each turn, such as an LLMRouter
+	// (default: the state's ActiveAgent, else DefaultActiveAgent). It is not
+	// used by supervisor swarms, which always start with the supervisor.
+	Router Router
+	// ResponseFormat requires the final answer of every run to be JSON
+	// matching a schema, repairing it if needed (see ResponseFormat). Runs
+	// whose answer does not match fail with ErrInvalidResponse.
+	ResponseFormat *ResponseFormat
+	// Middlewares hook into every agent run, and into the tool calls of
+	// agents using a ToolNode such as ReactAgent, to filter, redact or
+	// validate what agents see and produce (see Middleware)
+	Middlewares []Middleware
+	// Moderation checks user messages and agent answers with a Moderator
+	// and blocks, redacts or escalates flagged ones (see Moderation)
+	Moderation *Moderation
+	// OnHandoff is called before each handoff requested with a handoff tool
+	// of a ToolNode, such as those of a ReactAgent. Returning false denies
+	// the handoff: the model is told so and the agent carries on. Return
+	// RedirectHandoff to hand off to another agent instead; other errors
+	// fail the run.
+	OnHandoff HandoffApprover
 }
 
 // Agent represents a compiled agent in the swarm
 type Agent struct {
 	Name     string
-	Runnable any // CompiledGraph from graph.Compile()
+	Runnable AgentRunnable // e.g. a ReactAgent or CompiledGraph from graph.Compile(); see AdaptRunnable
+	// Description tells routers such as LLMRouter what the agent handles
+	Description string
 	// Destinations are the agent names this agent can hand off to
 	Destinations []string
+	// MessageVisibility controls which of the agent's messages are added to
+	// the shared conversation (default: SharedAll)
+	MessageVisibility MessageVisibility
+	// SystemPrompt is prepended to the conversation as a system message
+	// every time the agent runs. It is not added to the shared history.
+	SystemPrompt string
+	// Prompt builds the system prompt from the state on every run and takes
+	// precedence over SystemPrompt
+	Prompt PromptFunc
+	// PromptVersion identifies the version of the agent's system prompt in
+	// traces, e.g. as set by LoadConfig for prompts reloaded by a
+	// ConfigWatcher
+	PromptVersion string
+	// ContextPolicy selects the messages the agent sees on every run, such
+	// as a MessageWindow. The shared history is kept in full.
+	ContextPolicy ContextPolicy
+	// Model replaces the model of a prebuilt agent (see CreateReactAgent)
+	// for the agent's model calls, so agents built alike can use different
+	// providers
+	Model llms.Model
+	// CallOptions are added to the agent's model calls after those of the
+	// prebuilt agent, e.g. llms.WithTemperature(0.2)
+	CallOptions []llms.CallOption
+	// Tools are given to a prebuilt agent on top of its own, e.g. the
+	// handoff tools wired by NewTriage. Its own tools win on a name clash.
+	Tools []tools.Tool
+	// MessageAdapter rewrites the messages of the agent's model calls for
+	// its provider, e.g. AnthropicAdapter{}. It applies to prebuilt agents.
+	MessageAdapter MessageAdapter
+	// PostProcess rewrites the state after each model call of a prebuilt
+	// agent, e.g. StripTags("think"). Stream handlers still receive the
+	// tokens as the model produced them.
+	PostProcess PostProcessFunc
+	// AllowedTools, when not nil, lists the tools the agent may call. Its
+	// handoff tools may transfer only to its Destinations, if it declares
+	// any. Other calls are refused, logged, and answered with a
+	// PolicyViolation. It applies to agents using a ToolNode.
+	AllowedTools []string
+	// RetryPolicy retries the agent when a run fails (default: no retries)
+	RetryPolicy RetryPolicy
+	// Fallback is the agent that takes over the conversation when a run
+	// fails after its retries, with a system message describing the error
+	Fallback string
+	// Replicas are instances of the agent, such as the same agent on
+	// different models, among which its runs are distributed; retries go to
+	// the next replica. The state only names the agent.
+	Replicas []Replica
+	// LoadBalancing distributes the runs among the Replicas (default:
+	// RoundRobin)
+	LoadBalancing LoadBalancing
 }
 
-// CreateSwarm creates a multi-agent swarm graph.
+// startNode is the name of the routing node used as the graph entry point.
+// It forwards a copy of the state and routes to the active agent.
+const startNode = "__start__"
+
+// Swarm is a multi-agent swarm created by CreateSwarm.
+// It owns the underlying state graph, the agent registry and the router
+// that selects the active agent at the start of each invocation.
+type Swarm struct {
+	// mu guards the fields below against AddAgent and RemoveAgent
+	mu         sync.RWMutex
+	config     SwarmConfig
+	agents     map[string]Agent
+	agentNames []string
+	router     func(ctx context.Context, state SwarmState) string
+	graph      *graph.StateGraph[SwarmState]
+	// build creates the graph for the current agents; it is nil for swarms
+	// whose agents cannot change
+	build func(s *Swarm) *graph.StateGraph[SwarmState]
+	// version is incremented whenever the agents change, so that compiled
+	// swarms recompile the graph
+	version int
+}
+
+// CreateSwarm creates a multi-agent swarm.
 //
 // Args:
 //   - config: Configuration for the swarm including agents and default active agent
 //
 // Returns:
-//   - A StateGraph ready to be compiled
+//   - A Swarm ready to be compiled
 //
 // Example:
 //
@@ -52,74 +270,549 @@ type Agent struct {
 //	    DefaultActiveAgent: "Alice",
 //	})
 //	app, _ := workflow.Compile()
-func CreateSwarm(config SwarmConfig) (any, error) {
+//	result, _ := app.Invoke(ctx, initialState)
+func CreateSwarm(config SwarmConfig) (*Swarm, error) {
+	s, err := newSwarm(config)
+	if err != nil {
+		return nil, err
+	}
+	s.build = buildSwarmGraph
+	s.graph = s.build(s)
+	return s, nil
+}
+
+// buildSwarmGraph creates the graph of a swarm created by CreateSwarm.
+func buildSwarmGraph(s *Swarm) *graph.StateGraph[SwarmState] {
+	// Create state graph with SwarmState
+	// Note: When using typed structs, we don't need MapSchema.
+	// MapSchema is only for map[string]any state types.
+	g := graph.NewStateGraph[SwarmState]()
+
+	// Add active agent router
+	addRouterNode(g, s.router, s.config.Router, s.agentNames)
+
+	// Add nodes for each agent
+	for _, agent := range s.config.Agents {
+		g.AddNode(agent.Name, "", agentNode(agent, s.config))
+
+		// Follow handoffs made during the agent's run within the same
+		// invocation; otherwise the agent ends the turn.
+		g.AddConditionalEdge(agent.Name, handoffRoute(agent, s.config, s.agentNames))
+	}
+	return g
+}
+
+// newSwarm validates config and returns a swarm with its agent registry
+// and active agent router, but no graph.
+func newSwarm(config SwarmConfig) (*Swarm, error) {
+	agentNames, err := validateConfig(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Swarm{router: activeAgentRoute(config.DefaultActiveAgent)}
+	s.setAgents(config, agentNames)
+	return s, nil
+}
+
+// setAgents replaces the validated config and agent registry of s.
+func (s *Swarm) setAgents(config SwarmConfig, agentNames []string) {
+	s.config = config
+	s.agentNames = agentNames
+	s.agents = make(map[string]Agent, len(config.Agents))
+	for _, agent := range config.Agents {
+		s.agents[agent.Name] = agent
+	}
+}
+
+// Graph returns the underlying state graph.
+// It can be used for custom graph construction before compiling. Changes
+// made to it are lost when agents are added or removed.
+func (s *Swarm) Graph() *graph.StateGraph[SwarmState] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.graph
+}
+
+// AgentNames returns the names of the agents in the swarm, in registration order.
+func (s *Swarm) AgentNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.agentNames)
+}
+
+// Agent returns the agent registered under name.
+func (s *Swarm) Agent(name string) (Agent, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	agent, ok := s.agents[name]
+	return agent, ok
+}
+
+// DefaultActiveAgent returns the agent used when no agent is active.
+func (s *Swarm) DefaultActiveAgent() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.DefaultActiveAgent
+}
+
+// Compile compiles the swarm into a CompiledSwarm that can be invoked.
+func (s *Swarm) Compile() (*CompiledSwarm, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runnable, err := s.graph.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile swarm: %w", err)
+	}
+	return &CompiledSwarm{swarm: s, runnable: runnable, version: s.version}, nil
+}
+
+// ConfigError reports every problem found in a SwarmConfig.
+type ConfigError struct {
+	// Problems lists the individual validation failures
+	Problems []error
+}
+
+// Error lists all problems in a single message.
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		msgs[i] = problem.Error()
+	}
+	if len(msgs) == 1 {
+		return "invalid swarm config: " + msgs[0]
+	}
+	return fmt.Sprintf("invalid swarm config (%d problems): %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual problems for use with errors.Is and errors.As.
+func (e *ConfigError) Unwrap() []error {
+	return e.Problems
+}
+
+// validateConfig checks the swarm configuration and returns the agent names.
+// All problems are collected into a single *ConfigError. When
+// InferDestinations is set, it also fills in the agents' destinations.
+func validateConfig(config *SwarmConfig) ([]string, error) {
 	if len(config.Agents) == 0 {
-		return nil, fmt.Errorf("agents list cannot be empty")
+		return nil, &ConfigError{Problems: []error{fmt.Errorf("agents list cannot be empty")}}
 	}
 
-	agentNames := make([]string, len(config.Agents))
+	var problems []error
+	agentNames := make([]string, 0, len(config.Agents))
+	seen := make(map[string]bool, len(config.Agents))
 	for i, agent := range config.Agents {
-		agentNames[i] = agent.Name
+		switch {
+		case agent.Name == "":
+			problems = append(problems, fmt.Errorf("agent %d has an empty name", i))
+		case agent.Name == startNode || agent.Name == graph.END:
+			problems = append(problems, fmt.Errorf("agent name '%s' is reserved", agent.Name))
+		case seen[agent.Name]:
+			problems = append(problems, fmt.Errorf("duplicate agent name '%s'", agent.Name))
+		}
+		if !seen[agent.Name] {
+			agentNames = append(agentNames, agent.Name)
+		}
+		seen[agent.Name] = true
 	}
 
 	// Validate default active agent
-	found := false
-	for _, name := range agentNames {
-		if name == config.DefaultActiveAgent {
-			found = true
-			break
+	if !seen[config.DefaultActiveAgent] {
+		problems = append(problems, fmt.Errorf("%w: default active agent '%s' not found in agent names %v",
+			ErrUnknownAgent, config.DefaultActiveAgent, agentNames))
+	}
+
+	if config.InferDestinations {
+		config.Agents = slices.Clone(config.Agents)
+		for i, agent := range config.Agents {
+			provider, ok := agent.Runnable.(HandoffDestinationsProvider)
+			if !ok {
+				continue
+			}
+			inferred := provider.HandoffDestinations()
+			if len(agent.Destinations) == 0 {
+				config.Agents[i].Destinations = inferred
+				continue
+			}
+			if !sameNames(agent.Destinations, inferred) {
+				problems = append(problems, fmt.Errorf("%w: agent '%s' declares destinations %v but its handoff tools target %v",
+					ErrInvalidDestination, agent.Name, agent.Destinations, inferred))
+			}
 		}
 	}
-	if !found {
-		return nil, fmt.Errorf("default active agent '%s' not found in agent names %v",
-			config.DefaultActiveAgent, agentNames)
+
+	for _, agent := range config.Agents {
+		for _, dest := range agent.Destinations {
+			switch {
+			case dest == agent.Name:
+				problems = append(problems, fmt.Errorf("%w: agent '%s' lists itself as a destination", ErrInvalidDestination, agent.Name))
+			case !seen[dest]:
+				problems = append(problems, fmt.Errorf("%w: agent '%s' has unknown destination '%s'", ErrInvalidDestination, agent.Name, dest))
+			}
+		}
+		if agent.Runnable == nil && len(agent.Replicas) == 0 {
+			problems = append(problems, fmt.Errorf("agent '%s': %w: no runnable", agent.Name, ErrUnsupportedRunnable))
+		}
+		for i, replica := range agent.Replicas {
+			if replica.Runnable == nil && agent.Runnable == nil {
+				problems = append(problems, fmt.Errorf("replica %d of agent '%s' has no runnable", i, agent.Name))
+			}
+		}
+		switch {
+		case agent.Fallback == "":
+		case agent.Fallback == agent.Name:
+			problems = append(problems, fmt.Errorf("agent '%s' lists itself as its fallback", agent.Name))
+		case !seen[agent.Fallback]:
+			problems = append(problems, fmt.Errorf("%w: agent '%s' has unknown fallback '%s'", ErrUnknownAgent, agent.Name, agent.Fallback))
+		}
 	}
 
-	// Create state graph with SwarmState
-	// Note: When using typed structs, we don't need MapSchema.
-	// MapSchema is only for map[string]any state types.
-	g := graph.NewStateGraph[SwarmState]()
+	for i, m := range config.Middlewares {
+		if m == nil {
+			problems = append(problems, fmt.Errorf("middleware %d is nil", i))
+		}
+	}
 
-	// Add active agent router
-	if err := addActiveAgentRouter(g, agentNames, config.DefaultActiveAgent); err != nil {
-		return nil, err
+	if m := config.Moderation; m != nil {
+		if m.Moderator == nil {
+			problems = append(problems, fmt.Errorf("moderation has no moderator"))
+		}
+		switch {
+		case m.Input != ModerationEscalate && m.Output != ModerationEscalate:
+		case m.EscalateTo == "":
+			problems = append(problems, fmt.Errorf("moderation escalates but has no escalation agent"))
+		case !seen[m.EscalateTo]:
+			problems = append(problems, fmt.Errorf("%w: moderation has unknown escalation agent '%s'", ErrUnknownAgent, m.EscalateTo))
+		}
 	}
 
-	// Add nodes for each agent - following example pattern
-	for _, agent := range config.Agents {
-		agentCopy := agent // Capture loop variable
-
-		// Define the node function following the same pattern as examples
-		nodeFunc := func(ctx context.Context, state SwarmState) (SwarmState, error) {
-			// Invoke the agent's runnable
-			// The runnable should be a compiled graph that accepts SwarmState
-			if invoker, ok := agentCopy.Runnable.(interface {
-				Invoke(context.Context, SwarmState) (any, error)
-			}); ok {
-				result, err := invoker.Invoke(ctx, state)
-				if err != nil {
-					return state, err
+	if config.Budget.MaxUSD > 0 && config.Budget.Cost == nil {
+		problems = append(problems, fmt.Errorf("budget has a MaxUSD but no Cost"))
+	}
+
+	if config.ResponseFormat != nil && config.ResponseFormat.Schema == nil {
+		problems = append(problems, fmt.Errorf("response format has no schema"))
+	}
+
+	if l := config.StateLimit; l != nil {
+		if l.MaxBytes <= 0 {
+			problems = append(problems, fmt.Errorf("state limit has no MaxBytes"))
+		}
+		if config.Artifacts == nil && l.Summarize == nil {
+			problems = append(problems, fmt.Errorf("state limit needs an artifact store or a Summarize function"))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	return agentNames, nil
+}
+
+// sameNames reports whether a and b contain the same names, ignoring order
+// and repetition.
+func sameNames(a, b []string) bool {
+	for _, name := range a {
+		if !slices.Contains(b, name) {
+			return false
+		}
+	}
+	for _, name := range b {
+		if !slices.Contains(a, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// agentNode wraps an agent's runnable as a graph node function.
+// The runnable may return either SwarmState directly (as compiled
+// StateGraph[SwarmState] runnables do) or any holding a SwarmState.
+// Its result is merged into the input state with the configured reducers.
+func agentNode(agent Agent, config SwarmConfig) func(ctx context.Context, state SwarmState) (SwarmState, error) {
+	agentNames := make([]string, len(config.Agents))
+	for i, a := range config.Agents {
+		agentNames[i] = a.Name
+	}
+	replicas := newReplicaSet(agent)
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		agent := agent
+		if prompt, version, ok := config.Prompts.prompt(ctx, agent.Name); ok {
+			agent.Prompt, agent.PromptVersion = prompt, version
+		}
+		ctx = withAgentName(ctx, agent.Name)
+		ctx = withHandoffScope(ctx, agent, agentNames)
+		if len(config.InterruptBefore) > 0 {
+			ctx = withInterruptBefore(ctx, config.InterruptBefore)
+		}
+		if config.RateLimiter != nil {
+			ctx = withRateLimiter(ctx, config.RateLimiter)
+		}
+		if len(config.Middlewares) > 0 {
+			ctx = withMiddlewares(ctx, config.Middlewares)
+		}
+		if config.OnHandoff != nil {
+			ctx = withHandoffApprover(ctx, config.OnHandoff)
+		}
+		if agent.AllowedTools != nil {
+			ctx = withToolPolicy(ctx, agent, config.Logger)
+		}
+		if agent.MessageAdapter != nil {
+			ctx = withMessageAdapter(ctx, agent.MessageAdapter)
+		}
+		if agent.Model != nil || len(agent.CallOptions) > 0 || len(agent.Tools) > 0 || agent.PostProcess != nil {
+			ctx = withAgentModel(ctx, agent)
+		}
+		handler := StreamHandlerFromContext(ctx)
+		if handler == nil && config.StreamHandler != nil {
+			handler = config.StreamHandler
+			ctx = WithStreamHandler(ctx, handler)
+		}
+
+		bus := events.BusFromContext(ctx)
+		if bus == nil && config.Events != nil {
+			bus = config.Events
+			ctx = events.WithBus(ctx, bus)
+		}
+
+		ctx, step := beginStep(ctx, agent.Name)
+		ctx, span := tracer(ctx, config.TracerProvider).Start(ctx, "agent "+agent.Name,
+			trace.WithAttributes(attrAgent.String(agent.Name), attrMessages.Int(len(state.Messages))))
+		if agent.PromptVersion != "" {
+			step.update(func(step *TraceStep) { step.PromptVersion = agent.PromptVersion })
+			span.SetAttributes(attrPromptVersion.String(agent.PromptVersion))
+		}
+
+		logger := config.Logger
+		if logger != nil {
+			logger.LogAttrs(ctx, config.LogLevel, "agent started",
+				slog.String("agent", agent.Name),
+				slog.Int("messages", len(state.Messages)))
+		}
+		if handler != nil {
+			handler.OnAgentStart(ctx, agent.Name)
+		}
+
+		start := time.Now()
+		result, err := state, ctx.Err()
+		if err == nil {
+			err = countStep(ctx, agent.Name)
+		}
+		if err == nil {
+			state, err = beforeAgent(ctx, config.Middlewares, agent.Name, state)
+			result = state
+		}
+		if err == nil {
+			result, err = config.Moderation.run(ctx, agent.Name, state, func(ctx context.Context, state SwarmState) (SwarmState, error) {
+				return agent.RetryPolicy.retry(ctx, func() (SwarmState, error) {
+					result, err := replicas.run(ctx, agent, func(ctx context.Context, agent Agent) (SwarmState, error) {
+						return runAgent(ctx, agent, config, state)
+					})
+					if err != nil {
+						return result, err
+					}
+					return afterAgent(ctx, config.Middlewares, agent.Name, result)
+				}, func(retry int, delay time.Duration, err error) {
+					if bus != nil {
+						bus.Publish(ctx, events.AgentRetried{Time: time.Now(), Agent: agent.Name, Attempt: retry, Delay: delay, Err: err})
+					}
+					if logger != nil {
+						logger.LogAttrs(ctx, slog.LevelWarn, "agent retrying",
+							slog.String("agent", agent.Name),
+							slog.Int("attempt", retry),
+							slog.Duration("delay", delay),
+							slog.Any("error", err))
+					}
+				})
+			})
+		}
+		// Handoffs away from a pinned agent are undone; fallbacks still apply
+		if err == nil && state.PinnedAgent != "" {
+			result.ActiveAgent = state.PinnedAgent
+		}
+		err = agentInvokeError(agent.Name, err)
+		// runErr is the outcome of the run as reported to observers; err is
+		// returned to the graph and is nil when a fallback agent takes over.
+		runErr := err
+		if err != nil && agent.Fallback != "" && canFallback(ctx, err) {
+			result, err = fallbackState(agent, state, err), nil
+		}
+
+		if err == nil && config.DispatchTasks {
+			result = dispatchTask(agent.Name, agentNames, state, result)
+		}
+		if err == nil {
+			result, err = config.StateLimit.enforce(ctx, config, agent.Name, result)
+		}
+		handedOff := err == nil && result.ActiveAgent != "" && result.ActiveAgent != agent.Name
+		if handedOff {
+			var record HandoffRecord
+			result, record = recordHandoff(agent.Name, state, result)
+			if trail := handoffTrailFromContext(ctx); trail != nil {
+				if err = trail.add(config, record); err != nil {
+					handedOff = false
 				}
+			}
+		}
+		if runErr == nil {
+			runErr = err
+		}
+		// A stopped run hands off no further; the user is told why
+		if handedOff {
+			if cause := runStopped(ctx); cause != nil {
+				result.Messages = append(slices.Clip(result.Messages), stopMessage(cause))
+			}
+		}
 
-				// Update state with agent's result
-				if resultState, ok := result.(SwarmState); ok {
-					return resultState, nil
+		if handedOff {
+			span.SetAttributes(attrDestination.String(result.ActiveAgent))
+			_, handoffSpan := tracer(ctx, nil).Start(ctx, "handoff",
+				trace.WithAttributes(attrAgent.String(agent.Name), attrDestination.String(result.ActiveAgent)))
+			handoffSpan.End()
+		}
+		endSpan(span, runErr)
+		var handoffTo string
+		if handedOff {
+			handoffTo = result.ActiveAgent
+		}
+		step.end(handoffTo, runErr)
+
+		if handler != nil {
+			handler.OnAgentEnd(ctx, agent.Name, runErr)
+			if handedOff {
+				handler.OnHandoff(ctx, agent.Name, result.ActiveAgent)
+			}
+		}
+		if bus != nil {
+			now := time.Now()
+			bus.Publish(ctx, events.AgentInvoked{Time: now, Agent: agent.Name, Duration: now.Sub(start), Err: runErr})
+			if runErr != nil && !errors.Is(runErr, ErrInterrupted) {
+				bus.Publish(ctx, events.ErrorRaised{Time: now, Agent: agent.Name, Err: runErr})
+			}
+			if handedOff {
+				bus.Publish(ctx, events.HandoffOccurred{Time: now, From: agent.Name, To: result.ActiveAgent, Payload: result.HandoffPayload})
+			}
+		}
+		if err == nil {
+			recordProgress(ctx, result)
+		}
+		if logger != nil {
+			// The log names the agent already
+			logErr := runErr
+			if invokeErr, ok := runErr.(*AgentInvokeError); ok {
+				logErr = invokeErr.Cause
+			}
+			switch {
+			case errors.Is(runErr, ErrInterrupted):
+				logger.LogAttrs(ctx, config.LogLevel, "agent interrupted",
+					slog.String("agent", agent.Name),
+					slog.Duration("duration", time.Since(start)))
+			case runErr != nil:
+				logger.LogAttrs(ctx, slog.LevelError, "agent failed",
+					slog.String("agent", agent.Name),
+					slog.Duration("duration", time.Since(start)),
+					slog.Any("error", logErr))
+				if handedOff {
+					logger.LogAttrs(ctx, config.LogLevel, "fallback",
+						slog.String("from", agent.Name),
+						slog.String("to", result.ActiveAgent))
+				}
+			default:
+				logger.LogAttrs(ctx, config.LogLevel, "agent finished",
+					slog.String("agent", agent.Name),
+					slog.Duration("duration", time.Since(start)),
+					slog.Int("messages", len(result.Messages)),
+					slog.Int("added", len(result.Messages)-len(state.Messages)))
+				if handedOff {
+					logger.LogAttrs(ctx, config.LogLevel, "handoff",
+						slog.String("from", agent.Name),
+						slog.String("to", result.ActiveAgent))
 				}
 			}
+		}
+		return result, err
+	}
+}
 
-			return state, nil
+// recordHandoff makes sure the handoff from agent to after.ActiveAgent is
+// recorded in after.Handoffs and returns its record. Handoffs made through a
+// ToolNode are already recorded; agents that set ActiveAgent themselves get
+// a record without tool call details.
+func recordHandoff(agent string, before, after SwarmState) (SwarmState, HandoffRecord) {
+	if len(after.Handoffs) > len(before.Handoffs) {
+		last := &after.Handoffs[len(after.Handoffs)-1]
+		if last.To == after.ActiveAgent {
+			if last.From == "" {
+				after.Handoffs = slices.Clone(after.Handoffs)
+				after.Handoffs[len(after.Handoffs)-1].From = agent
+				last = &after.Handoffs[len(after.Handoffs)-1]
+			}
+			return after, *last
 		}
+	}
 
-		// Add node with name, description (empty), and function
-		g.AddNode(agent.Name, "", nodeFunc)
+	record := HandoffRecord{From: agent, To: after.ActiveAgent, Timestamp: time.Now().UTC()}
+	after.Handoffs = append(slices.Clip(after.Handoffs), record)
+	return after, record
+}
 
-		// Add edges to destinations
-		for _, dest := range agent.Destinations {
-			g.AddEdge(agent.Name, dest)
+// runAgent invokes an agent and merges its result into state.
+func runAgent(ctx context.Context, agent Agent, config SwarmConfig, state SwarmState) (SwarmState, error) {
+	input := state
+	if agent.ContextPolicy != nil {
+		window, err := agent.ContextPolicy.Apply(ctx, state.Messages)
+		if err != nil {
+			return state, fmt.Errorf("context policy: %w", err)
 		}
+		input.Messages = window
 	}
 
-	return g, nil
+	result, err := invokeWithPrompt(ctx, agent, input)
+	if agent.ContextPolicy != nil {
+		result.Messages = restoreHistory(state.Messages, input.Messages, result.Messages)
+		var interrupt *InterruptError
+		if errors.As(err, &interrupt) {
+			interrupt.State.Messages = restoreHistory(state.Messages, input.Messages, interrupt.State.Messages)
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+	merged, err := reduceState(config.Reducers, state, result)
+	if err != nil {
+		return state, err
+	}
+	if merged.Blackboard, err = reduceBlackboard(config.BlackboardReducers, state.Blackboard, result.Blackboard); err != nil {
+		return state, err
+	}
+	return applyVisibility(agent, state, merged), nil
+}
+
+// handoffRoute returns the routing function that runs after an agent node.
+// It routes to the new active agent when the agent handed off to another
+// registered agent, and to END otherwise. Agents that declare Destinations
+// may only hand off to those agents (or their Fallback, or the moderation
+// escalation agent) within the invocation; other handoffs, and those made
+// with HandoffEndTurn, take effect on the next invocation.
+func handoffRoute(agent Agent, config SwarmConfig, agentNames []string) func(ctx context.Context, state SwarmState) string {
+	escalation := config.Moderation.escalationAgent()
+	return func(ctx context.Context, state SwarmState) string {
+		target := state.ActiveAgent
+		if target == "" || target == agent.Name || !slices.Contains(agentNames, target) {
+			return graph.END
+		}
+		if runTruncated(ctx) != nil {
+			return graph.END
+		}
+		if endsTurn(state) {
+			return graph.END
+		}
+		if len(agent.Destinations) > 0 && target != agent.Fallback && (escalation == "" || target != escalation) &&
+			!slices.Contains(agent.Destinations, target) {
+			return graph.END
+		}
+		return target
+	}
 }
 
 // addActiveAgentRouter adds a router that routes to the currently active agent.
@@ -131,42 +824,59 @@ func CreateSwarm(config SwarmConfig) (any, error) {
 //
 // Returns:
 //   - error if validation fails
-func addActiveAgentRouter(g any, agentNames []string, defaultActiveAgent string) error {
+func addActiveAgentRouter(g *graph.StateGraph[SwarmState], agentNames []string, defaultActiveAgent string) error {
 	// Validate default active agent
-	found := false
-	for _, name := range agentNames {
-		if name == defaultActiveAgent {
-			found = true
-			break
-		}
-	}
-	if !found {
-		return fmt.Errorf("default active agent '%s' not found in routes %v",
-			defaultActiveAgent, agentNames)
+	if !slices.Contains(agentNames, defaultActiveAgent) {
+		return fmt.Errorf("%w: default active agent '%s' not found in routes %v",
+			ErrUnknownAgent, defaultActiveAgent, agentNames)
 	}
 
-	// Create routing function
-	routeFunc := func(state SwarmState) string {
+	addRouterNode(g, activeAgentRoute(defaultActiveAgent), nil, nil)
+	return nil
+}
+
+// activeAgentRoute returns a routing function that selects the active agent,
+// falling back to defaultActiveAgent when none is set.
+func activeAgentRoute(defaultActiveAgent string) func(ctx context.Context, state SwarmState) string {
+	return func(ctx context.Context, state SwarmState) string {
 		if state.ActiveAgent != "" {
 			return state.ActiveAgent
 		}
 		return defaultActiveAgent
 	}
+}
 
-	// Add conditional edges from START
-	pathMap := make(map[string]string)
-	for _, name := range agentNames {
-		pathMap[name] = name
-	}
+// addRouterNode installs the start node and its routing edge on g. When
+// router is set, the start node stores the agent it selects in
+// state.ActiveAgent before route runs.
+func addRouterNode(g *graph.StateGraph[SwarmState], route func(ctx context.Context, state SwarmState) string, router Router, agentNames []string) {
+	// LangGraphGo has no conditional edges from START, so the entry point is
+	// a node whose outgoing conditional edge does the routing.
+	g.AddNode(startNode, "Route to the active agent", routerNodeFunc(router, agentNames))
+	g.SetEntryPoint(startNode)
+	g.AddConditionalEdge(startNode, route)
+}
 
-	// Use the AddConditionalEdges method - following example pattern
-	if stateGraph, ok := g.(interface {
-		AddConditionalEdges(string, func(SwarmState) string, map[string]string)
-	}); ok {
-		stateGraph.AddConditionalEdges("__start__", routeFunc, pathMap)
+// routerNodeFunc returns the function of the start node, which applies
+// router, if any, to the state; a pinned agent takes precedence over it.
+// The state is copied first, so that streams and direct invocations of the
+// graph cannot modify their caller's state.
+func routerNodeFunc(router Router, agentNames []string) func(ctx context.Context, state SwarmState) (SwarmState, error) {
+	return func(ctx context.Context, state SwarmState) (SwarmState, error) {
+		state = state.Clone()
+		if state.PinnedAgent != "" {
+			state.ActiveAgent = state.PinnedAgent
+		} else if router != nil {
+			var err error
+			if state, err = applyRouter(ctx, router, agentNames, state); err != nil {
+				return state, err
+			}
+		}
+		if agentNames != nil && state.ActiveAgent != "" && !slices.Contains(agentNames, state.ActiveAgent) {
+			return state, fmt.Errorf("%w: active agent '%s'", ErrUnknownAgent, state.ActiveAgent)
+		}
+		return state, nil
 	}
-
-	return nil
 }
 
 // AddActiveAgentRouter is a standalone function to add routing to an existing graph.
@@ -174,10 +884,10 @@ func addActiveAgentRouter(g any, agentNames []string, defaultActiveAgent string)
 //
 // Example:
 //
-//	g := graph.NewStateGraph(swarm.SwarmState{})
-//	g.AddNode("Alice", aliceNode)
-//	g.AddNode("Bob", bobNode)
+//	g := graph.NewStateGraph[swarm.SwarmState]()
+//	g.AddNode("Alice", "", aliceNode)
+//	g.AddNode("Bob", "", bobNode)
 //	err := swarm.AddActiveAgentRouter(g, []string{"Alice", "Bob"}, "Alice")
-func AddActiveAgentRouter(g any, agentNames []string, defaultActiveAgent string) error {
+func AddActiveAgentRouter(g *graph.StateGraph[SwarmState], agentNames []string, defaultActiveAgent string) error {
 	return addActiveAgentRouter(g, agentNames, defaultActiveAgent)
 }
@@ -2,15 +2,17 @@ package swarm
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
 )
 
 // Mock agent for testing
-func createMockAgent(name string, response string) any {
+func createMockAgent(name string, response string) AgentRunnable {
 	g := graph.NewStateGraph[SwarmState]()
 
 	g.AddNode("process", "", func(ctx context.Context, state SwarmState) (SwarmState, error) {
@@ -61,6 +63,39 @@ func TestCreateSwarm(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "unknown destination",
+			config: SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: createMockAgent("Alice", "Hello"), Destinations: []string{"Bobb"}},
+					{Name: "Bob", Runnable: createMockAgent("Bob", "Hello")},
+				},
+				DefaultActiveAgent: "Alice",
+			},
+			expectError: true,
+		},
+		{
+			name: "duplicate agent names",
+			config: SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: createMockAgent("Alice", "Hello")},
+					{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")},
+				},
+				DefaultActiveAgent: "Alice",
+			},
+			expectError: true,
+		},
+		{
+			name: "empty agent name",
+			config: SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: createMockAgent("Alice", "Hello")},
+					{Name: "", Runnable: createMockAgent("", "Hi")},
+				},
+				DefaultActiveAgent: "Alice",
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -73,6 +108,30 @@ func TestCreateSwarm(t *testing.T) {
 	}
 }
 
+func TestCreateSwarmReportsAllProblems(t *testing.T) {
+	_, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi"), Destinations: []string{"Bobb"}},
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Hi"), Destinations: []string{"Bob"}},
+		},
+		DefaultActiveAgent: "Carol",
+	})
+
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("CreateSwarm() error = %v, want *ConfigError", err)
+	}
+	if len(configErr.Problems) != 4 {
+		t.Errorf("Expected 4 problems, got %d: %v", len(configErr.Problems), err)
+	}
+	for _, want := range []string{"duplicate agent name 'Alice'", "'Carol'", "unknown destination 'Bobb'", "'Bob' lists itself"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
 func TestAddActiveAgentRouter(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -124,12 +183,7 @@ func TestSwarmStateManagement(t *testing.T) {
 		t.Fatalf("Failed to create swarm: %v", err)
 	}
 
-	app, err := func() (any, error) {
-		if compiler, ok := workflow.(interface{ Compile() (any, error) }); ok {
-			return compiler.Compile()
-		}
-		return nil, fmt.Errorf("workflow does not support Compile()")
-	}()
+	app, err := workflow.Compile()
 	if err != nil {
 		t.Fatalf("Failed to compile swarm: %v", err)
 	}
@@ -141,23 +195,11 @@ func TestSwarmStateManagement(t *testing.T) {
 		},
 	}
 
-	result, err := func() (any, error) {
-		if invoker, ok := app.(interface {
-			Invoke(context.Context, SwarmState) (any, error)
-		}); ok {
-			return invoker.Invoke(ctx, initialState)
-		}
-		return nil, fmt.Errorf("app does not support Invoke()")
-	}()
+	resultState, err := app.Invoke(ctx, initialState)
 	if err != nil {
 		t.Fatalf("Failed to invoke: %v", err)
 	}
 
-	resultState, ok := result.(SwarmState)
-	if !ok {
-		t.Fatalf("Result is not SwarmState")
-	}
-
 	// Should start with Alice (default)
 	if len(resultState.Messages) < 2 {
 		t.Errorf("Expected at least 2 messages, got %d", len(resultState.Messages))
@@ -171,24 +213,233 @@ func TestSwarmStateManagement(t *testing.T) {
 		ActiveAgent: "Bob",
 	}
 
-	result2, err := func() (any, error) {
-		if invoker, ok := app.(interface {
-			Invoke(context.Context, SwarmState) (any, error)
-		}); ok {
-			return invoker.Invoke(ctx, stateWithAgent)
-		}
-		return nil, fmt.Errorf("app does not support Invoke()")
-	}()
+	resultState2, err := app.Invoke(ctx, stateWithAgent)
 	if err != nil {
 		t.Fatalf("Failed to invoke with active agent: %v", err)
 	}
 
-	resultState2, ok := result2.(SwarmState)
-	if !ok {
-		t.Fatalf("Result is not SwarmState")
-	}
-
 	if len(resultState2.Messages) < 2 {
 		t.Errorf("Expected at least 2 messages, got %d", len(resultState2.Messages))
 	}
+	if got := resultState2.Messages[len(resultState2.Messages)-1].Parts[0]; got != (llms.TextContent{Text: "Bob speaking"}) {
+		t.Errorf("Expected Bob to answer, got %v", got)
+	}
+}
+
+func TestSwarmAgentRegistry(t *testing.T) {
+	s, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: createMockAgent("Alice", "Hi"), Destinations: []string{"Bob"}},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy"), Destinations: []string{"Alice"}},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create swarm: %v", err)
+	}
+
+	if names := s.AgentNames(); len(names) != 2 || names[0] != "Alice" || names[1] != "Bob" {
+		t.Errorf("AgentNames() = %v, want [Alice Bob]", names)
+	}
+	if agent, ok := s.Agent("Bob"); !ok || agent.Name != "Bob" {
+		t.Errorf("Agent(Bob) = %v, %v", agent, ok)
+	}
+	if _, ok := s.Agent("Charlie"); ok {
+		t.Error("Agent(Charlie) should not be found")
+	}
+	if s.DefaultActiveAgent() != "Alice" {
+		t.Errorf("DefaultActiveAgent() = %q, want Alice", s.DefaultActiveAgent())
+	}
+
+	app, err := s.Compile()
+	if err != nil {
+		t.Fatalf("Failed to compile swarm: %v", err)
+	}
+	if app.Swarm() != s {
+		t.Error("CompiledSwarm.Swarm() should return the source swarm")
+	}
+}
+
+func TestSwarmHandoffRouting(t *testing.T) {
+	newAlice := func() AgentRunnable {
+		model := &scriptedModel{responses: []*llms.ContentChoice{
+			toolCallChoice("call_1", "transfer_to_bob", `{}`),
+		}}
+		alice, err := CreateReactAgent(model, []tools.Tool{
+			CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"}),
+		})
+		if err != nil {
+			t.Fatalf("CreateReactAgent() error = %v", err)
+		}
+		return alice
+	}
+
+	tests := []struct {
+		name         string
+		destinations []string
+		wantBob      bool
+	}{
+		{"declared destination", []string{"Bob"}, true},
+		{"no destinations declared", nil, true},
+		{"undeclared destination", []string{"Carol"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := CreateSwarm(SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: newAlice(), Destinations: tt.destinations},
+					{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+					{Name: "Carol", Runnable: createMockAgent("Carol", "Hi")},
+				},
+				DefaultActiveAgent: "Alice",
+			})
+			if err != nil {
+				t.Fatalf("CreateSwarm() error = %v", err)
+			}
+			app, err := s.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			result, err := app.Invoke(context.Background(), SwarmState{
+				Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "talk to Bob")},
+			})
+			if err != nil {
+				t.Fatalf("Invoke() error = %v", err)
+			}
+
+			if result.ActiveAgent != "Bob" {
+				t.Errorf("Expected active agent Bob, got %q", result.ActiveAgent)
+			}
+			last := result.Messages[len(result.Messages)-1].Parts[0]
+			if gotBob := last == (llms.TextContent{Text: "Ahoy"}); gotBob != tt.wantBob {
+				t.Errorf("Bob answered in the same invocation = %v, want %v (last part %v)", gotBob, tt.wantBob, last)
+			}
+		})
+	}
+}
+
+func TestCreateSwarmInferDestinations(t *testing.T) {
+	newAgent := func(targets ...string) *ReactAgent {
+		var agentTools []tools.Tool
+		for _, target := range targets {
+			agentTools = append(agentTools, CreateHandoffTool(HandoffToolConfig{AgentName: target}))
+		}
+		agent, err := CreateReactAgent(&scriptedModel{}, agentTools)
+		if err != nil {
+			t.Fatalf("CreateReactAgent() error = %v", err)
+		}
+		return agent
+	}
+
+	s, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: newAgent("Bob", "Carol")},
+			{Name: "Bob", Runnable: newAgent("Alice"), Destinations: []string{"Alice"}},
+			{Name: "Carol", Runnable: createMockAgent("Carol", "Hi")},
+		},
+		DefaultActiveAgent: "Alice",
+		InferDestinations:  true,
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	alice, _ := s.Agent("Alice")
+	if len(alice.Destinations) != 2 || alice.Destinations[0] != "Bob" || alice.Destinations[1] != "Carol" {
+		t.Errorf("Expected Alice's destinations to be inferred as [Bob Carol], got %v", alice.Destinations)
+	}
+
+	tests := []struct {
+		name   string
+		agents []Agent
+	}{
+		{"declared destinations differ", []Agent{
+			{Name: "Alice", Runnable: newAgent("Bob"), Destinations: []string{"Carol"}},
+			{Name: "Bob"}, {Name: "Carol"},
+		}},
+		{"tool targets unknown agent", []Agent{
+			{Name: "Alice", Runnable: newAgent("Bobb")},
+			{Name: "Bob"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreateSwarm(SwarmConfig{Agents: tt.agents, DefaultActiveAgent: "Alice", InferDestinations: true})
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Errorf("CreateSwarm() error = %v, want *ConfigError", err)
+			}
+		})
+	}
+}
+
+func TestSwarmRecordsHandoffs(t *testing.T) {
+	alice, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_bob", `{"reason":"needs a pirate"}`),
+	}}, []tools.Tool{CreateHandoffTool(HandoffToolConfig{
+		AgentName: "Bob",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"reason": map[string]any{"type": "string"}},
+		},
+	})})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	s, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: handoffTo("Carol")},
+			{Name: "Carol", Runnable: createMockAgent("Carol", "Hi")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := s.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	previous := HandoffRecord{From: "Carol", To: "Alice"}
+	result, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+		Handoffs: []HandoffRecord{previous},
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	if len(result.Handoffs) != 3 {
+		t.Fatalf("Expected 3 handoffs, got %+v", result.Handoffs)
+	}
+	if result.Handoffs[0] != previous {
+		t.Errorf("Expected earlier handoffs to be kept, got %+v", result.Handoffs[0])
+	}
+	viaTool := result.Handoffs[1]
+	if viaTool.From != "Alice" || viaTool.To != "Bob" || viaTool.Reason != "needs a pirate" || viaTool.ToolCallID != "call_1" || viaTool.Timestamp.IsZero() {
+		t.Errorf("Unexpected tool handoff record %+v", viaTool)
+	}
+	direct := result.Handoffs[2]
+	if direct.From != "Bob" || direct.To != "Carol" || direct.ToolCallID != "" {
+		t.Errorf("Unexpected direct handoff record %+v", direct)
+	}
+}
+
+// compileSwarm creates and compiles a swarm, failing the test on error.
+func compileSwarm(t *testing.T, config SwarmConfig) *CompiledSwarm {
+	t.Helper()
+	workflow, err := CreateSwarm(config)
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return app
 }
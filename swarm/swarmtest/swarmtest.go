@@ -0,0 +1,344 @@
+// Package swarmtest provides test doubles and assertions for testing swarms
+// without a real model.
+//
+// MockModel answers model calls with canned responses, so agents built with
+// swarm.CreateReactAgent can be tested end to end. MockAgent replaces a whole
+// agent with scripted replies. The assertions check the outcome of a run:
+//
+//	model := swarmtest.NewMockModel(
+//	    swarmtest.ToolCall("lookup", `{"order":"42"}`),
+//	    swarmtest.Handoff("Refunds"),
+//	)
+//	alice, _ := swarm.CreateReactAgent(model, []tools.Tool{lookup, transferToRefunds})
+//	refunds := swarmtest.NewMockAgent(swarmtest.Reply{Text: "Your refund is on its way"})
+//
+//	app := swarmtest.Compile(t, swarm.SwarmConfig{
+//	    Agents: []swarm.Agent{
+//	        {Name: "Alice", Runnable: alice},
+//	        {Name: "Refunds", Runnable: refunds},
+//	    },
+//	    DefaultActiveAgent: "Alice",
+//	})
+//	result, err := app.Invoke(ctx, swarmtest.UserMessage("Refund order 42"))
+//
+//	swarmtest.AssertHandoffPath(t, result, "Alice", "Refunds")
+//	swarmtest.AssertToolCalled(t, result, "lookup")
+//	swarmtest.AssertLastMessageContains(t, result, "refund")
+package swarmtest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// toolCallIDs numbers the tool calls of MockModel responses, so their IDs
+// are unique across models.
+var toolCallIDs atomic.Int64
+
+// MockModel is an llms.Model answering each call with the next of its
+// responses. A call after the last response fails. It is safe for concurrent
+// use.
+type MockModel struct {
+	mu        sync.Mutex
+	responses []*llms.ContentChoice
+	calls     [][]llms.MessageContent
+	options   []llms.CallOptions
+}
+
+// NewMockModel creates a MockModel with the given responses, built for
+// example with Text, ToolCall and Handoff.
+func NewMockModel(responses ...*llms.ContentChoice) *MockModel {
+	return &MockModel{responses: responses}
+}
+
+// GenerateContent returns the next response, streaming its text to the
+// call's streaming function, if any. Tool calls without an ID get a unique
+// one.
+func (m *MockModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.mu.Lock()
+	m.calls = append(m.calls, slices.Clone(messages))
+	m.options = append(m.options, opts)
+	n := len(m.calls)
+	if n > len(m.responses) {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("swarmtest: unexpected model call %d, only %d responses are scripted", n, len(m.responses))
+	}
+	choice := m.responses[n-1]
+	m.mu.Unlock()
+
+	if slices.ContainsFunc(choice.ToolCalls, func(call llms.ToolCall) bool { return call.ID == "" }) {
+		copied := *choice
+		copied.ToolCalls = slices.Clone(choice.ToolCalls)
+		for i := range copied.ToolCalls {
+			if copied.ToolCalls[i].ID == "" {
+				copied.ToolCalls[i].ID = fmt.Sprintf("call_%d", toolCallIDs.Add(1))
+			}
+		}
+		choice = &copied
+	}
+
+	if opts.StreamingFunc != nil && choice.Content != "" {
+		if err := opts.StreamingFunc(ctx, []byte(choice.Content)); err != nil {
+			return nil, err
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+// Call implements llms.Model.
+func (m *MockModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// Calls returns the messages of each call so far.
+func (m *MockModel) Calls() [][]llms.MessageContent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.calls)
+}
+
+// CallOptions returns the options of each call so far, such as the tools
+// offered to the model.
+func (m *MockModel) CallOptions() []llms.CallOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.options)
+}
+
+// Text returns a model response answering with text.
+func Text(text string) *llms.ContentChoice {
+	return &llms.ContentChoice{Content: text, StopReason: "stop"}
+}
+
+// ToolCall returns a model response calling the tool name with the given
+// JSON arguments. Tools that are not swarm.SchemaProvider take their input
+// as {"input": "..."}.
+func ToolCall(name, arguments string) *llms.ContentChoice {
+	return &llms.ContentChoice{ToolCalls: []llms.ToolCall{{
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: name, Arguments: arguments},
+	}}}
+}
+
+// WithUsage returns a copy of response reporting token usage, as providers
+// do in its GenerationInfo, so that runs count it in swarm.Usage.
+func WithUsage(response *llms.ContentChoice, inputTokens, outputTokens int) *llms.ContentChoice {
+	copied := *response
+	copied.GenerationInfo = maps.Clone(response.GenerationInfo)
+	if copied.GenerationInfo == nil {
+		copied.GenerationInfo = make(map[string]any, 2)
+	}
+	copied.GenerationInfo["PromptTokens"] = inputTokens
+	copied.GenerationInfo["CompletionTokens"] = outputTokens
+	return &copied
+}
+
+// Handoff returns a model response calling the default handoff tool to
+// agent (see swarm.CreateHandoffTool).
+func Handoff(agent string) *llms.ContentChoice {
+	return ToolCall(swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: agent}).Name(), "{}")
+}
+
+// Reply is what a MockAgent does in one run.
+type Reply struct {
+	// Text is the answer added as an AI message, if not empty
+	Text string
+	// HandoffTo is the agent handed off to after the answer, through a
+	// handoff tool call as a model would make it
+	HandoffTo string
+	// Err fails the run
+	Err error
+}
+
+// MockAgent is an agent runnable (see swarm.Agent.Runnable) performing the
+// next of its replies on each run, and repeating the last one once all were
+// performed. It is safe for concurrent use.
+type MockAgent struct {
+	mu      sync.Mutex
+	replies []Reply
+	runs    []swarm.SwarmState
+}
+
+// NewMockAgent creates a MockAgent with the given replies.
+//
+// Example:
+//
+//	alice := swarmtest.NewMockAgent(
+//	    swarmtest.Reply{Text: "Let me get Bob", HandoffTo: "Bob"},
+//	    swarmtest.Reply{Text: "Anything else?"},
+//	)
+func NewMockAgent(replies ...Reply) *MockAgent {
+	return &MockAgent{replies: replies}
+}
+
+// Invoke performs the agent's next reply.
+func (a *MockAgent) Invoke(ctx context.Context, state swarm.SwarmState) (swarm.SwarmState, error) {
+	a.mu.Lock()
+	a.runs = append(a.runs, state.Clone())
+	var reply Reply
+	if len(a.replies) > 0 {
+		reply = a.replies[min(len(a.runs), len(a.replies))-1]
+	}
+	a.mu.Unlock()
+
+	if reply.Err != nil {
+		return state, reply.Err
+	}
+	if reply.Text != "" {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, reply.Text))
+	}
+	if reply.HandoffTo == "" {
+		return state, nil
+	}
+
+	tool := swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: reply.HandoffTo})
+	call := llms.ToolCall{
+		ID:           fmt.Sprintf("call_%s_%d", tool.Name(), len(state.Messages)),
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: tool.Name(), Arguments: "{}"},
+	}
+	callCtx, capture := swarm.WithHandoffCapture(ctx)
+	result, err := tool.Call(callCtx, call.FunctionCall.Arguments)
+	if err != nil {
+		return state, err
+	}
+	state.Messages = append(state.Messages,
+		llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{call}},
+		llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+			llms.ToolCallResponse{ToolCallID: call.ID, Name: tool.Name(), Content: result},
+		}})
+	if handoff, ok := capture.Last(); ok {
+		state.ActiveAgent = handoff.AgentName
+		state.HandoffPayload = handoff.Payload
+		state.Handoffs = append(slices.Clip(state.Handoffs), swarm.HandoffRecord{
+			To:         handoff.AgentName,
+			ToolCallID: call.ID,
+			Timestamp:  time.Now().UTC(),
+		})
+	}
+	return state, nil
+}
+
+// Runs returns the state each run of the agent started with.
+func (a *MockAgent) Runs() []swarm.SwarmState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.runs)
+}
+
+// Compile creates and compiles a swarm, failing the test on error.
+func Compile(t testing.TB, config swarm.SwarmConfig) *swarm.CompiledSwarm {
+	t.Helper()
+	workflow, err := swarm.CreateSwarm(config)
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return app
+}
+
+// UserMessage returns a state holding a single user message.
+func UserMessage(text string) swarm.SwarmState {
+	return swarm.SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, text)}}
+}
+
+// HandoffPath returns the agents a run went through: the agent of the first
+// handoff followed by the target of each handoff, or only the active agent
+// if there was no handoff.
+func HandoffPath(state swarm.SwarmState) []string {
+	if len(state.Handoffs) == 0 {
+		if state.ActiveAgent == "" {
+			return nil
+		}
+		return []string{state.ActiveAgent}
+	}
+	path := []string{state.Handoffs[0].From}
+	for _, handoff := range state.Handoffs {
+		path = append(path, handoff.To)
+	}
+	return path
+}
+
+// AssertHandoffPath checks that the handoffs of state went through agents,
+// in order (see HandoffPath).
+func AssertHandoffPath(t testing.TB, state swarm.SwarmState, agents ...string) {
+	t.Helper()
+	if path := HandoffPath(state); !slices.Equal(path, agents) {
+		t.Errorf("handoff path = %s, want %s", formatPath(path), formatPath(agents))
+	}
+}
+
+// AssertActiveAgent checks the active agent of state.
+func AssertActiveAgent(t testing.TB, state swarm.SwarmState, agent string) {
+	t.Helper()
+	if state.ActiveAgent != agent {
+		t.Errorf("active agent = %q, want %q", state.ActiveAgent, agent)
+	}
+}
+
+// AssertToolCalled checks that an AI message of state calls the tool name.
+func AssertToolCalled(t testing.TB, state swarm.SwarmState, name string) {
+	t.Helper()
+	var called []string
+	for _, message := range state.Messages {
+		for _, part := range message.Parts {
+			if call, ok := part.(llms.ToolCall); ok && call.FunctionCall != nil {
+				if call.FunctionCall.Name == name {
+					return
+				}
+				called = append(called, call.FunctionCall.Name)
+			}
+		}
+	}
+	t.Errorf("tool %q was not called; called tools: %v", name, called)
+}
+
+// AssertLastMessageContains checks that the text of the last message of
+// state contains substr, ignoring case.
+func AssertLastMessageContains(t testing.TB, state swarm.SwarmState, substr string) {
+	t.Helper()
+	if len(state.Messages) == 0 {
+		t.Errorf("no messages, want a last message containing %q", substr)
+		return
+	}
+	text := MessageText(state.Messages[len(state.Messages)-1])
+	if !strings.Contains(strings.ToLower(text), strings.ToLower(substr)) {
+		t.Errorf("last message = %q, want it to contain %q", text, substr)
+	}
+}
+
+// MessageText returns the text parts of message, joined by newlines.
+func MessageText(message llms.MessageContent) string {
+	var texts []string
+	for _, part := range message.Parts {
+		if text, ok := part.(llms.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// formatPath formats a handoff path as "A -> B".
+func formatPath(path []string) string {
+	if len(path) == 0 {
+		return "(none)"
+	}
+	return strings.Join(path, " -> ")
+}
@@ -0,0 +1,131 @@
+package swarmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// recorder is a testing.TB recording failures instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockModelWithReactAgent(t *testing.T) {
+	model := NewMockModel(
+		ToolCall("upper", `{"text":"hi"}`),
+		Handoff("Bob"),
+	)
+	upper := swarm.NewStructTool("upper", "Upper-case the input", func(ctx context.Context, args struct {
+		Text string `json:"text"`
+	}) (string, error) {
+		return strings.ToUpper(args.Text), nil
+	})
+	alice, err := swarm.CreateReactAgent(model, []tools.Tool{
+		upper,
+		swarm.CreateHandoffTool(swarm.HandoffToolConfig{AgentName: "Bob"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob := NewMockAgent(Reply{Text: "Arr, HI to ye"})
+	app := Compile(t, swarm.SwarmConfig{
+		Agents: []swarm.Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: bob},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), UserMessage("Shout hi"))
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	AssertHandoffPath(t, result, "Alice", "Bob")
+	AssertActiveAgent(t, result, "Bob")
+	AssertToolCalled(t, result, "upper")
+	AssertLastMessageContains(t, result, "arr, hi")
+
+	calls := model.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("model called %d times, want 2", len(calls))
+	}
+	if got := calls[1][len(calls[1])-1].Parts[0].(llms.ToolCallResponse).Content; got != "HI" {
+		t.Errorf("model saw tool result %q, want HI", got)
+	}
+	if tools := model.CallOptions()[0].Tools; len(tools) != 2 {
+		t.Errorf("model was offered %d tools, want 2", len(tools))
+	}
+	if runs := bob.Runs(); len(runs) != 1 {
+		t.Errorf("Bob ran %d times, want once", len(runs))
+	}
+	if _, err := model.GenerateContent(context.Background(), nil); err == nil {
+		t.Error("GenerateContent() after the scripted responses succeeded")
+	}
+}
+
+func TestMockAgentReplies(t *testing.T) {
+	alice := NewMockAgent(
+		Reply{Text: "Let me get Bob", HandoffTo: "Bob"},
+		Reply{Text: "Anything else?"},
+	)
+	bob := NewMockAgent(Reply{Text: "Back to Alice", HandoffTo: "Alice"}, Reply{Err: errors.New("Bob is down")})
+	app := Compile(t, swarm.SwarmConfig{
+		Agents: []swarm.Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: bob},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.Invoke(context.Background(), UserMessage("Hi"))
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	AssertHandoffPath(t, result, "Alice", "Bob", "Alice")
+	AssertLastMessageContains(t, result, "anything else")
+	if result.Handoffs[0].ToolCallID == "" {
+		t.Errorf("handoff = %+v, want it recorded from the tool call", result.Handoffs[0])
+	}
+
+	// Bob's second reply fails
+	state := result
+	state.ActiveAgent = "Bob"
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Bob?"))
+	if _, err := app.Invoke(context.Background(), state); err == nil || !strings.Contains(err.Error(), "Bob is down") {
+		t.Errorf("Invoke() error = %v, want Bob's error", err)
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	state := UserMessage("Hi")
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Hello"))
+	state.ActiveAgent = "Alice"
+
+	r := &recorder{TB: t}
+	AssertHandoffPath(r, state, "Alice", "Bob")
+	AssertActiveAgent(r, state, "Bob")
+	AssertToolCalled(r, state, "lookup")
+	AssertLastMessageContains(r, state, "goodbye")
+	want := []string{
+		"handoff path = Alice, want Alice -> Bob",
+		`active agent = "Alice", want "Bob"`,
+		`tool "lookup" was not called; called tools: []`,
+		`last message = "Hello", want it to contain "goodbye"`,
+	}
+	if strings.Join(r.errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("failures =\n%s\nwant\n%s", strings.Join(r.errors, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,272 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// TaskStatus is the status of a Task.
+type TaskStatus string
+
+// Task statuses.
+const (
+	// TaskPending is the status of a task waiting for its assignee
+	TaskPending TaskStatus = "pending"
+	// TaskCompleted is the status of a task whose assignee reported a result
+	TaskCompleted TaskStatus = "completed"
+)
+
+// ErrTaskNotFound is returned when no task has the given ID.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrNoTaskList is returned by task tools called outside of a ToolNode,
+// which gives them the tasks of the state.
+var ErrNoTaskList = errors.New("no task list in context")
+
+// Task is a unit of work an agent assigns to another, such as a research
+// question a planner hands to a researcher.
+type Task struct {
+	// ID identifies the task, e.g. "task_1"
+	ID string `json:"id"`
+	// Description says what to do
+	Description string `json:"description"`
+	// Assignee is the agent that should do the task
+	Assignee string `json:"assignee"`
+	// Status is TaskPending until the assignee completes the task
+	Status TaskStatus `json:"status"`
+	// Result is the outcome reported by the assignee
+	Result string `json:"result,omitempty"`
+	// CreatedBy is the agent that created the task
+	CreatedBy string `json:"created_by,omitempty"`
+	// Created and Completed are when the task was created and completed
+	Created   time.Time `json:"created"`
+	Completed time.Time `json:"completed,omitzero"`
+}
+
+// AddTask appends a pending task to the state and returns it with its ID.
+// The slice is copied before it is written, so states sharing it are not
+// affected.
+func (s *SwarmState) AddTask(description, assignee, createdBy string) Task {
+	task := Task{
+		ID:          "task_" + strconv.Itoa(len(s.Tasks)+1),
+		Description: description,
+		Assignee:    assignee,
+		Status:      TaskPending,
+		CreatedBy:   createdBy,
+		Created:     time.Now().UTC(),
+	}
+	s.Tasks = append(slices.Clip(s.Tasks), task)
+	return task
+}
+
+// CompleteTask marks the task with the given ID as completed with result.
+func (s *SwarmState) CompleteTask(id, result string) (Task, error) {
+	i := slices.IndexFunc(s.Tasks, func(task Task) bool { return task.ID == id })
+	if i < 0 {
+		return Task{}, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	s.Tasks = slices.Clone(s.Tasks)
+	s.Tasks[i].Status = TaskCompleted
+	s.Tasks[i].Result = result
+	s.Tasks[i].Completed = time.Now().UTC()
+	return s.Tasks[i], nil
+}
+
+// PendingTasks returns the pending tasks of the state, oldest first.
+func (s SwarmState) PendingTasks() []Task {
+	var pending []Task
+	for _, task := range s.Tasks {
+		if task.Status == TaskPending {
+			pending = append(pending, task)
+		}
+	}
+	return pending
+}
+
+// mergeTasks is the default "tasks" reducer. Tasks of update replace the
+// tasks of current with their ID; new tasks are appended.
+func mergeTasks(current, update any) (any, error) {
+	cur, _ := current.([]Task)
+	upd, ok := update.([]Task)
+	if !ok && update != nil {
+		return nil, fmt.Errorf("tasks reducer: unexpected update type %T", update)
+	}
+
+	merged := slices.Clone(cur)
+	for _, task := range upd {
+		i := slices.IndexFunc(merged, func(t Task) bool { return t.ID == task.ID })
+		if i < 0 {
+			merged = append(merged, task)
+		} else {
+			merged[i] = task
+		}
+	}
+	return merged, nil
+}
+
+// createTaskArgs are the arguments of the create_task tool.
+type createTaskArgs struct {
+	Description string `json:"description" jsonschema:"description=What the assignee should do"`
+	Assignee    string `json:"assignee" jsonschema:"description=Name of the agent to assign the task to"`
+}
+
+// completeTaskArgs are the arguments of the complete_task tool.
+type completeTaskArgs struct {
+	ID     string `json:"id" jsonschema:"description=ID of the task such as task_1"`
+	Result string `json:"result" jsonschema:"description=The outcome of the task"`
+}
+
+// listTasksArgs are the arguments of the list_tasks tool.
+type listTasksArgs struct {
+	Status   TaskStatus `json:"status,omitempty" jsonschema:"description=Only list tasks with this status,enum=pending,enum=completed"`
+	Assignee string     `json:"assignee,omitempty" jsonschema:"description=Only list the tasks of this agent"`
+}
+
+// TaskTools returns the create_task, complete_task and list_tasks tools,
+// with which a planner assigns tasks to workers and workers report their
+// results in SwarmState.Tasks. Set SwarmConfig.DispatchTasks, or use
+// TaskRouter, to run the assignees of pending tasks.
+//
+// Example:
+//
+//	taskTools := swarm.TaskTools()
+//	planner, _ := swarm.CreateReactAgent(model, taskTools, swarm.WithSystemPrompt(
+//	    "Split the question into tasks for the researcher, then summarize their results."))
+//	researcher, _ := swarm.CreateReactAgent(model, append(searchTools, taskTools...))
+func TaskTools() []tools.Tool {
+	return []tools.Tool{CreateTaskTool(), CompleteTaskTool(), ListTasksTool()}
+}
+
+// CreateTaskTool returns the create_task tool, which assigns a new task to
+// an agent.
+func CreateTaskTool() tools.Tool {
+	return NewStructTool("create_task", "Create a task and assign it to an agent. The agent runs once you are done.",
+		func(ctx context.Context, args createTaskArgs) (string, error) {
+			s, ok := toolStateFromContext(ctx)
+			if !ok {
+				return "", ErrNoTaskList
+			}
+			s.mu.Lock()
+			task := s.state.AddTask(args.Description, strings.TrimSpace(args.Assignee), AgentNameFromContext(ctx))
+			s.mu.Unlock()
+			return fmt.Sprintf("Created %s for %s.", task.ID, task.Assignee), nil
+		})
+}
+
+// CompleteTaskTool returns the complete_task tool, which reports the result
+// of a task.
+func CompleteTaskTool() tools.Tool {
+	return NewStructTool("complete_task", "Mark one of your tasks as completed and report its result.",
+		func(ctx context.Context, args completeTaskArgs) (string, error) {
+			s, ok := toolStateFromContext(ctx)
+			if !ok {
+				return "", ErrNoTaskList
+			}
+			s.mu.Lock()
+			task, err := s.state.CompleteTask(strings.TrimSpace(args.ID), args.Result)
+			s.mu.Unlock()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Completed %s.", task.ID), nil
+		})
+}
+
+// ListTasksTool returns the list_tasks tool, which lists the tasks with
+// their status and result.
+func ListTasksTool() tools.Tool {
+	return NewStructTool("list_tasks", "List the tasks of the conversation with their assignee, status and result.",
+		func(ctx context.Context, args listTasksArgs) (string, error) {
+			s, ok := toolStateFromContext(ctx)
+			if !ok {
+				return "", ErrNoTaskList
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			var b strings.Builder
+			for _, task := range s.state.Tasks {
+				if (args.Status != "" && task.Status != args.Status) || (args.Assignee != "" && task.Assignee != args.Assignee) {
+					continue
+				}
+				fmt.Fprintf(&b, "- %s (%s, %s): %s\n", task.ID, task.Assignee, task.Status, task.Description)
+				if task.Result != "" {
+					fmt.Fprintf(&b, "  Result: %s\n", task.Result)
+				}
+			}
+			if b.Len() == 0 {
+				return "No tasks.", nil
+			}
+			return b.String(), nil
+		})
+}
+
+// TaskRouter returns a Router that starts a turn with the assignee of the
+// oldest pending task, and asks next (if not nil) when no task is pending.
+//
+// Example:
+//
+//	Router: swarm.TaskRouter(swarm.KeepActiveAgent(llmRouter)),
+func TaskRouter(next Router) Router {
+	return RouterFunc(func(ctx context.Context, state SwarmState) (string, error) {
+		if pending := state.PendingTasks(); len(pending) > 0 {
+			return pending[0].Assignee, nil
+		}
+		if next == nil {
+			return "", nil
+		}
+		return next.Route(ctx, state)
+	})
+}
+
+// dispatchTask hands off from an agent that ended its run without a handoff
+// to the next agent with work: the assignee of the oldest pending task of
+// another agent or, once no task is pending, the creator of a task the
+// agent completed during the run. It returns result unchanged when there is
+// none.
+func dispatchTask(agent string, agentNames []string, before, result SwarmState) SwarmState {
+	if result.ActiveAgent != "" && result.ActiveAgent != agent {
+		return result
+	}
+
+	var target, reason string
+	var payload map[string]any
+	pending := result.PendingTasks()
+	for _, task := range pending {
+		if task.Assignee != agent && slices.Contains(agentNames, task.Assignee) {
+			target, reason = task.Assignee, task.Description
+			payload = map[string]any{"task_id": task.ID, HandoffTaskDescriptionKey: task.Description}
+			break
+		}
+	}
+	if target == "" && len(pending) == 0 {
+		for _, task := range result.Tasks {
+			completedNow := task.Status == TaskCompleted && !slices.ContainsFunc(before.Tasks, func(t Task) bool {
+				return t.ID == task.ID && t.Status == TaskCompleted
+			})
+			if completedNow && task.CreatedBy != "" && task.CreatedBy != agent && slices.Contains(agentNames, task.CreatedBy) {
+				target, reason = task.CreatedBy, "all tasks completed"
+				break
+			}
+		}
+	}
+	if target == "" {
+		return result
+	}
+
+	result.ActiveAgent = target
+	result.HandoffPayload = payload
+	result.Handoffs = append(slices.Clip(result.Handoffs), HandoffRecord{
+		From:      agent,
+		To:        target,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	})
+	return result
+}
@@ -0,0 +1,100 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestDispatchTasks(t *testing.T) {
+	planner := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "create_task", `{"description":"Find flights","assignee":"researcher"}`),
+		toolCallChoice("call_2", "create_task", `{"description":"Find hotels","assignee":"researcher"}`),
+		{Content: "Tasks created."},
+		{Content: "Flight AF12 and Hotel Lutetia."},
+	}}
+	researcher := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_3", "complete_task", `{"id":"task_1","result":"AF12"}`),
+		toolCallChoice("call_4", "complete_task", `{"id":"task_2","result":"Lutetia"}`),
+		{Content: "Done."},
+	}}
+	plannerAgent, err := CreateReactAgent(planner, TaskTools())
+	if err != nil {
+		t.Fatal(err)
+	}
+	researcherAgent, err := CreateReactAgent(researcher, TaskTools())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "planner", Runnable: plannerAgent},
+			{Name: "researcher", Runnable: researcherAgent},
+		},
+		DefaultActiveAgent: "planner",
+		DispatchTasks:      true,
+	})
+
+	result, err := app.Invoke(context.Background(), SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Plan my trip to Paris"),
+	}})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(result.Tasks) != 2 || result.Tasks[0].Result != "AF12" || result.Tasks[1].Status != TaskCompleted ||
+		result.Tasks[0].CreatedBy != "planner" || len(result.PendingTasks()) != 0 {
+		t.Errorf("tasks = %+v", result.Tasks)
+	}
+	if len(result.Handoffs) != 2 || result.Handoffs[0].To != "researcher" || result.Handoffs[0].Reason != "Find flights" ||
+		result.Handoffs[1].To != "planner" {
+		t.Errorf("handoffs = %+v", result.Handoffs)
+	}
+	if result.ActiveAgent != "planner" || len(planner.calls) != 4 {
+		t.Errorf("active agent = %s after %d planner calls", result.ActiveAgent, len(planner.calls))
+	}
+}
+
+func TestTaskTools(t *testing.T) {
+	state := SwarmState{}
+	state.AddTask("Find flights", "researcher", "planner")
+	state.AddTask("Find hotels", "booker", "planner")
+	if _, err := state.CompleteTask("task_1", "AF12"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.CompleteTask("task_9", ""); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("CompleteTask() of an unknown task error = %v", err)
+	}
+
+	ctx := withToolState(context.Background(), &state)
+	list := ListTasksTool()
+	all, err := list.Call(ctx, `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- task_1 (researcher, completed): Find flights\n  Result: AF12\n- task_2 (booker, pending): Find hotels\n"
+	if all != want {
+		t.Errorf("list_tasks = %q, want %q", all, want)
+	}
+	if pending, _ := list.Call(ctx, `{"status":"pending"}`); strings.Contains(pending, "task_1") {
+		t.Errorf("list_tasks pending = %q", pending)
+	}
+
+	if _, err := CreateTaskTool().Call(context.Background(), `{"description":"x","assignee":"y"}`); !errors.Is(err, ErrNoTaskList) {
+		t.Errorf("Call() outside a tool node error = %v, want ErrNoTaskList", err)
+	}
+}
+
+func TestTaskRouter(t *testing.T) {
+	router := TaskRouter(nil)
+	state := SwarmState{}
+	if name, _ := router.Route(context.Background(), state); name != "" {
+		t.Errorf("Route() without tasks = %q", name)
+	}
+	state.AddTask("Find hotels", "booker", "planner")
+	if name, _ := router.Route(context.Background(), state); name != "booker" {
+		t.Errorf("Route() = %q, want the assignee", name)
+	}
+}
@@ -0,0 +1,183 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// UpdatedMetadataKey is the checkpoint metadata key marking the checkpoints
+// saved by CompiledSwarm.UpdateState.
+const UpdatedMetadataKey = "updated"
+
+// ErrThreadInterrupted is returned by CompiledSwarm.SetActiveAgent for
+// threads waiting for a human's input (see ThreadState.Interrupted), which
+// must be resumed first.
+var ErrThreadInterrupted = errors.New("thread is interrupted")
+
+// ThreadState is the saved state of a thread, as returned by
+// CompiledSwarm.GetState.
+type ThreadState struct {
+	ThreadID string
+	// CheckpointID is the ID of the thread's latest checkpoint
+	CheckpointID string
+	State        SwarmState
+	Metadata     map[string]any
+	CreatedAt    time.Time
+	// Next is the agent the thread continues with: the interrupted agent,
+	// or else the active agent, or else the swarm's default agent
+	Next string
+	// PendingToolCalls are the tool calls awaiting approval, if the thread
+	// is interrupted
+	PendingToolCalls []llms.ToolCall
+	// PendingReply is true if the thread is waiting for the reply of a
+	// HumanAgent
+	PendingReply bool
+}
+
+// Interrupted reports whether the thread is waiting for a human's input
+// before it can continue (see CompiledSwarm.Resume).
+func (s *ThreadState) Interrupted() bool {
+	return len(s.PendingToolCalls) > 0 || s.PendingReply
+}
+
+// StatePatch edits the state of a thread (see CompiledSwarm.UpdateState).
+type StatePatch func(state *SwarmState) error
+
+// GetState returns the latest saved state of a thread, so that operators
+// and review UIs can inspect a paused conversation. It returns an error
+// matching ErrCheckpointNotFound for unknown threads.
+func (c *CompiledSwarm) GetState(ctx context.Context, threadID string) (*ThreadState, error) {
+	_, config, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	if config.Checkpointer == nil {
+		return nil, fmt.Errorf("thread '%s': no checkpointer configured", threadID)
+	}
+	checkpoint, err := config.Checkpointer.Latest(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return threadState(config, checkpoint), nil
+}
+
+// UpdateState edits the saved state of a thread with patch and saves the
+// result as the thread's latest checkpoint, before the thread is resumed
+// or continues with its next message. The checkpoint keeps the metadata of
+// the previous one, so an interrupted thread stays interrupted while tool
+// calls are pending or a human agent awaits a reply, and is marked with
+// UpdatedMetadataKey. Updating an unknown thread starts it from the
+// patched empty state.
+//
+// Example:
+//
+//	// Lower the amount of a pending transfer before approving it
+//	_, err := app.UpdateState(ctx, "user_123", func(state *swarm.SwarmState) error {
+//	    last := state.Messages[len(state.Messages)-1]
+//	    call := last.Parts[0].(llms.ToolCall)
+//	    call.FunctionCall.Arguments = `{"amount": 100}`
+//	    last.Parts[0] = call
+//	    return nil
+//	})
+//	result, err := app.Resume(ctx, "user_123", swarm.Approval{Approved: true})
+func (c *CompiledSwarm) UpdateState(ctx context.Context, threadID string, patch StatePatch) (*ThreadState, error) {
+	return c.updateState(ctx, threadID, func(_ *ThreadState, state *SwarmState) error {
+		return patch(state)
+	})
+}
+
+// updateState is UpdateState with a patch that also gets the thread state
+// it edits, to refuse edits depending on it.
+func (c *CompiledSwarm) updateState(ctx context.Context, threadID string, patch func(previous *ThreadState, state *SwarmState) error) (*ThreadState, error) {
+	_, config, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+	store := config.Checkpointer
+	if store == nil {
+		return nil, fmt.Errorf("thread '%s': no checkpointer configured", threadID)
+	}
+	previous, err := store.Latest(ctx, threadID)
+	if errors.Is(err, ErrCheckpointNotFound) {
+		previous, err = &Checkpoint{ThreadID: threadID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := previous.State.Clone()
+	if err := patch(threadState(config, previous), &state); err != nil {
+		return nil, fmt.Errorf("thread '%s': update state: %w", threadID, err)
+	}
+	metadata := maps.Clone(previous.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]any, 1)
+	}
+	metadata[UpdatedMetadataKey] = true
+	if len(pendingToolCalls(state)) == 0 {
+		// The patch answered or removed the tool calls awaiting approval
+		delete(metadata, interruptedMetadataKey)
+	}
+	checkpoint := &Checkpoint{ThreadID: threadID, State: state, Metadata: metadata}
+	if err := store.Put(ctx, checkpoint); err != nil {
+		return nil, fmt.Errorf("thread '%s': save checkpoint: %w", threadID, err)
+	}
+	return threadState(config, checkpoint), nil
+}
+
+// SetActiveAgent pins a thread to agent, e.g. for a support supervisor to
+// route a conversation to a human escalation agent. The thread continues
+// with agent from its next message, whatever the router chooses, and
+// handoffs to other agents are denied until the thread is unpinned with an
+// empty agent, which keeps the active agent as it is. Fallback agents still
+// take over from a pinned agent that fails. Interrupted threads must be
+// resumed first: pinning them fails with an error matching
+// ErrThreadInterrupted.
+//
+// Example:
+//
+//	_, err := app.SetActiveAgent(ctx, "user_123", "human_escalation")
+//	...
+//	_, err = app.SetActiveAgent(ctx, "user_123", "") // back to model-driven handoffs
+func (c *CompiledSwarm) SetActiveAgent(ctx context.Context, threadID, agent string) (*ThreadState, error) {
+	if _, ok := c.swarm.Agent(agent); agent != "" && !ok {
+		return nil, fmt.Errorf("thread '%s': %w: '%s'", threadID, ErrUnknownAgent, agent)
+	}
+	return c.updateState(ctx, threadID, func(previous *ThreadState, state *SwarmState) error {
+		if agent != "" && previous.Interrupted() {
+			return ErrThreadInterrupted
+		}
+		state.PinnedAgent = agent
+		if agent != "" {
+			state.ActiveAgent = agent
+		}
+		return nil
+	})
+}
+
+// threadState returns the ThreadState of the latest checkpoint of a thread.
+func threadState(config SwarmConfig, checkpoint *Checkpoint) *ThreadState {
+	s := &ThreadState{
+		ThreadID:     checkpoint.ThreadID,
+		CheckpointID: checkpoint.ID,
+		State:        checkpoint.State,
+		Metadata:     checkpoint.Metadata,
+		CreatedAt:    checkpoint.CreatedAt,
+		Next:         checkpoint.State.ActiveAgent,
+	}
+	if agent, calls, ok := checkpoint.PendingApproval(); ok {
+		s.Next, s.PendingToolCalls = agent, calls
+	}
+	if agent, ok := checkpoint.PendingReply(); ok {
+		s.Next, s.PendingReply = agent, true
+	}
+	if s.Next == "" {
+		s.Next = config.DefaultActiveAgent
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestUpdateStateBeforeResume(t *testing.T) {
+	ctx := context.Background()
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "upper", `{"input":"hello"}`),
+		{Content: "Done"},
+	}}
+	app := compileInterruptible(t, model, NewMemorySaver())
+	if _, err := app.Invoke(ctx, SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "shout hello")},
+	}, WithThreadID("thread-1")); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Invoke() error = %v, want ErrInterrupted", err)
+	}
+
+	paused, err := app.GetState(ctx, "thread-1")
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if !paused.Interrupted() || paused.Next != "Alice" || paused.PendingToolCalls[0].ID != "call_1" || len(paused.State.Messages) != 2 {
+		t.Errorf("GetState() = %+v", paused)
+	}
+
+	if _, err := app.SetActiveAgent(ctx, "thread-1", "Alice"); !errors.Is(err, ErrThreadInterrupted) {
+		t.Errorf("SetActiveAgent() of an interrupted thread error = %v, want ErrThreadInterrupted", err)
+	}
+
+	// An operator corrects the pending tool call before approving it
+	updated, err := app.UpdateState(ctx, "thread-1", func(state *SwarmState) error {
+		last := state.Messages[len(state.Messages)-1]
+		call := last.Parts[0].(llms.ToolCall)
+		call.FunctionCall.Arguments = `{"input":"bye"}`
+		last.Parts[0] = call
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateState() error = %v", err)
+	}
+	if !updated.Interrupted() || updated.CheckpointID == paused.CheckpointID || updated.Metadata[UpdatedMetadataKey] != true {
+		t.Errorf("UpdateState() = %+v", updated)
+	}
+	if call := paused.State.Messages[1].Parts[0].(llms.ToolCall); call.FunctionCall.Arguments != `{"input":"hello"}` {
+		t.Errorf("UpdateState() changed the previous checkpoint: %s", call.FunctionCall.Arguments)
+	}
+
+	result, err := app.Resume(ctx, "thread-1", Approval{Approved: true})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if response := result.Messages[2].Parts[0].(llms.ToolCallResponse); response.Content != "BYE" {
+		t.Errorf("tool response = %q, want the edited call's result", response.Content)
+	}
+	if done, _ := app.GetState(ctx, "thread-1"); done.Interrupted() {
+		t.Error("GetState() after Resume() is still interrupted")
+	}
+}
+
+func TestUpdateStateRemovesPendingToolCalls(t *testing.T) {
+	ctx := context.Background()
+	model := &scriptedModel{responses: []*llms.ContentChoice{toolCallChoice("call_1", "upper", `{"input":"hello"}`)}}
+	app := compileInterruptible(t, model, NewMemorySaver())
+	if _, err := app.Invoke(ctx, SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "shout hello")},
+	}, WithThreadID("thread-1")); !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Invoke() error = %v, want ErrInterrupted", err)
+	}
+
+	// An operator drops the tool call instead of approving it
+	updated, err := app.UpdateState(ctx, "thread-1", func(state *SwarmState) error {
+		state.Messages = state.Messages[:len(state.Messages)-1]
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateState() error = %v", err)
+	}
+	if updated.Interrupted() || updated.PendingReply {
+		t.Errorf("UpdateState() = %+v, want a thread that is not interrupted", updated)
+	}
+	if _, err := app.Resume(ctx, "thread-1", HumanReply{Content: "Hi"}); err == nil {
+		t.Error("Resume() with a human reply: expected error")
+	}
+}
+
+func TestThreadStateErrors(t *testing.T) {
+	ctx := context.Background()
+	app := compileInterruptible(t, &scriptedModel{}, NewMemorySaver())
+
+	if _, err := app.GetState(ctx, "missing"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("GetState() of an unknown thread error = %v, want ErrCheckpointNotFound", err)
+	}
+	patchErr := errors.New("invalid edit")
+	if _, err := app.UpdateState(ctx, "thread-1", func(state *SwarmState) error { return patchErr }); !errors.Is(err, patchErr) {
+		t.Errorf("UpdateState() error = %v, want the patch error", err)
+	}
+	created, err := app.UpdateState(ctx, "thread-1", func(state *SwarmState) error {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "Hello"))
+		return nil
+	})
+	if err != nil || created.Next != "Alice" || created.Interrupted() || len(created.State.Messages) != 1 {
+		t.Errorf("UpdateState() of a new thread = %+v, %v", created, err)
+	}
+	if _, err := app.Resume(ctx, "thread-1", Approval{Approved: true}); err == nil {
+		t.Error("Resume() of a thread that is not interrupted: expected error")
+	}
+	if _, err := app.Resume(ctx, "thread-1", nil); err == nil {
+		t.Error("Resume() without input: expected error")
+	}
+}
+
+func TestSetActiveAgent(t *testing.T) {
+	ctx := context.Background()
+	model := &scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "transfer_to_triage", `{}`),
+		{Content: "A supervisor will take it from here."},
+	}}
+	escalation, err := CreateReactAgent(model, []tools.Tool{
+		CreateHandoffTool(HandoffToolConfig{AgentName: "triage"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "triage", Runnable: createMockAgent("triage", "How can I help?")},
+			{Name: "human_escalation", Runnable: escalation},
+		},
+		DefaultActiveAgent: "triage",
+		Router: RouterFunc(func(ctx context.Context, state SwarmState) (string, error) {
+			return "triage", nil
+		}),
+		Checkpointer: NewMemorySaver(),
+	})
+
+	if _, err := app.SetActiveAgent(ctx, "thread-1", "supervisor"); !errors.Is(err, ErrUnknownAgent) {
+		t.Errorf("SetActiveAgent() error = %v, want ErrUnknownAgent", err)
+	}
+	pinned, err := app.SetActiveAgent(ctx, "thread-1", "human_escalation")
+	if err != nil {
+		t.Fatalf("SetActiveAgent() error = %v", err)
+	}
+	if pinned.Next != "human_escalation" || pinned.State.PinnedAgent != "human_escalation" {
+		t.Errorf("SetActiveAgent() = %+v", pinned)
+	}
+
+	// The router and the agent's handoff are both overridden
+	state := pinned.State
+	state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeHuman, "I want to cancel"))
+	result, err := app.Invoke(ctx, state, WithThreadID("thread-1"))
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result.ActiveAgent != "human_escalation" || len(result.Handoffs) != 0 {
+		t.Errorf("ActiveAgent = %q, Handoffs = %v, want the pinned agent", result.ActiveAgent, result.Handoffs)
+	}
+	if response := result.Messages[2].Parts[0].(llms.ToolCallResponse); response.Content != deniedTransferMessage("triage") {
+		t.Errorf("tool result = %q, want a denied handoff", response.Content)
+	}
+
+	unpinned, err := app.SetActiveAgent(ctx, "thread-1", "")
+	if err != nil {
+		t.Fatalf("SetActiveAgent() error = %v", err)
+	}
+	if unpinned.State.PinnedAgent != "" || unpinned.Next != "human_escalation" {
+		t.Errorf("SetActiveAgent() after unpinning = %+v", unpinned)
+	}
+}
@@ -0,0 +1,395 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/events"
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ToolNode is a graph node that executes the tool calls requested by the
+// last AI message in the swarm state.
+//
+// Each tool call produces a tool message carrying the originating
+// tool_call_id. Tools that report a handoff through RecordHandoff (such as
+// those from CreateHandoffTool) also update the active agent and the
+// handoff payload, and append a HandoffRecord to SwarmState.Handoffs.
+type ToolNode struct {
+	tools map[string]tools.Tool
+}
+
+// NewToolNode creates a ToolNode for the given tools.
+// If several tools share a name, the last one wins.
+//
+// Example:
+//
+//	toolNode := swarm.NewToolNode([]tools.Tool{searchTool, transferToBob})
+//	g.AddNode("tools", "Execute tool calls", toolNode.Invoke)
+func NewToolNode(nodeTools []tools.Tool) *ToolNode {
+	byName := make(map[string]tools.Tool, len(nodeTools))
+	for _, t := range nodeTools {
+		byName[t.Name()] = t
+	}
+	return &ToolNode{tools: byName}
+}
+
+// HandoffDestinations returns the agents the node's handoff tools transfer
+// to, sorted by name.
+func (n *ToolNode) HandoffDestinations() []string {
+	var destinations []string
+	for _, t := range n.tools {
+		switch h := t.(type) {
+		case HandoffTool:
+			if !slices.Contains(destinations, h.HandoffDestination()) {
+				destinations = append(destinations, h.HandoffDestination())
+			}
+		case *dynamicHandoffTool:
+			for _, agent := range h.agents {
+				if !slices.Contains(destinations, agent) {
+					destinations = append(destinations, agent)
+				}
+			}
+		}
+	}
+	slices.Sort(destinations)
+	return destinations
+}
+
+// Invoke executes the pending tool calls and returns the updated state.
+// Its signature matches a StateGraph[SwarmState] node function.
+func (n *ToolNode) Invoke(ctx context.Context, state SwarmState) (SwarmState, error) {
+	state, _, err := n.execute(ctx, state)
+	return state, err
+}
+
+// InvokeCommand executes the pending tool calls and returns a Command whose
+// Update is the new state. When a handoff tool fired, Goto is the target agent.
+func (n *ToolNode) InvokeCommand(ctx context.Context, state SwarmState) (*graph.Command, error) {
+	state, targetAgent, err := n.execute(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &graph.Command{Update: state}
+	if targetAgent != "" {
+		cmd.Goto = targetAgent
+	}
+	return cmd, nil
+}
+
+// HandedOff reports whether the trailing tool messages in state include the
+// response of one of this node's handoff tools (see HandoffTool) that handed
+// off, as recorded in state.Handoffs. Denied handoffs (see
+// SwarmConfig.OnHandoff) do not count.
+func (n *ToolNode) HandedOff(state SwarmState) bool {
+	return n.handedOff(context.Background(), state)
+}
+
+// handedOff is HandedOff counting the handoff tools of the running agent's
+// Agent.Tools as the node's.
+func (n *ToolNode) handedOff(ctx context.Context, state SwarmState) bool {
+	messages := state.Messages
+	for i := len(messages) - 1; i >= 0 && messages[i].Role == llms.ChatMessageTypeTool; i-- {
+		for _, part := range messages[i].Parts {
+			resp, ok := part.(llms.ToolCallResponse)
+			if !ok {
+				continue
+			}
+			if t, ok := n.tool(ctx, resp.Name); !ok || !isHandoffTool(t) {
+				continue
+			}
+			if slices.ContainsFunc(state.Handoffs, func(h HandoffRecord) bool { return h.ToolCallID == resp.ToolCallID }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tool returns the tool named name among the node's tools and then the
+// running agent's Agent.Tools.
+func (n *ToolNode) tool(ctx context.Context, name string) (tools.Tool, bool) {
+	if t, ok := n.tools[name]; ok {
+		return t, true
+	}
+	if settings, ok := ctx.Value(agentModelKey{}).(agentModel); ok {
+		for _, t := range settings.tools {
+			if t.Name() == name {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// execute runs the tool calls of the last AI message and appends one tool
+// message per call. It returns the handoff target, if any.
+func (n *ToolNode) execute(ctx context.Context, state SwarmState) (SwarmState, string, error) {
+	if len(state.Messages) == 0 {
+		return state, "", fmt.Errorf("no messages in state")
+	}
+	last := state.Messages[len(state.Messages)-1]
+	if last.Role != llms.ChatMessageTypeAI {
+		return state, "", fmt.Errorf("last message is not an AI message")
+	}
+
+	// Pause before tool calls that need a human's approval, unless they are
+	// answered from the recording of a replay
+	calls := toolCalls(last)
+	recording := toolRecordingFromContext(ctx)
+	var held []llms.ToolCall
+	for _, tc := range calls {
+		if recording != nil || tc.FunctionCall == nil {
+			continue
+		}
+		if t, _ := n.tool(ctx, tc.FunctionCall.Name); !requiresApproval(ctx, tc.FunctionCall.Name, t) ||
+			toolPolicyViolation(ctx, tc.FunctionCall.Name, t) != nil {
+			continue
+		}
+		if _, decided := approvalFor(ctx, tc.ID); !decided {
+			held = append(held, tc)
+		}
+	}
+	if len(held) > 0 {
+		agent := AgentNameFromContext(ctx)
+		if agent == "" {
+			agent = state.ActiveAgent
+		}
+		return state, "", &InterruptError{Agent: agent, ToolCalls: held, State: state}
+	}
+
+	handler := StreamHandlerFromContext(ctx)
+	ctx = withToolState(ctx, &state)
+	var handoffTarget string
+	for _, tc := range calls {
+		if tc.FunctionCall == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return state, "", err
+		}
+		name := tc.FunctionCall.Name
+		if handler != nil {
+			handler.OnToolCall(ctx, AgentNameFromContext(ctx), tc)
+		}
+
+		var content string
+		start, duration := time.Now(), time.Duration(0)
+		var callErr error
+		t, found := n.tool(ctx, name)
+		if approval, decided := approvalFor(ctx, tc.ID); decided && !approval.Approved {
+			content = rejectionMessage(approval)
+		} else if violation := toolPolicyViolation(ctx, name, t); violation != nil {
+			content = refuseToolCall(ctx, tc, violation)
+		} else if stopped := deadlineExceeded(ctx); stopped != nil {
+			// A run past its deadline starts no further tool calls
+			content = skippedToolCall(stopped)
+		} else if !found {
+			content = fmt.Sprintf("Error: tool '%s' not found", name)
+		} else if arguments, err := beforeTool(ctx, tc); err != nil {
+			content = fmt.Sprintf("Error: %v", err)
+		} else if input, err := toolCallInput(ctx, state, t, arguments); err != nil {
+			content = fmt.Sprintf("Error: %v", err)
+		} else {
+			isHandoff := isHandoffTool(t)
+
+			callCtx, span := tracer(ctx, nil).Start(ctx, "tool "+name,
+				trace.WithAttributes(attrTool.String(name), attrToolCallID.String(tc.ID)))
+			callCtx, capture := WithHandoffCapture(callCtx)
+			start = time.Now()
+			var result string
+			var err error
+			if recording != nil && !isHandoff {
+				result, err = recording.output(tc)
+			} else {
+				result, err = t.Call(callCtx, input)
+			}
+			endSpan(span, err)
+			if err != nil {
+				err = &ToolExecutionError{Tool: name, ToolCallID: tc.ID, Cause: err}
+			}
+			duration, callErr = time.Since(start), err
+			if bus := events.BusFromContext(ctx); bus != nil {
+				bus.Publish(ctx, events.ToolCalled{
+					Time:       time.Now(),
+					Agent:      AgentNameFromContext(ctx),
+					Tool:       name,
+					ToolCallID: tc.ID,
+					Arguments:  arguments,
+					Result:     result,
+					Duration:   duration,
+					Err:        err,
+				})
+			}
+			if err != nil && ctx.Err() != nil {
+				return state, "", &ToolExecutionError{Tool: name, ToolCallID: tc.ID, Cause: ctx.Err()}
+			}
+			if err != nil {
+				content = fmt.Sprintf("Error: %v", callErr.(*ToolExecutionError).Cause)
+			} else {
+				content = result
+				if handoff, ok := capture.Last(); ok {
+					from := AgentNameFromContext(ctx)
+					if from == "" {
+						from = state.ActiveAgent
+					}
+					target, err := approveHandoff(ctx, from, handoff.AgentName, state)
+					if err != nil {
+						return state, "", err
+					}
+					if target == "" {
+						content = deniedTransferMessage(handoff.AgentName)
+						callErr = fmt.Errorf("%w: transfer to '%s'", ErrHandoffDenied, handoff.AgentName)
+					} else {
+						if target != handoff.AgentName {
+							content = transferMessage(target)
+						}
+						reason, _ := handoff.Payload["reason"].(string)
+						if reason == "" {
+							reason, _ = handoff.Payload[HandoffTaskDescriptionKey].(string)
+						}
+						state.Handoffs = append(slices.Clip(state.Handoffs), HandoffRecord{
+							From:       from,
+							To:         target,
+							Reason:     reason,
+							ToolCallID: tc.ID,
+							Mode:       handoff.Mode,
+							Timestamp:  time.Now().UTC(),
+						})
+						state.ActiveAgent = target
+						state.HandoffPayload = handoff.Payload
+						handoffTarget = target
+					}
+				}
+			}
+		}
+
+		if filtered, err := afterTool(ctx, tc, content); err != nil {
+			content = fmt.Sprintf("Error: %v", err)
+		} else {
+			content = filtered
+		}
+
+		call := TraceToolCall{ID: tc.ID, Name: name, Arguments: tc.FunctionCall.Arguments, Result: content, Start: start, Duration: duration}
+		if callErr != nil {
+			call.Error = callErr.Error()
+		}
+		recordToolCall(ctx, call)
+
+		state.Messages = append(state.Messages, llms.MessageContent{
+			Role: llms.ChatMessageTypeTool,
+			Parts: []llms.ContentPart{
+				llms.ToolCallResponse{
+					ToolCallID: tc.ID,
+					Name:       name,
+					Content:    content,
+				},
+			},
+		})
+	}
+
+	return state, handoffTarget, nil
+}
+
+// SchemaProvider is implemented by tools that describe their arguments with
+// a JSON schema. ToolDefinition advertises the schema to the model and
+// ToolNode passes such tools the raw JSON arguments of each call.
+type SchemaProvider interface {
+	// Schema returns the JSON schema (an object schema) of the tool's arguments
+	Schema() map[string]any
+}
+
+// ToolDefinition builds the llms.Tool definition that advertises t to a
+// model. Tools implementing SchemaProvider (including handoff tools) describe
+// their own arguments; other tools take a single "input" string, matching
+// how ToolNode calls them.
+//
+// Example:
+//
+//	response, err := model.GenerateContent(ctx, messages, llms.WithTools([]llms.Tool{
+//	    swarm.ToolDefinition(searchTool),
+//	    swarm.ToolDefinition(transferToBob),
+//	}))
+func ToolDefinition(t tools.Tool) llms.Tool {
+	parameters := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"input": map[string]any{
+				"type":        "string",
+				"description": "The input to the tool",
+			},
+		},
+		"required": []string{"input"},
+	}
+	if p, ok := t.(SchemaProvider); ok {
+		parameters = p.Schema()
+	}
+
+	return llms.Tool{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Parameters:  parameters,
+		},
+	}
+}
+
+// toolCallInput returns the input of a call to t. Handoff tools receive the
+// raw JSON arguments, and other SchemaProvider tools the JSON arguments;
+// the remaining tools receive the "input" extracted from them. Except for
+// handoff tools, the artifacts the arguments refer to are replaced by their
+// content.
+func toolCallInput(ctx context.Context, state SwarmState, t tools.Tool, arguments string) (string, error) {
+	if isHandoffTool(t) {
+		return arguments, nil
+	}
+	hydrated, err := hydrateArtifacts(ctx, state, arguments)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := t.(SchemaProvider); ok {
+		return hydrated, nil
+	}
+	return toolInput(hydrated), nil
+}
+
+// toolInput extracts the string input for a tool from the JSON call arguments.
+func toolInput(arguments string) string {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(arguments), &args); err == nil {
+		if input, ok := args["input"].(string); ok {
+			return input
+		}
+	}
+	return arguments
+}
+
+// toolState gives the tools called by a ToolNode, such as blackboard and
+// task tools, access to the state they update.
+type toolState struct {
+	mu    sync.Mutex
+	state *SwarmState
+}
+
+// toolStateKey is the context key of the toolState of a tool call.
+type toolStateKey struct{}
+
+// withToolState returns a copy of ctx in which tools read and write state.
+func withToolState(ctx context.Context, state *SwarmState) context.Context {
+	return context.WithValue(ctx, toolStateKey{}, &toolState{state: state})
+}
+
+// toolStateFromContext returns the toolState of a tool call, if any.
+func toolStateFromContext(ctx context.Context) (*toolState, bool) {
+	s, ok := ctx.Value(toolStateKey{}).(*toolState)
+	return s, ok
+}
@@ -0,0 +1,141 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// aiToolCalls builds an AI message requesting the given tool calls.
+func aiToolCalls(calls ...llms.ToolCall) llms.MessageContent {
+	msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+	for _, tc := range calls {
+		msg.Parts = append(msg.Parts, tc)
+	}
+	return msg
+}
+
+func TestToolNodeInvoke(t *testing.T) {
+	node := NewToolNode([]tools.Tool{upperTool{}})
+
+	state := SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(
+			llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "upper", Arguments: `{"input":"abc"}`}},
+			llms.ToolCall{ID: "call_2", FunctionCall: &llms.FunctionCall{Name: "missing", Arguments: `{}`}},
+		),
+	}}
+
+	result, err := node.Invoke(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if len(result.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(result.Messages))
+	}
+
+	tests := []struct {
+		index      int
+		toolCallID string
+		content    string
+	}{
+		{1, "call_1", "ABC"},
+		{2, "call_2", "Error: tool 'missing' not found"},
+	}
+	for _, tt := range tests {
+		resp, ok := result.Messages[tt.index].Parts[0].(llms.ToolCallResponse)
+		if !ok {
+			t.Fatalf("Message %d is not a tool response", tt.index)
+		}
+		if resp.ToolCallID != tt.toolCallID || resp.Content != tt.content {
+			t.Errorf("Message %d = %+v, want id %q content %q", tt.index, resp, tt.toolCallID, tt.content)
+		}
+	}
+	if node.HandedOff(result) {
+		t.Error("HandedOff() should be false without handoff tools")
+	}
+}
+
+func TestToolNodeInvokeCommand(t *testing.T) {
+	node := NewToolNode([]tools.Tool{CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+
+	state := SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "transfer_to_bob", Arguments: `{}`}}),
+	}}
+
+	cmd, err := node.InvokeCommand(context.Background(), state)
+	if err != nil {
+		t.Fatalf("InvokeCommand() error = %v", err)
+	}
+	if cmd.Goto != "Bob" {
+		t.Errorf("Expected Goto Bob, got %v", cmd.Goto)
+	}
+	update, ok := cmd.Update.(SwarmState)
+	if !ok {
+		t.Fatalf("Update is not a SwarmState")
+	}
+	if update.ActiveAgent != "Bob" {
+		t.Errorf("Expected active agent Bob, got %q", update.ActiveAgent)
+	}
+	if !node.HandedOff(update) {
+		t.Error("HandedOff() should be true after a handoff tool call")
+	}
+}
+
+func TestToolNodeRequiresAIMessage(t *testing.T) {
+	node := NewToolNode(nil)
+	if _, err := node.Invoke(context.Background(), SwarmState{}); err == nil {
+		t.Error("Expected error for empty state")
+	}
+	state := SwarmState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")}}
+	if _, err := node.Invoke(context.Background(), state); err == nil {
+		t.Error("Expected error when last message is not from the AI")
+	}
+}
+
+// echoSchemaTool returns its raw input and declares its own argument schema.
+type echoSchemaTool struct{}
+
+func (echoSchemaTool) Name() string        { return "echo" }
+func (echoSchemaTool) Description() string { return "Echo the arguments" }
+func (echoSchemaTool) Call(ctx context.Context, input string) (string, error) {
+	return input, nil
+}
+func (echoSchemaTool) Schema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"input": map[string]any{"type": "integer"}},
+	}
+}
+
+func TestToolDefinition(t *testing.T) {
+	def := ToolDefinition(upperTool{})
+	if def.Type != "function" || def.Function.Name != "upper" || def.Function.Description != "Upper-case the input" {
+		t.Errorf("Unexpected definition %+v", def.Function)
+	}
+	params := def.Function.Parameters.(map[string]any)
+	if params["properties"].(map[string]any)["input"] == nil {
+		t.Errorf("Expected the default input argument, got %v", params)
+	}
+
+	def = ToolDefinition(echoSchemaTool{})
+	if prop := def.Function.Parameters.(map[string]any)["properties"].(map[string]any)["input"].(map[string]any); prop["type"] != "integer" {
+		t.Errorf("Expected the tool's own schema, got %v", def.Function.Parameters)
+	}
+}
+
+func TestToolNodePassesRawArgumentsToSchemaProviders(t *testing.T) {
+	node := NewToolNode([]tools.Tool{echoSchemaTool{}})
+	state := SwarmState{Messages: []llms.MessageContent{
+		aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "echo", Arguments: `{"input":42}`}}),
+	}}
+
+	result, err := node.Invoke(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got := result.Messages[1].Parts[0].(llms.ToolCallResponse).Content; got != `{"input":42}` {
+		t.Errorf("Tool received %q, want the raw arguments", got)
+	}
+}
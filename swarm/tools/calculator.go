@@ -0,0 +1,334 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	langchaintools "github.com/tmc/langchaingo/tools"
+)
+
+// calculatorArgs are the arguments of the calculator tool.
+type calculatorArgs struct {
+	Expression string `json:"expression" jsonschema:"description=Math expression such as (2 + 3) * sqrt(16) / 2^3"`
+}
+
+// Calculator returns the calculator tool, which evaluates a math expression
+// with + - * / % and ^, parentheses, the constants pi and e, and the
+// functions abs, ceil, floor, round, sqrt, exp, ln, log10, log2, sin, cos,
+// tan, asin, acos, atan, pow, min and max.
+func Calculator() langchaintools.Tool {
+	return swarm.NewStructTool("calculator", "Evaluate a math expression and return the result. Use it for any arithmetic.",
+		func(ctx context.Context, args calculatorArgs) (string, error) {
+			value, err := Evaluate(args.Expression)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatFloat(value, 'g', -1, 64), nil
+		})
+}
+
+// Evaluate returns the value of a math expression, as evaluated by the
+// Calculator tool.
+func Evaluate(expression string) (float64, error) {
+	p := &exprParser{input: expression}
+	p.next()
+	value, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.token != "" {
+		return 0, fmt.Errorf("unexpected %q in expression", p.token)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.New("expression has no finite value")
+	}
+	return value, nil
+}
+
+// constants are the named constants of expressions.
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// functions are the functions of expressions, by name.
+var functions = map[string]func(args []float64) (float64, error){
+	"abs":   unary(math.Abs),
+	"ceil":  unary(math.Ceil),
+	"floor": unary(math.Floor),
+	"round": unary(math.Round),
+	"sqrt":  unary(math.Sqrt),
+	"exp":   unary(math.Exp),
+	"ln":    unary(math.Log),
+	"log10": unary(math.Log10),
+	"log2":  unary(math.Log2),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"pow": func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, errors.New("pow takes 2 arguments")
+		}
+		return math.Pow(args[0], args[1]), nil
+	},
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("min takes at least 1 argument")
+		}
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Min(m, a)
+		}
+		return m, nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("max takes at least 1 argument")
+		}
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Max(m, a)
+		}
+		return m, nil
+	},
+}
+
+// unary adapts a function of one argument.
+func unary(fn func(float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("function takes 1 argument, got %d", len(args))
+		}
+		return fn(args[0]), nil
+	}
+}
+
+// exprParser is a recursive-descent parser evaluating an expression as it
+// reads it.
+type exprParser struct {
+	input string
+	pos   int
+	// token is the current token, or "" at the end of the input
+	token string
+}
+
+// next reads the next token.
+func (p *exprParser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.token = ""
+		return
+	}
+	c := p.input[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent, as in 1.5e-3
+		if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.input) && (p.input[end] == '+' || p.input[end] == '-') {
+				end++
+			}
+			if end < len(p.input) && isDigit(p.input[end]) {
+				for end < len(p.input) && isDigit(p.input[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+	case unicode.IsLetter(rune(c)) || c == '_':
+		for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || isDigit(p.input[p.pos]) || p.input[p.pos] == '_') {
+			p.pos++
+		}
+	case c == '*' && p.pos+1 < len(p.input) && p.input[p.pos+1] == '*':
+		// ** is a synonym of ^
+		p.pos += 2
+		p.token = "^"
+		return
+	default:
+		p.pos++
+	}
+	p.token = p.input[start:p.pos]
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// expr parses a sum.
+func (p *exprParser) expr() (float64, error) {
+	value, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for p.token == "+" || p.token == "-" {
+		op := p.token
+		p.next()
+		right, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			value += right
+		} else {
+			value -= right
+		}
+	}
+	return value, nil
+}
+
+// term parses a product.
+func (p *exprParser) term() (float64, error) {
+	value, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for p.token == "*" || p.token == "/" || p.token == "%" {
+		op := p.token
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "*":
+			value *= right
+		case "/":
+			if right == 0 {
+				return 0, errors.New("division by zero")
+			}
+			value /= right
+		case "%":
+			if right == 0 {
+				return 0, errors.New("division by zero")
+			}
+			value = math.Mod(value, right)
+		}
+	}
+	return value, nil
+}
+
+// unary parses a signed power. The sign applies after the power, so that
+// -2^2 is -4.
+func (p *exprParser) unary() (float64, error) {
+	switch p.token {
+	case "-":
+		p.next()
+		value, err := p.unary()
+		return -value, err
+	case "+":
+		p.next()
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power parses a right-associative power.
+func (p *exprParser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if p.token != "^" {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+// primary parses a number, a constant, a function call or a parenthesized
+// expression.
+func (p *exprParser) primary() (float64, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return 0, errors.New("unexpected end of expression")
+	case token == "(":
+		p.next()
+		value, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if p.token != ")" {
+			return 0, errors.New("missing closing parenthesis")
+		}
+		p.next()
+		return value, nil
+	case isDigit(token[0]) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", token)
+		}
+		p.next()
+		return value, nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		name := strings.ToLower(token)
+		p.next()
+		if p.token != "(" {
+			value, ok := constants[name]
+			if !ok {
+				return 0, fmt.Errorf("unknown constant %q", token)
+			}
+			return value, nil
+		}
+		fn, ok := functions[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown function %q", token)
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return 0, err
+		}
+		value, err := fn(args)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		return value, nil
+	}
+	return 0, fmt.Errorf("unexpected %q in expression", token)
+}
+
+// arguments parses the parenthesized arguments of a function call.
+func (p *exprParser) arguments() ([]float64, error) {
+	p.next()
+	var args []float64
+	if p.token == ")" {
+		p.next()
+		return args, nil
+	}
+	for {
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+		switch p.token {
+		case ",":
+			p.next()
+		case ")":
+			p.next()
+			return args, nil
+		default:
+			return nil, errors.New("missing closing parenthesis")
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := map[string]float64{
+		"1 + 2 * 3":                7,
+		"(1 + 2) * 3":              9,
+		"2 ^ 3 ^ 2":                512,
+		"-2^2":                     -4,
+		"2 ** 10":                  1024,
+		"10 % 4":                   2,
+		"sqrt(16) + abs(-3)":       7,
+		"max(1, 5, 3) - min(4, 2)": 3,
+		"round(pi * 100) / 100":    3.14,
+		"1.5e3 / 3":                500,
+		"pow(2, 0.5) ^ 2":          2.0000000000000004,
+	}
+	for expression, want := range tests {
+		got, err := Evaluate(expression)
+		if err != nil || got != want {
+			t.Errorf("Evaluate(%q) = %v, %v, want %v", expression, got, err, want)
+		}
+	}
+
+	for _, expression := range []string{"1 / 0", "2 +", "(1 + 2", "foo(1)", "x + 1", "sqrt(-1)", "1 2"} {
+		if _, err := Evaluate(expression); err == nil {
+			t.Errorf("Evaluate(%q) should return an error", expression)
+		}
+	}
+}
+
+func TestCalculator(t *testing.T) {
+	result, err := Calculator().Call(context.Background(), `{"expression":"0.1 + 0.2 * 10"}`)
+	if err != nil || result != "2.1" {
+		t.Errorf("Call() = %q, %v", result, err)
+	}
+}
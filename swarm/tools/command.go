@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/internal/limitbuf"
+	langchaintools "github.com/tmc/langchaingo/tools"
+)
+
+// ErrCommandNotAllowed is returned for programs outside the allowlist of a
+// Command tool.
+var ErrCommandNotAllowed = errors.New("command not allowed")
+
+const (
+	defaultCommandTimeout   = 30 * time.Second
+	defaultCommandMaxOutput = 16 << 10
+)
+
+// commandArgs are the arguments of the run_command tool.
+type commandArgs struct {
+	Command string   `json:"command" jsonschema:"description=Name of the program to run"`
+	Args    []string `json:"args,omitempty" jsonschema:"description=Arguments of the program as separate strings"`
+}
+
+// commandConfig is the configuration of a Command tool.
+type commandConfig struct {
+	allowed   []string
+	dir       string
+	env       []string
+	timeout   time.Duration
+	maxOutput int
+}
+
+// CommandOption configures a Command tool.
+type CommandOption func(*commandConfig)
+
+// WithAllowedCommands adds programs the tool may run, by name.
+func WithAllowedCommands(names ...string) CommandOption {
+	return func(c *commandConfig) {
+		c.allowed = append(c.allowed, names...)
+	}
+}
+
+// WithDir sets the working directory of commands. By default each command
+// runs in a new temporary directory, removed when it exits.
+func WithDir(dir string) CommandOption {
+	return func(c *commandConfig) {
+		c.dir = dir
+	}
+}
+
+// WithEnv adds "KEY=value" variables to the environment of commands, which
+// otherwise only holds PATH.
+func WithEnv(env ...string) CommandOption {
+	return func(c *commandConfig) {
+		c.env = append(c.env, env...)
+	}
+}
+
+// WithCommandTimeout sets the time after which commands are killed
+// (default: 30s).
+func WithCommandTimeout(timeout time.Duration) CommandOption {
+	return func(c *commandConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxOutput sets the number of bytes of stdout and of stderr returned
+// to the model (default: 16 KiB each).
+func WithMaxOutput(n int) CommandOption {
+	return func(c *commandConfig) {
+		c.maxOutput = n
+	}
+}
+
+// Command returns the run_command tool, which runs one of the allowlisted
+// programs without a shell, so that pipes, redirections and substitutions
+// in arguments are passed literally. Commands get a minimal environment, a
+// timeout and a working directory of their own, and the tool returns their
+// exit code, stdout and stderr.
+//
+// Command is not a sandbox: programs run as the current user, and their
+// arguments may name any file, outside the working directory too. Only
+// allow programs that are safe with any arguments, and run untrusted
+// programs and generated code in a container with the sandbox package.
+//
+// Example:
+//
+//	grep := tools.Command(tools.WithAllowedCommands("grep", "wc"), tools.WithDir("./docs"))
+func Command(opts ...CommandOption) langchaintools.Tool {
+	config := commandConfig{timeout: defaultCommandTimeout, maxOutput: defaultCommandMaxOutput}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	description := "Run a program with arguments and return its output."
+	if len(config.allowed) > 0 {
+		description += " Available programs: " + strings.Join(config.allowed, ", ") + "."
+	}
+	return swarm.NewStructTool("run_command", description, config.run)
+}
+
+// run runs a command.
+func (c commandConfig) run(ctx context.Context, args commandArgs) (string, error) {
+	name := strings.TrimSpace(args.Command)
+	if !slices.Contains(c.allowed, name) {
+		if len(c.allowed) == 0 {
+			return "", fmt.Errorf("%w: %q", ErrCommandNotAllowed, name)
+		}
+		return "", fmt.Errorf("%w: %q, use one of %s", ErrCommandNotAllowed, name, strings.Join(c.allowed, ", "))
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	dir := c.dir
+	if dir == "" {
+		dir, err = os.MkdirTemp("", "swarm-command-")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(dir)
+	}
+	runCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	stdout := limitbuf.New(c.maxOutput)
+	stderr := limitbuf.New(c.maxOutput)
+	cmd := exec.CommandContext(runCtx, path, args.Args...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH")}, c.env...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return "", ctx.Err()
+	case runCtx.Err() != nil:
+		return "", fmt.Errorf("%s timed out after %s", name, c.timeout)
+	case err != nil && !errors.As(err, &exitErr):
+		return "", err
+	}
+	return fmt.Sprintf("exit code: %d\nstdout:\n%s\nstderr:\n%s", cmd.ProcessState.ExitCode(), stdout, stderr), nil
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("alpha\nbeta\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	run := Command(WithAllowedCommands("grep", "sleep"), WithDir(dir), WithCommandTimeout(200*time.Millisecond))
+	ctx := context.Background()
+
+	result, err := run.Call(ctx, `{"command":"grep","args":["-n","beta","notes.txt"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "exit code: 0\nstdout:\n2:beta\n\nstderr:\n" {
+		t.Errorf("Call() = %q", result)
+	}
+	if result, _ := run.Call(ctx, `{"command":"grep","args":["gamma","notes.txt"]}`); !strings.HasPrefix(result, "exit code: 1\n") {
+		t.Errorf("Call() without a match = %q", result)
+	}
+	if _, err := run.Call(ctx, `{"command":"rm","args":["notes.txt"]}`); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Errorf("Call() of rm error = %v, want ErrCommandNotAllowed", err)
+	}
+	if _, err := run.Call(ctx, `{"command":"sleep","args":["5"]}`); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Call() of a slow command error = %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := run.Call(cancelled, `{"command":"sleep","args":["5"]}`); !errors.Is(err, context.Canceled) {
+		t.Errorf("Call() with a cancelled context error = %v, want context.Canceled", err)
+	}
+}
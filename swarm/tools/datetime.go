@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	langchaintools "github.com/tmc/langchaingo/tools"
+)
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+// currentTimeArgs are the arguments of the current_time tool.
+type currentTimeArgs struct {
+	Timezone string `json:"timezone,omitempty" jsonschema:"description=IANA timezone such as Europe/Paris or America/New_York (default: UTC)"`
+}
+
+// CurrentTime returns the current_time tool, which tells the date and time
+// in a timezone, as models do not know when they run.
+func CurrentTime() langchaintools.Tool {
+	return swarm.NewStructTool("current_time", "Get the current date and time, optionally in a given timezone.",
+		func(ctx context.Context, args currentTimeArgs) (string, error) {
+			name := strings.TrimSpace(args.Timezone)
+			if name == "" {
+				name = "UTC"
+			}
+			location, err := time.LoadLocation(name)
+			if err != nil {
+				return "", fmt.Errorf("unknown timezone %q: use an IANA name such as Europe/Paris", name)
+			}
+			t := now().In(location)
+			return fmt.Sprintf("%s (%s, %s)", t.Format("Monday, 2 January 2006 15:04:05 MST"), t.Format(time.RFC3339), location), nil
+		})
+}
@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCurrentTime(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, time.October, 16, 8, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	result, err := CurrentTime().Call(context.Background(), `{"timezone":"Europe/Paris"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Friday, 16 October 2026 10:30:00 CEST (2026-10-16T10:30:00+02:00, Europe/Paris)"; result != want {
+		t.Errorf("Call() = %q, want %q", result, want)
+	}
+	if result, _ := CurrentTime().Call(context.Background(), `{}`); result != "Friday, 16 October 2026 08:30:00 UTC (2026-10-16T08:30:00Z, UTC)" {
+		t.Errorf("Call() without a timezone = %q", result)
+	}
+	if _, err := CurrentTime().Call(context.Background(), `{"timezone":"Mars/Olympus"}`); err == nil {
+		t.Error("Call() with an unknown timezone should return an error")
+	}
+}
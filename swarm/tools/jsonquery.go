@@ -0,0 +1,295 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	langchaintools "github.com/tmc/langchaingo/tools"
+)
+
+// jsonQueryArgs are the arguments of the json_query tool.
+type jsonQueryArgs struct {
+	JSON  string `json:"json" jsonschema:"description=The JSON document"`
+	Query string `json:"query" jsonschema:"description=jq-style path such as .items[0].name or .items[].id | length"`
+}
+
+// JSONQuery returns the json_query tool, which extracts values from a JSON
+// document with a jq-style query (see Query).
+func JSONQuery() langchaintools.Tool {
+	return swarm.NewStructTool("json_query", "Extract values from a JSON document with a jq-style path query.",
+		func(ctx context.Context, args jsonQueryArgs) (string, error) {
+			var document any
+			if err := json.Unmarshal([]byte(args.JSON), &document); err != nil {
+				return "", fmt.Errorf("invalid JSON: %w", err)
+			}
+			results, err := Query(document, args.Query)
+			if err != nil {
+				return "", err
+			}
+			lines := make([]string, len(results))
+			for i, result := range results {
+				encoded, err := json.Marshal(result)
+				if err != nil {
+					return "", err
+				}
+				lines[i] = string(encoded)
+			}
+			return strings.Join(lines, "\n"), nil
+		})
+}
+
+// Query evaluates a jq-style query against a decoded JSON document and
+// returns its results. A query is a pipeline of filters separated by |:
+//
+//   - a path of .field, ."field", .["field"], [index] (negative from the
+//     end), [start:end] slices and [] to iterate over an array or object,
+//     such as .items[].name; . alone is the input
+//   - length, keys, values, first, last, type
+//
+// Missing fields yield null, as in jq.
+func Query(document any, query string) ([]any, error) {
+	results := []any{document}
+	for stage := range strings.SplitSeq(query, "|") {
+		stage = strings.TrimSpace(stage)
+		var next []any
+		for _, value := range results {
+			out, err := applyFilter(value, stage)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, out...)
+		}
+		results = next
+	}
+	return results, nil
+}
+
+// applyFilter applies a stage of a query to value.
+func applyFilter(value any, filter string) ([]any, error) {
+	switch filter {
+	case "", ".":
+		return []any{value}, nil
+	case "length":
+		switch v := value.(type) {
+		case []any:
+			return []any{len(v)}, nil
+		case map[string]any:
+			return []any{len(v)}, nil
+		case string:
+			return []any{len([]rune(v))}, nil
+		case nil:
+			return []any{0}, nil
+		}
+		return nil, fmt.Errorf("%s has no length", typeName(value))
+	case "keys":
+		switch v := value.(type) {
+		case map[string]any:
+			keys := make([]any, 0, len(v))
+			for _, key := range slices.Sorted(maps.Keys(v)) {
+				keys = append(keys, key)
+			}
+			return []any{keys}, nil
+		case []any:
+			keys := make([]any, len(v))
+			for i := range v {
+				keys[i] = i
+			}
+			return []any{keys}, nil
+		}
+		return nil, fmt.Errorf("%s has no keys", typeName(value))
+	case "values":
+		return iterate(value)
+	case "first", "last":
+		array, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot take %s of %s", filter, typeName(value))
+		}
+		if len(array) == 0 {
+			return []any{nil}, nil
+		}
+		if filter == "first" {
+			return []any{array[0]}, nil
+		}
+		return []any{array[len(array)-1]}, nil
+	case "type":
+		return []any{typeName(value)}, nil
+	}
+	if !strings.HasPrefix(filter, ".") && !strings.HasPrefix(filter, "[") {
+		return nil, fmt.Errorf("unsupported filter %q", filter)
+	}
+	return applyPath(value, filter)
+}
+
+// applyPath applies a path such as .items[0].name to value.
+func applyPath(value any, path string) ([]any, error) {
+	results := []any{value}
+	for path != "" {
+		var step func(any) ([]any, error)
+		switch {
+		case strings.HasPrefix(path, ".["):
+			path = path[1:]
+			continue
+		case strings.HasPrefix(path, "[]"):
+			step, path = iterate, path[2:]
+		case strings.HasPrefix(path, "["):
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, errors.New("missing ] in query")
+			}
+			index, err := parseIndex(path[1:end])
+			if err != nil {
+				return nil, err
+			}
+			step, path = index, path[end+1:]
+		case strings.HasPrefix(path, `."`):
+			end := strings.IndexByte(path[2:], '"')
+			if end < 0 {
+				return nil, errors.New(`missing " in query`)
+			}
+			step, path = field(path[2:2+end]), path[end+3:]
+		case strings.HasPrefix(path, "."):
+			end := 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			name := strings.TrimSpace(path[1:end])
+			if name == "" {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			step, path = field(name), path[end:]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		var next []any
+		for _, v := range results {
+			out, err := step(v)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, out...)
+		}
+		results = next
+	}
+	return results, nil
+}
+
+// field returns a step selecting a field of an object.
+func field(name string) func(any) ([]any, error) {
+	return func(value any) ([]any, error) {
+		switch v := value.(type) {
+		case map[string]any:
+			return []any{v[name]}, nil
+		case nil:
+			return []any{nil}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(value), name)
+	}
+}
+
+// parseIndex returns a step selecting an element, a slice or, for a quoted
+// name, a field.
+func parseIndex(index string) (func(any) ([]any, error), error) {
+	index = strings.TrimSpace(index)
+	if name, err := strconv.Unquote(index); err == nil {
+		return field(name), nil
+	}
+	if from, to, ok := strings.Cut(index, ":"); ok {
+		return slice(from, to)
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index [%s]", index)
+	}
+	return func(value any) ([]any, error) {
+		switch v := value.(type) {
+		case []any:
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return []any{nil}, nil
+			}
+			return []any{v[i]}, nil
+		case nil:
+			return []any{nil}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with a number", typeName(value))
+	}, nil
+}
+
+// slice returns a step selecting the elements of an array between from and
+// to, either of which may be empty.
+func slice(from, to string) (func(any) ([]any, error), error) {
+	bound := func(s string, length, fallback int) (int, error) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return fallback, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid slice bound %q", s)
+		}
+		if n < 0 {
+			n += length
+		}
+		return min(max(n, 0), length), nil
+	}
+	if _, err := bound(from, 0, 0); err != nil {
+		return nil, err
+	}
+	if _, err := bound(to, 0, 0); err != nil {
+		return nil, err
+	}
+	return func(value any) ([]any, error) {
+		array, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot slice %s", typeName(value))
+		}
+		start, _ := bound(from, len(array), 0)
+		end, _ := bound(to, len(array), len(array))
+		if start > end {
+			start = end
+		}
+		return []any{slices.Clone(array[start:end])}, nil
+	}, nil
+}
+
+// iterate returns the elements of an array or the values of an object, by
+// key order.
+func iterate(value any) ([]any, error) {
+	switch v := value.(type) {
+	case []any:
+		return slices.Clone(v), nil
+	case map[string]any:
+		values := make([]any, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			values = append(values, v[key])
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", typeName(value))
+}
+
+// typeName returns the jq name of the type of a JSON value.
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONQuery(t *testing.T) {
+	document := `{"items":[{"id":1,"name":"a","tags":["x"]},{"id":2,"name":"b"}],"total":2,"meta":{"next page":"p2"}}`
+	tests := map[string]string{
+		".total":                     "2",
+		".items[0].name":             `"a"`,
+		".items[-1].id":              "2",
+		".items[].id":                "1\n2",
+		".items | length":            "2",
+		".items[1:] | first | .name": `"b"`,
+		`.meta."next page"`:          `"p2"`,
+		`.meta["next page"]`:         `"p2"`,
+		".items[1].tags":             "null",
+		". | keys":                   `["items","meta","total"]`,
+		".items[0] | values":         "1\n\"a\"\n[\"x\"]",
+		".total | type":              `"number"`,
+	}
+	query := JSONQuery()
+	for q, want := range tests {
+		got, err := query.Call(context.Background(), `{"json":`+quote(document)+`,"query":`+quote(q)+`}`)
+		if err != nil || got != want {
+			t.Errorf("query %s = %q, %v, want %q", q, got, err, want)
+		}
+	}
+
+	for _, q := range []string{".total[0]", ".items.name", "sort", ".items[x]"} {
+		if _, err := query.Call(context.Background(), `{"json":`+quote(document)+`,"query":`+quote(q)+`}`); err == nil {
+			t.Errorf("query %s should return an error", q)
+		}
+	}
+}
+
+// quote returns s as a JSON string.
+func quote(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
@@ -0,0 +1,220 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/internal/limitbuf"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultMemory    = "256m"
+	defaultCPUs      = 1
+	defaultPids      = 64
+	defaultMaxOutput = 16 << 10
+)
+
+// Runtime is how a language is run: the image of the container and the
+// command reading the program from stdin.
+type Runtime struct {
+	Image   string
+	Command []string
+}
+
+// DefaultRuntimes are the languages of a Docker sandbox created without
+// WithLanguage.
+var DefaultRuntimes = map[string]Runtime{
+	"python":     {Image: "python:3.12-slim", Command: []string{"python3", "-"}},
+	"javascript": {Image: "node:22-slim", Command: []string{"node", "-"}},
+	"bash":       {Image: "bash:5", Command: []string{"bash", "-s"}},
+}
+
+// Docker is a Sandbox running each program in a new Docker container, with
+// the docker command line.
+type Docker struct {
+	docker    string
+	runtimes  map[string]Runtime
+	runtime   string
+	timeout   time.Duration
+	memory    string
+	cpus      float64
+	pids      int
+	network   bool
+	maxOutput int
+}
+
+// DockerOption configures a Docker sandbox.
+type DockerOption func(*Docker)
+
+// WithDockerPath sets the docker executable (default: docker, from PATH).
+func WithDockerPath(path string) DockerOption {
+	return func(d *Docker) {
+		d.docker = path
+	}
+}
+
+// WithLanguage adds a language, or replaces the runtime of one. The
+// command must read the program from stdin.
+func WithLanguage(name string, runtime Runtime) DockerOption {
+	return func(d *Docker) {
+		d.runtimes[name] = runtime
+	}
+}
+
+// WithRuntime sets the OCI runtime of containers, such as "runsc" for
+// gVisor, which isolates them from the host kernel.
+func WithRuntime(runtime string) DockerOption {
+	return func(d *Docker) {
+		d.runtime = runtime
+	}
+}
+
+// WithTimeout sets the time after which programs are killed (default:
+// 30s).
+func WithTimeout(timeout time.Duration) DockerOption {
+	return func(d *Docker) {
+		d.timeout = timeout
+	}
+}
+
+// WithMemory sets the memory limit of containers, in Docker's notation
+// (default: "256m").
+func WithMemory(memory string) DockerOption {
+	return func(d *Docker) {
+		d.memory = memory
+	}
+}
+
+// WithCPUs sets the number of CPUs containers may use (default: 1).
+func WithCPUs(cpus float64) DockerOption {
+	return func(d *Docker) {
+		d.cpus = cpus
+	}
+}
+
+// WithPidsLimit sets the number of processes containers may run (default:
+// 64).
+func WithPidsLimit(n int) DockerOption {
+	return func(d *Docker) {
+		d.pids = n
+	}
+}
+
+// WithNetwork gives containers network access, which they lack by default.
+func WithNetwork() DockerOption {
+	return func(d *Docker) {
+		d.network = true
+	}
+}
+
+// WithMaxOutput sets the number of bytes of stdout and of stderr kept
+// (default: 16 KiB each).
+func WithMaxOutput(n int) DockerOption {
+	return func(d *Docker) {
+		d.maxOutput = n
+	}
+}
+
+// NewDocker creates a Docker sandbox. Containers run as nobody, without
+// capabilities, network or writable file system other than a small /tmp,
+// and with memory, CPU and process limits.
+func NewDocker(opts ...DockerOption) *Docker {
+	d := &Docker{
+		docker:    "docker",
+		runtimes:  maps.Clone(DefaultRuntimes),
+		timeout:   defaultTimeout,
+		memory:    defaultMemory,
+		cpus:      defaultCPUs,
+		pids:      defaultPids,
+		maxOutput: defaultMaxOutput,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Languages returns the names of the languages of the sandbox.
+func (d *Docker) Languages() []string {
+	return slices.Sorted(maps.Keys(d.runtimes))
+}
+
+// Network reports whether containers have network access.
+func (d *Docker) Network() bool {
+	return d.network
+}
+
+// Run runs code in a new container, removed once it exits.
+func (d *Docker) Run(ctx context.Context, code Code) (Result, error) {
+	runtime, ok := d.runtimes[code.Language]
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %q, use one of %s", ErrUnsupportedLanguage, code.Language, strings.Join(d.Languages(), ", "))
+	}
+
+	name := "swarm-sandbox-" + uuid.NewString()
+	runCtx := ctx
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	stdout := limitbuf.New(d.maxOutput)
+	stderr := limitbuf.New(d.maxOutput)
+	cmd := exec.CommandContext(runCtx, d.docker, d.args(name, runtime)...)
+	cmd.Stdin = strings.NewReader(code.Source)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{Duration: time.Since(start)}
+	if runCtx.Err() != nil {
+		// Killing the docker client leaves the container running
+		_ = exec.Command(d.docker, "rm", "-f", name).Run()
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+		result.TimedOut = true
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !result.TimedOut {
+		return Result{}, fmt.Errorf("sandbox: running docker: %w", err)
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	return result, nil
+}
+
+// args returns the arguments of the docker command running a container.
+func (d *Docker) args(name string, runtime Runtime) []string {
+	args := []string{
+		"run", "--rm", "-i", "--name", name,
+		"--read-only", "--tmpfs", "/tmp:rw,size=64m",
+		"--user", "65534:65534",
+		"--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+		"--memory", d.memory, "--memory-swap", d.memory,
+		"--cpus", strconv.FormatFloat(d.cpus, 'f', -1, 64),
+		"--pids-limit", strconv.Itoa(d.pids),
+		"--workdir", "/tmp",
+	}
+	if !d.network {
+		args = append(args, "--network", "none")
+	}
+	if d.runtime != "" {
+		args = append(args, "--runtime", d.runtime)
+	}
+	args = append(args, runtime.Image)
+	return append(args, runtime.Command...)
+}
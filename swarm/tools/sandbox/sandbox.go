@@ -0,0 +1,116 @@
+// Package sandbox runs code written by agents in isolated containers, so
+// that coding and research swarms can execute generated snippets without
+// exposing the host. Each run gets a fresh container with no network, a
+// read-only file system, resource limits and a timeout; its stdout and
+// stderr are captured for the model.
+//
+// Example:
+//
+//	sb := sandbox.NewDocker(sandbox.WithMemory("256m"), sandbox.WithRuntime("runsc"))
+//	coder, _ := swarm.CreateReactAgent(model, []tools.Tool{sandbox.Tool(sb)})
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ErrUnsupportedLanguage is returned for code in a language the sandbox
+// has no runtime for.
+var ErrUnsupportedLanguage = errors.New("sandbox: unsupported language")
+
+// Code is a program to run.
+type Code struct {
+	// Language is the name of the language, such as "python"
+	Language string
+	// Source is the source code of the program
+	Source string
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	// TimedOut is set when the program was killed at the timeout
+	TimedOut bool
+	Duration time.Duration
+}
+
+// Sandbox runs code in isolation.
+type Sandbox interface {
+	// Languages returns the names of the languages the sandbox runs
+	Languages() []string
+	// Run runs code and returns its outcome. Failing programs are reported
+	// in the Result; errors are for runs that could not happen.
+	Run(ctx context.Context, code Code) (Result, error)
+}
+
+// networkSandbox is implemented by sandboxes that may give programs
+// network access, such as Docker.
+type networkSandbox interface {
+	Network() bool
+}
+
+// runCodeArgs are the arguments of the run_code tool.
+type runCodeArgs struct {
+	Language string `json:"language" jsonschema:"description=Language of the code"`
+	Code     string `json:"code" jsonschema:"description=Complete program to run; print the results to stdout"`
+}
+
+// Tool returns the run_code tool, which runs programs in sb and returns
+// their exit code, stdout and stderr. The languages of sb are listed in the
+// tool's schema, and its description tells the model whether programs have
+// network access when sb has a Network() bool method.
+func Tool(sb Sandbox) tools.Tool {
+	languages := sb.Languages()
+	description := "Run a program in an isolated sandbox"
+	if n, ok := sb.(networkSandbox); ok {
+		if n.Network() {
+			description += " with network access"
+		} else {
+			description += " without network access"
+		}
+	}
+	description += " and return its output. Languages: " + strings.Join(languages, ", ") + "."
+	t := swarm.NewStructTool("run_code", description, func(ctx context.Context, args runCodeArgs) (string, error) {
+		result, err := sb.Run(ctx, Code{Language: strings.ToLower(strings.TrimSpace(args.Language)), Source: args.Code})
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if result.TimedOut {
+			fmt.Fprintf(&b, "timed out after %s\n", result.Duration.Round(time.Millisecond))
+		}
+		fmt.Fprintf(&b, "exit code: %d\nstdout:\n%s\nstderr:\n%s", result.ExitCode, result.Stdout, result.Stderr)
+		return b.String(), nil
+	})
+	return &languageTool{StructTool: t, languages: languages}
+}
+
+// languageTool is the run_code tool, advertising the languages of its
+// sandbox.
+type languageTool struct {
+	*swarm.StructTool[runCodeArgs]
+	languages []string
+}
+
+// Schema returns the schema of the run_code tool, with the languages of the
+// sandbox as the allowed values of "language".
+func (t *languageTool) Schema() map[string]any {
+	schema := maps.Clone(t.StructTool.Schema())
+	properties := maps.Clone(schema["properties"].(map[string]any))
+	language := maps.Clone(properties["language"].(map[string]any))
+	language["enum"] = slices.Clone(t.languages)
+	properties["language"] = language
+	schema["properties"] = properties
+	return schema
+}
@@ -0,0 +1,99 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// fakeDocker writes a docker executable that records its arguments and
+// echoes the program, sleeping when it contains "sleep".
+func fakeDocker(t *testing.T) (path, argsFile string) {
+	dir := t.TempDir()
+	path = filepath.Join(dir, "docker")
+	argsFile = filepath.Join(dir, "args")
+	script := `#!/bin/sh
+if [ "$1" = "rm" ]; then echo "$@" >> "` + argsFile + `.rm"; exit 0; fi
+echo "$@" > "` + argsFile + `"
+code=$(cat)
+case "$code" in *sleep*) sleep 5 ;; esac
+echo "$code"
+echo "warning" >&2
+exit 3
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path, argsFile
+}
+
+func TestDocker(t *testing.T) {
+	docker, argsFile := fakeDocker(t)
+	sb := NewDocker(WithDockerPath(docker), WithRuntime("runsc"), WithMemory("128m"), WithTimeout(300*time.Millisecond))
+
+	result, err := sb.Run(context.Background(), Code{Language: "python", Source: "print(42)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stdout != "print(42)\n" || result.Stderr != "warning\n" || result.ExitCode != 3 || result.TimedOut {
+		t.Errorf("result = %+v", result)
+	}
+	args, _ := os.ReadFile(argsFile)
+	for _, want := range []string{"--network none", "--read-only", "--memory 128m", "--runtime runsc", "--cap-drop ALL", "python:3.12-slim python3 -"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("docker arguments %q lack %q", args, want)
+		}
+	}
+
+	if _, err := sb.Run(context.Background(), Code{Language: "cobol"}); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("Run() of an unknown language error = %v", err)
+	}
+
+	result, err = sb.Run(context.Background(), Code{Language: "bash", Source: "sleep 10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimedOut {
+		t.Errorf("result = %+v, want a timeout", result)
+	}
+	if removed, _ := os.ReadFile(argsFile + ".rm"); !strings.HasPrefix(string(removed), "rm -f swarm-sandbox-") {
+		t.Errorf("timed out container was not removed: %q", removed)
+	}
+}
+
+func TestTool(t *testing.T) {
+	docker, _ := fakeDocker(t)
+	run := Tool(NewDocker(WithDockerPath(docker)))
+	output, err := run.Call(context.Background(), `{"language":"Python","code":"print(1)"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "exit code: 3\nstdout:\nprint(1)\n\nstderr:\nwarning\n" {
+		t.Errorf("Call() = %q", output)
+	}
+	schema := run.(swarm.SchemaProvider).Schema()
+	language := schema["properties"].(map[string]any)["language"].(map[string]any)
+	if enum := language["enum"].([]string); strings.Join(enum, ",") != "bash,javascript,python" {
+		t.Errorf("languages = %v", enum)
+	}
+}
+
+func TestToolNetwork(t *testing.T) {
+	for _, tt := range []struct {
+		sb   Sandbox
+		want string
+	}{
+		{NewDocker(), "without network access"},
+		{NewDocker(WithNetwork()), "with network access"},
+	} {
+		if description := Tool(tt.sb).Description(); !strings.Contains(description, "sandbox "+tt.want+" and") {
+			t.Errorf("Description() = %q, want %q", description, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultDuckDuckGoEndpoint is the HTML version of DuckDuckGo.
+const DefaultDuckDuckGoEndpoint = "https://html.duckduckgo.com"
+
+// DuckDuckGo searches DuckDuckGo, which needs no API key, by reading its
+// HTML results page. It suits development and light use; its results page
+// may change or throttle automated queries.
+type DuckDuckGo struct {
+	client
+}
+
+// NewDuckDuckGo returns a DuckDuckGo provider.
+func NewDuckDuckGo(opts ...Option) *DuckDuckGo {
+	return &DuckDuckGo{client: newClient(DefaultDuckDuckGoEndpoint, opts)}
+}
+
+// Search returns the results of the first page of a DuckDuckGo search.
+func (d *DuckDuckGo) Search(ctx context.Context, query string, n int) ([]Result, error) {
+	form := url.Values{"q": {query}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint+"/html/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; langchaingo-swarm)")
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	var results []Result
+	var walk func(n *html.Node)
+	walk = func(node *html.Node) {
+		if len(results) >= n {
+			return
+		}
+		if node.Type == html.ElementNode && hasClass(node, "result") && !hasClass(node, "result--ad") {
+			if result, ok := parseDuckDuckGoResult(node); ok {
+				results = append(results, result)
+			}
+			return
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return results, nil
+}
+
+// parseDuckDuckGoResult returns the result of a result element.
+func parseDuckDuckGoResult(node *html.Node) (Result, bool) {
+	var result Result
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case hasClass(n, "result__a"):
+				result.Title = text(n)
+				result.URL = resultURL(attribute(n, "href"))
+				return
+			case hasClass(n, "result__snippet"):
+				result.Snippet = text(n)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+	return result, result.URL != ""
+}
+
+// resultURL returns the target of a result link, which DuckDuckGo may wrap
+// in a redirect through /l/?uddg=.
+func resultURL(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if target := u.Query().Get("uddg"); target != "" {
+		return target
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+	return u.String()
+}
+
+// hasClass reports whether n has the given class.
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attribute(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// attribute returns the value of the named attribute of n.
+func attribute(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// text returns the text of n and its descendants, with whitespace
+// collapsed.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
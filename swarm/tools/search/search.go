@@ -0,0 +1,244 @@
+// Package search provides web search tools for researcher agents, backed by
+// SerpAPI, Tavily or DuckDuckGo.
+//
+// Tool turns a Provider into a web_search tool whose results are listed
+// with their title, URL and snippet, or summarized by a model:
+//
+//	web := search.Tool(search.NewTavily(os.Getenv("TAVILY_API_KEY")), search.WithMaxResults(5))
+//	researcher, _ := swarm.CreateReactAgent(model, []tools.Tool{web, webfetch.New()})
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	defaultMaxResults = 5
+	// maxErrorBody is the number of bytes of an error response quoted in a
+	// search error.
+	maxErrorBody = 512
+)
+
+// Result is a search result.
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// Provider searches the web.
+type Provider interface {
+	// Search returns up to n results for query.
+	Search(ctx context.Context, query string, n int) ([]Result, error)
+}
+
+// ProviderFunc is an adapter to allow the use of ordinary functions as
+// providers.
+type ProviderFunc func(ctx context.Context, query string, n int) ([]Result, error)
+
+// Search calls f(ctx, query, n).
+func (f ProviderFunc) Search(ctx context.Context, query string, n int) ([]Result, error) {
+	return f(ctx, query, n)
+}
+
+// Option configures a SerpAPI, Tavily or DuckDuckGo provider.
+type Option func(*client)
+
+// WithEndpoint sends requests to endpoint instead of the service's API.
+func WithEndpoint(endpoint string) Option {
+	return func(c *client) {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithHTTPClient sends requests with client (default: http.DefaultClient).
+// Use it to set timeouts or transports.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.http = httpClient
+	}
+}
+
+// client holds the settings common to the providers.
+type client struct {
+	endpoint string
+	http     *http.Client
+}
+
+// newClient returns the settings of a provider of the service at endpoint,
+// with opts applied.
+func newClient(endpoint string, opts []Option) client {
+	c := client{endpoint: endpoint, http: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// do sends req and decodes its JSON response into response.
+func (c *client) do(req *http.Request, response any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// postJSON sends body as JSON to url and decodes the response.
+func (c *client) postJSON(ctx context.Context, url string, body any, header http.Header, response any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, response)
+}
+
+// toolConfig is the configuration of a search tool.
+type toolConfig struct {
+	name          string
+	description   string
+	maxResults    int
+	snippetLength int
+	summarizer    llms.Model
+}
+
+// ToolOption configures a search tool.
+type ToolOption func(*toolConfig)
+
+// WithName sets the name of the tool (default: web_search).
+func WithName(name string) ToolOption {
+	return func(c *toolConfig) {
+		c.name = name
+	}
+}
+
+// WithDescription sets the description of the tool shown to the model.
+func WithDescription(description string) ToolOption {
+	return func(c *toolConfig) {
+		c.description = description
+	}
+}
+
+// WithMaxResults sets the number of results the model may ask for, and
+// gets by default (default: 5).
+func WithMaxResults(n int) ToolOption {
+	return func(c *toolConfig) {
+		c.maxResults = n
+	}
+}
+
+// WithSnippetLength truncates the snippets of results to n characters.
+func WithSnippetLength(n int) ToolOption {
+	return func(c *toolConfig) {
+		c.snippetLength = n
+	}
+}
+
+// WithSummary has model summarize the results for the query, citing their
+// URLs, instead of returning them as a list. It saves the researcher's
+// context window at the cost of a model call per search.
+func WithSummary(model llms.Model) ToolOption {
+	return func(c *toolConfig) {
+		c.summarizer = model
+	}
+}
+
+// searchArgs are the arguments of a search tool.
+type searchArgs struct {
+	Query      string `json:"query" jsonschema:"description=The search query"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"description=Number of results to return,minimum=1"`
+}
+
+// Tool returns a web_search tool searching with provider.
+func Tool(provider Provider, opts ...ToolOption) tools.Tool {
+	config := toolConfig{
+		name:        "web_search",
+		description: "Search the web and return the most relevant pages with their title, URL and snippet.",
+		maxResults:  defaultMaxResults,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return swarm.NewStructTool(config.name, config.description, func(ctx context.Context, args searchArgs) (string, error) {
+		query := strings.TrimSpace(args.Query)
+		if query == "" {
+			return "", fmt.Errorf("missing query")
+		}
+		n := config.maxResults
+		if args.MaxResults > 0 && args.MaxResults < n {
+			n = args.MaxResults
+		}
+		results, err := provider.Search(ctx, query, n)
+		if err != nil {
+			return "", fmt.Errorf("search failed: %w", err)
+		}
+		if len(results) > n {
+			results = results[:n]
+		}
+		if len(results) == 0 {
+			return "No results for " + query + ".", nil
+		}
+		listed := config.format(results)
+		if config.summarizer == nil {
+			return listed, nil
+		}
+		return summarize(ctx, config.summarizer, query, listed)
+	})
+}
+
+// format lists results with their title, URL and snippet.
+func (c toolConfig) format(results []Result) string {
+	var b strings.Builder
+	for i, result := range results {
+		snippet := strings.Join(strings.Fields(result.Snippet), " ")
+		if c.snippetLength > 0 && utf8.RuneCountInString(snippet) > c.snippetLength {
+			snippet = string([]rune(snippet)[:c.snippetLength]) + "..."
+		}
+		fmt.Fprintf(&b, "%d. %s\n   %s\n", i+1, result.Title, result.URL)
+		if snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", snippet)
+		}
+	}
+	return b.String()
+}
+
+// summarize asks model to summarize the listed results for query.
+func summarize(ctx context.Context, model llms.Model, query, listed string) (string, error) {
+	prompt := fmt.Sprintf(`Summarize what these web search results say about "%s" in a few sentences. `+
+		"Cite the URL of each fact in brackets, and only use the results.\n\n%s", query, listed)
+	response, err := model.GenerateContent(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	})
+	if err != nil {
+		return "", fmt.Errorf("summarizing results: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return listed, nil
+	}
+	return strings.TrimSpace(response.Choices[0].Content), nil
+}
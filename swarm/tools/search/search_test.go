@@ -0,0 +1,95 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+)
+
+func TestProviders(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "serp-key" || r.URL.Query().Get("q") != "golang" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"organic_results":[{"title":"Go","link":"https://go.dev","snippet":"The Go language"}]}`))
+	})
+	mux.HandleFunc("POST /search", func(w http.ResponseWriter, r *http.Request) {
+		var request tavilyRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.Header.Get("Authorization") != "Bearer tvly-key" || request.MaxResults != 3 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"results":[{"title":"Go","url":"https://go.dev","content":"The Go language"}]}`))
+	})
+	mux.HandleFunc("POST /html/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+<div class="result results_links result--ad"><a class="result__a" href="https://ads.example">Ad</a></div>
+<div class="result results_links">
+  <h2><a class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&amp;rut=x">The <b>Go</b> Programming Language</a></h2>
+  <a class="result__snippet" href="#">Build <b>simple</b>, secure systems.</a>
+</div>
+<div class="result"><a class="result__a" href="https://pkg.go.dev">Packages</a></div>
+</body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	ctx := context.Background()
+	want := Result{Title: "Go", URL: "https://go.dev", Snippet: "The Go language"}
+
+	if results, err := NewSerpAPI("serp-key", WithEndpoint(server.URL)).Search(ctx, "golang", 3); err != nil || len(results) != 1 || results[0] != want {
+		t.Errorf("SerpAPI results = %+v, %v", results, err)
+	}
+	if results, err := NewTavily("tvly-key", WithEndpoint(server.URL)).Search(ctx, "golang", 3); err != nil || len(results) != 1 || results[0] != want {
+		t.Errorf("Tavily results = %+v, %v", results, err)
+	}
+	if _, err := NewTavily("wrong", WithEndpoint(server.URL)).Search(ctx, "golang", 3); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("Tavily error = %v", err)
+	}
+
+	results, err := NewDuckDuckGo(WithEndpoint(server.URL)).Search(ctx, "golang", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Result{Title: "The Go Programming Language", URL: "https://go.dev/", Snippet: "Build simple, secure systems."}
+	if len(results) != 1 || results[0] != want {
+		t.Errorf("DuckDuckGo results = %+v", results)
+	}
+}
+
+func TestTool(t *testing.T) {
+	provider := ProviderFunc(func(ctx context.Context, query string, n int) ([]Result, error) {
+		return []Result{
+			{Title: "Go", URL: "https://go.dev", Snippet: "The Go programming language"},
+			{Title: "Tour", URL: "https://go.dev/tour", Snippet: "A tour of Go"},
+			{Title: "Blog", URL: "https://go.dev/blog"},
+		}[:n], nil
+	})
+
+	search := Tool(provider, WithMaxResults(2), WithSnippetLength(6))
+	output, err := search.Call(context.Background(), `{"query":"golang","max_results":10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1. Go\n   https://go.dev\n   The Go...\n2. Tour\n   https://go.dev/tour\n   A tour...\n"
+	if output != want {
+		t.Errorf("Call() = %q, want %q", output, want)
+	}
+
+	model := swarmtest.NewMockModel(swarmtest.Text("Go is a language [https://go.dev]."))
+	summary, err := Tool(provider, WithSummary(model)).Call(context.Background(), `{"query":"golang","max_results":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt := swarmtest.MessageText(model.Calls()[0][0])
+	if summary != "Go is a language [https://go.dev]." || !strings.Contains(prompt, "1. Go\n   https://go.dev") {
+		t.Errorf("summary = %q, prompt = %q", summary, prompt)
+	}
+}
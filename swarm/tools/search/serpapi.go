@@ -0,0 +1,55 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DefaultSerpAPIEndpoint is the SerpAPI API.
+const DefaultSerpAPIEndpoint = "https://serpapi.com"
+
+// SerpAPI searches Google through SerpAPI.
+type SerpAPI struct {
+	client
+	apiKey string
+	engine string
+}
+
+// NewSerpAPI returns a SerpAPI provider authenticated with apiKey.
+func NewSerpAPI(apiKey string, opts ...Option) *SerpAPI {
+	return &SerpAPI{client: newClient(DefaultSerpAPIEndpoint, opts), apiKey: apiKey, engine: "google"}
+}
+
+// serpAPIResponse is the response of the SerpAPI search API.
+type serpAPIResponse struct {
+	OrganicResults []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+	} `json:"organic_results"`
+}
+
+// Search returns the organic results of a Google search.
+func (s *SerpAPI) Search(ctx context.Context, query string, n int) ([]Result, error) {
+	params := url.Values{
+		"engine":  {s.engine},
+		"q":       {query},
+		"num":     {strconv.Itoa(n)},
+		"api_key": {s.apiKey},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/search.json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var response serpAPIResponse
+	if err := s.do(req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(response.OrganicResults))
+	for _, r := range response.OrganicResults {
+		results = append(results, Result{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return results, nil
+}
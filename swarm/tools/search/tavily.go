@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+	"net/http"
+)
+
+// DefaultTavilyEndpoint is the Tavily API.
+const DefaultTavilyEndpoint = "https://api.tavily.com"
+
+// Tavily searches with Tavily, a search API built for agents.
+type Tavily struct {
+	client
+	apiKey string
+}
+
+// NewTavily returns a Tavily provider authenticated with apiKey.
+func NewTavily(apiKey string, opts ...Option) *Tavily {
+	return &Tavily{client: newClient(DefaultTavilyEndpoint, opts), apiKey: apiKey}
+}
+
+// tavilyRequest is a request of the Tavily search API.
+type tavilyRequest struct {
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results"`
+}
+
+// tavilyResponse is the response of the Tavily search API.
+type tavilyResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+// Search returns the results of a Tavily search.
+func (t *Tavily) Search(ctx context.Context, query string, n int) ([]Result, error) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+t.apiKey)
+	var response tavilyResponse
+	request := tavilyRequest{Query: query, MaxResults: n}
+	if err := t.postJSON(ctx, t.endpoint+"/search", request, header, &response); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(response.Results))
+	for _, r := range response.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
@@ -0,0 +1,24 @@
+// Package tools is a library of common tools, with complete schemas, that
+// can be handed to swarm.CreateReactAgent directly: Calculator evaluates
+// math expressions, CurrentTime tells the time in any timezone, JSONQuery
+// extracts values from JSON documents with jq-style paths and Command runs
+// allowlisted programs.
+//
+// Example:
+//
+//	agent, _ := swarm.CreateReactAgent(model, tools.Defaults())
+//
+// Import it under another name next to langchaingo's tools package:
+//
+//	import swarmtools "github.com/go-hare/langchaingo_swarm/swarm/tools"
+package tools
+
+import (
+	langchaintools "github.com/tmc/langchaingo/tools"
+)
+
+// Defaults returns the tools that are safe for any agent: Calculator,
+// CurrentTime and JSONQuery. Command is left out, as it runs programs.
+func Defaults() []langchaintools.Tool {
+	return []langchaintools.Tool{Calculator(), CurrentTime(), JSONQuery()}
+}
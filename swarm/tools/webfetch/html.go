@@ -0,0 +1,236 @@
+package webfetch
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlToText converts an HTML page to plain text for a model: scripts,
+// styles and other invisible elements are dropped, block elements start new
+// lines, headings and list items keep a Markdown marker, and links are
+// written as Markdown links resolved against base.
+func htmlToText(body []byte, base *url.URL) string {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return strings.ToValidUTF8(string(body), "")
+	}
+	w := &textWriter{base: base}
+	if title := findTitle(doc); title != "" {
+		w.block("# " + title)
+	}
+	if body := findElement(doc, atom.Body); body != nil {
+		w.walk(body)
+	} else {
+		w.walk(doc)
+	}
+	return strings.TrimSpace(w.String())
+}
+
+// textWriter accumulates the text of an HTML document.
+type textWriter struct {
+	strings.Builder
+	base *url.URL
+	pre  int
+	// space is set when whitespace is pending before the next word
+	space bool
+}
+
+// skipped are the elements whose content is not shown.
+var skipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Svg: true, atom.Iframe: true, atom.Object: true,
+	atom.Button: true, atom.Select: true, atom.Form: true,
+}
+
+// blocks are the elements that start on a new line.
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Main: true, atom.Header: true, atom.Footer: true, atom.Nav: true,
+	atom.Aside: true, atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.Table: true, atom.Tr: true, atom.Blockquote: true, atom.Pre: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Hr: true,
+}
+
+// headings maps heading elements to their Markdown marker.
+var headings = map[atom.Atom]string{
+	atom.H1: "# ", atom.H2: "## ", atom.H3: "### ", atom.H4: "#### ", atom.H5: "##### ", atom.H6: "###### ",
+}
+
+// walk writes the text of n and its descendants.
+func (w *textWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.walk(c)
+		}
+		return
+	}
+
+	if skipped[n.DataAtom] {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		w.newline()
+		return
+	case atom.A:
+		w.link(n)
+		return
+	case atom.Td, atom.Th:
+		if n.PrevSibling != nil {
+			w.WriteString(" | ")
+			w.space = false
+		}
+	}
+
+	if blocks[n.DataAtom] {
+		w.paragraph()
+	}
+	if marker, ok := headings[n.DataAtom]; ok {
+		w.WriteString(marker)
+	}
+	if n.DataAtom == atom.Li {
+		w.WriteString("- ")
+	}
+	if n.DataAtom == atom.Pre {
+		w.pre++
+		defer func() { w.pre-- }()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+	if blocks[n.DataAtom] {
+		w.paragraph()
+	}
+}
+
+// link writes a as a Markdown link, or as its text when it has no usable
+// target.
+func (w *textWriter) link(a *html.Node) {
+	text := &textWriter{base: w.base}
+	for c := a.FirstChild; c != nil; c = c.NextSibling {
+		text.walk(c)
+	}
+	label := strings.Join(strings.Fields(text.String()), " ")
+	href := attribute(a, "href")
+	target, err := url.Parse(href)
+	if href == "" || strings.HasPrefix(href, "#") || err != nil || label == "" {
+		w.text(label)
+		return
+	}
+	if w.base != nil {
+		target = w.base.ResolveReference(target)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		w.text(label)
+		return
+	}
+	w.text("[" + label + "](" + target.String() + ")")
+}
+
+// text writes the words of s, or s as it is inside a pre element.
+func (w *textWriter) text(s string) {
+	if w.pre > 0 {
+		w.WriteString(s)
+		return
+	}
+	if s != "" && isSpace(s[0]) {
+		w.space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if (i > 0 || w.space) && !w.atLineStart() {
+			w.WriteByte(' ')
+		}
+		w.WriteString(word)
+		w.space = false
+	}
+	if s != "" && isSpace(s[len(s)-1]) {
+		w.space = true
+	}
+}
+
+// newline ends the current line.
+func (w *textWriter) newline() {
+	w.WriteByte('\n')
+	w.space = false
+}
+
+// paragraph separates what follows from what precedes with a blank line.
+func (w *textWriter) paragraph() {
+	w.space = false
+	s := w.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	if strings.HasSuffix(s, "\n") {
+		w.WriteByte('\n')
+	} else {
+		w.WriteString("\n\n")
+	}
+}
+
+// block writes s as a paragraph of its own.
+func (w *textWriter) block(s string) {
+	w.paragraph()
+	w.WriteString(s)
+	w.paragraph()
+}
+
+// atLineStart reports whether nothing was written on the current line.
+func (w *textWriter) atLineStart() bool {
+	s := w.String()
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+// isSpace reports whether c is HTML whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// findTitle returns the text of the document's title element.
+func findTitle(doc *html.Node) string {
+	title := findElement(doc, atom.Title)
+	if title == nil {
+		return ""
+	}
+	var b strings.Builder
+	for c := title.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// findElement returns the first element of n with the given tag.
+func findElement(n *html.Node, tag atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// attribute returns the value of the named attribute of n.
+func attribute(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
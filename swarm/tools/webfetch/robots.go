@@ -0,0 +1,178 @@
+package webfetch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxRobotsSize is the number of bytes read from a robots.txt file.
+const maxRobotsSize = 512 << 10
+
+// robotsRules are the rules of a robots.txt file that apply to the tool's
+// user agent.
+type robotsRules struct {
+	rules       []robotsRule
+	disallowAll bool
+}
+
+// robotsRule is an Allow or Disallow line of a robots.txt file.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// allowed reports whether u may be fetched. The longest matching pattern
+// wins, and Allow wins a tie.
+func (r *robotsRules) allowed(u *url.URL) bool {
+	if r.disallowAll {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || n == longest && rule.allow {
+			allowed, longest = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// robotsFor returns the robots.txt rules of the site of u, fetching them on
+// first use.
+func (t *Tool) robotsFor(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	site := u.Scheme + "://" + u.Host
+	t.mu.Lock()
+	rules, ok := t.robotsCache[site]
+	t.mu.Unlock()
+	if ok {
+		return rules, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt of %s: %w", site, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		rules = parseRobots(io.LimitReader(resp.Body, maxRobotsSize), t.userAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		// No robots.txt: everything is allowed
+		rules = &robotsRules{}
+	default:
+		// The site is unavailable; crawl nothing until it recovers
+		return &robotsRules{disallowAll: true}, nil
+	}
+
+	t.mu.Lock()
+	t.robotsCache[site] = rules
+	t.mu.Unlock()
+	return rules, nil
+}
+
+// parseRobots returns the rules of the group of a robots.txt file matching
+// userAgent, or of the "*" group when none does.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	product := strings.ToLower(userAgent)
+	if i := strings.IndexAny(product, "/ "); i >= 0 {
+		product = product[:i]
+	}
+
+	var specific, wildcard []robotsRule
+	var agents []string
+	inRules := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// An empty Disallow allows everything
+				continue
+			}
+			rule := robotsRule{pattern: value, allow: field == "allow"}
+			for _, agent := range agents {
+				switch {
+				case agent == "*":
+					wildcard = append(wildcard, rule)
+				case agent != "" && strings.HasPrefix(product, agent):
+					specific = append(specific, rule)
+				}
+			}
+		}
+	}
+
+	if specific != nil {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// matchRobotsPattern reports whether path matches a robots.txt pattern, in
+// which * matches any sequence of characters and a trailing $ anchors the
+// end of the path.
+func matchRobotsPattern(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "$") {
+		return matchGlob(strings.TrimSuffix(pattern, "$"), path, true)
+	}
+	return matchGlob(pattern, path, false)
+}
+
+// matchGlob matches path against pattern, as a whole when anchored and as a
+// prefix otherwise.
+func matchGlob(pattern, path string, anchored bool) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		if anchored {
+			return path == pattern
+		}
+		return strings.HasPrefix(path, pattern)
+	}
+	if !strings.HasPrefix(path, pattern[:star]) {
+		return false
+	}
+	pattern, path = pattern[star+1:], path[star:]
+	for i := 0; i <= len(path); i++ {
+		if matchGlob(pattern, path[i:], anchored) {
+			return true
+		}
+	}
+	return false
+}
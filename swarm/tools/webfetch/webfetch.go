@@ -0,0 +1,398 @@
+// Package webfetch provides a tool that fetches web pages for agents, with
+// the safety controls an agent browsing on a model's instructions needs:
+// timeouts, a maximum body size, URL allow and deny lists, respect for
+// robots.txt and refusal of private network addresses. HTML pages are
+// converted to plain text; other text formats, such as JSON, are returned as
+// they are, and binary content is refused.
+//
+// Example:
+//
+//	fetch := webfetch.New(
+//	    webfetch.WithAllowedHosts("langchain-ai.github.io"),
+//	    webfetch.WithMaxLength(8000),
+//	)
+//	researcher, _ := swarm.CreateReactAgent(model, []tools.Tool{fetch})
+package webfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultName is the name of tools created without WithName.
+	DefaultName = "fetch_url"
+	// DefaultUserAgent is the User-Agent of requests, also matched against
+	// the groups of robots.txt files.
+	DefaultUserAgent = "langchaingo-swarm-webfetch/1.0"
+
+	defaultTimeout     = 30 * time.Second
+	defaultMaxBodySize = 2 << 20
+	defaultMaxLength   = 20000
+	maxRedirects       = 10
+)
+
+var (
+	// ErrURLNotAllowed is returned for URLs outside the allowed hosts, on a
+	// denied host, or with a scheme other than http and https.
+	ErrURLNotAllowed = errors.New("webfetch: URL not allowed")
+	// ErrDisallowedByRobots is returned for URLs the site's robots.txt
+	// disallows.
+	ErrDisallowedByRobots = errors.New("webfetch: disallowed by robots.txt")
+	// ErrPrivateAddress is returned when a host resolves to a loopback,
+	// private or link-local address.
+	ErrPrivateAddress = errors.New("webfetch: private network address")
+	// ErrUnsupportedContentType is returned for responses that are not text.
+	ErrUnsupportedContentType = errors.New("webfetch: unsupported content type")
+)
+
+// Tool fetches the page at the URL given in its arguments. It is created by
+// New and safe for concurrent use.
+type Tool struct {
+	name         string
+	description  string
+	client       *http.Client
+	timeout      time.Duration
+	maxBodySize  int64
+	maxLength    int
+	userAgent    string
+	allowedHosts []string
+	deniedHosts  []string
+	robots       bool
+	private      bool
+
+	mu          sync.Mutex
+	robotsCache map[string]*robotsRules
+}
+
+// Option configures a Tool.
+type Option func(*Tool)
+
+// WithName sets the name of the tool (default: fetch_url).
+func WithName(name string) Option {
+	return func(t *Tool) {
+		t.name = name
+	}
+}
+
+// WithDescription sets the description of the tool shown to the model.
+func WithDescription(description string) Option {
+	return func(t *Tool) {
+		t.description = description
+	}
+}
+
+// WithHTTPClient sets the client used for requests. Its transport is
+// responsible for refusing private addresses, and its redirect policy is
+// replaced to check every redirect against the allow and deny lists.
+func WithHTTPClient(client *http.Client) Option {
+	return func(t *Tool) {
+		t.client = client
+	}
+}
+
+// WithTimeout sets the time allowed for a fetch, including the robots.txt
+// lookup (default: 30s).
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *Tool) {
+		t.timeout = timeout
+	}
+}
+
+// WithMaxBodySize sets the number of bytes read from a response (default:
+// 2 MiB). Longer bodies are truncated.
+func WithMaxBodySize(n int64) Option {
+	return func(t *Tool) {
+		t.maxBodySize = n
+	}
+}
+
+// WithMaxLength sets the number of characters of text returned to the model
+// (default: 20000). Longer texts are truncated.
+func WithMaxLength(n int) Option {
+	return func(t *Tool) {
+		t.maxLength = n
+	}
+}
+
+// WithUserAgent sets the User-Agent of requests (default: DefaultUserAgent).
+func WithUserAgent(userAgent string) Option {
+	return func(t *Tool) {
+		t.userAgent = userAgent
+	}
+}
+
+// WithAllowedHosts restricts fetches to the given hosts and their
+// subdomains.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(t *Tool) {
+		t.allowedHosts = append(t.allowedHosts, hosts...)
+	}
+}
+
+// WithDeniedHosts refuses fetches from the given hosts and their subdomains,
+// even when they are allowed.
+func WithDeniedHosts(hosts ...string) Option {
+	return func(t *Tool) {
+		t.deniedHosts = append(t.deniedHosts, hosts...)
+	}
+}
+
+// WithoutRobots disables the robots.txt check.
+func WithoutRobots() Option {
+	return func(t *Tool) {
+		t.robots = false
+	}
+}
+
+// AllowPrivateNetworks lets the tool fetch from loopback, private,
+// link-local and carrier-grade NAT addresses, which it refuses by default so
+// that a model cannot reach internal services. Only then does the tool use
+// the proxy of the HTTP_PROXY and HTTPS_PROXY variables.
+func AllowPrivateNetworks() Option {
+	return func(t *Tool) {
+		t.private = true
+	}
+}
+
+// New creates a fetch tool.
+func New(opts ...Option) *Tool {
+	t := &Tool{
+		name:        DefaultName,
+		description: "Fetch a web page or document from a URL and return its text content.",
+		timeout:     defaultTimeout,
+		maxBodySize: defaultMaxBodySize,
+		maxLength:   defaultMaxLength,
+		userAgent:   DefaultUserAgent,
+		robots:      true,
+		robotsCache: make(map[string]*robotsRules),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	client := &http.Client{}
+	if t.client != nil {
+		*client = *t.client
+	} else {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = (&net.Dialer{Timeout: 10 * time.Second, Control: t.checkAddress}).DialContext
+		// Through a proxy, the dialer would check the proxy's address
+		// rather than the fetched host's
+		if !t.private {
+			transport.Proxy = nil
+		}
+		client.Transport = transport
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return t.checkURL(req.URL)
+	}
+	t.client = client
+	return t
+}
+
+// Name returns the name of the tool.
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool.
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Schema returns the JSON schema of the tool's arguments.
+func (t *Tool) Schema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "The http or https URL to fetch",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+// Call fetches the URL of input, a JSON object with a "url" argument or a
+// bare URL, and returns the text of the response.
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
+	rawURL := strings.TrimSpace(input)
+	if strings.HasPrefix(rawURL, "{") {
+		var args struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal([]byte(input), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		rawURL = strings.TrimSpace(args.URL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q", rawURL)
+	}
+	if err := t.checkURL(u); err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q", rawURL)
+	}
+
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	if t.robots {
+		rules, err := t.robotsFor(ctx, u)
+		if err != nil {
+			return "", err
+		}
+		if !rules.allowed(u) {
+			return "", fmt.Errorf("%w: %s", ErrDisallowedByRobots, u)
+		}
+	}
+
+	return t.fetch(ctx, u)
+}
+
+// fetch returns the text of the response to a GET request for u.
+func (t *Tool) fetch(ctx context.Context, u *url.URL) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	req.Header.Set("Accept", "text/html, text/plain, application/json, application/xml;q=0.9, */*;q=0.5")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to fetch %s: %s", u, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBodySize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", u, err)
+	}
+	truncated := int64(len(body)) > t.maxBodySize
+	if truncated {
+		body = body[:t.maxBodySize]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	var text string
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		text = htmlToText(body, resp.Request.URL)
+	case isText(mediaType):
+		text = strings.ToValidUTF8(string(body), "")
+	default:
+		return "", fmt.Errorf("%w: %s is %s", ErrUnsupportedContentType, u, mediaType)
+	}
+
+	if t.maxLength > 0 && utf8.RuneCountInString(text) > t.maxLength {
+		text = string([]rune(text)[:t.maxLength])
+		truncated = true
+	}
+	if truncated {
+		text += "\n...(truncated)"
+	}
+	return text, nil
+}
+
+// isText reports whether mediaType is a text format returned as it is.
+func isText(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-yaml", "application/yaml":
+		return true
+	}
+	return false
+}
+
+// checkURL returns ErrURLNotAllowed unless u may be fetched.
+func (t *Tool) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrURLNotAllowed, u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, denied := range t.deniedHosts {
+		if matchHost(host, denied) {
+			return fmt.Errorf("%w: %s is denied", ErrURLNotAllowed, host)
+		}
+	}
+	if len(t.allowedHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range t.allowedHosts {
+		if matchHost(host, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not an allowed host", ErrURLNotAllowed, host)
+}
+
+// matchHost reports whether host is pattern or one of its subdomains.
+func matchHost(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimPrefix(pattern, "*."))
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// reservedNetworks are the networks refused besides the loopback, private
+// and link-local ones: "this network" and the shared address space of
+// carrier-grade NAT.
+var reservedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// checkAddress refuses connections to private addresses, once the host
+// name is resolved, unless AllowPrivateNetworks is set.
+func (t *Tool) checkAddress(network, address string, _ syscall.RawConn) error {
+	if t.private {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() ||
+		slices.ContainsFunc(reservedNetworks, func(network netip.Prefix) bool { return network.Contains(ip) }) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
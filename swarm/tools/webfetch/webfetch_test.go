@@ -0,0 +1,138 @@
+package webfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newSite serves a small site with a robots.txt disallowing /private.
+func newSite(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\nAllow: /private/ok$\n"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Docs</title><style>p{}</style></head><body>
+<h1>Agents</h1><script>alert(1)</script>
+<p>Build   a <b>ReAct</b> agent, see <a href="/guide">the guide</a>.</p>
+<ul><li>One</li><li>Two</li></ul></body></html>`))
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	})
+	mux.HandleFunc("/private/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://denied.example/", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetch(t *testing.T) {
+	server := newSite(t)
+	fetch := New(AllowPrivateNetworks())
+	ctx := context.Background()
+
+	text, err := fetch.Call(ctx, `{"url":"`+server.URL+`/page"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Docs\n\n# Agents\n\nBuild a ReAct agent, see [the guide](" + server.URL + "/guide).\n\n- One\n\n- Two"
+	if text != want {
+		t.Errorf("page text = %q, want %q", text, want)
+	}
+	if text, _ := fetch.Call(ctx, server.URL+"/data"); text != `{"ok":true}` {
+		t.Errorf("JSON text = %q", text)
+	}
+	if text, _ := New(AllowPrivateNetworks(), WithMaxBodySize(100)).Call(ctx, server.URL+"/large"); text != strings.Repeat("a", 100)+"\n...(truncated)" {
+		t.Errorf("large text = %q", text)
+	}
+	if _, err := fetch.Call(ctx, server.URL+"/image"); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("image error = %v", err)
+	}
+}
+
+func TestFetchSafety(t *testing.T) {
+	server := newSite(t)
+	ctx := context.Background()
+
+	if _, err := New().Call(ctx, server.URL+"/data"); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("loopback error = %v, want ErrPrivateAddress", err)
+	}
+	fetch := New(AllowPrivateNetworks(), WithDeniedHosts("denied.example"))
+	if _, err := fetch.Call(ctx, server.URL+"/private/page"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("robots error = %v", err)
+	}
+	if text, err := fetch.Call(ctx, server.URL+"/private/ok"); err != nil || text != "ok" {
+		t.Errorf("allowed path = %q, %v", text, err)
+	}
+	if _, err := fetch.Call(ctx, server.URL+"/redirect"); !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("redirect to a denied host error = %v", err)
+	}
+	if _, err := fetch.Call(ctx, "file:///etc/passwd"); !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("file URL error = %v", err)
+	}
+	if _, err := New(AllowPrivateNetworks(), WithAllowedHosts("example.com")).Call(ctx, server.URL+"/data"); !errors.Is(err, ErrURLNotAllowed) {
+		t.Errorf("host outside the allowlist error = %v", err)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	fetch := New()
+	for _, address := range []string{"127.0.0.1:80", "10.0.0.1:80", "169.254.169.254:80", "100.64.0.1:80", "0.1.2.3:80", "[::ffff:100.100.1.1]:80", "[::1]:443"} {
+		if err := fetch.checkAddress("tcp", address, nil); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("checkAddress(%s) error = %v, want ErrPrivateAddress", address, err)
+		}
+	}
+	if err := fetch.checkAddress("tcp", "93.184.215.14:443", nil); err != nil {
+		t.Errorf("checkAddress() of a public address error = %v", err)
+	}
+	if fetch.client.Transport.(*http.Transport).Proxy != nil {
+		t.Error("Proxy is set, want direct connections checked by the dialer")
+	}
+}
+
+func TestRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+User-agent: other
+Disallow: /
+
+User-agent: langchaingo-swarm-webfetch
+User-agent: bot
+Disallow: /tmp
+Disallow: /*.pdf$
+Allow: /tmp/public
+`), DefaultUserAgent)
+	tests := map[string]bool{
+		"/":                true,
+		"/tmp/x":           false,
+		"/tmp/public/a":    true,
+		"/docs/a.pdf":      false,
+		"/docs/a.pdf?x=1":  true,
+		"/docs/guide.html": true,
+	}
+	for path, want := range tests {
+		u, _ := url.Parse("https://example.com" + path)
+		if got := rules.allowed(u); got != want {
+			t.Errorf("allowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
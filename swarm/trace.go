@@ -0,0 +1,239 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// TraceExporter ships the Trace of each run of a swarm to an observability
+// backend; see SwarmConfig.TraceExporters and the traceexport package.
+type TraceExporter interface {
+	// ExportTrace exports the trace of a finished run
+	ExportTrace(ctx context.Context, trace *Trace) error
+}
+
+// TraceExporterFunc adapts a function to the TraceExporter interface.
+type TraceExporterFunc func(ctx context.Context, trace *Trace) error
+
+// ExportTrace calls f(ctx, trace).
+func (f TraceExporterFunc) ExportTrace(ctx context.Context, trace *Trace) error {
+	return f(ctx, trace)
+}
+
+// Trace is the structured record of an invocation, returned by
+// InvokeResult.Trace and passed to the swarm's TraceExporters. It marshals
+// to JSON for log pipelines; durations are in nanoseconds.
+type Trace struct {
+	// ThreadID is the thread of the run, if any
+	ThreadID string `json:"thread_id,omitempty"`
+	// Input is the text of the last user message of the input state
+	Input string `json:"input,omitempty"`
+	// Output is the text of the last AI message added by the run
+	Output string `json:"output,omitempty"`
+	// FinalAgent is the active agent of the resulting state
+	FinalAgent string `json:"final_agent,omitempty"`
+	// Error is the error the run failed or paused with, if any
+	Error string `json:"error,omitempty"`
+	// Interrupted reports whether the run paused for a human's approval
+	// (see InterruptError)
+	Interrupted bool `json:"interrupted,omitempty"`
+	// Start is when the invocation started
+	Start time.Time `json:"start"`
+	// Duration is how long the invocation took
+	Duration time.Duration `json:"duration"`
+	// Steps are the agent runs, in the order they started
+	Steps []TraceStep `json:"steps"`
+	// Usage counts the model calls of the invocation
+	Usage Usage `json:"usage"`
+	// Truncated is the reason the run was stopped before it finished, such
+	// as an exceeded Budget
+	Truncated string `json:"truncated,omitempty"`
+	// Experiments are the experiment flags of the run (see
+	// WithExperimentFlags)
+	Experiments []string `json:"experiments,omitempty"`
+
+	truncated error
+}
+
+// TraceStep is an agent run of a Trace.
+type TraceStep struct {
+	// Agent is the agent that ran
+	Agent string `json:"agent"`
+	// PromptVersion is the Agent.PromptVersion of the agent's system
+	// prompt, if any
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Start is when the run started
+	Start time.Time `json:"start"`
+	// Duration is how long the run took
+	Duration time.Duration `json:"duration"`
+	// ModelCalls are the calls of the agent to its model, in order
+	ModelCalls []TraceModelCall `json:"model_calls,omitempty"`
+	// ToolCalls are the tool calls the agent made, in order
+	ToolCalls []TraceToolCall `json:"tool_calls,omitempty"`
+	// HandoffTo is the agent the run handed off to, if any
+	HandoffTo string `json:"handoff_to,omitempty"`
+	// Usage counts the model calls of the run
+	Usage Usage `json:"usage"`
+	// Error is the error the run failed with, if any
+	Error string `json:"error,omitempty"`
+}
+
+// TraceModelCall is a model call of a TraceStep. Only the calls of
+// ReactAgent models are traced.
+type TraceModelCall struct {
+	// Start is when the call started
+	Start time.Time `json:"start"`
+	// Duration is how long the call took
+	Duration time.Duration `json:"duration"`
+	// Prompt are the messages sent to the model
+	Prompt []llms.MessageContent `json:"prompt"`
+	// Completion is the text of the model's response
+	Completion string `json:"completion,omitempty"`
+	// ToolCalls are the tool calls of the model's response
+	ToolCalls []llms.ToolCall `json:"tool_calls,omitempty"`
+	// Usage counts the tokens of the call
+	Usage Usage `json:"usage"`
+}
+
+// TraceToolCall is a tool call of a TraceStep.
+type TraceToolCall struct {
+	// ID is the ID of the tool call
+	ID string `json:"id"`
+	// Name is the name of the tool called
+	Name string `json:"name"`
+	// Arguments are the JSON arguments of the call
+	Arguments string `json:"arguments"`
+	// Result is the content of the tool message answering the call
+	Result string `json:"result"`
+	// Start is when the call started
+	Start time.Time `json:"start"`
+	// Duration is how long the tool took; it is zero when the tool was not
+	// run, e.g. because the call was refused
+	Duration time.Duration `json:"duration"`
+	// Error is the error the tool failed with, if any
+	Error string `json:"error,omitempty"`
+}
+
+// traceKey and traceStepKey are the context keys of the run's
+// traceRecorder and of the agent run's step.
+type (
+	traceKey     struct{}
+	traceStepKey struct{}
+)
+
+// traceRecorder collects the Trace of a run.
+type traceRecorder struct {
+	mu    sync.Mutex
+	trace Trace
+}
+
+// withTrace returns a context recording the run's trace in the returned
+// recorder.
+func withTrace(ctx context.Context) (context.Context, *traceRecorder) {
+	recorder := &traceRecorder{trace: Trace{Start: time.Now()}}
+	return context.WithValue(ctx, traceKey{}, recorder), recorder
+}
+
+// traceStep is the step of an agent run in a traceRecorder.
+type traceStep struct {
+	recorder *traceRecorder
+	index    int
+}
+
+// beginStep records the start of a run of agent, if ctx records a trace,
+// and returns the context of the run along with its step.
+func beginStep(ctx context.Context, agent string) (context.Context, *traceStep) {
+	recorder, ok := ctx.Value(traceKey{}).(*traceRecorder)
+	if !ok {
+		return ctx, nil
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.trace.Steps = append(recorder.trace.Steps, TraceStep{Agent: agent, Start: time.Now()})
+	step := &traceStep{recorder: recorder, index: len(recorder.trace.Steps) - 1}
+	return context.WithValue(ctx, traceStepKey{}, step), step
+}
+
+// update applies fn to the step.
+func (s *traceStep) update(fn func(*TraceStep)) {
+	if s == nil {
+		return
+	}
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	fn(&s.recorder.trace.Steps[s.index])
+}
+
+// end records the outcome of the step's run.
+func (s *traceStep) end(handoffTo string, err error) {
+	s.update(func(step *TraceStep) {
+		step.Duration = time.Since(step.Start)
+		step.HandoffTo = handoffTo
+		if err != nil {
+			step.Error = err.Error()
+		}
+	})
+}
+
+// traceStepFromContext returns the step of the agent run ctx belongs to,
+// or nil.
+func traceStepFromContext(ctx context.Context) *traceStep {
+	step, _ := ctx.Value(traceStepKey{}).(*traceStep)
+	return step
+}
+
+// recordModelCall adds a call to the model, started at start with prompt,
+// to the step of the agent run ctx belongs to, if any.
+func recordModelCall(ctx context.Context, start time.Time, prompt []llms.MessageContent, response *llms.ContentResponse) {
+	step := traceStepFromContext(ctx)
+	if step == nil {
+		return
+	}
+	call := TraceModelCall{Start: start, Duration: time.Since(start), Prompt: slices.Clone(prompt), Usage: responseUsage(response)}
+	if len(response.Choices) > 0 {
+		call.Completion = response.Choices[0].Content
+		call.ToolCalls = response.Choices[0].ToolCalls
+	}
+	step.update(func(step *TraceStep) {
+		step.ModelCalls = append(step.ModelCalls, call)
+	})
+}
+
+// recordToolCall adds call to the step of the agent run ctx belongs to, if
+// any.
+func recordToolCall(ctx context.Context, call TraceToolCall) {
+	traceStepFromContext(ctx).update(func(step *TraceStep) {
+		step.ToolCalls = append(step.ToolCalls, call)
+	})
+}
+
+// finish returns the trace of a run of input that ended with output and
+// err.
+func (r *traceRecorder) finish(threadID string, input, output SwarmState, err error) *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.trace
+	result.ThreadID = threadID
+	result.Input = lastHumanText(input.Messages)
+	if len(output.Messages) > len(input.Messages) {
+		_, result.Output = lastAnswer(output.Messages[len(input.Messages):])
+	}
+	result.FinalAgent = output.ActiveAgent
+	if err != nil {
+		result.Error = err.Error()
+		result.Interrupted = errors.Is(err, ErrInterrupted)
+	}
+	result.Duration = time.Since(result.Start)
+	result.Steps = slices.Clone(result.Steps)
+	for i, step := range result.Steps {
+		result.Steps[i].ModelCalls = slices.Clone(step.ModelCalls)
+		result.Steps[i].ToolCalls = slices.Clone(step.ToolCalls)
+		result.Usage = result.Usage.add(step.Usage)
+	}
+	return &result
+}
@@ -0,0 +1,109 @@
+package swarm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+func TestInvokeResultTrace(t *testing.T) {
+	lookup := toolCallChoice("call_1", "upper", `{"input":"order 42"}`)
+	lookup.GenerationInfo = map[string]any{"PromptTokens": 10, "CompletionTokens": 3}
+	handoff := toolCallChoice("call_2", "transfer_to_bob", `{"reason":"refund"}`)
+	handoff.GenerationInfo = map[string]any{"PromptTokens": 20, "CompletionTokens": 5}
+	model := &scriptedModel{responses: []*llms.ContentChoice{lookup, handoff}}
+	alice, err := CreateReactAgent(model, []tools.Tool{upperTool{}, CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+	})
+
+	result, err := app.InvokeWithResult(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Refund order 42")},
+	})
+	if err != nil {
+		t.Fatalf("InvokeWithResult() error = %v", err)
+	}
+	trace := result.Trace()
+	if len(trace.Steps) != 2 || trace.Steps[0].Agent != "Alice" || trace.Steps[1].Agent != "Bob" {
+		t.Fatalf("steps = %+v, want Alice's and Bob's runs", trace.Steps)
+	}
+	alicesRun := trace.Steps[0]
+	if alicesRun.HandoffTo != "Bob" || alicesRun.Usage != (Usage{ModelCalls: 2, InputTokens: 30, OutputTokens: 8}) {
+		t.Errorf("Alice's run = %+v", alicesRun)
+	}
+	if len(alicesRun.ModelCalls) != 2 || lastHumanText(alicesRun.ModelCalls[0].Prompt) != "Refund order 42" ||
+		alicesRun.ModelCalls[1].ToolCalls[0].FunctionCall.Name != "transfer_to_bob" || alicesRun.ModelCalls[1].Usage.InputTokens != 20 {
+		t.Errorf("model calls = %+v", alicesRun.ModelCalls)
+	}
+	if len(alicesRun.ToolCalls) != 2 {
+		t.Fatalf("tool calls = %+v", alicesRun.ToolCalls)
+	}
+	if call := alicesRun.ToolCalls[0]; call.ID != "call_1" || call.Name != "upper" || call.Arguments != `{"input":"order 42"}` || call.Result != "ORDER 42" {
+		t.Errorf("tool call = %+v", call)
+	}
+	if call := alicesRun.ToolCalls[1]; call.Name != "transfer_to_bob" {
+		t.Errorf("handoff call = %+v", call)
+	}
+	if bobsRun := trace.Steps[1]; bobsRun.HandoffTo != "" || len(bobsRun.ToolCalls) != 0 || bobsRun.Usage.ModelCalls != 0 {
+		t.Errorf("Bob's run = %+v", bobsRun)
+	}
+	if trace.Usage != result.Usage || trace.Duration <= 0 || trace.Start.IsZero() {
+		t.Errorf("trace = %+v", trace)
+	}
+
+	data, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded Trace
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(decoded.Steps) != 2 || decoded.Steps[0].ToolCalls[0].Result != "ORDER 42" ||
+		lastHumanText(decoded.Steps[0].ModelCalls[0].Prompt) != "Refund order 42" || decoded.Usage != trace.Usage {
+		t.Errorf("decoded trace = %s", data)
+	}
+}
+
+func TestTraceExporters(t *testing.T) {
+	var exported []*Trace
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: handoffTo("Bob")},
+			{Name: "Bob", Runnable: handoffTo("Alice")},
+		},
+		DefaultActiveAgent: "Alice",
+		TraceExporters: []TraceExporter{TraceExporterFunc(func(ctx context.Context, trace *Trace) error {
+			exported = append(exported, trace)
+			return nil
+		})},
+	})
+
+	// Failed runs are exported too
+	_, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+	}, WithRecursionLimit(2))
+	if err == nil {
+		t.Fatal("Invoke() error = nil, want the recursion limit")
+	}
+	if len(exported) != 1 {
+		t.Fatalf("exported %d traces, want 1", len(exported))
+	}
+	trace := exported[0]
+	if trace.Input != "Hi" || trace.Error != err.Error() {
+		t.Errorf("trace = %+v", trace)
+	}
+	if len(trace.Steps) != 3 || trace.Steps[0].HandoffTo != "Bob" || trace.Steps[2].Error == "" {
+		t.Errorf("steps = %+v, want the failing run's error", trace.Steps)
+	}
+}
@@ -0,0 +1,134 @@
+package traceexport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/google/uuid"
+)
+
+// DefaultLangfuseEndpoint is the Langfuse cloud API.
+const DefaultLangfuseEndpoint = "https://cloud.langfuse.com"
+
+// Langfuse exports the traces of swarm runs to Langfuse. Each run becomes
+// a trace, with a span per agent run, and a generation per model call and a
+// span per tool call nested under it. The thread of a run is its session.
+type Langfuse struct {
+	exporter
+	publicKey string
+	secretKey string
+}
+
+// NewLangfuse returns an exporter to Langfuse authenticated with the
+// project's API keys.
+func NewLangfuse(publicKey, secretKey string, opts ...Option) *Langfuse {
+	return &Langfuse{exporter: newExporter(DefaultLangfuseEndpoint, opts), publicKey: publicKey, secretKey: secretKey}
+}
+
+// langfuseEvent is an event of the Langfuse ingestion API.
+type langfuseEvent struct {
+	ID        string         `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Type      string         `json:"type"`
+	Body      map[string]any `json:"body"`
+}
+
+// langfuseResponse is the response of the Langfuse ingestion API, which
+// reports failed events with a success status.
+type langfuseResponse struct {
+	Errors []struct {
+		ID      string `json:"id"`
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// ExportTrace posts the events of trace to Langfuse.
+func (l *Langfuse) ExportTrace(ctx context.Context, trace *swarm.Trace) error {
+	traceID := uuid.NewString()
+	body := map[string]any{
+		"id":        traceID,
+		"timestamp": trace.Start.UTC(),
+		"name":      l.name,
+		"input":     trace.Input,
+		"output":    trace.Output,
+		"metadata":  map[string]any{"final_agent": trace.FinalAgent, "error": trace.Error},
+	}
+	if trace.ThreadID != "" {
+		body["sessionId"] = trace.ThreadID
+	}
+	if l.project != "" {
+		body["tags"] = []string{l.project}
+	}
+	events := []langfuseEvent{l.event("trace-create", body)}
+
+	for _, step := range trace.Steps {
+		span := l.observation(traceID, "", step.Agent, step.Start, step.Duration, step.Error)
+		span["output"] = map[string]any{"handoff_to": step.HandoffTo}
+		events = append(events, l.event("span-create", span))
+		for _, call := range step.ModelCalls {
+			generation := l.observation(traceID, span["id"].(string), "model", call.Start, call.Duration, "")
+			generation["input"] = messages(call.Prompt)
+			generation["output"] = completion(call)
+			generation["usage"] = map[string]any{
+				"input":  call.Usage.InputTokens,
+				"output": call.Usage.OutputTokens,
+				"total":  call.Usage.TotalTokens(),
+				"unit":   "TOKENS",
+			}
+			events = append(events, l.event("generation-create", generation))
+		}
+		for _, call := range step.ToolCalls {
+			tool := l.observation(traceID, span["id"].(string), call.Name, call.Start, call.Duration, call.Error)
+			tool["input"] = call.Arguments
+			tool["output"] = call.Result
+			tool["metadata"] = map[string]any{"tool_call_id": call.ID}
+			events = append(events, l.event("span-create", tool))
+		}
+	}
+
+	var response langfuseResponse
+	err := l.post(ctx, "/api/public/ingestion", map[string]any{"batch": events}, func(req *http.Request) {
+		req.SetBasicAuth(l.publicKey, l.secretKey)
+	}, &response)
+	if err != nil {
+		return fmt.Errorf("langfuse: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		messages := make([]string, len(response.Errors))
+		for i, e := range response.Errors {
+			messages[i] = fmt.Sprintf("%d %s", e.Status, e.Message)
+		}
+		return fmt.Errorf("langfuse: %d of %d events rejected: %s", len(response.Errors), len(events), strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// event returns an ingestion event of the given type.
+func (l *Langfuse) event(eventType string, body map[string]any) langfuseEvent {
+	return langfuseEvent{ID: uuid.NewString(), Timestamp: time.Now().UTC(), Type: eventType, Body: body}
+}
+
+// observation returns the body of an observation of the trace, under the
+// parent observation if any, that failed with errMessage if not empty.
+func (l *Langfuse) observation(traceID, parent, name string, start time.Time, duration time.Duration, errMessage string) map[string]any {
+	body := map[string]any{
+		"id":        uuid.NewString(),
+		"traceId":   traceID,
+		"name":      name,
+		"startTime": start.UTC(),
+		"endTime":   start.Add(duration).UTC(),
+	}
+	if parent != "" {
+		body["parentObservationId"] = parent
+	}
+	if errMessage != "" {
+		body["level"] = "ERROR"
+		body["statusMessage"] = errMessage
+	}
+	return body
+}
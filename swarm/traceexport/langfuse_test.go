@@ -0,0 +1,72 @@
+package traceexport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLangfuseExportTrace(t *testing.T) {
+	var body struct {
+		Batch []langfuseEvent `json:"batch"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if r.URL.Path != "/api/public/ingestion" || user != "pk" || password != "sk" {
+			t.Errorf("request = %s %s, auth %q:%q", r.Method, r.URL.Path, user, password)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Decode() error = %v", err)
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(`{"successes":[],"errors":[]}`))
+	}))
+	defer server.Close()
+
+	exporter := NewLangfuse("pk", "sk", WithEndpoint(server.URL+"/"))
+	if err := exporter.ExportTrace(context.Background(), sampleTrace()); err != nil {
+		t.Fatalf("ExportTrace() error = %v", err)
+	}
+
+	events := body.Batch
+	if len(events) != 5 {
+		t.Fatalf("events = %+v", events)
+	}
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	if got := strings.Join(types, " "); got != "trace-create span-create generation-create span-create span-create" {
+		t.Errorf("event types = %s", got)
+	}
+	trace, alice, generation, tool := events[0].Body, events[1].Body, events[2].Body, events[3].Body
+	if trace["sessionId"] != "thread-1" || trace["input"] != "Refund order 42" || trace["output"] != "Done" {
+		t.Errorf("trace = %v", trace)
+	}
+	if alice["traceId"] != trace["id"] || alice["name"] != "Alice" || alice["parentObservationId"] != nil {
+		t.Errorf("Alice's span = %v", alice)
+	}
+	usage, _ := generation["usage"].(map[string]any)
+	if generation["parentObservationId"] != alice["id"] || usage["total"] != float64(13) {
+		t.Errorf("generation = %v", generation)
+	}
+	if tool["parentObservationId"] != alice["id"] || tool["name"] != "lookup_order" || tool["output"] != "shipped" {
+		t.Errorf("tool span = %v", tool)
+	}
+}
+
+func TestLangfuseRejectedEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(`{"successes":[],"errors":[{"id":"1","status":400,"message":"invalid body"}]}`))
+	}))
+	defer server.Close()
+
+	err := NewLangfuse("pk", "sk", WithEndpoint(server.URL)).ExportTrace(context.Background(), sampleTrace())
+	if err == nil || !strings.Contains(err.Error(), "invalid body") {
+		t.Errorf("ExportTrace() error = %v, want the rejected event", err)
+	}
+}
@@ -0,0 +1,121 @@
+package traceexport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/google/uuid"
+)
+
+// DefaultLangSmithEndpoint is the LangSmith cloud API.
+const DefaultLangSmithEndpoint = "https://api.smith.langchain.com"
+
+// LangSmith exports the traces of swarm runs to LangSmith. Each run becomes
+// a chain run, with a chain run per agent run, an llm run per model call and
+// a tool run per tool call nested under it.
+type LangSmith struct {
+	exporter
+	apiKey string
+}
+
+// NewLangSmith returns an exporter to LangSmith authenticated with apiKey.
+func NewLangSmith(apiKey string, opts ...Option) *LangSmith {
+	e := newExporter(DefaultLangSmithEndpoint, opts)
+	if e.project == "" {
+		e.project = "default"
+	}
+	return &LangSmith{exporter: e, apiKey: apiKey}
+}
+
+// langSmithRun is a run of the LangSmith batch ingestion API.
+type langSmithRun struct {
+	ID          string         `json:"id"`
+	TraceID     string         `json:"trace_id"`
+	ParentRunID string         `json:"parent_run_id,omitempty"`
+	DottedOrder string         `json:"dotted_order"`
+	Name        string         `json:"name"`
+	RunType     string         `json:"run_type"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	Inputs      map[string]any `json:"inputs"`
+	Outputs     map[string]any `json:"outputs,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	SessionName string         `json:"session_name"`
+	Extra       map[string]any `json:"extra,omitempty"`
+}
+
+// ExportTrace posts the runs of trace to LangSmith.
+func (l *LangSmith) ExportTrace(ctx context.Context, trace *swarm.Trace) error {
+	root := l.run(nil, l.name, "chain", trace.Start, trace.Duration)
+	root.Inputs = map[string]any{"input": trace.Input}
+	root.Outputs = map[string]any{"output": trace.Output, "final_agent": trace.FinalAgent}
+	root.Error = trace.Error
+	root.Extra = map[string]any{"metadata": map[string]any{"thread_id": trace.ThreadID}}
+	runs := []langSmithRun{root}
+
+	for _, step := range trace.Steps {
+		agent := l.run(&root, step.Agent, "chain", step.Start, step.Duration)
+		agent.Outputs = map[string]any{"handoff_to": step.HandoffTo}
+		agent.Error = step.Error
+		runs = append(runs, agent)
+		for _, call := range step.ModelCalls {
+			llm := l.run(&agent, "model", "llm", call.Start, call.Duration)
+			llm.Inputs = map[string]any{"messages": messages(call.Prompt)}
+			llm.Outputs = map[string]any{
+				"choices": []any{map[string]any{"message": completion(call)}},
+				"usage_metadata": map[string]int{
+					"input_tokens":  call.Usage.InputTokens,
+					"output_tokens": call.Usage.OutputTokens,
+					"total_tokens":  call.Usage.TotalTokens(),
+				},
+			}
+			runs = append(runs, llm)
+		}
+		for _, call := range step.ToolCalls {
+			tool := l.run(&agent, call.Name, "tool", call.Start, call.Duration)
+			tool.Inputs = map[string]any{"input": call.Arguments}
+			tool.Outputs = map[string]any{"output": call.Result}
+			tool.Error = call.Error
+			tool.Extra = map[string]any{"metadata": map[string]any{"tool_call_id": call.ID}}
+			runs = append(runs, tool)
+		}
+	}
+
+	err := l.post(ctx, "/runs/batch", map[string]any{"post": runs}, func(req *http.Request) {
+		req.Header.Set("x-api-key", l.apiKey)
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("langsmith: %w", err)
+	}
+	return nil
+}
+
+// run returns a run named name under parent, or a root run when parent is
+// nil.
+func (l *LangSmith) run(parent *langSmithRun, name, runType string, start time.Time, duration time.Duration) langSmithRun {
+	id := uuid.NewString()
+	// The dotted order sorts runs by start time, then by ID, along the path
+	// from the root run
+	order := strings.Replace(start.UTC().Format("20060102T150405.000000Z"), ".", "", 1) + id
+	run := langSmithRun{
+		ID:          id,
+		TraceID:     id,
+		DottedOrder: order,
+		Name:        name,
+		RunType:     runType,
+		StartTime:   start.UTC(),
+		EndTime:     start.Add(duration).UTC(),
+		Inputs:      map[string]any{},
+		SessionName: l.project,
+	}
+	if parent != nil {
+		run.TraceID = parent.TraceID
+		run.ParentRunID = parent.ID
+		run.DottedOrder = parent.DottedOrder + "." + order
+	}
+	return run
+}
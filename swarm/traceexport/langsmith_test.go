@@ -0,0 +1,115 @@
+package traceexport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// sampleTrace is the trace of a run where Alice looks up an order, then
+// hands off to Bob, who answers.
+func sampleTrace() *swarm.Trace {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &swarm.Trace{
+		ThreadID:   "thread-1",
+		Input:      "Refund order 42",
+		Output:     "Done",
+		FinalAgent: "Bob",
+		Start:      start,
+		Duration:   3 * time.Second,
+		Steps: []swarm.TraceStep{
+			{
+				Agent:    "Alice",
+				Start:    start,
+				Duration: 2 * time.Second,
+				ModelCalls: []swarm.TraceModelCall{{
+					Start:    start,
+					Duration: time.Second,
+					Prompt:   []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Refund order 42")},
+					ToolCalls: []llms.ToolCall{{
+						ID: "call_1", Type: "function",
+						FunctionCall: &llms.FunctionCall{Name: "lookup_order", Arguments: `{"id":42}`},
+					}},
+					Usage: swarm.Usage{ModelCalls: 1, InputTokens: 10, OutputTokens: 3},
+				}},
+				ToolCalls: []swarm.TraceToolCall{{
+					ID: "call_1", Name: "lookup_order", Arguments: `{"id":42}`,
+					Result: "shipped", Start: start.Add(time.Second), Duration: time.Second,
+				}},
+				HandoffTo: "Bob",
+			},
+			{Agent: "Bob", Start: start.Add(2 * time.Second), Duration: time.Second},
+		},
+	}
+}
+
+func TestLangSmithExportTrace(t *testing.T) {
+	var body struct {
+		Post []langSmithRun `json:"post"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/runs/batch" || r.Header.Get("x-api-key") != "key" {
+			t.Errorf("request = %s %s, x-api-key %q", r.Method, r.URL.Path, r.Header.Get("x-api-key"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Decode() error = %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	exporter := NewLangSmith("key", WithEndpoint(server.URL), WithProject("support"))
+	if err := exporter.ExportTrace(context.Background(), sampleTrace()); err != nil {
+		t.Fatalf("ExportTrace() error = %v", err)
+	}
+
+	// The run, Alice's run with her model and tool calls, and Bob's run
+	runs := body.Post
+	if len(runs) != 5 {
+		t.Fatalf("runs = %+v", runs)
+	}
+	root, alice, model, tool, bob := runs[0], runs[1], runs[2], runs[3], runs[4]
+	if root.Name != "swarm" || root.RunType != "chain" || root.ParentRunID != "" || root.SessionName != "support" ||
+		root.Inputs["input"] != "Refund order 42" || root.Outputs["output"] != "Done" {
+		t.Errorf("root run = %+v", root)
+	}
+	if alice.Name != "Alice" || alice.ParentRunID != root.ID || alice.TraceID != root.ID || alice.Outputs["handoff_to"] != "Bob" {
+		t.Errorf("Alice's run = %+v", alice)
+	}
+	if !strings.HasPrefix(alice.DottedOrder, root.DottedOrder+".") || !strings.HasPrefix(root.DottedOrder, "20250301T120000000000Z") {
+		t.Errorf("dotted orders = %q, %q", root.DottedOrder, alice.DottedOrder)
+	}
+	if model.RunType != "llm" || model.ParentRunID != alice.ID {
+		t.Errorf("model run = %+v", model)
+	}
+	prompt, _ := json.Marshal(model.Inputs["messages"])
+	if string(prompt) != `[{"content":"Refund order 42","role":"user"}]` {
+		t.Errorf("prompt = %s", prompt)
+	}
+	if tool.RunType != "tool" || tool.Name != "lookup_order" || tool.Outputs["output"] != "shipped" ||
+		!tool.EndTime.Equal(tool.StartTime.Add(time.Second)) {
+		t.Errorf("tool run = %+v", tool)
+	}
+	if bob.Name != "Bob" || bob.ParentRunID != root.ID {
+		t.Errorf("Bob's run = %+v", bob)
+	}
+}
+
+func TestLangSmithExportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewLangSmith("bad", WithEndpoint(server.URL)).ExportTrace(context.Background(), sampleTrace())
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("ExportTrace() error = %v, want the response", err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package traceexport ships the traces of swarm runs to LLM observability
+// platforms, so teams already using them see swarm runs alongside their
+// other chains.
+//
+// LangSmith and Langfuse implement swarm.TraceExporter: add them to
+// SwarmConfig.TraceExporters and every run is exported once it is over, with
+// a span per agent run, model call (prompt and completion) and tool call:
+//
+//	s, err := swarm.CreateSwarm(swarm.SwarmConfig{
+//	    Agents: agents,
+//	    TraceExporters: []swarm.TraceExporter{
+//	        traceexport.NewLangSmith(os.Getenv("LANGSMITH_API_KEY"), traceexport.WithProject("support")),
+//	    },
+//	})
+//
+// Exports are synchronous and add a request to the end of each run; use
+// WithHTTPClient to bound it with a timeout.
+package traceexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxErrorBody is the number of bytes of an error response quoted in an
+// export error.
+const maxErrorBody = 512
+
+// defaultRunName is the name of the root span of exported runs.
+const defaultRunName = "swarm"
+
+// Option configures a LangSmith or Langfuse exporter.
+type Option func(*exporter)
+
+// WithEndpoint sends traces to endpoint, e.g. a self-hosted instance,
+// instead of the platform's cloud API.
+func WithEndpoint(endpoint string) Option {
+	return func(e *exporter) {
+		e.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithHTTPClient sends traces with client (default: http.DefaultClient).
+// Use it to set timeouts or transports.
+func WithHTTPClient(client *http.Client) Option {
+	return func(e *exporter) {
+		e.client = client
+	}
+}
+
+// WithProject files traces under a project: the LangSmith project runs are
+// logged to (default "default"), or a tag of Langfuse traces.
+func WithProject(project string) Option {
+	return func(e *exporter) {
+		e.project = project
+	}
+}
+
+// WithRunName names the root span of exported runs (default "swarm").
+func WithRunName(name string) Option {
+	return func(e *exporter) {
+		e.name = name
+	}
+}
+
+// exporter holds the settings common to the exporters.
+type exporter struct {
+	endpoint string
+	client   *http.Client
+	project  string
+	name     string
+}
+
+// newExporter returns the settings of an exporter of the platform at
+// endpoint, with opts applied.
+func newExporter(endpoint string, opts []Option) exporter {
+	e := exporter{endpoint: endpoint, client: http.DefaultClient, name: defaultRunName}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+// post sends body as JSON to path, authenticated by auth, and decodes the
+// response into response unless it is nil.
+func (e *exporter) post(ctx context.Context, path string, body any, auth func(*http.Request), response any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trace: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	auth(req)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	if response == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// message is a chat message in the OpenAI format both platforms render.
+type message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// toolCall is a tool call of a message.
+type toolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// messages converts a prompt to messages.
+func messages(prompt []llms.MessageContent) []message {
+	result := make([]message, 0, len(prompt))
+	for _, content := range prompt {
+		m := message{Role: role(content.Role)}
+		var text []string
+		for _, part := range content.Parts {
+			switch part := part.(type) {
+			case llms.TextContent:
+				text = append(text, part.Text)
+			case llms.ToolCall:
+				m.ToolCalls = append(m.ToolCalls, convertToolCall(part))
+			case llms.ToolCallResponse:
+				m.ToolCallID = part.ToolCallID
+				text = append(text, part.Content)
+			case llms.ImageURLContent:
+				// Images are referenced rather than uploaded with the trace
+				url := part.URL
+				if strings.HasPrefix(url, "data:") {
+					url = "data URL"
+				}
+				text = append(text, "[image: "+url+"]")
+			case llms.BinaryContent:
+				text = append(text, fmt.Sprintf("[%s attachment, %d bytes]", part.MIMEType, len(part.Data)))
+			}
+		}
+		m.Content = strings.Join(text, "\n")
+		result = append(result, m)
+	}
+	return result
+}
+
+// completion returns the model's response of call as a message.
+func completion(call swarm.TraceModelCall) message {
+	m := message{Role: "assistant", Content: call.Completion}
+	for _, tc := range call.ToolCalls {
+		m.ToolCalls = append(m.ToolCalls, convertToolCall(tc))
+	}
+	return m
+}
+
+// convertToolCall converts a langchaingo tool call.
+func convertToolCall(tc llms.ToolCall) toolCall {
+	converted := toolCall{ID: tc.ID, Type: "function"}
+	if tc.FunctionCall != nil {
+		converted.Function.Name = tc.FunctionCall.Name
+		converted.Function.Arguments = tc.FunctionCall.Arguments
+	}
+	return converted
+}
+
+// role returns the OpenAI role of a message type.
+func role(t llms.ChatMessageType) string {
+	switch t {
+	case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric:
+		return "user"
+	case llms.ChatMessageTypeAI:
+		return "assistant"
+	case llms.ChatMessageTypeSystem:
+		return "system"
+	case llms.ChatMessageTypeTool, llms.ChatMessageTypeFunction:
+		return "tool"
+	default:
+		return string(t)
+	}
+}
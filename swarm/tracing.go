@@ -0,0 +1,65 @@
+package swarm
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of swarm spans.
+const tracerName = "github.com/go-hare/langchaingo_swarm/swarm"
+
+// Span attribute keys.
+const (
+	attrAgent         = attribute.Key("swarm.agent")
+	attrReplica       = attribute.Key("swarm.agent.replica")
+	attrPromptVersion = attribute.Key("swarm.agent.prompt_version")
+	attrActiveAgent   = attribute.Key("swarm.active_agent")
+	attrDestination   = attribute.Key("swarm.handoff.destination")
+	attrMessages      = attribute.Key("swarm.messages")
+	attrTool          = attribute.Key("swarm.tool")
+	attrToolCallID    = attribute.Key("swarm.tool_call_id")
+	attrInputTokens   = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens  = attribute.Key("gen_ai.usage.output_tokens")
+)
+
+// tracer returns the tracer for spans started with ctx. It uses provider if
+// set and otherwise the provider of the span already in ctx, so nested
+// components (agents, tool nodes) join the swarm's trace without
+// configuration. Without either, spans are no-ops.
+func tracer(ctx context.Context, provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = trace.SpanFromContext(ctx).TracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tokenUsage extracts token counts from a model's generation info.
+// Providers report them under different keys.
+func tokenUsage(info map[string]any) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, key := range inputTokenKeys {
+		if n, ok := info[key].(int); ok {
+			attrs = append(attrs, attrInputTokens.Int(n))
+			break
+		}
+	}
+	for _, key := range outputTokenKeys {
+		if n, ok := info[key].(int); ok {
+			attrs = append(attrs, attrOutputTokens.Int(n))
+			break
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,95 @@
+package swarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSwarmTracing(t *testing.T) {
+	handoff := toolCallChoice("call_2", "transfer_to_bob", `{}`)
+	handoff.GenerationInfo = map[string]any{"PromptTokens": 12, "CompletionTokens": 3}
+
+	alice, err := CreateReactAgent(&scriptedModel{responses: []*llms.ContentChoice{
+		toolCallChoice("call_1", "upper", `{"input":"hi"}`),
+		handoff,
+	}}, []tools.Tool{upperTool{}, CreateHandoffTool(HandoffToolConfig{AgentName: "Bob"})})
+	if err != nil {
+		t.Fatalf("CreateReactAgent() error = %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s, err := CreateSwarm(SwarmConfig{
+		Agents: []Agent{
+			{Name: "Alice", Runnable: alice},
+			{Name: "Bob", Runnable: createMockAgent("Bob", "Ahoy")},
+		},
+		DefaultActiveAgent: "Alice",
+		TracerProvider:     provider,
+	})
+	if err != nil {
+		t.Fatalf("CreateSwarm() error = %v", err)
+	}
+	app, err := s.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := app.Invoke(context.Background(), SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")},
+	}); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	parents := map[string]string{
+		"agent Alice":          "swarm.invoke",
+		"agent Bob":            "swarm.invoke",
+		"model.generate":       "agent Alice",
+		"tool upper":           "agent Alice",
+		"tool transfer_to_bob": "agent Alice",
+		"handoff":              "agent Alice",
+	}
+	for name, parent := range parents {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("Expected span %q, got %v", name, recorder.Ended())
+			continue
+		}
+		if span.Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Errorf("Expected span %q to be a child of %q", name, parent)
+		}
+	}
+
+	if !hasAttribute(spans["handoff"].Attributes(), attrDestination.String("Bob")) {
+		t.Errorf("Expected handoff span to record the destination, got %v", spans["handoff"].Attributes())
+	}
+	var tokens bool
+	for _, span := range recorder.Ended() {
+		if span.Name() == "model.generate" && hasAttribute(span.Attributes(), attrInputTokens.Int(12)) {
+			tokens = true
+		}
+	}
+	if !tokens {
+		t.Error("Expected a model.generate span with token usage")
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
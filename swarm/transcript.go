@@ -0,0 +1,257 @@
+package swarm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// TranscriptFormat is an output format of ExportTranscript.
+type TranscriptFormat string
+
+const (
+	// TranscriptMarkdown renders the transcript as Markdown.
+	TranscriptMarkdown TranscriptFormat = "markdown"
+	// TranscriptHTML renders the transcript as a standalone HTML page.
+	TranscriptHTML TranscriptFormat = "html"
+	// TranscriptJSON renders the transcript as indented JSON: an object
+	// with the final "active_agent" and the "entries" of the conversation.
+	TranscriptJSON TranscriptFormat = "json"
+)
+
+// transcriptEntry is a step of a conversation as rendered in a transcript.
+type transcriptEntry struct {
+	// Kind is "message", "tool_call", "tool_result" or "handoff"
+	Kind string `json:"kind"`
+	// Role is the role of the message the entry comes from
+	Role llms.ChatMessageType `json:"role"`
+	// Agent is the agent active when the entry was added
+	Agent     string         `json:"agent,omitempty"`
+	Text      string         `json:"text,omitempty"`
+	Tool      string         `json:"tool,omitempty"`
+	Arguments string         `json:"arguments,omitempty"`
+	Handoff   *HandoffRecord `json:"handoff,omitempty"`
+	// Attachments are the images and files of a message
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+// transcript is a conversation as rendered by ExportTranscript.
+type transcript struct {
+	ActiveAgent string            `json:"active_agent,omitempty"`
+	Entries     []transcriptEntry `json:"entries"`
+}
+
+// ExportTranscript renders the conversation of state for people to read,
+// e.g. to attach it to a support ticket. AI messages and tool calls are
+// attributed to the agent active when they were made, and handoffs are
+// annotated with their reason in place of their tool call and result.
+//
+// Agents are attributed from state.Handoffs: the conversation starts with
+// the agent of the first handoff and switches agent at the result of each
+// handoff tool call. Handoffs made without a tool call, e.g. by a router,
+// are not tied to a message and are placed at the start of the turn
+// following the previous handoff, after the user's message.
+//
+// Example:
+//
+//	md, err := swarm.ExportTranscript(result, swarm.TranscriptMarkdown)
+func ExportTranscript(state SwarmState, format TranscriptFormat) (string, error) {
+	t := buildTranscript(state)
+	switch format {
+	case TranscriptMarkdown:
+		return t.markdown(), nil
+	case TranscriptHTML:
+		var b bytes.Buffer
+		if err := transcriptPage.Execute(&b, t); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	case TranscriptJSON:
+		data, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown transcript format '%s'", format)
+	}
+}
+
+// buildTranscript lists the steps of the conversation of state.
+func buildTranscript(state SwarmState) transcript {
+	t := transcript{ActiveAgent: state.ActiveAgent}
+	byCall := make(map[string]int)
+	for i, record := range state.Handoffs {
+		if record.ToolCallID != "" {
+			byCall[record.ToolCallID] = i
+		}
+	}
+	agent := state.ActiveAgent
+	if len(state.Handoffs) > 0 {
+		agent = state.Handoffs[0].From
+	}
+	// next is the index of the first handoff not yet placed
+	next := 0
+	handoff := func(i int, role llms.ChatMessageType) {
+		record := state.Handoffs[i]
+		t.Entries = append(t.Entries, transcriptEntry{Kind: "handoff", Role: role, Agent: agent, Handoff: &record})
+		agent = record.To
+		next = max(next, i+1)
+	}
+
+	for i, message := range state.Messages {
+		var texts []string
+		for _, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok && strings.TrimSpace(text.Text) != "" {
+				texts = append(texts, text.Text)
+			}
+		}
+		attached := attachments(message.Parts)
+		if len(texts) > 0 || len(attached) > 0 {
+			entry := transcriptEntry{Kind: "message", Role: message.Role, Text: strings.Join(texts, "\n"), Attachments: attached}
+			if message.Role == llms.ChatMessageTypeAI {
+				entry.Agent = agent
+			}
+			t.Entries = append(t.Entries, entry)
+		}
+
+		for _, part := range message.Parts {
+			switch part := part.(type) {
+			case llms.ToolCall:
+				if _, ok := byCall[part.ID]; ok || part.FunctionCall == nil {
+					continue
+				}
+				t.Entries = append(t.Entries, transcriptEntry{
+					Kind: "tool_call", Role: message.Role, Agent: agent,
+					Tool: part.FunctionCall.Name, Arguments: part.FunctionCall.Arguments,
+				})
+			case llms.ToolCallResponse:
+				if index, ok := byCall[part.ToolCallID]; ok {
+					handoff(index, message.Role)
+					continue
+				}
+				t.Entries = append(t.Entries, transcriptEntry{
+					Kind: "tool_result", Role: message.Role, Agent: agent, Tool: part.Name, Text: part.Content,
+				})
+			}
+		}
+
+		// Handoffs without a tool call are placed at the start of the next
+		// turn, after the user's message
+		if message.Role == llms.ChatMessageTypeHuman &&
+			i+1 < len(state.Messages) && state.Messages[i+1].Role != llms.ChatMessageTypeHuman {
+			for next < len(state.Handoffs) && state.Handoffs[next].ToolCallID == "" {
+				handoff(next, llms.ChatMessageTypeAI)
+			}
+		}
+	}
+	for i := next; i < len(state.Handoffs); i++ {
+		if state.Handoffs[i].ToolCallID == "" {
+			handoff(i, llms.ChatMessageTypeAI)
+		}
+	}
+	return t
+}
+
+// Author names who wrote the entry.
+func (e transcriptEntry) Author() string {
+	switch {
+	case e.Role == llms.ChatMessageTypeHuman:
+		return "User"
+	case e.Role == llms.ChatMessageTypeSystem:
+		return "System"
+	case e.Agent != "":
+		return e.Agent
+	default:
+		return "Assistant"
+	}
+}
+
+// markdown renders the transcript as Markdown.
+func (t transcript) markdown() string {
+	var b strings.Builder
+	b.WriteString("# Conversation transcript\n")
+	for _, entry := range t.Entries {
+		b.WriteString("\n")
+		switch entry.Kind {
+		case "message":
+			fmt.Fprintf(&b, "**%s:** %s\n", entry.Author(), entry.Text)
+			for _, a := range entry.Attachments {
+				fmt.Fprintf(&b, "\n%s\n", a.markdown())
+			}
+		case "tool_call":
+			fmt.Fprintf(&b, "**%s** called `%s`:\n\n%s", entry.Author(), entry.Tool, markdownFence(entry.Arguments, "json"))
+		case "tool_result":
+			fmt.Fprintf(&b, "Result of `%s`:\n\n%s", entry.Tool, markdownFence(entry.Text, ""))
+		case "handoff":
+			fmt.Fprintf(&b, "> Handoff from **%s** to **%s**", entry.Handoff.From, entry.Handoff.To)
+			if entry.Handoff.Reason != "" {
+				fmt.Fprintf(&b, ": %s", entry.Handoff.Reason)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if t.ActiveAgent != "" {
+		fmt.Fprintf(&b, "\n---\n\nActive agent: **%s**\n", t.ActiveAgent)
+	}
+	return b.String()
+}
+
+// markdownFence wraps text in a fenced code block whose fence is longer
+// than any run of backticks in text.
+func markdownFence(text, lang string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, lang, text, fence)
+}
+
+// transcriptPage renders a transcript as an HTML page.
+var transcriptPage = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conversation transcript</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 2em auto; }
+.entry { margin: 1em 0; }
+.author { font-weight: bold; }
+.human { background: #eef4ff; padding: 0.5em; border-radius: 4px; }
+.handoff { color: #666; font-style: italic; border-left: 3px solid #ccc; padding-left: 0.5em; }
+pre { background: #f6f6f6; padding: 0.5em; white-space: pre-wrap; }
+.entry img { display: block; max-width: 100%; margin-top: 0.5em; }
+.attachment { display: block; color: #666; }
+</style>
+</head>
+<body>
+<h1>Conversation transcript</h1>
+{{- range .Entries}}
+{{- if eq .Kind "message"}}
+<div class="entry {{.Role}}"><span class="author">{{.Author}}:</span> {{.Text}}
+{{- range $a := .Attachments}}{{with $a.Src}}<img src="{{.}}" alt="image">{{else}}<span class="attachment">{{$a}}</span>{{end}}{{end}}</div>
+{{- else if eq .Kind "tool_call"}}
+<div class="entry tool-call"><span class="author">{{.Author}}</span> called <code>{{.Tool}}</code>:<pre>{{.Arguments}}</pre></div>
+{{- else if eq .Kind "tool_result"}}
+<div class="entry tool-result">Result of <code>{{.Tool}}</code>:<pre>{{.Text}}</pre></div>
+{{- else if eq .Kind "handoff"}}
+<div class="entry handoff">Handoff from <b>{{.Handoff.From}}</b> to <b>{{.Handoff.To}}</b>{{with .Handoff.Reason}}: {{.}}{{end}}</div>
+{{- end}}
+{{- end}}
+{{- with .ActiveAgent}}
+<hr>
+<p>Active agent: <b>{{.}}</b></p>
+{{- end}}
+</body>
+</html>
+`))
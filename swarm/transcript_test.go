@@ -0,0 +1,103 @@
+package swarm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// transcriptState is a two-turn conversation: Alice looks up an order and
+// hands off to Bob, then a router hands the second turn back to Alice.
+func transcriptState() SwarmState {
+	return SwarmState{
+		ActiveAgent: "Alice",
+		Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Where is order 42?"),
+			{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{toolCall("call_1", "lookup", `{"order":"42"}`)}},
+			{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+				llms.ToolCallResponse{ToolCallID: "call_1", Name: "lookup", Content: "shipped <today>"},
+			}},
+			{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{toolCall("call_2", "transfer_to_bob", `{}`)}},
+			{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+				llms.ToolCallResponse{ToolCallID: "call_2", Name: "transfer_to_bob", Content: "Successfully transferred to Bob"},
+			}},
+			llms.TextParts(llms.ChatMessageTypeAI, "It shipped today."),
+			llms.TextParts(llms.ChatMessageTypeHuman, "Thanks"),
+			llms.TextParts(llms.ChatMessageTypeAI, "You're welcome!"),
+		},
+		Handoffs: []HandoffRecord{
+			{From: "Alice", To: "Bob", Reason: "shipping question", ToolCallID: "call_2"},
+			{From: "Bob", To: "Alice"},
+		},
+	}
+}
+
+func toolCall(id, name, arguments string) llms.ToolCall {
+	return llms.ToolCall{ID: id, Type: "function", FunctionCall: &llms.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+func TestExportTranscriptMarkdown(t *testing.T) {
+	md, err := ExportTranscript(transcriptState(), TranscriptMarkdown)
+	if err != nil {
+		t.Fatalf("ExportTranscript() error = %v", err)
+	}
+	want := "# Conversation transcript\n" +
+		"\n**User:** Where is order 42?\n" +
+		"\n**Alice** called `lookup`:\n\n```json\n{\"order\":\"42\"}\n```\n" +
+		"\nResult of `lookup`:\n\n```\nshipped <today>\n```\n" +
+		"\n> Handoff from **Alice** to **Bob**: shipping question\n" +
+		"\n**Bob:** It shipped today.\n" +
+		"\n**User:** Thanks\n" +
+		"\n> Handoff from **Bob** to **Alice**\n" +
+		"\n**Alice:** You're welcome!\n" +
+		"\n---\n\nActive agent: **Alice**\n"
+	if md != want {
+		t.Errorf("markdown =\n%s\nwant\n%s", md, want)
+	}
+}
+
+func TestExportTranscriptHTMLAndJSON(t *testing.T) {
+	page, err := ExportTranscript(transcriptState(), TranscriptHTML)
+	if err != nil {
+		t.Fatalf("ExportTranscript(html) error = %v", err)
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"shipped &lt;today&gt;",
+		"Handoff from <b>Alice</b> to <b>Bob</b>: shipping question",
+		`<span class="author">Bob:</span> It shipped today.`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML transcript misses %q:\n%s", want, page)
+		}
+	}
+
+	data, err := ExportTranscript(transcriptState(), TranscriptJSON)
+	if err != nil {
+		t.Fatalf("ExportTranscript(json) error = %v", err)
+	}
+	var decoded struct {
+		ActiveAgent string `json:"active_agent"`
+		Entries     []struct {
+			Kind  string `json:"kind"`
+			Agent string `json:"agent"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("invalid JSON transcript: %v", err)
+	}
+	var kinds []string
+	for _, entry := range decoded.Entries {
+		kinds = append(kinds, entry.Kind+":"+entry.Agent)
+	}
+	want := "message: tool_call:Alice tool_result:Alice handoff:Alice message:Bob message: handoff:Bob message:Alice"
+	if strings.Join(kinds, " ") != want {
+		t.Errorf("entries = %s, want %s", strings.Join(kinds, " "), want)
+	}
+
+	if _, err := ExportTranscript(transcriptState(), "pdf"); err == nil {
+		t.Error("ExportTranscript(pdf) succeeded, want an error")
+	}
+}
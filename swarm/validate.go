@@ -0,0 +1,211 @@
+package swarm
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ValidationReport describes the problems of a swarm's topology found by
+// Swarm.Validate. A swarm with problems still compiles and runs; the report
+// tells where conversations can get stuck or agents can never be used.
+type ValidationReport struct {
+	// Unreachable lists the agents no turn can reach: no router starts with
+	// them and no reachable agent hands off to them
+	Unreachable []string
+	// OneWay lists the handoffs after which the conversation can never
+	// return to the agent that made them. As the next turn starts with the
+	// agent handed off to, the conversation is trapped past them. They are
+	// not reported when a Router picks the agent of every turn.
+	OneWay []OneWayHandoff
+	// NoExit lists the agents from which every path keeps handing off, such
+	// as pipeline stages handing off in a loop: a turn reaching them never
+	// ends before the recursion limit
+	NoExit []string
+	// Router lists the problems of the router wiring, such as a Router
+	// starting turns with an agent the swarm does not have
+	Router []error
+}
+
+// OneWayHandoff is a handoff, or a fallback, from which no path leads back.
+type OneWayHandoff struct {
+	From string
+	To   string
+}
+
+// OK reports whether the report found no problem.
+func (r *ValidationReport) OK() bool {
+	return len(r.Unreachable) == 0 && len(r.OneWay) == 0 && len(r.NoExit) == 0 && len(r.Router) == 0
+}
+
+// Err returns a *ConfigError listing every problem of the report, or nil
+// when there is none.
+func (r *ValidationReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	var problems []error
+	for _, agent := range r.Unreachable {
+		problems = append(problems, fmt.Errorf("agent '%s' is unreachable", agent))
+	}
+	for _, handoff := range r.OneWay {
+		problems = append(problems, fmt.Errorf("agent '%s' hands off to '%s' with no way back", handoff.From, handoff.To))
+	}
+	for _, agent := range r.NoExit {
+		problems = append(problems, fmt.Errorf("agent '%s' never ends a turn", agent))
+	}
+	problems = append(problems, r.Router...)
+	return &ConfigError{Problems: problems}
+}
+
+// Validate checks the topology of the swarm before it is compiled: agents
+// no turn can reach, handoffs with no path back, agents from which no turn
+// can end, and routers wired to unknown agents.
+//
+// Handoffs are known from each agent's Destinations, Fallback and handoff
+// tools (its own, for a ReactAgent or ToolNode, and Agent.Tools). An agent
+// whose runnable cannot be inspected and declares no Destinations is
+// assumed to hand off to any agent, and a Router other than an LLMRouter
+// is assumed to start turns with any agent, so the report errs on the side
+// of finding no problem.
+//
+// Example:
+//
+//	if err := workflow.Validate().Err(); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *Swarm) Validate() *ValidationReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	config := s.config
+	report := &ValidationReport{}
+	known := func(name string) bool { _, ok := s.agents[name]; return ok }
+
+	// The agents turns start with; a Router that keeps the active agent
+	// leaves the conversation where handoffs took it
+	entries := []string{config.DefaultActiveAgent}
+	keepsActive := true
+	switch router := config.Router.(type) {
+	case nil:
+	case *LLMRouter:
+		for _, agent := range router.agents {
+			if !known(agent.Name) {
+				report.Router = append(report.Router, fmt.Errorf("%w: llm router chooses '%s'", ErrUnknownAgent, agent.Name))
+				continue
+			}
+			entries = append(entries, agent.Name)
+		}
+	case startRouter:
+		if !known(string(router)) {
+			report.Router = append(report.Router, fmt.Errorf("%w: router starts with '%s'", ErrUnknownAgent, string(router)))
+		}
+		entries, keepsActive = []string{string(router)}, false
+	default:
+		entries, keepsActive = slices.Clone(s.agentNames), false
+	}
+	if m := config.Moderation; m != nil && m.EscalateTo != "" {
+		entries = append(entries, m.EscalateTo)
+	}
+
+	edges := make(map[string][]string, len(config.Agents))
+	for _, agent := range config.Agents {
+		edges[agent.Name] = s.handoffs(agent)
+	}
+	reachable := func(from ...string) map[string]bool {
+		seen := make(map[string]bool)
+		queue := slices.Clone(from)
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if seen[name] || !known(name) {
+				continue
+			}
+			seen[name] = true
+			queue = append(queue, edges[name]...)
+		}
+		return seen
+	}
+
+	fromEntries := reachable(entries...)
+	for _, name := range s.agentNames {
+		if !fromEntries[name] {
+			report.Unreachable = append(report.Unreachable, name)
+		}
+	}
+
+	for _, agent := range config.Agents {
+		fromAgent := reachable(agent.Name)
+		if !slices.ContainsFunc(config.Agents, func(other Agent) bool { return fromAgent[other.Name] && !alwaysHandsOff(other) }) {
+			report.NoExit = append(report.NoExit, agent.Name)
+		}
+		if !keepsActive {
+			continue
+		}
+		for _, to := range edges[agent.Name] {
+			if known(to) && to != agent.Name && !reachable(to)[agent.Name] {
+				report.OneWay = append(report.OneWay, OneWayHandoff{From: agent.Name, To: to})
+			}
+		}
+	}
+	return report
+}
+
+// handoffs returns the agents agent may hand off to, or fall back to. An
+// agent whose handoffs cannot be known may hand off to any agent of s.
+func (s *Swarm) handoffs(agent Agent) []string {
+	targets := slices.Clone(agent.Destinations)
+	if agent.Fallback != "" {
+		targets = append(targets, agent.Fallback)
+	}
+
+	agentTools := slices.Clone(agent.Tools)
+	inspected := true
+	switch runnable := agent.Runnable.(type) {
+	case *ReactAgent:
+		agentTools = append(agentTools, toolNodeTools(runnable.toolNode)...)
+	case *ToolNode:
+		agentTools = append(agentTools, toolNodeTools(runnable)...)
+	case presetRunnable:
+		// Preset stages hand off to their Destinations
+	case HandoffDestinationsProvider:
+		targets = append(targets, runnable.HandoffDestinations()...)
+	default:
+		inspected = false
+	}
+
+	open := !inspected && len(agent.Destinations) == 0
+	for _, t := range agentTools {
+		switch h := t.(type) {
+		case HandoffTool:
+			targets = append(targets, h.HandoffDestination())
+		case *dynamicHandoffTool:
+			targets = append(targets, h.agents...)
+			// Without agents of its own, the tool transfers to the agent's
+			// Destinations, or to any agent
+			open = open || len(h.agents) == 0 && len(agent.Destinations) == 0
+		}
+	}
+	if open {
+		targets = append(targets, s.agentNames...)
+	}
+	slices.Sort(targets)
+	return slices.Compact(targets)
+}
+
+// toolNodeTools returns the tools of n.
+func toolNodeTools(n *ToolNode) []tools.Tool {
+	nodeTools := make([]tools.Tool, 0, len(n.tools))
+	for _, t := range n.tools {
+		nodeTools = append(nodeTools, t)
+	}
+	return nodeTools
+}
+
+// alwaysHandsOff reports whether agent hands off at the end of every run,
+// so that it never ends a turn itself.
+func alwaysHandsOff(agent Agent) bool {
+	_, ok := agent.Runnable.(presetRunnable)
+	return ok
+}
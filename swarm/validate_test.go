@@ -0,0 +1,140 @@
+package swarm
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+// transferAgent returns a ReactAgent whose only tools hand off to targets.
+func transferAgent(t *testing.T, targets ...string) *ReactAgent {
+	t.Helper()
+	var handoffs []tools.Tool
+	for _, target := range targets {
+		handoffs = append(handoffs, CreateHandoffTool(HandoffToolConfig{AgentName: target}))
+	}
+	agent, err := CreateReactAgent(&scriptedModel{}, handoffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return agent
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SwarmConfig
+		want    ValidationReport
+		wantErr error
+	}{
+		{
+			name: "triage",
+			config: NewTriage(
+				Agent{Name: "Triage", Runnable: transferAgent(t)},
+				Agent{Name: "Billing", Runnable: transferAgent(t)},
+				Agent{Name: "Technical", Runnable: transferAgent(t)},
+			),
+		},
+		{
+			name: "unreachable and one-way",
+			config: SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: transferAgent(t, "Bob")},
+					{Name: "Bob", Runnable: transferAgent(t)},
+					{Name: "Carol", Runnable: transferAgent(t, "Alice")},
+				},
+				DefaultActiveAgent: "Alice",
+			},
+			want: ValidationReport{
+				Unreachable: []string{"Carol"},
+				OneWay:      []OneWayHandoff{{From: "Alice", To: "Bob"}, {From: "Carol", To: "Alice"}},
+			},
+		},
+		{
+			name: "agents that cannot be inspected hand off anywhere",
+			config: SwarmConfig{
+				Agents: []Agent{
+					{Name: "Alice", Runnable: createMockAgent("Alice", "Hi")},
+					{Name: "Bob", Runnable: createMockAgent("Bob", "Hi")},
+				},
+				DefaultActiveAgent: "Alice",
+			},
+		},
+		{
+			name: "pipeline in a loop",
+			config: func() SwarmConfig {
+				config := NewPipeline(
+					Agent{Name: "Researcher", Runnable: transferAgent(t)},
+					Agent{Name: "Writer", Runnable: transferAgent(t)},
+				)
+				config.Agents[1].Runnable = handOffAfter(config.Agents[1].Runnable, func(SwarmState) string { return "Researcher" })
+				config.Agents[1].Destinations = []string{"Researcher"}
+				return config
+			}(),
+			want: ValidationReport{NoExit: []string{"Researcher", "Writer"}},
+		},
+		{
+			name: "router wiring",
+			config: SwarmConfig{
+				Agents:             []Agent{{Name: "Alice", Runnable: transferAgent(t)}},
+				DefaultActiveAgent: "Alice",
+				Router:             CreateLLMRouter(&scriptedModel{}, []Agent{{Name: "Alice"}, {Name: "Mallory"}}),
+			},
+			want:    ValidationReport{Router: []error{ErrUnknownAgent}},
+			wantErr: ErrUnknownAgent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow, err := CreateSwarm(tt.config)
+			if err != nil {
+				t.Fatalf("CreateSwarm() error = %v", err)
+			}
+			report := workflow.Validate()
+			if !slices.Equal(report.Unreachable, tt.want.Unreachable) {
+				t.Errorf("Unreachable = %v, want %v", report.Unreachable, tt.want.Unreachable)
+			}
+			if !slices.Equal(report.OneWay, tt.want.OneWay) {
+				t.Errorf("OneWay = %v, want %v", report.OneWay, tt.want.OneWay)
+			}
+			if !slices.Equal(report.NoExit, tt.want.NoExit) {
+				t.Errorf("NoExit = %v, want %v", report.NoExit, tt.want.NoExit)
+			}
+			if len(report.Router) != len(tt.want.Router) {
+				t.Errorf("Router = %v, want %v", report.Router, tt.want.Router)
+			}
+
+			err = report.Err()
+			wantOK := tt.want.Unreachable == nil && tt.want.OneWay == nil && tt.want.NoExit == nil && tt.want.Router == nil
+			if report.OK() != wantOK || (err == nil) != wantOK {
+				t.Errorf("OK() = %v, Err() = %v", report.OK(), err)
+			}
+			var configErr *ConfigError
+			if err != nil && !errors.As(err, &configErr) {
+				t.Errorf("Err() = %v, want a *ConfigError", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAfterAddAgent(t *testing.T) {
+	workflow, err := CreateSwarm(SwarmConfig{
+		Agents:             []Agent{{Name: "Alice", Runnable: transferAgent(t)}},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := workflow.AddAgent(t.Context(), Agent{Name: "Bob", Runnable: transferAgent(t, "Alice")}); err != nil {
+		t.Fatal(err)
+	}
+	report := workflow.Validate()
+	if !slices.Equal(report.Unreachable, []string{"Bob"}) {
+		t.Errorf("Unreachable = %v, want Bob", report.Unreachable)
+	}
+}
@@ -0,0 +1,332 @@
+// Package vcr records the model responses and tool outputs of swarm runs in
+// a cassette file and replays them, so integration tests run without API
+// keys and give the same results every time.
+//
+// Wrap the models and tools of the swarm under test with a Recorder. Record
+// the cassette once against the real model, commit it, and the test replays
+// it from then on:
+//
+//	rec, err := vcr.Open("testdata/refund.json", vcr.ModeFromEnv())
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer func() {
+//	    if err := rec.Close(); err != nil {
+//	        t.Error(err)
+//	    }
+//	}()
+//
+//	var model llms.Model
+//	if rec.Recording() {
+//	    model, _ = openai.New() // only needed while recording
+//	}
+//	agent, err := swarm.CreateReactAgent(rec.Model("default", model),
+//	    []tools.Tool{rec.Tool(refundTool)})
+//
+// Run the test with SWARM_VCR=record to record the cassette again, e.g.
+// after changing a prompt.
+package vcr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// EnvMode is the environment variable read by ModeFromEnv.
+const EnvMode = "SWARM_VCR"
+
+// ErrNoInteraction is matched (with errors.Is) by the error returned in
+// ModeReplay for a model call or tool call that the cassette does not hold.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay answers model and tool calls from the cassette, without
+	// calling the wrapped models and tools.
+	ModeReplay Mode = iota
+	// ModeRecord calls the wrapped models and tools and saves their answers
+	// to the cassette on Close, replacing its contents.
+	ModeRecord
+)
+
+// ModeFromEnv returns ModeRecord if the SWARM_VCR environment variable is
+// "record", and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if os.Getenv(EnvMode) == "record" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded model or tool call.
+type Interaction struct {
+	// Kind is "model" or "tool"
+	Kind string `json:"kind"`
+	// Name is the name the model was wrapped with, or the tool's name
+	Name string `json:"name"`
+	// Request is the conversation sent to a model (encoded with
+	// swarm.MarshalState), or the input of a tool, as a JSON string
+	Request json.RawMessage `json:"request"`
+	// Tools are the tools offered to a model
+	Tools []string `json:"tools,omitempty"`
+	// Response is the model's response or the tool's output
+	Response json.RawMessage `json:"response,omitempty"`
+	// Error is the error of the call, if it failed
+	Error string `json:"error,omitempty"`
+
+	// used is set once the interaction was replayed
+	used bool
+}
+
+// Recorder wraps models and tools to record or replay their calls. It is
+// safe for concurrent use.
+type Recorder struct {
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// Open creates a Recorder for the cassette at path. In ModeReplay the
+// cassette must exist.
+func Open(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("cassette %s does not exist; record it with %s=record", path, EnvMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Recording reports whether the Recorder records, that is, whether the
+// wrapped models and tools are called.
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Close saves the recorded interactions in ModeRecord, creating the
+// cassette's directory if needed. In ModeReplay it does nothing.
+func (r *Recorder) Close() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Model wraps model. name tells the models of a swarm apart in the cassette;
+// in ModeReplay model is not called and may be nil.
+func (r *Recorder) Model(name string, model llms.Model) llms.Model {
+	return &recordedModel{recorder: r, name: name, model: model}
+}
+
+// Tool wraps t. In ModeReplay its Call is not executed; only its name,
+// description and schema (see swarm.SchemaProvider) are used. Handoff tools
+// are returned unwrapped, since the swarm relies on them running.
+func (r *Recorder) Tool(t tools.Tool) tools.Tool {
+	if _, ok := t.(swarm.HandoffTool); ok {
+		return t
+	}
+	wrapped := &recordedTool{recorder: r, tool: t}
+	if _, ok := t.(swarm.SchemaProvider); ok {
+		return &recordedSchemaTool{wrapped}
+	}
+	return wrapped
+}
+
+// record calls fn in ModeRecord and saves the interaction, or returns the
+// response of the first unused matching interaction in ModeReplay.
+func (r *Recorder) record(call Interaction, fn func() (any, error)) (json.RawMessage, error) {
+	if r.mode == ModeRecord {
+		response, err := fn()
+		if err != nil {
+			call.Error = err.Error()
+		} else if call.Response, err = json.Marshal(response); err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		r.cassette.Interactions = append(r.cassette.Interactions, call)
+		r.mu.Unlock()
+		return call.Response, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.cassette.Interactions {
+		recorded := &r.cassette.Interactions[i]
+		if recorded.used || recorded.Kind != call.Kind || recorded.Name != call.Name ||
+			!slices.Equal(recorded.Tools, call.Tools) || !sameJSON(recorded.Request, call.Request) {
+			continue
+		}
+		recorded.used = true
+		if recorded.Error != "" {
+			return nil, errors.New(recorded.Error)
+		}
+		return recorded.Response, nil
+	}
+	return nil, fmt.Errorf("%w for %s '%s' in %s; the request changed since it was recorded, record it again with %s=record",
+		ErrNoInteraction, call.Kind, call.Name, r.path, EnvMode)
+}
+
+// sameJSON reports whether a and b encode the same JSON, ignoring
+// whitespace.
+func sameJSON(a, b json.RawMessage) bool {
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return false
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
+
+// recordedModel is a model wrapped by a Recorder.
+type recordedModel struct {
+	recorder *Recorder
+	name     string
+	model    llms.Model
+}
+
+// GenerateContent records or replays a model call. Replayed responses are
+// streamed to the call's streaming function, if any.
+func (m *recordedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	request, err := swarm.MarshalState(swarm.SwarmState{Messages: messages})
+	if err != nil {
+		return nil, err
+	}
+	call := Interaction{Kind: "model", Name: m.name, Request: request}
+	for _, t := range opts.Tools {
+		if t.Function != nil {
+			call.Tools = append(call.Tools, t.Function.Name)
+		}
+	}
+
+	data, err := m.recorder.record(call, func() (any, error) {
+		if m.model == nil {
+			return nil, fmt.Errorf("vcr: model '%s' is nil", m.name)
+		}
+		return m.model.GenerateContent(ctx, messages, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var response llms.ContentResponse
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err != nil {
+		return nil, fmt.Errorf("vcr: invalid response of model '%s': %w", m.name, err)
+	}
+	for _, choice := range response.Choices {
+		restoreNumbers(choice.GenerationInfo)
+	}
+	if !m.recorder.Recording() && opts.StreamingFunc != nil {
+		for _, choice := range response.Choices {
+			if choice.Content == "" {
+				continue
+			}
+			if err := opts.StreamingFunc(ctx, []byte(choice.Content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &response, nil
+}
+
+// Call implements llms.Model.
+func (m *recordedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// restoreNumbers converts the numbers of info decoded from JSON back to int
+// when they are whole, like the token counts models report, and to float64
+// otherwise.
+func restoreNumbers(info map[string]any) {
+	for key, value := range info {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		if i, err := number.Int64(); err == nil {
+			info[key] = int(i)
+		} else if f, err := number.Float64(); err == nil {
+			info[key] = f
+		}
+	}
+}
+
+// recordedTool is a tool wrapped by a Recorder.
+type recordedTool struct {
+	recorder *Recorder
+	tool     tools.Tool
+}
+
+func (t *recordedTool) Name() string        { return t.tool.Name() }
+func (t *recordedTool) Description() string { return t.tool.Description() }
+
+// Call records or replays a tool call.
+func (t *recordedTool) Call(ctx context.Context, input string) (string, error) {
+	request, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	data, err := t.recorder.record(Interaction{Kind: "tool", Name: t.tool.Name(), Request: request}, func() (any, error) {
+		return t.tool.Call(ctx, input)
+	})
+	if err != nil {
+		return "", err
+	}
+	var output string
+	if err := json.Unmarshal(data, &output); err != nil {
+		return "", fmt.Errorf("vcr: invalid output of tool '%s': %w", t.tool.Name(), err)
+	}
+	return output, nil
+}
+
+// recordedSchemaTool is a wrapped tool describing its arguments with a
+// JSON schema.
+type recordedSchemaTool struct {
+	*recordedTool
+}
+
+// Schema returns the schema of the wrapped tool.
+func (t *recordedSchemaTool) Schema() map[string]any {
+	return t.tool.(swarm.SchemaProvider).Schema()
+}
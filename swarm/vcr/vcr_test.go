@@ -0,0 +1,134 @@
+package vcr
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+type lookupArgs struct {
+	Order string `json:"order"`
+}
+
+// run runs a one-agent swarm with model and a lookup tool counting its calls.
+func run(t *testing.T, rec *Recorder, model llms.Model, prompt string, lookups *int) (*swarm.InvokeResult, error) {
+	t.Helper()
+	lookup := swarm.NewStructTool("lookup", "Look up an order", func(ctx context.Context, args lookupArgs) (string, error) {
+		*lookups++
+		return "order " + args.Order + " shipped", nil
+	})
+	agent, err := swarm.CreateReactAgent(rec.Model("default", model), []tools.Tool{rec.Tool(lookup)},
+		swarm.WithSystemPrompt(prompt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := swarm.CreateSwarm(swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Alice", Runnable: agent}},
+		DefaultActiveAgent: "Alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := workflow.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app.InvokeWithResult(context.Background(), swarm.SwarmState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Where is order 42?")},
+	})
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "order.json")
+	model := swarmtest.NewMockModel(
+		swarmtest.ToolCall("lookup", `{"order":"42"}`),
+		swarmtest.WithUsage(swarmtest.Text("Order 42 has shipped."), 10, 2),
+	)
+
+	rec, err := Open(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lookups int
+	recorded, err := run(t, rec, model, "You are Alice.", &lookups)
+	if err != nil {
+		t.Fatalf("recording run error = %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	rec, err = Open(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	lookups = 0
+	replayed, err := run(t, rec, nil, "You are Alice.", &lookups)
+	if err != nil {
+		t.Fatalf("replaying run error = %v", err)
+	}
+	if lookups != 0 {
+		t.Errorf("tool executed %d times during replay", lookups)
+	}
+	want, _ := swarm.MarshalState(recorded.State)
+	got, _ := swarm.MarshalState(replayed.State)
+	if string(got) != string(want) {
+		t.Errorf("replayed state = %s, want %s", got, want)
+	}
+	if recorded.Usage.TotalTokens() != 12 || replayed.Usage != recorded.Usage {
+		t.Errorf("replayed usage = %+v, recorded usage = %+v, want 12 tokens", replayed.Usage, recorded.Usage)
+	}
+
+	// A changed prompt changes the model request
+	rec, _ = Open(path, ModeReplay)
+	if _, err := run(t, rec, nil, "You are Bob.", &lookups); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("run with a changed prompt error = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestReplayedGenerationInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, _ := Open(path, ModeRecord)
+	model := swarmtest.NewMockModel(
+		&llms.ContentChoice{Content: "Hi", GenerationInfo: map[string]any{"TotalTokens": 12, "Score": 0.5}},
+	)
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")}
+	if _, err := rec.Model("default", model).GenerateContent(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, _ = Open(path, ModeReplay)
+	var streamed strings.Builder
+	response, err := rec.Model("default", nil).GenerateContent(context.Background(), messages,
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed.Write(chunk)
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("GenerateContent() error = %v", err)
+	}
+	info := response.Choices[0].GenerationInfo
+	if info["TotalTokens"] != 12 || info["Score"] != 0.5 {
+		t.Errorf("GenerationInfo = %#v", info)
+	}
+	if streamed.String() != "Hi" {
+		t.Errorf("streamed %q, want the replayed content", streamed.String())
+	}
+}
+
+func TestOpenMissingCassette(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	if err == nil || !strings.Contains(err.Error(), "SWARM_VCR=record") {
+		t.Errorf("Open() error = %v, want a hint to record the cassette", err)
+	}
+}
@@ -0,0 +1,94 @@
+package swarm
+
+import (
+	"slices"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// MessageVisibility controls how an agent's messages are shared with the
+// rest of the swarm.
+type MessageVisibility int
+
+const (
+	// SharedAll appends every message the agent produces, including tool
+	// calls and tool results, to the shared conversation. This is the default.
+	SharedAll MessageVisibility = iota
+
+	// SharedFinalOnly keeps the agent's intermediate messages in its private
+	// scratchpad (SwarmState.PrivateMessages) and only appends the text of its
+	// final response to the shared conversation.
+	SharedFinalOnly
+)
+
+// String returns the name of the visibility mode.
+func (v MessageVisibility) String() string {
+	switch v {
+	case SharedAll:
+		return "SharedAll"
+	case SharedFinalOnly:
+		return "SharedFinalOnly"
+	default:
+		return "MessageVisibility(unknown)"
+	}
+}
+
+// applyVisibility merges the messages an agent added during its run into the
+// shared conversation according to the agent's MessageVisibility.
+//
+// Args:
+//   - agent: The agent that ran
+//   - before: The state passed to the agent
+//   - after: The state returned by the agent
+//
+// Returns:
+//   - The state to continue the swarm with
+func applyVisibility(agent Agent, before, after SwarmState) SwarmState {
+	if agent.MessageVisibility != SharedFinalOnly {
+		return after
+	}
+
+	// The agent rewrote history rather than appending to it; keep its result.
+	if len(after.Messages) < len(before.Messages) {
+		return after
+	}
+
+	added := after.Messages[len(before.Messages):]
+	if len(added) == 0 {
+		return after
+	}
+
+	private := make(map[string][]llms.MessageContent, len(after.PrivateMessages)+1)
+	for name, messages := range after.PrivateMessages {
+		private[name] = messages
+	}
+	private[agent.Name] = append(slices.Clone(private[agent.Name]), added...)
+	after.PrivateMessages = private
+
+	shared := slices.Clone(after.Messages[:len(before.Messages)])
+	if final, ok := finalResponse(added); ok {
+		shared = append(shared, final)
+	}
+	after.Messages = shared
+
+	return after
+}
+
+// finalResponse returns the text of the last AI message among messages,
+// stripped of tool calls so it can stand alone in the shared conversation.
+func finalResponse(messages []llms.MessageContent) (llms.MessageContent, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != llms.ChatMessageTypeAI {
+			continue
+		}
+
+		final := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+		for _, part := range messages[i].Parts {
+			if _, isToolCall := part.(llms.ToolCall); !isToolCall {
+				final.Parts = append(final.Parts, part)
+			}
+		}
+		return final, len(final.Parts) > 0
+	}
+	return llms.MessageContent{}, false
+}
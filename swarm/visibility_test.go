@@ -0,0 +1,72 @@
+package swarm
+
+import (
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestApplyVisibility(t *testing.T) {
+	user := llms.TextParts(llms.ChatMessageTypeHuman, "shout hello")
+	toolCall := aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "upper"}})
+	toolResult := llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call_1", Name: "upper", Content: "HELLO"}},
+	}
+	answer := llms.TextParts(llms.ChatMessageTypeAI, "HELLO")
+
+	before := SwarmState{Messages: []llms.MessageContent{user}}
+	after := SwarmState{Messages: []llms.MessageContent{user, toolCall, toolResult, answer}}
+
+	tests := []struct {
+		name         string
+		visibility   MessageVisibility
+		wantShared   int
+		wantPrivate  int
+		wantLastText string
+	}{
+		{"shared all", SharedAll, 4, 0, "HELLO"},
+		{"shared final only", SharedFinalOnly, 2, 3, "HELLO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := Agent{Name: "Alice", MessageVisibility: tt.visibility}
+			result := applyVisibility(agent, before, after)
+
+			if len(result.Messages) != tt.wantShared {
+				t.Errorf("Expected %d shared messages, got %d", tt.wantShared, len(result.Messages))
+			}
+			if got := len(result.PrivateMessages["Alice"]); got != tt.wantPrivate {
+				t.Errorf("Expected %d private messages, got %d", tt.wantPrivate, got)
+			}
+			last := result.Messages[len(result.Messages)-1]
+			if text, ok := last.Parts[0].(llms.TextContent); !ok || text.Text != tt.wantLastText {
+				t.Errorf("Unexpected last shared message %v", last)
+			}
+		})
+	}
+}
+
+func TestApplyVisibilityHandoffSharesNothing(t *testing.T) {
+	user := llms.TextParts(llms.ChatMessageTypeHuman, "talk to Bob")
+	handoffCall := aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "transfer_to_bob"}})
+	handoffResult := llms.MessageContent{
+		Role:  llms.ChatMessageTypeTool,
+		Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call_1", Name: "transfer_to_bob", Content: "ok"}},
+	}
+
+	before := SwarmState{Messages: []llms.MessageContent{user}}
+	after := SwarmState{Messages: []llms.MessageContent{user, handoffCall, handoffResult}, ActiveAgent: "Bob"}
+
+	result := applyVisibility(Agent{Name: "Alice", MessageVisibility: SharedFinalOnly}, before, after)
+	if len(result.Messages) != 1 {
+		t.Errorf("Expected only the user message to be shared, got %d messages", len(result.Messages))
+	}
+	if result.ActiveAgent != "Bob" {
+		t.Errorf("Expected active agent Bob, got %q", result.ActiveAgent)
+	}
+	if len(result.PrivateMessages["Alice"]) != 2 {
+		t.Errorf("Expected 2 private messages, got %d", len(result.PrivateMessages["Alice"]))
+	}
+}
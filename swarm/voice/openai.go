@@ -0,0 +1,227 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultOpenAIEndpoint is the OpenAI API.
+	DefaultOpenAIEndpoint = "https://api.openai.com/v1"
+
+	defaultTranscriptionModel = "gpt-4o-mini-transcribe"
+	defaultSpeechModel        = "gpt-4o-mini-tts"
+	defaultVoice              = "alloy"
+	// maxErrorBody is the number of bytes of an error response quoted in
+	// an error.
+	maxErrorBody = 512
+)
+
+// speechFormats maps the response formats of the speech API to their MIME
+// types.
+var speechFormats = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+}
+
+// OpenAI transcribes and synthesizes speech with the OpenAI audio API.
+type OpenAI struct {
+	apiKey             string
+	endpoint           string
+	client             *http.Client
+	transcriptionModel string
+	speechModel        string
+	voice              string
+	format             string
+	language           string
+}
+
+// Option configures an OpenAI provider.
+type Option func(*OpenAI)
+
+// WithEndpoint sends requests to endpoint, such as an OpenAI-compatible
+// server, instead of the OpenAI API.
+func WithEndpoint(endpoint string) Option {
+	return func(o *OpenAI) {
+		o.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithHTTPClient sends requests with client (default: http.DefaultClient).
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *OpenAI) {
+		o.client = client
+	}
+}
+
+// WithTranscriptionModel sets the speech-to-text model (default:
+// gpt-4o-mini-transcribe).
+func WithTranscriptionModel(model string) Option {
+	return func(o *OpenAI) {
+		o.transcriptionModel = model
+	}
+}
+
+// WithSpeechModel sets the text-to-speech model (default: gpt-4o-mini-tts).
+func WithSpeechModel(model string) Option {
+	return func(o *OpenAI) {
+		o.speechModel = model
+	}
+}
+
+// WithVoice sets the voice of synthesized speech (default: alloy).
+func WithVoice(voice string) Option {
+	return func(o *OpenAI) {
+		o.voice = voice
+	}
+}
+
+// WithFormat sets the format of synthesized speech: mp3 (the default),
+// opus, aac, flac, wav or pcm.
+func WithFormat(format string) Option {
+	return func(o *OpenAI) {
+		o.format = format
+	}
+}
+
+// WithLanguage sets the ISO-639-1 language of inbound speech, which
+// improves transcription accuracy and latency.
+func WithLanguage(language string) Option {
+	return func(o *OpenAI) {
+		o.language = language
+	}
+}
+
+// NewOpenAI returns an OpenAI provider authenticated with apiKey.
+func NewOpenAI(apiKey string, opts ...Option) *OpenAI {
+	o := &OpenAI{
+		apiKey:             apiKey,
+		endpoint:           DefaultOpenAIEndpoint,
+		client:             http.DefaultClient,
+		transcriptionModel: defaultTranscriptionModel,
+		speechModel:        defaultSpeechModel,
+		voice:              defaultVoice,
+		format:             "mp3",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Transcribe returns the text of audio.
+func (o *OpenAI) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", o.transcriptionModel)
+	form.WriteField("response_format", "json")
+	if o.language != "" {
+		form.WriteField("language", o.language)
+	}
+	file, err := form.CreateFormFile("file", "audio"+extension(audio.MIMEType))
+	if err != nil {
+		return "", err
+	}
+	file.Write(audio.Data)
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := o.do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var transcription struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&transcription); err != nil {
+		return "", fmt.Errorf("transcription failed: invalid response: %w", err)
+	}
+	return transcription.Text, nil
+}
+
+// Synthesize returns text spoken in the configured voice and format.
+func (o *OpenAI) Synthesize(ctx context.Context, text string) (Audio, error) {
+	payload, err := json.Marshal(map[string]string{
+		"model":           o.speechModel,
+		"input":           text,
+		"voice":           o.voice,
+		"response_format": o.format,
+	})
+	if err != nil {
+		return Audio{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint+"/audio/speech", bytes.NewReader(payload))
+	if err != nil {
+		return Audio{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.do(req)
+	if err != nil {
+		return Audio{}, fmt.Errorf("speech synthesis failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Audio{}, fmt.Errorf("speech synthesis failed: %w", err)
+	}
+	mimeType := speechFormats[o.format]
+	if mimeType == "" {
+		mimeType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	}
+	return Audio{MIMEType: mimeType, Data: data}, nil
+}
+
+// do sends an authenticated request and returns the response, or an error
+// for a response that is not a success.
+func (o *OpenAI) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	return resp, nil
+}
+
+// extension returns the file extension of an audio MIME type, which the
+// transcription API uses to tell the format.
+func extension(mimeType string) string {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	switch mediaType {
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3"
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return ".wav"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/webm", "video/webm":
+		return ".webm"
+	case "audio/mp4", "audio/m4a", "audio/x-m4a":
+		return ".m4a"
+	case "audio/flac":
+		return ".flac"
+	}
+	return ".wav"
+}
@@ -0,0 +1,114 @@
+// Package voice puts speech in front of a swarm: inbound audio is
+// transcribed to a user message, and the final answer of each turn is
+// synthesized to audio. Providers are pluggable through the Transcriber and
+// Synthesizer interfaces; OpenAI implements both.
+//
+// Session runs voice turns over a swarm.ChatSession:
+//
+//	speech := voice.NewOpenAI(os.Getenv("OPENAI_API_KEY"))
+//	session := voice.NewSession(swarm.NewChatSession(app), speech, speech)
+//	reply, err := session.Send(ctx, voice.Audio{MIMEType: "audio/wav", Data: recording})
+//	// reply.Transcript is what the user said, reply.Audio the spoken answer
+//
+// The HTTP server accepts audio messages with server.WithVoice.
+package voice
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+)
+
+// ErrNoSpeech is returned when inbound audio transcribes to no text.
+var ErrNoSpeech = errors.New("voice: no speech in audio")
+
+// Audio is a recording in a container format such as audio/wav or
+// audio/mpeg.
+type Audio struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data"`
+}
+
+// Transcriber converts speech to text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio Audio) (string, error)
+}
+
+// TranscriberFunc is an adapter to allow the use of ordinary functions as
+// transcribers.
+type TranscriberFunc func(ctx context.Context, audio Audio) (string, error)
+
+// Transcribe calls f(ctx, audio).
+func (f TranscriberFunc) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	return f(ctx, audio)
+}
+
+// Synthesizer converts text to speech.
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string) (Audio, error)
+}
+
+// SynthesizerFunc is an adapter to allow the use of ordinary functions as
+// synthesizers.
+type SynthesizerFunc func(ctx context.Context, text string) (Audio, error)
+
+// Synthesize calls f(ctx, text).
+func (f SynthesizerFunc) Synthesize(ctx context.Context, text string) (Audio, error) {
+	return f(ctx, text)
+}
+
+// Session is a voice conversation with a swarm.
+type Session struct {
+	chat        *swarm.ChatSession
+	transcriber Transcriber
+	synthesizer Synthesizer
+}
+
+// Reply is the outcome of a voice turn.
+type Reply struct {
+	swarm.Reply
+	// Transcript is the text of the user's audio
+	Transcript string
+	// Audio is the spoken answer, empty without a synthesizer or when the
+	// turn ends without an answer
+	Audio Audio
+}
+
+// NewSession creates a voice session over chat. The synthesizer may be nil
+// to answer in text only.
+func NewSession(chat *swarm.ChatSession, transcriber Transcriber, synthesizer Synthesizer) *Session {
+	return &Session{chat: chat, transcriber: transcriber, synthesizer: synthesizer}
+}
+
+// Send transcribes audio, sends it as the user's message and speaks the
+// answer. An error from the swarm, such as an interrupt, is returned with
+// the turn so far, unspoken.
+func (s *Session) Send(ctx context.Context, audio Audio) (Reply, error) {
+	transcript, err := Transcribe(ctx, s.transcriber, audio)
+	if err != nil {
+		return Reply{}, err
+	}
+	reply := Reply{Transcript: transcript}
+	reply.Reply, err = s.chat.Send(ctx, transcript)
+	if err != nil || s.synthesizer == nil || reply.Text == "" {
+		return reply, err
+	}
+	reply.Audio, err = s.synthesizer.Synthesize(ctx, reply.Text)
+	return reply, err
+}
+
+// Transcribe returns the text of audio, trimmed, or ErrNoSpeech when there
+// is none.
+func Transcribe(ctx context.Context, transcriber Transcriber, audio Audio) (string, error) {
+	text, err := transcriber.Transcribe(ctx, audio)
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ErrNoSpeech
+	}
+	return text, nil
+}
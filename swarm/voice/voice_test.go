@@ -0,0 +1,91 @@
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-hare/langchaingo_swarm/swarm"
+	"github.com/go-hare/langchaingo_swarm/swarm/swarmtest"
+)
+
+func TestOpenAI(t *testing.T) {
+	var speech map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/audio/transcriptions":
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			if header.Filename != "audio.mp3" || r.FormValue("model") != "whisper-1" || r.FormValue("language") != "fr" {
+				http.Error(w, "bad form", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"text": string(data)})
+		case "/audio/speech":
+			json.NewDecoder(r.Body).Decode(&speech)
+			w.Write([]byte("speech:" + speech["input"]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	o := NewOpenAI("key", WithEndpoint(ts.URL+"/"), WithTranscriptionModel("whisper-1"), WithLanguage("fr"), WithVoice("nova"), WithFormat("wav"))
+	text, err := o.Transcribe(context.Background(), Audio{MIMEType: "audio/mpeg", Data: []byte("bonjour")})
+	if err != nil || text != "bonjour" {
+		t.Fatalf("Transcribe() = %q, %v", text, err)
+	}
+	audio, err := o.Synthesize(context.Background(), "hello")
+	if err != nil || string(audio.Data) != "speech:hello" || audio.MIMEType != "audio/wav" {
+		t.Fatalf("Synthesize() = %+v, %v", audio, err)
+	}
+	if speech["voice"] != "nova" || speech["model"] != defaultSpeechModel || speech["response_format"] != "wav" {
+		t.Errorf("Speech request = %v", speech)
+	}
+
+	if _, err := NewOpenAI("wrong", WithEndpoint(ts.URL)).Synthesize(context.Background(), "hello"); err == nil {
+		t.Error("Synthesize() with a wrong key should return an error")
+	}
+}
+
+func TestSession(t *testing.T) {
+	bob, err := swarm.CreateReactAgent(swarmtest.NewMockModel(swarmtest.Text("Ahoy")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := swarmtest.Compile(t, swarm.SwarmConfig{
+		Agents:             []swarm.Agent{{Name: "Bob", Runnable: bob}},
+		DefaultActiveAgent: "Bob",
+	})
+	transcriber := TranscriberFunc(func(ctx context.Context, audio Audio) (string, error) {
+		return string(audio.Data), nil
+	})
+	synthesizer := SynthesizerFunc(func(ctx context.Context, text string) (Audio, error) {
+		return Audio{MIMEType: "audio/wav", Data: []byte("spoken " + text)}, nil
+	})
+	session := NewSession(swarm.NewChatSession(app), transcriber, synthesizer)
+
+	reply, err := session.Send(context.Background(), Audio{Data: []byte("Hi Bob\n")})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if reply.Transcript != "Hi Bob" || reply.Text != "Ahoy" || string(reply.Audio.Data) != "spoken Ahoy" {
+		t.Errorf("Reply = %+v", reply)
+	}
+
+	if _, err := session.Send(context.Background(), Audio{Data: []byte(" ")}); !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("Send() of silence error = %v, want ErrNoSpeech", err)
+	}
+}
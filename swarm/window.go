@@ -0,0 +1,140 @@
+package swarm
+
+import (
+	"context"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ContextPolicy selects the part of the conversation an agent sees, e.g. to
+// stay within the model's context window. The swarm applies an agent's
+// policy (Agent.ContextPolicy) before every run; the shared history itself
+// is not shortened.
+type ContextPolicy interface {
+	Apply(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error)
+}
+
+// ContextPolicyFunc adapts a function to the ContextPolicy interface.
+type ContextPolicyFunc func(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error)
+
+// Apply calls f.
+func (f ContextPolicyFunc) Apply(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	return f(ctx, messages)
+}
+
+// MessageWindow is a ContextPolicy that keeps the most recent messages
+// within a message or token budget.
+//
+// System messages and the last user turn (the last human message and
+// everything after it) are always kept, even if they exceed the budget.
+// Older messages are dropped oldest first, and tool results are never kept
+// without the AI message that requested them.
+//
+// Example:
+//
+//	swarm.Agent{
+//	    Name:          "Alice",
+//	    Runnable:      alice,
+//	    ContextPolicy: swarm.MessageWindow{MaxTokens: 8000},
+//	}
+type MessageWindow struct {
+	// MaxMessages is the maximum number of messages kept (0: no limit)
+	MaxMessages int
+	// MaxTokens is the maximum number of tokens kept (0: no limit)
+	MaxTokens int
+	// CountTokens counts the tokens of a message. The default estimates
+	// one token per four characters of text and tool call arguments.
+	CountTokens func(msg llms.MessageContent) int
+}
+
+// Apply returns the messages that fit in the window, in their original order.
+func (w MessageWindow) Apply(ctx context.Context, messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	countTokens := w.CountTokens
+	if countTokens == nil {
+		countTokens = estimateTokens
+	}
+
+	lastTurn := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == llms.ChatMessageTypeHuman {
+			lastTurn = i
+			break
+		}
+	}
+
+	// Count what is always kept
+	count, tokens := 0, 0
+	for i, msg := range messages {
+		if i >= lastTurn || msg.Role == llms.ChatMessageTypeSystem {
+			count++
+			tokens += countTokens(msg)
+		}
+	}
+
+	// Add earlier messages, newest first, while they fit
+	start := lastTurn
+	for i := lastTurn - 1; i >= 0; i-- {
+		if messages[i].Role == llms.ChatMessageTypeSystem {
+			continue
+		}
+		n := countTokens(messages[i])
+		if (w.MaxMessages > 0 && count+1 > w.MaxMessages) || (w.MaxTokens > 0 && tokens+n > w.MaxTokens) {
+			break
+		}
+		count++
+		tokens += n
+		start = i
+	}
+	for start < lastTurn && messages[start].Role == llms.ChatMessageTypeTool {
+		start++
+	}
+
+	kept := make([]llms.MessageContent, 0, count)
+	for i, msg := range messages {
+		if i >= start || msg.Role == llms.ChatMessageTypeSystem {
+			kept = append(kept, msg)
+		}
+	}
+	return kept, nil
+}
+
+// estimateTokens approximates the token count of a message, counting
+// imageTokenEstimate tokens per image or binary part.
+func estimateTokens(msg llms.MessageContent) int {
+	chars, images := 0, 0
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			chars += utf8.RuneCountInString(p.Text)
+		case llms.ToolCall:
+			if p.FunctionCall != nil {
+				chars += utf8.RuneCountInString(p.FunctionCall.Name) + utf8.RuneCountInString(p.FunctionCall.Arguments)
+			}
+		case llms.ToolCallResponse:
+			chars += utf8.RuneCountInString(p.Content)
+		case llms.ImageURLContent, llms.BinaryContent:
+			images++
+		}
+	}
+	return chars/4 + 1 + images*imageTokenEstimate
+}
+
+// restoreHistory reinserts the messages a ContextPolicy left out. If the
+// agent echoed back the window it was given, the window is replaced by the
+// full history; otherwise the agent's messages are returned unchanged for
+// the reducer to merge.
+func restoreHistory(history, window, messages []llms.MessageContent) []llms.MessageContent {
+	if len(messages) < len(window) {
+		return messages
+	}
+	for i := range window {
+		if !reflect.DeepEqual(messages[i], window[i]) {
+			return messages
+		}
+	}
+	restored := make([]llms.MessageContent, 0, len(history)+len(messages)-len(window))
+	restored = append(restored, history...)
+	return append(restored, messages[len(window):]...)
+}
@@ -0,0 +1,86 @@
+package swarm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestMessageWindow(t *testing.T) {
+	system := llms.TextParts(llms.ChatMessageTypeSystem, "You are helpful.")
+	q1 := llms.TextParts(llms.ChatMessageTypeHuman, "first question")
+	a1 := llms.TextParts(llms.ChatMessageTypeAI, "first answer")
+	call := aiToolCalls(llms.ToolCall{ID: "call_1", FunctionCall: &llms.FunctionCall{Name: "upper", Arguments: `{}`}})
+	result := llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+		llms.ToolCallResponse{ToolCallID: "call_1", Name: "upper", Content: "DONE"},
+	}}
+	a2 := llms.TextParts(llms.ChatMessageTypeAI, "second answer")
+	q3 := llms.TextParts(llms.ChatMessageTypeHuman, "third question")
+	history := []llms.MessageContent{system, q1, a1, call, result, a2, q3}
+
+	// Every message counts as one token
+	oneToken := func(llms.MessageContent) int { return 1 }
+
+	tests := []struct {
+		name   string
+		window MessageWindow
+		want   []llms.MessageContent
+	}{
+		{name: "no limit", window: MessageWindow{}, want: history},
+		// Keeping 4 messages would start with an orphaned tool result, so it is dropped too
+		{name: "max messages", window: MessageWindow{MaxMessages: 4}, want: []llms.MessageContent{system, a2, q3}},
+		{name: "max tokens", window: MessageWindow{MaxTokens: 5, CountTokens: oneToken}, want: []llms.MessageContent{system, call, result, a2, q3}},
+		{name: "keeps system and last turn", window: MessageWindow{MaxMessages: 1}, want: []llms.MessageContent{system, q3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.window.Apply(context.Background(), history)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() kept %d messages %v, want %d", len(got), got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestAgentContextPolicy(t *testing.T) {
+	var seen []llms.MessageContent
+	app := compileSwarm(t, SwarmConfig{
+		Agents: []Agent{{
+			Name:          "Alice",
+			Runnable:      promptRecorder(&seen),
+			SystemPrompt:  "You are Alice.",
+			ContextPolicy: MessageWindow{MaxMessages: 2},
+		}},
+		DefaultActiveAgent: "Alice",
+	})
+
+	state := SwarmState{Messages: []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "one"),
+		llms.TextParts(llms.ChatMessageTypeAI, "two"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "three"),
+		llms.TextParts(llms.ChatMessageTypeAI, "four"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "five"),
+	}}
+	result, err := app.Invoke(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	// The system prompt plus a window of two messages
+	if len(seen) != 3 || seen[0].Role != llms.ChatMessageTypeSystem {
+		t.Fatalf("Agent saw %d messages: %v", len(seen), seen)
+	}
+	if text := seen[1].Parts[0].(llms.TextContent).Text; text != "four" {
+		t.Errorf("Window starts with %q, want four", text)
+	}
+
+	if len(result.Messages) != 6 {
+		t.Errorf("Shared history has %d messages, want the full 5 plus the answer", len(result.Messages))
+	}
+}